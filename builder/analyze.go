@@ -0,0 +1,134 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1execute "github.com/alexellis/go-execute/pkg/v1"
+)
+
+// ImageLayer is a layer of an image as reported by docker history
+type ImageLayer struct {
+	CreatedBy string
+	Size      int64
+}
+
+// ImageAnalysis gives the size of an image and where it comes from
+type ImageAnalysis struct {
+	Image  string
+	Size   int64
+	Layers []ImageLayer
+
+	// HandlerSize is the size of the layers which copy or build the function's
+	// handler, the rest of the image comes from the template and its base image
+	HandlerSize  int64
+	TemplateSize int64
+}
+
+// LargestLayers returns up to n layers ordered by size, the largest first
+func (a ImageAnalysis) LargestLayers(n int) []ImageLayer {
+	layers := make([]ImageLayer, len(a.Layers))
+	copy(layers, a.Layers)
+
+	sort.SliceStable(layers, func(i, j int) bool {
+		return layers[i].Size > layers[j].Size
+	})
+
+	if len(layers) > n {
+		layers = layers[:n]
+	}
+	return layers
+}
+
+// AnalyzeImage inspects a built image with docker image inspect and docker history,
+// layers which mention the handler folder are attributed to the handler
+func AnalyzeImage(image, handlerFolder string) (*ImageAnalysis, error) {
+	inspect, err := runDocker("image", "inspect", "--format", "{{.Size}}", image)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := strconv.ParseInt(strings.TrimSpace(inspect), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the size of %s: %s", image, err)
+	}
+
+	history, err := runDocker("history", "--no-trunc", "--human=false", "--format", "{{.Size}}\t{{.CreatedBy}}", image)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis, err := parseImageHistory(history, handlerFolder)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the history of %s: %s", image, err)
+	}
+
+	analysis.Image = image
+	analysis.Size = size
+	return analysis, nil
+}
+
+func parseImageHistory(history, handlerFolder string) (*ImageAnalysis, error) {
+	if len(handlerFolder) == 0 {
+		handlerFolder = defaultHandlerFolder
+	}
+
+	analysis := &ImageAnalysis{}
+	for _, line := range strings.Split(strings.TrimSpace(history), "\n") {
+		if len(line) == 0 {
+			continue
+		}
+
+		parts := strings.SplitN(line, "\t", 2)
+		size, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		layer := ImageLayer{Size: size}
+		if len(parts) == 2 {
+			layer.CreatedBy = strings.TrimSpace(parts[1])
+		}
+
+		if size == 0 {
+			continue
+		}
+
+		if strings.Contains(layer.CreatedBy, handlerFolder) {
+			analysis.HandlerSize += size
+		} else {
+			analysis.TemplateSize += size
+		}
+		analysis.Layers = append(analysis.Layers, layer)
+	}
+
+	return analysis, nil
+}
+
+func runDocker(args ...string) (string, error) {
+	command, engineArgs, err := EngineCommand(args...)
+	if err != nil {
+		return "", err
+	}
+
+	task := v1execute.ExecTask{
+		Command: command,
+		Args:    engineArgs,
+	}
+
+	res, err := task.Execute()
+	if err != nil {
+		return "", err
+	}
+
+	if res.ExitCode != 0 {
+		return "", fmt.Errorf("%s %s failed: %s", command, args[0], strings.TrimSpace(res.Stderr))
+	}
+
+	return res.Stdout, nil
+}
@@ -0,0 +1,36 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"testing"
+)
+
+func Test_parseImageHistory(t *testing.T) {
+	history := "1200\tCOPY function/ . # buildkit\n" +
+		"0\tUSER app\n" +
+		"45000000\t/bin/sh -c pip install -r requirements.txt\n" +
+		"300\t/bin/sh -c #(nop) COPY dir:abc in /home/app/function\n" +
+		"5600000\t/bin/sh -c #(nop) ADD file:abc in /\n"
+
+	analysis, err := parseImageHistory(history, "")
+	if err != nil {
+		t.Fatalf("want no error, but got: %s", err)
+	}
+
+	if analysis.HandlerSize != 1500 {
+		t.Errorf("want handler size 1500, but got: %d", analysis.HandlerSize)
+	}
+	if analysis.TemplateSize != 50600000 {
+		t.Errorf("want template size 50600000, but got: %d", analysis.TemplateSize)
+	}
+	if len(analysis.Layers) != 4 {
+		t.Errorf("want empty layers to be skipped, but got: %d layers", len(analysis.Layers))
+	}
+
+	largest := analysis.LargestLayers(2)
+	if len(largest) != 2 || largest[0].Size != 45000000 || largest[1].Size != 5600000 {
+		t.Errorf("want the two largest layers in order, but got: %v", largest)
+	}
+}
@@ -4,15 +4,17 @@
 package builder
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"log"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
 
 	v1execute "github.com/alexellis/go-execute/pkg/v1"
+	"github.com/openfaas/faas-cli/output"
 	"github.com/openfaas/faas-cli/schema"
 	"github.com/openfaas/faas-cli/stack"
 	vcs "github.com/openfaas/faas-cli/versioncontrol"
@@ -22,11 +24,21 @@ import (
 // Can also be passed as a build arg hence needs to be accessed from commands
 const AdditionalPackageBuildArg = "ADDITIONAL_PACKAGE"
 
-// BuildImage construct Docker image from function parameters
+// KeepBuildDir keeps the build folder of each function after it is built,
+// they are removed by default
+var KeepBuildDir bool
+
+// BuildImage construct Docker image from function parameters, the build is
+// stopped and its build folder removed when ctx is cancelled
 // TODO: refactor signature to a struct to simplify the length of the method header
-func BuildImage(image string, handler string, functionName string, language string, nocache bool, squash bool, shrinkwrap bool, buildArgMap map[string]string, buildOptions []string, tagMode schema.BuildFormat, buildLabelMap map[string]string, quietBuild bool, copyExtraPaths []string) error {
+func BuildImage(ctx context.Context, image string, handler string, functionName string, language string, nocache bool, squash bool, shrinkwrap bool, buildArgMap map[string]string, buildOptions []string, tagMode schema.BuildFormat, buildLabelMap map[string]string, quietBuild bool, copyExtraPaths []string, cacheFrom []string, cacheTo []string) error {
+	if ctx.Err() != nil {
+		return ErrBuildCancelled
+	}
 
 	if stack.IsValidTemplate(language) {
+		language, languageVersion := stack.SplitLanguage(language)
+
 		pathToTemplateYAML := fmt.Sprintf("./template/%s/template.yml", language)
 		if _, err := os.Stat(pathToTemplateYAML); os.IsNotExist(err) {
 			return err
@@ -37,6 +49,12 @@ func BuildImage(image string, handler string, functionName string, language stri
 			return fmt.Errorf("error reading language template: %s", err.Error())
 		}
 
+		templateVersion, err := langTemplate.ResolveVersion(language, languageVersion)
+		if err != nil {
+			return fmt.Errorf("[%s] %s", functionName, err)
+		}
+		buildArgMap = templateVersionBuildArgs(templateVersion, buildArgMap)
+
 		branch, version, err := GetImageTagValues(tagMode)
 		if err != nil {
 			return err
@@ -48,14 +66,29 @@ func BuildImage(image string, handler string, functionName string, language stri
 			return fmt.Errorf("building %s, %s is an invalid path", imageName, handler)
 		}
 
-		tempPath, buildErr := createBuildContext(functionName, handler, language, isLanguageTemplate(language), langTemplate.HandlerFolder, copyExtraPaths)
-		fmt.Printf("Building: %s with %s template. Please wait..\n", imageName, language)
+		if err := checkHandlerEntrypoint(functionName, handler, language, langTemplate); err != nil {
+			return err
+		}
+
+		out := output.Progress(quietBuild)
+		tempPath, buildErr := createBuildContext(functionName, handler, language, isLanguageTemplate(language), langTemplate.HandlerFolder, copyExtraPaths, shrinkwrap, out)
+		defer removeBuildDir(tempPath, shrinkwrap, out)
+		fmt.Fprintf(out, "Building: %s with %s template. Please wait..\n", imageName, language)
 		if buildErr != nil {
 			return buildErr
 		}
 
+		if err := useTemplateVersionDockerfile(tempPath, language, templateVersion); err != nil {
+			return err
+		}
+
+		if err := prepareReproducibleContext(functionName, tempPath); err != nil {
+			return err
+		}
+		buildArgMap = reproducibleBuildArgs(buildArgMap)
+
 		if shrinkwrap {
-			fmt.Printf("%s shrink-wrapped to %s\n", functionName, tempPath)
+			fmt.Fprintf(out, "%s shrink-wrapped to %s\n", functionName, tempPath)
 			return nil
 		}
 
@@ -66,6 +99,34 @@ func BuildImage(image string, handler string, functionName string, language stri
 
 		}
 
+		if len(RemoteBuilder) > 0 {
+			buildLog, logPath, err := OpenLog(functionName, "build")
+			if err != nil {
+				return fmt.Errorf("[%s] %s", functionName, err)
+			}
+			remoteLog := out
+			if buildLog != nil {
+				defer buildLog.Close()
+				remoteLog = buildLog
+			}
+
+			config := remoteBuildConfig{Image: imageName, BuildArgs: remoteBuildArgs(buildArgMap, buildOptPackages)}
+			if err := remoteBuild(ctx, RemoteBuilder, PayloadSecret, tempPath, config, remoteLog); err != nil {
+				if ctx.Err() != nil {
+					return fmt.Errorf("[%s] %s", functionName, ErrBuildCancelled)
+				}
+				if len(logPath) > 0 {
+					return fmt.Errorf("[%s] %s, see the log: %s", functionName, err, logPath)
+				}
+				return fmt.Errorf("[%s] %s", functionName, err)
+			}
+			fmt.Fprintln(out, output.Success("Image: %s built and pushed by %s.", imageName, RemoteBuilder))
+			if len(logPath) > 0 {
+				fmt.Fprintf(out, "Build log: %s\n", logPath)
+			}
+			return nil
+		}
+
 		dockerBuildVal := dockerBuild{
 			Image:            imageName,
 			NoCache:          nocache,
@@ -75,9 +136,15 @@ func BuildImage(image string, handler string, functionName string, language stri
 			BuildArgMap:      buildArgMap,
 			BuildOptPackages: buildOptPackages,
 			BuildLabelMap:    buildLabelMap,
+			CacheFrom:        cacheFrom,
+			CacheTo:          cacheTo,
 		}
 
-		command, args := getDockerBuildCommand(dockerBuildVal)
+		_, dockerArgs := getDockerBuildCommand(dockerBuildVal)
+		command, args, err := EngineCommand(dockerArgs...)
+		if err != nil {
+			return err
+		}
 
 		task := v1execute.ExecTask{
 			Cwd:         tempPath,
@@ -86,17 +153,33 @@ func BuildImage(image string, handler string, functionName string, language stri
 			StreamStdio: !quietBuild,
 		}
 
-		res, err := task.Execute()
+		buildLog, logPath, err := OpenLog(functionName, "build")
+		if err != nil {
+			return fmt.Errorf("[%s] %s", functionName, err)
+		}
+		if buildLog != nil {
+			defer buildLog.Close()
+		}
+
+		res, err := executeContext(ctx, task, buildLog)
+		if err == ErrBuildCancelled {
+			// The partial build folder is removed even with KeepBuildDir
+			os.RemoveAll(tempPath)
+			return fmt.Errorf("[%s] %s", functionName, err)
+		}
 
 		if err != nil {
 			return err
 		}
 
 		if res.ExitCode != 0 {
-			return fmt.Errorf("[%s] received non-zero exit code from build, error: %s", functionName, res.Stderr)
+			return nonZeroExitError(functionName, "build", res.Stderr, logPath)
 		}
 
-		fmt.Printf("Image: %s built.\n", imageName)
+		fmt.Fprintln(out, output.Success("Image: %s built.", imageName))
+		if len(logPath) > 0 {
+			fmt.Fprintf(out, "Build log: %s\n", logPath)
+		}
 
 	} else {
 		return fmt.Errorf("language template: %s not supported, build a custom Dockerfile", language)
@@ -142,7 +225,15 @@ func GetImageTagValues(tagType schema.BuildFormat) (branch, version string, err
 func getDockerBuildCommand(build dockerBuild) (string, []string) {
 	flagSlice := buildFlagSlice(build.NoCache, build.Squash, build.HTTPProxy, build.HTTPSProxy, build.BuildArgMap, build.BuildOptPackages, build.BuildLabelMap)
 	args := []string{"build"}
+
+	// Exporting the cache is only supported by buildx, --load keeps the image
+	// available in the local library as with docker build
+	if len(build.CacheTo) > 0 {
+		args = []string{"buildx", "build", "--load"}
+	}
+
 	args = append(args, flagSlice...)
+	args = append(args, cacheFlagSlice(build.CacheFrom, build.CacheTo)...)
 
 	args = append(args, "--tag", build.Image, ".")
 
@@ -167,6 +258,13 @@ type dockerBuild struct {
 
 	// ExtraTags for published images like :latest
 	ExtraTags []string
+
+	// CacheFrom and CacheTo are passed to --cache-from and --cache-to
+	CacheFrom []string
+	CacheTo   []string
+
+	// MetadataFile is where buildx writes the digest of the image it pushed
+	MetadataFile string
 }
 
 var defaultDirPermissions os.FileMode = 0700
@@ -183,15 +281,59 @@ func isRunningInCI() bool {
 	return false
 }
 
-// createBuildContext creates temporary build folder to perform a Docker build with language template
-func createBuildContext(functionName string, handler string, language string, useFunction bool, handlerFolder string, copyExtraPaths []string) (string, error) {
-	tempPath := fmt.Sprintf("./build/%s/", functionName)
-	fmt.Printf("Clearing temporary build folder: %s\n", tempPath)
+// buildDir returns the build folder of a function. A shrink-wrapped function
+// is written to ./build/<function>/, otherwise each build has a folder of its
+// own, so that parallel builds of functions never share one.
+func buildDir(functionName string, shrinkwrap bool, out io.Writer) (string, error) {
+	if isRunningInCI() {
+		defaultDirPermissions = 0777
+	}
+
+	if shrinkwrap {
+		tempPath := fmt.Sprintf("./build/%s/", functionName)
+		fmt.Fprintf(out, "Clearing temporary build folder: %s\n", tempPath)
+
+		if err := os.RemoveAll(tempPath); err != nil {
+			fmt.Fprintf(out, "Error clearing temporary build folder: %s\n", tempPath)
+			return tempPath, err
+		}
+		return tempPath, nil
+	}
+
+	if err := os.MkdirAll("./build", defaultDirPermissions); err != nil {
+		return "", err
+	}
+
+	tempPath, err := ioutil.TempDir("./build", functionName+"-")
+	if err != nil {
+		return "", fmt.Errorf("unable to create a build folder for %s: %s", functionName, err)
+	}
+	if err := os.Chmod(tempPath, defaultDirPermissions); err != nil {
+		return tempPath, err
+	}
+	return tempPath, nil
+}
+
+// removeBuildDir removes the build folder of a function once it has been
+// built, unless it was shrink-wrapped or KeepBuildDir is set
+func removeBuildDir(tempPath string, shrinkwrap bool, out io.Writer) {
+	if shrinkwrap || len(tempPath) == 0 {
+		return
+	}
+
+	if KeepBuildDir {
+		fmt.Fprintf(out, "Build folder kept: %s\n", tempPath)
+		return
+	}
+	os.RemoveAll(tempPath)
+}
 
-	clearErr := os.RemoveAll(tempPath)
-	if clearErr != nil {
-		fmt.Printf("Error clearing temporary build folder: %s\n", tempPath)
-		return tempPath, clearErr
+// createBuildContext creates temporary build folder to perform a Docker build with language template,
+// its progress is written to out
+func createBuildContext(functionName string, handler string, language string, useFunction bool, handlerFolder string, copyExtraPaths []string, shrinkwrap bool, out io.Writer) (string, error) {
+	tempPath, err := buildDir(functionName, shrinkwrap, out)
+	if err != nil {
+		return tempPath, err
 	}
 
 	functionPath := tempPath
@@ -204,22 +346,18 @@ func createBuildContext(functionName string, handler string, language string, us
 		}
 	}
 
-	fmt.Printf("Preparing: %s %s\n", handler+"/", functionPath)
-
-	if isRunningInCI() {
-		defaultDirPermissions = 0777
-	}
+	fmt.Fprintf(out, "Preparing: %s %s\n", handler+"/", functionPath)
 
 	mkdirErr := os.MkdirAll(functionPath, defaultDirPermissions)
 	if mkdirErr != nil {
-		fmt.Printf("Error creating path: %s - %s.\n", functionPath, mkdirErr.Error())
+		fmt.Fprintf(out, "Error creating path: %s - %s.\n", functionPath, mkdirErr.Error())
 		return tempPath, mkdirErr
 	}
 
 	if useFunction {
 		copyErr := CopyFiles(path.Join("./template/", language), tempPath)
 		if copyErr != nil {
-			fmt.Printf("Error copying template directory: %s.\n", copyErr.Error())
+			fmt.Fprintf(out, "Error copying template directory: %s.\n", copyErr.Error())
 			return tempPath, copyErr
 		}
 	}
@@ -228,14 +366,14 @@ func createBuildContext(functionName string, handler string, language string, us
 	// CopyFiles(handler, functionPath)
 	infos, readErr := ioutil.ReadDir(handler)
 	if readErr != nil {
-		fmt.Printf("Error reading the handler: %s - %s.\n", handler, readErr.Error())
+		fmt.Fprintf(out, "Error reading the handler: %s - %s.\n", handler, readErr.Error())
 		return tempPath, readErr
 	}
 
 	for _, info := range infos {
 		switch info.Name() {
 		case "build", "template":
-			fmt.Printf("Skipping \"%s\" folder\n", info.Name())
+			fmt.Fprintf(out, "Skipping \"%s\" folder\n", info.Name())
 			continue
 		default:
 			copyErr := CopyFiles(
@@ -295,49 +433,6 @@ func pathInScope(path string, scope string) (string, error) {
 	return "", fmt.Errorf("forbidden path appears to be outside of the build context: %s (%s)", path, abs)
 }
 
-// appears to be unused???
-func dockerBuildFolder(functionName string, handler string, language string) string {
-	tempPath := fmt.Sprintf("./build/%s/", functionName)
-	fmt.Printf("Clearing temporary build folder: %s\n", tempPath)
-
-	clearErr := os.RemoveAll(tempPath)
-	if clearErr != nil {
-		fmt.Printf("Error clearing temporary build folder: %s\n", tempPath)
-	}
-
-	fmt.Printf("Preparing: %s %s\n", handler+"/", tempPath)
-
-	// Both Dockerfile and dockerfile are accepted
-	if language == "Dockerfile" {
-		language = "dockerfile"
-	}
-
-	// CopyFiles(handler, tempPath)
-	infos, readErr := ioutil.ReadDir(handler)
-	if readErr != nil {
-		fmt.Printf("Error reading the handler: %s - %s.\n", handler, readErr.Error())
-	}
-
-	for _, info := range infos {
-		switch info.Name() {
-		case "build", "template":
-			fmt.Printf("Skipping \"%s\" folder\n", info.Name())
-			continue
-		default:
-			copyErr := CopyFiles(
-				filepath.Clean(path.Join(handler, info.Name())),
-				filepath.Clean(path.Join(tempPath, info.Name())),
-			)
-
-			if copyErr != nil {
-				log.Fatal(copyErr)
-			}
-		}
-	}
-
-	return tempPath
-}
-
 func buildFlagSlice(nocache bool, squash bool, httpProxy string, httpsProxy string, buildArgMap map[string]string, buildOptionPackages []string, buildLabelMap map[string]string) []string {
 
 	var spaceSafeBuildFlags []string
@@ -377,6 +472,19 @@ func buildFlagSlice(nocache bool, squash bool, httpProxy string, httpsProxy stri
 	return spaceSafeBuildFlags
 }
 
+func cacheFlagSlice(cacheFrom []string, cacheTo []string) []string {
+	var cacheFlags []string
+
+	for _, from := range cacheFrom {
+		cacheFlags = append(cacheFlags, "--cache-from", from)
+	}
+	for _, to := range cacheTo {
+		cacheFlags = append(cacheFlags, "--cache-to", to)
+	}
+
+	return cacheFlags
+}
+
 func ensureHandlerPath(handler string) error {
 	if _, err := os.Stat(handler); err != nil {
 		return err
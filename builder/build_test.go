@@ -2,6 +2,8 @@ package builder
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -140,6 +142,39 @@ func Test_getDockerBuildCommand_WithBuildArg(t *testing.T) {
 	}
 }
 
+func Test_getDockerBuildCommand_WithCacheFrom(t *testing.T) {
+	dockerBuildVal := dockerBuild{
+		Image:     "imagename:latest",
+		CacheFrom: []string{"type=registry,ref=user/imagename:cache"},
+	}
+
+	want := "build --cache-from type=registry,ref=user/imagename:cache --tag imagename:latest ."
+
+	_, args := getDockerBuildCommand(dockerBuildVal)
+
+	joined := strings.Join(args, " ")
+	if joined != want {
+		t.Errorf("getDockerBuildCommand want: \"%s\", got: \"%s\"", want, joined)
+	}
+}
+
+func Test_getDockerBuildCommand_WithCacheToUsesBuildx(t *testing.T) {
+	dockerBuildVal := dockerBuild{
+		Image:     "imagename:latest",
+		CacheFrom: []string{"type=registry,ref=user/imagename:cache"},
+		CacheTo:   []string{"type=registry,ref=user/imagename:cache,mode=max"},
+	}
+
+	want := "buildx build --load --cache-from type=registry,ref=user/imagename:cache --cache-to type=registry,ref=user/imagename:cache,mode=max --tag imagename:latest ."
+
+	_, args := getDockerBuildCommand(dockerBuildVal)
+
+	joined := strings.Join(args, " ")
+	if joined != want {
+		t.Errorf("getDockerBuildCommand want: \"%s\", got: \"%s\"", want, joined)
+	}
+}
+
 func Test_buildFlagSlice(t *testing.T) {
 
 	var buildFlagOpts = []struct {
@@ -536,3 +571,47 @@ func Test_pathInScope(t *testing.T) {
 		})
 	}
 }
+
+func Test_buildDir(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "faas-cli-build-dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	first, err := buildDir("fn", false, ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := buildDir("fn", false, ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Fatalf("want a folder for each build, got %s twice", first)
+	}
+	if !strings.HasPrefix(filepath.ToSlash(filepath.Clean(first)), "build/fn-") {
+		t.Fatalf("want a folder under ./build, got %s", first)
+	}
+
+	removeBuildDir(first, false, ioutil.Discard)
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Fatalf("want %s removed after the build", first)
+	}
+
+	shrinkwrapped, err := buildDir("fn", true, ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shrinkwrapped != "./build/fn/" {
+		t.Fatalf("want the shrink-wrapped function in ./build/fn/, got %s", shrinkwrapped)
+	}
+}
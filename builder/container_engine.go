@@ -0,0 +1,160 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"fmt"
+	osexec "os/exec"
+	"strings"
+)
+
+// Container engines which can build and push images
+const (
+	EngineDocker  = "docker"
+	EnginePodman  = "podman"
+	EngineNerdctl = "nerdctl"
+)
+
+// ContainerEngines are the engines which can be used, in the order they are
+// looked for in PATH
+var ContainerEngines = []string{EngineDocker, EnginePodman, EngineNerdctl}
+
+// ContainerEngine is the CLI which builds and pushes images, docker is used
+// when it is empty
+var ContainerEngine string
+
+// lookPath is a variable so that tests do not depend on what is installed
+var lookPath = osexec.LookPath
+
+// DetectContainerEngine checks that the engine given is installed, when no
+// engine is given it returns the first of ContainerEngines which is in PATH
+func DetectContainerEngine(name string) (string, error) {
+	if len(name) > 0 {
+		if !isContainerEngine(name) {
+			return "", fmt.Errorf("container engine %q is not supported, use one of: %s", name, strings.Join(ContainerEngines, ", "))
+		}
+		if _, err := lookPath(name); err != nil {
+			return "", fmt.Errorf("the container engine %s was not found in PATH", name)
+		}
+		return name, nil
+	}
+
+	for _, engine := range ContainerEngines {
+		if _, err := lookPath(engine); err == nil {
+			return engine, nil
+		}
+	}
+	return "", fmt.Errorf("no container engine was found in PATH, install one of: %s", strings.Join(ContainerEngines, ", "))
+}
+
+// Engine is the container engine which is in use
+func Engine() string {
+	if len(ContainerEngine) == 0 {
+		return EngineDocker
+	}
+	return ContainerEngine
+}
+
+func isContainerEngine(name string) bool {
+	for _, engine := range ContainerEngines {
+		if engine == name {
+			return true
+		}
+	}
+	return false
+}
+
+// EngineCommand maps the arguments of a docker command to the engine in use.
+// podman and nerdctl build with "build", as they do not have buildx, and
+// podman is given the image of a registry cache without its type.
+func EngineCommand(args ...string) (string, []string, error) {
+	engine := Engine()
+	if engine == EngineDocker {
+		return engine, DockerArgs(args...), nil
+	}
+
+	if len(DockerContext) > 0 {
+		return "", nil, fmt.Errorf("--docker-context can only be used with docker, not %s", engine)
+	}
+
+	if len(args) > 0 && args[0] == "buildx" {
+		if len(args) < 2 || args[1] != "build" || !containsArg(args, "--load") {
+			return "", nil, fmt.Errorf("docker buildx is needed to run: docker %s, it is not available with %s", strings.Join(args, " "), engine)
+		}
+		args = removeArg(args[1:], "--load")
+	}
+
+	if len(args) == 0 || args[0] != "build" {
+		return engine, args, nil
+	}
+
+	mapped := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--squash" && engine == EngineNerdctl:
+			return "", nil, fmt.Errorf("--squash is not supported by %s", engine)
+
+		case (arg == "--cache-from" || arg == "--cache-to") && engine == EnginePodman && i+1 < len(args):
+			ref, err := podmanCacheRef(args[i+1])
+			if err != nil {
+				return "", nil, err
+			}
+			mapped = append(mapped, arg, ref)
+			i++
+
+		default:
+			mapped = append(mapped, arg)
+		}
+	}
+	return engine, mapped, nil
+}
+
+// podmanCacheRef returns the image of a cache given in the syntax of buildx,
+// i.e. type=registry,ref=user/fn:cache, podman only caches to a registry
+func podmanCacheRef(cache string) (string, error) {
+	if !strings.Contains(cache, "=") {
+		return cache, nil
+	}
+
+	ref := ""
+	for _, option := range strings.Split(cache, ",") {
+		parts := strings.SplitN(option, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		switch parts[0] {
+		case "type":
+			if parts[1] != "registry" {
+				return "", fmt.Errorf("podman can only cache to a registry, not %s: %s", parts[1], cache)
+			}
+		case "ref":
+			ref = parts[1]
+		}
+	}
+
+	if len(ref) == 0 {
+		return "", fmt.Errorf("podman needs the image of the cache, i.e. type=registry,ref=user/fn:cache, got: %s", cache)
+	}
+	return ref, nil
+}
+
+func containsArg(args []string, arg string) bool {
+	for _, a := range args {
+		if a == arg {
+			return true
+		}
+	}
+	return false
+}
+
+func removeArg(args []string, arg string) []string {
+	kept := make([]string, 0, len(args))
+	for _, a := range args {
+		if a != arg {
+			kept = append(kept, a)
+		}
+	}
+	return kept
+}
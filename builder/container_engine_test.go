@@ -0,0 +1,104 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func Test_DetectContainerEngine(t *testing.T) {
+	defer func(look func(string) (string, error)) { lookPath = look }(lookPath)
+
+	cases := []struct {
+		name      string
+		engine    string
+		installed []string
+		want      string
+		wantErr   string
+	}{
+		{name: "docker first", installed: []string{"docker", "podman"}, want: "docker"},
+		{name: "podman without docker", installed: []string{"podman", "nerdctl"}, want: "podman"},
+		{name: "nerdctl only", installed: []string{"nerdctl"}, want: "nerdctl"},
+		{name: "engine given", engine: "nerdctl", installed: []string{"docker", "nerdctl"}, want: "nerdctl"},
+		{name: "engine given is not installed", engine: "podman", installed: []string{"docker"}, wantErr: "the container engine podman was not found in PATH"},
+		{name: "unknown engine", engine: "buildah", installed: []string{"buildah"}, wantErr: `container engine "buildah" is not supported, use one of: docker, podman, nerdctl`},
+		{name: "nothing installed", wantErr: "no container engine was found in PATH, install one of: docker, podman, nerdctl"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			lookPath = func(file string) (string, error) {
+				for _, installed := range tc.installed {
+					if installed == file {
+						return "/usr/bin/" + file, nil
+					}
+				}
+				return "", fmt.Errorf("%s: not found", file)
+			}
+
+			got, err := DetectContainerEngine(tc.engine)
+			if len(tc.wantErr) > 0 {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("want error %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Fatalf("want %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_EngineCommand(t *testing.T) {
+	defer func(engine, name string) { ContainerEngine, DockerContext = engine, name }(ContainerEngine, DockerContext)
+
+	buildxLoad := []string{"buildx", "build", "--load", "--squash", "--cache-from", "type=registry,ref=alexellis/fn:cache", "--cache-to", "type=registry,ref=alexellis/fn:cache,mode=max", "--tag", "alexellis/fn:latest", "."}
+
+	cases := []struct {
+		name        string
+		engine      string
+		context     string
+		args        []string
+		wantCommand string
+		wantArgs    []string
+		wantErr     string
+	}{
+		{name: "docker by default", args: []string{"push", "alexellis/fn"}, wantCommand: "docker", wantArgs: []string{"push", "alexellis/fn"}},
+		{name: "docker with a context", engine: "docker", context: "remote", args: []string{"push", "alexellis/fn"}, wantCommand: "docker", wantArgs: []string{"--context", "remote", "push", "alexellis/fn"}},
+		{name: "podman push", engine: "podman", args: []string{"push", "alexellis/fn"}, wantCommand: "podman", wantArgs: []string{"push", "alexellis/fn"}},
+		{name: "podman build with a cache", engine: "podman", args: buildxLoad, wantCommand: "podman", wantArgs: []string{"build", "--squash", "--cache-from", "alexellis/fn:cache", "--cache-to", "alexellis/fn:cache", "--tag", "alexellis/fn:latest", "."}},
+		{name: "podman local cache", engine: "podman", args: []string{"build", "--cache-from", "type=local,src=/tmp/cache", "."}, wantErr: "podman can only cache to a registry, not local: type=local,src=/tmp/cache"},
+		{name: "nerdctl build", engine: "nerdctl", args: []string{"build", "--no-cache", "--tag", "alexellis/fn:latest", "."}, wantCommand: "nerdctl", wantArgs: []string{"build", "--no-cache", "--tag", "alexellis/fn:latest", "."}},
+		{name: "nerdctl squash", engine: "nerdctl", args: buildxLoad, wantErr: "--squash is not supported by nerdctl"},
+		{name: "podman buildx publish", engine: "podman", args: []string{"buildx", "build", "--platform=linux/arm64", "."}, wantErr: "docker buildx is needed to run: docker buildx build --platform=linux/arm64 ., it is not available with podman"},
+		{name: "podman with a docker context", engine: "podman", context: "remote", args: []string{"push", "alexellis/fn"}, wantErr: "--docker-context can only be used with docker, not podman"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ContainerEngine = tc.engine
+			DockerContext = tc.context
+
+			command, args, err := EngineCommand(tc.args...)
+			if len(tc.wantErr) > 0 {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("want error %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if command != tc.wantCommand || !reflect.DeepEqual(args, tc.wantArgs) {
+				t.Fatalf("want %s %v, got %s %v", tc.wantCommand, tc.wantArgs, command, args)
+			}
+		})
+	}
+}
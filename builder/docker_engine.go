@@ -0,0 +1,176 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	osexec "os/exec"
+	"strings"
+	"time"
+)
+
+// DockerContext is passed to docker with --context when it is set, a context
+// given this way takes priority over DOCKER_HOST and DOCKER_CONTEXT
+var DockerContext string
+
+// dockerCheckTimeout is how long the daemon has to answer, a daemon reached
+// over ssh:// can take a few seconds
+const dockerCheckTimeout = 30 * time.Second
+
+// dockerHostSchemes are the schemes which docker accepts in DOCKER_HOST
+var dockerHostSchemes = []string{"unix", "tcp", "ssh", "npipe", "fd"}
+
+// runDockerOutput is a variable so that tests do not need a docker daemon
+var runDockerOutput = func(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := osexec.CommandContext(ctx, "docker", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("no answer after %s", dockerCheckTimeout)
+		}
+		if message := strings.TrimSpace(stderr.String()); len(message) > 0 {
+			return "", fmt.Errorf("%s", message)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// DockerArgs adds --context to the arguments of a docker command when a
+// context was chosen with DockerContext
+func DockerArgs(args ...string) []string {
+	if len(DockerContext) == 0 {
+		return args
+	}
+	return append([]string{"--context", DockerContext}, args...)
+}
+
+// DockerEngine is the daemon which docker commands are sent to
+type DockerEngine struct {
+	// Context is the name of the docker context, it is empty when DOCKER_HOST
+	// is used
+	Context string
+
+	// Host is the endpoint of the daemon, i.e. unix:///var/run/docker.sock or
+	// ssh://user@host
+	Host string
+
+	// Source is where the daemon was chosen
+	Source string
+
+	// ServerVersion is the version of the daemon
+	ServerVersion string
+}
+
+func (e DockerEngine) String() string {
+	description := engineHost(e)
+	if len(e.Host) > 0 && len(e.Context) > 0 {
+		description += fmt.Sprintf(" (context %s)", e.Context)
+	}
+	return fmt.Sprintf("%s from %s, version %s", description, e.Source, e.ServerVersion)
+}
+
+// CheckDockerEngine finds the daemon which docker uses, in the same order as
+// docker: DockerContext, DOCKER_HOST, DOCKER_CONTEXT and then the current
+// context. It checks that the daemon answers, so that a build does not fail
+// part way through or use a different engine than expected.
+func CheckDockerEngine(ctx context.Context) (DockerEngine, error) {
+	engine, err := findDockerEngine(ctx)
+	if err != nil {
+		return engine, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dockerCheckTimeout)
+	defer cancel()
+
+	version, err := runDockerOutput(ctx, DockerArgs("version", "--format", "{{.Server.Version}}")...)
+	if err != nil {
+		return engine, fmt.Errorf("unable to reach the Docker daemon at %s from %s: %s", engineHost(engine), engine.Source, err)
+	}
+
+	engine.ServerVersion = version
+	return engine, nil
+}
+
+func findDockerEngine(ctx context.Context) (DockerEngine, error) {
+	if len(DockerContext) > 0 {
+		return inspectDockerContext(ctx, DockerEngine{Context: DockerContext, Source: "--docker-context"})
+	}
+
+	if host := os.Getenv("DOCKER_HOST"); len(host) > 0 {
+		if err := validateDockerHost(host); err != nil {
+			return DockerEngine{}, err
+		}
+		return DockerEngine{Host: host, Source: "DOCKER_HOST"}, nil
+	}
+
+	if name := os.Getenv("DOCKER_CONTEXT"); len(name) > 0 {
+		return inspectDockerContext(ctx, DockerEngine{Context: name, Source: "DOCKER_CONTEXT"})
+	}
+
+	engine, err := inspectDockerContext(ctx, DockerEngine{Source: "the current docker context"})
+	if err != nil {
+		// Versions of docker before contexts were added use the default host
+		return DockerEngine{Source: "the default docker host"}, nil
+	}
+	return engine, nil
+}
+
+// inspectDockerContext fills in the name and host of a context, or of the
+// current context when the name is empty
+func inspectDockerContext(ctx context.Context, engine DockerEngine) (DockerEngine, error) {
+	args := []string{"context", "inspect", "--format", "{{.Name}} {{.Endpoints.docker.Host}}"}
+	if len(engine.Context) > 0 {
+		args = append(args, engine.Context)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dockerCheckTimeout)
+	defer cancel()
+
+	out, err := runDockerOutput(ctx, args...)
+	if err != nil {
+		if len(engine.Context) == 0 {
+			return engine, err
+		}
+		return engine, fmt.Errorf("the docker context %s given with %s was not found: %s", engine.Context, engine.Source, err)
+	}
+
+	fields := strings.Fields(out)
+	if len(fields) > 0 {
+		engine.Context = fields[0]
+	}
+	if len(fields) > 1 {
+		engine.Host = fields[1]
+	}
+	return engine, nil
+}
+
+func validateDockerHost(host string) error {
+	u, err := url.Parse(host)
+	if err == nil {
+		for _, scheme := range dockerHostSchemes {
+			if u.Scheme == scheme {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("DOCKER_HOST=%s is not valid, it must start with unix://, tcp://, ssh:// or npipe://", host)
+}
+
+func engineHost(engine DockerEngine) string {
+	if len(engine.Host) > 0 {
+		return engine.Host
+	}
+	if len(engine.Context) > 0 {
+		return "the context " + engine.Context
+	}
+	return "the default host"
+}
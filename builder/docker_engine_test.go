@@ -0,0 +1,141 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_DockerArgs(t *testing.T) {
+	defer func(name string) { DockerContext = name }(DockerContext)
+
+	DockerContext = ""
+	if got := DockerArgs("push", "alexellis/fn"); !reflect.DeepEqual(got, []string{"push", "alexellis/fn"}) {
+		t.Errorf("want the args unchanged without a context, got %v", got)
+	}
+
+	DockerContext = "remote"
+	want := []string{"--context", "remote", "push", "alexellis/fn"}
+	if got := DockerArgs("push", "alexellis/fn"); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func Test_CheckDockerEngine(t *testing.T) {
+	defer func(name string) { DockerContext = name }(DockerContext)
+	defer func(run func(context.Context, ...string) (string, error)) { runDockerOutput = run }(runDockerOutput)
+	defer restoreEnv("DOCKER_HOST")()
+	defer restoreEnv("DOCKER_CONTEXT")()
+
+	contexts := map[string]string{
+		"":       "default unix:///var/run/docker.sock",
+		"remote": "remote ssh://alex@build-server",
+		"colima": "colima unix:///home/alex/.colima/default/docker.sock",
+	}
+
+	cases := []struct {
+		name          string
+		flag          string
+		dockerHost    string
+		dockerContext string
+		noContexts    bool
+		unreachable   bool
+		want          DockerEngine
+		wantErr       string
+	}{
+		{name: "current context", want: DockerEngine{Context: "default", Host: "unix:///var/run/docker.sock", Source: "the current docker context", ServerVersion: "24.0.7"}},
+		{name: "DOCKER_HOST", dockerHost: "ssh://alex@build-server", dockerContext: "colima", want: DockerEngine{Host: "ssh://alex@build-server", Source: "DOCKER_HOST", ServerVersion: "24.0.7"}},
+		{name: "DOCKER_CONTEXT", dockerContext: "colima", want: DockerEngine{Context: "colima", Host: "unix:///home/alex/.colima/default/docker.sock", Source: "DOCKER_CONTEXT", ServerVersion: "24.0.7"}},
+		{name: "flag over DOCKER_HOST", flag: "remote", dockerHost: "tcp://127.0.0.1:2375", want: DockerEngine{Context: "remote", Host: "ssh://alex@build-server", Source: "--docker-context", ServerVersion: "24.0.7"}},
+		{name: "docker without contexts", noContexts: true, want: DockerEngine{Source: "the default docker host", ServerVersion: "24.0.7"}},
+		{name: "unknown context", flag: "missing", wantErr: "the docker context missing given with --docker-context was not found: context \"missing\" does not exist"},
+		{name: "invalid DOCKER_HOST", dockerHost: "build-server:2375", wantErr: "DOCKER_HOST=build-server:2375 is not valid, it must start with unix://, tcp://, ssh:// or npipe://"},
+		{name: "unreachable", dockerContext: "remote", unreachable: true, wantErr: "unable to reach the Docker daemon at ssh://alex@build-server from DOCKER_CONTEXT: Cannot connect to the Docker daemon"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			DockerContext = tc.flag
+			setEnv("DOCKER_HOST", tc.dockerHost)
+			setEnv("DOCKER_CONTEXT", tc.dockerContext)
+
+			runDockerOutput = func(ctx context.Context, args ...string) (string, error) {
+				if len(args) > 1 && args[0] == "--context" {
+					if args[1] != tc.flag {
+						return "", fmt.Errorf("want --context %s, got %v", tc.flag, args)
+					}
+					args = args[2:]
+				}
+
+				switch args[0] {
+				case "context":
+					if tc.noContexts {
+						return "", fmt.Errorf("docker: 'context' is not a docker command")
+					}
+					name := ""
+					if len(args) == 5 {
+						name = args[4]
+					}
+					if out, ok := contexts[name]; ok {
+						return out, nil
+					}
+					return "", fmt.Errorf("context %q does not exist", name)
+				case "version":
+					if tc.unreachable {
+						return "", fmt.Errorf("Cannot connect to the Docker daemon")
+					}
+					return "24.0.7", nil
+				}
+				return "", fmt.Errorf("unexpected command: %v", args)
+			}
+
+			got, err := CheckDockerEngine(context.Background())
+			if len(tc.wantErr) > 0 {
+				if err == nil || !strings.HasPrefix(err.Error(), tc.wantErr) {
+					t.Fatalf("want error %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Fatalf("want %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_DockerEngine_String(t *testing.T) {
+	engine := DockerEngine{Context: "remote", Host: "ssh://alex@build-server", Source: "DOCKER_CONTEXT", ServerVersion: "24.0.7"}
+	want := "ssh://alex@build-server (context remote) from DOCKER_CONTEXT, version 24.0.7"
+	if got := engine.String(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+// restoreEnv returns a func which puts back the value of an environment variable
+func restoreEnv(name string) func() {
+	value, ok := os.LookupEnv(name)
+	return func() {
+		if ok {
+			os.Setenv(name, value)
+		} else {
+			os.Unsetenv(name)
+		}
+	}
+}
+
+func setEnv(name, value string) {
+	if len(value) == 0 {
+		os.Unsetenv(name)
+		return
+	}
+	os.Setenv(name, value)
+}
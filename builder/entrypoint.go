@@ -0,0 +1,65 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+// defaultEntrypoints maps the prefix of a template's language to the file
+// which its Dockerfile copies from the handler folder
+var defaultEntrypoints = []struct {
+	prefix string
+	file   string
+}{
+	{prefix: "python", file: "handler.py"},
+	{prefix: "node", file: "handler.js"},
+	{prefix: "csharp", file: "FunctionHandler.cs"},
+	{prefix: "golang", file: "handler.go"},
+	{prefix: "go", file: "handler.go"},
+}
+
+// handlerEntrypoint returns the entry file expected by a template, the
+// template can declare it with entrypoint, otherwise it is inferred from
+// the language. An empty string means the entry file is not known.
+func handlerEntrypoint(language string, langTemplate *stack.LanguageTemplate) string {
+	if langTemplate != nil && len(langTemplate.Entrypoint) > 0 {
+		return langTemplate.Entrypoint
+	}
+
+	if langTemplate != nil && len(langTemplate.Language) > 0 {
+		language = langTemplate.Language
+	}
+	language = strings.ToLower(language)
+
+	for _, entrypoint := range defaultEntrypoints {
+		if strings.HasPrefix(language, entrypoint.prefix) {
+			return entrypoint.file
+		}
+	}
+	return ""
+}
+
+// checkHandlerEntrypoint fails fast when the entry file of the template is
+// missing from the handler, rather than part-way through the Dockerfile
+func checkHandlerEntrypoint(functionName, handler, language string, langTemplate *stack.LanguageTemplate) error {
+	entrypoint := handlerEntrypoint(language, langTemplate)
+	if len(entrypoint) == 0 {
+		return nil
+	}
+
+	entrypointPath := filepath.Join(handler, entrypoint)
+	if _, err := os.Stat(entrypointPath); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("[%s] the %s template expects %s, but it was not found", functionName, language, entrypointPath)
+		}
+		return fmt.Errorf("[%s] unable to check %s: %s", functionName, entrypointPath, err)
+	}
+	return nil
+}
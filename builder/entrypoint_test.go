@@ -0,0 +1,100 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+func Test_handlerEntrypoint(t *testing.T) {
+	cases := []struct {
+		name         string
+		language     string
+		langTemplate *stack.LanguageTemplate
+		want         string
+	}{
+		{name: "python", language: "python3-http", want: "handler.py"},
+		{name: "node", language: "node12", want: "handler.js"},
+		{name: "csharp", language: "csharp", want: "FunctionHandler.cs"},
+		{name: "go", language: "golang-middleware", want: "handler.go"},
+		{name: "dockerfile", language: "dockerfile", want: ""},
+		{name: "unknown", language: "java11", want: ""},
+		{
+			name:         "template language is used",
+			language:     "my-fork",
+			langTemplate: &stack.LanguageTemplate{Language: "python3"},
+			want:         "handler.py",
+		},
+		{
+			name:         "template declares the entrypoint",
+			language:     "python3",
+			langTemplate: &stack.LanguageTemplate{Language: "python3", Entrypoint: "index.py"},
+			want:         "index.py",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := handlerEntrypoint(tc.language, tc.langTemplate)
+			if got != tc.want {
+				t.Fatalf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_checkHandlerEntrypoint(t *testing.T) {
+	handler, err := ioutil.TempDir("", "faas-cli-entrypoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(handler)
+
+	err = checkHandlerEntrypoint("fn1", handler, "python3", nil)
+	if err == nil {
+		t.Fatal("want error for a missing entrypoint")
+	}
+	want := filepath.Join(handler, "handler.py")
+	if !strings.Contains(err.Error(), want) {
+		t.Fatalf("want the error to name %s, got: %s", want, err)
+	}
+
+	if err := ioutil.WriteFile(want, []byte("def handle(req):\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkHandlerEntrypoint("fn1", handler, "python3", nil); err != nil {
+		t.Fatalf("want no error, got: %s", err)
+	}
+}
+
+// Test_checkHandlerEntrypoint_csharpTemplate uses the layout of the handler
+// from the csharp template in openfaas/templates
+func Test_checkHandlerEntrypoint_csharpTemplate(t *testing.T) {
+	handler, err := ioutil.TempDir("", "faas-cli-entrypoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(handler)
+
+	files := map[string]string{
+		"FunctionHandler.cs": "namespace Function\n{\n    public class FunctionHandler\n    {\n    }\n}\n",
+		"Function.csproj":    "<Project Sdk=\"Microsoft.NET.Sdk\">\n</Project>\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(handler, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	langTemplate := &stack.LanguageTemplate{Language: "csharp", FProcess: "dotnet ./root.dll"}
+	if err := checkHandlerEntrypoint("fn1", handler, "csharp", langTemplate); err != nil {
+		t.Fatalf("want no error, got: %s", err)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	v1execute "github.com/alexellis/go-execute/pkg/v1"
+)
+
+// ErrBuildCancelled is returned by builds which were stopped, i.e. on Ctrl+C
+var ErrBuildCancelled = errors.New("build was cancelled")
+
+// cancelGracePeriod is how long the container engine has to stop a build
+// after it is interrupted, before it is killed
+var cancelGracePeriod = 10 * time.Second
+
+// executeContext runs a task like v1execute.ExecTask.Execute, but interrupts
+// the process when the context is cancelled, so that the container engine
+// stops the build rather than leaving it running after the CLI exits. When
+// log is not nil, the output is written to it instead of being streamed.
+func executeContext(ctx context.Context, task v1execute.ExecTask, log io.Writer) (v1execute.ExecResult, error) {
+	if err := ctx.Err(); err != nil {
+		return v1execute.ExecResult{}, ErrBuildCancelled
+	}
+
+	cmd := exec.Command(task.Command, task.Args...)
+	cmd.Dir = task.Cwd
+
+	stdout := bytes.Buffer{}
+	stderr := bytes.Buffer{}
+	if log != nil {
+		cmd.Stdout = io.MultiWriter(log, &stdout)
+		cmd.Stderr = io.MultiWriter(log, &stderr)
+	} else if task.StreamStdio {
+		cmd.Stdout = io.MultiWriter(os.Stdout, &stdout)
+		cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	} else {
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+	}
+
+	if err := cmd.Start(); err != nil {
+		return v1execute.ExecResult{}, err
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			// The interrupt is not supported on Windows, so it is killed
+			// straight away
+			if err := cmd.Process.Signal(os.Interrupt); err != nil {
+				cmd.Process.Kill()
+				return
+			}
+			select {
+			case <-exited:
+			case <-time.After(cancelGracePeriod):
+				cmd.Process.Kill()
+			}
+		case <-exited:
+		}
+	}()
+
+	waitErr := cmd.Wait()
+	close(exited)
+
+	if ctx.Err() != nil {
+		return v1execute.ExecResult{}, ErrBuildCancelled
+	}
+
+	exitCode := 0
+	if exitErr, ok := waitErr.(*exec.ExitError); ok {
+		exitCode = exitErr.ExitCode()
+	}
+
+	return v1execute.ExecResult{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		ExitCode: exitCode,
+	}, nil
+}
@@ -0,0 +1,52 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1execute "github.com/alexellis/go-execute/pkg/v1"
+)
+
+func Test_executeContext_ReturnsOutput(t *testing.T) {
+	task := v1execute.ExecTask{Command: "sh", Args: []string{"-c", "echo built; exit 3"}}
+
+	res, err := executeContext(context.Background(), task, nil)
+	if err != nil {
+		t.Fatalf("want no error, got: %s", err)
+	}
+	if strings.TrimSpace(res.Stdout) != "built" {
+		t.Fatalf("want stdout built, got: %q", res.Stdout)
+	}
+	if res.ExitCode != 3 {
+		t.Fatalf("want exit code 3, got: %d", res.ExitCode)
+	}
+}
+
+func Test_executeContext_StopsTheProcessWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := executeContext(ctx, v1execute.ExecTask{Command: "sleep", Args: []string{"30"}}, nil)
+	if err != ErrBuildCancelled {
+		t.Fatalf("want ErrBuildCancelled, got: %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatalf("the process was not stopped when the context was cancelled")
+	}
+}
+
+func Test_executeContext_DoesNotStartWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := executeContext(ctx, v1execute.ExecTask{Command: "sh", Args: []string{"-c", "exit 0"}}, nil)
+	if err != ErrBuildCancelled {
+		t.Fatalf("want ErrBuildCancelled, got: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// LogDir is a folder for the output of the container engine, each build and
+// push of a function writes to a file of its own in it rather than to the
+// console. When it is empty the output is printed.
+var LogDir string
+
+// OpenLog creates the log file of a function for a phase, i.e. build or
+// push, a nil writer is returned when LogDir is not set
+func OpenLog(functionName, phase string) (io.WriteCloser, string, error) {
+	if len(LogDir) == 0 {
+		return nil, "", nil
+	}
+
+	if err := os.MkdirAll(LogDir, 0700); err != nil {
+		return nil, "", fmt.Errorf("unable to create the log folder: %s", err)
+	}
+
+	logPath := filepath.Join(LogDir, fmt.Sprintf("%s.%s.log", functionName, phase))
+	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to create the log file: %s", err)
+	}
+	return file, logPath, nil
+}
+
+// nonZeroExitError reports a failed build or push, the output is left out
+// when it was written to a log file, so that the summary stays short
+func nonZeroExitError(functionName, phase, stderr, logPath string) error {
+	if len(logPath) > 0 {
+		return fmt.Errorf("[%s] received non-zero exit code from %s, see the log: %s", functionName, phase, logPath)
+	}
+	return fmt.Errorf("[%s] received non-zero exit code from %s, error: %s", functionName, phase, stderr)
+}
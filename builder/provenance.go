@@ -0,0 +1,96 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"net/url"
+	"os"
+	"strings"
+
+	vcs "github.com/openfaas/faas-cli/versioncontrol"
+)
+
+// Annotations which record where a function was deployed from
+const (
+	GitSHAAnnotation     = "com.openfaas.git-sha"
+	GitBranchAnnotation  = "com.openfaas.git-branch"
+	GitRepoAnnotation    = "com.openfaas.git-repo"
+	CIBuildURLAnnotation = "com.openfaas.ci-build-url"
+)
+
+// Variables so that tests do not depend on the repository they run in
+var (
+	gitSHA       = vcs.GetGitFullSHA
+	gitBranch    = vcs.GetGitBranch
+	gitRemoteURL = vcs.GetGitRemoteURL
+)
+
+// GetProvenance returns annotations for the commit, branch and repository of
+// the working directory and the URL of the CI build, if any. Values set by CI
+// systems are used over git's, as CI often checks out a detached HEAD.
+func GetProvenance(lookupEnv func(string) (string, bool)) map[string]string {
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+	env := func(names ...string) string {
+		for _, name := range names {
+			if value, ok := lookupEnv(name); ok && len(value) > 0 {
+				return value
+			}
+		}
+		return ""
+	}
+
+	annotations := map[string]string{}
+	set := func(key, value string) {
+		if len(value) > 0 {
+			annotations[key] = value
+		}
+	}
+
+	sha := env("GITHUB_SHA", "CI_COMMIT_SHA", "CIRCLE_SHA1", "GIT_COMMIT")
+	if len(sha) == 0 {
+		sha = gitSHA()
+	}
+	set(GitSHAAnnotation, sha)
+
+	branch := env("GITHUB_HEAD_REF", "GITHUB_REF_NAME", "CI_COMMIT_REF_NAME", "CIRCLE_BRANCH", "GIT_BRANCH")
+	if len(branch) == 0 {
+		if branch = gitBranch(); branch == "HEAD" {
+			branch = ""
+		}
+	}
+	set(GitBranchAnnotation, branch)
+
+	repo := ""
+	if server, repository := env("GITHUB_SERVER_URL"), env("GITHUB_REPOSITORY"); len(server) > 0 && len(repository) > 0 {
+		repo = server + "/" + repository
+	} else if repo = env("CI_PROJECT_URL", "CIRCLE_REPOSITORY_URL", "GIT_URL"); len(repo) == 0 {
+		repo = gitRemoteURL()
+	}
+	set(GitRepoAnnotation, redactRepoCredentials(repo))
+
+	buildURL := env("CI_JOB_URL", "CIRCLE_BUILD_URL", "BUILD_URL")
+	if server, repository, runID := env("GITHUB_SERVER_URL"), env("GITHUB_REPOSITORY"), env("GITHUB_RUN_ID"); len(buildURL) == 0 && len(runID) > 0 {
+		buildURL = server + "/" + repository + "/actions/runs/" + runID
+	}
+	set(CIBuildURLAnnotation, buildURL)
+
+	return annotations
+}
+
+// redactRepoCredentials removes a username and password or token from a
+// remote's URL, as CI systems often clone with a token in the URL
+func redactRepoCredentials(repo string) string {
+	if !strings.Contains(repo, "://") {
+		return repo
+	}
+
+	u, err := url.Parse(repo)
+	if err != nil {
+		return repo
+	}
+	u.User = nil
+	return u.String()
+}
@@ -4,59 +4,103 @@
 package builder
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 
 	v1execute "github.com/alexellis/go-execute/pkg/v1"
+	"github.com/openfaas/faas-cli/output"
 	"github.com/openfaas/faas-cli/schema"
 	"github.com/openfaas/faas-cli/stack"
 )
 
 // PublishImage will publish images as multi-arch
 // TODO: refactor signature to a struct to simplify the length of the method header
-func PublishImage(image string, handler string, functionName string, language string, nocache bool, squash bool, shrinkwrap bool, buildArgMap map[string]string,
-	buildOptions []string, tagMode schema.BuildFormat, buildLabelMap map[string]string, quietBuild bool, copyExtraPaths []string, platforms string, extraTags []string) error {
+func PublishImage(ctx context.Context, image string, handler string, functionName string, language string, nocache bool, squash bool, shrinkwrap bool, buildArgMap map[string]string,
+	buildOptions []string, tagMode schema.BuildFormat, buildLabelMap map[string]string, quietBuild bool, copyExtraPaths []string, platforms string, extraTags []string, cacheFrom []string, cacheTo []string) error {
+
+	_, err := PublishImageWithDigest(ctx, image, handler, functionName, language, nocache, squash, shrinkwrap, buildArgMap,
+		buildOptions, tagMode, buildLabelMap, quietBuild, copyExtraPaths, platforms, extraTags, cacheFrom, cacheTo)
+	return err
+}
+
+// PublishImageWithDigest publishes images in the same way as PublishImage and
+// returns the digest of the image which was pushed, as buildx reported it. The
+// digest is empty when the build is only shrink-wrapped.
+func PublishImageWithDigest(ctx context.Context, image string, handler string, functionName string, language string, nocache bool, squash bool, shrinkwrap bool, buildArgMap map[string]string,
+	buildOptions []string, tagMode schema.BuildFormat, buildLabelMap map[string]string, quietBuild bool, copyExtraPaths []string, platforms string, extraTags []string, cacheFrom []string, cacheTo []string) (string, error) {
 
 	if stack.IsValidTemplate(language) {
+		language, languageVersion := stack.SplitLanguage(language)
+
 		pathToTemplateYAML := fmt.Sprintf("./template/%s/template.yml", language)
 		if _, err := os.Stat(pathToTemplateYAML); os.IsNotExist(err) {
-			return err
+			return "", err
 		}
 
 		langTemplate, err := stack.ParseYAMLForLanguageTemplate(pathToTemplateYAML)
 		if err != nil {
-			return fmt.Errorf("error reading language template: %s", err.Error())
+			return "", fmt.Errorf("error reading language template: %s", err.Error())
 		}
 
+		templateVersion, err := langTemplate.ResolveVersion(language, languageVersion)
+		if err != nil {
+			return "", fmt.Errorf("[%s] %s", functionName, err)
+		}
+		buildArgMap = templateVersionBuildArgs(templateVersion, buildArgMap)
+
 		branch, version, err := GetImageTagValues(tagMode)
 		if err != nil {
-			return err
+			return "", err
 		}
 
 		imageName := schema.BuildImageName(tagMode, image, version, branch)
 
 		if err := ensureHandlerPath(handler); err != nil {
-			return fmt.Errorf("building %s, %s is an invalid path", imageName, handler)
+			return "", fmt.Errorf("building %s, %s is an invalid path", imageName, handler)
 		}
 
-		tempPath, buildErr := createBuildContext(functionName, handler, language, isLanguageTemplate(language), langTemplate.HandlerFolder, copyExtraPaths)
-		fmt.Printf("Building: %s with %s template. Please wait..\n", imageName, language)
+		out := output.Progress(quietBuild)
+		tempPath, buildErr := createBuildContext(functionName, handler, language, isLanguageTemplate(language), langTemplate.HandlerFolder, copyExtraPaths, shrinkwrap, out)
+		defer removeBuildDir(tempPath, shrinkwrap, out)
+		fmt.Fprintf(out, "Building: %s with %s template. Please wait..\n", imageName, language)
 		if buildErr != nil {
-			return buildErr
+			return "", buildErr
+		}
+
+		if err := useTemplateVersionDockerfile(tempPath, language, templateVersion); err != nil {
+			return "", err
 		}
 
+		if err := prepareReproducibleContext(functionName, tempPath); err != nil {
+			return "", err
+		}
+		buildArgMap = reproducibleBuildArgs(buildArgMap)
+
 		if shrinkwrap {
-			fmt.Printf("%s shrink-wrapped to %s\n", functionName, tempPath)
-			return nil
+			fmt.Fprintf(out, "%s shrink-wrapped to %s\n", functionName, tempPath)
+			return "", nil
 		}
 
 		buildOptPackages, buildPackageErr := getBuildOptionPackages(buildOptions, language, langTemplate.BuildOptions)
 
 		if buildPackageErr != nil {
-			return buildPackageErr
+			return "", buildPackageErr
+
+		}
 
+		// buildx writes the digest of the image it pushed to the metadata
+		// file, reading it back from the registry by its tag could race
+		// with another push of the same tag
+		metadataFile, err := ioutil.TempFile("", "faas-cli-metadata-*.json")
+		if err != nil {
+			return "", err
 		}
+		metadataFile.Close()
+		defer os.Remove(metadataFile.Name())
 
 		dockerBuildVal := dockerBuild{
 			Image:            imageName,
@@ -69,10 +113,17 @@ func PublishImage(image string, handler string, functionName string, language st
 			BuildLabelMap:    buildLabelMap,
 			Platforms:        platforms,
 			ExtraTags:        extraTags,
+			CacheFrom:        cacheFrom,
+			CacheTo:          cacheTo,
+			MetadataFile:     metadataFile.Name(),
 		}
 
-		command, args := getDockerBuildxCommand(dockerBuildVal)
-		fmt.Printf("Publishing with command: %v %v\n", command, args)
+		_, buildxArgs := getDockerBuildxCommand(dockerBuildVal)
+		command, args, err := EngineCommand(buildxArgs...)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(out, "Publishing with command: %v %v\n", command, args)
 
 		task := v1execute.ExecTask{
 			Cwd:         tempPath,
@@ -81,23 +132,57 @@ func PublishImage(image string, handler string, functionName string, language st
 			StreamStdio: !quietBuild,
 		}
 
-		res, err := task.Execute()
+		buildLog, logPath, err := OpenLog(functionName, "build")
+		if err != nil {
+			return "", fmt.Errorf("[%s] %s", functionName, err)
+		}
+		if buildLog != nil {
+			defer buildLog.Close()
+		}
+
+		res, err := executeContext(ctx, task, buildLog)
 
 		if err != nil {
-			return err
+			return "", err
 		}
 
 		if res.ExitCode != 0 {
-			return fmt.Errorf("[%s] received non-zero exit code from build, error: %s", functionName, res.Stderr)
+			return "", nonZeroExitError(functionName, "build", res.Stderr, logPath)
+		}
+
+		digest, err := readBuildxDigest(metadataFile.Name())
+		if err != nil {
+			return "", fmt.Errorf("[%s] %s", functionName, err)
+		}
+
+		fmt.Fprintln(out, output.Success("Image: %s built.", imageName))
+		if len(logPath) > 0 {
+			fmt.Fprintf(out, "Build log: %s\n", logPath)
 		}
+		return digest, nil
+	}
 
-		fmt.Printf("Image: %s built.\n", imageName)
+	return "", fmt.Errorf("language template: %s not supported, build a custom Dockerfile", language)
+}
 
-	} else {
-		return fmt.Errorf("language template: %s not supported, build a custom Dockerfile", language)
+// readBuildxDigest reads the digest of the pushed image from the metadata
+// file written by buildx with --metadata-file
+func readBuildxDigest(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read the digest of the image: %s", err)
 	}
 
-	return nil
+	var metadata struct {
+		Digest string `json:"containerimage.digest"`
+	}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return "", fmt.Errorf("unable to read the digest of the image: %s", err)
+	}
+	if !strings.HasPrefix(metadata.Digest, "sha256:") {
+		return "", fmt.Errorf("buildx did not report the digest of the image")
+	}
+	return metadata.Digest, nil
 }
 
 func getDockerBuildxCommand(build dockerBuild) (string, []string) {
@@ -105,11 +190,20 @@ func getDockerBuildxCommand(build dockerBuild) (string, []string) {
 		build.BuildOptPackages, build.BuildLabelMap)
 
 	// pushOnly defined at https://github.com/docker/buildx
-	const pushOnly = "--output=type=registry,push=true"
+	pushOnly := "--output=type=registry,push=true"
+	if Reproducible {
+		// rewrite-timestamp sets the files in each layer to SOURCE_DATE_EPOCH
+		pushOnly += ",rewrite-timestamp=true"
+	}
 
 	args := []string{"buildx", "build", "--progress=plain", "--platform=" + build.Platforms, pushOnly}
 
 	args = append(args, flagSlice...)
+	args = append(args, cacheFlagSlice(build.CacheFrom, build.CacheTo)...)
+
+	if len(build.MetadataFile) > 0 {
+		args = append(args, "--metadata-file", build.MetadataFile)
+	}
 
 	args = append(args, "--tag", build.Image, ".")
 
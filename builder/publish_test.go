@@ -0,0 +1,71 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_getDockerBuildxCommand_MetadataFile(t *testing.T) {
+	_, args := getDockerBuildxCommand(dockerBuild{Image: "fn:latest", Platforms: "linux/amd64", MetadataFile: "/tmp/metadata.json"})
+
+	joined := strings.Join(args, " ")
+	if !strings.Contains(joined, "--metadata-file /tmp/metadata.json") {
+		t.Fatalf("want the digest written to the metadata file, got %v", args)
+	}
+	if !strings.HasSuffix(joined, "--tag fn:latest .") {
+		t.Fatalf("want the build context last, got %v", args)
+	}
+}
+
+func Test_readBuildxDigest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "faas-cli-metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	digest := "sha256:" + strings.Repeat("a", 64)
+	cases := []struct {
+		name     string
+		metadata string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "digest of the pushed image",
+			metadata: `{"buildx.build.ref": "multiarch/multiarch0/abc", "containerimage.digest": "` + digest + `"}`,
+			want:     digest,
+		},
+		{name: "no digest", metadata: `{"buildx.build.ref": "multiarch/multiarch0/abc"}`, wantErr: true},
+		{name: "not JSON", metadata: `digest`, wantErr: true},
+	}
+
+	for i, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.Repeat("m", i+1)+".json")
+			if err := ioutil.WriteFile(path, []byte(tc.metadata), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := readBuildxDigest(path)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("want an error, got digest %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("want no error, got: %s", err)
+			}
+			if got != tc.want {
+				t.Fatalf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
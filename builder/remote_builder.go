@@ -0,0 +1,214 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RemoteBuilder is the URL of a builder service, i.e. the OpenFaaS Pro
+// builder, which builds and pushes images in place of a local container engine
+var RemoteBuilder string
+
+// PayloadSecret signs the requests sent to the RemoteBuilder
+var PayloadSecret string
+
+// remoteBuilderClient sends the build context to the RemoteBuilder, it has
+// no timeout as a build may take as long as it needs
+var remoteBuilderClient = &http.Client{}
+
+const (
+	// remoteBuildConfigFile is the name of the build config in the tar sent
+	// to the builder, the build context is in the context folder
+	remoteBuildConfigFile = "com.openfaas.docker.config"
+	remoteBuildContext    = "context"
+
+	// remoteBuildSignature is the header with the HMAC of the tar
+	remoteBuildSignature = "X-Build-Signature"
+
+	remoteBuildSuccess = "success"
+)
+
+// remoteBuildConfig tells the builder what to build
+type remoteBuildConfig struct {
+	Image     string            `json:"image"`
+	BuildArgs map[string]string `json:"buildArgs,omitempty"`
+}
+
+// remoteBuildResult is streamed back by the builder, one per line
+type remoteBuildResult struct {
+	Log    []string `json:"log"`
+	Image  string   `json:"image"`
+	Status string   `json:"status"`
+}
+
+// remoteBuildArgs combines the build args with the packages of the build
+// options, as they are passed to a local build
+func remoteBuildArgs(buildArgMap map[string]string, buildOptPackages []string) map[string]string {
+	args := map[string]string{}
+	packages := append([]string{}, buildOptPackages...)
+	for k, v := range buildArgMap {
+		if k == AdditionalPackageBuildArg {
+			packages = append(packages, strings.Split(v, " ")...)
+			continue
+		}
+		args[k] = v
+	}
+	if len(packages) > 0 {
+		args[AdditionalPackageBuildArg] = strings.Join(deDuplicate(packages), " ")
+	}
+	return args
+}
+
+// remoteBuild sends the build context in tempPath to the builder, which
+// builds and pushes the image, and writes the logs of the build to out as
+// they are streamed back. The request is stopped when ctx is cancelled.
+func remoteBuild(ctx context.Context, builderURL, secret, tempPath string, config remoteBuildConfig, out io.Writer) error {
+	payload, err := remoteBuildTar(tempPath, config)
+	if err != nil {
+		return fmt.Errorf("unable to create the build context for %s: %s", config.Image, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(builderURL, "/")+"/build", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Accept", "application/x-ndjson")
+	if len(secret) > 0 {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(payload)
+		req.Header.Set(remoteBuildSignature, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	res, err := remoteBuilderClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to reach the builder at %s: %s", builderURL, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 4096))
+		return fmt.Errorf("the builder at %s returned %d: %s", builderURL, res.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var last remoteBuildResult
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var result remoteBuildResult
+		if err := json.Unmarshal(line, &result); err != nil {
+			return fmt.Errorf("unable to read the response of the builder: %s", err)
+		}
+		for _, log := range result.Log {
+			fmt.Fprintln(out, log)
+		}
+		last = result
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("unable to read the response of the builder: %s", err)
+	}
+
+	if last.Status != remoteBuildSuccess {
+		status := last.Status
+		if len(status) == 0 {
+			status = "no status"
+		}
+		return fmt.Errorf("the builder was unable to build %s: %s", config.Image, status)
+	}
+	return nil
+}
+
+
+// remoteBuildTar writes the config and the build context into a tar
+func remoteBuildTar(tempPath string, config remoteBuildConfig) ([]byte, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	configData, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     remoteBuildConfigFile,
+		Mode:     0600,
+		Size:     int64(len(configData)),
+		Typeflag: tar.TypeReg,
+	}); err != nil {
+		return nil, err
+	}
+	if _, err := tw.Write(configData); err != nil {
+		return nil, err
+	}
+
+	err = filepath.Walk(tempPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(tempPath, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(filepath.Join(remoteBuildContext, rel))
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,137 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_remoteBuild(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "faas-cli-remote-build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempPath)
+
+	if err := ioutil.WriteFile(filepath.Join(tempPath, "Dockerfile"), []byte("FROM scratch\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var gotFiles map[string]string
+	builder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(body)
+		if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); r.Header.Get(remoteBuildSignature) != want {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		gotFiles = map[string]string{}
+		tr := tar.NewReader(bytes.NewReader(body))
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Errorf("unable to read the tar: %s", err)
+				return
+			}
+			data, _ := ioutil.ReadAll(tr)
+			gotFiles[header.Name] = string(data)
+		}
+
+		json.NewEncoder(w).Encode(remoteBuildResult{Log: []string{"Step 1/1 : FROM scratch"}, Status: "in_progress"})
+		json.NewEncoder(w).Encode(remoteBuildResult{Image: "alexellis/fn:latest", Status: remoteBuildSuccess})
+	}))
+	defer builder.Close()
+
+	config := remoteBuildConfig{Image: "alexellis/fn:latest", BuildArgs: map[string]string{"GO111MODULE": "on"}}
+
+	var out bytes.Buffer
+	if err := remoteBuild(context.Background(), builder.URL, "secret", tempPath, config, &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if gotFiles["context/Dockerfile"] != "FROM scratch\n" {
+		t.Fatalf("want the Dockerfile in the context folder, got: %v", gotFiles)
+	}
+	var gotConfig remoteBuildConfig
+	if err := json.Unmarshal([]byte(gotFiles[remoteBuildConfigFile]), &gotConfig); err != nil || !reflect.DeepEqual(gotConfig, config) {
+		t.Fatalf("want config %+v, got %s", config, gotFiles[remoteBuildConfigFile])
+	}
+	if !strings.Contains(out.String(), "Step 1/1 : FROM scratch") {
+		t.Fatalf("want the logs of the build, got: %s", out.String())
+	}
+
+	err = remoteBuild(context.Background(), builder.URL, "wrong", tempPath, config, &out)
+	if err == nil || !strings.Contains(err.Error(), "returned 401") {
+		t.Fatalf("want an error for a bad signature, got: %v", err)
+	}
+}
+
+func Test_remoteBuild_failedBuild(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "faas-cli-remote-build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempPath)
+
+	builder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(remoteBuildResult{Log: []string{"unknown instruction: FORM"}, Status: "failed"})
+	}))
+	defer builder.Close()
+
+	err = remoteBuild(context.Background(), builder.URL, "", tempPath, remoteBuildConfig{Image: "alexellis/fn:latest"}, ioutil.Discard)
+	if err == nil || !strings.Contains(err.Error(), "unable to build alexellis/fn:latest: failed") {
+		t.Fatalf("want the build to fail, got: %v", err)
+	}
+}
+
+func Test_remoteBuild_cancelled(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "faas-cli-remote-build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempPath)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	builder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		<-release
+	}))
+	defer builder.Close()
+	defer close(release)
+
+	err = remoteBuild(ctx, builder.URL, "", tempPath, remoteBuildConfig{Image: "alexellis/fn:latest"}, ioutil.Discard)
+	if err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Fatalf("want the build to be cancelled, got: %v", err)
+	}
+}
+
+func Test_remoteBuildArgs(t *testing.T) {
+	got := remoteBuildArgs(map[string]string{"ADDITIONAL_PACKAGE": "git", "GO111MODULE": "on"}, []string{"curl", "git"})
+	want := map[string]string{"ADDITIONAL_PACKAGE": "curl git", "GO111MODULE": "on"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	vcs "github.com/openfaas/faas-cli/versioncontrol"
+)
+
+// SourceDateEpochBuildArg is the build-arg which BuildKit reads for the
+// timestamps in an image, see https://reproducible-builds.org/specs/source-date-epoch/
+const SourceDateEpochBuildArg = "SOURCE_DATE_EPOCH"
+
+// Reproducible builds images which are the same for the same inputs, the
+// files in the build context get SourceDateEpoch as their modification time
+// and it is passed to the build as SOURCE_DATE_EPOCH
+var Reproducible bool
+
+// SourceDateEpoch is the time in seconds since the Unix epoch used by
+// reproducible builds
+var SourceDateEpoch int64
+
+// ResolveSourceDateEpoch reads SOURCE_DATE_EPOCH from the environment, or
+// else uses the time of the latest Git commit, or else the Unix epoch
+func ResolveSourceDateEpoch() (int64, error) {
+	if value, ok := os.LookupEnv(SourceDateEpochBuildArg); ok && len(value) > 0 {
+		epoch, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil || epoch < 0 {
+			return 0, fmt.Errorf("invalid %s %q, give the number of seconds since the Unix epoch", SourceDateEpochBuildArg, value)
+		}
+		return epoch, nil
+	}
+
+	if commitTime := vcs.GetGitCommitTime(); len(commitTime) > 0 {
+		if epoch, err := strconv.ParseInt(commitTime, 10, 64); err == nil {
+			return epoch, nil
+		}
+	}
+	return 0, nil
+}
+
+// reproducibleBuildArgs adds SOURCE_DATE_EPOCH to the build-args of a
+// reproducible build, unless it was given with --build-arg
+func reproducibleBuildArgs(buildArgMap map[string]string) map[string]string {
+	if !Reproducible {
+		return buildArgMap
+	}
+	if _, ok := buildArgMap[SourceDateEpochBuildArg]; ok {
+		return buildArgMap
+	}
+
+	args := map[string]string{SourceDateEpochBuildArg: strconv.FormatInt(SourceDateEpoch, 10)}
+	for k, v := range buildArgMap {
+		args[k] = v
+	}
+	return args
+}
+
+// normalizeTimestamps sets the modification time of every file and folder
+// in the build context to the epoch, so that the time they were copied does
+// not change the layers of the image. Symlinks are left as they are.
+func normalizeTimestamps(dir string, epoch int64) error {
+	when := time.Unix(epoch, 0)
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+		return os.Chtimes(path, when, when)
+	})
+}
+
+// prepareReproducibleContext normalizes the timestamps of a build context
+// for a reproducible build
+func prepareReproducibleContext(functionName, tempPath string) error {
+	if !Reproducible {
+		return nil
+	}
+	if err := normalizeTimestamps(tempPath, SourceDateEpoch); err != nil {
+		return fmt.Errorf("[%s] unable to normalize the timestamps of the build context: %s", functionName, err)
+	}
+	return nil
+}
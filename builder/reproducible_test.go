@@ -0,0 +1,91 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ResolveSourceDateEpoch(t *testing.T) {
+	defer os.Unsetenv(SourceDateEpochBuildArg)
+
+	os.Setenv(SourceDateEpochBuildArg, "1602806400")
+	epoch, err := ResolveSourceDateEpoch()
+	if err != nil {
+		t.Fatalf("want no error, got %s", err)
+	}
+	if epoch != 1602806400 {
+		t.Fatalf("want 1602806400, got %d", epoch)
+	}
+
+	os.Setenv(SourceDateEpochBuildArg, "yesterday")
+	if _, err := ResolveSourceDateEpoch(); err == nil {
+		t.Fatalf("want an error for an invalid %s", SourceDateEpochBuildArg)
+	}
+}
+
+func Test_reproducibleBuildArgs(t *testing.T) {
+	defer func() { Reproducible, SourceDateEpoch = false, 0 }()
+
+	args := map[string]string{"GO111MODULE": "on"}
+	if got := reproducibleBuildArgs(args); !reflect.DeepEqual(got, args) {
+		t.Fatalf("want the build-args unchanged, got %v", got)
+	}
+
+	Reproducible, SourceDateEpoch = true, 1602806400
+	want := map[string]string{"GO111MODULE": "on", SourceDateEpochBuildArg: "1602806400"}
+	if got := reproducibleBuildArgs(args); !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+
+	given := map[string]string{SourceDateEpochBuildArg: "0"}
+	if got := reproducibleBuildArgs(given); !reflect.DeepEqual(got, given) {
+		t.Fatalf("want --build-arg %s to be kept, got %v", SourceDateEpochBuildArg, got)
+	}
+}
+
+func Test_normalizeTimestamps(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reproducible")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.MkdirAll(filepath.Join(dir, "function"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Dockerfile", "function/handler.go"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := normalizeTimestamps(dir, 1602806400); err != nil {
+		t.Fatalf("want no error, got %s", err)
+	}
+
+	want := time.Unix(1602806400, 0)
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if !info.ModTime().Equal(want) {
+			t.Errorf("want %s modified at %s, got %s", strings.TrimPrefix(path, dir), want, info.ModTime())
+		}
+		return nil
+	})
+}
+
+func Test_getDockerBuildxCommand_Reproducible(t *testing.T) {
+	defer func() { Reproducible = false }()
+	Reproducible = true
+
+	_, args := getDockerBuildxCommand(dockerBuild{Image: "fn:latest", Platforms: "linux/amd64"})
+	if !containsArg(args, "--output=type=registry,push=true,rewrite-timestamp=true") {
+		t.Fatalf("want the timestamps in each layer rewritten, got %v", args)
+	}
+}
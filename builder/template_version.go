@@ -0,0 +1,43 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+// templateVersionBuildArgs returns the build args of a version of a template,
+// the build args of the function take precedence over those of the version
+func templateVersionBuildArgs(templateVersion *stack.TemplateVersion, buildArgMap map[string]string) map[string]string {
+	if templateVersion == nil || len(templateVersion.BuildArgs) == 0 {
+		return buildArgMap
+	}
+
+	merged := make(map[string]string, len(templateVersion.BuildArgs)+len(buildArgMap))
+	for k, v := range templateVersion.BuildArgs {
+		merged[k] = v
+	}
+	for k, v := range buildArgMap {
+		merged[k] = v
+	}
+	return merged
+}
+
+// useTemplateVersionDockerfile replaces the Dockerfile in the build context
+// with the one declared by a version of the template
+func useTemplateVersionDockerfile(tempPath, template string, templateVersion *stack.TemplateVersion) error {
+	if templateVersion == nil || len(templateVersion.Dockerfile) == 0 {
+		return nil
+	}
+
+	src := filepath.Join("./template", template, templateVersion.Dockerfile)
+	if _, err := os.Stat(src); err != nil {
+		return fmt.Errorf("the %s template declares %s for the version, but it was not found", template, src)
+	}
+	return copyFile(src, filepath.Join(tempPath, "Dockerfile"))
+}
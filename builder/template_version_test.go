@@ -0,0 +1,47 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+func Test_templateVersionBuildArgs(t *testing.T) {
+	cases := []struct {
+		name            string
+		templateVersion *stack.TemplateVersion
+		buildArgs       map[string]string
+		want            map[string]string
+	}{
+		{
+			name:      "no version",
+			buildArgs: map[string]string{"ADDITIONAL_PACKAGE": "git"},
+			want:      map[string]string{"ADDITIONAL_PACKAGE": "git"},
+		},
+		{
+			name:            "version build args are added",
+			templateVersion: &stack.TemplateVersion{BuildArgs: map[string]string{"PYTHON_VERSION": "3.11"}},
+			buildArgs:       map[string]string{"ADDITIONAL_PACKAGE": "git"},
+			want:            map[string]string{"ADDITIONAL_PACKAGE": "git", "PYTHON_VERSION": "3.11"},
+		},
+		{
+			name:            "function build args take precedence",
+			templateVersion: &stack.TemplateVersion{BuildArgs: map[string]string{"PYTHON_VERSION": "3.11"}},
+			buildArgs:       map[string]string{"PYTHON_VERSION": "3.12"},
+			want:            map[string]string{"PYTHON_VERSION": "3.12"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := templateVersionBuildArgs(tc.templateVersion, tc.buildArgs)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+
+	ingressv1alpha2 "github.com/openfaas/faas-cli/schema/ingress/v1alpha2"
+
+	"github.com/openfaas/faas-cli/schema"
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const defaultIngressType = "nginx"
+
+var (
+	aliasNamespace   string
+	aliasIngressType string
+)
+
+func init() {
+	aliasCmd.Flags().StringVarP(&aliasNamespace, "namespace", "n", "openfaas", "Kubernetes namespace of the OpenFaaS gateway")
+	aliasCmd.Flags().StringVar(&aliasIngressType, "ingress-type", defaultIngressType, "Ingress type to use when an alias does not set ingress_type")
+	aliasCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in stack.yml file")
+
+	faasCmd.AddCommand(aliasCmd)
+}
+
+var aliasCmd = &cobra.Command{
+	Use:   `alias -f YAML_FILE [--namespace openfaas] [--ingress-type nginx]`,
+	Short: "Generate FunctionIngress YAML for function aliases",
+	Long: `Generates FunctionIngress custom resources for the "aliases" of each function
+in the supplied YAML file. The resources are read by the OpenFaaS ingress-operator
+which creates an Ingress record for each custom domain and path.
+
+Aliases are defined per function in stack.yml:
+
+  functions:
+    nodeinfo:
+      image: functions/nodeinfo:latest
+      aliases:
+        - domain: nodeinfo.example.com
+          tls:
+            enabled: true
+            issuer_name: letsencrypt-prod
+            issuer_kind: ClusterIssuer`,
+	Example: `  faas-cli alias -f stack.yml | kubectl apply -f -
+  faas-cli alias -f stack.yml --filter "nodeinfo"
+  faas-cli alias -f stack.yml --ingress-type traefik`,
+	RunE: runAlias,
+}
+
+func runAlias(cmd *cobra.Command, args []string) error {
+	if len(yamlFile) == 0 {
+		return fmt.Errorf("you must supply a valid YAML file with --yaml")
+	}
+
+	services, err := parseStackFile(yamlFile, envsubst)
+	if err != nil {
+		return err
+	}
+
+	objectsString, err := generateAliasYAML(*services, aliasNamespace, aliasIngressType)
+	if err != nil {
+		return err
+	}
+
+	if len(objectsString) == 0 {
+		return fmt.Errorf("no aliases found for the functions in %s", yamlFile)
+	}
+
+	fmt.Println(objectsString)
+	return nil
+}
+
+// generateAliasYAML generates a FunctionIngress for each alias of each function
+func generateAliasYAML(services stack.Services, namespace, ingressType string) (string, error) {
+	var objectsString string
+
+	for _, name := range generateFunctionOrder(services.Functions) {
+		function := services.Functions[name]
+
+		for i, alias := range function.Aliases {
+			if len(alias.Domain) == 0 {
+				return "", fmt.Errorf("alias %d of function %s must have a domain", i, name)
+			}
+
+			// The first alias keeps the function's name so that it reads naturally
+			// in kubectl get functioningress
+			ingressName := name
+			if i > 0 {
+				ingressName = fmt.Sprintf("%s-%d", name, i)
+			}
+
+			spec := ingressv1alpha2.Spec{
+				Domain:      alias.Domain,
+				Function:    name,
+				IngressType: ingressType,
+				Path:        alias.Path,
+			}
+
+			if len(alias.IngressType) > 0 {
+				spec.IngressType = alias.IngressType
+			}
+
+			if alias.TLS != nil && alias.TLS.Enabled {
+				issuerKind := alias.TLS.IssuerKind
+				if len(issuerKind) == 0 {
+					issuerKind = "Issuer"
+				}
+
+				spec.TLS = &ingressv1alpha2.TLS{
+					Enabled: true,
+					IssuerRef: ingressv1alpha2.IssuerRef{
+						Name: alias.TLS.IssuerName,
+						Kind: issuerKind,
+					},
+				}
+			}
+
+			crd := ingressv1alpha2.CRD{
+				APIVersion: ingressv1alpha2.APIVersionLatest,
+				Kind:       ingressv1alpha2.Kind,
+				Metadata:   schema.Metadata{Name: ingressName, Namespace: namespace},
+				Spec:       spec,
+			}
+
+			objectString, err := yaml.Marshal(crd)
+			if err != nil {
+				return "", err
+			}
+			objectsString += "---\n" + string(objectString)
+		}
+	}
+
+	return objectsString, nil
+}
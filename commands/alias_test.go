@@ -0,0 +1,136 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"testing"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+var aliasTestcases = []struct {
+	Name        string
+	Input       string
+	Output      string
+	Namespace   string
+	IngressType string
+}{
+	{
+		Name: "Function without aliases",
+		Input: `
+provider:
+  name: openfaas
+functions:
+  url-ping:
+    image: alexellis/faas-url-ping:0.2`,
+		Output:      "",
+		Namespace:   "openfaas",
+		IngressType: "nginx",
+	},
+	{
+		Name: "Alias with TLS and default issuer kind",
+		Input: `
+provider:
+  name: openfaas
+functions:
+  nodeinfo:
+    image: functions/nodeinfo:latest
+    aliases:
+      - domain: nodeinfo.example.com
+        tls:
+          enabled: true
+          issuer_name: letsencrypt-prod`,
+		Output: `---
+apiVersion: openfaas.com/v1alpha2
+kind: FunctionIngress
+metadata:
+  name: nodeinfo
+  namespace: openfaas
+spec:
+  domain: nodeinfo.example.com
+  function: nodeinfo
+  ingressType: nginx
+  tls:
+    enabled: true
+    issuerRef:
+      name: letsencrypt-prod
+      kind: Issuer
+`,
+		Namespace:   "openfaas",
+		IngressType: "nginx",
+	},
+	{
+		Name: "Multiple aliases override ingress type",
+		Input: `
+provider:
+  name: openfaas
+functions:
+  profiles:
+    image: alexellis/profiles:latest
+    aliases:
+      - domain: api.example.com
+        path: /v1/profiles/(.*)
+      - domain: profiles.example.com
+        ingress_type: traefik`,
+		Output: `---
+apiVersion: openfaas.com/v1alpha2
+kind: FunctionIngress
+metadata:
+  name: profiles
+  namespace: openfaas-system
+spec:
+  domain: api.example.com
+  function: profiles
+  ingressType: nginx
+  path: /v1/profiles/(.*)
+---
+apiVersion: openfaas.com/v1alpha2
+kind: FunctionIngress
+metadata:
+  name: profiles-1
+  namespace: openfaas-system
+spec:
+  domain: profiles.example.com
+  function: profiles
+  ingressType: traefik
+`,
+		Namespace:   "openfaas-system",
+		IngressType: "nginx",
+	},
+}
+
+func Test_generateAliasYAML(t *testing.T) {
+	for _, testcase := range aliasTestcases {
+		t.Run(testcase.Name, func(t *testing.T) {
+			services, err := stack.ParseYAMLData([]byte(testcase.Input), "", "", false)
+			if err != nil {
+				t.Fatalf("error while parsing the input data: %s", err)
+			}
+
+			got, err := generateAliasYAML(*services, testcase.Namespace, testcase.IngressType)
+			if err != nil {
+				t.Fatalf("error while generating alias YAML: %s", err)
+			}
+
+			if got != testcase.Output {
+				t.Fatalf("want:\n%q, but got:\n%q", testcase.Output, got)
+			}
+		})
+	}
+}
+
+func Test_generateAliasYAML_MissingDomain(t *testing.T) {
+	services := stack.Services{
+		Functions: map[string]stack.Function{
+			"nodeinfo": {
+				Aliases: []stack.FunctionAlias{{Path: "/"}},
+			},
+		},
+	}
+
+	_, err := generateAliasYAML(services, "openfaas", "nginx")
+	if err == nil {
+		t.Fatalf("want error for alias without a domain, but got nil")
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// annotateCI prints an annotation for each function which failed to build,
+// push or deploy when running in GitHub Actions or GitLab CI
+var annotateCI bool
+
+const (
+	ciGitHub = "github"
+	ciGitLab = "gitlab"
+)
+
+// detectCI returns the CI system from its environment, or an empty string
+func detectCI() string {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return ciGitHub
+	case os.Getenv("GITLAB_CI") == "true":
+		return ciGitLab
+	}
+	return ""
+}
+
+// ciAnnotation is a failed step pointing at the function in the stack file
+type ciAnnotation struct {
+	File    string
+	Line    int
+	Title   string
+	Message string
+}
+
+// ciFailures returns an annotation for each step of the profiler which failed
+func (p *profiler) ciFailures(stackFile string) []ciAnnotation {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	annotations := []ciAnnotation{}
+	for _, s := range junitSuites {
+		for _, span := range p.spans {
+			if span.Phase != s.phase || span.Err == nil {
+				continue
+			}
+
+			name := strings.TrimPrefix(span.Name, deployStep)
+			annotation := ciAnnotation{
+				Title:   fmt.Sprintf("%s of %s failed", s.name, name),
+				Message: span.Err.Error(),
+			}
+			if line := functionLine(stackFile, name); line > 0 {
+				annotation.File = stackFile
+				annotation.Line = line
+			}
+			annotations = append(annotations, annotation)
+		}
+	}
+	return annotations
+}
+
+// functionLine finds the line where a function is declared in a local stack
+// file, it returns 0 when the file can not be read or the function is not
+// declared in it, i.e. it is in a fragment
+func functionLine(stackFile, name string) int {
+	if len(stackFile) == 0 {
+		return 0
+	}
+	if u, err := url.Parse(stackFile); err == nil && len(u.Scheme) > 1 {
+		return 0
+	}
+
+	file, err := os.Open(stackFile)
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	declaration := regexp.MustCompile(`^\s+["']?` + regexp.QuoteMeta(name) + `["']?\s*:\s*(#.*)?$`)
+	inFunctions := false
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if len(text) > 0 && text[0] != ' ' && text[0] != '\t' && text[0] != '#' {
+			inFunctions = strings.HasPrefix(text, "functions:")
+			continue
+		}
+		if inFunctions && declaration.MatchString(text) {
+			return line
+		}
+	}
+	return 0
+}
+
+// formatCIAnnotation formats an annotation as a workflow command for GitHub
+// Actions, or as file:line: error: for GitLab CI and problem matchers
+func formatCIAnnotation(ci string, annotation ciAnnotation) string {
+	if ci == ciGitHub {
+		properties := []string{}
+		if len(annotation.File) > 0 {
+			properties = append(properties, "file="+escapeGitHubProperty(annotation.File), fmt.Sprintf("line=%d", annotation.Line))
+		}
+		properties = append(properties, "title="+escapeGitHubProperty(annotation.Title))
+		return fmt.Sprintf("::error %s::%s", strings.Join(properties, ","), escapeGitHubData(annotation.Message))
+	}
+
+	message := strings.SplitN(annotation.Message, "\n", 2)[0]
+	if len(annotation.File) > 0 {
+		return fmt.Sprintf("%s:%d: error: %s: %s", annotation.File, annotation.Line, annotation.Title, message)
+	}
+	return fmt.Sprintf("error: %s: %s", annotation.Title, message)
+}
+
+func escapeGitHubData(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(value)
+}
+
+func escapeGitHubProperty(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(value)
+}
+
+// writeCIAnnotations prints the annotations for --annotate-ci, nothing is
+// printed outside of a CI system which is supported
+func writeCIAnnotations(w io.Writer) {
+	if !annotateCI {
+		return
+	}
+
+	ci := detectCI()
+	if len(ci) == 0 {
+		return
+	}
+
+	for _, annotation := range timings.ciFailures(yamlFile) {
+		fmt.Fprintln(w, formatCIAnnotation(ci, annotation))
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const annotateStack = `version: 1.0
+provider:
+  name: openfaas
+  gateway: http://127.0.0.1:8080
+functions:
+  # the api
+  api:
+    lang: go
+    handler: ./api
+  "worker":
+    lang: go
+    handler: ./worker
+`
+
+func Test_functionLine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "faas-cli-annotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	stackFile := filepath.Join(dir, "stack.yml")
+	if err := ioutil.WriteFile(stackFile, []byte(annotateStack), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]int{"api": 7, "worker": 10, "missing": 0, "provider": 0}
+	for name, want := range cases {
+		if got := functionLine(stackFile, name); got != want {
+			t.Errorf("%s: want line %d, got %d", name, want, got)
+		}
+	}
+
+	if got := functionLine("https://example.com/stack.yml", "api"); got != 0 {
+		t.Errorf("want no line for a remote stack file, got %d", got)
+	}
+}
+
+func Test_formatCIAnnotation(t *testing.T) {
+	annotation := ciAnnotation{
+		File:    "stack.yml",
+		Line:    7,
+		Title:   "build of api failed",
+		Message: "[api] received non-zero exit code from build\nstep 3: 100% failed",
+	}
+
+	cases := []struct {
+		ci   string
+		want string
+	}{
+		{
+			ci:   ciGitHub,
+			want: "::error file=stack.yml,line=7,title=build of api failed::[api] received non-zero exit code from build%0Astep 3: 100%25 failed",
+		},
+		{
+			ci:   ciGitLab,
+			want: "stack.yml:7: error: build of api failed: [api] received non-zero exit code from build",
+		},
+	}
+
+	for _, tc := range cases {
+		if got := formatCIAnnotation(tc.ci, annotation); got != tc.want {
+			t.Errorf("%s: want\n%s\ngot\n%s", tc.ci, tc.want, got)
+		}
+	}
+}
+
+func Test_profiler_ciFailures(t *testing.T) {
+	p := &profiler{}
+	p.add(profileSpan{Phase: phaseBuild, Name: "api", Duration: time.Second})
+	p.add(profileSpan{Phase: phaseGateway, Name: deployStep + "worker", Duration: time.Second, Err: fmt.Errorf("status code 500")})
+
+	annotations := p.ciFailures("")
+	if len(annotations) != 1 {
+		t.Fatalf("want 1 annotation, got %v", annotations)
+	}
+	if annotations[0].Title != "deploy of worker failed" || len(annotations[0].File) > 0 {
+		t.Errorf("unexpected annotation: %+v", annotations[0])
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const assembledStackHeader = "# Generated by faas-cli assemble from function.yml files, edit those instead\n"
+
+var (
+	assembleBase   string
+	assembleOutput string
+)
+
+func init() {
+	assembleCmd.Flags().StringVar(&assembleBase, "base", "", "Stack file with the provider, configuration and any shared functions")
+	assembleCmd.Flags().StringVarP(&assembleOutput, "output", "o", defaultYAML, "Stack file to write, or - for stdout")
+	assembleCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL to use when there is no --base file")
+
+	faasCmd.AddCommand(assembleCmd)
+}
+
+// assembleCmd merges the function.yml files of a monorepo into one stack file
+var assembleCmd = &cobra.Command{
+	Use:   `assemble [DIRECTORY] [--base base.yml] [-o stack.yml]`,
+	Short: "Assemble a stack file from the function.yml files in a directory tree",
+	Long: `Finds every function.yml file in a directory tree and merges them into a
+single stack file, so that each team can own the definition of its functions
+next to their code and the whole repository can still be deployed at once.
+
+A function.yml holds the same keys as a function in a stack file. The function
+is named after its folder unless it has a "name" and the handler defaults to
+the folder itself:
+
+  # functions/billing/resize/function.yml
+  lang: python3
+  image: ghcr.io/example/resize:latest
+  environment_file:
+    - env.yml
+
+Paths are rewritten to be relative to the assembled stack file. The provider and
+configuration come from the --base file, environment variables are left for
+faas-cli to substitute when the stack is used.`,
+	Example: `  faas-cli assemble ./functions
+  faas-cli assemble ./functions --base base.yml -o stack.yml
+  faas-cli assemble ./functions -o - > stack.yml && faas-cli up`,
+	RunE: runAssemble,
+}
+
+func runAssemble(cmd *cobra.Command, args []string) error {
+	root := "."
+	if len(args) > 0 {
+		root = args[0]
+	}
+
+	outputDir := "."
+	if assembleOutput != "-" {
+		outputDir = filepath.Dir(assembleOutput)
+	}
+
+	gatewayAddress := getGatewayURL(gateway, defaultGateway, "", os.Getenv(openFaaSURLEnvironment))
+
+	services, err := assembleStack(root, assembleBase, outputDir, gatewayAddress)
+	if err != nil {
+		return err
+	}
+
+	out, err := yaml.Marshal(services)
+	if err != nil {
+		return err
+	}
+	out = append([]byte(assembledStackHeader), out...)
+
+	if assembleOutput == "-" {
+		fmt.Print(string(out))
+		return nil
+	}
+
+	if err := ioutil.WriteFile(assembleOutput, out, 0600); err != nil {
+		return fmt.Errorf("unable to write %s: %s", assembleOutput, err)
+	}
+
+	fmt.Printf("Assembled %d function(s) into %s\n", len(services.Functions), assembleOutput)
+	return nil
+}
+
+// assembleStack merges a base stack file with the function.yml files under root,
+// paths are made relative to outputDir where the stack file will be written
+func assembleStack(root, base, outputDir, gatewayAddress string) (*stack.Services, error) {
+	services := &stack.Services{
+		Version:  defaultSchemaVersion,
+		Provider: stack.Provider{Name: "openfaas", GatewayURL: gatewayAddress},
+	}
+
+	sources := map[string]string{}
+
+	if len(base) > 0 {
+		data, err := ioutil.ReadFile(base)
+		if err != nil {
+			return nil, err
+		}
+
+		parsed, err := stack.ParseYAMLData(data, "", "", false)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", base, err)
+		}
+		services = parsed
+
+		baseDir := filepath.Dir(base)
+		for name, function := range services.Functions {
+			services.Functions[name] = rebaseFunctionPaths(function, baseDir, outputDir)
+			sources[name] = base
+		}
+	}
+
+	if services.Functions == nil {
+		services.Functions = map[string]stack.Function{}
+	}
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if path != root && (strings.HasPrefix(info.Name(), ".") || info.Name() == "node_modules" || info.Name() == "build" || info.Name() == "template") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.Name() != stack.FragmentFile {
+			return nil
+		}
+
+		function, err := stack.ParseFragmentFile(path)
+		if err != nil {
+			return err
+		}
+
+		if err := validateFunctionName(function.Name); err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+
+		if source, ok := sources[function.Name]; ok {
+			return fmt.Errorf("function %s is defined in both %s and %s", function.Name, source, path)
+		}
+		sources[function.Name] = path
+
+		name := function.Name
+		function.Name = ""
+		services.Functions[name] = rebaseFunctionPaths(function, ".", outputDir)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(services.Functions) == 0 {
+		return nil, fmt.Errorf("no %s files found in %s", stack.FragmentFile, root)
+	}
+
+	return services, nil
+}
+
+// rebaseFunctionPaths rewrites the handler and environment files of a function
+// from being relative to fromDir to being relative to toDir
+func rebaseFunctionPaths(function stack.Function, fromDir, toDir string) stack.Function {
+	function.Handler = rebasePath(function.Handler, fromDir, toDir)
+	function.EnvFile = rebasePath(function.EnvFile, fromDir, toDir)
+
+	if len(function.EnvironmentFile) > 0 {
+		files := make([]string, len(function.EnvironmentFile))
+		for i, file := range function.EnvironmentFile {
+			files[i] = rebasePath(file, fromDir, toDir)
+		}
+		function.EnvironmentFile = files
+	}
+
+	return function
+}
+
+func rebasePath(path, fromDir, toDir string) string {
+	if len(path) == 0 || strings.Contains(path, "$") {
+		return path
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(fromDir, path)
+	}
+
+	absFrom, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	absTo, err := filepath.Abs(toDir)
+	if err != nil {
+		return path
+	}
+
+	rel, err := filepath.Rel(absTo, absFrom)
+	if err != nil {
+		return path
+	}
+
+	rel = filepath.ToSlash(rel)
+	if rel == "." || strings.HasPrefix(rel, "../") {
+		return rel
+	}
+	return "./" + rel
+}
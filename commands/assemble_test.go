@@ -0,0 +1,82 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_assembleStack(t *testing.T) {
+	root, err := ioutil.TempDir("", "assemble")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	writeFile := func(name, content string) {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFile("base.yml", `version: 1.0
+provider:
+  name: openfaas
+  gateway: http://gateway:8080
+functions:
+  shared:
+    lang: node12
+    handler: ./shared
+    image: shared:latest
+`)
+	writeFile("functions/billing/resize/function.yml", "lang: python3\nimage: resize:latest\nenvironment_file:\n  - env.yml\n")
+	writeFile("functions/invoices/function.yml", "name: invoice-api\nlang: node12\nimage: invoices:latest\n")
+	writeFile("functions/.cache/invoices/function.yml", "lang: node12\nimage: stale:latest\n")
+
+	services, err := assembleStack(filepath.Join(root, "functions"), filepath.Join(root, "base.yml"), root, "http://127.0.0.1:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if services.Provider.GatewayURL != "http://gateway:8080" {
+		t.Errorf("want the provider from the base file, but got: %s", services.Provider.GatewayURL)
+	}
+
+	want := map[string]string{
+		"shared":      "./shared",
+		"resize":      "./functions/billing/resize",
+		"invoice-api": "./functions/invoices",
+	}
+	if len(services.Functions) != len(want) {
+		t.Fatalf("want %d functions, but got: %v", len(want), services.Functions)
+	}
+	for name, handler := range want {
+		if got := services.Functions[name].Handler; got != handler {
+			t.Errorf("want handler %s for %s, but got: %s", handler, name, got)
+		}
+	}
+
+	envFiles := services.Functions["resize"].EnvironmentFile
+	if len(envFiles) != 1 || envFiles[0] != "./functions/billing/resize/env.yml" {
+		t.Errorf("want environment file relative to the stack file, but got: %v", envFiles)
+	}
+
+	t.Run("duplicate function", func(t *testing.T) {
+		writeFile("functions/shared/function.yml", "lang: node12\nimage: shared:latest\n")
+		defer os.RemoveAll(filepath.Join(root, "functions", "shared"))
+
+		_, err := assembleStack(filepath.Join(root, "functions"), filepath.Join(root, "base.yml"), root, "http://127.0.0.1:8080")
+		if err == nil || !strings.Contains(err.Error(), "defined in both") {
+			t.Fatalf("want error for a duplicate function, but got: %v", err)
+		}
+	})
+}
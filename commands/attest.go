@@ -0,0 +1,223 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/openfaas/faas-cli/builder"
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/openfaas/faas-cli/version"
+)
+
+const (
+	// slsaPredicateType is the version of SLSA provenance which is attested
+	slsaPredicateType = "https://slsa.dev/provenance/v0.2"
+
+	// publishBuildType says how the image was built for the provenance
+	publishBuildType = "https://github.com/openfaas/faas-cli/publish@v1"
+
+	// faasCLIBuilderID identifies faas-cli as the builder, a CI run is
+	// recorded as the buildInvocationId instead
+	faasCLIBuilderID = "https://github.com/openfaas/faas-cli"
+)
+
+// attestProvenance is set by publish --attest
+var attestProvenance bool
+
+// slsaProvenance is the predicate of an in-toto statement, cosign adds the
+// statement with the image as its subject when it attests the image
+type slsaProvenance struct {
+	Builder    slsaBuilder    `json:"builder"`
+	BuildType  string         `json:"buildType"`
+	Invocation slsaInvocation `json:"invocation"`
+	Metadata   slsaMetadata   `json:"metadata"`
+	Materials  []slsaMaterial `json:"materials"`
+}
+
+type slsaBuilder struct {
+	ID string `json:"id"`
+}
+
+type slsaInvocation struct {
+	ConfigSource slsaConfigSource       `json:"configSource"`
+	Parameters   map[string]interface{} `json:"parameters,omitempty"`
+}
+
+type slsaConfigSource struct {
+	URI        string            `json:"uri,omitempty"`
+	Digest     map[string]string `json:"digest,omitempty"`
+	EntryPoint string            `json:"entryPoint,omitempty"`
+}
+
+type slsaMetadata struct {
+	BuildInvocationID string    `json:"buildInvocationId,omitempty"`
+	BuildStartedOn    time.Time `json:"buildStartedOn"`
+	BuildFinishedOn   time.Time `json:"buildFinishedOn"`
+	Reproducible      bool      `json:"reproducible"`
+}
+
+type slsaMaterial struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest"`
+}
+
+// checkCosign fails before anything is built when cosign is not installed
+func checkCosign() error {
+	if _, err := osexec.LookPath("cosign"); err != nil {
+		return fmt.Errorf("cosign is needed for --attest, see https://docs.sigstore.dev/cosign/installation/")
+	}
+	return nil
+}
+
+// attestImage attaches the provenance in predicatePath to an image which is
+// pinned to its digest, with cosign and the keys or keyless identity set in
+// its own environment
+var attestImage = func(ctx context.Context, image, predicatePath string) error {
+	cmd := osexec.CommandContext(ctx, "cosign", "attest", "--yes", "--type", slsaPredicateType, "--predicate", predicatePath, image)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("cosign attest failed: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// functionProvenance describes how publish built a function's image: the
+// hash of its handler and template, and the commit and flags it was built
+// with. dirty records that the working tree had changes which were not in
+// the commit.
+func functionProvenance(function stack.Function, source map[string]string, dirty bool, started, finished time.Time) (slsaProvenance, error) {
+	handlerDigest, err := templateChecksum(function.Handler)
+	if err != nil {
+		return slsaProvenance{}, fmt.Errorf("unable to hash the handler of %s: %s", function.Name, err)
+	}
+	materials := []slsaMaterial{
+		{URI: "file:" + filepath.ToSlash(function.Handler), Digest: sha256Digest(handlerDigest)},
+	}
+
+	if stack.IsValidTemplate(function.Language) {
+		language, _ := stack.SplitLanguage(function.Language)
+		templateDir := filepath.Join("template", language)
+		templateDigest, err := templateChecksum(templateDir)
+		if err != nil {
+			return slsaProvenance{}, fmt.Errorf("unable to hash the template of %s: %s", function.Name, err)
+		}
+		materials = append(materials, slsaMaterial{URI: "file:" + filepath.ToSlash(templateDir), Digest: sha256Digest(templateDigest)})
+	}
+
+	configSource := slsaConfigSource{EntryPoint: yamlFile}
+	if repo, sha := source[builder.GitRepoAnnotation], source[builder.GitSHAAnnotation]; len(repo) > 0 && len(sha) > 0 {
+		configSource.URI = "git+" + repo
+		configSource.Digest = map[string]string{"sha1": sha}
+		materials = append(materials, slsaMaterial{URI: configSource.URI, Digest: configSource.Digest})
+	}
+
+	parameters := provenanceParameters(function)
+	if dirty {
+		parameters["git_dirty"] = true
+	}
+
+	return slsaProvenance{
+		Builder:   slsaBuilder{ID: faasCLIBuilderID + "@" + version.BuildVersion()},
+		BuildType: publishBuildType,
+		Invocation: slsaInvocation{
+			ConfigSource: configSource,
+			Parameters:   parameters,
+		},
+		Metadata: slsaMetadata{
+			BuildInvocationID: source[builder.CIBuildURLAnnotation],
+			BuildStartedOn:    started.UTC(),
+			BuildFinishedOn:   finished.UTC(),
+			Reproducible:      builder.Reproducible,
+		},
+		Materials: materials,
+	}, nil
+}
+
+// provenanceParameters are the flags and stack.yml settings which changed
+// the build, build-args which look like credentials are redacted
+func provenanceParameters(function stack.Function) map[string]interface{} {
+	sensitive := sensitiveKeys{pattern: regexp.MustCompile(defaultSensitivePattern)}
+
+	parameters := map[string]interface{}{
+		"function":  function.Name,
+		"lang":      function.Language,
+		"platforms": platforms,
+	}
+	if buildArgs := sensitive.redactEnv(mergeMap(function.BuildArgs, buildArgMap)); len(buildArgs) > 0 {
+		parameters["build_args"] = buildArgs
+	}
+	if options := combineBuildOpts(function.BuildOptions, buildOptions); len(options) > 0 {
+		parameters["build_options"] = options
+	}
+	if len(extraTags) > 0 {
+		parameters["extra_tags"] = extraTags
+	}
+	return parameters
+}
+
+// sha256Digest turns a checksum such as sha256:HEX into an in-toto digest
+func sha256Digest(checksum string) map[string]string {
+	return map[string]string{"sha256": strings.TrimPrefix(checksum, templateChecksumPrefix)}
+}
+
+// attestPublishedImages attaches a provenance attestation to the image of
+// each function which publish pushed, by the digest which the push reported
+func attestPublishedImages(ctx context.Context, services *stack.Services, digests map[string]string, started, finished time.Time) error {
+	source := builder.GetProvenance(nil)
+	dirty := gitWorkingTreeDirty()
+
+	dir, err := ioutil.TempDir("", "faas-cli-attest")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	attested := 0
+	for _, name := range services.FunctionNames() {
+		digest, ok := digests[name]
+		if !ok {
+			continue
+		}
+		function := services.Functions[name]
+		function.Name = name
+
+		imageName, err := builtImageName(function.Image)
+		if err != nil {
+			return err
+		}
+
+		provenance, err := functionProvenance(function, source, dirty, started, finished)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(provenance, "", "  ")
+		if err != nil {
+			return err
+		}
+		predicatePath := filepath.Join(dir, name+".provenance.json")
+		if err := ioutil.WriteFile(predicatePath, data, 0600); err != nil {
+			return err
+		}
+
+		pinned := pinImage(imageName, digest)
+		if err := attestImage(ctx, pinned, predicatePath); err != nil {
+			return fmt.Errorf("[%s] %s", name, err)
+		}
+		fmt.Printf("Attested the provenance of %s\n", pinned)
+		attested++
+	}
+
+	fmt.Printf("Attested the images of %d functions\n", attested)
+	return nil
+}
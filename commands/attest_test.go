@@ -0,0 +1,153 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas-cli/builder"
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/openfaas/faas-cli/test"
+)
+
+func Test_functionProvenance(t *testing.T) {
+	handler, err := ioutil.TempDir("", "faas-cli-attest-handler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(handler)
+	if err := ioutil.WriteFile(filepath.Join(handler, "Dockerfile"), []byte("FROM alpine:3.12\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	function := stack.Function{Name: "api", Language: "dockerfile", Handler: handler}
+	source := map[string]string{
+		builder.GitRepoAnnotation:    "https://github.com/alexellis/api",
+		builder.GitSHAAnnotation:     "7b4c3f1",
+		builder.CIBuildURLAnnotation: "https://github.com/alexellis/api/actions/runs/1",
+	}
+	started := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	finished := started.Add(time.Minute)
+
+	provenance, err := functionProvenance(function, source, true, started, finished)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	checksum, _ := templateChecksum(handler)
+	if len(provenance.Materials) != 2 {
+		t.Fatalf("want 2 materials, got: %v", provenance.Materials)
+	}
+	if got := provenance.Materials[0].Digest["sha256"]; "sha256:"+got != checksum {
+		t.Fatalf("want the handler digest %s, got: %s", checksum, got)
+	}
+	if got := provenance.Materials[1].URI; got != "git+https://github.com/alexellis/api" {
+		t.Fatalf("want the git repository as a material, got: %s", got)
+	}
+	if got := provenance.Invocation.ConfigSource.Digest["sha1"]; got != "7b4c3f1" {
+		t.Fatalf("want the commit 7b4c3f1, got: %s", got)
+	}
+	if got := provenance.Builder.ID; !strings.HasPrefix(got, faasCLIBuilderID+"@") {
+		t.Fatalf("want faas-cli as the builder, got: %s", got)
+	}
+	if got := provenance.Metadata.BuildInvocationID; got != source[builder.CIBuildURLAnnotation] {
+		t.Fatalf("want the CI build as the build invocation, got: %s", got)
+	}
+	if got := provenance.Invocation.Parameters["git_dirty"]; got != true {
+		t.Fatalf("want the dirty working tree recorded, got: %v", got)
+	}
+	if !provenance.Metadata.BuildFinishedOn.Equal(finished) {
+		t.Fatalf("want finished on %s, got: %s", finished, provenance.Metadata.BuildFinishedOn)
+	}
+}
+
+func Test_functionProvenance_missingHandler(t *testing.T) {
+	function := stack.Function{Name: "api", Language: "dockerfile", Handler: "./does-not-exist"}
+
+	_, err := functionProvenance(function, nil, false, time.Now(), time.Now())
+	if err == nil || !strings.Contains(err.Error(), "handler of api") {
+		t.Fatalf("want an error for the handler of api, got: %v", err)
+	}
+}
+
+func Test_provenanceParameters_redactsBuildArgs(t *testing.T) {
+	function := stack.Function{
+		Name:     "api",
+		Language: "go",
+		BuildArgs: map[string]string{
+			"GO111MODULE": "on",
+			"NPM_TOKEN":   "s3cr3t",
+		},
+	}
+
+	parameters := provenanceParameters(function)
+	buildArgs, ok := parameters["build_args"].(map[string]string)
+	if !ok {
+		t.Fatalf("want build_args, got: %v", parameters)
+	}
+	if got := buildArgs["GO111MODULE"]; got != "on" {
+		t.Fatalf("want GO111MODULE=on, got: %s", got)
+	}
+	if got := buildArgs["NPM_TOKEN"]; got != redactedEnvValue {
+		t.Fatalf("want NPM_TOKEN redacted, got: %s", got)
+	}
+}
+
+func Test_attestPublishedImages(t *testing.T) {
+	attested := map[string]slsaProvenance{}
+	defer func(original func(context.Context, string, string) error) { attestImage = original }(attestImage)
+	attestImage = func(ctx context.Context, image, predicatePath string) error {
+		data, err := ioutil.ReadFile(predicatePath)
+		if err != nil {
+			return err
+		}
+		var provenance slsaProvenance
+		if err := json.Unmarshal(data, &provenance); err != nil {
+			return err
+		}
+		attested[image] = provenance
+		return nil
+	}
+
+	handler, err := ioutil.TempDir("", "faas-cli-attest-handler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(handler)
+	if err := ioutil.WriteFile(filepath.Join(handler, "Dockerfile"), []byte("FROM alpine:3.12\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	services := &stack.Services{
+		Functions: map[string]stack.Function{
+			"api":   {Image: "alexellis/api:0.1", Language: "dockerfile", Handler: handler},
+			"nginx": {Image: "nginx:latest"},
+		},
+	}
+
+	test.CaptureStdout(func() {
+		err = attestPublishedImages(context.Background(), services, map[string]string{"api": testDigest}, time.Now(), time.Now())
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(attested) != 1 {
+		t.Fatalf("want only the built image attested, got: %v", attested)
+	}
+	provenance, ok := attested["alexellis/api:0.1@"+testDigest]
+	if !ok {
+		t.Fatalf("want the image pinned to its digest, got: %v", attested)
+	}
+	if got := provenance.Invocation.Parameters["function"]; got != "api" {
+		t.Fatalf("want the function api in the parameters, got: %v", got)
+	}
+}
@@ -4,11 +4,8 @@
 package commands
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
@@ -18,6 +15,7 @@ import (
 	"time"
 
 	"github.com/openfaas/faas-cli/config"
+	"github.com/openfaas/faas-cli/proxy"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
@@ -32,6 +30,7 @@ var (
 	grant         string
 	clientSecret  string
 	redirectHost  string
+	refreshToken  string
 )
 
 func init() {
@@ -44,8 +43,10 @@ func init() {
 	authCmd.Flags().StringVar(&redirectHost, "redirect-host", "http://127.0.0.1", "Host for OAuth2 redirection in the implicit flow including URL scheme")
 
 	authCmd.Flags().StringVar(&scope, "scope", "openid profile", "scope for OAuth2 flow - i.e. \"openid profile\"")
-	authCmd.Flags().StringVar(&grant, "grant", "implicit", "grant for OAuth2 flow - either implicit, implicit-id or client_credentials")
-	authCmd.Flags().StringVar(&clientSecret, "client-secret", "", "OAuth2 client_secret, for use with client_credentials grant")
+	authCmd.Flags().StringVar(&grant, "grant", "implicit", "grant for OAuth2 flow - either implicit, implicit-id, client_credentials or refresh_token")
+	authCmd.Flags().StringVar(&clientSecret, "client-secret", "", "OAuth2 client_secret, for use with client_credentials grant, or refresh_token grant for a confidential client")
+	authCmd.Flags().StringVar(&refreshToken, "refresh-token", "", "OAuth2 refresh_token issued by your IdP, for use with refresh_token grant")
+	authCmd.Flags().BoolVar(&insecureCredentialStore, "insecure-credential-store", false, "Store the token unencrypted in the config file instead of the keyring of the OS")
 
 	faasCmd.AddCommand(authCmd)
 }
@@ -55,11 +56,13 @@ var authCmd = &cobra.Command{
   [--audience AUDIENCE]
   [--launch-browser LAUNCH_BROWSER]
   [--client-secret]
+  [--refresh-token]
   [--grant GRANT]`,
 	Short: "Obtain a token for your OpenFaaS gateway",
 	Long:  "Authenticate to an OpenFaaS gateway using OAuth2.",
 	Example: `  faas-cli auth --client-id my-id --auth-url https://tenant.auth0.com/authorize --scope "oidc profile" --audience my-id
-  faas-cli auth --grant=client_credentials --client-id=id --client-secret=secret --auth-url=https://tenant.auth0.com/token`,
+  faas-cli auth --grant=client_credentials --client-id=id --client-secret=secret --auth-url=https://tenant.auth0.com/token
+  faas-cli auth --grant=refresh_token --client-id=id --refresh-token=token --auth-url=https://tenant.auth0.com/oauth/token`,
 	RunE:    runAuth,
 	PreRunE: preRunAuth,
 }
@@ -96,8 +99,17 @@ func runAuth(cmd *cobra.Command, args []string) error {
 		return authImplicit("token")
 	} else if grant == "implicit-id" {
 		return authImplicit("id_token")
-	} else if grant == "client_credentials" {
-		return authClientCredentials()
+	} else if grant == "client_credentials" || grant == config.RefreshTokenGrant {
+		// The default scope is for a user's first login, only send one which
+		// was given
+		clientScope := ""
+		if cmd.Flags().Changed("scope") {
+			clientScope = scope
+		}
+		if grant == config.RefreshTokenGrant {
+			return authRefreshToken(clientScope)
+		}
+		return authClientCredentials(clientScope)
 	}
 	return nil
 }
@@ -178,48 +190,75 @@ func makeRedirectURI(host string, port int) (*url.URL, error) {
 	return res, err
 }
 
-func authClientCredentials() error {
+func authClientCredentials(scope string) error {
+	if len(clientSecret) == 0 {
+		return fmt.Errorf("--client-secret is required for the client_credentials grant")
+	}
 
-	body := ClientCredentialsReq{
-		ClientID:     clientID,
-		ClientSecret: clientSecret,
-		Audience:     audience,
-		GrantType:    grant,
+	auth := config.AuthConfig{
+		Gateway: gateway,
+		Auth:    config.Oauth2AuthType,
+		ClientCredentials: &config.ClientCredentials{
+			TokenURL:     authURL,
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Audience:     audience,
+			Scope:        scope,
+		},
 	}
 
-	bodyBytes, marshalErr := json.Marshal(body)
-	if marshalErr != nil {
-		return errors.Wrapf(marshalErr, "unable to unmarshal %s", string(bodyBytes))
+	token, err := proxy.RequestClientCredentialsToken(http.DefaultClient, *auth.ClientCredentials)
+	if err != nil {
+		return err
 	}
+	return saveRefreshableToken(auth, token, "client credentials")
+}
 
-	buf := bytes.NewBuffer(bodyBytes)
-	req, _ := http.NewRequest(http.MethodPost, authURL, buf)
-	req.Header.Set("Content-Type", "application/json")
-	res, err := http.DefaultClient.Do(req)
+// authRefreshToken exchanges a refresh token which the IdP issued for a user,
+// i.e. with the offline_access scope, for a token. The refresh token is saved
+// so that the token can be refreshed when it expires, as the implicit grants
+// do not issue one.
+func authRefreshToken(scope string) error {
+	if len(refreshToken) == 0 {
+		return fmt.Errorf("--refresh-token is required for the refresh_token grant")
+	}
 
-	if err != nil {
-		return errors.Wrap(err, fmt.Sprintf("cannot POST to %s", authURL))
+	auth := config.AuthConfig{
+		Gateway: gateway,
+		Auth:    config.Oauth2AuthType,
+		ClientCredentials: &config.ClientCredentials{
+			TokenURL:     authURL,
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Scope:        scope,
+			Grant:        config.RefreshTokenGrant,
+			RefreshToken: refreshToken,
+		},
 	}
-	if res.Body != nil {
-		defer res.Body.Close()
 
-		tokenData, _ := ioutil.ReadAll(res.Body)
+	token, err := proxy.RequestRefreshedToken(http.DefaultClient, *auth.ClientCredentials)
+	if err != nil {
+		return err
+	}
+	if len(token.RefreshToken) > 0 {
+		auth.ClientCredentials.RefreshToken = token.RefreshToken
+	}
+	return saveRefreshableToken(auth, token, "refresh token")
+}
 
-		if res.StatusCode != http.StatusOK {
-			return fmt.Errorf("cannot authenticate, code: %d.\nResponse: %s", res.StatusCode, string(tokenData))
-		}
-		token := ClientCredentialsToken{}
-		tokenErr := json.Unmarshal(tokenData, &token)
-		if tokenErr != nil {
-			return errors.Wrapf(tokenErr, "unable to unmarshal token: %s", string(tokenData))
-		}
+// saveRefreshableToken saves a token along with what refreshes it
+func saveRefreshableToken(auth config.AuthConfig, token proxy.ClientCredentialsToken, refreshedWith string) error {
+	auth.Token = token.AccessToken
+	auth.ExpiresAt = token.ExpiresAt(time.Now())
 
-		if err := config.UpdateAuthConfig(gateway, token.AccessToken, config.Oauth2AuthType); err != nil {
-			return err
-		}
-		fmt.Println("credentials saved for", gateway)
-		printExampleTokenUsage(gateway, token.AccessToken)
+	if err := saveClientCredentialsAuth(auth); err != nil {
+		return err
 	}
+	fmt.Println("credentials saved for", gateway)
+	if auth.ExpiresAt > 0 {
+		fmt.Printf("The token expires in %s, it is refreshed with the %s when it does\n", time.Duration(token.ExpiresIn)*time.Second, refreshedWith)
+	}
+	printExampleTokenUsage(gateway, token.AccessToken)
 
 	return nil
 }
@@ -268,7 +307,7 @@ func makeCallbackHandler(cancel context.CancelFunc) func(w http.ResponseWriter,
 			key := "id_token"
 			if token := q.Get(key); len(token) > 0 {
 
-				if err := config.UpdateAuthConfig(gateway, token, config.Oauth2AuthType); err != nil {
+				if err := saveAuthConfig(gateway, token, config.Oauth2AuthType); err != nil {
 					fmt.Printf("error while saving authentication token: %s", err.Error())
 				}
 				fmt.Println("credentials saved for", gateway)
@@ -309,17 +348,3 @@ func buildCaptureFragment() string {
 </body>
 </html>`
 }
-
-type ClientCredentialsReq struct {
-	ClientID     string `json:"client_id"`
-	ClientSecret string `json:"client_secret"`
-	Audience     string `json:"audience"`
-	GrantType    string `json:"grant_type"`
-}
-
-type ClientCredentialsToken struct {
-	AccessToken string `json:"access_token"`
-	Scope       string `json:"scope"`
-	ExpiresIn   int    `json:"expires_in"`
-	TokenType   string `json:"token_type"`
-}
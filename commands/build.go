@@ -7,12 +7,15 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/morikuni/aec"
 	"github.com/openfaas/faas-cli/builder"
+	"github.com/openfaas/faas-cli/flags"
+	"github.com/openfaas/faas-cli/i18n"
+	"github.com/openfaas/faas-cli/output"
 	"github.com/openfaas/faas-cli/schema"
 	"github.com/openfaas/faas-cli/stack"
 	"github.com/openfaas/faas-cli/versioncontrol"
@@ -25,6 +28,8 @@ var (
 	squash           bool
 	parallel         int
 	shrinkwrap       bool
+	keepBuildDir     bool
+	logDir           string
 	buildArgs        []string
 	buildArgMap      map[string]string
 	buildOptions     []string
@@ -35,8 +40,23 @@ var (
 	envsubst         bool
 	quietBuild       bool
 	disableStackPull bool
+	cacheFrom        []string
+	cacheTo          []string
+	analyzeBuild     bool
+	maxImageSize     string
+	maxImageBytes    int64
+	requireCleanGit  bool
+	reproducible     bool
 )
 
+// gitDirtyLabel is added to the images which are built from a working tree
+// with changes which are not committed
+const gitDirtyLabel = "dirty"
+
+// gitWorkingTreeDirty reports whether there are changes which are not
+// committed, it is replaced in tests
+var gitWorkingTreeDirty = versioncontrol.GetGitDirty
+
 func init() {
 	// Setup flags that are used by multiple commands (variables defined in faas.go)
 	buildCmd.Flags().StringVar(&image, "image", "", "Docker image name to build")
@@ -49,14 +69,31 @@ func init() {
 	buildCmd.Flags().BoolVar(&squash, "squash", false, `Use Docker's squash flag for smaller images [experimental] `)
 	buildCmd.Flags().IntVar(&parallel, "parallel", 1, "Build in parallel to depth specified.")
 	buildCmd.Flags().BoolVar(&shrinkwrap, "shrinkwrap", false, "Just write files to ./build/ folder for shrink-wrapping")
+	buildCmd.Flags().StringVar(&logDir, "log-dir", "", "Write the output of the container engine for each function to a file in this folder, i.e. ./logs")
+	buildCmd.Flags().BoolVar(&keepBuildDir, "keep-build-dir", false, "Keep the build folder of each function under ./build/ after it is built")
 	buildCmd.Flags().StringArrayVarP(&buildArgs, "build-arg", "b", []string{}, "Add a build-arg for Docker (KEY=VALUE)")
 	buildCmd.Flags().StringArrayVarP(&buildOptions, "build-option", "o", []string{}, "Set a build option, e.g. dev")
 	buildCmd.Flags().Var(&tagFormat, "tag", "Override latest tag on function Docker image, accepts 'latest', 'sha', 'branch', or 'describe'")
 	buildCmd.Flags().StringArrayVar(&buildLabels, "build-label", []string{}, "Add a label for Docker image (LABEL=VALUE)")
 	buildCmd.Flags().StringArrayVar(&copyExtra, "copy-extra", []string{}, "Extra paths that will be copied into the function build context")
 	buildCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in stack.yml file")
-	buildCmd.Flags().BoolVar(&quietBuild, "quiet", false, "Perform a quiet build, without showing output from Docker")
+	buildCmd.Flags().BoolVarP(&quietBuild, "quiet", "q", false, "Perform a quiet build, printing only the name of each image which was built")
 	buildCmd.Flags().BoolVar(&disableStackPull, "disable-stack-pull", false, "Disables the template configuration in the stack.yml")
+	buildCmd.Flags().StringArrayVar(&cacheFrom, "cache-from", []string{}, "External cache source for the build, {{ name }} is the name of the function, e.g. type=registry,ref=user/{{ name }}:cache")
+	buildCmd.Flags().BoolVar(&analyzeBuild, "analyze", false, "Report the size and largest layers of each image after it is built")
+	buildCmd.Flags().StringVar(&maxImageSize, "max-image-size", "", "Warn when an analyzed image is larger than this size, e.g. 250MB")
+	buildCmd.Flags().StringSliceVar(&skipFunctions, "skip", []string{}, "Names of functions in the stack file to leave out, i.e. fn1,fn2")
+	buildCmd.Flags().StringSliceVar(&selectTags, "tags", []string{}, "Only use the functions in the stack file which have one of these tags, i.e. frontend,critical")
+	buildCmd.Flags().BoolVar(&profileTimings, "profile", false, "Print the time taken to pull templates, build, push and deploy each function at the end")
+	buildCmd.Flags().StringVar(&junitReport, "junit", "", "Write the result of each function to this file as a JUnit XML report, i.e. report.xml")
+	buildCmd.Flags().BoolVar(&annotateCI, "annotate-ci", false, "Annotate the functions which failed in stack.yml when running in GitHub Actions or GitLab CI")
+	buildCmd.Flags().StringVar(&containerEngine, "container-engine", "", "Build and push with docker, podman or nerdctl, the first which is installed is used by default")
+	buildCmd.Flags().StringVar(&dockerContext, "docker-context", "", "Docker context to use instead of DOCKER_HOST, DOCKER_CONTEXT or the current context, i.e. a remote daemon")
+	buildCmd.Flags().StringVar(&remoteBuilder, "remote", "", "URL of a builder service, i.e. the OpenFaaS Pro builder, which builds and pushes the images without a local container engine")
+	buildCmd.Flags().StringVar(&payloadSecretPath, "payload-secret", "", "File with the secret which signs the requests to the builder given with --remote")
+	buildCmd.Flags().BoolVar(&reproducible, "reproducible", false, "Build images which are the same for the same inputs, using SOURCE_DATE_EPOCH or the time of the latest Git commit for every timestamp")
+	buildCmd.Flags().BoolVar(&requireCleanGit, "require-clean-git", false, "Fail when the Git working tree has changes which are not committed, instead of warning and labelling the images dirty=true")
+	buildCmd.Flags().StringArrayVar(&cacheTo, "cache-to", []string{}, "Cache export destination for the build, requires Docker buildx, {{ name }} is the name of the function, e.g. type=registry,ref=user/{{ name }}:cache,mode=max")
 
 	// Set bash-completion.
 	_ = buildCmd.Flags().SetAnnotation("handler", cobra.BashCompSubdirsInDir, []string{})
@@ -78,6 +115,8 @@ var buildCmd = &cobra.Command{
                  [--build-arg KEY=VALUE]
                  [--build-option VALUE]
                  [--copy-extra PATH]
+                 [--cache-from CACHE] [--cache-to CACHE]
+                 [--analyze] [--max-image-size SIZE]
                  [--tag <sha|branch|describe>]`,
 	Short: "Builds OpenFaaS function containers",
 	Long: `Builds OpenFaaS function containers either via the supplied YAML config using
@@ -93,7 +132,15 @@ via flags.`,
   faas-cli build -f ./stack.yml --regex "fn[0-9]_.*"
   faas-cli build --image=my_image --lang=python --handler=/path/to/fn/
                  --name=my_fn --squash
-  faas-cli build -f ./stack.yml --build-label org.label-schema.label-name="value"`,
+  faas-cli build -f ./stack.yml --build-label org.label-schema.label-name="value"
+  faas-cli build -f ./stack.yml --cache-from type=registry,ref=user/fn:cache
+                 --cache-to type=registry,ref=user/fn:cache,mode=max
+  faas-cli build -f ./stack.yml --analyze --max-image-size 250MB
+  faas-cli build -f ./stack.yml --tag sha --require-clean-git
+  SOURCE_DATE_EPOCH=1602806400 faas-cli build -f ./stack.yml --reproducible
+  faas-cli build -f ./stack.yml --remote https://builder.example.com
+                 --payload-secret ./payload.txt
+  docker save $(faas-cli build -f ./stack.yml -q) -o functions.tar`,
 	PreRunE: preRunBuild,
 	RunE:    runBuild,
 }
@@ -109,14 +156,57 @@ func preRunBuild(cmd *cobra.Command, args []string) error {
 	}
 
 	buildLabelMap, err = parseMap(buildLabels, "build-label")
+	if err != nil {
+		return err
+	}
+
+	if buildLabelMap, err = checkCleanGit(requireCleanGit, buildLabelMap); err != nil {
+		return err
+	}
 
 	if parallel < 1 {
 		return fmt.Errorf("the --parallel flag must be great than 0")
 	}
 
+	var sizeErr error
+	if maxImageBytes, sizeErr = parseByteSize(maxImageSize); sizeErr != nil {
+		return sizeErr
+	}
+
 	return err
 }
 
+// checkCleanGit fails with --require-clean-git when the working tree has
+// changes which are not committed, otherwise it warns and labels the images
+// so that they can be told apart from those built from a commit
+func checkCleanGit(require bool, labels map[string]string) (map[string]string, error) {
+	if !gitWorkingTreeDirty() {
+		return labels, nil
+	}
+	if require {
+		return nil, fmt.Errorf("the Git working tree has changes which are not committed, commit or stash them, or build without --require-clean-git")
+	}
+
+	fmt.Fprintln(os.Stderr, output.Warning("The Git working tree has changes which are not committed, the images are labelled %s=true", gitDirtyLabel))
+	return mergeMap(labels, map[string]string{gitDirtyLabel: "true"}), nil
+}
+
+// setReproducible configures the builder for --reproducible, the time used
+// for every timestamp is resolved once for all of the functions
+func setReproducible(enabled bool) error {
+	builder.Reproducible = enabled
+	if !enabled {
+		return nil
+	}
+
+	epoch, err := builder.ResolveSourceDateEpoch()
+	if err != nil {
+		return err
+	}
+	builder.SourceDateEpoch = epoch
+	return nil
+}
+
 func parseBuildArgs(args []string) (map[string]string, error) {
 	mapped := make(map[string]string)
 
@@ -148,36 +238,52 @@ func parseBuildArgs(args []string) (map[string]string, error) {
 	return mapped, nil
 }
 
-func runBuild(cmd *cobra.Command, args []string) error {
+func buildFunctions(cmd *cobra.Command, args []string) ([]string, error) {
+	builder.KeepBuildDir = keepBuildDir
+	builder.LogDir = logDir
+	if err := setReproducible(reproducible); err != nil {
+		return nil, err
+	}
 
 	var services stack.Services
 	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter, envsubst)
+		parsedServices, err := parseStackFile(yamlFile, envsubst)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if parsedServices != nil {
 			services = *parsedServices
 		}
+
+		if err := selectTagged(&services, selectTags); err != nil {
+			return nil, err
+		}
 	}
 
 	templateAddress := getTemplateURL("", os.Getenv(templateURLEnvironment), DefaultTemplateRepository)
 	if pullErr := PullTemplates(templateAddress); pullErr != nil {
-		return fmt.Errorf("could not pull templates for OpenFaaS: %v", pullErr)
+		return nil, i18n.Errorf(i18n.BuildPullTemplatesError, pullErr)
 	}
 
 	if len(services.Functions) == 0 {
 		if len(image) == 0 {
-			return fmt.Errorf("please provide a valid --image name for your Docker image")
+			return nil, i18n.Errorf(i18n.BuildMissingImage)
 		}
 		if len(handler) == 0 {
-			return fmt.Errorf("please provide the full path to your function's handler")
+			return nil, i18n.Errorf(i18n.BuildMissingHandler)
 		}
 		if len(functionName) == 0 {
-			return fmt.Errorf("please provide the deployed --name of your function")
+			return nil, i18n.Errorf(i18n.BuildMissingName)
+		}
+		if !shrinkwrap {
+			if err := checkBuilder(quietBuild); err != nil {
+				return nil, err
+			}
 		}
-		err := builder.BuildImage(image,
+		ctx, cancel := interruptContext()
+		done := timings.trackResult(phaseBuild, functionName)
+		err := builder.BuildImage(ctx, image,
 			handler,
 			functionName,
 			language,
@@ -190,40 +296,104 @@ func runBuild(cmd *cobra.Command, args []string) error {
 			buildLabelMap,
 			quietBuild,
 			copyExtra,
+			functionCacheRefs(nil, cacheFrom, functionName),
+			functionCacheRefs(nil, cacheTo, functionName),
 		)
+		done(err)
+		cancel()
 		if err != nil {
-			return err
+			return nil, err
 		}
-		return nil
+
+		if shrinkwrap {
+			return nil, nil
+		}
+
+		if analyzeBuild && len(remoteBuilder) == 0 {
+			analyzeBuiltImage(output.Warnings(quietBuild), image, language, maxImageBytes)
+		}
+
+		imageName, err := builtImageName(image)
+		if err != nil {
+			return nil, err
+		}
+		return []string{imageName}, nil
 	}
 
 	if len(services.StackConfiguration.TemplateConfigs) != 0 && !disableStackPull {
 		newTemplateInfos, err := filterExistingTemplates(services.StackConfiguration.TemplateConfigs, "./template")
 		if err != nil {
-			return fmt.Errorf("Already pulled templates directory has issue: %s", err.Error())
+			return nil, fmt.Errorf("Already pulled templates directory has issue: %s", err.Error())
 		}
 
-		err = pullStackTemplates(newTemplateInfos, cmd)
+		err = pullStackTemplates(output.Progress(quietBuild), newTemplateInfos)
 		if err != nil {
-			return fmt.Errorf("could not pull templates from function yaml file: %s", err.Error())
+			return nil, fmt.Errorf("could not pull templates from function yaml file: %s", err.Error())
+		}
+	}
+
+	if !shrinkwrap && needsContainerEngine(&services, false) {
+		if err := checkBuilder(quietBuild); err != nil {
+			return nil, err
 		}
 	}
 
-	errors := build(&services, parallel, shrinkwrap, quietBuild)
+	warnUnknownSkips(output.Warnings(quietBuild), &services, skipFunctions)
+	if err := checkSharedCacheTo(&services, cacheTo); err != nil {
+		return nil, err
+	}
+	images, errors := build(&services, parallel, shrinkwrap, quietBuild)
 	if len(errors) > 0 {
-		errorSummary := "Errors received during build:\n"
+		errorSummary := i18n.T(i18n.BuildErrorSummary)
 		for _, err := range errors {
 			errorSummary = errorSummary + "- " + err.Error() + "\n"
 		}
-		return fmt.Errorf("%s", aec.Apply(errorSummary, aec.RedF))
+		return images, fmt.Errorf("%s", errorSummary)
 	}
-	return nil
+	return images, nil
+}
+
+func runBuild(cmd *cobra.Command, args []string) error {
+	// up reports the timings once it has pushed and deployed too
+	if cmd.Name() == "build" {
+		defer reportProfile(os.Stderr)
+		defer reportResults()
+	}
+
+	images, err := buildFunctions(cmd, args)
+	if quietBuild {
+		for _, image := range images {
+			fmt.Println(image)
+		}
+	}
+	return err
 }
 
-func build(services *stack.Services, queueDepth int, shrinkwrap, quietBuild bool) []error {
+// build builds the functions in parallel and returns the names of the images
+// which were built, sorted by name. On Ctrl+C the builds which are running
+// are stopped, no more are started and what was built is printed. With
+// quietBuild the progress is left out and warnings are printed to stderr.
+func build(services *stack.Services, queueDepth int, shrinkwrap, quietBuild bool) ([]string, []error) {
 	startOuter := time.Now()
+	progress := output.Progress(quietBuild)
+	warnings := output.Warnings(quietBuild)
 
 	errors := []error{}
+	images := []string{}
+	built := map[string]bool{}
+	var mu sync.Mutex
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	finished := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			fmt.Fprint(warnings, i18n.T(i18n.BuildInterrupted))
+		case <-finished:
+		}
+	}()
 
 	wg := sync.WaitGroup{}
 
@@ -235,14 +405,17 @@ func build(services *stack.Services, queueDepth int, shrinkwrap, quietBuild bool
 			for function := range workChannel {
 				start := time.Now()
 
-				fmt.Printf(aec.YellowF.Apply("[%d] > Building %s.\n"), index, function.Name)
+				fmt.Fprint(progress, output.Info("%s", i18n.T(i18n.BuildStarted, index, function.Name)))
 				if len(function.Language) == 0 {
-					fmt.Println("Please provide a valid language for your function.")
+					fmt.Fprintln(warnings, i18n.T(i18n.BuildMissingLanguage))
 				} else {
 					combinedBuildOptions := combineBuildOpts(function.BuildOptions, buildOptions)
 					combinedBuildArgMap := mergeMap(function.BuildArgs, buildArgMap)
 					combinedExtraPaths := mergeSlice(services.StackConfiguration.CopyExtraPaths, copyExtra)
-					err := builder.BuildImage(function.Image,
+					combinedCacheFrom := functionCacheRefs(function.CacheFrom, cacheFrom, function.Name)
+					combinedCacheTo := functionCacheRefs(function.CacheTo, cacheTo, function.Name)
+					done := timings.trackResult(phaseBuild, function.Name)
+					err := builder.BuildImage(ctx, function.Image,
 						function.Handler,
 						function.Name,
 						function.Language,
@@ -255,39 +428,79 @@ func build(services *stack.Services, queueDepth int, shrinkwrap, quietBuild bool
 						buildLabelMap,
 						quietBuild,
 						combinedExtraPaths,
+						combinedCacheFrom,
+						combinedCacheTo,
 					)
+					done(err)
 
+					mu.Lock()
 					if err != nil {
 						errors = append(errors, err)
+					} else {
+						built[function.Name] = true
+					}
+					if err == nil && !shrinkwrap {
+						if imageName, nameErr := builtImageName(function.Image); nameErr == nil {
+							images = append(images, imageName)
+						}
+					}
+					mu.Unlock()
+
+					if err == nil && analyzeBuild && !shrinkwrap && len(remoteBuilder) == 0 {
+						analyzeBuiltImage(warnings, function.Image, function.Language, maxImageBytes)
 					}
 				}
 
 				duration := time.Since(start)
-				fmt.Printf(aec.YellowF.Apply("[%d] < Building %s done in %1.2fs.\n"), index, function.Name, duration.Seconds())
+				fmt.Fprint(progress, output.Info("%s", i18n.T(i18n.BuildFinished, index, function.Name, duration.Seconds())))
 			}
 
-			fmt.Printf(aec.YellowF.Apply("[%d] Worker done.\n"), index)
+			fmt.Fprint(progress, output.Info("%s", i18n.T(i18n.BuildWorkerDone, index)))
 			wg.Done()
 		}(i)
 
 	}
 
-	for k, function := range services.Functions {
-		if function.SkipBuild {
-			fmt.Printf("Skipping build of: %s.\n", function.Name)
+	queued := []string{}
+	for _, k := range services.FunctionNames() {
+		function := services.Functions[k]
+		if function.SkipBuild || skipped(skipFunctions, k) {
+			fmt.Fprint(progress, i18n.T(i18n.BuildSkipping, k))
+		} else if function.Prebuilt() {
+			fmt.Fprint(progress, i18n.T(i18n.BuildSkippingPrebuilt, k, function.Image))
 		} else {
 			function.Name = k
-			workChannel <- function
+			queued = append(queued, k)
+			select {
+			case workChannel <- function:
+			case <-ctx.Done():
+			}
 		}
 	}
 
 	close(workChannel)
 
 	wg.Wait()
+	close(finished)
+
+	if ctx.Err() != nil {
+		completed, notBuilt := []string{}, []string{}
+		for _, name := range queued {
+			if built[name] {
+				completed = append(completed, name)
+			} else {
+				notBuilt = append(notBuilt, name)
+			}
+		}
+		fmt.Fprint(warnings, i18n.T(i18n.BuildInterruptedSummary, listOrNone(completed), listOrNone(notBuilt)))
+		errors = append(errors, fmt.Errorf("the build was interrupted"))
+	}
 
 	duration := time.Since(startOuter)
-	fmt.Printf("\n%s\n", aec.Apply(fmt.Sprintf("Total build time: %1.2fs", duration.Seconds()), aec.YellowF))
-	return errors
+	fmt.Fprintf(progress, "\n%s\n", output.Info("%s", i18n.T(i18n.BuildTotalTime, duration.Seconds())))
+
+	sort.Strings(images)
+	return images, errors
 }
 
 // PullTemplates pulls templates from specified git remote. templateURL may be a pinned repository.
@@ -298,7 +511,8 @@ func PullTemplates(templateURL string) error {
 		log.Println("No templates found in current directory.")
 
 		templateURL, refName := versioncontrol.ParsePinnedRemote(templateURL)
-		err = fetchTemplates(templateURL, refName, false)
+		defer timings.track(phaseTemplates, templateURL)()
+		err = fetchTemplates(templateURL, refName, flags.Overwrite{}, false, templateVerification{})
 		if err != nil {
 			log.Println("Unable to download templates from Github.")
 			return err
@@ -312,3 +526,11 @@ func combineBuildOpts(YAMLBuildOpts []string, buildFlagBuildOpts []string) []str
 	return mergeSlice(YAMLBuildOpts, buildFlagBuildOpts)
 
 }
+
+// listOrNone joins the names for a summary, or prints none when empty
+func listOrNone(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,119 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/openfaas/faas-cli/builder"
+	"github.com/openfaas/faas-cli/schema"
+	"github.com/openfaas/faas-cli/stack"
+)
+
+const largestLayersShown = 5
+
+var byteSizeUnits = []struct {
+	Suffix     string
+	Multiplier int64
+}{
+	{"GB", 1000 * 1000 * 1000},
+	{"MB", 1000 * 1000},
+	{"KB", 1000},
+	{"B", 1},
+}
+
+// analyzeBuiltImage prints the size report for a function's image after it has
+// been built, a failed analysis is a warning as the build itself succeeded
+func analyzeBuiltImage(w io.Writer, image, language string, maxSize int64) {
+	imageName, err := builtImageName(image)
+	if err != nil {
+		fmt.Fprintf(w, "Warning: unable to analyze %s: %s\n", image, err)
+		return
+	}
+
+	var handlerFolder string
+	templateName, _ := stack.SplitLanguage(language)
+	if langTemplate, err := stack.LoadLanguageTemplate(templateName); err == nil && langTemplate != nil {
+		handlerFolder = langTemplate.HandlerFolder
+	} else if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(w, "Warning: unable to read the %s template of %s: %s\n", templateName, image, err)
+	}
+
+	analysis, err := builder.AnalyzeImage(imageName, handlerFolder)
+	if err != nil {
+		fmt.Fprintf(w, "Warning: unable to analyze %s: %s\n", imageName, err)
+		return
+	}
+
+	fmt.Fprint(w, formatImageAnalysis(*analysis, maxSize))
+}
+
+func formatImageAnalysis(analysis builder.ImageAnalysis, maxSize int64) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Image: %s size: %s (template: %s, handler: %s)\n", analysis.Image,
+		formatByteSize(analysis.Size), formatByteSize(analysis.TemplateSize), formatByteSize(analysis.HandlerSize))
+
+	sb.WriteString("Largest layers:\n")
+	for _, layer := range analysis.LargestLayers(largestLayersShown) {
+		createdBy := layer.CreatedBy
+		if len(createdBy) > 80 {
+			createdBy = createdBy[:77] + "..."
+		}
+		fmt.Fprintf(&sb, "  %-10s %s\n", formatByteSize(layer.Size), createdBy)
+	}
+
+	if maxSize > 0 && analysis.Size > maxSize {
+		fmt.Fprintf(&sb, "Warning: %s is %s, which exceeds --max-image-size of %s\n",
+			analysis.Image, formatByteSize(analysis.Size), formatByteSize(maxSize))
+	}
+
+	return sb.String()
+}
+
+// builtImageName is the name and tag given to an image by builder.BuildImage
+func builtImageName(image string) (string, error) {
+	branch, version, err := builder.GetImageTagValues(tagFormat)
+	if err != nil {
+		return "", err
+	}
+	return schema.BuildImageName(tagFormat, image, version, branch), nil
+}
+
+// parseByteSize parses sizes such as 250MB or 1.5GB, a plain number is in bytes
+func parseByteSize(value string) (int64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if len(value) == 0 {
+		return 0, nil
+	}
+
+	for _, unit := range byteSizeUnits {
+		if strings.HasSuffix(value, unit.Suffix) {
+			number, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(value, unit.Suffix)), 64)
+			if err != nil || number < 0 {
+				return 0, fmt.Errorf("invalid size %q, use a value such as 250MB", value)
+			}
+			return int64(number * float64(unit.Multiplier)), nil
+		}
+	}
+
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size %q, use a value such as 250MB", value)
+	}
+	return number, nil
+}
+
+func formatByteSize(size int64) string {
+	for _, unit := range byteSizeUnits[:len(byteSizeUnits)-1] {
+		if size >= unit.Multiplier {
+			return fmt.Sprintf("%.1f%s", float64(size)/float64(unit.Multiplier), unit.Suffix)
+		}
+	}
+	return fmt.Sprintf("%dB", size)
+}
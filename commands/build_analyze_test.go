@@ -0,0 +1,74 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/builder"
+)
+
+func Test_parseByteSize(t *testing.T) {
+	testcases := []struct {
+		Input   string
+		Want    int64
+		WantErr bool
+	}{
+		{Input: "", Want: 0},
+		{Input: "1024", Want: 1024},
+		{Input: "250MB", Want: 250000000},
+		{Input: "1.5gb", Want: 1500000000},
+		{Input: "500 KB", Want: 500000},
+		{Input: "lots", WantErr: true},
+		{Input: "-1MB", WantErr: true},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.Input, func(t *testing.T) {
+			got, err := parseByteSize(testcase.Input)
+			if testcase.WantErr {
+				if err == nil {
+					t.Fatalf("want error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("want no error, but got: %s", err)
+			}
+			if got != testcase.Want {
+				t.Fatalf("want %d, but got %d", testcase.Want, got)
+			}
+		})
+	}
+}
+
+func Test_formatImageAnalysis_WarnsAboveMaxSize(t *testing.T) {
+	analysis := builder.ImageAnalysis{
+		Image:        "alexellis/figlet:latest",
+		Size:         300000000,
+		TemplateSize: 299000000,
+		HandlerSize:  1000000,
+		Layers: []builder.ImageLayer{
+			{CreatedBy: "COPY function/ .", Size: 1000000},
+			{CreatedBy: "/bin/sh -c apk add figlet", Size: 299000000},
+		},
+	}
+
+	got := formatImageAnalysis(analysis, 250000000)
+
+	want := `Image: alexellis/figlet:latest size: 300.0MB (template: 299.0MB, handler: 1.0MB)
+Largest layers:
+  299.0MB    /bin/sh -c apk add figlet
+  1.0MB      COPY function/ .
+Warning: alexellis/figlet:latest is 300.0MB, which exceeds --max-image-size of 250.0MB
+`
+	if got != want {
+		t.Fatalf("want:\n%s\nbut got:\n%s", want, got)
+	}
+
+	if strings.Contains(formatImageAnalysis(analysis, 0), "Warning") {
+		t.Fatalf("want no warning without --max-image-size")
+	}
+}
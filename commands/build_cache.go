@@ -0,0 +1,52 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+// functionCacheRefs merges the cache refs of a function with those given as
+// flags, where {{ name }} is the name of the function so that each function
+// of a stack can have its own cache
+func functionCacheRefs(refs, flagRefs []string, functionName string) []string {
+	expanded := []string{}
+	for _, ref := range flagRefs {
+		expanded = append(expanded, envTemplatePattern.ReplaceAllStringFunc(ref, func(match string) string {
+			if envTemplatePattern.FindStringSubmatch(match)[1] == "name" {
+				return functionName
+			}
+			return match
+		}))
+	}
+	return mergeSlice(refs, expanded)
+}
+
+// checkSharedCacheTo fails when a --cache-to ref without {{ name }} would be
+// exported by more than one function, as each build would overwrite the
+// cache of the one before it
+func checkSharedCacheTo(services *stack.Services, cacheTo []string) error {
+	built := 0
+	for name, function := range services.Functions {
+		if !function.SkipBuild && !skipped(skipFunctions, name) && !function.Prebuilt() {
+			built++
+		}
+	}
+	if built < 2 {
+		return nil
+	}
+
+	for _, ref := range cacheTo {
+		named := false
+		for _, match := range envTemplatePattern.FindAllStringSubmatch(ref, -1) {
+			named = named || match[1] == "name"
+		}
+		if !named {
+			return fmt.Errorf("--cache-to %s would be exported by each of the %d functions, add {{ name }} to give each function its own cache, i.e. ref=user/{{ name }}:cache", ref, built)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+func Test_functionCacheRefs(t *testing.T) {
+	got := functionCacheRefs(
+		[]string{"type=local,src=/tmp/api"},
+		[]string{"type=registry,ref=user/{{ name }}:cache", "type=gha"},
+		"api",
+	)
+	want := []string{"type=registry,ref=user/api:cache", "type=gha", "type=local,src=/tmp/api"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+}
+
+func Test_checkSharedCacheTo(t *testing.T) {
+	services := &stack.Services{
+		Functions: map[string]stack.Function{
+			"api":    {Language: "go"},
+			"worker": {Language: "go"},
+		},
+	}
+
+	err := checkSharedCacheTo(services, []string{"type=registry,ref=user/fn:cache"})
+	if err == nil || !strings.Contains(err.Error(), "would be exported by each of the 2 functions") {
+		t.Fatalf("want an error for a cache ref shared by 2 functions, got: %v", err)
+	}
+
+	if err := checkSharedCacheTo(services, []string{"type=registry,ref=user/{{name}}:cache"}); err != nil {
+		t.Fatalf("want no error when the ref has {{ name }}, got: %s", err)
+	}
+
+	services.Functions["worker"] = stack.Function{Language: "go", SkipBuild: true}
+	if err := checkSharedCacheTo(services, []string{"type=registry,ref=user/fn:cache"}); err != nil {
+		t.Fatalf("want no error when only one function is built, got: %s", err)
+	}
+}
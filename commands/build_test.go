@@ -4,7 +4,10 @@
 package commands
 
 import (
+	"reflect"
 	"testing"
+
+	"github.com/openfaas/faas-cli/versioncontrol"
 )
 
 func Test_build(t *testing.T) {
@@ -91,3 +94,40 @@ func Test_parseBuildArgs_MultipleSeparators(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func Test_checkCleanGit(t *testing.T) {
+	defer func() { gitWorkingTreeDirty = versioncontrol.GetGitDirty }()
+
+	cases := []struct {
+		name    string
+		dirty   bool
+		require bool
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "clean", want: map[string]string{"team": "web"}},
+		{name: "clean with --require-clean-git", require: true, want: map[string]string{"team": "web"}},
+		{name: "dirty is labelled", dirty: true, want: map[string]string{"team": "web", "dirty": "true"}},
+		{name: "dirty with --require-clean-git", dirty: true, require: true, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gitWorkingTreeDirty = func() bool { return tc.dirty }
+
+			got, err := checkCleanGit(tc.require, map[string]string{"team": "web"})
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("want no error, got %s", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/openfaas/faas-cli/output"
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/openfaas/faas-cli/stack"
+)
+
+// providerCapabilities are the parts of a deployment which a provider
+// applies, the others are accepted by the gateway but dropped
+type providerCapabilities struct {
+	Namespaces  bool
+	Constraints bool
+	Secrets     bool
+	Annotations bool
+	// Limits and Requests are the resources which can be set, i.e. memory
+	Limits   []string
+	Requests []string
+}
+
+// providerFeatures are the capabilities by the orchestration which the
+// gateway reports in /system/info, an unknown orchestration is not checked
+var providerFeatures = map[string]providerCapabilities{
+	"kubernetes": {
+		Namespaces:  true,
+		Constraints: true,
+		Secrets:     true,
+		Annotations: true,
+		Limits:      []string{"memory", "cpu"},
+		Requests:    []string{"memory", "cpu"},
+	},
+	"swarm": {
+		Constraints: true,
+		Secrets:     true,
+		Limits:      []string{"memory", "cpu"},
+		Requests:    []string{"memory", "cpu"},
+	},
+	"containerd": {
+		Namespaces:  true,
+		Secrets:     true,
+		Annotations: true,
+		Limits:      []string{"memory", "cpu"},
+	},
+}
+
+// featureUsage is what a function asks of the provider
+type featureUsage struct {
+	Namespace   string
+	Constraints []string
+	Secrets     []string
+	Annotations map[string]string
+	Limits      *stack.FunctionResources
+	Requests    *stack.FunctionResources
+}
+
+// unsupported lists the parts of a function which the provider would drop
+func (c providerCapabilities) unsupported(usage featureUsage) []string {
+	found := []string{}
+
+	if !c.Namespaces && len(usage.Namespace) > 0 {
+		found = append(found, "namespace: "+usage.Namespace)
+	}
+	if !c.Constraints && len(usage.Constraints) > 0 {
+		found = append(found, "constraints: "+strings.Join(usage.Constraints, ", "))
+	}
+	if !c.Secrets && len(usage.Secrets) > 0 {
+		found = append(found, "secrets: "+strings.Join(usage.Secrets, ", "))
+	}
+	if !c.Annotations && len(usage.Annotations) > 0 {
+		keys := []string{}
+		for k := range usage.Annotations {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		found = append(found, "annotations: "+strings.Join(keys, ", "))
+	}
+
+	found = append(found, unsupportedResources("limits", usage.Limits, c.Limits)...)
+	found = append(found, unsupportedResources("requests", usage.Requests, c.Requests)...)
+	return found
+}
+
+func unsupportedResources(kind string, resources *stack.FunctionResources, supported []string) []string {
+	if resources == nil {
+		return nil
+	}
+
+	set := map[string]string{"memory": resources.Memory, "cpu": resources.CPU}
+	found := []string{}
+	for _, name := range []string{"memory", "cpu"} {
+		if len(set[name]) == 0 {
+			continue
+		}
+		if !contains(supported, name) {
+			found = append(found, kind+"."+name)
+		}
+	}
+	return found
+}
+
+// stackFeatureUsage is what each function in the stack file asks of the
+// provider, with the flags of deploy applied
+func stackFeatureUsage(services *stack.Services, namespace string, deployFlags DeployFlags) map[string]featureUsage {
+	usage := map[string]featureUsage{}
+	for name, function := range services.Functions {
+		constraints := deployFlags.constraints
+		if function.Constraints != nil {
+			constraints = *function.Constraints
+		}
+
+		annotations := map[string]string{}
+		if function.Annotations != nil {
+			annotations = *function.Annotations
+		}
+		if annotationArgs, err := parseMap(deployFlags.annotationOpts, "annotation"); err == nil {
+			annotations = mergeMap(annotations, annotationArgs)
+		}
+
+		if nodeSelector, err := function.K8s.Constraints(); err == nil {
+			constraints = append(append([]string{}, constraints...), nodeSelector...)
+		}
+		if k8sAnnotations, err := function.K8s.Annotations(); err == nil {
+			annotations = mergeMap(annotations, k8sAnnotations)
+		}
+
+		usage[name] = featureUsage{
+			Namespace:   getNamespace(namespace, function.Namespace),
+			Constraints: constraints,
+			Secrets:     mergeSlice(function.Secrets, deployFlags.secrets),
+			Annotations: annotations,
+			Limits:      overrideResources(function.Limits, deployFlags.memoryLimit, deployFlags.cpuLimit),
+			Requests:    overrideResources(function.Requests, deployFlags.memoryRequest, deployFlags.cpuRequest),
+		}
+	}
+	return usage
+}
+
+// usesFeatures is true when a function uses a feature which some provider
+// does not support, otherwise the gateway is not asked for its provider
+func usesFeatures(usage map[string]featureUsage) bool {
+	var none providerCapabilities
+	for _, u := range usage {
+		if len(none.unsupported(u)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// gatewayCapabilities asks the gateway for its provider, the result is
+// false when the provider is not known or the gateway can not say
+func gatewayCapabilities(ctx context.Context, client *proxy.Client) (string, providerCapabilities, bool) {
+	orchestration := gatewayOrchestration(ctx, client)
+	if len(orchestration) == 0 {
+		return "", providerCapabilities{}, false
+	}
+
+	capabilities, ok := providerFeatures[orchestration]
+	return orchestration, capabilities, ok
+}
+
+// gatewayOrchestration asks the gateway for the orchestration of its
+// provider, i.e. kubernetes, it is empty when the gateway can not say
+func gatewayOrchestration(ctx context.Context, client *proxy.Client) string {
+	info, err := client.GetSystemInfo(ctx)
+	if err != nil || info.Provider == nil {
+		return ""
+	}
+	return info.Provider.Orchestration
+}
+
+// checkCapabilities warns about, or with strict fails on, the parts of each
+// function which the provider of the gateway would drop
+func checkCapabilities(w io.Writer, orchestration string, capabilities providerCapabilities, usage map[string]featureUsage, strict bool) error {
+	names := []string{}
+	for name := range usage {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	messages := []string{}
+	for _, name := range names {
+		if unsupported := capabilities.unsupported(usage[name]); len(unsupported) > 0 {
+			messages = append(messages, fmt.Sprintf("%s uses %s", name, strings.Join(unsupported, "; ")))
+		}
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	summary := fmt.Sprintf("the %s provider of the gateway does not support:\n- %s", orchestration, strings.Join(messages, "\n- "))
+	if strict {
+		return fmt.Errorf("%s\nremove them from the stack file or deploy without --strict-capabilities", summary)
+	}
+	fmt.Fprintln(w, output.Warning("Warning: %s", summary))
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+func Test_providerCapabilities_unsupported(t *testing.T) {
+	usage := featureUsage{
+		Namespace:   "staging",
+		Constraints: []string{"node.platform.os == linux"},
+		Secrets:     []string{"api-key"},
+		Annotations: map[string]string{"topic": "orders", "schedule": "*/5 * * * *"},
+		Limits:      &stack.FunctionResources{Memory: "128Mi"},
+		Requests:    &stack.FunctionResources{Memory: "64Mi", CPU: "100m"},
+	}
+
+	cases := []struct {
+		orchestration string
+		want          []string
+	}{
+		{orchestration: "kubernetes", want: []string{}},
+		{
+			orchestration: "swarm",
+			want:          []string{"namespace: staging", "annotations: schedule, topic"},
+		},
+		{
+			orchestration: "containerd",
+			want:          []string{"constraints: node.platform.os == linux", "requests.memory", "requests.cpu"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.orchestration, func(t *testing.T) {
+			got := providerFeatures[tc.orchestration].unsupported(usage)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_checkCapabilities(t *testing.T) {
+	usage := map[string]featureUsage{
+		"api":    {Constraints: []string{"node.platform.os == linux"}},
+		"worker": {Secrets: []string{"api-key"}},
+	}
+
+	var out bytes.Buffer
+	err := checkCapabilities(&out, "containerd", providerFeatures["containerd"], usage, false)
+	if err != nil {
+		t.Fatalf("want a warning without --strict-capabilities, got: %s", err)
+	}
+	if !strings.Contains(out.String(), "api uses constraints: node.platform.os == linux") {
+		t.Fatalf("want a warning for the constraints of api, got: %s", out.String())
+	}
+
+	err = checkCapabilities(ioutil.Discard, "containerd", providerFeatures["containerd"], usage, true)
+	if err == nil || !strings.Contains(err.Error(), "the containerd provider of the gateway does not support") {
+		t.Fatalf("want an error with --strict-capabilities, got: %v", err)
+	}
+}
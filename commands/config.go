@@ -0,0 +1,24 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	faasCmd.AddCommand(configCmd)
+}
+
+// configCmd groups the commands which inspect the faas-cli configuration
+var configCmd = &cobra.Command{
+	Use:   `config [COMMAND]`,
+	Short: "Inspect and change the faas-cli configuration",
+	Long:  "Commands to inspect and change the configuration used by faas-cli",
+	Example: `  faas-cli config view
+  faas-cli config view --resolved
+  faas-cli config set defaults.gateway https://gw.example.com
+  faas-cli config get
+  faas-cli config fallback --gateway https://gw.example.com https://gw-dr.example.com`,
+}
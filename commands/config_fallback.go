@@ -0,0 +1,81 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/openfaas/faas-cli/config"
+	"github.com/spf13/cobra"
+)
+
+var configFallbackRemove bool
+
+func init() {
+	configFallbackCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
+	configFallbackCmd.Flags().BoolVar(&configFallbackRemove, "remove", false, "Remove the fallbacks of the gateway")
+
+	configCmd.AddCommand(configFallbackCmd)
+}
+
+// configFallbackCmd sets the gateways to use when a gateway cannot be reached
+var configFallbackCmd = &cobra.Command{
+	Use:   `fallback [--gateway GATEWAY_URL] [FALLBACK_URL...]`,
+	Short: "Set the fallback gateways for a gateway",
+	Long: `Sets the gateways which read-only commands use, in order, when the gateway
+cannot be reached: list, describe, namespaces and secret list. The gateway which
+answered is printed to stderr when it is a fallback. Commands which change
+anything are never sent to a fallback.
+
+Without any fallback URLs the current fallbacks of the gateway are printed.
+Log in to each fallback with "faas-cli login" when it needs credentials.`,
+	Example: `  faas-cli config fallback --gateway https://gw.example.com https://gw-dr.example.com
+  faas-cli config fallback --gateway https://gw.example.com
+  faas-cli config fallback --gateway https://gw.example.com --remove`,
+	RunE: runConfigFallback,
+}
+
+func runConfigFallback(cmd *cobra.Command, args []string) error {
+	gatewayAddress := getGatewayURL(gateway, defaultGateway, "", os.Getenv(openFaaSURLEnvironment))
+
+	if configFallbackRemove {
+		if len(args) > 0 {
+			return fmt.Errorf("give either fallback URLs or --remove")
+		}
+		if err := config.UpdateFallbacks(gatewayAddress, nil); err != nil {
+			return err
+		}
+		fmt.Printf("Removed the fallbacks of %s\n", gatewayAddress)
+		return nil
+	}
+
+	if len(args) == 0 {
+		fallbacks, err := config.LookupFallbacks(gatewayAddress)
+		if err != nil {
+			return err
+		}
+		if len(fallbacks) == 0 {
+			fmt.Printf("%s has no fallbacks\n", gatewayAddress)
+			return nil
+		}
+		fmt.Printf("Fallbacks for %s:\n", gatewayAddress)
+		for _, fallback := range fallbacks {
+			fmt.Printf(" - %s\n", fallback)
+		}
+		return nil
+	}
+
+	for _, fallback := range args {
+		if fallback == gatewayAddress {
+			return fmt.Errorf("%s cannot be a fallback for itself", fallback)
+		}
+	}
+
+	if err := config.UpdateFallbacks(gatewayAddress, args); err != nil {
+		return err
+	}
+	fmt.Printf("Set %d fallback(s) for %s\n", len(args), gatewayAddress)
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/openfaas/faas-cli/config"
+	"github.com/spf13/cobra"
+)
+
+var configHeaderRemove bool
+
+func init() {
+	configHeaderCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
+	configHeaderCmd.Flags().BoolVar(&configHeaderRemove, "remove", false, "Remove the headers of the gateway")
+
+	configCmd.AddCommand(configHeaderCmd)
+}
+
+// configHeaderCmd sets the headers to send with every request to a gateway
+var configHeaderCmd = &cobra.Command{
+	Use:   `header [--gateway GATEWAY_URL] [NAME=VALUE...]`,
+	Short: "Set the headers to send to a gateway",
+	Long: `Sets headers which are sent with every request to the gateway, for gateways
+behind Cloudflare Access or an API gateway which needs its own credentials.
+The headers replace those which were set before for the gateway, and
+--gateway-header takes priority over them for a single command. A header
+given to invoke with --header is kept as it is.
+
+Without any headers the names of the current headers of the gateway are
+printed, their values are not.`,
+	Example: `  faas-cli config header --gateway https://gw.example.com \
+    CF-Access-Client-Id=abc.access CF-Access-Client-Secret=$CF_SECRET
+  faas-cli config header --gateway https://gw.example.com
+  faas-cli config header --gateway https://gw.example.com --remove
+  faas-cli list --gateway-header X-Org-Token=$ORG_TOKEN`,
+	RunE: runConfigHeader,
+}
+
+func runConfigHeader(cmd *cobra.Command, args []string) error {
+	gatewayAddress := getGatewayURL(gateway, defaultGateway, "", os.Getenv(openFaaSURLEnvironment))
+
+	if configHeaderRemove {
+		if len(args) > 0 {
+			return fmt.Errorf("give either headers or --remove")
+		}
+		if err := config.UpdateHeaders(gatewayAddress, nil); err != nil {
+			return err
+		}
+		fmt.Printf("Removed the headers of %s\n", gatewayAddress)
+		return nil
+	}
+
+	if len(args) == 0 {
+		headers, err := config.LookupHeaders(gatewayAddress)
+		if err != nil {
+			return err
+		}
+		if len(headers) == 0 {
+			fmt.Printf("%s has no headers\n", gatewayAddress)
+			return nil
+		}
+		names := make([]string, 0, len(headers))
+		for name := range headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Printf("Headers for %s:\n", gatewayAddress)
+		for _, name := range names {
+			fmt.Printf(" - %s\n", name)
+		}
+		return nil
+	}
+
+	headers, err := parseGatewayHeaders(args)
+	if err != nil {
+		return err
+	}
+	if err := config.UpdateHeaders(gatewayAddress, headers); err != nil {
+		return err
+	}
+	fmt.Printf("Set %d header(s) for %s\n", len(headers), gatewayAddress)
+	return nil
+}
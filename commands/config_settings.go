@@ -0,0 +1,144 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openfaas/faas-cli/config"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configSchemaCmd)
+}
+
+// configKeysHelp lists each key which can be set along with its description
+func configKeysHelp() string {
+	lines := []string{"Keys:"}
+	for _, setting := range config.Settings {
+		lines = append(lines, fmt.Sprintf("  %-28s %s", setting.Key, setting.Description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// configGetCmd prints the settings in the config file
+var configGetCmd = &cobra.Command{
+	Use:   `get [KEY]`,
+	Short: "Print a setting from the config file",
+	Long: `Prints the value of a key in the config file, or every key which is set when
+no key is given. The command exits non-zero when the key is not set.
+
+` + configKeysHelp(),
+	Example: `  faas-cli config get
+  faas-cli config get defaults.gateway
+  faas-cli config get contexts.staging.namespace`,
+	RunE: runConfigGet,
+}
+
+// configSetCmd validates and writes a setting to the config file
+var configSetCmd = &cobra.Command{
+	Use:   `set KEY VALUE`,
+	Short: "Change a setting in the config file",
+	Long: `Validates a value and writes it to the config file. The settings apply when a
+value is not given by a flag, stack.yml or an environment variable, the current
+context takes priority over the defaults. See where each setting comes from with
+"faas-cli config view --resolved".
+
+` + configKeysHelp(),
+	Example: `  faas-cli config set defaults.gateway https://gw.example.com
+  faas-cli config set defaults.prefix docker.io/alexellis
+  faas-cli config set templates.repository https://github.com/openfaas/templates.git
+  faas-cli config set contexts.staging.gateway https://gw-staging.example.com
+  faas-cli config set contexts.staging.namespace staging-fn
+  faas-cli config set current_context staging`,
+	RunE: runConfigSet,
+}
+
+// configUnsetCmd removes a setting from the config file
+var configUnsetCmd = &cobra.Command{
+	Use:   `unset KEY`,
+	Short: "Remove a setting from the config file",
+	Long: `Removes a key from the config file. A context is removed along with its last
+setting.
+
+` + configKeysHelp(),
+	Example: `  faas-cli config unset defaults.prefix
+  faas-cli config unset current_context`,
+	RunE: runConfigUnset,
+}
+
+// configSchemaCmd prints the JSON schema of the config file
+var configSchemaCmd = &cobra.Command{
+	Use:   `schema`,
+	Short: "Print the JSON schema of the config file",
+	Long: `Prints a JSON schema for the config file, so that an editor can check the file
+when it is changed by hand.`,
+	Example: `  faas-cli config schema > ~/.openfaas/config.schema.json`,
+	RunE:    runConfigSchema,
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("give one key, i.e. faas-cli config get defaults.gateway")
+	}
+
+	if len(args) == 0 {
+		values, err := config.ListSettings()
+		if err != nil {
+			return err
+		}
+		for _, value := range values {
+			fmt.Printf("%s: %s\n", value.Key, value.Value)
+		}
+		return nil
+	}
+
+	value, err := config.GetSetting(args[0])
+	if err != nil {
+		return err
+	}
+	if len(value) == 0 {
+		return fmt.Errorf("%s is not set", args[0])
+	}
+	fmt.Println(value)
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("give a key and a value, i.e. faas-cli config set defaults.gateway https://gw.example.com")
+	}
+
+	if err := config.SetSetting(args[0], args[1]); err != nil {
+		return err
+	}
+	fmt.Printf("Set %s to %s\n", args[0], strings.TrimSpace(args[1]))
+	return nil
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("give the key to remove, i.e. faas-cli config unset defaults.prefix")
+	}
+
+	if err := config.UnsetSetting(args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("Unset %s\n", args[0])
+	return nil
+}
+
+func runConfigSchema(cmd *cobra.Command, args []string) error {
+	schema, err := config.Schema()
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(schema))
+	return nil
+}
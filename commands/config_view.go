@@ -0,0 +1,236 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openfaas/faas-cli/config"
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	sourceFlag        = "flag"
+	sourceEnvironment = "env"
+	sourceStack       = "stack"
+	sourceConfig      = "config"
+	sourceDefault     = "default"
+)
+
+var (
+	configViewResolved bool
+	configViewTemplate string
+	configViewStore    string
+)
+
+func init() {
+	configViewCmd.Flags().BoolVar(&configViewResolved, "resolved", false, "Print the settings in effect after applying flags, environment variables and stack.yml")
+	configViewCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
+	configViewCmd.Flags().StringVarP(&functionNamespace, "namespace", "n", "", "Namespace of the function")
+	configViewCmd.Flags().StringVar(&imagePrefix, "prefix", "", "Prefix for the function's image")
+	configViewCmd.Flags().StringVar(&configViewTemplate, "template-url", "", "URL of the repository to pull templates from")
+	configViewCmd.Flags().StringVar(&configViewStore, "template-store-url", DefaultTemplatesStore, "URL of the template store")
+	configViewCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in stack.yml file")
+
+	configCmd.AddCommand(configViewCmd)
+}
+
+// configViewCmd prints the config file, or the settings which are in effect
+var configViewCmd = &cobra.Command{
+	Use:   `view [--resolved]`,
+	Short: "Print the faas-cli configuration",
+	Long: `Prints the config file with tokens redacted. With --resolved it prints the
+settings which other commands would use after applying flags, environment
+variables, stack.yml and the settings in the config file, along with where each
+value came from.
+
+Pass the same flags that you pass to another command to see what it would use,
+i.e. to find out why it is talking to the wrong gateway.`,
+	Example: `  faas-cli config view
+  faas-cli config view --resolved
+  faas-cli config view --resolved -f functions.yml
+  OPENFAAS_URL=https://gw.example.com faas-cli config view --resolved`,
+	RunE: runConfigView,
+}
+
+// resolvedSetting is a value and where it came from: flag, env, stack, config or default
+type resolvedSetting struct {
+	Value  string `yaml:"value"`
+	Source string `yaml:"source"`
+}
+
+// resolvedConfig is the configuration in effect for a command
+type resolvedConfig struct {
+	ConfigFile         string            `yaml:"config_file"`
+	Stack              string            `yaml:"stack,omitempty"`
+	Gateway            resolvedSetting   `yaml:"gateway"`
+	Auth               string            `yaml:"auth"`
+	Namespace          resolvedSetting   `yaml:"namespace"`
+	TemplateRepository resolvedSetting   `yaml:"template_repository"`
+	TemplateStore      resolvedSetting   `yaml:"template_store"`
+	Prefix             resolvedSetting   `yaml:"prefix"`
+	Proxy              map[string]string `yaml:"proxy,omitempty"`
+}
+
+func runConfigView(cmd *cobra.Command, args []string) error {
+	if !configViewResolved {
+		return printConfigFile(filepath.Join(config.ConfigDir(), config.DefaultFile))
+	}
+
+	var services *stack.Services
+	if len(yamlFile) > 0 {
+		parsed, err := parseStackFile(yamlFile, envsubst)
+		if err != nil {
+			return err
+		}
+		services = parsed
+	}
+
+	resolved := resolveConfig(services)
+
+	out, err := yaml.Marshal(resolved)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(out))
+	return nil
+}
+
+// printConfigFile prints the config file with each token redacted
+func printConfigFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		fmt.Printf("No config file found at %s, run \"faas-cli login\" to create one\n", path)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var configFile config.ConfigFile
+	if err := yaml.Unmarshal(data, &configFile); err != nil {
+		return fmt.Errorf("unable to parse %s: %s", path, err)
+	}
+
+	for i := range configFile.AuthConfigs {
+		if len(configFile.AuthConfigs[i].Token) > 0 {
+			configFile.AuthConfigs[i].Token = "REDACTED"
+		}
+	}
+
+	out, err := yaml.Marshal(configFile)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("# %s\n%s", path, string(out))
+	return nil
+}
+
+// resolveConfig applies the same order of priority to each setting as the
+// commands which use it
+func resolveConfig(services *stack.Services) resolvedConfig {
+	resolved := resolvedConfig{
+		ConfigFile: filepath.Join(config.ConfigDir(), config.DefaultFile),
+		Stack:      yamlFile,
+	}
+	settings := configSettings()
+
+	var yamlGateway string
+	if services != nil {
+		yamlGateway = services.Provider.GatewayURL
+	}
+
+	environmentGateway := os.Getenv(openFaaSURLEnvironment)
+	resolved.Gateway = resolvedSetting{
+		Value:  getGatewayURL(gateway, defaultGateway, yamlGateway, environmentGateway),
+		Source: gatewaySource(gateway, defaultGateway, yamlGateway, environmentGateway, settings.Gateway),
+	}
+
+	resolved.Auth = "none"
+	if authConfig, err := config.LookupAuthConfig(resolved.Gateway.Value); err == nil {
+		resolved.Auth = string(authConfig.Auth)
+	}
+
+	resolved.Namespace = resolvedSetting{Value: defaultFunctionNamespace, Source: sourceDefault}
+	if len(functionNamespace) > 0 {
+		resolved.Namespace = resolvedSetting{Value: functionNamespace, Source: sourceFlag}
+	} else if namespace := stackNamespace(services); len(namespace) > 0 {
+		resolved.Namespace = resolvedSetting{Value: namespace, Source: sourceStack}
+	} else if len(settings.Namespace) > 0 {
+		resolved.Namespace = resolvedSetting{Value: settings.Namespace, Source: sourceConfig}
+	}
+
+	resolved.TemplateRepository = resolveSetting(configViewTemplate, DefaultTemplateRepository, templateURLEnvironment, settings.TemplateRepository, DefaultTemplateRepository)
+	resolved.TemplateStore = resolveSetting(configViewStore, DefaultTemplatesStore, templateStoreURLEnvironment, settings.TemplateStore, DefaultTemplatesStore)
+	resolved.Prefix = resolveSetting(imagePrefix, "", "OPENFAAS_PREFIX", settings.Prefix, "")
+
+	for _, name := range []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY"} {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			value, ok = os.LookupEnv(strings.ToLower(name))
+		}
+		if ok {
+			if resolved.Proxy == nil {
+				resolved.Proxy = map[string]string{}
+			}
+			resolved.Proxy[name] = value
+		}
+	}
+
+	return resolved
+}
+
+func gatewaySource(argumentURL, defaultURL, yamlURL, environmentURL, configURL string) string {
+	if len(argumentURL) > 0 && argumentURL != defaultURL {
+		return sourceFlag
+	} else if len(yamlURL) > 0 && yamlURL != defaultURL {
+		return sourceStack
+	} else if len(environmentURL) > 0 {
+		return sourceEnvironment
+	} else if len(configURL) > 0 {
+		return sourceConfig
+	}
+	return sourceDefault
+}
+
+// resolveSetting gives a flag priority over an environment variable, then the
+// config file and then the default
+func resolveSetting(flagValue, flagDefault, environmentVariable, configValue, defaultValue string) resolvedSetting {
+	if len(flagValue) > 0 && flagValue != flagDefault {
+		return resolvedSetting{Value: flagValue, Source: sourceFlag}
+	}
+	if value := os.Getenv(environmentVariable); len(value) > 0 {
+		return resolvedSetting{Value: value, Source: sourceEnvironment}
+	}
+	if len(configValue) > 0 {
+		return resolvedSetting{Value: configValue, Source: sourceConfig}
+	}
+	return resolvedSetting{Value: defaultValue, Source: sourceDefault}
+}
+
+// stackNamespace returns the namespace set on the functions in a stack, when
+// they all use the same one
+func stackNamespace(services *stack.Services) string {
+	if services == nil {
+		return ""
+	}
+
+	namespace := ""
+	for _, function := range services.Functions {
+		if len(function.Namespace) == 0 {
+			continue
+		}
+		if len(namespace) > 0 && namespace != function.Namespace {
+			return "(set per function)"
+		}
+		namespace = function.Namespace
+	}
+	return namespace
+}
@@ -0,0 +1,110 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"os"
+	"testing"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+func Test_resolveConfig(t *testing.T) {
+	resetForTest()
+	defer func() {
+		gateway = defaultGateway
+		functionNamespace = ""
+		imagePrefix = ""
+		configViewTemplate = ""
+		configViewStore = DefaultTemplatesStore
+	}()
+
+	cases := []struct {
+		name          string
+		flagGateway   string
+		envGateway    string
+		stackGateway  string
+		flagNamespace string
+		flagPrefix    string
+		envPrefix     string
+		wantGateway   resolvedSetting
+		wantNamespace resolvedSetting
+		wantPrefix    resolvedSetting
+	}{
+		{
+			name:          "defaults",
+			flagGateway:   defaultGateway,
+			wantGateway:   resolvedSetting{Value: defaultGateway, Source: sourceDefault},
+			wantNamespace: resolvedSetting{Value: "", Source: sourceDefault},
+			wantPrefix:    resolvedSetting{Value: "", Source: sourceDefault},
+		},
+		{
+			name:          "environment is used over the default",
+			flagGateway:   defaultGateway,
+			envGateway:    "https://env.example.com",
+			envPrefix:     "alexellis2",
+			wantGateway:   resolvedSetting{Value: "https://env.example.com", Source: sourceEnvironment},
+			wantNamespace: resolvedSetting{Value: "", Source: sourceDefault},
+			wantPrefix:    resolvedSetting{Value: "alexellis2", Source: sourceEnvironment},
+		},
+		{
+			name:          "stack is used over the environment",
+			flagGateway:   defaultGateway,
+			envGateway:    "https://env.example.com",
+			stackGateway:  "https://stack.example.com",
+			wantGateway:   resolvedSetting{Value: "https://stack.example.com", Source: sourceStack},
+			wantNamespace: resolvedSetting{Value: "openfaas-fn", Source: sourceStack},
+			wantPrefix:    resolvedSetting{Value: "", Source: sourceDefault},
+		},
+		{
+			name:          "flags are used over everything else",
+			flagGateway:   "https://flag.example.com",
+			envGateway:    "https://env.example.com",
+			stackGateway:  "https://stack.example.com",
+			flagNamespace: "staging",
+			flagPrefix:    "ghcr.io/openfaas",
+			envPrefix:     "alexellis2",
+			wantGateway:   resolvedSetting{Value: "https://flag.example.com", Source: sourceFlag},
+			wantNamespace: resolvedSetting{Value: "staging", Source: sourceFlag},
+			wantPrefix:    resolvedSetting{Value: "ghcr.io/openfaas", Source: sourceFlag},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Setenv(openFaaSURLEnvironment, tc.envGateway)
+			os.Setenv("OPENFAAS_PREFIX", tc.envPrefix)
+			defer os.Unsetenv(openFaaSURLEnvironment)
+			defer os.Unsetenv("OPENFAAS_PREFIX")
+
+			gateway = tc.flagGateway
+			functionNamespace = tc.flagNamespace
+			imagePrefix = tc.flagPrefix
+
+			var services *stack.Services
+			if len(tc.stackGateway) > 0 {
+				services = &stack.Services{
+					Provider: stack.Provider{GatewayURL: tc.stackGateway},
+					Functions: map[string]stack.Function{
+						"fn1": {Namespace: "openfaas-fn"},
+					},
+				}
+			}
+
+			resolved := resolveConfig(services)
+			if resolved.Gateway != tc.wantGateway {
+				t.Errorf("gateway want: %v, got: %v", tc.wantGateway, resolved.Gateway)
+			}
+			if resolved.Namespace != tc.wantNamespace {
+				t.Errorf("namespace want: %v, got: %v", tc.wantNamespace, resolved.Namespace)
+			}
+			if resolved.Prefix != tc.wantPrefix {
+				t.Errorf("prefix want: %v, got: %v", tc.wantPrefix, resolved.Prefix)
+			}
+			if resolved.TemplateRepository.Value != DefaultTemplateRepository {
+				t.Errorf("template repository want: %s, got: %s", DefaultTemplateRepository, resolved.TemplateRepository.Value)
+			}
+		})
+	}
+}
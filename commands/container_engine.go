@@ -0,0 +1,79 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openfaas/faas-cli/builder"
+	"github.com/openfaas/faas-cli/stack"
+)
+
+// Flags which choose how images are built and pushed
+var (
+	// containerEngine is docker, podman or nerdctl, the first which is
+	// installed is used when it is not set
+	containerEngine string
+
+	// dockerContext is the docker context to build and push with, it takes
+	// priority over DOCKER_HOST and DOCKER_CONTEXT
+	dockerContext string
+)
+
+// containerEngineChecked is set once the engine has been checked, so that up
+// checks it once for both the build and the push
+var containerEngineChecked bool
+
+// checkContainerEngine chooses the engine given with --container-engine, or
+// the first one installed, before any image is built. For docker it also
+// points the builder at the context given with --docker-context and checks
+// that its daemon answers, the daemon is printed so that a remote or second
+// engine is not used without saying so.
+func checkContainerEngine(quiet bool) error {
+	if containerEngineChecked {
+		return nil
+	}
+
+	engine, err := builder.DetectContainerEngine(containerEngine)
+	if err != nil {
+		return err
+	}
+	builder.ContainerEngine = engine
+	builder.DockerContext = dockerContext
+
+	if engine != builder.EngineDocker {
+		if len(dockerContext) > 0 {
+			return fmt.Errorf("--docker-context can only be used with docker, not %s", engine)
+		}
+		if !quiet {
+			fmt.Printf("Using %s to build and push images\n", engine)
+		}
+		containerEngineChecked = true
+		return nil
+	}
+
+	daemon, err := builder.CheckDockerEngine(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if !quiet {
+		fmt.Printf("Using the Docker daemon at %s\n", daemon)
+	}
+	containerEngineChecked = true
+	return nil
+}
+
+// needsContainerEngine is true when a function of the stack file will be
+// built, or pushed for push, rather than being skipped or prebuilt
+func needsContainerEngine(services *stack.Services, push bool) bool {
+	for name, function := range services.Functions {
+		if function.SkipBuild || (push && function.SkipPush) || skipped(skipFunctions, name) || function.Prebuilt() {
+			continue
+		}
+		return true
+	}
+	return false
+}
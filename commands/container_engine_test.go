@@ -0,0 +1,40 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"testing"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+func Test_needsContainerEngine(t *testing.T) {
+	defer func() { skipFunctions = nil }()
+
+	built := stack.Function{Image: "alexellis/fn1", Language: "go", Handler: "./fn1"}
+	prebuilt := stack.Function{Image: "functions/nodeinfo"}
+
+	cases := []struct {
+		name      string
+		functions map[string]stack.Function
+		skip      []string
+		push      bool
+		want      bool
+	}{
+		{name: "a function to build", functions: map[string]stack.Function{"fn1": built, "nodeinfo": prebuilt}, want: true},
+		{name: "only prebuilt images", functions: map[string]stack.Function{"nodeinfo": prebuilt}, want: false},
+		{name: "skipped with --skip", functions: map[string]stack.Function{"fn1": built}, skip: []string{"fn1"}, want: false},
+		{name: "skip_push only for push", functions: map[string]stack.Function{"fn1": {Image: "alexellis/fn1", Language: "go", SkipPush: true}}, push: true, want: false},
+		{name: "skip_push still builds", functions: map[string]stack.Function{"fn1": {Image: "alexellis/fn1", Language: "go", SkipPush: true}}, want: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			skipFunctions = c.skip
+			if got := needsContainerEngine(&stack.Services{Functions: c.functions}, c.push); got != c.want {
+				t.Fatalf("want %v, got %v", c.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/openfaas/faas-cli/version"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	newIssueURL = "https://github.com/openfaas/faas-cli/issues/new"
+	redacted    = "REDACTED"
+)
+
+// sensitiveFlagNames are parts of flag names whose values are left out of a
+// crash report as they may hold credentials
+var sensitiveFlagNames = []string{"password", "token", "secret", "literal", "header", "env", "build-arg", "key", "cred"}
+
+// recoverPanic writes a crash report when a command panics and exits, it must
+// be deferred so that recover() can stop the panic
+func recoverPanic(args []string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	report := crashReport(r, debug.Stack(), sanitizeArgs(faasCmd, args))
+
+	fmt.Fprintf(os.Stderr, "faas-cli crashed unexpectedly: %v\n", r)
+	if path, err := writeCrashReport(report); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to write the crash report: %s\n\n%s", err, report)
+	} else {
+		fmt.Fprintf(os.Stderr, "A crash report was written to: %s\n", path)
+	}
+	fmt.Fprintf(os.Stderr, "Please open an issue at %s and attach the report, check it for anything you do not want to share first.\n", newIssueURL)
+
+	sshTunnels.close()
+	os.Exit(exitCodeError)
+}
+
+// crashReport describes the version, platform and command line of a panic
+func crashReport(r interface{}, stack []byte, args []string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "faas-cli crash report\n\n")
+	fmt.Fprintf(&sb, "Version:    %s\n", version.BuildVersion())
+	fmt.Fprintf(&sb, "Git commit: %s\n", version.GitCommit)
+	fmt.Fprintf(&sb, "Go:         %s\n", runtime.Version())
+	fmt.Fprintf(&sb, "Platform:   %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&sb, "Command:    faas-cli %s\n\n", strings.Join(args, " "))
+	fmt.Fprintf(&sb, "Panic: %v\n\n%s", r, stack)
+	return sb.String()
+}
+
+func writeCrashReport(report string) (string, error) {
+	file, err := ioutil.TempFile("", "faas-cli-crash-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(report); err != nil {
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// sanitizeArgs redacts the values of flags which may hold credentials and
+// any username or password in a URL
+func sanitizeArgs(root *cobra.Command, args []string) []string {
+	command, _, err := root.Find(args)
+	if err != nil || command == nil {
+		command = root
+	}
+
+	sanitized := make([]string, len(args))
+	redactNext := false
+	for i, arg := range args {
+		switch {
+		case redactNext:
+			sanitized[i] = redacted
+			redactNext = false
+			continue
+		case arg == "--" || !strings.HasPrefix(arg, "-") || arg == "-":
+			sanitized[i] = redactURL(arg)
+			continue
+		}
+
+		name := strings.TrimLeft(arg, "-")
+		value, hasValue := "", false
+		if separator := strings.Index(name, "="); separator >= 0 {
+			name, value, hasValue = name[:separator], name[separator+1:], true
+		}
+
+		flag := lookupFlag(command, name, !strings.HasPrefix(arg, "--"))
+		if flag == nil || !isSensitiveFlag(flag.Name) {
+			sanitized[i] = redactURL(arg)
+			continue
+		}
+
+		if hasValue {
+			sanitized[i] = arg[:len(arg)-len(value)] + redacted
+			continue
+		}
+
+		sanitized[i] = arg
+		redactNext = flag.NoOptDefVal == ""
+	}
+	return sanitized
+}
+
+func lookupFlag(command *cobra.Command, name string, shorthand bool) *pflag.Flag {
+	if shorthand {
+		if len(name) != 1 {
+			return nil
+		}
+		if flag := command.Flags().ShorthandLookup(name); flag != nil {
+			return flag
+		}
+		return command.InheritedFlags().ShorthandLookup(name)
+	}
+
+	if flag := command.Flags().Lookup(name); flag != nil {
+		return flag
+	}
+	return command.InheritedFlags().Lookup(name)
+}
+
+func isSensitiveFlag(name string) bool {
+	for _, sensitive := range sensitiveFlagNames {
+		if strings.Contains(name, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactURL(value string) string {
+	if !strings.Contains(value, "://") {
+		return value
+	}
+
+	prefix, rawURL := "", value
+	if separator := strings.Index(value, "="); separator >= 0 && separator < strings.Index(value, "://") {
+		prefix, rawURL = value[:separator+1], value[separator+1:]
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return value
+	}
+	u.User = url.User(redacted)
+	return prefix + u.String()
+}
@@ -0,0 +1,71 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/openfaas/faas-cli/config"
+	"github.com/openfaas/faas-cli/output"
+)
+
+// insecureCredentialStore saves tokens in plaintext in the config file
+var insecureCredentialStore bool
+
+// defaultCredentialStore returns the keyring store of the OS
+var defaultCredentialStore = config.DefaultCredentialStore
+
+// saveAuthConfig saves the token for a gateway in the keyring of the OS, or in
+// plaintext in the config file with --insecure-credential-store. In CI, where
+// there is rarely a keyring, plaintext is used with a warning.
+func saveAuthConfig(gatewayURL, token string, authType config.AuthType) error {
+	store, err := credentialStore()
+	if err != nil {
+		return err
+	}
+	if store == nil {
+		return config.UpdateAuthConfig(gatewayURL, token, authType)
+	}
+	return config.UpdateAuthConfigInStore(gatewayURL, token, authType, store)
+}
+
+// saveClientCredentialsAuth saves a token obtained with the client_credentials
+// or refresh_token grant along with the client secret and refresh token, which
+// are kept in the same store as the token, so that the token can be refreshed
+func saveClientCredentialsAuth(auth config.AuthConfig) error {
+	store, err := credentialStore()
+	if err != nil {
+		return err
+	}
+	return config.UpdateClientCredentialsAuthConfig(auth, store)
+}
+
+// credentialStore returns the keyring store of the OS, or nil when credentials
+// are to be stored in plaintext in the config file
+func credentialStore() (config.CredentialStore, error) {
+	configFile := filepath.Join(config.ConfigDir(), config.DefaultFile)
+
+	if insecureCredentialStore {
+		fmt.Fprintln(os.Stderr, output.Warning("WARNING! Your credentials will be stored unencrypted in %s", configFile))
+		return nil, nil
+	}
+
+	store, err := defaultCredentialStore()
+	if err != nil {
+		if isCI() {
+			fmt.Fprintln(os.Stderr, output.Warning("WARNING! %s, your credentials will be stored unencrypted in %s", err, configFile))
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to use the keyring to store your credentials: %s, install it or pass --insecure-credential-store to store them unencrypted in %s", err, configFile)
+	}
+	return store, nil
+}
+
+// isCI is true when the CI environment variable is set to true or 1
+func isCI() bool {
+	ci := os.Getenv("CI")
+	return ci == "true" || ci == "1"
+}
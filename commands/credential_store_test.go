@@ -0,0 +1,51 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/config"
+)
+
+func Test_saveAuthConfig_WithoutKeyring(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "faas-cli-credential-store-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(configDir)
+
+	os.Setenv(config.ConfigLocationEnv, configDir)
+	defer os.Unsetenv(config.ConfigLocationEnv)
+
+	defer func(ci string) { os.Setenv("CI", ci) }(os.Getenv("CI"))
+	os.Setenv("CI", "false")
+
+	defer func(store func() (config.CredentialStore, error)) { defaultCredentialStore = store }(defaultCredentialStore)
+	defaultCredentialStore = func() (config.CredentialStore, error) {
+		return nil, fmt.Errorf("the credential helper docker-credential-secretservice was not found in PATH")
+	}
+
+	gatewayURL := "http://127.0.0.1:8080"
+	err = saveAuthConfig(gatewayURL, config.EncodeAuth("admin", "pass"), config.BasicAuthType)
+	if err == nil || !strings.Contains(err.Error(), "--insecure-credential-store") {
+		t.Fatalf("want an error which suggests --insecure-credential-store, got: %v", err)
+	}
+	if _, err := config.LookupAuthConfig(gatewayURL); err == nil {
+		t.Errorf("want no credentials to be saved")
+	}
+
+	insecureCredentialStore = true
+	defer func() { insecureCredentialStore = false }()
+	if err := saveAuthConfig(gatewayURL, config.EncodeAuth("admin", "pass"), config.BasicAuthType); err != nil {
+		t.Fatal(err)
+	}
+	if authConfig, err := config.LookupAuthConfig(gatewayURL); err != nil || len(authConfig.Token) == 0 {
+		t.Errorf("want the token in the config file, got %v, %v", authConfig, err)
+	}
+}
@@ -5,13 +5,19 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/openfaas/faas-cli/builder"
+	"github.com/openfaas/faas-cli/i18n"
+	"github.com/openfaas/faas-cli/output"
 	"github.com/openfaas/faas-cli/proxy"
 	"github.com/openfaas/faas-cli/schema"
 	"github.com/openfaas/faas-cli/stack"
@@ -22,11 +28,24 @@ import (
 var (
 	// readTemplate controls whether we should read the function's template when deploying.
 	readTemplate bool
+
+	// deployDryRun prints the functions instead of deploying them
+	deployDryRun bool
+)
+
+const (
+	// deployStrategyRolling updates existing functions with a rolling update
+	deployStrategyRolling = "rolling"
+
+	// deployStrategyRecreate updates existing functions in place when possible and
+	// removes then re-creates them when the gateway refuses the update
+	deployStrategyRecreate = "recreate"
 )
 
 // DeployFlags holds flags that are to be added to commands.
 type DeployFlags struct {
 	envvarOpts             []string
+	envFiles               []string
 	replace                bool
 	update                 bool
 	readOnlyRootFilesystem bool
@@ -34,10 +53,217 @@ type DeployFlags struct {
 	secrets                []string
 	labelOpts              []string
 	annotationOpts         []string
+	quiet                  bool
+	skipSecretCheck        bool
+	checkImage             bool
+	strategy               string
+	memoryLimit            string
+	cpuLimit               string
+	memoryRequest          string
+	cpuRequest             string
+	noProvenance           bool
+	timeout                time.Duration
+	wait                   bool
+	waitTimeout            time.Duration
+	strictCapabilities     bool
+	fixName                bool
 }
 
 var deployFlags DeployFlags
 
+// getProvenance is a variable so that tests do not depend on the repository they run in
+var getProvenance = builder.GetProvenance
+
+// DeployOptions configures Deploy, which programs can call to deploy functions
+// in the same way as "faas-cli deploy" without going through the CLI.
+// Functions are read from YAMLFile when it is set, otherwise a single function
+// is deployed from Image and FunctionName.
+type DeployOptions struct {
+	// YAMLFile is a path or URL to a stack.yml file, Selector picks functions
+	// from it and EnvSubst substitutes environment variables in it
+	YAMLFile string
+	Selector stack.SelectorFlags
+	EnvSubst bool
+
+	// Deprecated: use Selector.Regex, Regex is used when it is not set
+	Regex string
+	// Deprecated: use Selector.Filter, Filter is used when it is not set
+	Filter string
+
+	// OverrideYAMLFiles are merged over YAMLFile in order, a later file
+	// overrides the ones before it
+	OverrideYAMLFiles []string
+
+	// EnvProfile applies a profile from the environments of the stack file
+	EnvProfile string
+
+	Image        string
+	FProcess     string
+	FunctionName string
+
+	// Gateway is used when stack.yml does not set one, OPENFAAS_URL is used
+	// when neither is set
+	Gateway     string
+	Token       string
+	TLSInsecure bool
+	RequestID   string
+
+	// Timeout defaults to 60 seconds
+	Timeout time.Duration
+
+	// Namespace overrides the namespace of each function
+	Namespace string
+
+	// ReadTemplate reads the fprocess of each function from its template
+	ReadTemplate bool
+	TagFormat    schema.BuildFormat
+
+	// ImagesFile pins the image of each function to the digest written by
+	// publish --images-file, the tag format is not applied to them
+	ImagesFile string
+
+	// Record keeps each function which is deployed from the stack file in
+	// the state file of the gateway, for diff and rollback
+	Record bool
+
+	Env         []string
+	EnvFiles    []string
+	Labels      []string
+	Annotations []string
+	Constraints []string
+	Secrets     []string
+
+	Replace bool
+	Update  bool
+
+	// Strategy is "rolling" or "recreate", the default is "rolling"
+	Strategy string
+
+	ReadOnlyRootFilesystem bool
+	SkipSecretCheck        bool
+	CheckImage             bool
+
+	// StrictCapabilities fails the deployment when a function uses a feature
+	// which the provider of the gateway would drop, instead of warning
+	StrictCapabilities bool
+
+	// FixName deploys a function with an invalid name under the valid name
+	// suggested for it, i.e. my-fn for My_Fn, instead of failing
+	FixName bool
+
+	MemoryLimit   string
+	CPULimit      string
+	MemoryRequest string
+	CPURequest    string
+
+	// NoProvenance leaves out the annotations with the git commit, branch,
+	// repository and CI build which functions from a stack.yml file are given
+	NoProvenance bool
+
+	// FunctionTimeout sets the read_timeout, write_timeout and exec_timeout
+	// of each function when it is greater than zero
+	FunctionTimeout time.Duration
+
+	// Skip names functions in the stack file which are not deployed, along
+	// with those which set skip_deploy
+	Skip []string
+
+	// Tags selects the functions in the stack file which have at least one of
+	// these tags, when it is empty all of the functions are deployed
+	Tags []string
+
+	// Wait polls each function after it is deployed until all of its
+	// replicas run the new image, for at most WaitTimeout in total
+	Wait        bool
+	WaitTimeout time.Duration
+
+	// Quiet leaves out the progress of Deploy and prints its warnings to
+	// stderr, from the command line only the URL of each function which was
+	// deployed is then printed
+	Quiet bool
+
+	// plan is given each function from the stack file instead of it being
+	// deployed, so that diff can compare them with the last deployment
+	plan func(gateway string, spec *proxy.DeployFunctionSpec)
+}
+
+func (o DeployOptions) flags() DeployFlags {
+	return DeployFlags{
+		envvarOpts:             o.Env,
+		envFiles:               o.EnvFiles,
+		replace:                o.Replace,
+		update:                 o.Update,
+		readOnlyRootFilesystem: o.ReadOnlyRootFilesystem,
+		constraints:            o.Constraints,
+		secrets:                o.Secrets,
+		labelOpts:              o.Labels,
+		annotationOpts:         o.Annotations,
+		skipSecretCheck:        o.SkipSecretCheck,
+		checkImage:             o.CheckImage,
+		strictCapabilities:     o.StrictCapabilities,
+		fixName:                o.FixName,
+		strategy:               o.Strategy,
+		memoryLimit:            o.MemoryLimit,
+		cpuLimit:               o.CPULimit,
+		memoryRequest:          o.MemoryRequest,
+		cpuRequest:             o.CPURequest,
+		noProvenance:           o.NoProvenance,
+		timeout:                o.FunctionTimeout,
+		wait:                   o.Wait,
+		waitTimeout:            o.WaitTimeout,
+		quiet:                  o.Quiet,
+	}
+}
+
+// deployOptions collects the values of the deploy command's flags
+func deployOptions(flags DeployFlags) DeployOptions {
+	return DeployOptions{
+		YAMLFile:               yamlFile,
+		OverrideYAMLFiles:      overrideYAMLFiles,
+		Selector:               stackSelectorFlags(),
+		EnvProfile:             envProfile,
+		EnvSubst:               envsubst,
+		Image:                  image,
+		FProcess:               fprocess,
+		FunctionName:           functionName,
+		Gateway:                gateway,
+		Token:                  token,
+		TLSInsecure:            tlsInsecure,
+		RequestID:              requestID,
+		Timeout:                commandTimeout,
+		Namespace:              functionNamespace,
+		ReadTemplate:           readTemplate,
+		TagFormat:              tagFormat,
+		ImagesFile:             imagesFile,
+		Record:                 recordDeploy,
+		Env:                    flags.envvarOpts,
+		EnvFiles:               flags.envFiles,
+		Labels:                 flags.labelOpts,
+		Annotations:            flags.annotationOpts,
+		Constraints:            flags.constraints,
+		Secrets:                flags.secrets,
+		Replace:                flags.replace,
+		Update:                 flags.update,
+		Strategy:               flags.strategy,
+		ReadOnlyRootFilesystem: flags.readOnlyRootFilesystem,
+		SkipSecretCheck:        flags.skipSecretCheck,
+		CheckImage:             flags.checkImage,
+		StrictCapabilities:     flags.strictCapabilities,
+		FixName:                flags.fixName,
+		MemoryLimit:            flags.memoryLimit,
+		CPULimit:               flags.cpuLimit,
+		MemoryRequest:          flags.memoryRequest,
+		CPURequest:             flags.cpuRequest,
+		NoProvenance:           flags.noProvenance,
+		FunctionTimeout:        flags.timeout,
+		Wait:                   flags.wait,
+		WaitTimeout:            flags.waitTimeout,
+		Quiet:                  flags.quiet,
+		Skip:                   skipFunctions,
+		Tags:                   selectTags,
+	}
+}
+
 func init() {
 	// Setup flags that are used by multiple commands (variables defined in faas.go)
 	deployCmd.Flags().StringVar(&fprocess, "fprocess", "", "fprocess value to be run as a serverless function by the watchdog")
@@ -51,6 +277,7 @@ func init() {
 
 	// Setup flags that are used only by this command (variables defined above)
 	deployCmd.Flags().StringArrayVarP(&deployFlags.envvarOpts, "env", "e", []string{}, "Set one or more environment variables (ENVVAR=VALUE)")
+	deployCmd.Flags().StringArrayVar(&deployFlags.envFiles, "env-file", []string{}, "Read environment variables from one or more dotenv files, overridden by --env")
 
 	deployCmd.Flags().StringArrayVarP(&deployFlags.labelOpts, "label", "l", []string{}, "Set one or more label (LABEL=VALUE)")
 
@@ -58,11 +285,30 @@ func init() {
 
 	deployCmd.Flags().BoolVar(&deployFlags.replace, "replace", false, "Remove and re-create existing function(s)")
 	deployCmd.Flags().BoolVar(&deployFlags.update, "update", true, "Perform rolling update on existing function(s)")
+	deployCmd.Flags().StringVar(&deployFlags.strategy, "strategy", deployStrategyRolling, "How to update existing functions: \"rolling\", or \"recreate\" to remove and re-create them when they cannot be updated, the same as --replace")
 
 	deployCmd.Flags().StringArrayVar(&deployFlags.constraints, "constraint", []string{}, "Apply a constraint to the function")
 	deployCmd.Flags().StringArrayVar(&deployFlags.secrets, "secret", []string{}, "Give the function access to a secure secret")
+	deployCmd.Flags().BoolVar(&deployFlags.skipSecretCheck, "skip-secret-check", false, "Warn instead of failing when a secret or namespace used by a function does not exist on the gateway")
+	deployCmd.Flags().BoolVar(&deployFlags.fixName, "fix-name", false, "Deploy a function with an invalid name under a valid one, i.e. my-fn for My_Fn, instead of failing")
+	deployCmd.Flags().BoolVar(&deployFlags.strictCapabilities, "strict-capabilities", false, "Fail instead of warning when a function uses a feature which the provider of the gateway does not support, i.e. constraints on faasd")
+	deployCmd.Flags().BoolVar(&deployFlags.checkImage, "check-image", false, "Warn when a function's image cannot be found in its registry, i.e. it was not pushed")
+	deployCmd.Flags().StringVar(&deployFlags.memoryLimit, "memory-limit", "", "Set a memory limit such as 128Mi, overrides stack.yml")
+	deployCmd.Flags().StringVar(&deployFlags.cpuLimit, "cpu-limit", "", "Set a CPU limit such as 500m, overrides stack.yml")
+	deployCmd.Flags().StringVar(&deployFlags.memoryRequest, "memory-request", "", "Set a memory request such as 64Mi, overrides stack.yml")
+	deployCmd.Flags().StringVar(&deployFlags.cpuRequest, "cpu-request", "", "Set a CPU request such as 100m, overrides stack.yml")
 	deployCmd.Flags().BoolVar(&deployFlags.readOnlyRootFilesystem, "readonly", false, "Force the root container filesystem to be read only")
-
+	deployCmd.Flags().StringSliceVar(&skipFunctions, "skip", []string{}, "Names of functions in the stack file to leave out, i.e. fn1,fn2")
+	deployCmd.Flags().StringSliceVar(&selectTags, "tags", []string{}, "Only use the functions in the stack file which have one of these tags, i.e. frontend,critical")
+	deployCmd.Flags().DurationVar(&deployFlags.timeout, "timeout", 0, "Set the read_timeout, write_timeout and exec_timeout of the function(s) to the same value, i.e. 60s")
+	deployCmd.Flags().BoolVar(&deployFlags.noProvenance, "no-provenance", false, "Do not annotate functions with the git commit, branch, repository and CI build they were deployed from")
+	deployCmd.Flags().BoolVar(&deployFlags.wait, "wait", false, "Wait for all of the replicas of each function to run the new image after it is deployed")
+	deployCmd.Flags().DurationVar(&deployFlags.waitTimeout, "wait-timeout", defaultWaitTimeout, "The longest time to --wait for all of the functions to be ready")
+
+	deployCmd.Flags().BoolVar(&deployDryRun, "dry-run", false, "Print what would be deployed for each function in the stack file without deploying it")
+	addSensitiveFlags(deployCmd.Flags())
+	deployCmd.Flags().BoolVar(&recordDeploy, "record", false, "Record the functions which are deployed in a state file for the gateway, for diff --against last-applied and rollback")
+	deployCmd.Flags().StringVar(&imagesFile, "images-file", "", "Deploy the images and digests written by publish --images-file, i.e. images.json")
 	deployCmd.Flags().Var(&tagFormat, "tag", "Override latest tag on function Docker image, accepts 'latest', 'sha', 'branch', or 'describe'")
 
 	deployCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
@@ -71,6 +317,10 @@ func init() {
 	// Set bash-completion.
 	_ = deployCmd.Flags().SetAnnotation("handler", cobra.BashCompSubdirsInDir, []string{})
 	deployCmd.Flags().BoolVar(&readTemplate, "read-template", true, "Read the function's template")
+	deployCmd.Flags().BoolVarP(&deployFlags.quiet, "quiet", "q", false, "Quiet mode - print out only the URL of each deployed function")
+	deployCmd.Flags().StringVar(&junitReport, "junit", "", "Write the result of each function to this file as a JUnit XML report, i.e. report.xml")
+	deployCmd.Flags().BoolVar(&annotateCI, "annotate-ci", false, "Annotate the functions which failed in stack.yml when running in GitHub Actions or GitLab CI")
+	deployCmd.Flags().StringVar(&sopsAgeKeyFile, "sops-age-key-file", "", "age key file to decrypt SOPS encrypted environment_file(s), overrides SOPS_AGE_KEY_FILE")
 
 	faasCmd.AddCommand(deployCmd)
 }
@@ -86,22 +336,33 @@ var deployCmd = &cobra.Command{
                   [--handler HANDLER_DIR]
                   [--fprocess PROCESS]
                   [--env ENVVAR=VALUE ...]
+                  [--env-file ENV_FILE ...]
 				  [--label LABEL=VALUE ...]
 				  [--annotation ANNOTATION=VALUE ...]
 				  [--replace=false]
+				  [--strategy rolling|recreate]
 				  [--update=false]
                   [--constraint PLACEMENT_CONSTRAINT ...]
                   [--regex "REGEX"]
                   [--filter "WILDCARD"]
 				  [--secret "SECRET_NAME"]
+				  [--memory-limit 128Mi] [--cpu-limit 500m]
+				  [--memory-request 64Mi] [--cpu-request 100m]
+				  [--skip-secret-check]
+				  [--check-image]
 				  [--tag <sha|branch|describe>]
 				  [--readonly=false]
+				  [--no-provenance]
 				  [--tls-no-verify]`,
 
 	Short: "Deploy OpenFaaS functions",
 	Long: `Deploys OpenFaaS function containers either via the supplied YAML config using
 the "--yaml" flag (which may contain multiple function definitions), or directly
-via flags. Note: --replace and --update are mutually exclusive.`,
+via flags. Note: --replace and --update are mutually exclusive.
+
+With --dry-run, the settings of each function in the stack file are printed
+instead of being deployed. The values of environment variables whose names
+match --sensitive-pattern are redacted, use --show-sensitive to print them.`,
 	Example: `  faas-cli deploy -f https://domain/path/myfunctions.yml
   faas-cli deploy -f ./stack.yml
   faas-cli deploy -f ./stack.yml --label canary=true
@@ -110,13 +371,16 @@ via flags. Note: --replace and --update are mutually exclusive.`,
   faas-cli deploy -f ./stack.yml --regex "fn[0-9]_.*"
   faas-cli deploy -f ./stack.yml --replace=false --update=true
   faas-cli deploy -f ./stack.yml --replace=true --update=false
+  faas-cli deploy -f ./stack.yml --strategy recreate
   faas-cli deploy -f ./stack.yml --tag sha
   faas-cli deploy -f ./stack.yml --tag branch
   faas-cli deploy -f ./stack.yml --tag describe
+  faas-cli deploy -f ./stack.yml --dry-run
   faas-cli deploy --image=alexellis/faas-url-ping --name=url-ping
   faas-cli deploy --image=my_image --name=my_fn --handler=/path/to/fn/
                   --gateway=http://remote-site.com:8080 --lang=python
-                  --env=MYVAR=myval`,
+                  --env=MYVAR=myval
+  faas-cli deploy -f ./stack.yml --quiet | xargs -n1 curl -s`,
 	PreRunE: preRunDeploy,
 	RunE:    runDeploy,
 }
@@ -129,52 +393,253 @@ func preRunDeploy(cmd *cobra.Command, args []string) error {
 }
 
 func runDeploy(cmd *cobra.Command, args []string) error {
-	return runDeployCommand(args, image, fprocess, functionName, deployFlags, tagFormat)
+	// up writes the report once it has built and pushed too
+	if cmd.Name() == "deploy" {
+		defer reportResults()
+	}
+
+	return deployWithOptions(deployOptions(deployFlags))
+}
+
+// deployWithOptions deploys from the command line, in quiet mode only the URL
+// of each function is printed
+func deployWithOptions(options DeployOptions) error {
+	if deployDryRun {
+		return dryRunDeploy(options)
+	}
+
+	deployedURLs, err := Deploy(context.Background(), options)
+	if options.Quiet {
+		for _, deployedURL := range deployedURLs {
+			fmt.Println(deployedURL)
+		}
+	}
+	return err
 }
 
-func runDeployCommand(args []string, image string, fprocess string, functionName string, deployFlags DeployFlags, tagMode schema.BuildFormat) error {
+// dryRunDeploy prints what deploy would send to the gateway for each function
+// in the stack file
+func dryRunDeploy(options DeployOptions) error {
+	if len(options.YAMLFile) == 0 {
+		return fmt.Errorf("give a stack file with -f for --dry-run")
+	}
+	sensitive, err := sensitiveKeysFromFlags()
+	if err != nil {
+		return err
+	}
+
+	var planned []appliedFunction
+	var plannedGateway string
+	options.Record = false
+	options.Quiet = true
+	options.plan = func(gateway string, spec *proxy.DeployFunctionSpec) {
+		plannedGateway = gateway
+		planned = append(planned, newAppliedFunction(spec, time.Time{}))
+	}
+
+	if _, err = Deploy(context.Background(), options); err != nil {
+		return err
+	}
+
+	printDeployPlan(os.Stdout, plannedGateway, planned, sensitive)
+	return nil
+}
+
+// printDeployPlan prints the settings of each function, one per line and
+// sorted by key, with the sensitive environment variables redacted
+func printDeployPlan(w io.Writer, gateway string, planned []appliedFunction, sensitive sensitiveKeys) {
+	fmt.Fprintf(w, "Dry run, nothing was deployed to %s\n", gateway)
+	for _, function := range planned {
+		fmt.Fprintf(w, "\n%s\n", appliedKey(function.Name, function.Namespace))
+
+		values := flattenApplied(function)
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			fmt.Fprintf(w, "    %s: %s\n", k, sensitive.redactFlattened(k, values[k]))
+		}
+	}
+}
+
+// selectorFlags is Selector, with the deprecated Regex and Filter fields
+// filled in where it leaves them empty
+func (o DeployOptions) selectorFlags() stack.SelectorFlags {
+	flags := o.Selector
+	if len(flags.Regex) == 0 {
+		flags.Regex = o.Regex
+	}
+	if len(flags.Filter) == 0 {
+		flags.Filter = o.Filter
+	}
+	return flags
+}
+
+// Deploy deploys functions and returns the URL of each function which was
+// deployed, progress is printed to stdout unless options.Quiet is set, when
+// only warnings are printed, to stderr
+func Deploy(ctx context.Context, options DeployOptions) ([]string, error) {
+	deployFlags := options.flags()
+	tagMode := options.TagFormat
+	progress := output.Progress(deployFlags.quiet)
+	warnings := output.Warnings(deployFlags.quiet)
+
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = commandTimeout
+	}
+
+	// The strategy is applied first so that recreate, which is the same as
+	// --replace --update=false, is not rejected for the default of --update
+	switch deployFlags.strategy {
+	case deployStrategyRolling, "":
+	case deployStrategyRecreate:
+		deployFlags.replace = true
+		deployFlags.update = false
+	default:
+		return nil, fmt.Errorf("unknown --strategy %q, use %q or %q", deployFlags.strategy, deployStrategyRolling, deployStrategyRecreate)
+	}
+
 	if deployFlags.update && deployFlags.replace {
-		fmt.Println(`Cannot specify --update and --replace at the same time. One of --update or --replace must be false.
-  --replace    removes an existing deployment before re-creating it
-  --update     performs a rolling update to a new function image or configuration (default true)`)
-		return fmt.Errorf("cannot specify --update and --replace at the same time")
+		fmt.Fprintln(warnings, i18n.T(i18n.DeployUpdateReplaceConflict))
+		return nil, i18n.Errorf(i18n.DeployUpdateReplaceConflictErr)
+	}
+
+	if deployFlags.timeout < 0 {
+		return nil, fmt.Errorf("--timeout must not be negative")
 	}
 
 	var services stack.Services
-	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter, envsubst)
+	pinnedImages := map[string]bool{}
+	if len(options.YAMLFile) > 0 {
+		selector, err := stack.NewSelector(options.selectorFlags())
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		yamlFiles := append([]string{options.YAMLFile}, options.OverrideYAMLFiles...)
+		parsedServices, err := parseStackProfile(yamlFiles, selector, options.EnvSubst, options.EnvProfile)
+		if err != nil {
+			return nil, err
 		}
 
-		parsedServices.Provider.GatewayURL = getGatewayURL(gateway, defaultGateway, parsedServices.Provider.GatewayURL, os.Getenv(openFaaSURLEnvironment))
+		parsedServices.Provider.GatewayURL = getGatewayURL(options.Gateway, defaultGateway, parsedServices.Provider.GatewayURL, os.Getenv(openFaaSURLEnvironment))
 
 		if parsedServices != nil {
 			services = *parsedServices
 		}
+
+		if err := selectTagged(&services, options.Tags); err != nil {
+			return nil, err
+		}
+
+		found := len(services.Functions)
+		warnUnknownSkips(warnings, &services, options.Skip)
+		removeSkipped(progress, &services, options.Skip, "deploy", func(function stack.Function) bool {
+			return function.SkipDeploy
+		})
+		if found > 0 && len(services.Functions) == 0 {
+			fmt.Fprintln(progress, "All of the functions were skipped, there is nothing to deploy.")
+			return nil, nil
+		}
+
+		if len(options.ImagesFile) > 0 {
+			images, err := readImagesFile(options.ImagesFile)
+			if err != nil {
+				return nil, err
+			}
+			if pinnedImages, err = pinStackImages(&services, images, options.ImagesFile); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	transport := GetDefaultCLITransport(tlsInsecure, &commandTimeout)
-	ctx := context.Background()
+	transport := GetDefaultCLITransport(options.TLSInsecure, &timeout)
 
 	var failedStatusCodes = make(map[string]int)
+	var failedErrs = make(map[string]error)
+	var deployedURLs []string
+	var waitClient *proxy.Client
+	var waitTargets []waitTarget
+	var applied []appliedFunction
 	if len(services.Functions) > 0 {
+		if err := fixFunctionNames(&services, deployFlags.fixName); err != nil {
+			return nil, err
+		}
 
-		cliAuth, err := proxy.NewCLIAuth(token, services.Provider.GatewayURL)
+		cliAuth, err := proxy.NewCLIAuth(options.Token, services.Provider.GatewayURL)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		proxyClient, err := proxy.NewClient(cliAuth, services.Provider.GatewayURL, transport, &commandTimeout)
+		proxyClient, err := proxy.NewClient(cliAuth, services.Provider.GatewayURL, transport, &timeout)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		proxyClient.CallID = options.RequestID
+		proxyClient.Quiet = deployFlags.quiet
+		waitClient = proxyClient
+
+		namespaces := []string{}
+		for _, name := range services.FunctionNames() {
+			function := services.Functions[name]
+			if len(function.Secrets) > 0 || len(deployFlags.secrets) > 0 {
+				namespaces = append(namespaces, getNamespace(options.Namespace, function.Namespace))
+			}
+		}
+		var extensionHandler providerExtensionHandler
+		var extension stack.ProviderExtension
+		var orchestration string
+		if usesNodeSelector(&services) || (options.plan == nil && len(services.Provider.Extensions) > 0) {
+			orchestration = gatewayOrchestration(ctx, proxyClient)
+		}
+		if options.plan == nil {
+			references := prefetchReferences(ctx, proxyClient, namespaces)
+
+			missing := []string{}
+			for _, name := range services.FunctionNames() {
+				function := services.Functions[name]
+				secrets := mergeSlice(function.Secrets, deployFlags.secrets)
+				missing = append(missing, references.missingReferences(name, getNamespace(options.Namespace, function.Namespace), secrets)...)
+			}
+			if err := checkReferences(warnings, missing, deployFlags.skipSecretCheck); err != nil {
+				return nil, err
+			}
+
+			if usage := stackFeatureUsage(&services, options.Namespace, deployFlags); usesFeatures(usage) {
+				if orchestration, capabilities, ok := gatewayCapabilities(ctx, proxyClient); ok {
+					if err := checkCapabilities(warnings, orchestration, capabilities, usage, deployFlags.strictCapabilities); err != nil {
+						return nil, err
+					}
+				}
+			}
+
+			if len(services.Provider.Extensions) > 0 {
+				var warning string
+				extensionHandler, extension, warning = resolveProviderExtension(services.Provider, orchestration)
+				if len(warning) > 0 {
+					fmt.Fprintln(warnings, output.Warning("%s", warning))
+				}
+			}
+		}
+
+		provenance := map[string]string{}
+		if !deployFlags.noProvenance {
+			provenance = getProvenance(nil)
 		}
 
-		for k, function := range services.Functions {
+		templates := &labelTemplates{}
+		fromVault := &vaultSecrets{}
+
+		for _, k := range services.FunctionNames() {
+			function := services.Functions[k]
 
 			functionSecrets := deployFlags.secrets
 
 			function.Name = k
-			fmt.Printf("Deploying: %s.\n", function.Name)
+			fmt.Fprint(progress, i18n.T(i18n.DeployingFunction, function.Name))
 
 			var functionConstraints []string
 			if function.Constraints != nil {
@@ -187,13 +652,30 @@ func runDeployCommand(args []string, image string, fprocess string, functionName
 				functionSecrets = mergeSlice(function.Secrets, functionSecrets)
 			}
 
+			if err := stack.ValidateSecretHints(function.Secrets, function.SecretHints); err != nil {
+				return nil, fmt.Errorf("function %s: %s", function.Name, err)
+			}
+			for _, note := range secretHintNotes(function) {
+				fmt.Fprintln(warnings, output.Warning("%s", note))
+			}
+
 			// Check if there is a functionNamespace flag passed, if so, override the namespace value
 			// defined in the stack.yaml
-			function.Namespace = getNamespace(functionNamespace, function.Namespace)
+			function.Namespace = getNamespace(options.Namespace, function.Namespace)
 
-			fileEnvironment, err := readFiles(function.EnvironmentFile)
+			// A plan must not read from vault or write to the gateway
+			if options.plan != nil {
+				functionSecrets, err = fromVault.names(functionSecrets, function.Namespace)
+			} else {
+				functionSecrets, err = fromVault.resolve(ctx, proxyClient, progress, functionSecrets, function.Namespace)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			fileEnvironment, err := readFunctionEnvironmentFiles(function, deployFlags.envFiles)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			labelMap := map[string]string{}
@@ -203,32 +685,48 @@ func runDeployCommand(args []string, image string, fprocess string, functionName
 
 			labelArgumentMap, labelErr := parseMap(deployFlags.labelOpts, "label")
 			if labelErr != nil {
-				return fmt.Errorf("error parsing labels: %v", labelErr)
+				return nil, fmt.Errorf("error parsing labels: %v", labelErr)
 			}
 
-			allLabels := mergeMap(labelMap, labelArgumentMap)
+			allLabels, err := templates.expand(mergeMap(labelMap, labelArgumentMap))
+			if err != nil {
+				return nil, err
+			}
 
 			allEnvironment, envErr := compileEnvironment(deployFlags.envvarOpts, function.Environment, fileEnvironment)
 			if envErr != nil {
-				return envErr
+				return nil, envErr
 			}
 
-			if readTemplate {
+			allEnvironment, err = addWatchdogEnvironment(function, allEnvironment)
+			if err != nil {
+				return nil, err
+			}
+
+			allEnvironment = applyTimeout(allEnvironment, deployFlags.timeout)
+			if msg := checkTimeouts(function.Name, allEnvironment); len(msg) > 0 {
+				fmt.Fprintln(warnings, output.Warning("%s", msg))
+			}
+
+			for _, warning := range function.Lint(options.ReadTemplate) {
+				fmt.Fprintln(warnings, output.Warning("Function %s: %s", function.Name, warning))
+			}
+
+			if options.ReadTemplate {
 				// Get FProcess to use from the ./template/template.yml, if a template is being used
 				if languageExistsNotDockerfile(function.Language) {
 					var fprocessErr error
 
 					function.FProcess, fprocessErr = deriveFprocess(function)
 					if fprocessErr != nil {
-						return fmt.Errorf(`template directory may be missing or invalid, please run "faas-cli template pull"
-Error: %s`, fprocessErr.Error())
+						return nil, i18n.Errorf(i18n.DeployTemplateMissing, fprocessErr.Error())
 					}
 				}
 			}
 
-			functionResourceRequest := proxy.FunctionResourceRequest{
-				Limits:   function.Limits,
-				Requests: function.Requests,
+			functionResourceRequest, err := resolveResources(function.Name, function.Limits, function.Requests, deployFlags)
+			if err != nil {
+				return nil, err
 			}
 
 			var annotations map[string]string
@@ -238,22 +736,39 @@ Error: %s`, fprocessErr.Error())
 
 			annotationArgs, annotationErr := parseMap(deployFlags.annotationOpts, "annotation")
 			if annotationErr != nil {
-				return fmt.Errorf("error parsing annotations: %v", annotationErr)
+				return nil, fmt.Errorf("error parsing annotations: %v", annotationErr)
 			}
 
-			allAnnotations := mergeMap(annotations, annotationArgs)
+			allAnnotations := mergeMap(mergeMap(provenance, annotations), annotationArgs)
 
-			branch, sha, err := builder.GetImageTagValues(tagMode)
+			allAnnotations, functionConstraints, err = addKubernetesOptions(warnings, function, allAnnotations, functionConstraints, orchestration)
 			if err != nil {
-				return err
+				return nil, err
+			}
+
+			// A prebuilt image is deployed as it is given, the tag is only
+			// changed for images which faas-cli built and which were not
+			// pinned by --images-file
+			if !function.Prebuilt() && !pinnedImages[k] {
+				branch, sha, err := builder.GetImageTagValues(tagMode)
+				if err != nil {
+					return nil, err
+				}
+
+				function.Image = schema.BuildImageName(tagMode, function.Image, sha, branch)
 			}
 
-			function.Image = schema.BuildImageName(tagMode, function.Image, sha, branch)
+			if deployFlags.checkImage {
+				warnUnpushedImage(ctx, warnings, function.Name, function.Image)
+			}
 
 			if deployFlags.readOnlyRootFilesystem {
 				function.ReadOnlyRootFilesystem = deployFlags.readOnlyRootFilesystem
 			}
 
+			allEnvironment = expandEnvTemplates(allEnvironment,
+				envTemplateValues(services.Provider.GatewayURL, function.Name, function.Namespace, function.Image))
+
 			deploySpec := &proxy.DeployFunctionSpec{
 				FProcess:                function.FProcess,
 				FunctionName:            function.Name,
@@ -268,55 +783,103 @@ Error: %s`, fprocessErr.Error())
 				Annotations:             allAnnotations,
 				FunctionResourceRequest: functionResourceRequest,
 				ReadOnlyRootFilesystem:  function.ReadOnlyRootFilesystem,
-				TLSInsecure:             tlsInsecure,
-				Token:                   token,
+				TLSInsecure:             options.TLSInsecure,
+				Token:                   options.Token,
 				Namespace:               function.Namespace,
 			}
 
+			if err := applyProviderExtension(extensionHandler, extension, deploySpec); err != nil {
+				return nil, err
+			}
+
+			if options.plan != nil {
+				options.plan(services.Provider.GatewayURL, deploySpec)
+				continue
+			}
+
 			if msg := checkTLSInsecure(services.Provider.GatewayURL, deploySpec.TLSInsecure); len(msg) > 0 {
-				fmt.Println(msg)
+				fmt.Fprintln(warnings, output.Warning("%s", msg))
 			}
-			statusCode := proxyClient.DeployFunction(ctx, deploySpec)
-			if badStatusCode(statusCode) {
+			done := timings.trackResult(phaseGateway, deployStep+function.Name)
+			statusCode, err := proxyClient.DeployFunctionWithError(ctx, deploySpec)
+			done(deployStatusError(function.Name, statusCode))
+			if err != nil {
 				failedStatusCodes[k] = statusCode
+				failedErrs[k] = err
+			} else {
+				deployedURLs = append(deployedURLs, functionURL(services.Provider.GatewayURL, function.Name, function.Namespace))
+				waitTargets = append(waitTargets, waitTarget{Name: function.Name, Namespace: function.Namespace, Image: deploySpec.Image})
+				applied = append(applied, newAppliedFunction(deploySpec, time.Now()))
+			}
+		}
+
+		if options.Record && len(applied) > 0 {
+			if err := recordApplied(services.Provider.GatewayURL, applied); err != nil {
+				fmt.Fprintln(warnings, output.Warning("Unable to record the deployment: %s", err))
 			}
 		}
 	} else {
-		if len(image) == 0 || len(functionName) == 0 {
-			return fmt.Errorf("To deploy a function give --yaml/-f or a --image and --name flag")
+		if len(options.Image) == 0 || len(options.FunctionName) == 0 {
+			return nil, i18n.Errorf(i18n.DeployMissingImageOrName)
 		}
-		gateway = getGatewayURL(gateway, defaultGateway, "", os.Getenv(openFaaSURLEnvironment))
-		cliAuth, err := proxy.NewCLIAuth(token, gateway)
+		name, err := fixFunctionName(options.FunctionName, deployFlags.fixName)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		proxyClient, err := proxy.NewClient(cliAuth, gateway, transport, &commandTimeout)
+		options.FunctionName = name
+
+		gatewayAddress := getGatewayURL(options.Gateway, defaultGateway, "", os.Getenv(openFaaSURLEnvironment))
+		cliAuth, err := proxy.NewCLIAuth(options.Token, gatewayAddress)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		proxyClient, err := proxy.NewClient(cliAuth, gatewayAddress, transport, &timeout)
+		if err != nil {
+			return nil, err
+		}
+		proxyClient.CallID = options.RequestID
+		proxyClient.Quiet = deployFlags.quiet
+
+		if len(deployFlags.secrets) > 0 {
+			references := prefetchReferences(ctx, proxyClient, []string{options.Namespace})
+			missing := references.missingReferences(options.FunctionName, options.Namespace, deployFlags.secrets)
+			if err := checkReferences(warnings, missing, deployFlags.skipSecretCheck); err != nil {
+				return nil, err
+			}
+		}
+
+		if deployFlags.checkImage {
+			warnUnpushedImage(ctx, warnings, options.FunctionName, options.Image)
 		}
 
 		// default to a readable filesystem until we get more input about the expected behavior
 		// and if we want to add another flag for this case
 		defaultReadOnlyRFS := false
-		statusCode, err := deployImage(ctx, proxyClient, image, fprocess, functionName, "", deployFlags,
-			tlsInsecure, defaultReadOnlyRFS, token, functionNamespace)
-		if err != nil {
-			return err
+		if _, err := deployImage(ctx, proxyClient, options.Image, options.FProcess, options.FunctionName, "", deployFlags,
+			options.TLSInsecure, defaultReadOnlyRFS, options.Token, options.Namespace); err != nil {
+			return nil, err
 		}
 
-		if badStatusCode(statusCode) {
-			failedStatusCodes[functionName] = statusCode
-		}
+		deployedURLs = append(deployedURLs, functionURL(gatewayAddress, options.FunctionName, options.Namespace))
+		waitTargets = append(waitTargets, waitTarget{Name: options.FunctionName, Namespace: options.Namespace, Image: options.Image})
+		waitClient = proxyClient
 	}
 
-	if err := deployFailed(failedStatusCodes); err != nil {
-		return err
+	if err := deployFailed(failedStatusCodes, failedErrs); err != nil {
+		return deployedURLs, err
 	}
 
-	return nil
+	if deployFlags.wait && waitClient != nil {
+		if err := waitForFunctions(ctx, waitClient, waitTargets, deployFlags.waitTimeout, os.Stderr, stderrIsTerminal()); err != nil {
+			return deployedURLs, err
+		}
+	}
+
+	return deployedURLs, nil
 }
 
-// deployImage deploys a function with the given image
+// deployImage deploys a function with the given image, the error is a
+// DeployError when the gateway did not deploy it
 func deployImage(
 	ctx context.Context,
 	client *proxy.Client,
@@ -332,25 +895,48 @@ func deployImage(
 ) (int, error) {
 
 	var statusCode int
+	warnings := output.Warnings(deployFlags.quiet)
 	readOnlyRFS := deployFlags.readOnlyRootFilesystem || readOnlyRootFilesystem
-	envvars, err := parseMap(deployFlags.envvarOpts, "env")
+	fileEnvironment, err := readFunctionEnvironmentFiles(stack.Function{}, deployFlags.envFiles)
+	if err != nil {
+		return statusCode, err
+	}
 
+	envvars, err := compileEnvironment(deployFlags.envvarOpts, nil, fileEnvironment)
 	if err != nil {
-		return statusCode, fmt.Errorf("error parsing envvars: %v", err)
+		return statusCode, err
 	}
 
+	envvars = applyTimeout(envvars, deployFlags.timeout)
+	if msg := checkTimeouts(functionName, envvars); len(msg) > 0 {
+		fmt.Fprintln(warnings, output.Warning("%s", msg))
+	}
+
+	envvars = expandEnvTemplates(envvars,
+		envTemplateValues(client.GatewayURL.String(), functionName, namespace, image))
+
 	labelMap, labelErr := parseMap(deployFlags.labelOpts, "label")
 
 	if labelErr != nil {
 		return statusCode, fmt.Errorf("error parsing labels: %v", labelErr)
 	}
 
+	labelMap, err = (&labelTemplates{}).expand(labelMap)
+	if err != nil {
+		return statusCode, err
+	}
+
 	annotationMap, annotationErr := parseMap(deployFlags.annotationOpts, "annotation")
 
 	if annotationErr != nil {
 		return statusCode, fmt.Errorf("error parsing annotations: %v", annotationErr)
 	}
 
+	functionResourceRequest, err := resolveResources(functionName, nil, nil, deployFlags)
+	if err != nil {
+		return statusCode, err
+	}
+
 	deploySpec := &proxy.DeployFunctionSpec{
 		FProcess:                fprocess,
 		FunctionName:            functionName,
@@ -364,22 +950,86 @@ func deployImage(
 		Secrets:                 deployFlags.secrets,
 		Labels:                  labelMap,
 		Annotations:             annotationMap,
-		FunctionResourceRequest: proxy.FunctionResourceRequest{},
+		FunctionResourceRequest: functionResourceRequest,
 		ReadOnlyRootFilesystem:  readOnlyRFS,
 		TLSInsecure:             tlsInsecure,
 		Token:                   token,
 		Namespace:               namespace,
 	}
 
-	if msg := checkTLSInsecure(gateway, deploySpec.TLSInsecure); len(msg) > 0 {
-		fmt.Println(msg)
+	if msg := checkTLSInsecure(client.GatewayURL.String(), deploySpec.TLSInsecure); len(msg) > 0 {
+		fmt.Fprintln(warnings, output.Warning("%s", msg))
 	}
 
-	statusCode = client.DeployFunction(ctx, deploySpec)
+	done := timings.trackResult(phaseGateway, deployStep+functionName)
+	statusCode, err = client.DeployFunctionWithError(ctx, deploySpec)
+	done(deployStatusError(functionName, statusCode))
+	if err != nil {
+		return statusCode, deployFailed(map[string]int{functionName: statusCode}, map[string]error{functionName: err})
+	}
 
 	return statusCode, nil
 }
 
+// resolveResources applies the resource flags over the limits and requests from
+// stack.yml, then validates the units and prints the values which will be used
+func resolveResources(functionName string, limits, requests *stack.FunctionResources, deployFlags DeployFlags) (proxy.FunctionResourceRequest, error) {
+	limits = overrideResources(limits, deployFlags.memoryLimit, deployFlags.cpuLimit)
+	requests = overrideResources(requests, deployFlags.memoryRequest, deployFlags.cpuRequest)
+
+	warnings, err := stack.ValidateResources(limits, requests)
+	if err != nil {
+		return proxy.FunctionResourceRequest{}, fmt.Errorf("function %s has invalid %s", functionName, err)
+	}
+
+	for _, warning := range warnings {
+		fmt.Fprintln(output.Warnings(deployFlags.quiet), output.Warning("Function %s: %s", functionName, warning))
+	}
+
+	if description := describeResources(limits, requests); len(description) > 0 {
+		fmt.Fprintf(output.Progress(deployFlags.quiet), "Resources: %s\n", description)
+	}
+
+	return proxy.FunctionResourceRequest{Limits: limits, Requests: requests}, nil
+}
+
+func overrideResources(resources *stack.FunctionResources, memory, cpu string) *stack.FunctionResources {
+	if len(memory) == 0 && len(cpu) == 0 {
+		return resources
+	}
+
+	overridden := stack.FunctionResources{}
+	if resources != nil {
+		overridden = *resources
+	}
+	if len(memory) > 0 {
+		overridden.Memory = memory
+	}
+	if len(cpu) > 0 {
+		overridden.CPU = cpu
+	}
+	return &overridden
+}
+
+func describeResources(limits, requests *stack.FunctionResources) string {
+	parts := []string{}
+	for _, set := range []struct {
+		name      string
+		resources *stack.FunctionResources
+	}{{"requests", requests}, {"limits", limits}} {
+		if set.resources == nil {
+			continue
+		}
+		if len(set.resources.CPU) > 0 {
+			parts = append(parts, fmt.Sprintf("%s.cpu=%s", set.name, set.resources.CPU))
+		}
+		if len(set.resources.Memory) > 0 {
+			parts = append(parts, fmt.Sprintf("%s.memory=%s", set.name, set.resources.Memory))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
 func mergeSlice(values []string, overlay []string) []string {
 	results := []string{}
 	added := make(map[string]bool)
@@ -406,6 +1056,14 @@ func readFiles(files []string) (map[string]string, error) {
 			return nil, readErr
 		}
 
+		if isSOPSEncrypted(bytesOut) {
+			decrypted, decryptErr := decryptSOPSFile(file)
+			if decryptErr != nil {
+				return nil, decryptErr
+			}
+			bytesOut = decrypted
+		}
+
 		envFile := stack.EnvironmentFile{}
 		unmarshalErr := yaml.Unmarshal(bytesOut, &envFile)
 		if unmarshalErr != nil {
@@ -440,6 +1098,30 @@ func parseMap(envvars []string, keyName string) (map[string]string, error) {
 	return result, nil
 }
 
+// readFunctionEnvironmentFiles reads the function's environment_file and
+// env_file, followed by any extra dotenv files, later files take precedence
+func readFunctionEnvironmentFiles(function stack.Function, envFiles []string) (map[string]string, error) {
+	envs, err := readFiles(function.EnvironmentFile)
+	if err != nil {
+		return nil, err
+	}
+
+	dotEnvFiles := envFiles
+	if len(function.EnvFile) > 0 {
+		dotEnvFiles = append([]string{function.EnvFile}, envFiles...)
+	}
+
+	for _, file := range dotEnvFiles {
+		dotEnv, err := stack.ParseDotEnvFile(file)
+		if err != nil {
+			return nil, err
+		}
+		envs = mergeMap(envs, dotEnv)
+	}
+
+	return envs, nil
+}
+
 func mergeMap(i map[string]string, j map[string]string) map[string]string {
 	merged := make(map[string]string)
 
@@ -462,10 +1144,105 @@ func compileEnvironment(envvarOpts []string, yamlEnvironment map[string]string,
 	return mergeMap(functionAndStack, envvarArguments), nil
 }
 
+// addWatchdogEnvironment adds the environment variables for the function's
+// watchdog block, after checking that its template supports the mode. A
+// variable which is also set to a different value elsewhere is an error.
+func addWatchdogEnvironment(function stack.Function, envs map[string]string) (map[string]string, error) {
+	watchdogEnvs, err := function.Watchdog.Environment()
+	if err != nil {
+		return nil, fmt.Errorf("function %s: %s", function.Name, err)
+	}
+	if len(watchdogEnvs) == 0 {
+		return envs, nil
+	}
+
+	// The template is read without the version of a pinned language, i.e.
+	// python3@3.11, and may not have been pulled where the function is deployed
+	language, _ := stack.SplitLanguage(function.Language)
+	if languageExistsNotDockerfile(language) {
+		template, err := stack.LoadLanguageTemplate(language)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("function %s: %s", function.Name, err)
+		}
+		if err := stack.ValidateWatchdog(function.Watchdog, template); err != nil {
+			return nil, fmt.Errorf("function %s: %s", function.Name, err)
+		}
+	}
+
+	for k, v := range watchdogEnvs {
+		if existing, ok := envs[k]; ok && existing != v {
+			return nil, fmt.Errorf("function %s: %s is set to %q by the watchdog block and to %q in the environment, remove one of them", function.Name, k, v, existing)
+		}
+	}
+	return mergeMap(envs, watchdogEnvs), nil
+}
+
+// usesNodeSelector is true when a function has a node_selector in its k8s
+// block, which is only applied when the gateway runs on Kubernetes
+func usesNodeSelector(services *stack.Services) bool {
+	for _, function := range services.Functions {
+		if function.K8s != nil && len(function.K8s.NodeSelector) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// addKubernetesOptions adds the annotation for the function's service account
+// and, when the gateway's orchestration is kubernetes, a constraint for each
+// label of its node selector, which faas-netes reads. Other providers would
+// read the labels as constraints of their own, so they are left out with a
+// warning to w. A node selector is an error when the orchestration is not known.
+func addKubernetesOptions(w io.Writer, function stack.Function, annotations map[string]string, constraints []string, orchestration string) (map[string]string, []string, error) {
+	k8sAnnotations, err := function.K8s.Annotations()
+	if err != nil {
+		return nil, nil, fmt.Errorf("function %s: %s", function.Name, err)
+	}
+	nodeSelector, err := function.K8s.Constraints()
+	if err != nil {
+		return nil, nil, fmt.Errorf("function %s: %s", function.Name, err)
+	}
+	if len(nodeSelector) > 0 && len(orchestration) == 0 {
+		// Deploying without the node selector could place the function on
+		// any node, so the provider must be known
+		return nil, nil, fmt.Errorf("function %s: k8s.node_selector is set, but the gateway's provider could not be read from /system/info", function.Name)
+	}
+	if len(nodeSelector) > 0 && orchestration != "kubernetes" {
+		fmt.Fprintln(w, output.Warning("function %s: k8s.node_selector is only used on Kubernetes, the gateway's provider is %s", function.Name, orchestration))
+		nodeSelector = nil
+	}
+
+	for k, v := range k8sAnnotations {
+		if existing, ok := annotations[k]; ok && existing != v {
+			return nil, nil, fmt.Errorf("function %s: %s is set to %q by the k8s block and to %q in the annotations, remove one of them", function.Name, k, v, existing)
+		}
+	}
+
+	all := append([]string{}, constraints...)
+	for _, selector := range nodeSelector {
+		key := stack.ConstraintKey(selector)
+		conflict := false
+		for _, existing := range constraints {
+			if stack.ConstraintKey(existing) == key {
+				if existing != selector {
+					return nil, nil, fmt.Errorf("function %s: node_selector %s in the k8s block conflicts with the constraint %q, remove one of them", function.Name, selector, existing)
+				}
+				conflict = true
+			}
+		}
+		if !conflict {
+			all = append(all, selector)
+		}
+	}
+
+	return mergeMap(annotations, k8sAnnotations), all, nil
+}
+
 func deriveFprocess(function stack.Function) (string, error) {
 	var fprocess string
 
-	pathToTemplateYAML := "./template/" + function.Language + "/template.yml"
+	language, _ := stack.SplitLanguage(function.Language)
+	pathToTemplateYAML := "./template/" + language + "/template.yml"
 	if _, err := os.Stat(pathToTemplateYAML); os.IsNotExist(err) {
 		return "", err
 	}
@@ -510,19 +1287,103 @@ var (
 	configDir = os.Getenv("DOCKER_CONFIG")
 )
 
-func deployFailed(status map[string]int) error {
-	if len(status) == 0 {
-		return nil
+// DeployError is returned when one or more functions failed to deploy. Why
+// each of them failed can be checked with errors.Is, i.e. for
+// proxy.ErrGatewayUnreachable or proxy.ErrDeployRejected.
+type DeployError struct {
+	// StatusCodes of the functions which failed, by name
+	StatusCodes map[string]int
+
+	// Errs is why each function failed, by name
+	Errs map[string]error
+}
+
+func (e *DeployError) Error() string {
+	names := make([]string, 0, len(e.StatusCodes))
+	for name := range e.StatusCodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	allErrors := make([]string, 0, len(names))
+	for _, name := range names {
+		message := i18n.T(i18n.DeployFailedStatus, name, e.StatusCodes[name])
+		if callID := errorCallID(e.Errs[name]); len(callID) > 0 {
+			message += fmt.Sprintf(" (%s: %s)", proxy.CallIDHeader, callID)
+		}
+		allErrors = append(allErrors, message)
+	}
+	return strings.Join(allErrors, "\n")
+}
+
+// Is matches when any of the functions failed for the target reason, a
+// function without a reason was rejected by the gateway
+func (e *DeployError) Is(target error) bool {
+	for name := range e.StatusCodes {
+		err := e.Errs[name]
+		if err == nil {
+			err = proxy.ErrDeployRejected
+		}
+		if errors.Is(err, target) {
+			return true
+		}
 	}
+	return false
+}
 
-	var allErrors []string
-	for funcName, funcStatus := range status {
-		err := fmt.Errorf("Function '%s' failed to deploy with status code: %d", funcName, funcStatus)
-		allErrors = append(allErrors, err.Error())
+// deployFailed returns a DeployError for the functions which failed, or nil
+// when all of them were deployed
+func deployFailed(status map[string]int, errs map[string]error) error {
+	if len(status) == 0 {
+		return nil
 	}
-	return fmt.Errorf(strings.Join(allErrors, "\n"))
+	return &DeployError{StatusCodes: status, Errs: errs}
 }
 
 func badStatusCode(statusCode int) bool {
 	return statusCode != http.StatusAccepted && statusCode != http.StatusOK
 }
+
+// fixFunctionNames checks the name of each function before any of them are
+// deployed, so that an invalid name fails here rather than at the gateway. A
+// function which is renamed keeps its place in the stack.
+func fixFunctionNames(services *stack.Services, fix bool) error {
+	for _, name := range services.FunctionNames() {
+		fixed, err := fixFunctionName(name, fix)
+		if err != nil {
+			return err
+		}
+		if fixed == name {
+			continue
+		}
+
+		if _, exists := services.Functions[fixed]; exists {
+			return fmt.Errorf("function %s can not be renamed to %s, as the stack already has a function with that name", name, fixed)
+		}
+		services.Functions[fixed] = services.Functions[name]
+		delete(services.Functions, name)
+	}
+	return nil
+}
+
+// deployedFunctionName is the name a function of the stack file is deployed
+// under. A name which is not valid can only have been deployed with the name
+// --fix-name gives it, so prune keeps that name and remove deletes it.
+func deployedFunctionName(name string) string {
+	if validateFunctionName(name) == nil {
+		return name
+	}
+	if suggestion := suggestFunctionName(name); len(suggestion) > 0 {
+		return suggestion
+	}
+	return name
+}
+
+// deployStatusError is why a deployment failed for --junit, it is nil when
+// the gateway accepted the deployment
+func deployStatusError(functionName string, statusCode int) error {
+	if !badStatusCode(statusCode) {
+		return nil
+	}
+	return i18n.Errorf(i18n.DeployFailedStatus, functionName, statusCode)
+}
@@ -0,0 +1,208 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/openfaas/faas-cli/config"
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/openfaas/faas-cli/stack"
+)
+
+// maxAppliedRevisions is the number of deployments kept for each function,
+// rollback can go back through all but the oldest of them
+const maxAppliedRevisions = 10
+
+// deployStateDir is the folder in the config directory with a state file
+// for each gateway
+const deployStateDir = "state"
+
+// recordDeploy is set by deploy --record
+var recordDeploy bool
+
+// deployStateName replaces the characters of a gateway URL which can not be
+// used in the name of a file
+var deployStateName = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// appliedFunction is what was sent to the gateway to deploy a function, it
+// leaves out the token
+type appliedFunction struct {
+	AppliedAt              time.Time                `json:"applied_at"`
+	Name                   string                   `json:"name"`
+	Namespace              string                   `json:"namespace,omitempty"`
+	Image                  string                   `json:"image"`
+	RegistryAuth           string                   `json:"registry_auth,omitempty"`
+	FProcess               string                   `json:"fprocess,omitempty"`
+	Language               string                   `json:"lang,omitempty"`
+	EnvVars                map[string]string        `json:"environment,omitempty"`
+	Labels                 map[string]string        `json:"labels,omitempty"`
+	Annotations            map[string]string        `json:"annotations,omitempty"`
+	Constraints            []string                 `json:"constraints,omitempty"`
+	Secrets                []string                 `json:"secrets,omitempty"`
+	Limits                 *stack.FunctionResources `json:"limits,omitempty"`
+	Requests               *stack.FunctionResources `json:"requests,omitempty"`
+	ReadOnlyRootFilesystem bool                     `json:"readonly_root_filesystem,omitempty"`
+}
+
+// deployState is the last deployments of each function to a gateway, by
+// the name and namespace of the function, the latest is last
+type deployState struct {
+	Gateway   string                       `json:"gateway"`
+	Functions map[string][]appliedFunction `json:"functions"`
+}
+
+func newAppliedFunction(spec *proxy.DeployFunctionSpec, now time.Time) appliedFunction {
+	return appliedFunction{
+		AppliedAt:              now.UTC(),
+		Name:                   spec.FunctionName,
+		Namespace:              spec.Namespace,
+		Image:                  spec.Image,
+		RegistryAuth:           spec.RegistryAuth,
+		FProcess:               spec.FProcess,
+		Language:               spec.Language,
+		EnvVars:                spec.EnvVars,
+		Labels:                 spec.Labels,
+		Annotations:            spec.Annotations,
+		Constraints:            spec.Constraints,
+		Secrets:                spec.Secrets,
+		Limits:                 spec.FunctionResourceRequest.Limits,
+		Requests:               spec.FunctionResourceRequest.Requests,
+		ReadOnlyRootFilesystem: spec.ReadOnlyRootFilesystem,
+	}
+}
+
+// spec returns the request to deploy the function again
+func (f appliedFunction) spec() *proxy.DeployFunctionSpec {
+	return &proxy.DeployFunctionSpec{
+		FunctionName: f.Name,
+		Namespace:    f.Namespace,
+		Image:        f.Image,
+		RegistryAuth: f.RegistryAuth,
+		FProcess:     f.FProcess,
+		Language:     f.Language,
+		EnvVars:      f.EnvVars,
+		Labels:       f.Labels,
+		Annotations:  f.Annotations,
+		Constraints:  f.Constraints,
+		Secrets:      f.Secrets,
+		FunctionResourceRequest: proxy.FunctionResourceRequest{
+			Limits:   f.Limits,
+			Requests: f.Requests,
+		},
+		ReadOnlyRootFilesystem: f.ReadOnlyRootFilesystem,
+		Update:                 true,
+	}
+}
+
+func appliedKey(name, namespace string) string {
+	if len(namespace) > 0 {
+		return name + "." + namespace
+	}
+	return name
+}
+
+// deployStatePath is the state file of a gateway, the scheme is part of its
+// name as http:// and https:// on the same host may be different gateways
+func deployStatePath(gateway string) string {
+	name := deployStateName.ReplaceAllString(strings.TrimRight(gateway, "/"), "_")
+	return filepath.Join(config.ConfigDir(), deployStateDir, name+".json")
+}
+
+// loadDeployState reads the state file of a gateway, which is empty when
+// nothing has been recorded yet
+func loadDeployState(gateway string) (*deployState, error) {
+	state := &deployState{Gateway: gateway, Functions: map[string][]appliedFunction{}}
+
+	data, err := ioutil.ReadFile(deployStatePath(gateway))
+	if os.IsNotExist(err) {
+		return state, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to read the deployment state: %s", err)
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("unable to parse the deployment state %s: %s", deployStatePath(gateway), err)
+	}
+	if state.Functions == nil {
+		state.Functions = map[string][]appliedFunction{}
+	}
+	return state, nil
+}
+
+// save writes the state file, it may have secrets in the environment of a
+// function, so only the user can read it
+func (s *deployState) save() error {
+	path := deployStatePath(s.Gateway)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("unable to create the deployment state folder: %s", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("unable to write the deployment state: %s", err)
+	}
+	return nil
+}
+
+// record adds a deployment of a function, and drops the oldest when there
+// are more than maxAppliedRevisions
+func (s *deployState) record(applied appliedFunction) {
+	key := appliedKey(applied.Name, applied.Namespace)
+	revisions := append(s.Functions[key], applied)
+	if len(revisions) > maxAppliedRevisions {
+		revisions = revisions[len(revisions)-maxAppliedRevisions:]
+	}
+	s.Functions[key] = revisions
+}
+
+// lastApplied is the latest deployment of a function
+func (s *deployState) lastApplied(name, namespace string) (appliedFunction, bool) {
+	revisions := s.Functions[appliedKey(name, namespace)]
+	if len(revisions) == 0 {
+		return appliedFunction{}, false
+	}
+	return revisions[len(revisions)-1], true
+}
+
+// previous is the deployment before the latest, which rollback deploys
+func (s *deployState) previous(name, namespace string) (appliedFunction, error) {
+	revisions := s.Functions[appliedKey(name, namespace)]
+	if len(revisions) < 2 {
+		return appliedFunction{}, fmt.Errorf("there is no earlier deployment of %s recorded for %s, deploy with --record to keep a history", appliedKey(name, namespace), s.Gateway)
+	}
+	return revisions[len(revisions)-2], nil
+}
+
+// rolledBack drops the latest deployment of a function, so that the one
+// before it is the latest
+func (s *deployState) rolledBack(name, namespace string) {
+	key := appliedKey(name, namespace)
+	if revisions := s.Functions[key]; len(revisions) > 0 {
+		s.Functions[key] = revisions[:len(revisions)-1]
+	}
+}
+
+// recordApplied adds the functions which were deployed to the state file of
+// the gateway
+func recordApplied(gateway string, applied []appliedFunction) error {
+	state, err := loadDeployState(gateway)
+	if err != nil {
+		return err
+	}
+	for _, function := range applied {
+		state.record(function)
+	}
+	return state.save()
+}
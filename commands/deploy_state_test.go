@@ -0,0 +1,184 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas-cli/config"
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/openfaas/faas-cli/test"
+)
+
+func Test_deployState_recordAndRollBack(t *testing.T) {
+	state := &deployState{Gateway: "http://127.0.0.1:8080", Functions: map[string][]appliedFunction{}}
+
+	if _, err := state.previous("api", "dev"); err == nil {
+		t.Fatalf("want an error when nothing was recorded")
+	}
+
+	for i := 0; i < maxAppliedRevisions+2; i++ {
+		state.record(appliedFunction{Name: "api", Namespace: "dev", Image: fmt.Sprintf("alexellis/api:%d", i)})
+	}
+
+	revisions := state.Functions["api.dev"]
+	if len(revisions) != maxAppliedRevisions || revisions[0].Image != "alexellis/api:2" {
+		t.Fatalf("want the last %d revisions to be kept, got %d from %s", maxAppliedRevisions, len(revisions), revisions[0].Image)
+	}
+
+	previous, err := state.previous("api", "dev")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if previous.Image != fmt.Sprintf("alexellis/api:%d", maxAppliedRevisions) {
+		t.Fatalf("want the revision before the latest, got %s", previous.Image)
+	}
+
+	state.rolledBack("api", "dev")
+	last, _ := state.lastApplied("api", "dev")
+	if last.Image != previous.Image {
+		t.Fatalf("want %s to be the latest after a rollback, got %s", previous.Image, last.Image)
+	}
+}
+
+func Test_appliedFunction_specKeepsRegistryAuth(t *testing.T) {
+	spec := &proxy.DeployFunctionSpec{
+		FunctionName: "api",
+		Image:        "registry.example.com/api:0.1",
+		RegistryAuth: "dXNlcjpwYXNz",
+		Token:        "t0k3n",
+	}
+
+	restored := newAppliedFunction(spec, time.Now()).spec()
+	if restored.RegistryAuth != spec.RegistryAuth {
+		t.Fatalf("want the registry auth %q to be restored, got %q", spec.RegistryAuth, restored.RegistryAuth)
+	}
+	if len(restored.Token) > 0 {
+		t.Fatalf("want the token to be left out, got %q", restored.Token)
+	}
+}
+
+func Test_deployStatePath(t *testing.T) {
+	os.Setenv(config.ConfigLocationEnv, "/tmp/openfaas")
+	defer os.Unsetenv(config.ConfigLocationEnv)
+
+	got := deployStatePath("https://gw.example.com:8080/")
+	want := filepath.Join("/tmp/openfaas", "state", "https_gw.example.com_8080.json")
+	if got != want {
+		t.Fatalf("want %s, got %s", want, got)
+	}
+
+	if insecure := deployStatePath("http://gw.example.com:8080"); insecure == got {
+		t.Fatalf("want http:// and https:// gateways to have their own state file, got %s for both", got)
+	}
+}
+
+func Test_diffApplied(t *testing.T) {
+	last := flattenApplied(appliedFunction{
+		Image:   "alexellis/api:0.1",
+		EnvVars: map[string]string{"MODE": "test", "DEBUG": "true"},
+	})
+	planned := flattenApplied(appliedFunction{
+		Image:   "alexellis/api:0.2",
+		EnvVars: map[string]string{"MODE": "test"},
+		Labels:  map[string]string{"team": "web"},
+	})
+
+	want := []string{
+		"- environment.DEBUG: true",
+		"~ image: alexellis/api:0.1 -> alexellis/api:0.2",
+		"+ labels.team: web",
+	}
+	if got := diffApplied(last, planned, sensitiveKeys{}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+}
+
+func Test_Deploy_recordAndDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "faas-cli-state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Setenv(config.ConfigLocationEnv, dir)
+	defer os.Unsetenv(config.ConfigLocationEnv)
+
+	stackFile := filepath.Join(dir, "stack.yml")
+	writeStack := func(image string) {
+		ioutil.WriteFile(stackFile, []byte(`version: 1.0
+provider:
+  name: openfaas
+functions:
+  fn1:
+    image: `+image+`
+`), 0600)
+	}
+
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodPut,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusOK,
+		},
+	})
+	defer s.Close()
+
+	writeStack("functions/fn1:0.1")
+	test.CaptureStdout(func() {
+		_, err = Deploy(context.Background(), DeployOptions{
+			YAMLFile:     stackFile,
+			Gateway:      s.URL,
+			Update:       true,
+			NoProvenance: true,
+			Record:       true,
+		})
+	})
+	if err != nil {
+		t.Fatalf("want no error, got: %s", err)
+	}
+
+	writeStack("functions/fn1:0.2")
+	var planned []appliedFunction
+	test.CaptureStdout(func() {
+		_, err = Deploy(context.Background(), DeployOptions{
+			YAMLFile:     stackFile,
+			Gateway:      s.URL,
+			Update:       true,
+			NoProvenance: true,
+			plan: func(gateway string, spec *proxy.DeployFunctionSpec) {
+				planned = append(planned, newAppliedFunction(spec, time.Now()))
+			},
+		})
+	})
+	if err != nil {
+		t.Fatalf("want no error, got: %s", err)
+	}
+	if len(s.Requests()) != 1 {
+		t.Fatalf("want the plan not to be deployed, got %d requests", len(s.Requests()))
+	}
+
+	state, err := loadDeployState(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if !printAppliedDiff(&out, state, planned, sensitiveKeys{}) {
+		t.Fatalf("want a change to be found")
+	}
+	if !strings.Contains(out.String(), "~ image: functions/fn1:0.1 -> functions/fn1:0.2") {
+		t.Fatalf("want the image change, got:\n%s", out.String())
+	}
+}
@@ -4,12 +4,19 @@
 package commands
 
 import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/openfaas/faas-cli/stack"
 	"github.com/openfaas/faas-cli/test"
 )
 
@@ -42,6 +49,260 @@ func Test_deploy(t *testing.T) {
 	}
 }
 
+func Test_deploy_quiet(t *testing.T) {
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodPut,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusOK,
+		},
+	})
+	defer s.Close()
+	defer func() {
+		deployFlags.quiet = false
+		functionNamespace = ""
+	}()
+
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{
+			"deploy",
+			"--gateway=" + s.URL,
+			"--image=golang",
+			"--name=test-function",
+			"--namespace=dev",
+			"--quiet",
+		})
+		faasCmd.Execute()
+	})
+
+	want := s.URL + "/function/test-function.dev\n"
+	if stdOut != want {
+		t.Fatalf("want only the function URL: %q, but got: %q", want, stdOut)
+	}
+}
+
+func Test_Deploy_withOptions(t *testing.T) {
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method: http.MethodPut,
+			Uri:    "/system/functions",
+			RequestBody: map[string]interface{}{
+				"service":     "test-function",
+				"image":       "golang",
+				"namespace":   "dev",
+				"envVars":     map[string]string{"MODE": "test"},
+				"labels":      map[string]string{},
+				"annotations": map[string]string{},
+			},
+			ResponseStatusCode: http.StatusOK,
+		},
+	})
+	defer s.Close()
+
+	gatewayFlag := gateway
+
+	var deployedURLs []string
+	var err error
+	test.CaptureStdout(func() {
+		deployedURLs, err = Deploy(context.Background(), DeployOptions{
+			Gateway:      s.URL,
+			Image:        "golang",
+			FunctionName: "test-function",
+			Namespace:    "dev",
+			Env:          []string{"MODE=test"},
+			Update:       true,
+		})
+	})
+
+	if err != nil {
+		t.Fatalf("want no error, got: %s", err)
+	}
+
+	want := []string{s.URL + "/function/test-function.dev"}
+	if len(deployedURLs) != 1 || deployedURLs[0] != want[0] {
+		t.Errorf("want: %v, got: %v", want, deployedURLs)
+	}
+
+	if gateway != gatewayFlag {
+		t.Errorf("want the gateway flag to be left as %s, got: %s", gatewayFlag, gateway)
+	}
+}
+
+func Test_Deploy_strategyRecreate(t *testing.T) {
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodPut,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusOK,
+		},
+	})
+	defer s.Close()
+
+	var err error
+	stdout := test.CaptureStdout(func() {
+		_, err = Deploy(context.Background(), DeployOptions{
+			Gateway:      s.URL,
+			Image:        "golang",
+			FunctionName: "test-function",
+			Update:       true,
+			Strategy:     deployStrategyRecreate,
+		})
+	})
+
+	if err != nil {
+		t.Fatalf("want no error for the default of --update, got: %s", err)
+	}
+	if !strings.Contains(stdout, "was updated in place") {
+		t.Fatalf("want the function updated in place, got: %s", stdout)
+	}
+}
+
+func Test_DeployOptions_selectorFlags(t *testing.T) {
+	cases := []struct {
+		name    string
+		options DeployOptions
+		want    stack.SelectorFlags
+	}{
+		{
+			name:    "deprecated fields",
+			options: DeployOptions{Regex: "^fn", Filter: "fn*"},
+			want:    stack.SelectorFlags{Regex: "^fn", Filter: "fn*"},
+		},
+		{
+			name: "Selector over the deprecated fields",
+			options: DeployOptions{
+				Selector: stack.SelectorFlags{Regex: "api", RegexExact: true},
+				Regex:    "^fn",
+				Filter:   "fn*",
+			},
+			want: stack.SelectorFlags{Regex: "api", RegexExact: true, Filter: "fn*"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.options.selectorFlags(); got != c.want {
+				t.Fatalf("want %+v, got %+v", c.want, got)
+			}
+		})
+	}
+}
+
+func Test_deployWithOptions_quiet(t *testing.T) {
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodPost,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusAccepted,
+		},
+	})
+	defer s.Close()
+
+	var err error
+	stdout := test.CaptureStdout(func() {
+		err = deployWithOptions(DeployOptions{
+			Gateway:      s.URL,
+			Image:        "golang",
+			FunctionName: "test-function",
+			Quiet:        true,
+		})
+	})
+
+	if err != nil {
+		t.Fatalf("want no error, got: %s", err)
+	}
+	if want := s.URL + "/function/test-function\n"; stdout != want {
+		t.Fatalf("want stdout %q, got %q", want, stdout)
+	}
+}
+
+func Test_Deploy_provenance(t *testing.T) {
+	defer func(original func(func(string) (string, bool)) map[string]string) { getProvenance = original }(getProvenance)
+	getProvenance = func(func(string) (string, bool)) map[string]string {
+		return map[string]string{
+			"com.openfaas.git-sha":    "4f2a1c9",
+			"com.openfaas.git-branch": "main",
+		}
+	}
+
+	stackFile, err := ioutil.TempFile("", "stack*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(stackFile.Name())
+	stackFile.WriteString(`version: 1.0
+provider:
+  name: openfaas
+functions:
+  fn1:
+    image: functions/fn1:latest
+    annotations:
+      com.openfaas.git-branch: release
+`)
+	stackFile.Close()
+
+	cases := []struct {
+		name         string
+		noProvenance bool
+		annotations  []string
+		want         map[string]string
+	}{
+		{
+			name:        "stack.yml and flags override provenance",
+			annotations: []string{"com.openfaas.git-sha=override"},
+			want: map[string]string{
+				"com.openfaas.git-sha":    "override",
+				"com.openfaas.git-branch": "release",
+			},
+		},
+		{
+			name:         "no provenance",
+			noProvenance: true,
+			want: map[string]string{
+				"com.openfaas.git-branch": "release",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The annotations are checked against the provider first
+			s := test.MockHttpServer(t, []test.Request{
+				test.SystemInfoRequest(),
+				{
+					Method:             http.MethodPut,
+					Uri:                "/system/functions",
+					ResponseStatusCode: http.StatusOK,
+				},
+			})
+			defer s.Close()
+
+			test.CaptureStdout(func() {
+				_, err = Deploy(context.Background(), DeployOptions{
+					YAMLFile:     stackFile.Name(),
+					Gateway:      s.URL,
+					Update:       true,
+					Annotations:  tc.annotations,
+					NoProvenance: tc.noProvenance,
+				})
+			})
+			if err != nil {
+				t.Fatalf("want no error, got: %s", err)
+			}
+
+			var spec struct {
+				Annotations map[string]string `json:"annotations"`
+			}
+			if err := json.Unmarshal(s.Requests()[1].Body, &spec); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(spec.Annotations, tc.want) {
+				t.Errorf("want annotations: %v, got: %v", tc.want, spec.Annotations)
+			}
+		})
+	}
+}
+
 func Test_deployFailed(t *testing.T) {
 
 	var failedDeploy = make(map[string]int)
@@ -50,7 +311,7 @@ func Test_deployFailed(t *testing.T) {
 	failedDeploy["example2"] = 300
 	failedDeploy["example3"] = 400
 	failedDeploy["example4"] = 500
-	err := deployFailed(failedDeploy)
+	err := deployFailed(failedDeploy, nil)
 	if err == nil {
 		t.Errorf("\nHad to exit with errors!")
 		t.Fail()
@@ -68,7 +329,7 @@ func Test_deployFailed(t *testing.T) {
 
 func Test_deploySucceeded(t *testing.T) {
 	var succededDeploy = make(map[string]int)
-	if err := deployFailed(succededDeploy); err != nil {
+	if err := deployFailed(succededDeploy, nil); err != nil {
 		t.Errorf("\nHad to exit with no errors!")
 		t.Fail()
 	}
@@ -90,3 +351,161 @@ func Test_badStatusCOde(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func Test_addWatchdogEnvironment(t *testing.T) {
+	function := stack.Function{
+		Name:     "fn",
+		Language: "dockerfile",
+		Watchdog: &stack.FunctionWatchdog{Mode: "http", Port: 3000},
+	}
+
+	got, err := addWatchdogEnvironment(function, map[string]string{"mode": "http", "debug": "true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"mode": "http", "debug": "true", "upstream_url": "http://127.0.0.1:3000"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, but got %v", want, got)
+	}
+
+	_, err = addWatchdogEnvironment(function, map[string]string{"mode": "streaming"})
+	wantErr := `function fn: mode is set to "http" by the watchdog block and to "streaming" in the environment, remove one of them`
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("want error %q, but got %v", wantErr, err)
+	}
+}
+
+func Test_addKubernetesOptions(t *testing.T) {
+	function := stack.Function{
+		Name: "fn",
+		K8s: &stack.KubernetesOptions{
+			ServiceAccount: "s3-reader",
+			NodeSelector:   map[string]string{"accelerator": "nvidia-tesla-t4", "disktype": "ssd"},
+		},
+	}
+
+	annotations, constraints, err := addKubernetesOptions(ioutil.Discard, function, map[string]string{"team": "ml"}, []string{"disktype=ssd"}, "kubernetes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantAnnotations := map[string]string{"team": "ml", stack.ServiceAccountAnnotation: "s3-reader"}
+	if !reflect.DeepEqual(annotations, wantAnnotations) {
+		t.Errorf("want %v, but got %v", wantAnnotations, annotations)
+	}
+	wantConstraints := []string{"disktype=ssd", "accelerator=nvidia-tesla-t4"}
+	if !reflect.DeepEqual(constraints, wantConstraints) {
+		t.Errorf("want %v, but got %v", wantConstraints, constraints)
+	}
+
+	_, _, err = addKubernetesOptions(ioutil.Discard, function, nil, []string{"disktype=hdd"}, "kubernetes")
+	wantErr := `function fn: node_selector disktype=ssd in the k8s block conflicts with the constraint "disktype=hdd", remove one of them`
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("want error %q, but got %v", wantErr, err)
+	}
+
+	_, _, err = addKubernetesOptions(ioutil.Discard, function, map[string]string{stack.ServiceAccountAnnotation: "default"}, nil, "kubernetes")
+	if err == nil {
+		t.Errorf("want an error when the annotation sets another service account")
+	}
+
+	_, constraints, err = addKubernetesOptions(ioutil.Discard, function, nil, []string{"node.platform.os=linux"}, "swarm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"node.platform.os=linux"}; !reflect.DeepEqual(constraints, want) {
+		t.Errorf("want the node selector left out on swarm, %v, but got %v", want, constraints)
+	}
+
+	_, _, err = addKubernetesOptions(ioutil.Discard, function, nil, nil, "")
+	wantErr = "function fn: k8s.node_selector is set, but the gateway's provider could not be read from /system/info"
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("want error %q when the provider is not known, but got %v", wantErr, err)
+	}
+}
+
+func Test_fixFunctionNames(t *testing.T) {
+	services := &stack.Services{
+		Functions: map[string]stack.Function{
+			"Api_V2": {Image: "alexellis/api:latest"},
+			"worker": {Image: "alexellis/worker:latest"},
+		},
+	}
+
+	if err := fixFunctionNames(services, true); err != nil {
+		t.Fatalf("want no error, got: %s", err)
+	}
+	if _, ok := services.Functions["api-v2"]; !ok || len(services.Functions) != 2 {
+		t.Fatalf("want Api_V2 renamed to api-v2, got %v", services.FunctionNames())
+	}
+
+	services.Functions["API-V2"] = stack.Function{Image: "alexellis/other:latest"}
+	if err := fixFunctionNames(services, true); err == nil {
+		t.Fatalf("want an error when the name is already in the stack")
+	}
+}
+
+func Test_deployedFunctionName(t *testing.T) {
+	cases := map[string]string{
+		"my-fn": "my-fn",
+		"My_Fn": "my-fn",
+		"___":   "___",
+	}
+	for name, want := range cases {
+		if got := deployedFunctionName(name); got != want {
+			t.Fatalf("want %s deployed as %q, got %q", name, want, got)
+		}
+	}
+}
+
+func Test_resolveResources_printsOnlyWhenSet(t *testing.T) {
+	cases := []struct {
+		name     string
+		limits   *stack.FunctionResources
+		requests *stack.FunctionResources
+		want     string
+	}{
+		{name: "none", want: ""},
+		{name: "empty", limits: &stack.FunctionResources{}, requests: &stack.FunctionResources{}, want: ""},
+		{name: "legacy memory", limits: &stack.FunctionResources{Memory: "128m"}, want: "Resources: limits.memory=128m\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var err error
+			stdout := test.CaptureStdout(func() {
+				_, err = resolveResources("figlet", c.limits, c.requests, DeployFlags{})
+			})
+			if err != nil {
+				t.Fatalf("want no error, got: %s", err)
+			}
+			if stdout != c.want {
+				t.Fatalf("want %q, got %q", c.want, stdout)
+			}
+		})
+	}
+}
+
+func Test_addWatchdogEnvironment_pinnedLanguage(t *testing.T) {
+	templateDir := filepath.Join("template", "watchdog-pinned-test")
+	if err := os.MkdirAll(templateDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove("template")
+	defer os.RemoveAll(templateDir)
+
+	templateYAML := "language: watchdog-pinned-test\nfprocess: ./handler\nwatchdog:\n  modes:\n  - http\n"
+	if err := ioutil.WriteFile(filepath.Join(templateDir, "template.yml"), []byte(templateYAML), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	function := stack.Function{
+		Name:     "fn",
+		Language: "watchdog-pinned-test@1.0",
+		Watchdog: &stack.FunctionWatchdog{Mode: "streaming"},
+	}
+
+	_, err := addWatchdogEnvironment(function, nil)
+	if err == nil || !strings.Contains(err.Error(), `does not support watchdog mode "streaming"`) {
+		t.Fatalf("want the watchdog mode to be checked against the template, got: %v", err)
+	}
+}
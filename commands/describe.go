@@ -6,18 +6,28 @@ package commands
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
+	"github.com/openfaas/faas-cli/output"
 	"github.com/openfaas/faas-cli/proxy"
 	"github.com/openfaas/faas-cli/schema"
 	"github.com/openfaas/faas-cli/stack"
+	"github.com/openfaas/faas-provider/types"
 
 	"github.com/spf13/cobra"
 )
 
+// redactedEnvValue is printed in place of the value of an environment variable
+const redactedEnvValue = "<redacted>"
+
+var describeShowEnv bool
+
 func init() {
 	describeCmd.Flags().StringVar(&functionName, "name", "", "Name of the function")
 	describeCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
@@ -25,17 +35,37 @@ func init() {
 	describeCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in stack.yml file")
 	describeCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
 	describeCmd.Flags().StringVarP(&functionNamespace, "namespace", "n", "", "Namespace of the function")
+	describeCmd.Flags().BoolVar(&describeShowEnv, "show-env", false, "Print the values of the function's environment variables, which are redacted by default")
+	addSensitiveFlags(describeCmd.Flags())
+	describeCmd.Flags().BoolVar(&describeEvents, "events", false, "Print recent events for the function from kubectl or docker, i.e. image pull errors or OOMKilled")
 
 	faasCmd.AddCommand(describeCmd)
 }
 
 var describeCmd = &cobra.Command{
-	Use:   "describe FUNCTION_NAME [--gateway GATEWAY_URL]",
-	Short: "Describe an OpenFaaS function",
-	Long:  `Display details of an OpenFaaS function`,
+	Use:     "describe FUNCTION_NAME [--gateway GATEWAY_URL]",
+	Aliases: []string{"inspect"},
+	Short:   "Describe an OpenFaaS function",
+	Long: `Display details of an OpenFaaS function, including its labels, annotations,
+secrets and environment variables. The values of environment variables are
+redacted unless --show-env is given, and even then those whose names match
+--sensitive-pattern, i.e. DB_PASSWORD or API_TOKEN, stay redacted unless
+--show-sensitive is given too.
+
+With --events, the recent events of the function are read from the
+orchestrator to explain why it is not ready, such as an image which cannot be
+pulled or a container killed for running out of memory. This uses kubectl on
+Kubernetes and docker on Swarm, which must be able to reach the cluster.
+
+Without a function name in a terminal, the functions on the gateway are
+listed to pick one by its number or by typing part of its name.`,
 	Example: `faas-cli describe figlet 
 faas-cli describe env --gateway http://127.0.0.1:8080
-faas-cli describe echo -g http://127.0.0.1.8080`,
+faas-cli describe echo -g http://127.0.0.1.8080
+faas-cli describe echo --show-env
+faas-cli describe echo --show-env --show-sensitive
+faas-cli describe echo --events
+faas-cli describe`,
 	PreRunE: preRunDescribe,
 	RunE:    runDescribe,
 }
@@ -45,15 +75,19 @@ func preRunDescribe(cmd *cobra.Command, args []string) error {
 }
 
 func runDescribe(cmd *cobra.Command, args []string) error {
-	if len(args) < 1 {
-		return fmt.Errorf("please provide a name for the function")
+	if len(args) < 1 && !interactive() {
+		return errNoFunctionName
 	}
 	var yamlGateway string
 	var services stack.Services
-	functionName = args[0]
+
+	sensitive, err := sensitiveKeysFromFlags()
+	if err != nil {
+		return err
+	}
 
 	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter, envsubst)
+		parsedServices, err := parseStackFile(yamlFile, envsubst)
 		if err != nil {
 			return err
 		}
@@ -64,25 +98,58 @@ func runDescribe(cmd *cobra.Command, args []string) error {
 		}
 	}
 	gatewayAddress := getGatewayURL(gateway, defaultGateway, yamlGateway, os.Getenv(openFaaSURLEnvironment))
-	cliAuth, err := proxy.NewCLIAuth(token, gatewayAddress)
-	if err != nil {
-		return err
-	}
-	transport := GetDefaultCLITransport(tlsInsecure, &commandTimeout)
-	cliClient, err := proxy.NewClient(cliAuth, gatewayAddress, transport, &commandTimeout)
-	if err != nil {
-		return err
-	}
-
 	ctx := context.Background()
 
-	function, err := cliClient.GetFunctionInfo(ctx, functionName, functionNamespace)
-	if err != nil {
-		return err
+	if len(args) < 1 {
+		picked, err := pickFunction(gatewayAddress, functionNamespace, token)
+		if err != nil {
+			return err
+		}
+		functionName = picked
+	} else {
+		functionName = args[0]
 	}
 
-	//To get correct value for invocation count from /system/functions endpoint
-	functionList, err := cliClient.ListFunctions(ctx, functionNamespace)
+	var function types.FunctionStatus
+	var functionList []types.FunctionStatus
+	var orchestration string
+	err = withGatewayFailover(gatewayAddress, func(servedBy string) error {
+		cliAuth, err := proxy.NewCLIAuth(token, servedBy)
+		if err != nil {
+			return err
+		}
+		transport := GetDefaultCLITransport(tlsInsecure, &commandTimeout)
+		cliClient, err := proxy.NewClient(cliAuth, servedBy, transport, &commandTimeout)
+		if err != nil {
+			return err
+		}
+		cliClient.CallID = requestID
+
+		function, err = cliClient.GetFunctionInfo(ctx, functionName, functionNamespace)
+		if err != nil {
+			return err
+		}
+
+		//To get correct value for invocation count from /system/functions endpoint
+		functionList, err = cliClient.ListFunctions(ctx, functionNamespace)
+		if err != nil {
+			return err
+		}
+
+		if describeEvents {
+			info, err := cliClient.GetSystemInfo(ctx)
+			if err != nil {
+				return err
+			}
+			if info.Provider != nil {
+				orchestration = info.Provider.Orchestration
+			}
+		}
+
+		// The URLs of the function are those of the gateway which answered
+		gatewayAddress = servedBy
+		return nil
+	})
 	if err != nil {
 		return err
 	}
@@ -114,10 +181,23 @@ func runDescribe(cmd *cobra.Command, args []string) error {
 		AsyncURL:          asyncURL,
 		Labels:            function.Labels,
 		Annotations:       function.Annotations,
+		EnvVars:           describeEnvVars(function.EnvVars, describeShowEnv, sensitive),
+		Secrets:           function.Secrets,
 	}
 
 	printFunctionDescription(funcDesc)
 
+	if describeEvents {
+		// The description is still useful when the events cannot be read,
+		// i.e. kubectl is not installed
+		events, err := inspectEvents(ctx, orchestration, functionName, functionNamespace)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, output.Warning("%s", err))
+			return nil
+		}
+		printFunctionEvents(os.Stdout, events, time.Now())
+	}
+
 	return nil
 }
 
@@ -135,8 +215,26 @@ func getFunctionURLs(gateway string, functionName string, functionNamespace stri
 	return url, asyncURL
 }
 
+// describeEnvVars redacts the values of environment variables unless show is
+// set, when only the sensitive values are redacted
+func describeEnvVars(envVars map[string]string, show bool, sensitive sensitiveKeys) map[string]string {
+	if show {
+		return sensitive.redactEnv(envVars)
+	}
+
+	redacted := make(map[string]string, len(envVars))
+	for key := range envVars {
+		redacted[key] = redactedEnvValue
+	}
+	return redacted
+}
+
 func printFunctionDescription(funcDesc schema.FunctionDescription) {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.TabIndent)
+	printFunctionDescriptionTo(os.Stdout, funcDesc)
+}
+
+func printFunctionDescriptionTo(out io.Writer, funcDesc schema.FunctionDescription) {
+	w := tabwriter.NewWriter(out, 0, 0, 1, ' ', tabwriter.TabIndent)
 	fmt.Fprintln(w, "Name:\t "+funcDesc.Name)
 	fmt.Fprintln(w, "Status:\t "+funcDesc.Status)
 	fmt.Fprintln(w, "Replicas:\t "+strconv.Itoa(funcDesc.Replicas))
@@ -148,17 +246,41 @@ func printFunctionDescription(funcDesc schema.FunctionDescription) {
 	fmt.Fprintln(w, "Async URL:\t "+funcDesc.AsyncURL)
 
 	if funcDesc.Labels != nil {
-		fmt.Fprintf(w, "Labels:")
-		for key, value := range *funcDesc.Labels {
-			fmt.Fprintln(w, " \t "+key+" : "+value)
-		}
+		printDescriptionMap(w, "Labels:", *funcDesc.Labels)
 	}
 
 	if funcDesc.Annotations != nil {
-		fmt.Fprintf(w, "Annotations:")
-		for key, value := range *funcDesc.Annotations {
-			fmt.Fprintln(w, " \t "+key+" : "+value)
+		printDescriptionMap(w, "Annotations:", *funcDesc.Annotations)
+	}
+
+	if len(funcDesc.EnvVars) > 0 {
+		printDescriptionMap(w, "Environment:", funcDesc.EnvVars)
+	}
+
+	if len(funcDesc.Secrets) > 0 {
+		fmt.Fprintf(w, "Secrets:")
+		for _, secret := range funcDesc.Secrets {
+			fmt.Fprintln(w, " \t "+secret)
 		}
 	}
 	w.Flush()
 }
+
+// printDescriptionMap prints a map sorted by key so that its order is stable
+func printDescriptionMap(w io.Writer, title string, values map[string]string) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if len(keys) == 0 {
+		fmt.Fprintln(w, title)
+		return
+	}
+
+	fmt.Fprint(w, title)
+	for _, key := range keys {
+		fmt.Fprintln(w, " \t "+key+" : "+values[key])
+	}
+}
@@ -0,0 +1,312 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// maxDescribeEvents is the number of the most recent events which are printed
+const maxDescribeEvents = 20
+
+// defaultKubernetesNamespace is the namespace of functions on Kubernetes when
+// --namespace is not given
+const defaultKubernetesNamespace = "openfaas-fn"
+
+var describeEvents bool
+
+// functionEvent is something which happened to a function in its provider,
+// i.e. its image could not be pulled or it was killed for using too much memory
+type functionEvent struct {
+	// Time is when the event was last seen, it is zero when unknown
+	Time    time.Time
+	Type    string
+	Reason  string
+	Object  string
+	Message string
+}
+
+// eventInspector reads the events of a function from the orchestrator of a
+// provider, it needs access to the orchestrator as well as to the gateway
+type eventInspector interface {
+	// Tool is the command which is run to read the events
+	Tool() string
+	Events(ctx context.Context, name, namespace string) ([]functionEvent, error)
+}
+
+// eventInspectors are the inspectors by the orchestration which the gateway
+// reports in /system/info
+var eventInspectors = map[string]eventInspector{
+	"kubernetes": kubernetesInspector{},
+	"swarm":      swarmInspector{},
+}
+
+// runInspector runs the command of an inspector and returns its output
+var runInspector = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); len(message) > 0 {
+			return nil, fmt.Errorf("%s: %s", name, message)
+		}
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// kubernetesInspector reads events with kubectl, for the function's
+// Deployment, ReplicaSets and Pods
+type kubernetesInspector struct{}
+
+// functionLabel is the label which the provider sets on the ReplicaSets and
+// Pods of a function
+const functionLabel = "faas_function"
+
+func (kubernetesInspector) Tool() string {
+	return "kubectl"
+}
+
+func (kubernetesInspector) Events(ctx context.Context, name, namespace string) ([]functionEvent, error) {
+	if len(namespace) == 0 {
+		namespace = defaultKubernetesNamespace
+	}
+
+	owned, err := kubernetesFunctionObjects(ctx, name, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := runInspector(ctx, "kubectl", "get", "events", "--namespace", namespace, "--output", "json")
+	if err != nil {
+		return nil, err
+	}
+
+	var list struct {
+		Items []struct {
+			Type           string `json:"type"`
+			Reason         string `json:"reason"`
+			Message        string `json:"message"`
+			LastTimestamp  string `json:"lastTimestamp"`
+			EventTime      string `json:"eventTime"`
+			InvolvedObject struct {
+				Kind string `json:"kind"`
+				Name string `json:"name"`
+			} `json:"involvedObject"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(out, &list); err != nil {
+		return nil, fmt.Errorf("unable to read the events from kubectl: %s", err)
+	}
+
+	events := []functionEvent{}
+	for _, item := range list.Items {
+		object := item.InvolvedObject.Name
+		if !owned.has(item.InvolvedObject.Kind, object) {
+			continue
+		}
+
+		seen := item.LastTimestamp
+		if len(seen) == 0 {
+			seen = item.EventTime
+		}
+		when, _ := time.Parse(time.RFC3339Nano, seen)
+
+		events = append(events, functionEvent{
+			Time:    when,
+			Type:    item.Type,
+			Reason:  item.Reason,
+			Object:  strings.ToLower(item.InvolvedObject.Kind) + "/" + object,
+			Message: strings.TrimSpace(item.Message),
+		})
+	}
+	return events, nil
+}
+
+// functionObjects are the Kubernetes objects of a function, by kind and name
+type functionObjects struct {
+	deployment  string
+	replicaSets map[string]bool
+	pods        map[string]bool
+}
+
+// has is true for the function's Deployment and the ReplicaSets and Pods with
+// its label. A Pod which has since been removed is matched by the name of its
+// ReplicaSet, i.e. figlet-6d4f9b7c8-x2x9z of figlet-6d4f9b7c8.
+func (o functionObjects) has(kind, name string) bool {
+	switch kind {
+	case "Deployment":
+		return name == o.deployment
+	case "ReplicaSet":
+		return o.replicaSets[name]
+	case "Pod":
+		if o.pods[name] {
+			return true
+		}
+		if i := strings.LastIndex(name, "-"); i > 0 {
+			return o.replicaSets[name[:i]]
+		}
+	}
+	return false
+}
+
+// kubernetesFunctionObjects lists the ReplicaSets and Pods of a function by its
+// label, so that the events of another function whose name starts with the
+// same prefix, i.e. figlet-api for figlet, are left out
+func kubernetesFunctionObjects(ctx context.Context, name, namespace string) (functionObjects, error) {
+	objects := functionObjects{
+		deployment:  name,
+		replicaSets: map[string]bool{},
+		pods:        map[string]bool{},
+	}
+
+	out, err := runInspector(ctx, "kubectl", "get", "replicasets,pods", "--namespace", namespace,
+		"--selector", functionLabel+"="+name, "--output", "json")
+	if err != nil {
+		return objects, err
+	}
+
+	var list struct {
+		Items []struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(out, &list); err != nil {
+		return objects, fmt.Errorf("unable to read the objects of %s from kubectl: %s", name, err)
+	}
+
+	for _, item := range list.Items {
+		switch item.Kind {
+		case "ReplicaSet":
+			objects.replicaSets[item.Metadata.Name] = true
+		case "Pod":
+			objects.pods[item.Metadata.Name] = true
+		}
+	}
+	return objects, nil
+}
+
+// swarmInspector reads the errors of the function's tasks with docker
+type swarmInspector struct{}
+
+func (swarmInspector) Tool() string {
+	return "docker"
+}
+
+func (swarmInspector) Events(ctx context.Context, name, namespace string) ([]functionEvent, error) {
+	out, err := runInspector(ctx, "docker", "service", "ps", "--no-trunc", "--format", "{{json .}}", name)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []functionEvent{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+
+		var task struct {
+			Name         string `json:"Name"`
+			CurrentState string `json:"CurrentState"`
+			Error        string `json:"Error"`
+		}
+		if err := json.Unmarshal([]byte(line), &task); err != nil {
+			return nil, fmt.Errorf("unable to read the tasks from docker: %s", err)
+		}
+		if len(task.Error) == 0 {
+			continue
+		}
+
+		// CurrentState is i.e. "Rejected 2 minutes ago", docker does not
+		// give the time itself
+		reason := task.CurrentState
+		if fields := strings.Fields(task.CurrentState); len(fields) > 0 {
+			reason = fields[0]
+		}
+
+		events = append(events, functionEvent{
+			Type:    "Warning",
+			Reason:  reason,
+			Object:  "task/" + task.Name,
+			Message: fmt.Sprintf("%s (%s)", task.Error, task.CurrentState),
+		})
+	}
+	return events, scanner.Err()
+}
+
+// inspectEvents reads the recent events of a function with the inspector for
+// the orchestration, the oldest are dropped beyond maxDescribeEvents
+func inspectEvents(ctx context.Context, orchestration, name, namespace string) ([]functionEvent, error) {
+	inspector, ok := eventInspectors[orchestration]
+	if !ok {
+		if len(orchestration) == 0 {
+			orchestration = "unknown"
+		}
+		return nil, fmt.Errorf("events are not available for the %s orchestration", orchestration)
+	}
+
+	events, err := inspector.Events(ctx, name, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read events with %s: %s", inspector.Tool(), err)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	if len(events) > maxDescribeEvents {
+		events = events[len(events)-maxDescribeEvents:]
+	}
+	return events, nil
+}
+
+// printFunctionEvents prints events oldest first, as kubectl describe does
+func printFunctionEvents(out io.Writer, events []functionEvent, now time.Time) {
+	if len(events) == 0 {
+		fmt.Fprintln(out, "Events: <none>")
+		return
+	}
+
+	fmt.Fprintln(out, "Events:")
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  LAST SEEN\tTYPE\tREASON\tOBJECT\tMESSAGE")
+	for _, event := range events {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", eventAge(event.Time, now), event.Type, event.Reason, event.Object, event.Message)
+	}
+	w.Flush()
+}
+
+// eventAge is the time since an event, i.e. 45s, 12m or 3h
+func eventAge(when, now time.Time) string {
+	if when.IsZero() {
+		return "-"
+	}
+
+	age := now.Sub(when)
+	switch {
+	case age < time.Minute:
+		return fmt.Sprintf("%ds", int(age.Seconds()))
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(age.Hours()/24))
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const kubectlEvents = `{
+  "items": [
+    {
+      "type": "Normal",
+      "reason": "Scheduled",
+      "message": "Successfully assigned openfaas-fn/figlet-6d4f9b7c8-x2x9z to node-1",
+      "lastTimestamp": null,
+      "eventTime": "2020-06-01T10:00:00.000000Z",
+      "involvedObject": {"kind": "Pod", "name": "figlet-6d4f9b7c8-x2x9z"}
+    },
+    {
+      "type": "Warning",
+      "reason": "Failed",
+      "message": "Failed to pull image \"functions/figlet:missing\": not found",
+      "lastTimestamp": "2020-06-01T10:01:00Z",
+      "involvedObject": {"kind": "Pod", "name": "figlet-6d4f9b7c8-x2x9z"}
+    },
+    {
+      "type": "Normal",
+      "reason": "ScalingReplicaSet",
+      "message": "Scaled up replica set figlet-6d4f9b7c8 to 1",
+      "lastTimestamp": "2020-06-01T09:59:59Z",
+      "involvedObject": {"kind": "Deployment", "name": "figlet"}
+    },
+    {
+      "type": "Warning",
+      "reason": "BackOff",
+      "message": "Back-off restarting failed container",
+      "lastTimestamp": "2020-06-01T10:02:00Z",
+      "involvedObject": {"kind": "Pod", "name": "figlet2-5c7d8-abcde"}
+    },
+    {
+      "type": "Warning",
+      "reason": "BackOff",
+      "message": "Back-off restarting failed container",
+      "lastTimestamp": "2020-06-01T10:03:00Z",
+      "involvedObject": {"kind": "Pod", "name": "figlet-api-7b9c6d5f4-k8s2p"}
+    },
+    {
+      "type": "Normal",
+      "reason": "Killing",
+      "message": "Stopping container figlet",
+      "lastTimestamp": "2020-06-01T09:58:00Z",
+      "involvedObject": {"kind": "Pod", "name": "figlet-6d4f9b7c8-gone1"}
+    }
+  ]
+}`
+
+const kubectlFunctionObjects = `{
+  "items": [
+    {"kind": "ReplicaSet", "metadata": {"name": "figlet-6d4f9b7c8"}},
+    {"kind": "Pod", "metadata": {"name": "figlet-6d4f9b7c8-x2x9z"}}
+  ]
+}`
+
+const dockerTasks = `{"CurrentState":"Running 5 minutes ago","Error":"","Name":"figlet.1"}
+{"CurrentState":"Rejected 6 minutes ago","Error":"No such image: functions/figlet:missing","Name":"figlet.1"}
+`
+
+func stubRunInspector(out string, err error) (calls *[]string, restore func()) {
+	original := runInspector
+	recorded := []string{}
+	runInspector = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		recorded = append(recorded, name+" "+strings.Join(args, " "))
+		return []byte(out), err
+	}
+	return &recorded, func() { runInspector = original }
+}
+
+// stubRunInspectorCommands returns the output for each command line
+func stubRunInspectorCommands(outputs map[string]string) (calls *[]string, restore func()) {
+	original := runInspector
+	recorded := []string{}
+	runInspector = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		command := name + " " + strings.Join(args, " ")
+		recorded = append(recorded, command)
+		out, ok := outputs[command]
+		if !ok {
+			return nil, fmt.Errorf("unexpected command: %s", command)
+		}
+		return []byte(out), nil
+	}
+	return &recorded, func() { runInspector = original }
+}
+
+func Test_inspectEvents_kubernetes(t *testing.T) {
+	calls, restore := stubRunInspectorCommands(map[string]string{
+		"kubectl get replicasets,pods --namespace openfaas-fn --selector faas_function=figlet --output json": kubectlFunctionObjects,
+		"kubectl get events --namespace openfaas-fn --output json":                                           kubectlEvents,
+	})
+	defer restore()
+
+	events, err := inspectEvents(context.Background(), "kubernetes", "figlet", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantCalls := []string{
+		"kubectl get replicasets,pods --namespace openfaas-fn --selector faas_function=figlet --output json",
+		"kubectl get events --namespace openfaas-fn --output json",
+	}
+	if !reflect.DeepEqual(*calls, wantCalls) {
+		t.Errorf("want calls %v, got %v", wantCalls, *calls)
+	}
+
+	want := []functionEvent{
+		{Time: time.Date(2020, 6, 1, 9, 58, 0, 0, time.UTC), Type: "Normal", Reason: "Killing", Object: "pod/figlet-6d4f9b7c8-gone1", Message: "Stopping container figlet"},
+		{Time: time.Date(2020, 6, 1, 9, 59, 59, 0, time.UTC), Type: "Normal", Reason: "ScalingReplicaSet", Object: "deployment/figlet", Message: "Scaled up replica set figlet-6d4f9b7c8 to 1"},
+		{Time: time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC), Type: "Normal", Reason: "Scheduled", Object: "pod/figlet-6d4f9b7c8-x2x9z", Message: "Successfully assigned openfaas-fn/figlet-6d4f9b7c8-x2x9z to node-1"},
+		{Time: time.Date(2020, 6, 1, 10, 1, 0, 0, time.UTC), Type: "Warning", Reason: "Failed", Object: "pod/figlet-6d4f9b7c8-x2x9z", Message: `Failed to pull image "functions/figlet:missing": not found`},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("want events\n%v\ngot\n%v", want, events)
+	}
+}
+
+func Test_inspectEvents_swarm(t *testing.T) {
+	calls, restore := stubRunInspector(dockerTasks, nil)
+	defer restore()
+
+	events, err := inspectEvents(context.Background(), "swarm", "figlet", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantCalls := []string{"docker service ps --no-trunc --format {{json .}} figlet"}
+	if !reflect.DeepEqual(*calls, wantCalls) {
+		t.Errorf("want calls %v, got %v", wantCalls, *calls)
+	}
+
+	want := []functionEvent{
+		{Type: "Warning", Reason: "Rejected", Object: "task/figlet.1", Message: "No such image: functions/figlet:missing (Rejected 6 minutes ago)"},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("want events %v, got %v", want, events)
+	}
+}
+
+func Test_inspectEvents_errors(t *testing.T) {
+	_, restore := stubRunInspector("", fmt.Errorf("kubectl: connection refused"))
+	defer restore()
+
+	cases := []struct {
+		name          string
+		orchestration string
+		want          string
+	}{
+		{name: "unsupported orchestration", orchestration: "containerd", want: "events are not available for the containerd orchestration"},
+		{name: "orchestration not reported", orchestration: "", want: "events are not available for the unknown orchestration"},
+		{name: "inspector fails", orchestration: "kubernetes", want: "unable to read events with kubectl: kubectl: connection refused"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := inspectEvents(context.Background(), tc.orchestration, "figlet", "")
+			if err == nil || err.Error() != tc.want {
+				t.Errorf("want error %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func Test_printFunctionEvents(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	var out bytes.Buffer
+	printFunctionEvents(&out, []functionEvent{
+		{Time: now.Add(-90 * time.Minute), Type: "Warning", Reason: "OOMKilling", Object: "pod/figlet-1", Message: "Memory cgroup out of memory"},
+		{Time: now.Add(-30 * time.Second), Type: "Normal", Reason: "Pulled", Object: "pod/figlet-2", Message: "Container image pulled"},
+		{Type: "Warning", Reason: "Rejected", Object: "task/figlet.1", Message: "No such image"},
+	}, now)
+
+	want := `Events:
+  LAST SEEN  TYPE     REASON      OBJECT         MESSAGE
+  1h         Warning  OOMKilling  pod/figlet-1   Memory cgroup out of memory
+  30s        Normal   Pulled      pod/figlet-2   Container image pulled
+  -          Warning  Rejected    task/figlet.1  No such image
+`
+	if out.String() != want {
+		t.Errorf("want\n%s\ngot\n%s", want, out.String())
+	}
+
+	out.Reset()
+	printFunctionEvents(&out, nil, now)
+	if got := out.String(); got != "Events: <none>\n" {
+		t.Errorf("want no events to be printed as <none>, got %q", got)
+	}
+}
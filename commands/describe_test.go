@@ -1,6 +1,13 @@
 package commands
 
-import "testing"
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/schema"
+)
 
 func Test_getFunctionURLs(t *testing.T) {
 	cases := []struct {
@@ -26,3 +33,63 @@ func Test_getFunctionURLs(t *testing.T) {
 		})
 	}
 }
+
+func Test_printFunctionDescription_envAndLabels(t *testing.T) {
+	labels := map[string]string{"team": "payments", "app": "checkout"}
+	envVars := map[string]string{"write_debug": "true", "db_host": "postgres", "db_password": "s3cret"}
+
+	cases := []struct {
+		name          string
+		showEnv       bool
+		showSensitive bool
+		want          []string
+		notWant       []string
+	}{
+		{
+			name:    "env values are redacted",
+			want:    []string{"db_host : <redacted>", "write_debug : <redacted>"},
+			notWant: []string{"postgres", "s3cret"},
+		},
+		{
+			name:    "env values are shown with --show-env",
+			showEnv: true,
+			want:    []string{"db_host : postgres", "write_debug : true", "db_password : <redacted>"},
+			notWant: []string{"s3cret"},
+		},
+		{
+			name:          "sensitive values are shown with --show-sensitive",
+			showEnv:       true,
+			showSensitive: true,
+			want:          []string{"db_password : s3cret"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			printFunctionDescriptionTo(&out, schema.FunctionDescription{
+				Name:    "checkout",
+				Labels:  &labels,
+				EnvVars: describeEnvVars(envVars, tc.showEnv, sensitiveKeys{pattern: regexp.MustCompile(defaultSensitivePattern), show: tc.showSensitive}),
+				Secrets: []string{"db-password"},
+			})
+			got := out.String()
+
+			want := append(tc.want, "Secrets:", "db-password")
+			for _, w := range want {
+				if !strings.Contains(got, w) {
+					t.Errorf("want output to contain %q, got:\n%s", w, got)
+				}
+			}
+			for _, w := range tc.notWant {
+				if strings.Contains(got, w) {
+					t.Errorf("want output not to contain %q, got:\n%s", w, got)
+				}
+			}
+
+			if strings.Index(got, "app : checkout") > strings.Index(got, "team : payments") {
+				t.Errorf("want labels sorted by key, got:\n%s", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"os/signal"
+	"runtime/trace"
+	"sync"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+// Flags to diagnose the CPU and memory use of faas-cli itself, they are hidden
+// from the help as they are only useful to maintainers
+var (
+	pprofAddr string
+	traceFile string
+)
+
+// diagnostics are the profiling server and trace which are running, if any
+var diagnostics struct {
+	sync.Mutex
+	listener net.Listener
+	trace    *os.File
+	signals  chan os.Signal
+}
+
+func init() {
+	faasCmd.PersistentFlags().StringVar(&pprofAddr, "pprof-addr", "", "Serve the runtime profiles of faas-cli on this address while it runs, i.e. 127.0.0.1:6060")
+	faasCmd.PersistentFlags().StringVar(&traceFile, "trace-file", "", "Write an execution trace of faas-cli to this file, view it with go tool trace")
+	_ = faasCmd.PersistentFlags().MarkHidden("pprof-addr")
+	_ = faasCmd.PersistentFlags().MarkHidden("trace-file")
+
+	cobra.OnInitialize(startDiagnostics)
+}
+
+// startDiagnostics starts the profiling server and the trace once the flags
+// have been parsed
+func startDiagnostics() {
+	if len(pprofAddr) == 0 && len(traceFile) == 0 {
+		return
+	}
+
+	diagnostics.Lock()
+	defer diagnostics.Unlock()
+
+	if diagnostics.listener != nil || diagnostics.trace != nil {
+		return
+	}
+
+	if len(pprofAddr) > 0 {
+		listener, err := net.Listen("tcp", pprofAddr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "unable to serve pprof on %s: %s\n", pprofAddr, err)
+		} else {
+			diagnostics.listener = listener
+			fmt.Fprintf(os.Stderr, "Serving pprof on http://%s/debug/pprof/\n", listener.Addr())
+			go http.Serve(listener, pprofHandler())
+		}
+	}
+
+	if len(traceFile) > 0 {
+		file, err := os.Create(traceFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "unable to create the trace file: %s\n", err)
+		} else if err := trace.Start(file); err != nil {
+			file.Close()
+			fmt.Fprintf(os.Stderr, "unable to start the trace: %s\n", err)
+		} else {
+			diagnostics.trace = file
+
+			// The end of the trace is only written by trace.Stop, so stop it
+			// before exiting on Ctrl+C. While a command can be interrupted,
+			// it cleans up and returns, and Execute stops the trace.
+			diagnostics.signals = make(chan os.Signal, 1)
+			signal.Notify(diagnostics.signals, os.Interrupt, syscall.SIGTERM)
+			go func(signals chan os.Signal) {
+				for range signals {
+					if interruptible() {
+						continue
+					}
+					stopDiagnostics()
+					sshTunnels.close()
+					os.Exit(130)
+				}
+			}(diagnostics.signals)
+		}
+	}
+}
+
+// stopDiagnostics stops the profiling server and writes the end of the trace,
+// it is safe to call when neither was started
+func stopDiagnostics() {
+	diagnostics.Lock()
+	defer diagnostics.Unlock()
+
+	if diagnostics.listener != nil {
+		diagnostics.listener.Close()
+		diagnostics.listener = nil
+	}
+
+	if diagnostics.trace != nil {
+		signal.Stop(diagnostics.signals)
+		close(diagnostics.signals)
+		diagnostics.signals = nil
+
+		trace.Stop()
+		if err := diagnostics.trace.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "unable to write the trace file: %s\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Trace written to %s, view it with: go tool trace %s\n", traceFile, traceFile)
+		}
+		diagnostics.trace = nil
+	}
+}
+
+// pprofHandler serves the handlers of net/http/pprof on a ServeMux of its own,
+// so that they are only served on --pprof-addr
+func pprofHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
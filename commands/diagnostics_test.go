@@ -0,0 +1,65 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_pprofHandler(t *testing.T) {
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/debug/pprof/goroutine"} {
+		rec := httptest.NewRecorder()
+		pprofHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: want status 200, got %d", path, rec.Code)
+		}
+	}
+}
+
+func Test_startDiagnostics_Trace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "faas-cli-trace-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	traceFile = filepath.Join(dir, "trace.out")
+	defer func() { traceFile = "" }()
+
+	startDiagnostics()
+	stopDiagnostics()
+	// Stopping again is a no-op
+	stopDiagnostics()
+
+	info, err := os.Stat(traceFile)
+	if err != nil {
+		t.Fatalf("want the trace file to be written, got: %s", err)
+	}
+	if info.Size() == 0 {
+		t.Errorf("want the trace file to have the trace in it")
+	}
+}
+
+func Test_interruptible(t *testing.T) {
+	if interruptible() {
+		t.Fatalf("want no interrupt context in use")
+	}
+
+	_, cancel := interruptContext()
+	if !interruptible() {
+		t.Fatalf("want Ctrl+C left to the interrupt context while it is in use")
+	}
+
+	cancel()
+	// Cancelling again must not count the context twice
+	cancel()
+	if interruptible() {
+		t.Fatalf("want no interrupt context in use after it is cancelled")
+	}
+}
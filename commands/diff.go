@@ -0,0 +1,202 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/spf13/cobra"
+)
+
+// diffAgainstLastApplied compares the stack file with the last deployment
+// recorded by deploy --record
+const diffAgainstLastApplied = "last-applied"
+
+var diffAgainst string
+
+func init() {
+	diffCmd.Flags().StringVar(&diffAgainst, "against", diffAgainstLastApplied, "What to compare the stack file with, only last-applied is supported")
+	diffCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
+	diffCmd.Flags().StringVarP(&functionNamespace, "namespace", "n", "", "Namespace of the function")
+	diffCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in stack.yml file")
+	diffCmd.Flags().BoolVar(&readTemplate, "read-template", true, "Read the function's template")
+	diffCmd.Flags().Var(&tagFormat, "tag", "Override latest tag on function Docker image, accepts 'latest', 'sha', 'branch', or 'describe'")
+	diffCmd.Flags().StringVar(&imagesFile, "images-file", "", "Compare the images and digests written by publish --images-file, i.e. images.json")
+	diffCmd.Flags().StringSliceVar(&skipFunctions, "skip", []string{}, "Names of functions in the stack file to leave out, i.e. fn1,fn2")
+	diffCmd.Flags().StringSliceVar(&selectTags, "tags", []string{}, "Only use the functions in the stack file which have one of these tags, i.e. frontend,critical")
+	addSensitiveFlags(diffCmd.Flags())
+
+	faasCmd.AddCommand(diffCmd)
+}
+
+var diffCmd = &cobra.Command{
+	Use:   `diff -f YAML_FILE [--against last-applied]`,
+	Short: "Show what deploy would change since the last recorded deployment",
+	Long: `Compares what deploy would send to the gateway for each function in the
+stack file with the last deployment which was recorded with deploy --record.
+The state file is kept on this machine for each gateway, so no history is
+needed on the gateway.
+
+The values of environment variables whose names match --sensitive-pattern
+are redacted, use --show-sensitive to print them.`,
+	Example: `  faas-cli deploy -f stack.yml --record
+  faas-cli diff -f stack.yml --against last-applied
+  faas-cli diff -f stack.yml --env-profile prod --tag sha`,
+	RunE: runDiff,
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	if diffAgainst != diffAgainstLastApplied {
+		return fmt.Errorf("unknown --against %q, only %q is supported", diffAgainst, diffAgainstLastApplied)
+	}
+	if len(yamlFile) == 0 {
+		return fmt.Errorf("give a stack file with -f to compare")
+	}
+	sensitive, err := sensitiveKeysFromFlags()
+	if err != nil {
+		return err
+	}
+
+	var planned []appliedFunction
+	var plannedGateway string
+
+	options := deployOptions(deployFlags)
+	options.Gateway = gateway
+	options.Namespace = functionNamespace
+	options.ReadTemplate = readTemplate
+	options.Update = true
+	options.Record = false
+	options.Quiet = true
+	options.plan = func(gateway string, spec *proxy.DeployFunctionSpec) {
+		plannedGateway = gateway
+		planned = append(planned, newAppliedFunction(spec, time.Time{}))
+	}
+
+	if _, err = Deploy(context.Background(), options); err != nil {
+		return err
+	}
+	if len(planned) == 0 {
+		return fmt.Errorf("no functions found in %s", yamlFile)
+	}
+
+	state, err := loadDeployState(plannedGateway)
+	if err != nil {
+		return err
+	}
+
+	if !printAppliedDiff(os.Stdout, state, planned, sensitive) {
+		fmt.Printf("No changes since the last deployment to %s\n", plannedGateway)
+	}
+	return nil
+}
+
+// printAppliedDiff prints the changes to each function since its last
+// deployment, and reports whether there were any
+func printAppliedDiff(w io.Writer, state *deployState, planned []appliedFunction, sensitive sensitiveKeys) bool {
+	changed := false
+	for _, function := range planned {
+		key := appliedKey(function.Name, function.Namespace)
+
+		last, ok := state.lastApplied(function.Name, function.Namespace)
+		if !ok {
+			fmt.Fprintf(w, "+ %s (no deployment recorded)\n", key)
+			changed = true
+			continue
+		}
+
+		lines := diffApplied(flattenApplied(last), flattenApplied(function), sensitive)
+		if len(lines) == 0 {
+			continue
+		}
+
+		changed = true
+		fmt.Fprintf(w, "~ %s (last applied %s)\n", key, last.AppliedAt.Format(time.RFC3339))
+		for _, line := range lines {
+			fmt.Fprintf(w, "    %s\n", line)
+		}
+	}
+	return changed
+}
+
+// flattenApplied turns a deployment into keys and values, i.e.
+// environment.MODE, so that the changes to it can be listed one per line
+func flattenApplied(f appliedFunction) map[string]string {
+	values := map[string]string{
+		"image":     f.Image,
+		"fprocess":  f.FProcess,
+		"lang":      f.Language,
+		"namespace": f.Namespace,
+	}
+	if f.ReadOnlyRootFilesystem {
+		values["readonly_root_filesystem"] = "true"
+	}
+	if len(f.Constraints) > 0 {
+		values["constraints"] = strings.Join(f.Constraints, ", ")
+	}
+	if len(f.Secrets) > 0 {
+		values["secrets"] = strings.Join(f.Secrets, ", ")
+	}
+	for k, v := range f.EnvVars {
+		values["environment."+k] = v
+	}
+	for k, v := range f.Labels {
+		values["labels."+k] = v
+	}
+	for k, v := range f.Annotations {
+		values["annotations."+k] = v
+	}
+	if f.Limits != nil {
+		values["limits.memory"] = f.Limits.Memory
+		values["limits.cpu"] = f.Limits.CPU
+	}
+	if f.Requests != nil {
+		values["requests.memory"] = f.Requests.Memory
+		values["requests.cpu"] = f.Requests.CPU
+	}
+
+	for k, v := range values {
+		if len(v) == 0 {
+			delete(values, k)
+		}
+	}
+	return values
+}
+
+// diffApplied lists the keys which were added, removed or changed, sorted.
+// Values are compared before they are redacted, so that a changed secret is
+// still listed
+func diffApplied(last, planned map[string]string, sensitive sensitiveKeys) []string {
+	keys := []string{}
+	for k := range last {
+		keys = append(keys, k)
+	}
+	for k := range planned {
+		if _, ok := last[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	lines := []string{}
+	for _, k := range keys {
+		before, hadBefore := last[k]
+		after, hasAfter := planned[k]
+		switch {
+		case !hadBefore:
+			lines = append(lines, fmt.Sprintf("+ %s: %s", k, sensitive.redactFlattened(k, after)))
+		case !hasAfter:
+			lines = append(lines, fmt.Sprintf("- %s: %s", k, sensitive.redactFlattened(k, before)))
+		case before != after:
+			lines = append(lines, fmt.Sprintf("~ %s: %s -> %s", k, sensitive.redactFlattened(k, before), sensitive.redactFlattened(k, after)))
+		}
+	}
+	return lines
+}
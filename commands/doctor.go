@@ -0,0 +1,340 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	v1execute "github.com/alexellis/go-execute/pkg/v1"
+	"github.com/openfaas/faas-cli/output"
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// minBuildDiskSpace is the free space below which builds are likely to fail
+	minBuildDiskSpace = 5 * 1024 * 1024 * 1024
+
+	// maxClockSkew is the difference from the gateway's clock above which
+	// tokens and TLS certificates may be rejected
+	maxClockSkew = time.Minute
+
+	doctorTimeout = 5 * time.Second
+)
+
+type doctorStatus int
+
+const (
+	doctorPass doctorStatus = iota
+	doctorWarn
+	doctorFail
+)
+
+// doctorResult is the outcome of a check and a hint to fix it when it did not pass
+type doctorResult struct {
+	Name    string
+	Status  doctorStatus
+	Message string
+	Hint    string
+}
+
+func init() {
+	doctorCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
+	doctorCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	doctorCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
+	doctorCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in stack.yml file")
+
+	faasCmd.AddCommand(doctorCmd)
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   `doctor [--gateway GATEWAY_URL]`,
+	Short: "Check the environment for common problems",
+	Long: `Checks the things that faas-cli needs to build and deploy functions: the
+Docker daemon and buildx, the gateway and your credentials for it, the templates
+used by stack.yml, free disk space for builds and the difference between your
+clock and the gateway's. A hint is printed for each check which does not pass.
+
+The command exits non-zero when any check fails.`,
+	Example: `  faas-cli doctor
+  faas-cli doctor --gateway https://gw.example.com
+  faas-cli doctor -f functions.yml`,
+	RunE: runDoctor,
+}
+
+// runDoctorCommand runs a command and returns its stdout, it is a variable so
+// that tests do not need Docker
+var runDoctorCommand = func(name string, args ...string) (string, error) {
+	task := v1execute.ExecTask{
+		Command: name,
+		Args:    args,
+	}
+
+	res, err := task.Execute()
+	if err != nil {
+		return "", err
+	}
+	if res.ExitCode != 0 {
+		return "", fmt.Errorf("%s", strings.TrimSpace(res.Stderr))
+	}
+	return strings.TrimSpace(res.Stdout), nil
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	var services *stack.Services
+	var yamlGateway string
+	if len(yamlFile) > 0 {
+		parsed, err := parseStackFile(yamlFile, envsubst)
+		if err != nil {
+			return err
+		}
+		services = parsed
+		yamlGateway = services.Provider.GatewayURL
+	}
+	gatewayAddress := getGatewayURL(gateway, defaultGateway, yamlGateway, os.Getenv(openFaaSURLEnvironment))
+
+	results := []doctorResult{
+		checkDocker(),
+		checkBuildx(),
+	}
+	results = append(results, checkGateway(gatewayAddress)...)
+	results = append(results,
+		checkTemplates(templateDirectory, services),
+		checkDiskSpace("."),
+	)
+
+	failed := 0
+	for _, result := range results {
+		fmt.Println(formatDoctorResult(result))
+		if result.Status == doctorFail {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(results))
+	}
+	return nil
+}
+
+func formatDoctorResult(result doctorResult) string {
+	line := fmt.Sprintf("%s: %s", result.Name, result.Message)
+	switch result.Status {
+	case doctorWarn:
+		line = output.Warning("%s", line)
+	case doctorFail:
+		line = output.Failure("%s", line)
+	default:
+		return output.Success("%s", line)
+	}
+
+	if len(result.Hint) > 0 {
+		line += "\n  " + result.Hint
+	}
+	return line
+}
+
+func checkDocker() doctorResult {
+	result := doctorResult{Name: "Docker"}
+
+	version, err := runDoctorCommand("docker", "version", "--format", "{{.Server.Version}}")
+	if err != nil {
+		result.Status = doctorFail
+		result.Message = fmt.Sprintf("the Docker daemon is not available: %s", err)
+		result.Hint = "Install Docker, or start the daemon, then check that \"docker version\" prints a Server section"
+		return result
+	}
+
+	result.Message = fmt.Sprintf("daemon version %s", version)
+	return result
+}
+
+func checkBuildx() doctorResult {
+	result := doctorResult{Name: "Buildx"}
+
+	version, err := runDoctorCommand("docker", "buildx", "version")
+	if err != nil {
+		result.Status = doctorWarn
+		result.Message = "buildx is not available"
+		result.Hint = "Install the buildx plugin to use \"faas-cli publish\" and multi-arch builds"
+		return result
+	}
+
+	result.Message = version
+	return result
+}
+
+// checkGateway checks that the gateway can be reached without credentials,
+// then that the saved credentials are accepted and how far its clock is from ours
+func checkGateway(gatewayAddress string) []doctorResult {
+	reachable := doctorResult{Name: "Gateway"}
+
+	timeout := doctorTimeout
+	client := &http.Client{
+		Transport: GetDefaultCLITransport(tlsInsecure, &timeout),
+		Timeout:   timeout,
+	}
+
+	res, err := client.Get(gatewayAddress + "/healthz")
+	if err != nil {
+		reachable.Status = doctorFail
+		reachable.Message = fmt.Sprintf("cannot connect to %s", gatewayAddress)
+		reachable.Hint = gatewayUnreachableHint
+		return []doctorResult{reachable}
+	}
+	ioutil.ReadAll(res.Body)
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		reachable.Status = doctorFail
+		reachable.Message = fmt.Sprintf("%s returned %d for /healthz", gatewayAddress, res.StatusCode)
+		reachable.Hint = "Check that the URL is for the OpenFaaS gateway and that it is ready"
+		return []doctorResult{reachable}
+	}
+	reachable.Message = fmt.Sprintf("%s is reachable", gatewayAddress)
+
+	return []doctorResult{
+		reachable,
+		checkGatewayAuth(gatewayAddress, timeout),
+		checkClockSkew(res.Header.Get("Date"), time.Now()),
+	}
+}
+
+func checkGatewayAuth(gatewayAddress string, timeout time.Duration) doctorResult {
+	result := doctorResult{Name: "Authentication"}
+
+	cliAuth, err := proxy.NewCLIAuth(token, gatewayAddress)
+	if err == nil {
+		var client *proxy.Client
+		client, err = proxy.NewClient(cliAuth, gatewayAddress, GetDefaultCLITransport(tlsInsecure, &timeout), &timeout)
+		if err == nil {
+			client.CallID = requestID
+			_, err = client.GetSystemInfo(context.Background())
+		}
+	}
+
+	switch {
+	case errors.Is(err, proxy.ErrUnauthorized):
+		result.Status = doctorFail
+		result.Message = "the gateway rejected the credentials"
+		result.Hint = fmt.Sprintf("Run \"faas-cli login --gateway %s\" or pass --token", gatewayAddress)
+	case err != nil:
+		result.Status = doctorFail
+		result.Message = err.Error()
+	default:
+		result.Message = "credentials accepted"
+	}
+	return result
+}
+
+func checkClockSkew(date string, now time.Time) doctorResult {
+	result := doctorResult{Name: "Clock"}
+
+	gatewayTime, err := http.ParseTime(date)
+	if err != nil {
+		result.Status = doctorWarn
+		result.Message = "the gateway did not return its time"
+		return result
+	}
+
+	skew := now.Sub(gatewayTime).Round(time.Second)
+	if skew < 0 {
+		skew = -skew
+	}
+
+	if skew > maxClockSkew {
+		result.Status = doctorWarn
+		result.Message = fmt.Sprintf("the local clock is %s from the gateway's", skew)
+		result.Hint = "Sync your clock with NTP, tokens and certificates may be rejected when clocks differ"
+		return result
+	}
+
+	result.Message = fmt.Sprintf("within %s of the gateway's", maxClockSkew)
+	return result
+}
+
+// checkTemplates checks that every template used by the stack has been pulled,
+// or that there are any templates when there is no stack
+func checkTemplates(templateDir string, services *stack.Services) doctorResult {
+	result := doctorResult{Name: "Templates"}
+
+	if services == nil {
+		matches, _ := filepath.Glob(filepath.Join(templateDir, "*", "template.yml"))
+		if len(matches) == 0 {
+			result.Status = doctorWarn
+			result.Message = fmt.Sprintf("no templates found in %s", templateDir)
+			result.Hint = "Run \"faas-cli template pull\" to get the official templates"
+			return result
+		}
+		result.Message = fmt.Sprintf("%d templates found in %s", len(matches), templateDir)
+		return result
+	}
+
+	missing := []string{}
+	seen := map[string]bool{}
+	for _, function := range services.Functions {
+		language, _ := stack.SplitLanguage(function.Language)
+		if !languageExistsNotDockerfile(language) || seen[language] {
+			continue
+		}
+		seen[language] = true
+
+		if _, err := os.Stat(filepath.Join(templateDir, language, "template.yml")); err != nil {
+			missing = append(missing, language)
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		result.Status = doctorFail
+		result.Message = fmt.Sprintf("missing templates: %s", strings.Join(missing, ", "))
+		result.Hint = "Run \"faas-cli template pull stack\" or \"faas-cli template store pull\" to get them"
+		return result
+	}
+
+	result.Message = fmt.Sprintf("all %d templates used by %s are present", len(seen), yamlFile)
+	return result
+}
+
+func checkDiskSpace(path string) doctorResult {
+	result := doctorResult{Name: "Disk space"}
+
+	free, err := freeDiskSpace(path)
+	if err != nil {
+		result.Status = doctorWarn
+		result.Message = fmt.Sprintf("unable to check free disk space: %s", err)
+		return result
+	}
+
+	result.Message = fmt.Sprintf("%s free", formatBytes(free))
+	if free < minBuildDiskSpace {
+		result.Status = doctorWarn
+		result.Hint = "Builds may fail, free up space with \"docker system prune\""
+	}
+	return result
+}
+
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,14 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package commands
+
+import "fmt"
+
+// freeDiskSpace is not implemented on this OS
+var freeDiskSpace = func(path string) (uint64, error) {
+	return 0, fmt.Errorf("not supported on this OS")
+}
@@ -0,0 +1,18 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+//go:build linux || darwin
+// +build linux darwin
+
+package commands
+
+import "syscall"
+
+// freeDiskSpace returns the bytes available to the user on the filesystem of path
+var freeDiskSpace = func(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
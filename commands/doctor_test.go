@@ -0,0 +1,143 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+func Test_checkDocker(t *testing.T) {
+	defer func(original func(string, ...string) (string, error)) { runDoctorCommand = original }(runDoctorCommand)
+
+	runDoctorCommand = func(name string, args ...string) (string, error) {
+		return "20.10.7", nil
+	}
+	if result := checkDocker(); result.Status != doctorPass || result.Message != "daemon version 20.10.7" {
+		t.Errorf("want pass with the daemon version, got: %+v", result)
+	}
+
+	runDoctorCommand = func(name string, args ...string) (string, error) {
+		return "", fmt.Errorf("Cannot connect to the Docker daemon")
+	}
+	if result := checkDocker(); result.Status != doctorFail || len(result.Hint) == 0 {
+		t.Errorf("want fail with a hint, got: %+v", result)
+	}
+}
+
+func Test_checkGateway(t *testing.T) {
+	cases := []struct {
+		name       string
+		infoStatus int
+		want       []doctorStatus
+	}{
+		{name: "authorized", infoStatus: http.StatusOK, want: []doctorStatus{doctorPass, doctorPass, doctorPass}},
+		{name: "unauthorized", infoStatus: http.StatusUnauthorized, want: []doctorStatus{doctorPass, doctorFail, doctorPass}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/healthz":
+					w.WriteHeader(http.StatusOK)
+				case "/system/info":
+					w.WriteHeader(tc.infoStatus)
+					w.Write([]byte(`{}`))
+				}
+			}))
+			defer s.Close()
+
+			results := checkGateway(s.URL)
+			if len(results) != len(tc.want) {
+				t.Fatalf("want %d results, got: %+v", len(tc.want), results)
+			}
+			for i, want := range tc.want {
+				if results[i].Status != want {
+					t.Errorf("%s: want status %d, got: %+v", results[i].Name, want, results[i])
+				}
+			}
+		})
+	}
+
+	if results := checkGateway("http://127.0.0.1:1"); len(results) != 1 || results[0].Status != doctorFail {
+		t.Errorf("want a single failure for an unreachable gateway, got: %+v", results)
+	}
+}
+
+func Test_checkClockSkew(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name string
+		date string
+		want doctorStatus
+	}{
+		{name: "in sync", date: now.Add(2 * time.Second).Format(http.TimeFormat), want: doctorPass},
+		{name: "behind", date: now.Add(-5 * time.Minute).Format(http.TimeFormat), want: doctorWarn},
+		{name: "ahead", date: now.Add(5 * time.Minute).Format(http.TimeFormat), want: doctorWarn},
+		{name: "no date", date: "", want: doctorWarn},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := checkClockSkew(tc.date, now); result.Status != tc.want {
+				t.Errorf("want status %d, got: %+v", tc.want, result)
+			}
+		})
+	}
+}
+
+func Test_checkTemplates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "faas-cli-doctor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if result := checkTemplates(dir, nil); result.Status != doctorWarn {
+		t.Errorf("want a warning with no templates, got: %+v", result)
+	}
+
+	os.MkdirAll(filepath.Join(dir, "python3"), 0700)
+	ioutil.WriteFile(filepath.Join(dir, "python3", "template.yml"), []byte("language: python3\n"), 0600)
+
+	if result := checkTemplates(dir, nil); result.Status != doctorPass {
+		t.Errorf("want pass with a template, got: %+v", result)
+	}
+
+	services := &stack.Services{
+		Functions: map[string]stack.Function{
+			"fn1": {Language: "python3"},
+			"fn2": {Language: "dockerfile"},
+			"fn3": {Language: "go"},
+		},
+	}
+	result := checkTemplates(dir, services)
+	if result.Status != doctorFail || result.Message != "missing templates: go" {
+		t.Errorf("want the go template to be missing, got: %+v", result)
+	}
+}
+
+func Test_formatBytes(t *testing.T) {
+	cases := map[uint64]string{
+		512:                    "512B",
+		2048:                   "2.0KiB",
+		5 * 1024 * 1024 * 1024: "5.0GiB",
+	}
+
+	for n, want := range cases {
+		if got := formatBytes(n); got != want {
+			t.Errorf("%d want: %s, got: %s", n, want, got)
+		}
+	}
+}
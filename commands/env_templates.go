@@ -0,0 +1,38 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"regexp"
+	"strings"
+)
+
+var envTemplatePattern = regexp.MustCompile(`{{\s*([a-z_]+)\s*}}`)
+
+// envTemplateValues builds the values which environment variables can reference
+// as {{ name }}, such as "{{ gateway_url }}/function/{{ function_name }}"
+func envTemplateValues(gatewayURL, functionName, namespace, image string) map[string]string {
+	return map[string]string{
+		"gateway_url":   strings.TrimRight(gatewayURL, "/"),
+		"function_name": functionName,
+		"namespace":     namespace,
+		"image":         image,
+	}
+}
+
+// expandEnvTemplates expands {{ name }} in each environment value at deploy time,
+// unknown names are left as they are so that other template syntax is untouched
+func expandEnvTemplates(envs map[string]string, values map[string]string) map[string]string {
+	expanded := make(map[string]string, len(envs))
+	for k, v := range envs {
+		expanded[k] = envTemplatePattern.ReplaceAllStringFunc(v, func(match string) string {
+			name := envTemplatePattern.FindStringSubmatch(match)[1]
+			if value, ok := values[name]; ok {
+				return value
+			}
+			return match
+		})
+	}
+	return expanded
+}
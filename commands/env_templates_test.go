@@ -0,0 +1,32 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_expandEnvTemplates(t *testing.T) {
+	values := envTemplateValues("http://127.0.0.1:8080/", "figlet", "openfaas-fn", "functions/figlet:latest")
+
+	envs := map[string]string{
+		"callback_url": "{{ gateway_url }}/function/{{function_name}}",
+		"identity":     "{{ function_name }}.{{ namespace }} ({{ image }})",
+		"unknown":      "{{ .Values.name }} {{ other }}",
+		"plain":        "value",
+	}
+
+	want := map[string]string{
+		"callback_url": "http://127.0.0.1:8080/function/figlet",
+		"identity":     "figlet.openfaas-fn (functions/figlet:latest)",
+		"unknown":      "{{ .Values.name }} {{ other }}",
+		"plain":        "value",
+	}
+
+	got := expandEnvTemplates(envs, values)
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want: %q, but got: %q", want, got)
+	}
+}
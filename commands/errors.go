@@ -4,14 +4,79 @@
 package commands
 
 import (
+	"errors"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/openfaas/faas-cli/proxy"
 )
 
 const (
 	// NoTLSWarn Warning thrown when no SSL/TLS is used
 	NoTLSWarn = "WARNING! You are not using an encrypted connection to the gateway, consider using HTTPS."
+
+	// gatewayUnreachableHint is printed when the gateway cannot be reached
+	gatewayUnreachableHint = "Is OpenFaaS deployed? Do you need to specify the --gateway flag?"
+)
+
+// Exit codes returned by the CLI, scripts can branch on these values
+const (
+	exitCodeError              = 1
+	exitCodeGatewayUnreachable = 2
+	exitCodeUnauthorized       = 3
+	exitCodeNotFound           = 4
+	exitCodeDeployRejected     = 5
+	exitCodeWaitTimeout        = 6
 )
 
+// exitCode returns the exit code of the CLI for an error returned by a command
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, proxy.ErrGatewayUnreachable):
+		return exitCodeGatewayUnreachable
+	case errors.Is(err, proxy.ErrUnauthorized):
+		return exitCodeUnauthorized
+	case errors.Is(err, proxy.ErrNotFound):
+		return exitCodeNotFound
+	case errors.Is(err, proxy.ErrDeployRejected):
+		return exitCodeDeployRejected
+	case errors.Is(err, proxy.ErrWaitTimeout):
+		return exitCodeWaitTimeout
+	}
+	return exitCodeError
+}
+
+// errorMessage is the message of an error to print, starting with a capital
+// letter. The colour is added when it is printed, so that the values of
+// errors stay plain text.
+func errorMessage(err error) string {
+	e := err.Error()
+	first, size := utf8.DecodeRuneInString(e)
+	if first == utf8.RuneError {
+		return e
+	}
+	return string(unicode.ToUpper(first)) + e[size:]
+}
+
+// errorCallID is the call ID of the gateway call which failed, if there is
+// one, so that the failure can be found in the logs of the gateway
+func errorCallID(err error) string {
+	var callErr *proxy.CallError
+	if errors.As(err, &callErr) {
+		return callErr.CallID
+	}
+	return ""
+}
+
+// errorHint returns a hint to print after the error, if there is one
+func errorHint(err error) string {
+	if errors.Is(err, proxy.ErrGatewayUnreachable) {
+		return gatewayUnreachableHint
+	}
+	return ""
+}
+
 // checkTLSInsecure returns a warning message if the given gateway does not have https.
 // Use tsInsecure to skip validations
 func checkTLSInsecure(gateway string, tlsInsecure bool) string {
@@ -2,7 +2,13 @@
 // Licensed under the MIT license. See LICENSE file in the project root for full license information.
 package commands
 
-import "testing"
+import (
+	"fmt"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/openfaas/faas-cli/proxy"
+)
 
 func Test_checkTLSInsecure(t *testing.T) {
 	type args struct {
@@ -44,3 +50,69 @@ func Test_checkTLSInsecure(t *testing.T) {
 		})
 	}
 }
+
+func Test_exitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "generic error", err: fmt.Errorf("please provide a name for the function"), want: exitCodeError},
+		{name: "gateway unreachable", err: fmt.Errorf("%w on URL: http://127.0.0.1:8080", proxy.ErrGatewayUnreachable), want: exitCodeGatewayUnreachable},
+		{name: "unauthorized", err: proxy.ErrUnauthorized, want: exitCodeUnauthorized},
+		{name: "function not found", err: fmt.Errorf("function figlet %w", proxy.ErrNotFound), want: exitCodeNotFound},
+		{name: "deploy rejected", err: deployFailed(map[string]int{"figlet": 400}, map[string]error{"figlet": fmt.Errorf("%w with status 400: bad image", proxy.ErrDeployRejected)}), want: exitCodeDeployRejected},
+		{name: "deploy without a reason", err: deployFailed(map[string]int{"figlet": 500}, nil), want: exitCodeDeployRejected},
+		{name: "deploy to an unreachable gateway", err: deployFailed(map[string]int{"figlet": 500, "env": 400}, map[string]error{"figlet": fmt.Errorf("%w on URL: http://127.0.0.1:8080", proxy.ErrGatewayUnreachable), "env": proxy.ErrDeployRejected}), want: exitCodeGatewayUnreachable},
+		{name: "deploy unauthorized", err: deployFailed(map[string]int{"figlet": 401}, map[string]error{"figlet": proxy.ErrUnauthorized}), want: exitCodeUnauthorized},
+		{name: "wait timeout", err: fmt.Errorf("%w: figlet", proxy.ErrWaitTimeout), want: exitCodeWaitTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_errorCallID(t *testing.T) {
+	callErr := &proxy.CallError{CallID: "deploy-1234", Err: proxy.ErrUnauthorized}
+	if got := errorCallID(fmt.Errorf("unable to list secrets: %w", callErr)); got != "deploy-1234" {
+		t.Errorf("want the call ID of a wrapped CallError, got: %q", got)
+	}
+	if got := errorCallID(fmt.Errorf("please provide a name for the function")); got != "" {
+		t.Errorf("want no call ID for an error without one, got: %q", got)
+	}
+
+	err := deployFailed(map[string]int{"figlet": 401}, map[string]error{"figlet": callErr})
+	want := "Function 'figlet' failed to deploy with status code: 401 (X-Call-Id: deploy-1234)"
+	if err.Error() != want {
+		t.Errorf("want %q, got %q", want, err.Error())
+	}
+}
+
+func Test_errorMessage(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "capitalised", err: fmt.Errorf("function not found"), want: "Function not found"},
+		{name: "multi-byte first letter", err: fmt.Errorf("échec du déploiement"), want: "Échec du déploiement"},
+		{name: "glyph is kept", err: fmt.Errorf("✘ build failed"), want: "✘ build failed"},
+		{name: "empty", err: fmt.Errorf(""), want: ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := errorMessage(tc.err)
+			if got != tc.want {
+				t.Fatalf("want %q, got %q", tc.want, got)
+			}
+			if !utf8.ValidString(got) {
+				t.Fatalf("want valid UTF-8, got %q", got)
+			}
+		})
+	}
+}
@@ -6,9 +6,10 @@ package commands
 import (
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/docker/docker/pkg/term"
+	"github.com/openfaas/faas-cli/output"
+	"github.com/openfaas/faas-cli/proxy"
 	"github.com/openfaas/faas-cli/version"
 	"github.com/spf13/cobra"
 )
@@ -22,9 +23,14 @@ const (
 
 // Flags that are to be added to all commands.
 var (
-	yamlFile string
-	regex    string
-	filter   string
+	yamlFile         string
+	regex            string
+	regexExact       bool
+	filter           string
+	filterIgnoreCase bool
+	selectExpr       string
+	envProfile       string
+	requestID        string
 )
 
 // Flags that are to be added to subset of commands.
@@ -48,20 +54,37 @@ var stat = func(filename string) (os.FileInfo, error) {
 // TODO: remove this workaround once these vars are no longer global
 func resetForTest() {
 	yamlFile = ""
+	overrideYAMLFiles = nil
+	yamlFileFlag = yamlFileValue{}
 	regex = ""
+	regexExact = false
 	filter = ""
+	filterIgnoreCase = false
+	selectExpr = ""
+	envProfile = ""
+	requestID = ""
 	version.Version = ""
 	shortVersion = false
 	appendFile = ""
+	strictAppend = false
+	resetConfigSettings()
+	containerEngineChecked = false
 }
 
 func init() {
 	// Setup terminal std
 	term.StdStreams()
 
-	faasCmd.PersistentFlags().StringVarP(&yamlFile, "yaml", "f", "", "Path to YAML file describing function(s)")
+	faasCmd.PersistentFlags().VarP(&yamlFileFlag, "yaml", "f", "Path to YAML file describing function(s), give more than once to merge files over the first in order")
 	faasCmd.PersistentFlags().StringVarP(&regex, "regex", "", "", "Regex to match with function names in YAML file")
+	faasCmd.PersistentFlags().BoolVar(&regexExact, "regex-exact", false, "Match --regex against the whole function name, so that fn1 does not match fn10")
 	faasCmd.PersistentFlags().StringVarP(&filter, "filter", "", "", "Wildcard to match with function names in YAML file")
+	faasCmd.PersistentFlags().BoolVar(&filterIgnoreCase, "filter-ignore-case", false, "Match --filter without regard to case")
+	faasCmd.PersistentFlags().StringVar(&envProfile, "env-profile", "", "Apply a profile from the environments section of the YAML file, i.e. prod")
+	faasCmd.PersistentFlags().StringVar(&selectExpr, "select", "", "Select functions in YAML file by name and tag, i.e. 'name~api, !name~legacy, tag=critical'")
+	faasCmd.PersistentFlags().StringVar(&requestID, "request-id", "", "Call ID to send in the X-Call-Id header to the gateway, generated per request if not set")
+	faasCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "Answer yes to every confirmation")
+	faasCmd.PersistentFlags().BoolVar(&nonInteractive, "non-interactive", false, "Fail instead of prompting for input, the default when stdin is not a terminal")
 
 	// Set Bash completion options
 	validYAMLFilenames := []string{"yaml", "yml"}
@@ -70,15 +93,31 @@ func init() {
 
 // Execute TODO
 func Execute(customArgs []string) {
+	defer recoverPanic(customArgs[1:])
+
+	resetConfigSettings()
 	checkAndSetDefaultYaml()
+	applyLegacyEnvironment()
+	faasCmd.SetGlobalNormalizationFunc(normalizeLegacyFlags)
 
 	faasCmd.SilenceUsage = true
 	faasCmd.SilenceErrors = true
-	faasCmd.SetArgs(customArgs[1:])
-	if err := faasCmd.Execute(); err != nil {
-		e := err.Error()
-		fmt.Println(strings.ToUpper(e[:1]) + e[1:])
-		os.Exit(1)
+	suggestSubcommands(faasCmd)
+	faasCmd.SetArgs(expandShortcuts(customArgs[1:], faasCmd.PersistentFlags()))
+	err := faasCmd.Execute()
+	stopDiagnostics()
+	sshTunnels.close()
+	if err != nil {
+		// Errors go to stderr, so that they are not read as the output of
+		// a quiet command, i.e. $(faas-cli build -q)
+		fmt.Fprintln(os.Stderr, output.Failure("%s", errorMessage(err)))
+		if callID := errorCallID(err); len(callID) > 0 {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", proxy.CallIDHeader, callID)
+		}
+		if hint := errorHint(err); len(hint) > 0 {
+			fmt.Fprintln(os.Stderr, hint)
+		}
+		os.Exit(exitCode(err))
 	}
 }
 
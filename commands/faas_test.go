@@ -4,8 +4,19 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+
+	"github.com/openfaas/faas-cli/config"
 )
 
+// TestMain keeps the config file of whoever runs the tests out of them, a
+// test which needs settings from the config file sets lookupConfigSettings
+func TestMain(m *testing.M) {
+	lookupConfigSettings = func() (config.Resolved, error) {
+		return config.Resolved{}, nil
+	}
+	os.Exit(m.Run())
+}
+
 var mockStatParams string
 
 func setupFaas(statError error) {
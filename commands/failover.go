@@ -0,0 +1,50 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/openfaas/faas-cli/config"
+	"github.com/openfaas/faas-cli/output"
+	"github.com/openfaas/faas-cli/proxy"
+)
+
+// lookupFallbacks is a variable so that tests do not need a config file
+var lookupFallbacks = config.LookupFallbacks
+
+// withGatewayFailover calls fn with the gateway, then with each of its
+// fallbacks from the config file for as long as the gateway which was tried
+// is unreachable. It is only for read-only commands, as a change may have
+// been made by a gateway which then became unreachable.
+func withGatewayFailover(gatewayAddress string, fn func(gatewayAddress string) error) error {
+	err := fn(gatewayAddress)
+	if err == nil || !errors.Is(err, proxy.ErrGatewayUnreachable) {
+		return err
+	}
+
+	fallbacks, lookupErr := lookupFallbacks(gatewayAddress)
+	if lookupErr != nil || len(fallbacks) == 0 {
+		return err
+	}
+
+	unreachable := gatewayAddress
+	for _, fallback := range fallbacks {
+		fmt.Fprintln(os.Stderr, output.Warning("%s is unreachable, trying fallback %s", unreachable, fallback))
+		sshTunnels.addGateway(fallback)
+
+		err = fn(fallback)
+		if err == nil {
+			fmt.Fprintf(os.Stderr, "Served by fallback gateway %s\n", fallback)
+			return nil
+		}
+		if !errors.Is(err, proxy.ErrGatewayUnreachable) {
+			return err
+		}
+		unreachable = fallback
+	}
+	return err
+}
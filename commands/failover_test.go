@@ -0,0 +1,132 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/openfaas/faas-cli/test"
+)
+
+func Test_withGatewayFailover(t *testing.T) {
+	defer func() {
+		lookupFallbacks = defaultLookupFallbacks
+	}()
+	lookupFallbacks = func(gateway string) ([]string, error) {
+		return []string{"http://fallback-1", "http://fallback-2"}, nil
+	}
+
+	unreachable := func(gateway string) error {
+		return fmt.Errorf("%w on URL: %s", proxy.ErrGatewayUnreachable, gateway)
+	}
+
+	cases := []struct {
+		name      string
+		responses map[string]error
+		wantTried []string
+		wantErr   string
+	}{
+		{
+			name:      "primary answers",
+			responses: map[string]error{},
+			wantTried: []string{"http://primary"},
+		},
+		{
+			name: "second fallback answers",
+			responses: map[string]error{
+				"http://primary":    unreachable("http://primary"),
+				"http://fallback-1": unreachable("http://fallback-1"),
+			},
+			wantTried: []string{"http://primary", "http://fallback-1", "http://fallback-2"},
+		},
+		{
+			name: "fallback is not tried for other errors",
+			responses: map[string]error{
+				"http://primary": proxy.ErrUnauthorized,
+			},
+			wantTried: []string{"http://primary"},
+			wantErr:   proxy.ErrUnauthorized.Error(),
+		},
+		{
+			name: "every gateway is unreachable",
+			responses: map[string]error{
+				"http://primary":    unreachable("http://primary"),
+				"http://fallback-1": unreachable("http://fallback-1"),
+				"http://fallback-2": unreachable("http://fallback-2"),
+			},
+			wantTried: []string{"http://primary", "http://fallback-1", "http://fallback-2"},
+			wantErr:   "cannot connect to OpenFaaS on URL: http://fallback-2",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tried := []string{}
+			err := withGatewayFailover("http://primary", func(gateway string) error {
+				tried = append(tried, gateway)
+				return c.responses[gateway]
+			})
+
+			if !reflect.DeepEqual(tried, c.wantTried) {
+				t.Errorf("want gateways %v to be tried, got %v", c.wantTried, tried)
+			}
+			if len(c.wantErr) == 0 && err != nil {
+				t.Errorf("want no error, got %s", err)
+			}
+			if len(c.wantErr) > 0 && (err == nil || err.Error() != c.wantErr) {
+				t.Errorf("want error %q, got %v", c.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_list_usesFallbackGateway(t *testing.T) {
+	resetForTest()
+	defer func() {
+		lookupFallbacks = defaultLookupFallbacks
+		quiet = false
+	}()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	primary := closed.URL
+	closed.Close()
+
+	s := test.MockHttpServer(t, []test.Request{
+		test.ListFunctionsRequest("", "figlet"),
+	})
+	defer s.Close()
+
+	lookupFallbacks = func(gateway string) ([]string, error) {
+		if gateway != primary {
+			return nil, errors.New("unexpected gateway " + gateway)
+		}
+		return []string{s.URL}, nil
+	}
+
+	var err error
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{
+			"list",
+			"--gateway=" + primary,
+			"--quiet",
+		})
+		err = faasCmd.Execute()
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(stdOut) != "figlet" {
+		t.Errorf("want the functions from the fallback, got:\n%s", stdOut)
+	}
+}
+
+var defaultLookupFallbacks = lookupFallbacks
@@ -4,13 +4,17 @@
 package commands
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/openfaas/faas-cli/builder"
+	"github.com/openfaas/faas-cli/flags"
 	"github.com/openfaas/faas-cli/versioncontrol"
 )
 
@@ -19,15 +23,25 @@ const DefaultTemplateRepository = "https://github.com/openfaas/templates.git"
 
 const templateDirectory = "./template/"
 
+const defaultTemplateDirPermissions = 0700
+
+// templateSourcesFile records the repository which each language in
+// ./template was pulled from, so that --prune only deletes the languages of
+// the repository which is pulled
+const templateSourcesFile = ".sources.json"
+
+// renameTemplate is a variable so that tests can make a move fail
+var renameTemplate = os.Rename
+
 // fetchTemplates fetch code templates using git clone.
-func fetchTemplates(templateURL string, refName string, overwrite bool) error {
+func fetchTemplates(templateURL string, refName string, overwrite flags.Overwrite, prune bool, verification templateVerification) error {
 	if len(templateURL) == 0 {
 		return fmt.Errorf("pass valid templateURL")
 	}
 
 	dir, err := ioutil.TempDir("", "openFaasTemplates")
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	if !pullDebug {
 		defer os.RemoveAll(dir) // clean up
@@ -43,28 +57,46 @@ func fetchTemplates(templateURL string, refName string, overwrite bool) error {
 		cmd = versioncontrol.GitClone
 	}
 
-	if err := cmd.Invoke(".", args); err != nil {
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	if err := cmd.InvokeContext(ctx, ".", args); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("template pull was cancelled")
+		}
+		return err
+	}
+
+	if err := verifyTemplates(dir, refName, verification); err != nil {
 		return err
 	}
 
-	preExistingLanguages, fetchedLanguages, err := moveTemplates(dir, overwrite)
+	summary, err := moveTemplates(dir, templateURL, overwrite, prune)
 	if err != nil {
 		return err
 	}
 
-	if len(preExistingLanguages) > 0 {
-		log.Printf("Cannot overwrite the following %d template(s): %v\n", len(preExistingLanguages), preExistingLanguages)
+	if len(summary.Skipped) > 0 {
+		log.Printf("Cannot overwrite the following %d template(s): %v\n", len(summary.Skipped), summary.Skipped)
+	}
+
+	if len(summary.Overwritten) > 0 {
+		log.Printf("Overwrote the following %d template(s): %v\n", len(summary.Overwritten), summary.Overwritten)
+	}
+
+	if len(summary.Pruned) > 0 {
+		log.Printf("Pruned the following %d template(s): %v\n", len(summary.Pruned), summary.Pruned)
 	}
 
-	log.Printf("Fetched %d template(s) : %v from %s\n", len(fetchedLanguages), fetchedLanguages, templateURL)
+	log.Printf("Fetched %d template(s) : %v from %s\n", len(summary.Fetched), summary.Fetched, templateURL)
 
-	return err
+	return nil
 }
 
 // canWriteLanguage tells whether the language can be expanded from the zip or not.
 // availableLanguages map keeps track of which languages we know to be okay to copy.
 // overwrite flag will allow to force copy the language template
-func canWriteLanguage(availableLanguages map[string]bool, language string, overwrite bool) bool {
+func canWriteLanguage(availableLanguages map[string]bool, language string, overwrite flags.Overwrite) bool {
 	canWrite := false
 	if availableLanguages != nil && len(language) > 0 {
 		if _, found := availableLanguages[language]; found {
@@ -78,53 +110,167 @@ func canWriteLanguage(availableLanguages map[string]bool, language string, overw
 }
 
 // Takes a language input (e.g. "node"), tells whether or not it is OK to download
-func templateFolderExists(language string, overwrite bool) bool {
+func templateFolderExists(language string, overwrite flags.Overwrite) bool {
 	dir := templateDirectory + language
-	if _, err := os.Stat(dir); err == nil && !overwrite {
+	if _, err := os.Stat(dir); err == nil && !overwrite.Allows(language) {
 		// The directory template/language/ exists
 		return false
 	}
 	return true
 }
 
-func moveTemplates(repoPath string, overwrite bool) ([]string, []string, error) {
-	var (
-		existingLanguages []string
-		fetchedLanguages  []string
-		err               error
-	)
+// templatePullSummary lists what happened to each language found in a template repository
+type templatePullSummary struct {
+	Fetched     []string
+	Overwritten []string
+	Skipped     []string
+	Pruned      []string
+}
 
+// moveTemplates copies the languages from the repository into ./template. The
+// languages are first extracted into a staging folder and only moved into place
+// once all of them have been copied, so that a failure never leaves a partly
+// written template behind. The repository is recorded as the source of each
+// language which is fetched. With prune, local languages which were pulled
+// from the same repository, but are no longer in it, are deleted. When a
+// language cannot be moved or pruned, the changes already made are undone.
+func moveTemplates(repoPath, source string, overwrite flags.Overwrite, prune bool) (*templatePullSummary, error) {
+	summary := &templatePullSummary{}
 	availableLanguages := make(map[string]bool)
 
 	templateDir := filepath.Join(repoPath, templateDirectory)
 	templates, err := ioutil.ReadDir(templateDir)
 	if err != nil {
-		return nil, nil, fmt.Errorf("can't find templates in: %s", repoPath)
+		return nil, fmt.Errorf("can't find templates in: %s", repoPath)
 	}
 
+	if err := os.MkdirAll(templateDirectory, defaultTemplateDirPermissions); err != nil {
+		return nil, err
+	}
+
+	staging, err := ioutil.TempDir(templateDirectory, ".pull-")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create a staging folder for templates: %s", err)
+	}
+	defer os.RemoveAll(staging)
+
+	sources, err := readTemplateSources()
+	if err != nil {
+		return nil, err
+	}
+
+	upstream := map[string]bool{}
 	for _, file := range templates {
 		if !file.IsDir() {
 			continue
 		}
 		language := file.Name()
+		upstream[language] = true
 
-		canWrite := canWriteLanguage(availableLanguages, language, overwrite)
-		if canWrite {
-			fetchedLanguages = append(fetchedLanguages, language)
-			// Do cp here
-			languageSrc := filepath.Join(templateDir, language)
-			languageDest := filepath.Join(templateDirectory, language)
-			builder.CopyFiles(languageSrc, languageDest)
-		} else {
-			existingLanguages = append(existingLanguages, language)
+		if !canWriteLanguage(availableLanguages, language, overwrite) {
+			summary.Skipped = append(summary.Skipped, language)
 			continue
 		}
+
+		languageSrc := filepath.Join(templateDir, language)
+		if err := builder.CopyFiles(languageSrc, filepath.Join(staging, language)); err != nil {
+			return nil, fmt.Errorf("unable to extract template %s, no templates were written: %s", language, err)
+		}
+		summary.Fetched = append(summary.Fetched, language)
+	}
+
+	// Every change to ./template is a rename, which is undone when a later
+	// one fails, so that the templates are left as they were
+	undo := []func(){}
+	rollback := func(err error) (*templatePullSummary, error) {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+		return nil, fmt.Errorf("%s, no templates were changed", err)
+	}
+	move := func(from, to string) error {
+		if err := renameTemplate(from, to); err != nil {
+			return err
+		}
+		undo = append(undo, func() { renameTemplate(to, from) })
+		return nil
 	}
 
-	return existingLanguages, fetchedLanguages, nil
+	for _, language := range summary.Fetched {
+		languageDest := filepath.Join(templateDirectory, language)
+
+		if _, err := os.Stat(languageDest); err == nil {
+			if err := move(languageDest, filepath.Join(staging, "."+language+".previous")); err != nil {
+				return rollback(fmt.Errorf("unable to replace template %s: %s", language, err))
+			}
+			summary.Overwritten = append(summary.Overwritten, language)
+		}
+
+		if err := move(filepath.Join(staging, language), languageDest); err != nil {
+			return rollback(fmt.Errorf("unable to move template %s into place: %s", language, err))
+		}
+		sources[language] = source
+	}
+
+	if prune {
+		local, err := ioutil.ReadDir(templateDirectory)
+		if err != nil {
+			return rollback(err)
+		}
+
+		// Pruned languages are moved into the staging folder, which is
+		// removed once everything else has worked. Languages from other
+		// repositories, or which were not pulled, are kept.
+		for _, file := range local {
+			language := file.Name()
+			if !file.IsDir() || strings.HasPrefix(language, ".") || upstream[language] || sources[language] != source {
+				continue
+			}
+
+			if err := move(filepath.Join(templateDirectory, language), filepath.Join(staging, "."+language+".pruned")); err != nil {
+				return rollback(fmt.Errorf("unable to prune template %s: %s", language, err))
+			}
+			summary.Pruned = append(summary.Pruned, language)
+			delete(sources, language)
+		}
+	}
+
+	if err := writeTemplateSources(sources); err != nil {
+		return rollback(fmt.Errorf("unable to record the source of the templates: %s", err))
+	}
+
+	return summary, nil
 }
 
-func pullTemplate(repository string) error {
+// readTemplateSources reads the repository of each language in ./template,
+// languages which were pulled before the sources were recorded have none
+func readTemplateSources() (map[string]string, error) {
+	sources := map[string]string{}
+
+	data, err := ioutil.ReadFile(filepath.Join(templateDirectory, templateSourcesFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return sources, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &sources); err != nil {
+		return nil, fmt.Errorf("unable to read %s: %s", filepath.Join(templateDirectory, templateSourcesFile), err)
+	}
+	return sources, nil
+}
+
+func writeTemplateSources(sources map[string]string) error {
+	data, err := json.MarshalIndent(sources, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(templateDirectory, templateSourcesFile), data, 0600)
+}
+
+// pullTemplate pulls the templates from a repository, which may be pinned to
+// a tag or branch, the progress is written to out
+func pullTemplate(out io.Writer, repository string) error {
 	if _, err := os.Stat(repository); err != nil {
 		if !versioncontrol.IsGitRemote(repository) && !versioncontrol.IsPinnedGitRemote(repository) {
 			return fmt.Errorf("The repository URL must be a valid git repo uri")
@@ -136,15 +282,21 @@ func pullTemplate(repository string) error {
 	if refName != "" {
 		err := versioncontrol.GitCheckRefName.Invoke("", map[string]string{"refname": refName})
 		if err != nil {
-			fmt.Printf("Invalid tag or branch name `%s`\n", refName)
-			fmt.Println("See https://git-scm.com/docs/git-check-ref-format for more details of the rules Git enforces on branch and reference names.")
+			fmt.Fprintf(out, "Invalid tag or branch name `%s`\n", refName)
+			fmt.Fprintln(out, "See https://git-scm.com/docs/git-check-ref-format for more details of the rules Git enforces on branch and reference names.")
 
 			return err
 		}
 	}
 
-	fmt.Printf("Fetch templates from repository: %s at %s\n", repository, refName)
-	if err := fetchTemplates(repository, refName, overwrite); err != nil {
+	checksum, err := parseTemplateChecksum(templateChecksumFlag)
+	if err != nil {
+		return err
+	}
+	verification := templateVerification{Signature: verifyTemplateFlag, Checksum: checksum}
+
+	fmt.Fprintf(out, "Fetch templates from repository: %s at %s\n", repository, refName)
+	if err := fetchTemplates(repository, refName, overwriteFlags(), prune, verification); err != nil {
 		return fmt.Errorf("error while fetching templates: %s", err)
 	}
 
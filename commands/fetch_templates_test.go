@@ -3,12 +3,15 @@
 package commands
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/openfaas/faas-cli/builder"
+	"github.com/openfaas/faas-cli/flags"
 	"github.com/openfaas/faas-cli/versioncontrol"
 )
 
@@ -27,7 +30,7 @@ func Test_PullTemplates(t *testing.T) {
 	t.Run("fetchTemplates", func(t *testing.T) {
 		defer tearDownFetchTemplates(t)
 
-		err := fetchTemplates(localTemplateRepository, "master", false)
+		err := fetchTemplates(localTemplateRepository, "master", flags.Overwrite{}, false, templateVerification{})
 		if err != nil {
 			t.Error(err)
 		}
@@ -37,7 +40,7 @@ func Test_PullTemplates(t *testing.T) {
 	t.Run("fetchTemplates with default ref", func(t *testing.T) {
 		defer tearDownFetchTemplates(t)
 
-		err := fetchTemplates(localTemplateRepository, "", false)
+		err := fetchTemplates(localTemplateRepository, "", flags.Overwrite{}, false, templateVerification{})
 		if err != nil {
 			t.Error(err)
 		}
@@ -80,3 +83,191 @@ func tearDownFetchTemplates(t *testing.T) {
 		t.Logf("Directory template was not created: %s", err)
 	}
 }
+
+func Test_moveTemplates(t *testing.T) {
+	defer tearDownFetchTemplates(t)
+
+	repo, err := ioutil.TempDir("", "openFaasTestTemplates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repo)
+
+	for _, language := range []string{"go", "node"} {
+		writeTestTemplate(t, filepath.Join(repo, "template", language), "from repo")
+	}
+	writeTestTemplate(t, filepath.Join(templateDirectory, "go"), "existing")
+
+	summary, err := moveTemplates(repo, repo, flags.Overwrite{}, false)
+	if err != nil {
+		t.Fatalf("want no error, but got: %s", err)
+	}
+	if !reflect.DeepEqual(summary.Fetched, []string{"node"}) || !reflect.DeepEqual(summary.Skipped, []string{"go"}) {
+		t.Fatalf("want node fetched and go skipped, but got: %+v", summary)
+	}
+	assertTemplateContent(t, "go", "existing")
+
+	summary, err = moveTemplates(repo, repo, flags.Overwrite{All: true}, false)
+	if err != nil {
+		t.Fatalf("want no error, but got: %s", err)
+	}
+	if !reflect.DeepEqual(summary.Overwritten, []string{"go", "node"}) {
+		t.Fatalf("want go and node overwritten, but got: %+v", summary)
+	}
+	assertTemplateContent(t, "go", "from repo")
+
+	writeTestTemplate(t, filepath.Join(repo, "template", "go"), "go from repo v2")
+	writeTestTemplate(t, filepath.Join(repo, "template", "node"), "node from repo v2")
+	writeTestTemplate(t, filepath.Join(templateDirectory, "csharp"), "existing")
+	writeTestTemplate(t, filepath.Join(repo, "template", "python"), "from repo")
+	if _, err := moveTemplates(repo, repo, flags.Overwrite{}, false); err != nil {
+		t.Fatalf("want no error, but got: %s", err)
+	}
+	if err := os.RemoveAll(filepath.Join(repo, "template", "python")); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err = moveTemplates(repo, repo, flags.Overwrite{Languages: []string{"node"}}, true)
+	if err != nil {
+		t.Fatalf("want no error, but got: %s", err)
+	}
+	if !reflect.DeepEqual(summary.Overwritten, []string{"node"}) || !reflect.DeepEqual(summary.Skipped, []string{"go"}) {
+		t.Fatalf("want only node overwritten, but got: %+v", summary)
+	}
+	if !reflect.DeepEqual(summary.Pruned, []string{"python"}) {
+		t.Fatalf("want python pruned, but got: %+v", summary)
+	}
+	assertTemplateContent(t, "csharp", "existing")
+	assertTemplateContent(t, "go", "from repo")
+	assertTemplateContent(t, "node", "node from repo v2")
+
+	staged, _ := filepath.Glob(filepath.Join(templateDirectory, ".pull-*"))
+	if len(staged) > 0 {
+		t.Fatalf("want the staging folder to be removed, but found: %v", staged)
+	}
+}
+
+func Test_moveTemplates_FailureWritesNothing(t *testing.T) {
+	defer tearDownFetchTemplates(t)
+
+	repo, err := ioutil.TempDir("", "openFaasTestTemplates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repo)
+
+	writeTestTemplate(t, filepath.Join(repo, "template", "go"), "from repo")
+	writeTestTemplate(t, filepath.Join(repo, "template", "node"), "from repo")
+	if err := os.Symlink(filepath.Join(repo, "missing"), filepath.Join(repo, "template", "node", "broken")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := moveTemplates(repo, repo, flags.Overwrite{}, false); err == nil {
+		t.Fatalf("want error for a template which cannot be copied, but got nil")
+	}
+
+	if _, err := os.Stat(filepath.Join(templateDirectory, "go")); !os.IsNotExist(err) {
+		t.Fatalf("want no templates written after a failure, but found go: %v", err)
+	}
+}
+
+func Test_moveTemplates_PruneFailureUndoesChanges(t *testing.T) {
+	defer tearDownFetchTemplates(t)
+	defer func() { renameTemplate = os.Rename }()
+
+	repo, err := ioutil.TempDir("", "openFaasTestTemplates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repo)
+
+	writeTestTemplate(t, filepath.Join(repo, "template", "go"), "from repo")
+	writeTestTemplate(t, filepath.Join(repo, "template", "node"), "from repo")
+	writeTestTemplate(t, filepath.Join(templateDirectory, "go"), "existing")
+	writeTestTemplate(t, filepath.Join(templateDirectory, "csharp"), "existing")
+	if err := writeTemplateSources(map[string]string{"go": repo, "csharp": repo}); err != nil {
+		t.Fatal(err)
+	}
+
+	renameTemplate = func(from, to string) error {
+		if from == filepath.Join(templateDirectory, "csharp") {
+			return fmt.Errorf("permission denied")
+		}
+		return os.Rename(from, to)
+	}
+
+	if _, err := moveTemplates(repo, repo, flags.Overwrite{All: true}, true); err == nil {
+		t.Fatalf("want error for a template which cannot be pruned, but got nil")
+	}
+
+	assertTemplateContent(t, "go", "existing")
+	assertTemplateContent(t, "csharp", "existing")
+	if _, err := os.Stat(filepath.Join(templateDirectory, "node")); !os.IsNotExist(err) {
+		t.Fatalf("want node removed again after a failure, but found it: %v", err)
+	}
+}
+
+func Test_moveTemplates_PruneKeepsOtherRepositories(t *testing.T) {
+	defer tearDownFetchTemplates(t)
+
+	repo, err := ioutil.TempDir("", "openFaasTestTemplates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repo)
+
+	writeTestTemplate(t, filepath.Join(repo, "template", "go"), "from repo")
+	writeTestTemplate(t, filepath.Join(templateDirectory, "python3-http"), "from another repo")
+	writeTestTemplate(t, filepath.Join(templateDirectory, "java11"), "from the store")
+	writeTestTemplate(t, filepath.Join(templateDirectory, "csharp"), "from repo v1")
+	sources := map[string]string{
+		"python3-http": "https://github.com/openfaas/python-flask-template",
+		"java11":       "https://github.com/openfaas/templates",
+		"csharp":       repo,
+	}
+	if err := writeTemplateSources(sources); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := moveTemplates(repo, repo, flags.Overwrite{}, true)
+	if err != nil {
+		t.Fatalf("want no error, but got: %s", err)
+	}
+	if !reflect.DeepEqual(summary.Pruned, []string{"csharp"}) {
+		t.Fatalf("want only csharp pruned, but got: %+v", summary)
+	}
+	assertTemplateContent(t, "python3-http", "from another repo")
+	assertTemplateContent(t, "java11", "from the store")
+
+	got, err := readTemplateSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"python3-http": "https://github.com/openfaas/python-flask-template",
+		"java11":       "https://github.com/openfaas/templates",
+		"go":           repo,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want sources %v, but got: %v", want, got)
+	}
+}
+
+func writeTestTemplate(t *testing.T, dir, content string) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "template.yml"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertTemplateContent(t *testing.T, language, want string) {
+	got, err := ioutil.ReadFile(filepath.Join(templateDirectory, language, "template.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Fatalf("want template %s to contain %q, but got: %q", language, want, string(got))
+	}
+}
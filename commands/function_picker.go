@@ -0,0 +1,162 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openfaas/faas-cli/proxy"
+)
+
+// maxPickerFunctions is the number of functions which are listed at once,
+// more can be found by typing part of their name
+const maxPickerFunctions = 20
+
+// errNoFunctionName is returned by a command which needs the name of a
+// function when none is given and none can be picked
+var errNoFunctionName = fmt.Errorf("please provide a name for the function")
+
+// pickerOutput is where the functions and the question are written, so that
+// the output of the command itself can still be piped
+var pickerOutput io.Writer = os.Stderr
+
+// listFunctionNames is a variable so that tests do not need a gateway
+var listFunctionNames = func(gatewayAddress, namespace, token string) ([]string, error) {
+	cliAuth, err := proxy.NewCLIAuth(token, gatewayAddress)
+	if err != nil {
+		return nil, err
+	}
+	transport := GetDefaultCLITransport(tlsInsecure, &commandTimeout)
+	client, err := proxy.NewClient(cliAuth, gatewayAddress, transport, &commandTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	functions, err := client.ListFunctions(context.Background(), namespace)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(functions))
+	for _, function := range functions {
+		names = append(names, function.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// pickFunction asks which function to use when a command which needs the name
+// of one is run without it in a terminal
+func pickFunction(gatewayAddress, namespace, token string) (string, error) {
+	if !interactive() {
+		return "", errNoFunctionName
+	}
+
+	names, err := listFunctionNames(gatewayAddress, namespace, token)
+	if err != nil {
+		return "", err
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("there are no functions on %s to pick from", gatewayAddress)
+	}
+	return chooseFunction(names)
+}
+
+// chooseFunction lists the functions until one is picked by its number, or
+// by typing part of its name until it is the only one which matches
+func chooseFunction(names []string) (string, error) {
+	matches := names
+	for {
+		for i, name := range matches {
+			if i == maxPickerFunctions {
+				fmt.Fprintf(pickerOutput, "  ... and %d more, type part of a name to find them\n", len(matches)-i)
+				break
+			}
+			fmt.Fprintf(pickerOutput, "%3d) %s\n", i+1, name)
+		}
+		fmt.Fprint(pickerOutput, "Function (number or search): ")
+
+		answer, err := readPromptLine()
+		if err != nil {
+			return "", err
+		}
+		if len(answer) == 0 {
+			if len(matches) == 1 {
+				return matches[0], nil
+			}
+			continue
+		}
+
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(matches) && n <= maxPickerFunctions {
+			return matches[n-1], nil
+		}
+
+		found := fuzzyMatch(names, answer)
+		switch len(found) {
+		case 0:
+			fmt.Fprintf(pickerOutput, "No functions match %q\n", answer)
+		case 1:
+			fmt.Fprintf(pickerOutput, "Picked %s\n", found[0])
+			return found[0], nil
+		default:
+			matches = found
+		}
+	}
+}
+
+// fuzzyMatch returns the names which contain the letters of search in order,
+// regardless of case. Names which contain search as it was typed come first,
+// then those where the letters are closest together.
+func fuzzyMatch(names []string, search string) []string {
+	search = strings.ToLower(search)
+
+	type match struct {
+		name  string
+		score int
+	}
+	matches := []match{}
+	for _, name := range names {
+		lower := strings.ToLower(name)
+		if i := strings.Index(lower, search); i > -1 {
+			matches = append(matches, match{name: name, score: i})
+			continue
+		}
+		if span, ok := subsequenceSpan(lower, search); ok {
+			matches = append(matches, match{name: name, score: len(name) + span})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score < matches[j].score
+	})
+	found := make([]string, 0, len(matches))
+	for _, m := range matches {
+		found = append(found, m.name)
+	}
+	return found
+}
+
+// subsequenceSpan is how many characters of s the letters of search are
+// spread over, when they are all in s in order
+func subsequenceSpan(s, search string) (int, bool) {
+	start, j := -1, 0
+	for i := 0; i < len(s) && j < len(search); i++ {
+		if s[i] != search[j] {
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+		j++
+		if j == len(search) {
+			return i - start + 1, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,111 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_fuzzyMatch(t *testing.T) {
+	names := []string{"figlet", "nodeinfo", "env", "markdown-render", "sentiment-analysis"}
+
+	cases := []struct {
+		name   string
+		search string
+		want   []string
+	}{
+		{name: "substring", search: "info", want: []string{"nodeinfo"}},
+		{name: "ignores case", search: "FIG", want: []string{"figlet"}},
+		{name: "substrings first", search: "en", want: []string{"env", "sentiment-analysis", "markdown-render", "nodeinfo"}},
+		{name: "letters in order", search: "mdr", want: []string{"markdown-render"}},
+		{name: "letters out of order", search: "gif", want: []string{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := fuzzyMatch(names, tc.search)
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_pickFunction(t *testing.T) {
+	defer func(terminal func() bool, reader *bufio.Reader, list func(string, string, string) ([]string, error), output io.Writer) {
+		stdinIsTerminal = terminal
+		promptReader = reader
+		listFunctionNames = list
+		pickerOutput = output
+		nonInteractive = false
+	}(stdinIsTerminal, promptReader, listFunctionNames, pickerOutput)
+
+	pickerOutput = ioutil.Discard
+	listFunctionNames = func(gatewayAddress, namespace, token string) ([]string, error) {
+		return []string{"env", "figlet", "markdown-render", "nodeinfo", "sentiment-analysis"}, nil
+	}
+
+	cases := []struct {
+		name           string
+		terminal       bool
+		nonInteractive bool
+		input          string
+		want           string
+		wantErr        string
+	}{
+		{name: "by number", terminal: true, input: "2\n", want: "figlet"},
+		{name: "by a single match", terminal: true, input: "node\n", want: "nodeinfo"},
+		{name: "narrowed then by number", terminal: true, input: "en\n3\n", want: "markdown-render"},
+		{name: "narrowed then by search", terminal: true, input: "en\nsent\n", want: "sentiment-analysis"},
+		{name: "no match then by number", terminal: true, input: "xyz\n1\n", want: "env"},
+		{name: "number out of range is a search", terminal: true, input: "9\n4\n", want: "nodeinfo"},
+		{name: "no answer", terminal: true, input: "", wantErr: "EOF"},
+		{name: "no terminal", input: "1\n", wantErr: errNoFunctionName.Error()},
+		{name: "--non-interactive", terminal: true, nonInteractive: true, input: "1\n", wantErr: errNoFunctionName.Error()},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stdinIsTerminal = func() bool { return tc.terminal }
+			promptReader = bufio.NewReader(strings.NewReader(tc.input))
+			nonInteractive = tc.nonInteractive
+
+			got, err := pickFunction("http://127.0.0.1:8080", "", "")
+			if len(tc.wantErr) > 0 {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("want error %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("want no error, got %s", err)
+			}
+			if got != tc.want {
+				t.Fatalf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_pickFunction_noFunctions(t *testing.T) {
+	defer func(terminal func() bool, list func(string, string, string) ([]string, error)) {
+		stdinIsTerminal = terminal
+		listFunctionNames = list
+	}(stdinIsTerminal, listFunctionNames)
+
+	stdinIsTerminal = func() bool { return true }
+	listFunctionNames = func(gatewayAddress, namespace, token string) ([]string, error) {
+		return nil, nil
+	}
+
+	want := "there are no functions on http://127.0.0.1:8080 to pick from"
+	if _, err := pickFunction("http://127.0.0.1:8080", "", ""); err == nil || err.Error() != want {
+		t.Fatalf("want error %q, got %v", want, err)
+	}
+}
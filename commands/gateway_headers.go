@@ -0,0 +1,55 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openfaas/faas-cli/config"
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/spf13/cobra"
+)
+
+// gatewayHeaderFlags are the headers given with --gateway-header
+var gatewayHeaderFlags []string
+
+func init() {
+	faasCmd.PersistentFlags().StringArrayVar(&gatewayHeaderFlags, "gateway-header", []string{}, "Header to send with every request to the gateway as NAME=VALUE, i.e. for Cloudflare Access in front of it")
+
+	faasCmd.PersistentPreRunE = applyGatewayHeaders
+}
+
+// applyGatewayHeaders sets the headers which the proxy package sends to the
+// gateway once the flags have been parsed. It runs before the command, so
+// that an invalid header is returned as its error.
+func applyGatewayHeaders(cmd *cobra.Command, args []string) error {
+	headers, err := parseGatewayHeaders(gatewayHeaderFlags)
+	if err != nil {
+		return err
+	}
+	proxy.ExtraHeaders = headers
+	return nil
+}
+
+// parseGatewayHeaders parses headers given as NAME=VALUE
+func parseGatewayHeaders(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	headers := make(map[string]string, len(values))
+	for _, v := range values {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || len(parts[1]) == 0 {
+			return nil, fmt.Errorf("a gateway header must take the form of NAME=VALUE, got: %q", v)
+		}
+		name := strings.TrimSpace(parts[0])
+		if err := config.ValidateHeader(name, parts[1]); err != nil {
+			return nil, err
+		}
+		headers[name] = parts[1]
+	}
+	return headers, nil
+}
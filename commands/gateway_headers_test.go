@@ -0,0 +1,67 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/proxy"
+)
+
+func Test_parseGatewayHeaders(t *testing.T) {
+	cases := []struct {
+		name    string
+		values  []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "none", values: nil, want: nil},
+		{
+			name:   "value with equals",
+			values: []string{"CF-Access-Client-Id=abc.access", "X-Org-Token=a=b"},
+			want:   map[string]string{"CF-Access-Client-Id": "abc.access", "X-Org-Token": "a=b"},
+		},
+		{name: "no value", values: []string{"X-Org-Token="}, wantErr: true},
+		{name: "no equals", values: []string{"X-Org-Token"}, wantErr: true},
+		{name: "invalid name", values: []string{"X Org=1"}, wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := parseGatewayHeaders(c.values)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("want an error, got: %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("want %v, got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func Test_gatewayHeader_invalidIsReturned(t *testing.T) {
+	resetForTest()
+	defer func() {
+		gatewayHeaderFlags = nil
+		proxy.ExtraHeaders = nil
+	}()
+
+	faasCmd.SetArgs([]string{
+		"version",
+		"--warn-update=false",
+		"--gateway-header", "X-Org-Token",
+	})
+	err := faasCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "NAME=VALUE") {
+		t.Fatalf("want an error for the header, got: %v", err)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	v2 "github.com/openfaas/faas-cli/schema/store/v2"
 
 	"github.com/openfaas/faas-cli/builder"
+	"github.com/openfaas/faas-cli/output"
 	"github.com/openfaas/faas-cli/proxy"
 	"github.com/openfaas/faas-cli/schema"
 	knativev1 "github.com/openfaas/faas-cli/schema/knative/v1"
@@ -46,6 +47,7 @@ func init() {
 	generateCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in stack.yml file")
 	generateCmd.Flags().StringVar(&desiredArch, "arch", "x86_64", "Desired image arch. (Default x86_64)")
 	generateCmd.Flags().StringArrayVar(&annotationArgs, "annotation", []string{}, "Any annotations you want to add (to store functions only)")
+	generateCmd.Flags().StringVar(&sopsAgeKeyFile, "sops-age-key-file", "", "age key file to decrypt SOPS encrypted environment_file(s), overrides SOPS_AGE_KEY_FILE")
 
 	faasCmd.AddCommand(generateCmd)
 }
@@ -140,7 +142,7 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		}
 
 	} else if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter, envsubst)
+		parsedServices, err := parseStackFile(yamlFile, envsubst)
 		if err != nil {
 			return err
 		}
@@ -149,10 +151,8 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 			services = *parsedServices
 		}
 	} else {
-		fmt.Println(
-			`"stack.yml" file not found in the current directory.
-Use "--yaml" to pass a file or "--from-store" to generate using function store.`)
-		os.Exit(1)
+		return fmt.Errorf(`"stack.yml" file not found in the current directory.
+Use "--yaml" to pass a file or "--from-store" to generate using function store`)
 	}
 
 	branch, version, err := builder.GetImageTagValues(tagFormat)
@@ -188,7 +188,7 @@ func generateCRDYAML(services stack.Services, format schema.BuildFormat, apiVers
 
 			function := services.Functions[name]
 			//read environment variables from the file
-			fileEnvironment, err := readFiles(function.EnvironmentFile)
+			fileEnvironment, err := readFunctionEnvironmentFiles(function, nil)
 			if err != nil {
 				return "", err
 			}
@@ -199,6 +199,19 @@ func generateCRDYAML(services stack.Services, format schema.BuildFormat, apiVers
 				return "", envErr
 			}
 
+			allEnvironment, err = addWatchdogEnvironment(function, allEnvironment)
+			if err != nil {
+				return "", err
+			}
+
+			if err := stack.ValidateSecretHints(function.Secrets, function.SecretHints); err != nil {
+				return "", fmt.Errorf("function %s: %s", function.Name, err)
+			}
+			// Notes go to stderr so that the output can be piped to kubectl
+			for _, note := range secretHintNotes(function) {
+				fmt.Fprintln(os.Stderr, output.Warning("%s", note))
+			}
+
 			metadata := schema.Metadata{Name: name, Namespace: namespace}
 			imageName := schema.BuildImageName(format, function.Image, version, branch)
 
@@ -242,7 +255,7 @@ func generateknativev1ServingServiceCRDYAML(services stack.Services, format sche
 
 		function := services.Functions[name]
 
-		fileEnvironment, err := readFiles(function.EnvironmentFile)
+		fileEnvironment, err := readFunctionEnvironmentFiles(function, nil)
 		if err != nil {
 			return "", err
 		}
@@ -253,6 +266,15 @@ func generateknativev1ServingServiceCRDYAML(services stack.Services, format sche
 			return "", envErr
 		}
 
+		allEnvironment, err = addWatchdogEnvironment(function, allEnvironment)
+		if err != nil {
+			return "", err
+		}
+
+		if err := stack.ValidateSecretHints(function.Secrets, function.SecretHints); err != nil {
+			return "", fmt.Errorf("function %s: %s", function.Name, err)
+		}
+
 		env := orderknativeEnv(allEnvironment)
 
 		var annotations map[string]string
@@ -290,8 +312,19 @@ func generateknativev1ServingServiceCRDYAML(services stack.Services, format sche
 		var volumes []knativev1.Volume
 
 		for _, secret := range function.Secrets {
+			hint := function.SecretHints[secret]
+			if hint.IsEnv() {
+				env = append(env, knativev1.EnvPair{
+					Name: hint.TargetFor(secret),
+					ValueFrom: &knativev1.EnvVarSource{
+						SecretKeyRef: &knativev1.SecretKeySelector{Name: secret, Key: secret},
+					},
+				})
+				continue
+			}
+
 			mounts = append(mounts, knativev1.VolumeMount{
-				MountPath: "/var/openfaas/secrets/" + secret,
+				MountPath: stack.SecretsMountPath + hint.TargetFor(secret),
 				ReadOnly:  true,
 				Name:      secret,
 			})
@@ -303,6 +336,7 @@ func generateknativev1ServingServiceCRDYAML(services stack.Services, format sche
 			})
 		}
 
+		crd.Spec.Template.Containers[0].Env = env
 		crd.Spec.Template.Volumes = volumes
 		crd.Spec.Template.Containers[0].VolumeMounts = mounts
 
@@ -0,0 +1,175 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	openapiv3 "github.com/openfaas/faas-cli/schema/openapi/v3"
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const defaultOpenAPIContentType = "application/json"
+
+var (
+	openAPITitle   string
+	openAPIVersion string
+)
+
+func init() {
+	generateOpenAPICmd.Flags().StringVar(&openAPITitle, "title", "OpenFaaS functions", "Title of the API")
+	generateOpenAPICmd.Flags().StringVar(&openAPIVersion, "api-version", "1.0.0", "Version of the API")
+	generateOpenAPICmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in stack.yml file")
+
+	generateCmd.AddCommand(generateOpenAPICmd)
+}
+
+var generateOpenAPICmd = &cobra.Command{
+	Use:   "openapi -f YAML_FILE [--title TITLE] [--api-version VERSION]",
+	Short: "Generate an OpenAPI 3 document for functions",
+	Long: `Generates an OpenAPI 3 document with one path per function in the supplied
+YAML file. Each function may give hints about its API with the "openapi" key:
+
+  functions:
+    profiles:
+      lang: node12
+      openapi:
+        summary: Look up a profile
+        methods: [GET, POST]
+        request_schema:
+          type: object
+          properties:
+            id:
+              type: string
+        response_schema:
+          type: object`,
+	Example: `  faas-cli generate openapi -f stack.yml > openapi.yaml
+  faas-cli generate openapi -f stack.yml --title "Profiles API" --api-version 2.0.0`,
+	RunE: runGenerateOpenAPI,
+}
+
+func runGenerateOpenAPI(cmd *cobra.Command, args []string) error {
+	if len(yamlFile) == 0 {
+		return fmt.Errorf("you must supply a valid YAML file with --yaml")
+	}
+
+	services, err := parseStackFile(yamlFile, envsubst)
+	if err != nil {
+		return err
+	}
+
+	gatewayAddress := getGatewayURL("", defaultGateway, services.Provider.GatewayURL, os.Getenv(openFaaSURLEnvironment))
+
+	document, err := generateOpenAPIYAML(*services, gatewayAddress, openAPITitle, openAPIVersion)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(document)
+	return nil
+}
+
+// generateOpenAPIYAML generates an OpenAPI document with a path for each function
+func generateOpenAPIYAML(services stack.Services, gatewayAddress, title, version string) (string, error) {
+	document := openapiv3.Document{
+		OpenAPI: openapiv3.Version,
+		Info: openapiv3.Info{
+			Title:   title,
+			Version: version,
+		},
+		Servers: []openapiv3.Server{{URL: gatewayAddress}},
+		Paths:   map[string]openapiv3.PathItem{},
+	}
+
+	for _, name := range generateFunctionOrder(services.Functions) {
+		function := services.Functions[name]
+
+		path := "/function/" + name
+		if len(function.Namespace) > 0 {
+			path += "." + function.Namespace
+		}
+
+		pathItem, err := generateOpenAPIPathItem(name, function.OpenAPI)
+		if err != nil {
+			return "", err
+		}
+		document.Paths[path] = pathItem
+	}
+
+	out, err := yaml.Marshal(document)
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+func generateOpenAPIPathItem(name string, hints *stack.FunctionOpenAPI) (openapiv3.PathItem, error) {
+	if hints == nil {
+		hints = &stack.FunctionOpenAPI{}
+	}
+
+	methods := hints.Methods
+	if len(methods) == 0 {
+		methods = []string{http.MethodPost}
+	}
+
+	contentType := hints.ContentType
+	if len(contentType) == 0 {
+		contentType = defaultOpenAPIContentType
+	}
+
+	pathItem := openapiv3.PathItem{}
+	for _, method := range methods {
+		method = strings.ToUpper(method)
+		if !isValidOpenAPIMethod(method) {
+			return nil, fmt.Errorf("function %s has an invalid method in openapi.methods: %s", name, method)
+		}
+
+		operationID := name
+		if len(methods) > 1 {
+			operationID = name + "-" + strings.ToLower(method)
+		}
+
+		operation := openapiv3.Operation{
+			OperationID: operationID,
+			Summary:     hints.Summary,
+			Description: hints.Description,
+			Responses: map[string]openapiv3.Response{
+				"200": {
+					Description: "Response from the function",
+					Content: map[string]openapiv3.MediaType{
+						contentType: {Schema: hints.ResponseSchema},
+					},
+				},
+			},
+		}
+
+		if method != http.MethodGet && method != http.MethodHead && method != http.MethodDelete {
+			operation.RequestBody = &openapiv3.RequestBody{
+				Content: map[string]openapiv3.MediaType{
+					contentType: {Schema: hints.RequestSchema},
+				},
+			}
+		}
+
+		pathItem[strings.ToLower(method)] = operation
+	}
+
+	return pathItem, nil
+}
+
+func isValidOpenAPIMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"testing"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+func Test_generateOpenAPIYAML(t *testing.T) {
+	input := `
+provider:
+  name: openfaas
+functions:
+  env:
+    image: functions/alpine:latest
+  profiles:
+    image: alexellis/profiles:latest
+    namespace: dev
+    openapi:
+      summary: Look up a profile
+      methods: [get, post]
+      request_schema:
+        type: object
+      response_schema:
+        type: string
+`
+	want := `openapi: 3.0.3
+info:
+  title: Functions
+  version: 1.0.0
+servers:
+- url: http://127.0.0.1:8080
+paths:
+  /function/env:
+    post:
+      operationId: env
+      requestBody:
+        content:
+          application/json: {}
+      responses:
+        "200":
+          description: Response from the function
+          content:
+            application/json: {}
+  /function/profiles.dev:
+    get:
+      operationId: profiles-get
+      summary: Look up a profile
+      responses:
+        "200":
+          description: Response from the function
+          content:
+            application/json:
+              schema:
+                type: string
+    post:
+      operationId: profiles-post
+      summary: Look up a profile
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+      responses:
+        "200":
+          description: Response from the function
+          content:
+            application/json:
+              schema:
+                type: string
+`
+
+	services, err := stack.ParseYAMLData([]byte(input), "", "", false)
+	if err != nil {
+		t.Fatalf("error while parsing the input data: %s", err)
+	}
+
+	got, err := generateOpenAPIYAML(*services, "http://127.0.0.1:8080", "Functions", "1.0.0")
+	if err != nil {
+		t.Fatalf("error while generating OpenAPI YAML: %s", err)
+	}
+
+	if got != want {
+		t.Fatalf("want:\n%s\nbut got:\n%s", want, got)
+	}
+}
+
+func Test_generateOpenAPIYAML_InvalidMethod(t *testing.T) {
+	services := stack.Services{
+		Functions: map[string]stack.Function{
+			"env": {
+				OpenAPI: &stack.FunctionOpenAPI{Methods: []string{"FETCH"}},
+			},
+		},
+	}
+
+	_, err := generateOpenAPIYAML(services, "http://127.0.0.1:8080", "Functions", "1.0.0")
+	if err == nil {
+		t.Fatalf("want error for invalid method, but got nil")
+	}
+}
@@ -3,11 +3,14 @@
 package commands
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
 	v2 "github.com/openfaas/faas-cli/schema/store/v2"
 
 	"github.com/openfaas/faas-cli/schema"
+	knativev1 "github.com/openfaas/faas-cli/schema/knative/v1"
 
 	"github.com/openfaas/faas-cli/stack"
 )
@@ -494,3 +497,63 @@ func Test_generateFunctionOrder(t *testing.T) {
 
 	}
 }
+
+func Test_generateknativev1ServingServiceCRDYAML_secretHints(t *testing.T) {
+	services := stack.Services{
+		Functions: map[string]stack.Function{
+			"url-ping": {
+				Name:    "url-ping",
+				Image:   "alexellis/faas-url-ping:0.2",
+				Secrets: []string{"api-key", "db-cert"},
+				SecretHints: map[string]stack.FunctionSecretHint{
+					"api-key": {Type: stack.SecretHintEnv, Target: "API_KEY"},
+					"db-cert": {Target: "tls.crt"},
+				},
+			},
+		},
+	}
+
+	generatedYAML, err := generateknativev1ServingServiceCRDYAML(services, schema.DefaultFormat, knativev1.APIVersionLatest, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `        env:
+        - name: API_KEY
+          valueFrom:
+            secretKeyRef:
+              name: api-key
+              key: api-key
+        volumeMounts:
+        - name: db-cert
+          mountPath: /var/openfaas/secrets/tls.crt
+          readOnly: true
+`
+	if !strings.Contains(generatedYAML, want) {
+		t.Fatalf("want:\n%s\nin:\n%s", want, generatedYAML)
+	}
+	if strings.Contains(generatedYAML, "name: api-key\n      secret:") {
+		t.Errorf("want no volume for a secret read from the environment, got:\n%s", generatedYAML)
+	}
+}
+
+func Test_secretHintNotes(t *testing.T) {
+	function := stack.Function{
+		Name:    "url-ping",
+		Secrets: []string{"api-key", "db-cert", "token"},
+		SecretHints: map[string]stack.FunctionSecretHint{
+			"api-key": {Type: stack.SecretHintEnv, Target: "API_KEY"},
+			"db-cert": {Target: "tls.crt"},
+			"token":   {Type: stack.SecretHintFile},
+		},
+	}
+
+	got := secretHintNotes(function)
+	want := []string{
+		"url-ping: OpenFaaS providers do not set API_KEY from secret api-key, read it from /var/openfaas/secrets/api-key instead",
+		"url-ping: OpenFaaS providers mount secret db-cert at /var/openfaas/secrets/db-cert, not /var/openfaas/secrets/tls.crt",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %q, but got %q", want, got)
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/openfaas/faas-cli/builder"
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/spf13/cobra"
+)
+
+// handlerTestSample is a unit test which faas-cli new writes for a template
+// which does not declare its own, it only checks that the handler loads, so
+// that it holds for every template of the family
+type handlerTestSample struct {
+	// prefix of the names of the templates in the family, i.e. python3 for
+	// python3, python3-http and python3-flask
+	prefix  string
+	file    string
+	content string
+	command string
+}
+
+var handlerTestSamples = []handlerTestSample{
+	{
+		prefix: "go",
+		file:   "handler_test.go",
+		content: `package function
+
+import "testing"
+
+func TestHandle(t *testing.T) {
+	// Replace with a test of your handler's output
+	_ = Handle
+}
+`,
+		command: "go test ./...",
+	},
+	{
+		prefix: "python",
+		file:   "test_handler.py",
+		content: `import unittest
+
+import handler
+
+
+class HandlerTest(unittest.TestCase):
+    def test_handle(self):
+        # Replace with a test of your handler's output
+        self.assertTrue(callable(handler.handle))
+
+
+if __name__ == "__main__":
+    unittest.main()
+`,
+		command: "python3 -m unittest discover",
+	},
+	{
+		prefix: "node",
+		file:   "handler.test.js",
+		content: `"use strict"
+
+const assert = require("assert")
+const handler = require("./handler")
+
+// Replace with a test of your handler's output
+assert.strictEqual(typeof handler, "function")
+console.log("ok handler.test.js")
+`,
+		command: "node handler.test.js",
+	},
+}
+
+// writeHandlerTest adds sample tests to a new handler and returns the command
+// which runs them. The tests declared in template.yml are used when there are
+// any, otherwise a sample for the family of the template is written. There is
+// no test for other templates.
+func writeHandlerTest(handlerDir, language string, langTemplate *stack.LanguageTemplate) (string, error) {
+	if langTemplate != nil && langTemplate.Test != nil && len(langTemplate.Test.Command) > 0 {
+		if folder := langTemplate.Test.Folder; len(folder) > 0 {
+			from := filepath.Join("template", language, folder)
+			if _, err := os.Stat(from); err != nil {
+				return "", fmt.Errorf("the test folder %s of the template %s was not found", folder, language)
+			}
+			if err := builder.CopyFiles(from, handlerDir); err != nil {
+				return "", fmt.Errorf("unable to copy the test folder %s of the template %s: %s", folder, language, err)
+			}
+		}
+		return langTemplate.Test.Command, nil
+	}
+
+	for _, sample := range handlerTestSamples {
+		if !strings.HasPrefix(language, sample.prefix) {
+			continue
+		}
+
+		file := filepath.Join(handlerDir, sample.file)
+		if _, err := os.Stat(file); err == nil {
+			// The template has a test with the same name already
+			return sample.command, nil
+		}
+		if err := ioutil.WriteFile(file, []byte(sample.content), 0600); err != nil {
+			return "", fmt.Errorf("unable to write the sample test %s: %s", file, err)
+		}
+		return sample.command, nil
+	}
+	return "", nil
+}
+
+// handlerTestNotes tells the user how to run the tests of a new function,
+// locally and in CI
+func handlerTestNotes(stackFile, functionName string) string {
+	return fmt.Sprintf(`
+Run the unit tests of the handler with:
+  faas-cli test -f %s --filter %s
+
+In CI, i.e. as a step of a GitHub Actions job:
+  - name: Test functions
+    run: faas-cli test -f %s
+`, stackFile, functionName, stackFile)
+}
+
+// runHandlerTest runs the test command of a function in its handler folder,
+// it is a variable so that tests do not run the commands
+var runHandlerTest = func(dir, command string, stdout, stderr io.Writer) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Dir = dir
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
+func init() {
+	testCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in stack.yml file")
+
+	faasCmd.AddCommand(testCmd)
+}
+
+var testCmd = &cobra.Command{
+	Use:   `test -f YAML_FILE [--filter "WILDCARD"] [--regex "REGEX"]`,
+	Short: "Run the unit tests of the functions in a stack file",
+	Long: `Runs the command given by "test" for each function in the stack file, from the
+folder of its handler. faas-cli new adds a sample test and its command for the
+go, python and node templates, or the test declared by the template.`,
+	Example: `  faas-cli test -f stack.yml
+  faas-cli test -f stack.yml --filter "api*"`,
+	RunE: runTest,
+}
+
+func runTest(cmd *cobra.Command, args []string) error {
+	services, err := parseStackFile(yamlFile, envsubst)
+	if err != nil {
+		return err
+	}
+
+	return testFunctions(services, cmd.OutOrStdout(), cmd.ErrOrStderr())
+}
+
+// testFunctions runs the tests of each function which has them, and fails
+// when any of them fail
+func testFunctions(services *stack.Services, stdout, stderr io.Writer) error {
+	tested := 0
+	failed := []string{}
+
+	for _, name := range services.FunctionNames() {
+		function := services.Functions[name]
+		if len(function.Test) == 0 {
+			fmt.Fprintf(stdout, "Skipping %s, it has no test command\n", name)
+			continue
+		}
+
+		tested++
+		fmt.Fprintf(stdout, "Testing %s: %s\n", name, function.Test)
+		if err := runHandlerTest(function.Handler, function.Test, stdout, stderr); err != nil {
+			fmt.Fprintf(stdout, "%s failed: %s\n", name, err)
+			failed = append(failed, name)
+			continue
+		}
+		fmt.Fprintf(stdout, "%s passed\n", name)
+	}
+
+	if tested == 0 {
+		return fmt.Errorf("no functions in the stack file have a test command, add one with test: in stack.yml")
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d functions failed their tests: %s", len(failed), tested, strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+func Test_writeHandlerTest(t *testing.T) {
+	cases := []struct {
+		name        string
+		language    string
+		template    *stack.LanguageTemplate
+		wantCommand string
+		wantFile    string
+		wantErr     string
+	}{
+		{name: "go", language: "golang-middleware", wantCommand: "go test ./...", wantFile: "handler_test.go"},
+		{name: "python", language: "python3-http", wantCommand: "python3 -m unittest discover", wantFile: "test_handler.py"},
+		{name: "node", language: "node18", wantCommand: "node handler.test.js", wantFile: "handler.test.js"},
+		{name: "no sample", language: "ruby"},
+		{name: "declared by the template", language: "go", template: &stack.LanguageTemplate{Test: &stack.TemplateTest{Command: "make test"}}, wantCommand: "make test"},
+		{name: "missing test folder", language: "faas-cli-no-such-template", template: &stack.LanguageTemplate{Test: &stack.TemplateTest{Command: "make test", Folder: "tests"}}, wantErr: "the test folder tests of the template faas-cli-no-such-template was not found"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handlerDir, err := ioutil.TempDir("", "faas-cli-handler-test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(handlerDir)
+
+			command, err := writeHandlerTest(handlerDir, tc.language, tc.template)
+			if len(tc.wantErr) > 0 {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("want error %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if command != tc.wantCommand {
+				t.Errorf("want command %q, got %q", tc.wantCommand, command)
+			}
+
+			files, _ := ioutil.ReadDir(handlerDir)
+			if len(tc.wantFile) == 0 {
+				if len(files) > 0 {
+					t.Errorf("want no sample test, got %s", files[0].Name())
+				}
+				return
+			}
+			if _, err := os.Stat(filepath.Join(handlerDir, tc.wantFile)); err != nil {
+				t.Errorf("want the sample test %s: %s", tc.wantFile, err)
+			}
+		})
+	}
+}
+
+func Test_testFunctions(t *testing.T) {
+	defer func(run func(string, string, io.Writer, io.Writer) error) { runHandlerTest = run }(runHandlerTest)
+
+	ran := []string{}
+	runHandlerTest = func(dir, command string, stdout, stderr io.Writer) error {
+		ran = append(ran, dir+": "+command)
+		if strings.Contains(dir, "broken") {
+			return fmt.Errorf("exit status 1")
+		}
+		return nil
+	}
+
+	services := &stack.Services{Functions: map[string]stack.Function{
+		"api":    {Handler: "./api", Test: "go test ./..."},
+		"broken": {Handler: "./broken", Test: "python3 -m unittest discover"},
+		"legacy": {Handler: "./legacy"},
+	}}
+
+	var out bytes.Buffer
+	err := testFunctions(services, &out, &out)
+
+	want := "1 of 2 functions failed their tests: broken"
+	if err == nil || err.Error() != want {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+
+	wantRan := []string{"./api: go test ./...", "./broken: python3 -m unittest discover"}
+	if strings.Join(ran, "\n") != strings.Join(wantRan, "\n") {
+		t.Errorf("want the tests to run in the handler folders:\n%s\ngot:\n%s", strings.Join(wantRan, "\n"), strings.Join(ran, "\n"))
+	}
+	if !strings.Contains(out.String(), "Skipping legacy, it has no test command") {
+		t.Errorf("want legacy to be skipped, got:\n%s", out.String())
+	}
+
+	err = testFunctions(&stack.Services{Functions: map[string]stack.Function{"legacy": {Handler: "./legacy"}}}, &out, &out)
+	if err == nil || !strings.HasPrefix(err.Error(), "no functions in the stack file have a test command") {
+		t.Errorf("want an error when there are no tests, got %v", err)
+	}
+}
+
+func Test_prepareYAMLContent_Test(t *testing.T) {
+	function := &stack.Function{Name: "api", Language: "go", Handler: "./api", Image: "api:latest", Test: "go test ./..."}
+
+	content := prepareYAMLContent(true, defaultGateway, function)
+
+	want := "  api:\n    lang: go\n    handler: ./api\n    image: api:latest\n    test: \"go test ./...\"\n\n"
+	if content != want {
+		t.Errorf("want:\n%q\ngot:\n%q", want, content)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openfaas/faas-cli/output"
+	"github.com/openfaas/faas-cli/registry"
+)
+
+// imageExists asks the image's registry whether it has been pushed
+var imageExists = func(ctx context.Context, image string) (bool, error) {
+	client := &http.Client{
+		Transport: GetDefaultCLITransport(tlsInsecure, &commandTimeout),
+		Timeout:   commandTimeout,
+	}
+	return registry.ManifestExists(ctx, client, image, dockerConfigCredentials)
+}
+
+// warnUnpushedImage prints a warning when an image is not in its registry,
+// the function would be deployed but fail to start with ImagePullBackOff
+func warnUnpushedImage(ctx context.Context, w io.Writer, functionName, image string) {
+	found, err := imageExists(ctx, image)
+	if err != nil {
+		fmt.Fprintln(w, output.Warning("Unable to check the image %s of %s: %s", image, functionName, err))
+		return
+	}
+
+	if !found {
+		fmt.Fprintln(w, output.Warning("The image %s of %s was not found in its registry, did you forget to run faas-cli push?", image, functionName))
+	}
+}
+
+// dockerConfigCredentials reads the credentials for a registry from the
+// docker config file, images are checked anonymously when there are none
+// or when they are held by a credentials store
+func dockerConfigCredentials(registryHost string) (string, string) {
+	dir := configDir
+	if len(dir) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", ""
+		}
+		dir = filepath.Join(home, configFileDir)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, configFileName))
+	if err != nil {
+		return "", ""
+	}
+
+	config := configFile{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return "", ""
+	}
+
+	for key, auth := range config.AuthConfigs {
+		if !matchesRegistry(key, registryHost) || len(auth.Auth) == 0 {
+			continue
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+		if err != nil {
+			continue
+		}
+
+		parts := strings.SplitN(string(decoded), ":", 2)
+		if len(parts) == 2 {
+			return parts[0], parts[1]
+		}
+	}
+
+	return "", ""
+}
+
+// matchesRegistry compares a key of the docker config file, which may be a
+// URL such as https://index.docker.io/v1/, with a registry host
+func matchesRegistry(key, registryHost string) bool {
+	host := strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://")
+	if slash := strings.Index(host, "/"); slash >= 0 {
+		host = host[:slash]
+	}
+
+	if registryHost == registry.DockerHub {
+		return host == "index.docker.io" || host == registry.DockerHub || host == "registry-1.docker.io"
+	}
+	return host == registryHost
+}
@@ -0,0 +1,54 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_dockerConfigCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(dir string) { configDir = dir }(configDir)
+	configDir = dir
+
+	config := `{
+  "auths": {
+    "https://index.docker.io/v1/": {"auth": "YWxleDpodWIx"},
+    "ghcr.io": {"auth": "YWxleDpnaHIx"},
+    "quay.io": {}
+  },
+  "credsStore": "desktop"
+}`
+	if err := ioutil.WriteFile(filepath.Join(dir, configFileName), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		registry     string
+		wantUser     string
+		wantPassword string
+	}{
+		{registry: "docker.io", wantUser: "alex", wantPassword: "hub1"},
+		{registry: "ghcr.io", wantUser: "alex", wantPassword: "ghr1"},
+		{registry: "quay.io"},
+		{registry: "registry.example.com"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.registry, func(t *testing.T) {
+			user, password := dockerConfigCredentials(c.registry)
+			if user != c.wantUser || password != c.wantPassword {
+				t.Fatalf("want %q/%q, but got %q/%q", c.wantUser, c.wantPassword, user, password)
+			}
+		})
+	}
+}
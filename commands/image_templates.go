@@ -0,0 +1,86 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/openfaas/faas-cli/versioncontrol"
+)
+
+// imageTagEnvironment sets {{ tag }} in the image of a function, so that CI
+// gives build, push and deploy the same tag
+const imageTagEnvironment = "OPENFAAS_IMAGE_TAG"
+
+// imageGitSHA is a variable so that tests do not depend on the repository
+var imageGitSHA = versioncontrol.GetGitSHA
+
+// imageTemplates computes the values which an image can reference as
+// {{ name }}, the prefix and tag are only looked up when an image uses them
+type imageTemplates struct {
+	values map[string]string
+}
+
+func (i *imageTemplates) value(name, functionName string) (string, error) {
+	if name == "name" {
+		return functionName, nil
+	}
+
+	if i.values == nil {
+		i.values = map[string]string{}
+	}
+	if value, ok := i.values[name]; ok {
+		return value, nil
+	}
+
+	var value string
+	switch name {
+	case "prefix":
+		value = strings.TrimRight(getPrefixValue(), "/")
+		if len(value) == 0 {
+			return "", fmt.Errorf("{{ prefix }} is used, but no prefix was set, set OPENFAAS_PREFIX or run: faas-cli config set defaults.prefix")
+		}
+	case "tag":
+		if value = os.Getenv(imageTagEnvironment); len(value) == 0 {
+			if value = imageGitSHA(); len(value) == 0 {
+				value = "latest"
+			}
+		}
+	default:
+		return "", fmt.Errorf("{{ %s }} is not known, use {{ prefix }}, {{ name }} or {{ tag }}", name)
+	}
+
+	i.values[name] = value
+	return value, nil
+}
+
+// expandImageTemplates expands {{ prefix }}, {{ name }} and {{ tag }} in the
+// image of each function, {{ tag }} is $OPENFAAS_IMAGE_TAG, then the git
+// commit and then latest
+func expandImageTemplates(services *stack.Services) error {
+	templates := imageTemplates{}
+
+	for name, function := range services.Functions {
+		if !strings.Contains(function.Image, "{{") {
+			continue
+		}
+
+		var err error
+		function.Image = envTemplatePattern.ReplaceAllStringFunc(function.Image, func(match string) string {
+			value, valueErr := templates.value(envTemplatePattern.FindStringSubmatch(match)[1], name)
+			if valueErr != nil && err == nil {
+				err = valueErr
+			}
+			return value
+		})
+		if err != nil {
+			return fmt.Errorf("image of %s: %s", name, err)
+		}
+		services.Functions[name] = function
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openfaas/faas-cli/config"
+	"github.com/openfaas/faas-cli/stack"
+)
+
+func Test_expandImageTemplates(t *testing.T) {
+	defer resetConfigSettings()
+	defer func(lookup func() (config.Resolved, error)) { lookupConfigSettings = lookup }(lookupConfigSettings)
+	defer func(gitSHA func() string) { imageGitSHA = gitSHA }(imageGitSHA)
+	defer os.Unsetenv("OPENFAAS_PREFIX")
+	defer os.Unsetenv(imageTagEnvironment)
+
+	cases := []struct {
+		name         string
+		image        string
+		envPrefix    string
+		configPrefix string
+		envTag       string
+		gitSHA       string
+		want         string
+		wantErr      string
+	}{
+		{name: "prefix from the environment and tag from CI", image: "{{ prefix }}/{{ name }}:{{ tag }}", envPrefix: "ghcr.io/alexellis/", configPrefix: "docker.io/alexellis", envTag: "0.2.0", gitSHA: "3ab5e7c", want: "ghcr.io/alexellis/url-ping:0.2.0"},
+		{name: "prefix from the config file and tag from git", image: "{{prefix}}/{{name}}:{{tag}}", configPrefix: "docker.io/alexellis", gitSHA: "3ab5e7c", want: "docker.io/alexellis/url-ping:3ab5e7c"},
+		{name: "tag outside a git repository", image: "alexellis/{{ name }}:{{ tag }}", want: "alexellis/url-ping:latest"},
+		{name: "no templates", image: "alexellis/url-ping:0.1.0", want: "alexellis/url-ping:0.1.0"},
+		{name: "no prefix", image: "{{ prefix }}/{{ name }}", wantErr: "image of url-ping: {{ prefix }} is used, but no prefix was set, set OPENFAAS_PREFIX or run: faas-cli config set defaults.prefix"},
+		{name: "unknown name", image: "alexellis/{{ function }}", wantErr: "image of url-ping: {{ function }} is not known, use {{ prefix }}, {{ name }} or {{ tag }}"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Setenv("OPENFAAS_PREFIX", tc.envPrefix)
+			os.Setenv(imageTagEnvironment, tc.envTag)
+			resetConfigSettings()
+			lookupConfigSettings = func() (config.Resolved, error) {
+				return config.Resolved{Prefix: tc.configPrefix}, nil
+			}
+			imageGitSHA = func() string { return tc.gitSHA }
+
+			services := &stack.Services{Functions: map[string]stack.Function{
+				"url-ping": {Name: "url-ping", Image: tc.image},
+			}}
+
+			err := expandImageTemplates(services)
+			if len(tc.wantErr) > 0 {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("want error %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := services.Functions["url-ping"].Image; got != tc.want {
+				t.Fatalf("want image %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_expandImageTemplates_TagOutsideGitRepository(t *testing.T) {
+	defer os.Unsetenv(imageTagEnvironment)
+	os.Unsetenv(imageTagEnvironment)
+	defer chdirOutsideGitRepository(t)()
+
+	services := &stack.Services{Functions: map[string]stack.Function{
+		"url-ping": {Name: "url-ping", Image: "alexellis/{{ name }}:{{ tag }}"},
+	}}
+	if err := expandImageTemplates(services); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "alexellis/url-ping:latest"
+	if got := services.Functions["url-ping"].Image; got != want {
+		t.Fatalf("want image %s, got %s", want, got)
+	}
+}
+
+// chdirOutsideGitRepository moves the test into an empty folder which git
+// does not see as part of a repository, the returned func moves it back
+func chdirOutsideGitRepository(t *testing.T) func() {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "faas-cli-no-git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	ceiling, ceilingSet := os.LookupEnv("GIT_CEILING_DIRECTORIES")
+	os.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(dir))
+
+	return func() {
+		if ceilingSet {
+			os.Setenv("GIT_CEILING_DIRECTORIES", ceiling)
+		} else {
+			os.Unsetenv("GIT_CEILING_DIRECTORIES")
+		}
+		os.Chdir(wd)
+		os.RemoveAll(dir)
+	}
+}
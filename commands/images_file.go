@@ -0,0 +1,114 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+// imagesFile is written by publish with the image and digest of each
+// function, and read by deploy to deploy exactly those images
+var imagesFile string
+
+// pinImage adds a digest to an image, replacing any digest it has already
+func pinImage(image, digest string) string {
+	if at := strings.Index(image, "@"); at >= 0 {
+		image = image[:at]
+	}
+	return image + "@" + digest
+}
+
+// writePublishedImages writes the image of each function which publish pushed
+// to the images file, pinned to the digest which the push reported
+func writePublishedImages(services *stack.Services, digests map[string]string, path string) error {
+	images := map[string]string{}
+	for _, name := range services.FunctionNames() {
+		digest, ok := digests[name]
+		if !ok {
+			continue
+		}
+
+		imageName, err := builtImageName(services.Functions[name].Image)
+		if err != nil {
+			return err
+		}
+		images[name] = pinImage(imageName, digest)
+	}
+
+	if err := writeImagesFile(path, images); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote the images of %d functions to %s\n", len(images), path)
+	return nil
+}
+
+// writeImagesFile writes the pinned image of each function as JSON
+func writeImagesFile(path string, images map[string]string) error {
+	data, err := json.MarshalIndent(images, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("unable to write the images file: %s", err)
+	}
+	return nil
+}
+
+// readImagesFile reads the images written by publish, each of them must be
+// pinned with a digest
+func readImagesFile(path string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the images file: %s", err)
+	}
+
+	images := map[string]string{}
+	if err := json.Unmarshal(data, &images); err != nil {
+		return nil, fmt.Errorf("unable to parse the images file %s: %s", path, err)
+	}
+
+	for name, image := range images {
+		if !strings.Contains(image, "@sha256:") {
+			return nil, fmt.Errorf("the image of %s in %s must have a digest, i.e. image:tag@sha256:..., but was: %s", name, path, image)
+		}
+	}
+	return images, nil
+}
+
+// pinStackImages sets the image of each function which faas-cli builds to
+// the one in the images file, and returns the names of those functions. It
+// fails when one of them is missing, so that an image is never deployed
+// without the digest which was published.
+func pinStackImages(services *stack.Services, images map[string]string, path string) (map[string]bool, error) {
+	pinned := map[string]bool{}
+	missing := []string{}
+
+	for name, function := range services.Functions {
+		if function.Prebuilt() || function.SkipBuild {
+			continue
+		}
+
+		image, ok := images[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+
+		function.Image = image
+		services.Functions[name] = function
+		pinned[name] = true
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("%s not found in %s, publish them first or remove --images-file", strings.Join(missing, ", "), path)
+	}
+	return pinned, nil
+}
@@ -0,0 +1,159 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/schema"
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/openfaas/faas-cli/test"
+)
+
+var testDigest = "sha256:" + strings.Repeat("a", 64)
+
+func Test_writePublishedImages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "faas-cli-images")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "images.json")
+
+	services := &stack.Services{
+		Functions: map[string]stack.Function{
+			"api":    {Image: "alexellis/api:0.1", Language: "go", Handler: "./api"},
+			"nginx":  {Image: "nginx:latest"},
+			"worker": {Image: "alexellis/worker:0.1", Language: "go", Handler: "./worker"},
+		},
+	}
+	// worker was skipped, so publish did not push it
+	digests := map[string]string{"api": testDigest}
+
+	test.CaptureStdout(func() {
+		err = writePublishedImages(services, digests, path)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	images, err := readImagesFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := map[string]string{"api": "alexellis/api:0.1@" + testDigest}
+	if !reflect.DeepEqual(images, want) {
+		t.Fatalf("want %v, got %v", want, images)
+	}
+}
+
+func Test_readImagesFile_requiresDigest(t *testing.T) {
+	file, err := ioutil.TempFile("", "images*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(`{"api": "alexellis/api:0.1"}`)
+	file.Close()
+
+	_, err = readImagesFile(file.Name())
+	if err == nil || !strings.Contains(err.Error(), "the image of api") {
+		t.Fatalf("want an error for an image without a digest, got: %v", err)
+	}
+}
+
+func Test_pinStackImages(t *testing.T) {
+	services := &stack.Services{
+		Functions: map[string]stack.Function{
+			"api":    {Image: "alexellis/api:0.1", Language: "go", Handler: "./api"},
+			"worker": {Image: "alexellis/worker:0.1", Language: "go", Handler: "./worker"},
+			"nginx":  {Image: "nginx:latest"},
+		},
+	}
+
+	_, err := pinStackImages(services, map[string]string{"api": "alexellis/api:0.1@" + testDigest}, "images.json")
+	if err == nil || !strings.Contains(err.Error(), "worker not found in images.json") {
+		t.Fatalf("want an error for a function missing from the images file, got: %v", err)
+	}
+
+	images := map[string]string{
+		"api":    "alexellis/api:0.1@" + testDigest,
+		"worker": "alexellis/worker:0.1@" + testDigest,
+	}
+	pinned, err := pinStackImages(services, images, "images.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !pinned["api"] || !pinned["worker"] || pinned["nginx"] {
+		t.Fatalf("want api and worker to be pinned, got: %v", pinned)
+	}
+	if services.Functions["api"].Image != images["api"] || services.Functions["nginx"].Image != "nginx:latest" {
+		t.Fatalf("want the images to be pinned, got: %+v", services.Functions)
+	}
+}
+
+func Test_Deploy_imagesFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "faas-cli-images")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	stackFile := filepath.Join(dir, "stack.yml")
+	ioutil.WriteFile(stackFile, []byte(`version: 1.0
+provider:
+  name: openfaas
+functions:
+  fn1:
+    lang: go
+    handler: ./fn1
+    image: functions/fn1:0.1
+`), 0600)
+
+	images := filepath.Join(dir, "images.json")
+	if err := writeImagesFile(images, map[string]string{"fn1": "functions/fn1:0.1@" + testDigest}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodPut,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusOK,
+		},
+	})
+	defer s.Close()
+
+	test.CaptureStdout(func() {
+		_, err = Deploy(context.Background(), DeployOptions{
+			YAMLFile:     stackFile,
+			Gateway:      s.URL,
+			Update:       true,
+			NoProvenance: true,
+			TagFormat:    schema.SHAFormat,
+			ImagesFile:   images,
+		})
+	})
+	if err != nil {
+		t.Fatalf("want no error, got: %s", err)
+	}
+
+	var spec struct {
+		Image string `json:"image"`
+	}
+	if err := json.Unmarshal(s.Requests()[0].Body, &spec); err != nil {
+		t.Fatal(err)
+	}
+	if want := "functions/fn1:0.1@" + testDigest; spec.Image != want {
+		t.Errorf("want image %s, got: %s", want, spec.Image)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// interrupts counts the interrupt contexts which have not been cancelled, so
+// that other handlers of Ctrl+C leave the clean up to the work which is
+// running
+var interrupts struct {
+	sync.Mutex
+	active int
+}
+
+// interruptible reports whether an interrupt context is in use, Ctrl+C then
+// cancels it and the command returns on its own
+func interruptible() bool {
+	interrupts.Lock()
+	defer interrupts.Unlock()
+	return interrupts.active > 0
+}
+
+// interruptContext returns a context which is cancelled on Ctrl+C or SIGTERM,
+// so that long running work can stop and clean up before the CLI exits
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	interrupts.Lock()
+	interrupts.active++
+	interrupts.Unlock()
+
+	var once sync.Once
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		once.Do(func() {
+			interrupts.Lock()
+			interrupts.active--
+			interrupts.Unlock()
+		})
+		signal.Stop(signals)
+		cancel()
+	}
+}
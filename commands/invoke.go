@@ -7,9 +7,12 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
+	"strings"
 
 	"github.com/alexellis/hmac"
+	"github.com/openfaas/faas-cli/output"
 	"github.com/openfaas/faas-cli/proxy"
 	"github.com/openfaas/faas-cli/stack"
 	"github.com/spf13/cobra"
@@ -24,6 +27,8 @@ var (
 	sigHeader               string
 	key                     string
 	functionInvokeNamespace string
+	invokeData              string
+	invokeDataFile          string
 )
 
 func init() {
@@ -41,6 +46,8 @@ func init() {
 	invokeCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
 	invokeCmd.Flags().StringVar(&sigHeader, "sign", "", "name of HTTP request header to hold the signature")
 	invokeCmd.Flags().StringVar(&key, "key", "", "key to be used to sign the request (must be used with --sign)")
+	invokeCmd.Flags().StringVarP(&invokeData, "data", "d", "", "Body of the request, instead of reading it from STDIN")
+	invokeCmd.Flags().StringVar(&invokeDataFile, "data-file", "", "Read the body of the request from a file, or STDIN for -")
 
 	invokeCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in stack.yml file")
 
@@ -50,7 +57,18 @@ func init() {
 var invokeCmd = &cobra.Command{
 	Use:   `invoke FUNCTION_NAME [--gateway GATEWAY_URL] [--content-type CONTENT_TYPE] [--query PARAM=VALUE] [--header PARAM=VALUE] [--method HTTP_METHOD]`,
 	Short: "Invoke an OpenFaaS function",
-	Long:  `Invokes an OpenFaaS function and reads from STDIN for the body of the request`,
+	Long: `Invokes an OpenFaaS function and reads from STDIN for the body of the request.
+
+Without --gateway, the gateway is read from stack.yml, OPENFAAS_URL and then
+the config file, and the one which is used is printed to stderr. A function
+which is in stack.yml is invoked in its namespace unless --namespace is given.
+
+Use --data or --data-file to give the body instead. When STDIN is a terminal,
+GET and HEAD requests and --non-interactive send an empty body rather than
+waiting for input.
+
+Without a function name in a terminal, the functions on the gateway are
+listed to pick one by its number or by typing part of its name.`,
 	Example: `  faas-cli invoke echo --gateway https://host:port
   faas-cli invoke echo --gateway https://host:port --content-type application/json
   faas-cli invoke env --query repo=faas-cli --query org=openfaas
@@ -58,15 +76,18 @@ var invokeCmd = &cobra.Command{
   faas-cli invoke resize-img --async -H "X-Callback-Url=http://gateway:8080/function/send2slack" < image.png
   faas-cli invoke env -H X-Ping-Url=http://request.bin/etc
   faas-cli invoke flask --method GET --namespace dev
-  faas-cli invoke env --sign X-GitHub-Event --key yoursecret`,
+  faas-cli invoke env --sign X-GitHub-Event --key yoursecret
+  faas-cli invoke echo --data "hello world"
+  faas-cli invoke resize-img --data-file image.png --content-type image/png
+  faas-cli invoke`,
 	RunE: runInvoke,
 }
 
 func runInvoke(cmd *cobra.Command, args []string) error {
 	var services stack.Services
 
-	if len(args) < 1 {
-		return fmt.Errorf("please provide a name for the function")
+	if len(args) < 1 && !interactive() {
+		return errNoFunctionName
 	}
 
 	if missingSignFlag(sigHeader, key) {
@@ -74,10 +95,9 @@ func runInvoke(cmd *cobra.Command, args []string) error {
 	}
 
 	var yamlGateway string
-	functionName = args[0]
 
 	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter, envsubst)
+		parsedServices, err := parseStackFile(yamlFile, envsubst)
 		if err != nil {
 			return err
 		}
@@ -88,16 +108,29 @@ func runInvoke(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	gatewayAddress := getGatewayURL(gateway, defaultGateway, yamlGateway, os.Getenv(openFaaSURLEnvironment))
+	environmentGateway := os.Getenv(openFaaSURLEnvironment)
+	gatewayAddress := getGatewayURL(gateway, defaultGateway, yamlGateway, environmentGateway)
+	source := gatewaySource(gateway, defaultGateway, yamlGateway, environmentGateway, configSettings().Gateway)
+
+	if len(args) < 1 {
+		picked, err := pickFunction(gatewayAddress, functionInvokeNamespace, "")
+		if err != nil {
+			return err
+		}
+		functionName = picked
+	} else {
+		functionName = args[0]
+	}
 
-	stat, _ := os.Stdin.Stat()
-	if (stat.Mode() & os.ModeCharDevice) != 0 {
-		fmt.Fprintf(os.Stderr, "Reading from STDIN - hit (Control + D) to stop.\n")
+	if notice := invokeGatewayNotice(functionName, gatewayAddress, source, yamlFile); len(notice) > 0 {
+		fmt.Fprintln(os.Stderr, notice)
 	}
 
-	functionInput, err := ioutil.ReadAll(os.Stdin)
+	namespace := invokeNamespace(functionInvokeNamespace, services, functionName)
+
+	functionInput, err := readInvokeInput(cmd.Flags().Changed("data"), invokeData, invokeDataFile, httpMethod)
 	if err != nil {
-		return fmt.Errorf("unable to read standard input: %s", err.Error())
+		return err
 	}
 
 	if len(sigHeader) > 0 {
@@ -108,7 +141,11 @@ func runInvoke(cmd *cobra.Command, args []string) error {
 		headers = append(headers, signedHeader)
 	}
 
-	response, err := proxy.InvokeFunction(gatewayAddress, functionName, &functionInput, contentType, query, headers, invokeAsync, httpMethod, tlsInsecure, functionInvokeNamespace)
+	if len(requestID) > 0 {
+		headers = append(headers, proxy.CallIDHeader+"="+requestID)
+	}
+
+	response, err := proxy.InvokeFunction(gatewayAddress, functionName, &functionInput, contentType, query, headers, invokeAsync, httpMethod, tlsInsecure, namespace)
 	if err != nil {
 		return err
 	}
@@ -120,6 +157,61 @@ func runInvoke(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// invokeGatewayNotice says which gateway a function is invoked on and where
+// it came from, nothing is said when it was given with --gateway
+func invokeGatewayNotice(name, gatewayAddress, source, stackFile string) string {
+	switch source {
+	case sourceFlag:
+		return ""
+	case sourceStack:
+		return fmt.Sprintf("Invoking %s on %s from %s", name, gatewayAddress, stackFile)
+	case sourceEnvironment:
+		return fmt.Sprintf("Invoking %s on %s from %s", name, gatewayAddress, openFaaSURLEnvironment)
+	case sourceConfig:
+		return fmt.Sprintf("Invoking %s on %s from the config file", name, gatewayAddress)
+	}
+	return output.Warning("Invoking %s on the default gateway %s, set one with --gateway, stack.yml, %s or faas-cli config set defaults.gateway",
+		name, gatewayAddress, openFaaSURLEnvironment)
+}
+
+// invokeNamespace is the namespace given with --namespace, or else the one
+// of the function in stack.yml
+func invokeNamespace(flagNamespace string, services stack.Services, name string) string {
+	if len(flagNamespace) > 0 {
+		return flagNamespace
+	}
+	if function, ok := services.Functions[name]; ok {
+		return function.Namespace
+	}
+	return ""
+}
+
+// readInvokeInput returns the body of the request from --data, --data-file or
+// STDIN, STDIN is not read when it is a terminal which nobody will type into
+func readInvokeInput(hasData bool, data, dataFile, method string) ([]byte, error) {
+	switch {
+	case hasData && len(dataFile) > 0:
+		return nil, fmt.Errorf("--data and --data-file are mutually exclusive")
+	case hasData:
+		return []byte(data), nil
+	case len(dataFile) > 0 && dataFile != "-":
+		return ioutil.ReadFile(dataFile)
+	}
+
+	if stdinIsTerminal() {
+		if len(dataFile) == 0 && (nonInteractive || strings.EqualFold(method, http.MethodGet) || strings.EqualFold(method, http.MethodHead)) {
+			return []byte{}, nil
+		}
+		fmt.Fprintf(os.Stderr, "Reading from STDIN - hit (Control + D) to submit, or pass --data.\n")
+	}
+
+	functionInput, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read standard input: %s", err.Error())
+	}
+	return functionInput, nil
+}
+
 func generateSignedHeader(message []byte, key string, headerName string) (string, error) {
 
 	if len(headerName) == 0 {
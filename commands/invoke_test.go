@@ -13,6 +13,7 @@ import (
 	"io/ioutil"
 
 	"github.com/alexellis/hmac"
+	"github.com/openfaas/faas-cli/stack"
 	"github.com/openfaas/faas-cli/test"
 )
 
@@ -199,3 +200,117 @@ func Test_missingSignFlag(t *testing.T) {
 		})
 	}
 }
+
+func Test_readInvokeInput(t *testing.T) {
+	defer func(original func() bool, stdin *os.File) {
+		stdinIsTerminal = original
+		os.Stdin = stdin
+		nonInteractive = false
+	}(stdinIsTerminal, os.Stdin)
+
+	dataFile, _ := ioutil.TempFile("", "data")
+	dataFile.WriteString("from-file")
+	dataFile.Close()
+	defer os.Remove(dataFile.Name())
+
+	os.Stdin, _ = ioutil.TempFile("", "stdin")
+	defer os.Remove(os.Stdin.Name())
+
+	cases := []struct {
+		title          string
+		hasData        bool
+		data           string
+		dataFile       string
+		method         string
+		terminal       bool
+		nonInteractive bool
+		stdin          string
+		want           string
+		wantErr        bool
+	}{
+		{title: "data flag", hasData: true, data: "from-flag", method: "POST", stdin: "from-stdin", want: "from-flag"},
+		{title: "empty data flag", hasData: true, data: "", method: "POST", stdin: "from-stdin", want: ""},
+		{title: "data file", dataFile: dataFile.Name(), method: "POST", stdin: "from-stdin", want: "from-file"},
+		{title: "data file from stdin", dataFile: "-", method: "POST", terminal: true, stdin: "from-stdin", want: "from-stdin"},
+		{title: "piped stdin", method: "POST", stdin: "from-stdin", want: "from-stdin"},
+		{title: "GET with a terminal", method: "get", terminal: true, stdin: "from-stdin", want: ""},
+		{title: "POST with a terminal", method: "POST", terminal: true, stdin: "from-stdin", want: "from-stdin"},
+		{title: "non-interactive with a terminal", method: "POST", terminal: true, nonInteractive: true, stdin: "from-stdin", want: ""},
+		{title: "data and data file", hasData: true, dataFile: dataFile.Name(), method: "POST", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			stdinIsTerminal = func() bool { return tc.terminal }
+			nonInteractive = tc.nonInteractive
+
+			os.Stdin.Truncate(0)
+			os.Stdin.Seek(0, 0)
+			os.Stdin.WriteString(tc.stdin)
+			os.Stdin.Seek(0, 0)
+
+			got, err := readInvokeInput(tc.hasData, tc.data, tc.dataFile, tc.method)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("want an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("want no error, got: %s", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("want: %q, got: %q", tc.want, string(got))
+			}
+		})
+	}
+}
+
+func Test_invokeGatewayNotice(t *testing.T) {
+	cases := []struct {
+		source string
+		want   string
+	}{
+		{sourceFlag, ""},
+		{sourceStack, "Invoking figlet on http://gw.example.com from stack.yml"},
+		{sourceEnvironment, "Invoking figlet on http://gw.example.com from OPENFAAS_URL"},
+		{sourceConfig, "Invoking figlet on http://gw.example.com from the config file"},
+		{sourceDefault, "Invoking figlet on the default gateway http://gw.example.com"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.source, func(t *testing.T) {
+			got := invokeGatewayNotice("figlet", "http://gw.example.com", tc.source, "stack.yml")
+			if (len(tc.want) == 0) != (len(got) == 0) || !strings.Contains(got, tc.want) {
+				t.Fatalf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_invokeNamespace(t *testing.T) {
+	services := stack.Services{
+		Functions: map[string]stack.Function{
+			"figlet": {Namespace: "staging-fn"},
+		},
+	}
+
+	cases := []struct {
+		name string
+		flag string
+		fn   string
+		want string
+	}{
+		{"flag takes precedence", "dev", "figlet", "dev"},
+		{"namespace of the function in the stack", "", "figlet", "staging-fn"},
+		{"function which is not in the stack", "", "env", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := invokeNamespace(tc.flag, services, tc.fn); got != tc.want {
+				t.Fatalf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/openfaas/faas-cli/output"
+)
+
+// junitReport is a file to write the result of each build, push and deploy
+// to in the JUnit XML format, so that CI shows which functions failed
+var junitReport string
+
+// deployStep prefixes the name of the deployment of a function in the
+// gateway phase
+const deployStep = "deploy "
+
+// junitSuites are the phases which are reported with --junit and the name
+// of the suite of each
+var junitSuites = []struct {
+	phase string
+	name  string
+}{
+	{phase: phaseBuild, name: "build"},
+	{phase: phasePush, name: "push"},
+	{phase: phaseGateway, name: "deploy"},
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Contents string `xml:",chardata"`
+}
+
+// junit converts the steps recorded by the profiler into JUnit test suites,
+// each function is a test case of the suite of its phase
+func (p *profiler) junit() junitTestSuites {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	report := junitTestSuites{}
+	var total time.Duration
+
+	for _, s := range junitSuites {
+		suite := junitTestSuite{Name: s.name}
+		var suiteTime time.Duration
+
+		for _, span := range p.spans {
+			if span.Phase != s.phase {
+				continue
+			}
+
+			testCase := junitTestCase{
+				ClassName: s.name,
+				Name:      strings.TrimPrefix(span.Name, deployStep),
+				Time:      junitSeconds(span.Duration),
+			}
+			if span.Err != nil {
+				message := strings.SplitN(span.Err.Error(), "\n", 2)[0]
+				testCase.Failure = &junitFailure{Message: message, Contents: span.Err.Error()}
+				suite.Failures++
+			}
+
+			suite.TestCases = append(suite.TestCases, testCase)
+			suite.Tests++
+			suiteTime += span.Duration
+		}
+
+		if suite.Tests == 0 {
+			continue
+		}
+
+		suite.Time = junitSeconds(suiteTime)
+		report.Suites = append(report.Suites, suite)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		total += suiteTime
+	}
+
+	report.Time = junitSeconds(total)
+	return report
+}
+
+// reportResults writes the result of each build, push and deploy for --junit
+// and --annotate-ci
+func reportResults() {
+	writeJUnitReport()
+	writeCIAnnotations(os.Stdout)
+}
+
+// writeJUnitReport writes the report for --junit, a failure to write it is
+// printed rather than returned so that it never hides the result of the
+// command
+func writeJUnitReport() {
+	if len(junitReport) == 0 {
+		return
+	}
+
+	data, err := xml.MarshalIndent(timings.junit(), "", "  ")
+	if err == nil {
+		data = append([]byte(xml.Header), append(data, '\n')...)
+		err = ioutil.WriteFile(junitReport, data, 0600)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, output.Warning("Unable to write the JUnit report: %s", err))
+		return
+	}
+	fmt.Fprintf(os.Stderr, "JUnit report written to %s\n", junitReport)
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
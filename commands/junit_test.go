@@ -0,0 +1,46 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"encoding/xml"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func Test_profiler_junit(t *testing.T) {
+	p := &profiler{}
+	p.add(profileSpan{Phase: phaseTemplates, Name: "https://github.com/openfaas/templates.git", Duration: time.Second})
+	p.add(profileSpan{Phase: phaseBuild, Name: "fn1", Duration: 1500 * time.Millisecond})
+	p.add(profileSpan{Phase: phaseBuild, Name: "fn2", Duration: time.Second, Err: fmt.Errorf("[fn2] received non-zero exit code from build\nstep 3 failed")})
+	p.add(profileSpan{Phase: phaseGateway, Name: deployStep + "fn1", Duration: 250 * time.Millisecond})
+
+	report := p.junit()
+
+	if report.Tests != 3 || report.Failures != 1 {
+		t.Fatalf("want 3 tests and 1 failure, got %d and %d", report.Tests, report.Failures)
+	}
+	if len(report.Suites) != 2 || report.Suites[0].Name != "build" || report.Suites[1].Name != "deploy" {
+		t.Fatalf("want build and deploy suites, got %+v", report.Suites)
+	}
+
+	build := report.Suites[0]
+	if build.Time != "2.500" {
+		t.Errorf("want the build suite to take 2.500s, got %s", build.Time)
+	}
+	failure := build.TestCases[1].Failure
+	if failure == nil || failure.Message != "[fn2] received non-zero exit code from build" {
+		t.Fatalf("want the first line of the error as the message, got %+v", failure)
+	}
+
+	deploy := report.Suites[1].TestCases[0]
+	if deploy.Name != "fn1" || deploy.ClassName != "deploy" || deploy.Failure != nil {
+		t.Errorf("want a passing deploy of fn1, got %+v", deploy)
+	}
+
+	if _, err := xml.Marshal(report); err != nil {
+		t.Fatalf("want the report to marshal, got: %s", err)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/openfaas/faas-cli/versioncontrol"
+)
+
+// These are variables so that tests do not depend on the repository, clock or
+// user they run with
+var (
+	labelGitSHA    = versioncontrol.GetGitSHA
+	labelGitBranch = versioncontrol.GetGitBranch
+	labelNow       = time.Now
+	labelUser      = currentUser
+)
+
+// invalidLabelValue matches the characters which Kubernetes does not allow in
+// the value of a label
+var invalidLabelValue = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// labelTemplates computes the values which labels can reference as {{ name }}
+// once per deploy, so that every function is given the same timestamp
+type labelTemplates struct {
+	values map[string]string
+}
+
+// value computes a value the first time it is used, git is only run when a
+// label references it
+func (l *labelTemplates) value(name string) (string, bool, error) {
+	if l.values == nil {
+		l.values = map[string]string{}
+	}
+	if value, ok := l.values[name]; ok {
+		return value, true, nil
+	}
+
+	var value string
+	switch name {
+	case "git_sha":
+		value = labelGitSHA()
+		if len(value) == 0 {
+			return "", true, fmt.Errorf("{{ git_sha }} is used in a label, but the git commit could not be read, run faas-cli in a git repository")
+		}
+	case "git_branch":
+		value = labelGitBranch()
+		if len(value) == 0 {
+			return "", true, fmt.Errorf("{{ git_branch }} is used in a label, but the git branch could not be read, run faas-cli in a git repository")
+		}
+	case "timestamp":
+		value = strconv.FormatInt(labelNow().Unix(), 10)
+	case "user":
+		value = labelUser()
+		if len(value) == 0 {
+			return "", true, fmt.Errorf("{{ user }} is used in a label, but the current user could not be found")
+		}
+	default:
+		return "", false, nil
+	}
+
+	value = invalidLabelValue.ReplaceAllString(value, "-")
+	l.values[name] = value
+	return value, true, nil
+}
+
+// expand expands {{ git_sha }}, {{ git_branch }}, {{ timestamp }} and {{ user }}
+// in each label value, unknown names are left as they are
+func (l *labelTemplates) expand(labels map[string]string) (map[string]string, error) {
+	expanded := make(map[string]string, len(labels))
+	for k, v := range labels {
+		var err error
+		expanded[k] = envTemplatePattern.ReplaceAllStringFunc(v, func(match string) string {
+			name := envTemplatePattern.FindStringSubmatch(match)[1]
+			value, ok, valueErr := l.value(name)
+			if valueErr != nil && err == nil {
+				err = valueErr
+			}
+			if !ok {
+				return match
+			}
+			return value
+		})
+		if err != nil {
+			return nil, fmt.Errorf("label %s: %s", k, err)
+		}
+	}
+	return expanded, nil
+}
+
+// currentUser is the name of the user running faas-cli, $USER is preferred
+// so that it can be overridden in CI
+func currentUser() string {
+	if name := os.Getenv("USER"); len(name) > 0 {
+		return name
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
@@ -0,0 +1,97 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// stubLabelTemplates replaces git, the clock and the user, call the returned
+// func to restore them
+func stubLabelTemplates(sha, branch, user string) func() {
+	gitSHA, gitBranch, now, currentUser := labelGitSHA, labelGitBranch, labelNow, labelUser
+
+	labelGitSHA = func() string { return sha }
+	labelGitBranch = func() string { return branch }
+	labelNow = func() time.Time { return time.Unix(1700000000, 0) }
+	labelUser = func() string { return user }
+
+	return func() {
+		labelGitSHA, labelGitBranch, labelNow, labelUser = gitSHA, gitBranch, now, currentUser
+	}
+}
+
+func Test_labelTemplates_expand(t *testing.T) {
+	defer stubLabelTemplates("3ab5e7c", "feature/labels", `CORP\alex`)()
+
+	labels := map[string]string{
+		"version":    "{{ git_sha }}",
+		"branch":     "{{git_branch}}",
+		"deployed":   "{{ timestamp }}",
+		"owner":      "{{ user }}",
+		"release":    "v1-{{ git_sha }}",
+		"unknown":    "{{ .Values.name }} {{ other }}",
+		"plain":      "value",
+		"function":   "{{ function_name }}",
+		"deployedAt": "{{ timestamp }}",
+	}
+
+	want := map[string]string{
+		"version":    "3ab5e7c",
+		"branch":     "feature-labels",
+		"deployed":   "1700000000",
+		"owner":      "CORP-alex",
+		"release":    "v1-3ab5e7c",
+		"unknown":    "{{ .Values.name }} {{ other }}",
+		"plain":      "value",
+		"function":   "{{ function_name }}",
+		"deployedAt": "1700000000",
+	}
+
+	got, err := (&labelTemplates{}).expand(labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want: %q, but got: %q", want, got)
+	}
+}
+
+func Test_labelTemplates_expandOutsideGit(t *testing.T) {
+	defer stubLabelTemplates("", "", "alex")()
+
+	templates := &labelTemplates{}
+
+	got, err := templates.expand(map[string]string{"owner": "{{ user }}"})
+	if err != nil {
+		t.Fatalf("want no error when git is not used, got %s", err)
+	}
+	if got["owner"] != "alex" {
+		t.Fatalf("want owner alex, got %q", got["owner"])
+	}
+
+	_, err = templates.expand(map[string]string{"version": "{{ git_sha }}"})
+	want := "label version: {{ git_sha }} is used in a label, but the git commit could not be read, run faas-cli in a git repository"
+	if err == nil || err.Error() != want {
+		t.Fatalf("want error %q, got %v", want, err)
+	}
+}
+
+func Test_labelTemplates_expandOutsideGitRepository(t *testing.T) {
+	defer chdirOutsideGitRepository(t)()
+
+	cases := map[string]string{
+		"{{ git_sha }}":    "label version: {{ git_sha }} is used in a label, but the git commit could not be read, run faas-cli in a git repository",
+		"{{ git_branch }}": "label version: {{ git_branch }} is used in a label, but the git branch could not be read, run faas-cli in a git repository",
+	}
+	for value, want := range cases {
+		templates := &labelTemplates{}
+		_, err := templates.expand(map[string]string{"version": value})
+		if err == nil || err.Error() != want {
+			t.Fatalf("want error %q, got %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/openfaas/faas-cli/output"
+	"github.com/spf13/pflag"
+)
+
+// legacyFlags maps the names of flags from older docs and scripts to the
+// flags which replaced them, for every command
+var legacyFlags = map[string]string{
+	"gateway-url":  "gateway",
+	"stack":        "yaml",
+	"stack-file":   "yaml",
+	"tls-insecure": "tls-no-verify",
+	"yml":          "yaml",
+}
+
+// legacyEnvironment maps environment variables from older docs and scripts
+// to the variables which replaced them
+var legacyEnvironment = map[string]string{
+	"OPENFAAS_GATEWAY":     openFaaSURLEnvironment,
+	"OPENFAAS_GATEWAY_URL": openFaaSURLEnvironment,
+	"OPENFAAS_TEMPLATE":    templateURLEnvironment,
+}
+
+var (
+	legacyWarned   = map[string]bool{}
+	legacyWarnedMu sync.Mutex
+
+	// legacyWarnings is where the deprecation warnings are written
+	legacyWarnings io.Writer = os.Stderr
+)
+
+// warnLegacyName warns once that legacy is deprecated in favour of canonical
+func warnLegacyName(legacy, canonical string) {
+	legacyWarnedMu.Lock()
+	defer legacyWarnedMu.Unlock()
+
+	if legacyWarned[legacy] {
+		return
+	}
+	legacyWarned[legacy] = true
+	fmt.Fprintln(legacyWarnings, output.Warning("%s is deprecated and will be removed in a future release, use %s instead", legacy, canonical))
+}
+
+// normalizeLegacyFlags is the normalization function for the flags of every
+// command, so that a legacy flag sets the flag which replaced it
+func normalizeLegacyFlags(f *pflag.FlagSet, name string) pflag.NormalizedName {
+	if canonical, ok := legacyFlags[name]; ok {
+		warnLegacyName("--"+name, "--"+canonical)
+		return pflag.NormalizedName(canonical)
+	}
+	return pflag.NormalizedName(name)
+}
+
+// applyLegacyEnvironment copies each legacy environment variable which is set
+// to the variable which replaced it, unless that is already set
+func applyLegacyEnvironment() {
+	names := make([]string, 0, len(legacyEnvironment))
+	for legacy := range legacyEnvironment {
+		names = append(names, legacy)
+	}
+	sort.Strings(names)
+
+	for _, legacy := range names {
+		value, ok := os.LookupEnv(legacy)
+		if !ok {
+			continue
+		}
+		canonical := legacyEnvironment[legacy]
+		warnLegacyName(legacy, canonical)
+		if _, set := os.LookupEnv(canonical); !set {
+			os.Setenv(canonical, value)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func resetLegacyWarnings(t *testing.T) *bytes.Buffer {
+	var out bytes.Buffer
+	legacyWarnings = &out
+	legacyWarned = map[string]bool{}
+	t.Cleanup(func() {
+		legacyWarnings = os.Stderr
+		legacyWarned = map[string]bool{}
+	})
+	return &out
+}
+
+func Test_normalizeLegacyFlags(t *testing.T) {
+	out := resetLegacyWarnings(t)
+
+	var gatewayURL string
+	var tlsNoVerify bool
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringVarP(&gatewayURL, "gateway", "g", "", "")
+	flags.BoolVar(&tlsNoVerify, "tls-no-verify", false, "")
+	flags.SetNormalizeFunc(normalizeLegacyFlags)
+
+	if err := flags.Parse([]string{"--gateway-url", "http://gw:8080", "--tls-insecure", "--tls-insecure"}); err != nil {
+		t.Fatalf("want no error, got %s", err)
+	}
+
+	if gatewayURL != "http://gw:8080" {
+		t.Fatalf("want --gateway-url to set --gateway, got %q", gatewayURL)
+	}
+	if !tlsNoVerify {
+		t.Fatalf("want --tls-insecure to set --tls-no-verify")
+	}
+
+	got := out.String()
+	for _, want := range []string{"--gateway-url is deprecated", "use --gateway instead", "--tls-insecure is deprecated"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want warning to contain %q, got:\n%s", want, got)
+		}
+	}
+	if n := strings.Count(got, "--tls-insecure"); n != 1 {
+		t.Errorf("want one warning for --tls-insecure, got %d:\n%s", n, got)
+	}
+}
+
+func Test_normalizeLegacyFlags_canonicalNameDoesNotWarn(t *testing.T) {
+	out := resetLegacyWarnings(t)
+
+	var yaml string
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringVarP(&yaml, "yaml", "f", "", "")
+	flags.SetNormalizeFunc(normalizeLegacyFlags)
+
+	if err := flags.Parse([]string{"--yaml", "stack.yml"}); err != nil {
+		t.Fatalf("want no error, got %s", err)
+	}
+	if yaml != "stack.yml" {
+		t.Fatalf("want stack.yml, got %q", yaml)
+	}
+	if out.Len() > 0 {
+		t.Fatalf("want no warning, got:\n%s", out.String())
+	}
+}
+
+func Test_applyLegacyEnvironment(t *testing.T) {
+	cases := []struct {
+		name      string
+		legacy    string
+		canonical string
+		want      string
+		wantWarn  bool
+	}{
+		{
+			name:     "legacy variable sets OPENFAAS_URL",
+			legacy:   "http://legacy:8080",
+			want:     "http://legacy:8080",
+			wantWarn: true,
+		},
+		{
+			name:      "OPENFAAS_URL takes precedence",
+			legacy:    "http://legacy:8080",
+			canonical: "http://canonical:8080",
+			want:      "http://canonical:8080",
+			wantWarn:  true,
+		},
+		{
+			name:      "no legacy variable",
+			canonical: "http://canonical:8080",
+			want:      "http://canonical:8080",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := resetLegacyWarnings(t)
+			os.Unsetenv("OPENFAAS_GATEWAY")
+			os.Unsetenv(openFaaSURLEnvironment)
+			defer os.Unsetenv("OPENFAAS_GATEWAY")
+			defer os.Unsetenv(openFaaSURLEnvironment)
+
+			if len(tc.legacy) > 0 {
+				os.Setenv("OPENFAAS_GATEWAY", tc.legacy)
+			}
+			if len(tc.canonical) > 0 {
+				os.Setenv(openFaaSURLEnvironment, tc.canonical)
+			}
+
+			applyLegacyEnvironment()
+
+			if got := os.Getenv(openFaaSURLEnvironment); got != tc.want {
+				t.Fatalf("want %s, got %s", tc.want, got)
+			}
+			if warned := strings.Contains(out.String(), "OPENFAAS_GATEWAY is deprecated"); warned != tc.wantWarn {
+				t.Fatalf("want warning: %t, got:\n%s", tc.wantWarn, out.String())
+			}
+		})
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strings"
+	"sync"
 
 	"github.com/openfaas/faas-cli/proxy"
 	"github.com/openfaas/faas-cli/stack"
@@ -15,16 +17,21 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// allNamespaces lists the functions of every namespace, when the provider supports them
+const allNamespaces = "all"
+
 var (
 	verboseList bool
 	token       string
 	sortOrder   string
+	listLimit   int
+	listOffset  int
 )
 
 func init() {
 	// Setup flags that are used by multiple commands (variables defined in faas.go)
 	listCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
-	listCmd.Flags().StringVarP(&functionNamespace, "namespace", "n", "", "Namespace of the function")
+	listCmd.Flags().StringVarP(&functionNamespace, "namespace", "n", "", "Namespace of the function, or \"all\" for every namespace")
 	listCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Quiet mode - print out only the function's ID")
 
 	listCmd.Flags().BoolVarP(&verboseList, "verbose", "v", false, "Verbose output for the function list")
@@ -32,17 +39,27 @@ func init() {
 	listCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in stack.yml file")
 	listCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
 	listCmd.Flags().StringVar(&sortOrder, "sort", "name", "Sort the functions by \"name\" or \"invocations\"")
+	listCmd.Flags().IntVar(&listLimit, "limit", 0, "Print at most this many functions, 0 for all")
+	listCmd.Flags().IntVar(&listOffset, "offset", 0, "Skip this many functions after sorting, use with --limit to page through the list")
 
 	faasCmd.AddCommand(listCmd)
 }
 
 var listCmd = &cobra.Command{
-	Use:     `list [--gateway GATEWAY_URL] [--verbose] [--tls-no-verify]`,
-	Aliases: []string{"ls"},
-	Short:   "List OpenFaaS functions",
-	Long:    `Lists OpenFaaS functions either on a local or remote gateway`,
+	Use:        `list [--gateway GATEWAY_URL] [--verbose] [--tls-no-verify]`,
+	Aliases:    []string{"ls"},
+	SuggestFor: []string{"get", "ps"},
+	Short:      "List OpenFaaS functions",
+	Long: `Lists OpenFaaS functions either on a local or remote gateway.
+
+Pass --namespace all to list the functions of every namespace in one table with
+a namespace column, when the provider supports namespaces. In quiet mode each
+function is then printed as NAME.NAMESPACE.`,
 	Example: `  faas-cli list
-  faas-cli list --gateway https://127.0.0.1:8080 --verbose`,
+  faas-cli list --namespace all
+  faas-cli list --gateway https://127.0.0.1:8080 --verbose
+  faas-cli list --sort invocations --limit 10
+  faas-cli list --limit 100 --offset 100`,
 	RunE: runList,
 }
 
@@ -51,7 +68,7 @@ func runList(cmd *cobra.Command, args []string) error {
 	var gatewayAddress string
 	var yamlGateway string
 	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter, envsubst)
+		parsedServices, err := parseStackFile(yamlFile, envsubst)
 		if err != nil {
 			return err
 		}
@@ -63,33 +80,56 @@ func runList(cmd *cobra.Command, args []string) error {
 	}
 	gatewayAddress = getGatewayURL(gateway, defaultGateway, yamlGateway, os.Getenv(openFaaSURLEnvironment))
 
-	cliAuth, err := proxy.NewCLIAuth(token, gatewayAddress)
-	if err != nil {
-		return err
-	}
-	transport := GetDefaultCLITransport(tlsInsecure, &commandTimeout)
-	proxyClient, err := proxy.NewClient(cliAuth, gatewayAddress, transport, &commandTimeout)
-	if err != nil {
-		return err
-	}
+	showNamespace := functionNamespace == allNamespaces
 
-	functions, err := proxyClient.ListFunctions(context.Background(), functionNamespace)
+	var functions []types.FunctionStatus
+	err := withGatewayFailover(gatewayAddress, func(gatewayAddress string) error {
+		cliAuth, err := proxy.NewCLIAuth(token, gatewayAddress)
+		if err != nil {
+			return err
+		}
+		transport := GetDefaultCLITransport(tlsInsecure, &commandTimeout)
+		proxyClient, err := proxy.NewClient(cliAuth, gatewayAddress, transport, &commandTimeout)
+		if err != nil {
+			return err
+		}
+		proxyClient.CallID = requestID
+
+		if showNamespace {
+			functions, err = listAllFunctions(context.Background(), proxyClient)
+		} else {
+			functions, err = proxyClient.ListFunctions(context.Background(), functionNamespace)
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
+	// A stable sort keeps functions of the same name ordered by namespace
 	if sortOrder == "name" {
-		sort.Sort(byName(functions))
+		sort.Stable(byName(functions))
 	} else if sortOrder == "invocations" {
-		sort.Sort(byInvocations(functions))
+		sort.Stable(byInvocations(functions))
 	} else if sortOrder == "creation" {
-		sort.Sort(byCreation(functions))
+		sort.Stable(byCreation(functions))
+	}
+
+	functions, err = paginateFunctions(functions, listOffset, listLimit)
+	if err != nil {
+		return err
 	}
 
 	if quiet {
 		for _, function := range functions {
+			if showNamespace {
+				fmt.Printf("%s.%s\n", function.Name, function.Namespace)
+				continue
+			}
 			fmt.Printf("%s\n", function.Name)
 		}
+	} else if showNamespace {
+		printFunctionsWithNamespace(functions, verboseList)
 	} else if verboseList {
 
 		maxWidth := 40
@@ -116,6 +156,118 @@ func runList(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// functionLister lists the namespaces of a provider and the functions in each
+type functionLister interface {
+	ListNamespaces(ctx context.Context) ([]string, error)
+	ListFunctions(ctx context.Context, namespace string) ([]types.FunctionStatus, error)
+}
+
+// listAllFunctions lists the functions of every namespace concurrently and
+// merges them ordered by namespace, then name
+func listAllFunctions(ctx context.Context, client functionLister) ([]types.FunctionStatus, error) {
+	namespaces, err := client.ListNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(namespaces) == 0 {
+		return nil, fmt.Errorf("the provider does not support namespaces, list functions without --namespace %s", allNamespaces)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	functions := []types.FunctionStatus{}
+	failed := map[string]error{}
+
+	for _, namespace := range namespaces {
+		wg.Add(1)
+		go func(namespace string) {
+			defer wg.Done()
+
+			list, err := client.ListFunctions(ctx, namespace)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed[namespace] = err
+				return
+			}
+			for _, function := range list {
+				if len(function.Namespace) == 0 {
+					function.Namespace = namespace
+				}
+				functions = append(functions, function)
+			}
+		}(namespace)
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		errs := []string{}
+		for namespace, err := range failed {
+			errs = append(errs, fmt.Sprintf("%s: %s", namespace, err))
+		}
+		sort.Strings(errs)
+		return nil, fmt.Errorf("unable to list functions in %d namespace(s):\n%s", len(failed), strings.Join(errs, "\n"))
+	}
+
+	sort.Slice(functions, func(i, j int) bool {
+		if functions[i].Namespace != functions[j].Namespace {
+			return functions[i].Namespace < functions[j].Namespace
+		}
+		return functions[i].Name < functions[j].Name
+	})
+	return functions, nil
+}
+
+func printFunctionsWithNamespace(functions []types.FunctionStatus, verbose bool) {
+	namespaceWidth := 20
+	for _, function := range functions {
+		if len(function.Namespace) > namespaceWidth {
+			namespaceWidth = len(function.Namespace)
+		}
+	}
+	namespaceFormat := "%-" + fmt.Sprintf("%d", namespaceWidth) + "s"
+
+	if !verbose {
+		fmt.Printf("%-30s\t"+namespaceFormat+"\t%-15s\t%-5s\n", "Function", "Namespace", "Invocations", "Replicas")
+		for _, function := range functions {
+			fmt.Printf("%-30s\t"+namespaceFormat+"\t%-15d\t%-5d\n", function.Name, function.Namespace, int64(function.InvocationCount), function.Replicas)
+		}
+		return
+	}
+
+	maxWidth := 40
+	for _, function := range functions {
+		if len(function.Image) > maxWidth {
+			maxWidth = len(function.Image)
+		}
+	}
+	imageFormat := "%-" + fmt.Sprintf("%d", maxWidth) + "s"
+
+	fmt.Printf("%-30s\t"+namespaceFormat+"\t"+imageFormat+"\t%-15s\t%-5s\t%-5s\n", "Function", "Namespace", "Image", "Invocations", "Replicas", "CreatedAt")
+	for _, function := range functions {
+		fmt.Printf("%-30s\t"+namespaceFormat+"\t"+imageFormat+"\t%-15d\t%-5d\t\t%-5s\n", function.Name, function.Namespace, function.Image, int64(function.InvocationCount), function.Replicas, function.CreatedAt.String())
+	}
+}
+
+// paginateFunctions returns up to limit functions after skipping offset, the
+// gateway has no pagination so the page is taken from the sorted list
+func paginateFunctions(functions []types.FunctionStatus, offset, limit int) ([]types.FunctionStatus, error) {
+	if offset < 0 || limit < 0 {
+		return nil, fmt.Errorf("--offset and --limit must not be negative")
+	}
+
+	if offset >= len(functions) {
+		return []types.FunctionStatus{}, nil
+	}
+	functions = functions[offset:]
+
+	if limit > 0 && limit < len(functions) {
+		functions = functions[:limit]
+	}
+	return functions, nil
+}
+
 type byName []types.FunctionStatus
 
 func (a byName) Len() int           { return len(a) }
@@ -4,8 +4,12 @@
 package commands
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"reflect"
 	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/openfaas/faas-cli/test"
@@ -78,3 +82,116 @@ func Test_list_errors(t *testing.T) {
 		t.Fatal("No error found while testing missing yaml")
 	}
 }
+
+func Test_paginateFunctions(t *testing.T) {
+	functions := []types.FunctionStatus{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}}
+
+	cases := []struct {
+		name    string
+		offset  int
+		limit   int
+		want    []string
+		wantErr bool
+	}{
+		{name: "all", want: []string{"a", "b", "c", "d"}},
+		{name: "first page", limit: 3, want: []string{"a", "b", "c"}},
+		{name: "last page", offset: 3, limit: 3, want: []string{"d"}},
+		{name: "past the end", offset: 4, limit: 3, want: []string{}},
+		{name: "negative offset", offset: -1, wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := paginateFunctions(functions, c.offset, c.limit)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("want error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			names := []string{}
+			for _, function := range got {
+				names = append(names, function.Name)
+			}
+			if !reflect.DeepEqual(names, c.want) {
+				t.Fatalf("want %v, but got %v", c.want, names)
+			}
+		})
+	}
+}
+
+func Test_listAllFunctions(t *testing.T) {
+	client := &fakeFunctionLister{
+		namespaces: []string{"openfaas-fn", "dev"},
+		functions: map[string][]types.FunctionStatus{
+			"openfaas-fn": {{Name: "figlet"}, {Name: "env"}},
+			"dev":         {{Name: "figlet", Namespace: "dev"}},
+		},
+	}
+
+	functions, err := listAllFunctions(context.Background(), client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []string{}
+	for _, function := range functions {
+		got = append(got, function.Name+"."+function.Namespace)
+	}
+	want := []string{"figlet.dev", "env.openfaas-fn", "figlet.openfaas-fn"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %v, but got %v", want, got)
+	}
+}
+
+func Test_listAllFunctions_errors(t *testing.T) {
+	cases := []struct {
+		name    string
+		client  *fakeFunctionLister
+		wantErr string
+	}{
+		{
+			name:    "namespaces not supported",
+			client:  &fakeFunctionLister{},
+			wantErr: "the provider does not support namespaces",
+		},
+		{
+			name: "namespace fails",
+			client: &fakeFunctionLister{
+				namespaces: []string{"openfaas-fn", "dev"},
+				functions:  map[string][]types.FunctionStatus{"openfaas-fn": {{Name: "env"}}},
+			},
+			wantErr: "unable to list functions in 1 namespace(s):\ndev: namespace dev not found",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := listAllFunctions(context.Background(), c.client)
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Fatalf("want error %q, but got %v", c.wantErr, err)
+			}
+		})
+	}
+}
+
+type fakeFunctionLister struct {
+	namespaces []string
+	functions  map[string][]types.FunctionStatus
+}
+
+func (f *fakeFunctionLister) ListNamespaces(ctx context.Context) ([]string, error) {
+	return f.namespaces, nil
+}
+
+func (f *fakeFunctionLister) ListFunctions(ctx context.Context, namespace string) ([]types.FunctionStatus, error) {
+	functions, ok := f.functions[namespace]
+	if !ok {
+		return nil, fmt.Errorf("namespace %s not found", namespace)
+	}
+	return functions, nil
+}
@@ -30,6 +30,7 @@ func init() {
 	loginCmd.Flags().BoolVarP(&passwordStdin, "password-stdin", "s", false, "Reads the gateway password from stdin")
 	loginCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
 	loginCmd.Flags().Duration("timeout", time.Second*5, "Override the timeout for this API call")
+	loginCmd.Flags().BoolVar(&insecureCredentialStore, "insecure-credential-store", false, "Store the credentials unencrypted in the config file instead of the keyring of the OS")
 
 	faasCmd.AddCommand(loginCmd)
 }
@@ -37,7 +38,15 @@ func init() {
 var loginCmd = &cobra.Command{
 	Use:   `login [--username admin|USERNAME] [--password PASSWORD] [--gateway GATEWAY_URL] [--tls-no-verify]`,
 	Short: "Log in to OpenFaaS gateway",
-	Long:  "Log in to OpenFaaS gateway.\nIf no gateway is specified, the default value will be used.",
+	Long: `Log in to OpenFaaS gateway.
+If no gateway is specified, the default value will be used.
+You are prompted for the password when it is not given and stdin is a terminal.
+
+The credentials are stored in the keyring of the OS through a Docker credential
+helper: docker-credential-osxkeychain on MacOS, docker-credential-wincred on
+Windows and docker-credential-secretservice on Linux. Pass
+--insecure-credential-store to store them unencrypted in the config file
+instead, which is done with a warning in CI when there is no helper.`,
 	Example: `  cat ~/faas_pass.txt | faas-cli login -u user --password-stdin
   echo $PASSWORD | faas-cli login -s  --gateway https://openfaas.mydomain.com
   faas-cli login -u user -p password`,
@@ -79,6 +88,13 @@ func runLogin(cmd *cobra.Command, args []string) error {
 		password = strings.TrimSpace(string(passwordStdin))
 	}
 
+	if len(password) == 0 && !passwordStdin && interactive() {
+		password, err = promptPassword("Password: ")
+		if err != nil {
+			return err
+		}
+	}
+
 	password = strings.TrimSpace(password)
 	if len(password) == 0 {
 		return fmt.Errorf("must provide a non-empty password via --password or --password-stdin")
@@ -93,7 +109,7 @@ func runLogin(cmd *cobra.Command, args []string) error {
 	}
 
 	token := config.EncodeAuth(username, password)
-	if err := config.UpdateAuthConfig(gateway, token, config.BasicAuthType); err != nil {
+	if err := saveAuthConfig(gateway, token, config.BasicAuthType); err != nil {
 		return err
 	}
 
@@ -124,6 +140,9 @@ func validateLogin(gatewayURL string, user string, pass string, timeout time.Dur
 	}
 
 	req.SetBasicAuth(user, pass)
+	if err := proxy.SetGatewayHeaders(req, gatewayURL); err != nil {
+		return err
+	}
 	res, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("cannot connect to OpenFaaS on URL: %s. %v", gatewayURL, err)
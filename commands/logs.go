@@ -10,6 +10,8 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/openfaas/faas-cli/flags"
@@ -35,6 +37,8 @@ type logFlags struct {
 	includeName     bool
 	includeInstance bool
 	timeFormat      flags.TimeFormat
+	grep            string
+	callID          string
 }
 
 func init() {
@@ -46,12 +50,25 @@ func init() {
 var functionLogsCmd = &cobra.Command{
 	Use:   `logs <NAME> [--tls-no-verify] [--gateway] [--output=text/json]`,
 	Short: "Fetch logs for a functions",
-	Long:  "Fetch logs for a given function name in plain text or JSON format.",
+	Long: `Fetch logs for a given function name in plain text or JSON format.
+
+The lines can be narrowed down to one instance of the function with
+--instance-id, to those which match a regular expression with --grep, or to
+those of a single invocation with --call-id. The call ID is sent by invoke in
+the X-Call-Id header and can be set with --request-id, it is only in the logs
+when the watchdog is told to print it, i.e. with log_call_id=true for the
+of-watchdog.
+
+Without a function name in a terminal, the functions on the gateway are
+listed to pick one by its number or by typing part of its name.`,
 	Example: `  faas-cli logs FN
   faas-cli logs FN --output=json
   faas-cli logs FN --lines=5
   faas-cli logs FN --tail=false --since=10m
   faas-cli logs FN --tail=false --since=2010-01-01T00:00:00Z
+  faas-cli logs FN --instance-id FN-7d9c8b5f4-x2x6z --instance
+  faas-cli logs FN --grep "(?i)error|timeout"
+  faas-cli invoke FN --request-id 3f2c; faas-cli logs FN --call-id 3f2c
 `,
 	Args:    cobra.MaximumNArgs(1),
 	RunE:    runLogs,
@@ -59,7 +76,7 @@ var functionLogsCmd = &cobra.Command{
 }
 
 func noopPreRunCmd(cmd *cobra.Command, args []string) error {
-	if len(args) == 0 {
+	if len(args) == 0 && !interactive() {
 		return fmt.Errorf("function name is required")
 	}
 	return nil
@@ -86,6 +103,9 @@ func initLogCmdFlags(cmd *cobra.Command) {
 	cmd.Flags().Var(&logFlagValues.timeFormat, "time-format", "string format for the timestamp, any value go time format string is allowed, empty will not print the timestamp")
 	cmd.Flags().BoolVar(&logFlagValues.includeName, "name", false, "print the function name")
 	cmd.Flags().BoolVar(&logFlagValues.includeInstance, "instance", false, "print the function instance name/id")
+	cmd.Flags().StringVar(&logFlagValues.instance, "instance-id", "", "only print the logs of this instance of the function, i.e. the name of a Pod")
+	cmd.Flags().StringVar(&logFlagValues.grep, "grep", "", "only print the lines which match this regular expression")
+	cmd.Flags().StringVar(&logFlagValues.callID, "call-id", "", "only print the lines which contain this X-Call-Id, i.e. from invoke --request-id")
 }
 
 func runLogs(cmd *cobra.Command, args []string) error {
@@ -95,6 +115,19 @@ func runLogs(cmd *cobra.Command, args []string) error {
 		fmt.Println(msg)
 	}
 
+	filter, err := logFilterFromFlags()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		picked, err := pickFunction(gatewayAddress, functionNamespace, logFlagValues.token)
+		if err != nil {
+			return err
+		}
+		args = []string{picked}
+	}
+
 	logRequest := logRequestFromFlags(cmd, args)
 	cliAuth, err := proxy.NewCLIAuth(logFlagValues.token, gatewayAddress)
 	if err != nil {
@@ -105,6 +138,7 @@ func runLogs(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	cliClient.CallID = requestID
 
 	logEvents, err := cliClient.GetLogs(context.Background(), logRequest)
 	if err != nil {
@@ -113,6 +147,9 @@ func runLogs(cmd *cobra.Command, args []string) error {
 
 	formatter := GetLogFormatter(string(logFlagValues.logFormat))
 	for logMsg := range logEvents {
+		if !filter.match(logMsg) {
+			continue
+		}
 		fmt.Fprintln(os.Stdout, formatter(logMsg, logFlagValues.timeFormat.String(), logFlagValues.includeName, logFlagValues.includeInstance))
 	}
 
@@ -129,12 +166,49 @@ func logRequestFromFlags(cmd *cobra.Command, args []string) logs.Request {
 	return logs.Request{
 		Name:      args[0],
 		Namespace: ns,
+		Instance:  logFlagValues.instance,
 		Tail:      logFlagValues.lines,
 		Since:     sinceValue(logFlagValues.sinceTime.AsTime(), logFlagValues.since),
 		Follow:    logFlagValues.tail,
 	}
 }
 
+// logFilter picks the log messages to print. The instance is checked here
+// too, as not every provider filters by it.
+type logFilter struct {
+	instance string
+	grep     *regexp.Regexp
+	callID   string
+}
+
+func logFilterFromFlags() (logFilter, error) {
+	filter := logFilter{
+		instance: logFlagValues.instance,
+		callID:   logFlagValues.callID,
+	}
+	if len(logFlagValues.grep) > 0 {
+		grep, err := regexp.Compile(logFlagValues.grep)
+		if err != nil {
+			return logFilter{}, fmt.Errorf("invalid --grep %q: %s", logFlagValues.grep, err)
+		}
+		filter.grep = grep
+	}
+	return filter, nil
+}
+
+func (f logFilter) match(msg logs.Message) bool {
+	if len(f.instance) > 0 && msg.Instance != f.instance {
+		return false
+	}
+	if len(f.callID) > 0 && !strings.Contains(msg.Text, f.callID) {
+		return false
+	}
+	if f.grep != nil && !f.grep.MatchString(msg.Text) {
+		return false
+	}
+	return true
+}
+
 func sinceValue(t time.Time, d time.Duration) *time.Time {
 	if !t.IsZero() {
 		return &t
@@ -148,11 +222,14 @@ func sinceValue(t time.Time, d time.Duration) *time.Time {
 }
 
 func getLogStreamingTransport(tlsInsecure bool) http.RoundTripper {
-	if tlsInsecure {
+	if tlsInsecure || proxy.DialGateway != nil {
 		tr := &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
+			Proxy:       http.ProxyFromEnvironment,
+			DialContext: proxy.DialGateway,
+		}
+		if tlsInsecure {
+			tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: tlsInsecure}
 		}
-		tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: tlsInsecure}
 
 		return tr
 	}
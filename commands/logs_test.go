@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
@@ -26,6 +27,7 @@ func Test_logsCmdFlagParsing(t *testing.T) {
 		{"can limit number of messages returned", []string{"funcFoo", "--lines=5"}, logs.Request{Name: "funcFoo", Follow: true, Tail: 5}},
 		{"can set timestamp to send logs since using duration", []string{"funcFoo", "--since=5m"}, logs.Request{Name: "funcFoo", Follow: true, Tail: -1, Since: &fiveMinAgo}},
 		{"can set timestamp to send logs since using timestamp", []string{"funcFoo", "--since-time=" + fiveMinAgoStr}, logs.Request{Name: "funcFoo", Follow: true, Tail: -1, Since: &fiveMinAgo}},
+		{"can request the logs of one instance", []string{"funcFoo", "--instance-id=funcFoo-7d9c8b5f4-x2x6z"}, logs.Request{Name: "funcFoo", Follow: true, Tail: -1, Instance: "funcFoo-7d9c8b5f4-x2x6z"}},
 	}
 
 	for _, s := range scenarios {
@@ -43,6 +45,57 @@ func Test_logsCmdFlagParsing(t *testing.T) {
 	}
 }
 
+func Test_logFilter(t *testing.T) {
+	messages := []logs.Message{
+		{Instance: "fn-a", Text: "Forking fprocess."},
+		{Instance: "fn-a", Text: "POST / - 200 OK - ContentLength: 2B (0.01s) [3f2c]"},
+		{Instance: "fn-b", Text: "error: connection refused"},
+		{Instance: "fn-b", Text: "POST / - 500 Internal Server Error [9a1d]"},
+	}
+
+	scenarios := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"no filter", []string{"fn"}, []string{"Forking fprocess.", "POST / - 200 OK - ContentLength: 2B (0.01s) [3f2c]", "error: connection refused", "POST / - 500 Internal Server Error [9a1d]"}},
+		{"instance", []string{"fn", "--instance-id=fn-b"}, []string{"error: connection refused", "POST / - 500 Internal Server Error [9a1d]"}},
+		{"grep", []string{"fn", "--grep=(?i)error"}, []string{"error: connection refused", "POST / - 500 Internal Server Error [9a1d]"}},
+		{"call ID", []string{"fn", "--call-id=3f2c"}, []string{"POST / - 200 OK - ContentLength: 2B (0.01s) [3f2c]"}},
+		{"instance and grep", []string{"fn", "--instance-id=fn-a", "--grep=POST"}, []string{"POST / - 200 OK - ContentLength: 2B (0.01s) [3f2c]"}},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			functionLogsCmd.ResetFlags()
+			initLogCmdFlags(functionLogsCmd)
+			functionLogsCmd.ParseFlags(s.args)
+
+			filter, err := logFilterFromFlags()
+			if err != nil {
+				t.Fatalf("want no error, got %s", err)
+			}
+
+			got := []string{}
+			for _, msg := range messages {
+				if filter.match(msg) {
+					got = append(got, msg.Text)
+				}
+			}
+			if !reflect.DeepEqual(got, s.want) {
+				t.Errorf("want %v, got %v", s.want, got)
+			}
+		})
+	}
+
+	functionLogsCmd.ResetFlags()
+	initLogCmdFlags(functionLogsCmd)
+	functionLogsCmd.ParseFlags([]string{"fn", "--grep=("})
+	if _, err := logFilterFromFlags(); err == nil {
+		t.Errorf("want an error for an invalid --grep")
+	}
+}
+
 func strP(s string) *string {
 	return &s
 }
@@ -30,17 +30,23 @@ var namespacesCmd = &cobra.Command{
 
 func runNamespaces(cmd *cobra.Command, args []string) error {
 	gatewayAddress := getGatewayURL(gateway, defaultGateway, "", os.Getenv(openFaaSURLEnvironment))
-	cliAuth, err := proxy.NewCLIAuth(token, gatewayAddress)
-	if err != nil {
-		return err
-	}
-	transport := GetDefaultCLITransport(tlsInsecure, &commandTimeout)
-	client, err := proxy.NewClient(cliAuth, gatewayAddress, transport, &commandTimeout)
-	if err != nil {
-		return err
-	}
 
-	namespaces, err := client.ListNamespaces(context.Background())
+	var namespaces []string
+	err := withGatewayFailover(gatewayAddress, func(gatewayAddress string) error {
+		cliAuth, err := proxy.NewCLIAuth(token, gatewayAddress)
+		if err != nil {
+			return err
+		}
+		transport := GetDefaultCLITransport(tlsInsecure, &commandTimeout)
+		client, err := proxy.NewClient(cliAuth, gatewayAddress, transport, &commandTimeout)
+		if err != nil {
+			return err
+		}
+		client.CallID = requestID
+
+		namespaces, err = client.ListNamespaces(context.Background())
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -10,15 +10,20 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/openfaas/faas-cli/builder"
+	"github.com/openfaas/faas-cli/i18n"
+	"github.com/openfaas/faas-cli/output"
 	"github.com/openfaas/faas-cli/stack"
 	"github.com/spf13/cobra"
 )
 
 var (
 	appendFile    string
+	strictAppend  bool
+	fixName       bool
 	list          bool
 	quiet         bool
 	memoryLimit   string
@@ -41,7 +46,9 @@ func init() {
 
 	newFunctionCmd.Flags().BoolVar(&list, "list", false, "List available languages")
 	newFunctionCmd.Flags().StringVarP(&appendFile, "append", "a", "", "Append to existing YAML file")
+	newFunctionCmd.Flags().BoolVar(&strictAppend, "strict", false, "With --append, fail instead of warning when the function is in another stack file in the current directory")
 	newFunctionCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Skip template notes")
+	newFunctionCmd.Flags().BoolVar(&fixName, "fix-name", false, "Use a valid name in place of an invalid one, i.e. my-fn for My_Fn")
 
 	faasCmd.AddCommand(newFunctionCmd)
 }
@@ -54,6 +61,7 @@ var newFunctionCmd = &cobra.Command{
 language or type in --list for a list of languages available.`,
 	Example: `  faas-cli new chatbot --lang node
   faas-cli new chatbot --lang node --append stack.yml
+  faas-cli new chatbot --lang node --append stack.yml --strict
   faas-cli new text-parser --lang python --quiet
   faas-cli new text-parser --lang python --gateway http://mydomain:8080
   faas-cli new --list`,
@@ -61,17 +69,70 @@ language or type in --list for a list of languages available.`,
 	RunE:    runNewFunction,
 }
 
+// maxFunctionNameLength is the longest name of a Kubernetes service, which is
+// an RFC-1123 DNS label
+const maxFunctionNameLength = 63
+
+// validDNS is the regex for RFC-1123 validation from
+// k8s.io/kubernetes/pkg/util/validation/validation.go
+var validDNS = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// invalidNameCharacters are replaced with a dash by suggestFunctionName
+var invalidNameCharacters = regexp.MustCompile(`[^a-z0-9]+`)
+
 // validateFunctionName provides least-common-denominator validation - i.e. only allows valid Kubernetes services names
 func validateFunctionName(functionName string) error {
-	// Regex for RFC-1123 validation:
-	// 	k8s.io/kubernetes/pkg/util/validation/validation.go
-	var validDNS = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	if len(functionName) > maxFunctionNameLength {
+		return fmt.Errorf("function name can be at most %d characters, but %s has %d", maxFunctionNameLength, functionName, len(functionName))
+	}
 	if matched := validDNS.MatchString(functionName); !matched {
 		return fmt.Errorf(`function name can only contain a-z, 0-9 and dashes`)
 	}
 	return nil
 }
 
+// suggestFunctionName returns a valid name which is close to an invalid one,
+// i.e. My_Function becomes my-function. It is empty when no letters or
+// digits are left.
+func suggestFunctionName(functionName string) string {
+	suggestion := invalidNameCharacters.ReplaceAllString(strings.ToLower(functionName), "-")
+	suggestion = strings.Trim(suggestion, "-")
+
+	if len(suggestion) > maxFunctionNameLength {
+		suggestion = strings.TrimRight(suggestion[:maxFunctionNameLength], "-")
+	}
+	return suggestion
+}
+
+// fixFunctionName checks a function name and returns the name to use. An
+// invalid name is replaced by the suggested one with --fix-name, or when the
+// user agrees to it, otherwise the suggestion is added to the error.
+func fixFunctionName(functionName string, fix bool) (string, error) {
+	err := validateFunctionName(functionName)
+	if err == nil {
+		return functionName, nil
+	}
+
+	suggestion := suggestFunctionName(functionName)
+	if len(suggestion) == 0 {
+		return "", err
+	}
+
+	if fix {
+		fmt.Printf("Using the name %s for %s\n", suggestion, functionName)
+		return suggestion, nil
+	}
+
+	if interactive() {
+		fmt.Println(err)
+		if ok, promptErr := confirm(fmt.Sprintf("Use %s instead?", suggestion)); promptErr == nil && ok {
+			return suggestion, nil
+		}
+	}
+
+	return "", fmt.Errorf("%s, try %s or pass --fix-name", err, suggestion)
+}
+
 // preRunNewFunction validates args & flags
 func preRunNewFunction(cmd *cobra.Command, args []string) error {
 	if list == true {
@@ -81,37 +142,37 @@ func preRunNewFunction(cmd *cobra.Command, args []string) error {
 	language, _ = validateLanguageFlag(language)
 
 	if len(language) == 0 && len(args) < 1 {
-		cmd.Help()
-		os.Exit(0)
+		// runNewFunction prints the help
+		return nil
 	}
 	if len(language) == 0 {
-		return fmt.Errorf("you must supply a function language with the --lang flag")
+		return i18n.Errorf(i18n.NewMissingLanguage)
 	}
 
 	if len(args) < 1 {
-		return fmt.Errorf(`please provide a name for the function`)
+		return i18n.Errorf(i18n.NewMissingName)
 	}
 
-	functionName = args[0]
-
-	if err := validateFunctionName(functionName); err != nil {
+	name, err := fixFunctionName(args[0], fixName)
+	if err != nil {
 		return err
 	}
+	functionName = name
 
 	return nil
 }
 
 func runNewFunction(cmd *cobra.Command, args []string) error {
+	if list == false && len(language) == 0 && len(args) < 1 {
+		return cmd.Help()
+	}
+
 	if list == true {
 		var availableTemplates []string
 
 		templateFolders, err := ioutil.ReadDir(templateDirectory)
 		if err != nil {
-			return fmt.Errorf(`no language templates were found.
-
-Download templates:
-  faas-cli template pull           download the default templates
-  faas-cli template store list     view the community template store`)
+			return i18n.Errorf(i18n.NewNoTemplates)
 		}
 
 		for _, file := range templateFolders {
@@ -120,7 +181,7 @@ Download templates:
 			}
 		}
 
-		fmt.Printf("Languages available as templates:\n%s\n", printAvailableTemplates(availableTemplates))
+		fmt.Print(i18n.T(i18n.NewLanguagesAvailable, printAvailableTemplates(availableTemplates)))
 
 		return nil
 	}
@@ -129,7 +190,7 @@ Download templates:
 	PullTemplates(templateAddress)
 
 	if !stack.IsValidTemplate(language) {
-		return fmt.Errorf("%s is unavailable or not supported", language)
+		return i18n.Errorf(i18n.NewUnsupportedLanguage, language)
 	}
 
 	var fileName, outputMsg string
@@ -151,13 +212,20 @@ Download templates:
 			return duplicateError
 		}
 
+		if others := functionInOtherStacks(functionName, appendFile, "."); len(others) > 0 {
+			if strictAppend {
+				return i18n.Errorf(i18n.NewFunctionInOtherStacks, functionName, strings.Join(others, ", "))
+			}
+			fmt.Println(output.Warning("%s", i18n.T(i18n.NewFunctionInOtherStacks, functionName, strings.Join(others, ", "))))
+		}
+
 		fileName = appendFile
-		outputMsg = fmt.Sprintf("Stack file updated: %s\n", fileName)
+		outputMsg = i18n.T(i18n.NewStackFileUpdated, fileName)
 
 	} else {
 		gateway = getGatewayURL(gateway, defaultGateway, gateway, os.Getenv(openFaaSURLEnvironment))
 		fileName = functionName + ".yml"
-		outputMsg = fmt.Sprintf("Stack file written: %s\n", fileName)
+		outputMsg = i18n.T(i18n.NewStackFileWritten, fileName)
 	}
 
 	if len(handlerDir) == 0 {
@@ -165,18 +233,18 @@ Download templates:
 	}
 
 	if _, err := os.Stat(handlerDir); err == nil {
-		return fmt.Errorf("folder: %s already exists", handlerDir)
+		return i18n.Errorf(i18n.NewFolderExists, handlerDir)
 	}
 
 	_, err := os.Stat(fileName)
 	if err == nil && appendMode == false {
-		return fmt.Errorf("file: %s already exists", fileName)
+		return i18n.Errorf(i18n.NewFileExists, fileName)
 	}
 
 	if err := os.Mkdir(handlerDir, 0700); err != nil {
 		return fmt.Errorf("folder: could not create %s : %s", handlerDir, err)
 	}
-	fmt.Printf("Folder: %s created.\n", handlerDir)
+	fmt.Print(i18n.T(i18n.NewFolderCreated, handlerDir))
 
 	if err := updateGitignore(); err != nil {
 		return fmt.Errorf("got unexpected error while updating .gitignore file: %s", err)
@@ -201,8 +269,13 @@ Download templates:
 
 	// Create function directory from template.
 	builder.CopyFiles(fromTemplateHandler, handlerDir)
+
+	testCommand, err := writeHandlerTest(handlerDir, language, langTemplate)
+	if err != nil {
+		return err
+	}
 	printLogo()
-	fmt.Printf("\nFunction created in folder: %s\n", handlerDir)
+	fmt.Print(i18n.T(i18n.NewFunctionCreated, handlerDir))
 
 	imageName := fmt.Sprintf("%s:latest", functionName)
 
@@ -217,6 +290,7 @@ Download templates:
 		Handler:  "./" + handlerDir,
 		Language: language,
 		Image:    imageName,
+		Test:     testCommand,
 	}
 
 	if len(memoryLimit) > 0 || len(cpuLimit) > 0 {
@@ -251,9 +325,13 @@ Download templates:
 		languageTemplate, _ := stack.LoadLanguageTemplate(language)
 
 		if languageTemplate.WelcomeMessage != "" {
-			fmt.Printf("\nNotes:\n")
+			fmt.Print(i18n.T(i18n.NewTemplateNotes))
 			fmt.Printf("%s\n", languageTemplate.WelcomeMessage)
 		}
+
+		if len(testCommand) > 0 {
+			fmt.Print(handlerTestNotes(fileName, functionName))
+		}
 	}
 
 	return nil
@@ -267,6 +345,8 @@ func getPrefixValue() string {
 
 	if val, ok := os.LookupEnv("OPENFAAS_PREFIX"); ok && len(val) > 0 {
 		prefix = val
+	} else if configPrefix := configSettings().Prefix; len(configPrefix) > 0 {
+		prefix = configPrefix
 	}
 	return prefix
 }
@@ -279,6 +359,10 @@ func prepareYAMLContent(appendMode bool, gateway string, function *stack.Functio
     image: ` + function.Image + `
 `
 
+	if len(function.Test) > 0 {
+		yamlContent += `    test: ` + strconv.Quote(function.Test) + "\n"
+	}
+
 	if function.Requests != nil && (len(function.Requests.CPU) > 0 || len(function.Requests.Memory) > 0) {
 		yamlContent += "    requests:\n"
 		if len(function.Requests.CPU) > 0 {
@@ -339,10 +423,40 @@ func duplicateFunctionName(functionName string, appendFile string) error {
 	}
 
 	if _, exists := services.Functions[functionName]; exists {
-		return fmt.Errorf(`
-Function %s already exists in %s file. 
-Cannot have duplicate function names in same yaml file`, functionName, appendFile)
+		return i18n.Errorf(i18n.NewDuplicateFunctionName, functionName, appendFile)
 	}
 
 	return nil
 }
+
+// functionInOtherStacks returns the stack files in dir, other than appendFile,
+// which already have the function. YAML files which are not stack files, or
+// which can not be parsed, are ignored.
+func functionInOtherStacks(functionName, appendFile, dir string) []string {
+	appendPath, _ := filepath.Abs(appendFile)
+
+	others := []string{}
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		for _, match := range matches {
+			if matchPath, _ := filepath.Abs(match); matchPath == appendPath {
+				continue
+			}
+
+			fileBytes, err := ioutil.ReadFile(match)
+			if err != nil {
+				continue
+			}
+
+			services, err := stack.ParseYAMLData(fileBytes, "", "", envsubst)
+			if err != nil || services == nil {
+				continue
+			}
+
+			if _, exists := services.Functions[functionName]; exists {
+				others = append(others, match)
+			}
+		}
+	}
+	return others
+}
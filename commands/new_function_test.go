@@ -4,7 +4,10 @@
 package commands
 
 import (
+	"bytes"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"strings"
@@ -245,6 +248,24 @@ func Test_newFunctionListNoTemplates(t *testing.T) {
 	}
 }
 
+func Test_newFunctionWithoutArgsPrintsHelp(t *testing.T) {
+	resetForTest()
+	list = false
+	language = ""
+
+	var out bytes.Buffer
+	faasCmd.SetOutput(&out)
+	defer faasCmd.SetOutput(nil)
+
+	faasCmd.SetArgs([]string{"new"})
+	if err := faasCmd.Execute(); err != nil {
+		t.Fatalf("want no error, got: %s", err)
+	}
+	if !strings.Contains(out.String(), "Usage:") {
+		t.Fatalf("want the help to be printed, got: %q", out.String())
+	}
+}
+
 func Test_languageNotExists(t *testing.T) {
 	// Download templates
 	templatePullLocalTemplateRepo(t)
@@ -341,6 +362,62 @@ func Test_duplicateFunctionName(t *testing.T) {
 	}
 }
 
+func Test_functionInOtherStacks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "faas-cli-other-stacks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"stack.yml": `version: 1.0
+provider:
+  name: openfaas
+functions:
+  api:
+    lang: go
+    handler: ./api
+    image: api:latest
+`,
+		"backend.yaml": `version: 1.0
+provider:
+  name: openfaas
+functions:
+  worker:
+    lang: go
+    handler: ./worker
+    image: worker:latest
+`,
+		"frontend.yml": `version: 1.0
+provider:
+  name: openfaas
+functions:
+  worker:
+    lang: node
+    handler: ./worker
+    image: worker:latest
+`,
+		"docker-compose.yml": "services:\n  worker:\n    image: worker:latest\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	appendFile := filepath.Join(dir, "stack.yml")
+
+	got := functionInOtherStacks("worker", appendFile, dir)
+	want := []string{filepath.Join(dir, "frontend.yml"), filepath.Join(dir, "backend.yaml")}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+
+	if got := functionInOtherStacks("api", appendFile, dir); len(got) != 0 {
+		t.Fatalf("want the stack which is appended to be ignored, got %v", got)
+	}
+}
+
 func Test_backfillTemplates(t *testing.T) {
 	resetForTest()
 	const functionName = "samplefunc"
@@ -423,3 +500,54 @@ func Test_getPrefixValue_Flag(t *testing.T) {
 		t.Errorf("want %s, got %s", want, val)
 	}
 }
+
+func Test_suggestFunctionName(t *testing.T) {
+	cases := map[string]string{
+		"My_Function":                  "my-function",
+		"api.v2":                       "api-v2",
+		"_private__fn_":                "private-fn",
+		"valid-name":                   "valid-name",
+		"___":                          "",
+		strings.Repeat("a", 70):        strings.Repeat("a", 63),
+		strings.Repeat("a", 62) + "_b": strings.Repeat("a", 62),
+	}
+
+	for name, want := range cases {
+		got := suggestFunctionName(name)
+		if got != want {
+			t.Errorf("%s: want %q, got %q", name, want, got)
+		}
+		if len(got) > 0 && validateFunctionName(got) != nil {
+			t.Errorf("%s: the suggestion %s is not valid", name, got)
+		}
+	}
+}
+
+func Test_fixFunctionName(t *testing.T) {
+	defer func(original func() bool) { stdinIsTerminal = original }(stdinIsTerminal)
+	stdinIsTerminal = func() bool { return false }
+
+	_, err := fixFunctionName("My_Fn", false)
+	if err == nil || !strings.Contains(err.Error(), "try my-fn or pass --fix-name") {
+		t.Fatalf("want the suggestion in the error, got: %v", err)
+	}
+
+	got, err := fixFunctionName("My_Fn", true)
+	if err != nil || got != "my-fn" {
+		t.Fatalf("want my-fn with --fix-name, got %q, %v", got, err)
+	}
+
+	got, err = fixFunctionName("my-fn", false)
+	if err != nil || got != "my-fn" {
+		t.Fatalf("want a valid name to be kept, got %q, %v", got, err)
+	}
+}
+
+func Test_validateFunctionName_Length(t *testing.T) {
+	if err := validateFunctionName(strings.Repeat("a", 63)); err != nil {
+		t.Fatalf("want a name of 63 characters to be valid, got: %s", err)
+	}
+	if err := validateFunctionName(strings.Repeat("a", 64)); err == nil {
+		t.Fatalf("want an error for a name of 64 characters")
+	}
+}
@@ -0,0 +1,299 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/openfaas/faas-cli/output"
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/spf13/cobra"
+)
+
+// certExpiryWarning is how long before its expiry a certificate is reported
+const certExpiryWarning = 14 * 24 * time.Hour
+
+var (
+	pingCount    int
+	pingInterval time.Duration
+	pingTimeout  time.Duration
+)
+
+// pingEndpoints are requested in order on each attempt
+var pingEndpoints = []string{"/healthz", "/system/info"}
+
+// pingResult is the outcome of one request to the gateway
+type pingResult struct {
+	Path   string
+	Status int
+	Err    error
+
+	// Connect and TLSHandshake are zero when a connection was reused
+	Connect      time.Duration
+	TLSHandshake time.Duration
+	FirstByte    time.Duration
+	Total        time.Duration
+
+	Certificate *x509.Certificate
+}
+
+func (r pingResult) ok() bool {
+	return r.Err == nil && r.Status >= 200 && r.Status < 300
+}
+
+func init() {
+	pingCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
+	pingCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	pingCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
+	pingCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in stack.yml file")
+	pingCmd.Flags().IntVarP(&pingCount, "count", "c", 1, "Number of times to request each endpoint")
+	pingCmd.Flags().DurationVar(&pingInterval, "interval", time.Second, "Time to wait between attempts")
+	pingCmd.Flags().DurationVar(&pingTimeout, "timeout", 5*time.Second, "Timeout for each request")
+
+	faasCmd.AddCommand(pingCmd)
+}
+
+var pingCmd = &cobra.Command{
+	Use:   `ping [--gateway GATEWAY_URL] [--count N]`,
+	Short: "Check the connection to the gateway",
+	Long: `Requests /healthz and /system/info from the gateway and prints the status
+and latency of each request, split into the time to connect, to complete the TLS
+handshake and to receive the first byte of the response. For an https gateway
+the expiry of its certificate is printed too.
+
+Each request uses a new connection so that the cost of connecting is measured on
+every attempt, use --count to find intermittent timeouts. The command exits
+non-zero when any request fails, so it can be used as a preflight step in CI.`,
+	Example: `  faas-cli ping
+  faas-cli ping --gateway https://gw.example.com
+  faas-cli ping --count 10 --interval 500ms --timeout 2s`,
+	RunE: runPing,
+}
+
+func runPing(cmd *cobra.Command, args []string) error {
+	if pingCount < 1 {
+		return fmt.Errorf("--count must be at least 1")
+	}
+
+	var yamlGateway string
+	if len(yamlFile) > 0 {
+		services, err := parseStackFile(yamlFile, envsubst)
+		if err == nil && services != nil {
+			yamlGateway = services.Provider.GatewayURL
+		}
+	}
+	gatewayAddress := getGatewayURL(gateway, defaultGateway, yamlGateway, os.Getenv(openFaaSURLEnvironment))
+
+	cliAuth, err := proxy.NewCLIAuth(token, gatewayAddress)
+	if err != nil {
+		return err
+	}
+
+	timeout := pingTimeout
+	transport := GetDefaultCLITransport(tlsInsecure, &timeout)
+	transport.DisableKeepAlives = true
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}
+
+	fmt.Printf("PING %s\n", gatewayAddress)
+
+	results := map[string][]pingResult{}
+	var certificate *x509.Certificate
+	failed := 0
+
+	for attempt := 0; attempt < pingCount; attempt++ {
+		if attempt > 0 {
+			time.Sleep(pingInterval)
+		}
+
+		for _, path := range pingEndpoints {
+			result := pingGateway(client, cliAuth, gatewayAddress, path)
+			fmt.Println(formatPingResult(result))
+
+			if !result.ok() {
+				failed++
+			}
+			if result.Certificate != nil {
+				certificate = result.Certificate
+			}
+			results[path] = append(results[path], result)
+		}
+	}
+
+	if pingCount > 1 {
+		fmt.Println()
+		for _, path := range pingEndpoints {
+			fmt.Println(summarisePing(path, results[path]))
+		}
+	}
+
+	if certificate != nil {
+		message, expiring := describeCertificate(certificate, time.Now())
+		fmt.Println()
+		if expiring {
+			fmt.Println(output.Warning("%s", message))
+		} else {
+			fmt.Println(message)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d requests to %s failed", failed, pingCount*len(pingEndpoints), gatewayAddress)
+	}
+	return nil
+}
+
+// pingGateway requests a path from the gateway and times each phase of the request
+func pingGateway(client *http.Client, auth proxy.ClientAuth, gatewayAddress, path string) pingResult {
+	result := pingResult{Path: path}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(gatewayAddress, "/")+path, nil)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if err := auth.Set(req); err != nil {
+		result.Err = err
+		return result
+	}
+	if err := proxy.SetGatewayHeaders(req, gatewayAddress); err != nil {
+		result.Err = err
+		return result
+	}
+
+	var start, connectStart, tlsStart time.Time
+	trace := &httptrace.ClientTrace{
+		ConnectStart: func(network, addr string) {
+			connectStart = time.Now()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			if !connectStart.IsZero() {
+				result.Connect = time.Since(connectStart)
+			}
+		},
+		TLSHandshakeStart: func() {
+			tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			if !tlsStart.IsZero() {
+				result.TLSHandshake = time.Since(tlsStart)
+			}
+		},
+		GotFirstResponseByte: func() {
+			result.FirstByte = time.Since(start)
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	start = time.Now()
+	res, err := client.Do(req)
+	if err != nil {
+		result.Total = time.Since(start)
+		result.Err = err
+		return result
+	}
+	ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	result.Total = time.Since(start)
+
+	result.Status = res.StatusCode
+	if res.TLS != nil && len(res.TLS.PeerCertificates) > 0 {
+		result.Certificate = res.TLS.PeerCertificates[0]
+	}
+	return result
+}
+
+func formatPingResult(result pingResult) string {
+	if result.Err != nil {
+		return fmt.Sprintf("%-14s %s after %s", result.Path, output.Failure("%s", result.Err), roundLatency(result.Total))
+	}
+
+	timings := []string{}
+	if result.Connect > 0 {
+		timings = append(timings, "connect "+roundLatency(result.Connect).String())
+	}
+	if result.TLSHandshake > 0 {
+		timings = append(timings, "tls "+roundLatency(result.TLSHandshake).String())
+	}
+	timings = append(timings, "first byte "+roundLatency(result.FirstByte).String())
+
+	status := fmt.Sprintf("%d", result.Status)
+	switch {
+	case result.Status == http.StatusUnauthorized:
+		status = output.Failure("%s unauthorized, run \"faas-cli login\" or pass --token", status)
+	case !result.ok():
+		status = output.Failure("%s", status)
+	}
+
+	return fmt.Sprintf("%-14s %s time=%s (%s)", result.Path, status, roundLatency(result.Total), strings.Join(timings, ", "))
+}
+
+// summarisePing gives the number of successful requests and their min, avg and max latency
+func summarisePing(path string, results []pingResult) string {
+	var min, max, sum time.Duration
+	ok := 0
+	for _, result := range results {
+		if !result.ok() {
+			continue
+		}
+		if ok == 0 || result.Total < min {
+			min = result.Total
+		}
+		if result.Total > max {
+			max = result.Total
+		}
+		sum += result.Total
+		ok++
+	}
+
+	if ok == 0 {
+		return fmt.Sprintf("%-14s 0/%d ok", path, len(results))
+	}
+
+	avg := sum / time.Duration(ok)
+	return fmt.Sprintf("%-14s %d/%d ok, min/avg/max = %s/%s/%s", path, ok, len(results),
+		roundLatency(min), roundLatency(avg), roundLatency(max))
+}
+
+// describeCertificate gives the subject, issuer and expiry of a certificate and
+// whether it expires within certExpiryWarning
+func describeCertificate(cert *x509.Certificate, now time.Time) (string, bool) {
+	remaining := cert.NotAfter.Sub(now)
+
+	var expiry string
+	switch {
+	case remaining <= 0:
+		expiry = fmt.Sprintf("expired on %s", cert.NotAfter.UTC().Format(time.RFC3339))
+	default:
+		expiry = fmt.Sprintf("expires on %s (in %d days)", cert.NotAfter.UTC().Format(time.RFC3339), int(remaining.Hours()/24))
+	}
+
+	subject := cert.Subject.CommonName
+	if len(subject) == 0 && len(cert.DNSNames) > 0 {
+		subject = cert.DNSNames[0]
+	}
+
+	message := fmt.Sprintf("TLS certificate for %s issued by %s %s", subject, cert.Issuer.CommonName, expiry)
+	if len(cert.Issuer.CommonName) == 0 {
+		message = fmt.Sprintf("TLS certificate for %s %s", subject, expiry)
+	}
+	return message, remaining < certExpiryWarning
+}
+
+func roundLatency(d time.Duration) time.Duration {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond)
+	}
+	return d.Round(100 * time.Microsecond)
+}
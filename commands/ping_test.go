@@ -0,0 +1,151 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas-cli/test"
+)
+
+func Test_pingGateway_timesRequestAndReadsCertificate(t *testing.T) {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer s.Close()
+
+	result := pingGateway(s.Client(), noAuth{}, s.URL, "/healthz")
+
+	if !result.ok() {
+		t.Fatalf("want ok result, got status: %d, error: %v", result.Status, result.Err)
+	}
+	if result.Total <= 0 || result.FirstByte <= 0 || result.Connect <= 0 || result.TLSHandshake <= 0 {
+		t.Errorf("want all timings to be measured, got: %+v", result)
+	}
+	if result.Certificate == nil {
+		t.Errorf("want the gateway's certificate")
+	}
+}
+
+func Test_pingGateway_connectionRefused(t *testing.T) {
+	s := httptest.NewServer(http.NotFoundHandler())
+	address := s.URL
+	s.Close()
+
+	result := pingGateway(&http.Client{Timeout: time.Second}, noAuth{}, address, "/healthz")
+	if result.ok() || result.Err == nil {
+		t.Fatalf("want an error for a closed server, got: %+v", result)
+	}
+}
+
+func Test_runPing_failsWhenUnauthorized(t *testing.T) {
+	resetForTest()
+	defer func() {
+		pingCount = 1
+	}()
+
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodGet,
+			Uri:                "/healthz",
+			ResponseStatusCode: http.StatusOK,
+		},
+		test.UnauthorizedRequest(),
+	})
+	defer s.Close()
+
+	var err error
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{
+			"ping",
+			"--gateway=" + s.URL,
+			"--count=1",
+		})
+		err = faasCmd.Execute()
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 requests") {
+		t.Fatalf("want 1 of 2 requests to fail, got: %v", err)
+	}
+	if !strings.Contains(stdOut, "/healthz") || !strings.Contains(stdOut, "unauthorized") {
+		t.Errorf("want the result of each request, got:\n%s", stdOut)
+	}
+}
+
+func Test_summarisePing(t *testing.T) {
+	results := []pingResult{
+		{Path: "/healthz", Status: 200, Total: 10 * time.Millisecond},
+		{Path: "/healthz", Status: 200, Total: 30 * time.Millisecond},
+		{Path: "/healthz", Status: 502, Total: time.Millisecond},
+	}
+
+	got := summarisePing("/healthz", results)
+	want := "/healthz       2/3 ok, min/avg/max = 10ms/20ms/30ms"
+	if got != want {
+		t.Errorf("want: %q, got: %q", want, got)
+	}
+}
+
+func Test_describeCertificate(t *testing.T) {
+	now := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name         string
+		notAfter     time.Time
+		wantMessage  string
+		wantExpiring bool
+	}{
+		{
+			name:        "valid",
+			notAfter:    now.Add(90 * 24 * time.Hour),
+			wantMessage: "TLS certificate for gw.example.com issued by R3 expires on 2020-12-30T00:00:00Z (in 90 days)",
+		},
+		{
+			name:         "expiring soon",
+			notAfter:     now.Add(3 * 24 * time.Hour),
+			wantMessage:  "TLS certificate for gw.example.com issued by R3 expires on 2020-10-04T00:00:00Z (in 3 days)",
+			wantExpiring: true,
+		},
+		{
+			name:         "expired",
+			notAfter:     now.Add(-time.Hour),
+			wantMessage:  "TLS certificate for gw.example.com issued by R3 expired on 2020-09-30T23:00:00Z",
+			wantExpiring: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			cert := &x509.Certificate{
+				Subject:  pkix.Name{CommonName: "gw.example.com"},
+				Issuer:   pkix.Name{CommonName: "R3"},
+				NotAfter: testCase.notAfter,
+			}
+
+			message, expiring := describeCertificate(cert, now)
+			if message != testCase.wantMessage {
+				t.Errorf("want message: %q, got: %q", testCase.wantMessage, message)
+			}
+			if expiring != testCase.wantExpiring {
+				t.Errorf("want expiring: %t, got: %t", testCase.wantExpiring, expiring)
+			}
+		})
+	}
+}
+
+type noAuth struct{}
+
+func (noAuth) Set(req *http.Request) error {
+	return nil
+}
@@ -5,7 +5,11 @@ package commands
 
 import (
 	"fmt"
+	"os"
 	"strings"
+
+	"github.com/openfaas/faas-cli/config"
+	"github.com/openfaas/faas-cli/output"
 )
 
 const (
@@ -14,6 +18,35 @@ const (
 	templateStoreURLEnvironment = "OPENFAAS_TEMPLATE_STORE_URL"
 )
 
+// lookupConfigSettings reads the settings from the config file, which apply
+// after flags, stack.yml and environment variables but before the defaults
+var lookupConfigSettings = config.LookupSettings
+
+// commandSettings are the settings from the config file once they have been
+// read for the command, so that the file is only read and warned about once
+var commandSettings *config.Resolved
+
+// configSettings returns the settings from the config file, they are ignored
+// with a warning when the file is not valid
+func configSettings() config.Resolved {
+	if commandSettings != nil {
+		return *commandSettings
+	}
+
+	settings, err := lookupConfigSettings()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, output.Warning("ignoring the settings in the config file: %s", err))
+		settings = config.Resolved{}
+	}
+	commandSettings = &settings
+	return settings
+}
+
+// resetConfigSettings makes configSettings read the config file again
+func resetConfigSettings() {
+	commandSettings = nil
+}
+
 func getGatewayURL(argumentURL, defaultURL, yamlURL, environmentURL string) string {
 	var gatewayURL string
 
@@ -23,6 +56,8 @@ func getGatewayURL(argumentURL, defaultURL, yamlURL, environmentURL string) stri
 		gatewayURL = yamlURL
 	} else if len(environmentURL) > 0 {
 		gatewayURL = environmentURL
+	} else if configURL := configSettings().Gateway; len(configURL) > 0 {
+		gatewayURL = configURL
 	} else {
 		gatewayURL = defaultURL
 	}
@@ -32,6 +67,7 @@ func getGatewayURL(argumentURL, defaultURL, yamlURL, environmentURL string) stri
 		gatewayURL = fmt.Sprintf("http://%s", gatewayURL)
 	}
 
+	sshTunnels.addGateway(gatewayURL)
 	return gatewayURL
 }
 
@@ -42,6 +78,8 @@ func getTemplateURL(argumentURL, environmentURL, defaultURL string) string {
 		templateURL = argumentURL
 	} else if len(environmentURL) > 0 {
 		templateURL = environmentURL
+	} else if configURL := configSettings().TemplateRepository; len(configURL) > 0 {
+		templateURL = configURL
 	} else {
 		templateURL = defaultURL
 	}
@@ -54,6 +92,8 @@ func getTemplateStoreURL(argumentURL, environmentURL, defaultURL string) string
 		return argumentURL
 	} else if len(environmentURL) > 0 {
 		return environmentURL
+	} else if configURL := configSettings().TemplateStore; len(configURL) > 0 {
+		return configURL
 	} else {
 		return defaultURL
 	}
@@ -69,6 +109,10 @@ func getNamespace(flagNamespace, stackNamespace string) string {
 		return stackNamespace
 	}
 
+	if configNamespace := configSettings().Namespace; len(configNamespace) > 0 {
+		return configNamespace
+	}
+
 	return defaultFunctionNamespace
 
 }
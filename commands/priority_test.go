@@ -1,6 +1,11 @@
 package commands
 
-import "testing"
+import (
+	"fmt"
+	"testing"
+
+	"github.com/openfaas/faas-cli/config"
+)
 
 func Test_getTemplateStoreURL(t *testing.T) {
 	tests := []struct {
@@ -99,3 +104,63 @@ func Test_getOverrideNamespace(t *testing.T) {
 		})
 	}
 }
+
+func Test_configSettingsPriority(t *testing.T) {
+	defer resetConfigSettings()
+	defer func(lookup func() (config.Resolved, error)) { lookupConfigSettings = lookup }(lookupConfigSettings)
+	resetConfigSettings()
+	lookupConfigSettings = func() (config.Resolved, error) {
+		return config.Resolved{
+			Gateway:            "https://gw.example.com",
+			Namespace:          "staging-fn",
+			TemplateRepository: "https://github.com/alexellis/templates.git",
+			TemplateStore:      "https://example.com/templates.json",
+		}, nil
+	}
+
+	if got := getGatewayURL("", defaultGateway, "", ""); got != "https://gw.example.com" {
+		t.Errorf("want the gateway from the config file, got %q", got)
+	}
+	if got := getGatewayURL("", defaultGateway, "", "http://127.0.0.1:31112"); got != "http://127.0.0.1:31112" {
+		t.Errorf("want OPENFAAS_URL to take priority over the config file, got %q", got)
+	}
+	if got := getNamespace("", ""); got != "staging-fn" {
+		t.Errorf("want the namespace from the config file, got %q", got)
+	}
+	if got := getNamespace("", "openfaas-fn"); got != "openfaas-fn" {
+		t.Errorf("want stack.yml to take priority over the config file, got %q", got)
+	}
+	if got := getTemplateURL("", "", DefaultTemplateRepository); got != "https://github.com/alexellis/templates.git" {
+		t.Errorf("want the template repository from the config file, got %q", got)
+	}
+	if got := getTemplateStoreURL(DefaultTemplatesStore, "", DefaultTemplatesStore); got != "https://example.com/templates.json" {
+		t.Errorf("want the template store from the config file, got %q", got)
+	}
+
+	resetConfigSettings()
+	lookupConfigSettings = func() (config.Resolved, error) {
+		return config.Resolved{}, fmt.Errorf("invalid value for defaults.gateway")
+	}
+	if got := getGatewayURL("", defaultGateway, "", ""); got != defaultGateway {
+		t.Errorf("want the default gateway when the config file is invalid, got %q", got)
+	}
+}
+
+func Test_configSettings_readOnce(t *testing.T) {
+	defer resetConfigSettings()
+	defer func(lookup func() (config.Resolved, error)) { lookupConfigSettings = lookup }(lookupConfigSettings)
+	resetConfigSettings()
+
+	reads := 0
+	lookupConfigSettings = func() (config.Resolved, error) {
+		reads++
+		return config.Resolved{}, fmt.Errorf("invalid value for defaults.gateway")
+	}
+
+	getGatewayURL("", defaultGateway, "", "")
+	getNamespace("", "")
+	getTemplateURL("", "", DefaultTemplateRepository)
+	if reads != 1 {
+		t.Fatalf("want the config file read once, got %d reads", reads)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Phases which are timed for --profile
+const (
+	phaseTemplates = "templates"
+	phaseBuild     = "build"
+	phasePush      = "push"
+	phaseGateway   = "gateway"
+)
+
+// profilePhases is the order in which the phases are reported
+var profilePhases = []string{phaseTemplates, phaseBuild, phasePush, phaseGateway}
+
+// profileTimings prints where the time went at the end of build and up
+var profileTimings bool
+
+// profileSpan is the time taken by one step, i.e. the build of a function
+type profileSpan struct {
+	Phase    string
+	Name     string
+	Duration time.Duration
+	// Err is why the step failed, for --junit
+	Err error
+}
+
+// profiler records the time taken by each step, steps may run in parallel
+type profiler struct {
+	mu    sync.Mutex
+	start time.Time
+	spans []profileSpan
+}
+
+// timings is shared by the commands which build, push and deploy so that up
+// reports them together
+var timings = &profiler{}
+
+// track starts timing a step, call the returned func when the step ends
+func (p *profiler) track(phase, name string) func() {
+	done := p.trackResult(phase, name)
+	return func() { done(nil) }
+}
+
+// trackResult starts timing a step which can fail, call the returned func
+// with the error of the step, if any, when it ends
+func (p *profiler) trackResult(phase, name string) func(error) {
+	start := time.Now()
+
+	p.mu.Lock()
+	if p.start.IsZero() {
+		p.start = start
+	}
+	p.mu.Unlock()
+
+	return func(err error) {
+		p.add(profileSpan{Phase: phase, Name: name, Duration: time.Since(start), Err: err})
+	}
+}
+
+func (p *profiler) add(span profileSpan) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.spans = append(p.spans, span)
+}
+
+// report writes each step and the total for each phase, the total of a phase
+// can be longer than the wall time when its steps ran in parallel
+func (p *profiler) report(w io.Writer, wall time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.spans) == 0 {
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nPHASE\tSTEP\tTIME")
+
+	totals := map[string]time.Duration{}
+	for _, phase := range profilePhases {
+		for _, span := range p.spans {
+			if span.Phase != phase {
+				continue
+			}
+			totals[phase] += span.Duration
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", span.Phase, span.Name, roundProfile(span.Duration))
+		}
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	for _, phase := range profilePhases {
+		if total, ok := totals[phase]; ok {
+			fmt.Fprintf(w, "%-10s %s\n", phase, roundProfile(total))
+		}
+	}
+	fmt.Fprintf(w, "%-10s %s\n", "wall", roundProfile(wall))
+}
+
+// reportProfile prints the timings when --profile is given
+func reportProfile(w io.Writer) {
+	if !profileTimings {
+		return
+	}
+
+	timings.mu.Lock()
+	start := timings.start
+	timings.mu.Unlock()
+	if start.IsZero() {
+		return
+	}
+
+	timings.report(w, time.Since(start))
+}
+
+func roundProfile(d time.Duration) time.Duration {
+	if d < time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(10 * time.Millisecond)
+}
@@ -0,0 +1,63 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_profiler_report(t *testing.T) {
+	p := &profiler{}
+	p.add(profileSpan{Phase: phaseGateway, Name: "deploy fn1", Duration: 250 * time.Millisecond})
+	p.add(profileSpan{Phase: phaseBuild, Name: "fn1", Duration: 12345 * time.Millisecond})
+	p.add(profileSpan{Phase: phaseBuild, Name: "fn2", Duration: 3 * time.Second})
+	p.add(profileSpan{Phase: phaseTemplates, Name: "https://github.com/openfaas/templates.git", Duration: 1500 * time.Millisecond})
+
+	var out bytes.Buffer
+	p.report(&out, 14*time.Second)
+	got := out.String()
+
+	order := []string{"templates  https://github.com/openfaas/templates.git  1.5s", "build      fn1", "build      fn2", "gateway    deploy fn1"}
+	last := -1
+	for _, want := range order {
+		index := strings.Index(got, want)
+		if index < 0 {
+			t.Fatalf("want %q in the report, got:\n%s", want, got)
+		}
+		if index < last {
+			t.Errorf("want %q to be reported in the order of the phases, got:\n%s", want, got)
+		}
+		last = index
+	}
+
+	for _, want := range []string{"build      15.35s\n", "wall       14s\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want %q in the report, got:\n%s", want, got)
+		}
+	}
+}
+
+func Test_profiler_track(t *testing.T) {
+	p := &profiler{}
+	done := p.track(phasePush, "fn1")
+	done()
+
+	if len(p.spans) != 1 || p.spans[0].Phase != phasePush || p.spans[0].Name != "fn1" {
+		t.Errorf("want one push span for fn1, got %v", p.spans)
+	}
+	if p.start.IsZero() {
+		t.Errorf("want the start of the first step to be recorded")
+	}
+}
+
+func Test_reportProfile_Disabled(t *testing.T) {
+	var out bytes.Buffer
+	reportProfile(&out)
+	if out.Len() > 0 {
+		t.Errorf("want no report without --profile, got:\n%s", out.String())
+	}
+}
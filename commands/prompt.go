@@ -0,0 +1,91 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/docker/docker/pkg/term"
+)
+
+var (
+	// assumeYes answers yes to every confirmation
+	assumeYes bool
+
+	// nonInteractive turns every prompt into an error, as when stdin is not a terminal
+	nonInteractive bool
+)
+
+// errNonInteractive is returned by a prompt which cannot be shown
+var errNonInteractive = errors.New("cannot prompt for input when --non-interactive is set or stdin is not a terminal")
+
+// stdinIsTerminal is a variable so that tests can act as a terminal
+var stdinIsTerminal = func() bool {
+	return term.IsTerminal(os.Stdin.Fd())
+}
+
+// promptReader reads answers to prompts, it is shared so that input which
+// was read ahead for one prompt is available to the next
+var promptReader = bufio.NewReader(os.Stdin)
+
+// interactive is true when prompts can be shown
+func interactive() bool {
+	return !nonInteractive && stdinIsTerminal()
+}
+
+// confirm asks a yes or no question, --yes answers it without asking and
+// errNonInteractive is returned when it cannot be asked
+func confirm(question string) (bool, error) {
+	if assumeYes {
+		return true, nil
+	}
+	if !interactive() {
+		return false, errNonInteractive
+	}
+
+	fmt.Printf("%s [y/N]: ", question)
+	answer, err := readPromptLine()
+	if err != nil {
+		return false, err
+	}
+
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// promptPassword reads a value without echoing it to the terminal
+func promptPassword(prompt string) (string, error) {
+	if !interactive() {
+		return "", errNonInteractive
+	}
+
+	fmt.Print(prompt)
+	fd := os.Stdin.Fd()
+	if term.IsTerminal(fd) {
+		if state, err := term.SaveState(fd); err == nil {
+			if err := term.DisableEcho(fd, state); err == nil {
+				defer term.RestoreTerminal(fd, state)
+			}
+		}
+		defer fmt.Println()
+	}
+
+	return readPromptLine()
+}
+
+func readPromptLine() (string, error) {
+	line, err := promptReader.ReadString('\n')
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,71 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_confirm(t *testing.T) {
+	defer func(original func() bool, reader *bufio.Reader) {
+		stdinIsTerminal = original
+		promptReader = reader
+		assumeYes = false
+		nonInteractive = false
+	}(stdinIsTerminal, promptReader)
+
+	cases := []struct {
+		name           string
+		terminal       bool
+		assumeYes      bool
+		nonInteractive bool
+		input          string
+		want           bool
+		wantErr        error
+	}{
+		{name: "answered yes", terminal: true, input: "y\n", want: true},
+		{name: "answered YES", terminal: true, input: "YES\n", want: true},
+		{name: "answered no", terminal: true, input: "n\n", want: false},
+		{name: "empty answer is no", terminal: true, input: "\n", want: false},
+		{name: "--yes answers without a terminal", assumeYes: true, want: true},
+		{name: "--yes wins over --non-interactive", terminal: true, assumeYes: true, nonInteractive: true, want: true},
+		{name: "no terminal", wantErr: errNonInteractive},
+		{name: "--non-interactive with a terminal", terminal: true, nonInteractive: true, input: "y\n", wantErr: errNonInteractive},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stdinIsTerminal = func() bool { return tc.terminal }
+			promptReader = bufio.NewReader(strings.NewReader(tc.input))
+			assumeYes = tc.assumeYes
+			nonInteractive = tc.nonInteractive
+
+			got, err := confirm("Overwrite?")
+
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("want error: %v, got: %v", tc.wantErr, err)
+			}
+			if got != tc.want {
+				t.Errorf("want: %t, got: %t", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_promptPassword_nonInteractive(t *testing.T) {
+	defer func(original func() bool) {
+		stdinIsTerminal = original
+		nonInteractive = false
+	}(stdinIsTerminal)
+
+	stdinIsTerminal = func() bool { return true }
+	nonInteractive = true
+
+	if _, err := promptPassword("Password: "); !errors.Is(err, errNonInteractive) {
+		t.Fatalf("want: %s, got: %v", errNonInteractive, err)
+	}
+}
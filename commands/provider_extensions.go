@@ -0,0 +1,97 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/openfaas/faas-cli/stack"
+)
+
+// providerExtensionHandler applies the settings from provider.extensions to
+// a function before it is deployed
+type providerExtensionHandler func(extension stack.ProviderExtension, spec *proxy.DeployFunctionSpec) error
+
+// providerExtensionHandlers are keyed by the orchestration which the gateway
+// reports, the settings for an orchestration without one are ignored
+var providerExtensionHandlers = map[string]providerExtensionHandler{
+	"kubernetes": applyKubernetesExtension,
+}
+
+// resolveProviderExtension picks the settings from provider.extensions for
+// the gateway's orchestration and the handler which applies them. The
+// handler is nil when there is nothing to apply, and a warning is returned
+// when the settings would be ignored.
+func resolveProviderExtension(provider stack.Provider, orchestration string) (providerExtensionHandler, stack.ProviderExtension, string) {
+	extension := provider.Extension(orchestration)
+	if len(extension) == 0 {
+		return nil, nil, ""
+	}
+
+	handler, ok := providerExtensionHandlers[orchestration]
+	if !ok {
+		return nil, nil, fmt.Sprintf("provider.extensions for %s are not used by this version of faas-cli: %s",
+			orchestration, strings.Join(extension.Keys(), ", "))
+	}
+	return handler, extension, ""
+}
+
+// applyProviderExtension applies the settings for the gateway's provider to
+// a function
+func applyProviderExtension(handler providerExtensionHandler, extension stack.ProviderExtension, spec *proxy.DeployFunctionSpec) error {
+	if handler == nil {
+		return nil
+	}
+	if err := handler(extension, spec); err != nil {
+		return fmt.Errorf("[%s] %s", spec.FunctionName, err)
+	}
+	return nil
+}
+
+// applyKubernetesExtension gives each function the service_account and
+// node_selector from provider.extensions.kubernetes, unless the function's
+// k8s block, annotations or constraints already set them
+func applyKubernetesExtension(extension stack.ProviderExtension, spec *proxy.DeployFunctionSpec) error {
+	serviceAccount, err := extension.String("service_account")
+	if err != nil {
+		return err
+	}
+	nodeSelector, err := extension.StringMap("node_selector")
+	if err != nil {
+		return err
+	}
+
+	defaults := &stack.KubernetesOptions{ServiceAccount: serviceAccount, NodeSelector: nodeSelector}
+	annotations, err := defaults.Annotations()
+	if err != nil {
+		return err
+	}
+	constraints, err := defaults.Constraints()
+	if err != nil {
+		return err
+	}
+
+	for k, v := range annotations {
+		if _, ok := spec.Annotations[k]; ok {
+			continue
+		}
+		if spec.Annotations == nil {
+			spec.Annotations = map[string]string{}
+		}
+		spec.Annotations[k] = v
+	}
+
+	set := map[string]bool{}
+	for _, constraint := range spec.Constraints {
+		set[stack.ConstraintKey(constraint)] = true
+	}
+	for _, constraint := range constraints {
+		if !set[stack.ConstraintKey(constraint)] {
+			spec.Constraints = append(spec.Constraints, constraint)
+		}
+	}
+	return nil
+}
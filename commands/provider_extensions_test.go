@@ -0,0 +1,121 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/openfaas/faas-cli/stack"
+)
+
+func Test_resolveProviderExtension(t *testing.T) {
+	provider := stack.Provider{
+		Extensions: map[string]stack.ProviderExtension{
+			"kubernetes": {"serviceAccount": "fn-runner"},
+			"faasd":      {"socket": "/run/faasd/faasd.sock"},
+		},
+	}
+
+	defer func(handlers map[string]providerExtensionHandler) { providerExtensionHandlers = handlers }(providerExtensionHandlers)
+	providerExtensionHandlers = map[string]providerExtensionHandler{
+		"kubernetes": func(extension stack.ProviderExtension, spec *proxy.DeployFunctionSpec) error {
+			serviceAccount, _ := extension.String("serviceAccount")
+			spec.Annotations = map[string]string{"serviceAccount": serviceAccount}
+			return nil
+		},
+	}
+
+	cases := []struct {
+		name          string
+		orchestration string
+		wantHandler   bool
+		wantWarning   string
+	}{
+		{name: "handler for the provider", orchestration: "kubernetes", wantHandler: true},
+		{name: "no handler for the provider", orchestration: "containerd", wantWarning: "not used by this version of faas-cli: socket"},
+		{name: "no extensions for the provider", orchestration: "swarm"},
+		{name: "unknown provider", orchestration: ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, extension, warning := resolveProviderExtension(provider, tc.orchestration)
+			if (handler != nil) != tc.wantHandler {
+				t.Fatalf("want handler: %t, got: %t", tc.wantHandler, handler != nil)
+			}
+			if (len(tc.wantWarning) == 0) != (len(warning) == 0) || !strings.Contains(warning, tc.wantWarning) {
+				t.Fatalf("want warning %q, got %q", tc.wantWarning, warning)
+			}
+
+			spec := &proxy.DeployFunctionSpec{FunctionName: "fn1"}
+			if err := applyProviderExtension(handler, extension, spec); err != nil {
+				t.Fatalf("want no error, got %s", err)
+			}
+			if tc.wantHandler && spec.Annotations["serviceAccount"] != "fn-runner" {
+				t.Fatalf("want the extension applied, got %v", spec.Annotations)
+			}
+		})
+	}
+}
+
+func Test_applyProviderExtension_error(t *testing.T) {
+	handler := func(extension stack.ProviderExtension, spec *proxy.DeployFunctionSpec) error {
+		return fmt.Errorf("bad setting")
+	}
+
+	err := applyProviderExtension(handler, stack.ProviderExtension{}, &proxy.DeployFunctionSpec{FunctionName: "fn1"})
+	if err == nil || err.Error() != "[fn1] bad setting" {
+		t.Fatalf("want [fn1] bad setting, got %v", err)
+	}
+}
+
+func Test_applyKubernetesExtension(t *testing.T) {
+	extension := stack.ProviderExtension{
+		"service_account": "fn-runner",
+		"node_selector":   map[interface{}]interface{}{"disktype": "ssd", "zone": "eu-west-1a"},
+	}
+
+	cases := []struct {
+		name            string
+		spec            proxy.DeployFunctionSpec
+		wantAccount     string
+		wantConstraints []string
+	}{
+		{
+			name:            "defaults are applied",
+			spec:            proxy.DeployFunctionSpec{FunctionName: "fn1"},
+			wantAccount:     "fn-runner",
+			wantConstraints: []string{"disktype=ssd", "zone=eu-west-1a"},
+		},
+		{
+			name: "the function's settings are kept",
+			spec: proxy.DeployFunctionSpec{
+				FunctionName: "fn1",
+				Annotations:  map[string]string{stack.ServiceAccountAnnotation: "s3-reader"},
+				Constraints:  []string{"disktype=hdd"},
+			},
+			wantAccount:     "s3-reader",
+			wantConstraints: []string{"disktype=hdd", "zone=eu-west-1a"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := tc.spec
+			if err := applyKubernetesExtension(extension, &spec); err != nil {
+				t.Fatalf("want no error, got %s", err)
+			}
+			if got := spec.Annotations[stack.ServiceAccountAnnotation]; got != tc.wantAccount {
+				t.Fatalf("want service account %s, got %s", tc.wantAccount, got)
+			}
+			if !reflect.DeepEqual(spec.Constraints, tc.wantConstraints) {
+				t.Fatalf("want constraints %v, got %v", tc.wantConstraints, spec.Constraints)
+			}
+		})
+	}
+}
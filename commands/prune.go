@@ -0,0 +1,205 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/openfaas/faas-cli/stack"
+	types "github.com/openfaas/faas-provider/types"
+	"github.com/spf13/cobra"
+)
+
+// pruneProtectedLabel keeps a function on the gateway when it is set to
+// true, even when it is not in the stack file
+const pruneProtectedLabel = "com.openfaas.protected"
+
+var pruneDryRun bool
+
+func init() {
+	pruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "List the functions which would be removed without removing them")
+	pruneCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
+	pruneCmd.Flags().StringVarP(&functionNamespace, "namespace", "n", "", "Only prune this namespace, instead of each namespace used by the stack file")
+	pruneCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	pruneCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in stack.yml file")
+	pruneCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
+
+	faasCmd.AddCommand(pruneCmd)
+}
+
+var pruneCmd = &cobra.Command{
+	Use:   `prune -f YAML_FILE [--dry-run] [--namespace NAMESPACE]`,
+	Short: "Remove functions from the gateway which are not in the stack file",
+	Long: `Removes the functions on the gateway which are not in the stack file, so that
+the gateway matches the stack file. Only the namespaces used by the stack file
+are pruned, or the one given with --namespace. A function with the label
+com.openfaas.protected=true is never removed.
+
+The whole stack file is used, --filter, --regex and --tags are ignored so
+that functions which were not selected are not removed.`,
+	Example: `  faas-cli prune -f stack.yml --dry-run
+  faas-cli prune -f stack.yml --yes
+  faas-cli prune -f stack.yml --namespace staging`,
+	RunE: runPrune,
+}
+
+// pruneTarget is a function on the gateway which is not in the stack file
+type pruneTarget struct {
+	Name      string
+	Namespace string
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	if len(yamlFile) == 0 {
+		return fmt.Errorf("give the stack file to keep with -f")
+	}
+
+	selector, err := stack.NewSelector(stack.SelectorFlags{})
+	if err != nil {
+		return err
+	}
+	services, err := parseStackProfile(stackFiles(), selector, envsubst, envProfile)
+	if err != nil {
+		return err
+	}
+	if len(services.Functions) == 0 {
+		return fmt.Errorf("there are no functions in %s, refusing to remove every function from the gateway", yamlFile)
+	}
+
+	gatewayAddress := getGatewayURL(gateway, defaultGateway, services.Provider.GatewayURL, os.Getenv(openFaaSURLEnvironment))
+
+	cliAuth, err := proxy.NewCLIAuth(token, gatewayAddress)
+	if err != nil {
+		return err
+	}
+	transport := GetDefaultCLITransport(tlsInsecure, &commandTimeout)
+	proxyClient, err := proxy.NewClient(cliAuth, gatewayAddress, transport, &commandTimeout)
+	if err != nil {
+		return err
+	}
+	proxyClient.CallID = requestID
+
+	ctx := context.Background()
+	targets, err := findPruneTargets(ctx, proxyClient, services, functionNamespace)
+	if err != nil {
+		return err
+	}
+
+	if len(targets) == 0 {
+		fmt.Printf("Every function on %s is in %s, there is nothing to prune.\n", gatewayAddress, yamlFile)
+		return nil
+	}
+
+	for _, target := range targets {
+		fmt.Printf("- %s\n", appliedKey(target.Name, target.Namespace))
+	}
+
+	if pruneDryRun {
+		fmt.Printf("%d functions would be removed from %s.\n", len(targets), gatewayAddress)
+		return nil
+	}
+
+	ok, err := confirm(fmt.Sprintf("Remove these %d functions from %s?", len(targets), gatewayAddress))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+
+	failed := 0
+	for _, target := range targets {
+		fmt.Printf("Deleting: %s\n", appliedKey(target.Name, target.Namespace))
+		if err := proxyClient.DeleteFunction(ctx, target.Name, target.Namespace); err != nil {
+			fmt.Printf("Unable to delete %s: %s\n", target.Name, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("unable to remove %d of %d functions", failed, len(targets))
+	}
+	return nil
+}
+
+// findPruneTargets lists the functions in each namespace of the stack file,
+// and returns those which are not in it and are not protected
+func findPruneTargets(ctx context.Context, client *proxy.Client, services *stack.Services, namespace string) ([]pruneTarget, error) {
+	keep := map[string]map[string]bool{}
+	for name, function := range services.Functions {
+		ns := getNamespace(namespace, function.Namespace)
+		if keep[ns] == nil {
+			keep[ns] = map[string]bool{}
+		}
+		keep[ns][deployedFunctionName(name)] = true
+	}
+
+	// Functions without a namespace are deployed to the default namespace of
+	// the gateway, which may also be named in the stack file, so they are
+	// kept along with the functions which name it
+	listed := map[string][]types.FunctionStatus{}
+	if names, ok := keep[""]; ok {
+		functions, err := client.ListFunctions(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+
+		defaultNamespace := ""
+		for _, function := range functions {
+			if len(function.Namespace) > 0 {
+				defaultNamespace = function.Namespace
+				break
+			}
+		}
+		if len(defaultNamespace) > 0 {
+			delete(keep, "")
+			if keep[defaultNamespace] == nil {
+				keep[defaultNamespace] = map[string]bool{}
+			}
+			for name := range names {
+				keep[defaultNamespace][name] = true
+			}
+		}
+		listed[defaultNamespace] = functions
+	}
+
+	namespaces := []string{}
+	for ns := range keep {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	targets := []pruneTarget{}
+	for _, ns := range namespaces {
+		functions, ok := listed[ns]
+		if !ok {
+			var err error
+			functions, err = client.ListFunctions(ctx, ns)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, function := range functions {
+			if keep[ns][function.Name] {
+				continue
+			}
+			if function.Labels != nil && (*function.Labels)[pruneProtectedLabel] == "true" {
+				continue
+			}
+			targets = append(targets, pruneTarget{Name: function.Name, Namespace: ns})
+		}
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Namespace != targets[j].Namespace {
+			return targets[i].Namespace < targets[j].Namespace
+		}
+		return targets[i].Name < targets[j].Name
+	})
+	return targets, nil
+}
@@ -0,0 +1,92 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/openfaas/faas-cli/test"
+	types "github.com/openfaas/faas-provider/types"
+)
+
+func Test_findPruneTargets(t *testing.T) {
+	protected := map[string]string{pruneProtectedLabel: "true"}
+
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusOK,
+			ResponseBody: []types.FunctionStatus{
+				{Name: "api"},
+				{Name: "old-api"},
+				{Name: "my-fn"},
+				{Name: "ingress", Labels: &protected},
+			},
+		},
+		test.ListFunctionsRequest("staging", "worker", "old-worker"),
+	})
+	defer s.Close()
+
+	services := &stack.Services{
+		Functions: map[string]stack.Function{
+			"api":    {},
+			"My_Fn":  {},
+			"worker": {Namespace: "staging"},
+		},
+	}
+
+	client, err := proxy.NewClient(&noAuth{}, s.URL, nil, &commandTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targets, err := findPruneTargets(context.Background(), client, services, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []pruneTarget{
+		{Name: "old-api"},
+		{Name: "old-worker", Namespace: "staging"},
+	}
+	if !reflect.DeepEqual(targets, want) {
+		t.Fatalf("want %v, got %v", want, targets)
+	}
+}
+
+func Test_findPruneTargets_DefaultNamespaceInStack(t *testing.T) {
+	s := test.MockHttpServer(t, []test.Request{
+		test.ListFunctionsRequest("", "fn-a", "fn-b", "old-fn"),
+	})
+	defer s.Close()
+
+	// fn-a is deployed to the default namespace of the gateway, which fn-b names
+	services := &stack.Services{
+		Functions: map[string]stack.Function{
+			"fn-a": {},
+			"fn-b": {Namespace: "openfaas-fn"},
+		},
+	}
+
+	client, err := proxy.NewClient(&noAuth{}, s.URL, nil, &commandTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targets, err := findPruneTargets(context.Background(), client, services, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []pruneTarget{{Name: "old-fn", Namespace: "openfaas-fn"}}
+	if !reflect.DeepEqual(targets, want) {
+		t.Fatalf("want %v, got %v", want, targets)
+	}
+}
@@ -4,14 +4,15 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"sync"
 	"time"
 
 	v1execute "github.com/alexellis/go-execute/pkg/v1"
-	"github.com/morikuni/aec"
 	"github.com/openfaas/faas-cli/builder"
+	"github.com/openfaas/faas-cli/output"
 	"github.com/openfaas/faas-cli/stack"
 	"github.com/spf13/cobra"
 )
@@ -33,16 +34,27 @@ func init() {
 	publishCmd.Flags().BoolVar(&squash, "squash", false, `Use Docker's squash flag for smaller images [experimental] `)
 	publishCmd.Flags().IntVar(&parallel, "parallel", 1, "Build in parallel to depth specified.")
 	publishCmd.Flags().BoolVar(&shrinkwrap, "shrinkwrap", false, "Just write files to ./build/ folder for shrink-wrapping")
+	publishCmd.Flags().StringVar(&logDir, "log-dir", "", "Write the output of the container engine for each function to a file in this folder, i.e. ./logs")
+	publishCmd.Flags().BoolVar(&reproducible, "reproducible", false, "Build images which are the same for the same inputs, using SOURCE_DATE_EPOCH or the time of the latest Git commit for every timestamp")
+	publishCmd.Flags().BoolVar(&keepBuildDir, "keep-build-dir", false, "Keep the build folder of each function under ./build/ after it is built")
 	publishCmd.Flags().StringArrayVarP(&buildArgs, "build-arg", "b", []string{}, "Add a build-arg for Docker (KEY=VALUE)")
 	publishCmd.Flags().StringArrayVarP(&buildOptions, "build-option", "o", []string{}, "Set a build option, e.g. dev")
 	publishCmd.Flags().Var(&tagFormat, "tag", "Override latest tag on function Docker image, accepts 'latest', 'sha', 'branch', or 'describe'")
 	publishCmd.Flags().StringArrayVar(&buildLabels, "build-label", []string{}, "Add a label for Docker image (LABEL=VALUE)")
+	publishCmd.Flags().BoolVar(&requireCleanGit, "require-clean-git", false, "Fail when the Git working tree has changes which are not committed, instead of warning and labelling the images dirty=true")
 	publishCmd.Flags().StringArrayVar(&copyExtra, "copy-extra", []string{}, "Extra paths that will be copied into the function build context")
 	publishCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in stack.yml file")
 	publishCmd.Flags().BoolVar(&quietBuild, "quiet", false, "Perform a quiet build, without showing output from Docker")
 	publishCmd.Flags().BoolVar(&disableStackPull, "disable-stack-pull", false, "Disables the template configuration in the stack.yml")
 	publishCmd.Flags().StringVar(&platforms, "platforms", "linux/amd64", "A set of platforms to publish")
 	publishCmd.Flags().StringArrayVar(&extraTags, "extra-tag", []string{}, "Additional extra image tag")
+	publishCmd.Flags().StringArrayVar(&cacheFrom, "cache-from", []string{}, "External cache source for the build, {{ name }} is the name of the function, e.g. type=registry,ref=user/{{ name }}:cache")
+	publishCmd.Flags().StringVar(&containerEngine, "container-engine", "", "Build and push with docker, podman or nerdctl, the first which is installed is used by default")
+	publishCmd.Flags().StringVar(&dockerContext, "docker-context", "", "Docker context to use instead of DOCKER_HOST, DOCKER_CONTEXT or the current context, i.e. a remote daemon")
+	publishCmd.Flags().BoolVar(&attestProvenance, "attest", false, "Attach a SLSA provenance attestation to each image with cosign, with the hash of its handler and template and the commit it was built from")
+	publishCmd.Flags().StringVar(&imagesFile, "images-file", "", "Write the image and digest of each function to this file for deploy --images-file, i.e. images.json")
+	publishCmd.Flags().StringSliceVar(&skipFunctions, "skip", []string{}, "Names of functions in the stack file to leave out, i.e. fn1,fn2")
+	publishCmd.Flags().StringArrayVar(&cacheTo, "cache-to", []string{}, "Cache export destination for the build, {{ name }} is the name of the function, e.g. type=registry,ref=user/{{ name }}:cache,mode=max")
 
 	// Set bash-completion.
 	_ = publishCmd.Flags().SetAnnotation("handler", cobra.BashCompSubdirsInDir, []string{})
@@ -83,6 +95,8 @@ See also: faas-cli build`,
   faas-cli publish -f go.yml --no-cache --build-arg NPM_VERSION=0.2.2
   faas-cli publish --build-option dev
   faas-cli publish --tag sha
+  faas-cli publish --images-file images.json
+  faas-cli publish --attest
   `,
 	PreRunE: preRunPublish,
 	RunE:    runPublish,
@@ -99,6 +113,13 @@ func preRunPublish(cmd *cobra.Command, args []string) error {
 	}
 
 	buildLabelMap, err = parseMap(buildLabels, "build-label")
+	if err != nil {
+		return err
+	}
+
+	if buildLabelMap, err = checkCleanGit(requireCleanGit, buildLabelMap); err != nil {
+		return err
+	}
 
 	if parallel < 1 {
 		return fmt.Errorf("the --parallel flag must be great than 0")
@@ -108,14 +129,19 @@ func preRunPublish(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("--yaml or -f is required")
 	}
 
-	return err
+	return nil
 }
 
 func runPublish(cmd *cobra.Command, args []string) error {
+	if attestProvenance && !shrinkwrap {
+		if err := checkCosign(); err != nil {
+			return err
+		}
+	}
 
 	var services stack.Services
 	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter, envsubst)
+		parsedServices, err := parseStackFile(yamlFile, envsubst)
 		if err != nil {
 			return err
 		}
@@ -130,9 +156,18 @@ func runPublish(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("could not pull templates for OpenFaaS: %v", pullErr)
 	}
 
+	if err := checkContainerEngine(quietBuild); err != nil {
+		return err
+	}
+
+	command, buildxArgs, err := builder.EngineCommand("buildx", "create", "--use", "--name=multiarch", "--node=multiarch")
+	if err != nil {
+		return err
+	}
+
 	task := v1execute.ExecTask{
-		Command:     "docker",
-		Args:        []string{"buildx", "create", "--use", "--name=multiarch", "--node=multiarch"},
+		Command:     command,
+		Args:        buildxArgs,
 		StreamStdio: !quietBuild,
 		Env:         []string{"DOCKER_CLI_EXPERIMENTAL=enabled"},
 	}
@@ -153,27 +188,56 @@ func runPublish(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("Already pulled templates directory has issue: %s", err.Error())
 		}
 
-		err = pullStackTemplates(newTemplateInfos, cmd)
+		err = pullStackTemplates(os.Stdout, newTemplateInfos)
 		if err != nil {
 			return fmt.Errorf("could not pull templates from function yaml file: %s", err.Error())
 		}
 	}
 
-	errors := publish(&services, parallel, shrinkwrap, quietBuild)
+	builder.KeepBuildDir = keepBuildDir
+	builder.LogDir = logDir
+	if err := setReproducible(reproducible); err != nil {
+		return err
+	}
+	warnUnknownSkips(os.Stdout, &services, skipFunctions)
+	if err := checkSharedCacheTo(&services, cacheTo); err != nil {
+		return err
+	}
+	started := time.Now()
+	digests, errors := publish(&services, parallel, shrinkwrap, quietBuild)
 	if len(errors) > 0 {
 		errorSummary := "Errors received during build:\n"
 		for _, err := range errors {
 			errorSummary = errorSummary + "- " + err.Error() + "\n"
 		}
-		return fmt.Errorf("%s", aec.Apply(errorSummary, aec.RedF))
+		return fmt.Errorf("%s", errorSummary)
+	}
+
+	if shrinkwrap {
+		return nil
+	}
+	if attestProvenance {
+		if err := attestPublishedImages(context.Background(), &services, digests, started, time.Now()); err != nil {
+			return err
+		}
+	}
+	if len(imagesFile) > 0 {
+		return writePublishedImages(&services, digests, imagesFile)
 	}
 	return nil
 }
 
-func publish(services *stack.Services, queueDepth int, shrinkwrap, quietBuild bool) []error {
+// publish builds and pushes the image of each function and returns the digest
+// of each image which was pushed, by the name of its function
+func publish(services *stack.Services, queueDepth int, shrinkwrap, quietBuild bool) (map[string]string, []error) {
 	startOuter := time.Now()
 
 	errors := []error{}
+	digests := map[string]string{}
+	var mu sync.Mutex
+
+	ctx, cancel := interruptContext()
+	defer cancel()
 
 	wg := sync.WaitGroup{}
 
@@ -185,14 +249,16 @@ func publish(services *stack.Services, queueDepth int, shrinkwrap, quietBuild bo
 			for function := range workChannel {
 				start := time.Now()
 
-				fmt.Printf(aec.YellowF.Apply("[%d] > Building %s.\n"), index, function.Name)
+				fmt.Print(output.Info("[%d] > Building %s.\n", index, function.Name))
 				if len(function.Language) == 0 {
 					fmt.Println("Please provide a valid language for your function.")
 				} else {
 					combinedBuildOptions := combineBuildOpts(function.BuildOptions, buildOptions)
 					combinedBuildArgMap := mergeMap(function.BuildArgs, buildArgMap)
 					combinedExtraPaths := mergeSlice(services.StackConfiguration.CopyExtraPaths, copyExtra)
-					err := builder.PublishImage(function.Image,
+					combinedCacheFrom := functionCacheRefs(function.CacheFrom, cacheFrom, function.Name)
+					combinedCacheTo := functionCacheRefs(function.CacheTo, cacheTo, function.Name)
+					digest, err := builder.PublishImageWithDigest(ctx, function.Image,
 						function.Handler,
 						function.Name,
 						function.Language,
@@ -207,26 +273,35 @@ func publish(services *stack.Services, queueDepth int, shrinkwrap, quietBuild bo
 						combinedExtraPaths,
 						platforms,
 						extraTags,
+						combinedCacheFrom,
+						combinedCacheTo,
 					)
 
+					mu.Lock()
 					if err != nil {
 						errors = append(errors, err)
+					} else if len(digest) > 0 {
+						digests[function.Name] = digest
 					}
+					mu.Unlock()
 				}
 
 				duration := time.Since(start)
-				fmt.Printf(aec.YellowF.Apply("[%d] < Building %s done in %1.2fs.\n"), index, function.Name, duration.Seconds())
+				fmt.Print(output.Info("[%d] < Building %s done in %1.2fs.\n", index, function.Name, duration.Seconds()))
 			}
 
-			fmt.Printf(aec.YellowF.Apply("[%d] Worker done.\n"), index)
+			fmt.Print(output.Info("[%d] Worker done.\n", index))
 			wg.Done()
 		}(i)
 
 	}
 
-	for k, function := range services.Functions {
-		if function.SkipBuild {
-			fmt.Printf("Skipping build of: %s.\n", function.Name)
+	for _, k := range services.FunctionNames() {
+		function := services.Functions[k]
+		if function.SkipBuild || skipped(skipFunctions, k) {
+			fmt.Printf("Skipping build of: %s.\n", k)
+		} else if function.Prebuilt() {
+			fmt.Printf("Skipping build of: %s, it uses the prebuilt image %s.\n", k, function.Image)
 		} else {
 			function.Name = k
 			workChannel <- function
@@ -238,6 +313,6 @@ func publish(services *stack.Services, queueDepth int, shrinkwrap, quietBuild bo
 	wg.Wait()
 
 	duration := time.Since(startOuter)
-	fmt.Printf("\n%s\n", aec.Apply(fmt.Sprintf("Total build time: %1.2fs", duration.Seconds()), aec.YellowF))
-	return errors
+	fmt.Printf("\n%s\n", output.Info("Total build time: %1.2fs", duration.Seconds()))
+	return digests, errors
 }
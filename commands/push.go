@@ -5,24 +5,39 @@ package commands
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
 	"strings"
 	"sync"
+	"text/tabwriter"
 
-	"github.com/openfaas/faas-cli/exec"
-
-	"github.com/morikuni/aec"
+	v1execute "github.com/alexellis/go-execute/pkg/v1"
 	"github.com/openfaas/faas-cli/builder"
+	"github.com/openfaas/faas-cli/output"
+	"github.com/openfaas/faas-cli/registry"
 	"github.com/openfaas/faas-cli/schema"
 	"github.com/openfaas/faas-cli/stack"
 	"github.com/spf13/cobra"
 )
 
+// registryParallel is the most images pushed to one registry at once
+var registryParallel int
+
 func init() {
 	faasCmd.AddCommand(pushCmd)
 
 	pushCmd.Flags().IntVar(&parallel, "parallel", 1, "Push images in parallel to depth specified.")
+	pushCmd.Flags().IntVar(&registryParallel, "registry-parallel", 0, "Most images to push to the same registry at once, i.e. 2 to stay under the rate limits of Docker Hub, 0 for no limit")
 	pushCmd.Flags().Var(&tagFormat, "tag", "Override latest tag on function Docker image, accepts 'latest', 'sha', 'branch', 'describe'")
 	pushCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in stack.yml file")
+	pushCmd.Flags().StringSliceVar(&skipFunctions, "skip", []string{}, "Names of functions in the stack file to leave out, i.e. fn1,fn2")
+	pushCmd.Flags().StringVar(&containerEngine, "container-engine", "", "Build and push with docker, podman or nerdctl, the first which is installed is used by default")
+	pushCmd.Flags().StringVar(&dockerContext, "docker-context", "", "Docker context to use instead of DOCKER_HOST, DOCKER_CONTEXT or the current context, i.e. a remote daemon")
+	pushCmd.Flags().StringSliceVar(&selectTags, "tags", []string{}, "Only use the functions in the stack file which have one of these tags, i.e. frontend,critical")
+	pushCmd.Flags().StringVar(&junitReport, "junit", "", "Write the result of each function to this file as a JUnit XML report, i.e. report.xml")
+	pushCmd.Flags().BoolVar(&annotateCI, "annotate-ci", false, "Annotate the functions which failed in stack.yml when running in GitHub Actions or GitLab CI")
+	pushCmd.Flags().StringVar(&logDir, "log-dir", "", "Write the output of the container engine for each function to a file in this folder, i.e. ./logs")
 
 }
 
@@ -38,6 +53,7 @@ These container images must already be present in your local image cache.`,
 	Example: `  faas-cli push -f https://domain/path/myfunctions.yml
   faas-cli push -f ./stack.yml
   faas-cli push -f ./stack.yml --parallel 4
+  faas-cli push -f ./stack.yml --parallel 8 --registry-parallel 2
   faas-cli push -f ./stack.yml --filter "*gif*"
   faas-cli push -f ./stack.yml --regex "fn[0-9]_.*"
   faas-cli push -f ./stack.yml --tag sha
@@ -47,10 +63,21 @@ These container images must already be present in your local image cache.`,
 }
 
 func runPush(cmd *cobra.Command, args []string) error {
+	// up writes the report once it has built and deployed too
+	if cmd.Name() == "push" {
+		defer reportResults()
+	}
+
+	if parallel < 1 {
+		return fmt.Errorf("the --parallel flag must be great than 0")
+	}
+	if registryParallel < 0 {
+		return fmt.Errorf("the --registry-parallel flag must not be negative")
+	}
 
 	var services stack.Services
 	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter, envsubst)
+		parsedServices, err := parseStackFile(yamlFile, envsubst)
 		if err != nil {
 			return err
 		}
@@ -58,6 +85,10 @@ func runPush(cmd *cobra.Command, args []string) error {
 		if parsedServices != nil {
 			services = *parsedServices
 		}
+
+		if err := selectTagged(&services, selectTags); err != nil {
+			return err
+		}
 	}
 
 	if len(services.Functions) > 0 {
@@ -71,58 +102,237 @@ Unable to push one or more of your functions to Docker Hub:
 You must provide a username or registry prefix to the Function's image such as user1/function1`)
 		}
 
-		pushStack(&services, parallel, tagFormat)
+		if needsContainerEngine(&services, true) {
+			if err := checkContainerEngine(false); err != nil {
+				return err
+			}
+		}
+
+		builder.LogDir = logDir
+		// quietBuild is only set when push is run by up -q
+		warnUnknownSkips(output.Warnings(quietBuild), &services, skipFunctions)
+		results := pushStack(&services, parallel, registryParallel, tagFormat, quietBuild)
+		printPushSummary(output.Progress(quietBuild), results)
+
+		failed := 0
+		for _, result := range results {
+			if result.Err != nil {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("unable to push %d of %d images", failed, len(results))
+		}
 	} else {
 		return fmt.Errorf("you must supply a valid YAML file")
 	}
 	return nil
 }
 
-func pushImage(image string) {
-	exec.Command("./", []string{"docker", "push", image})
+// pushResult is the outcome of pushing the image of a function
+type pushResult struct {
+	Function string
+	Image    string
+	Digest   string
+	Err      error
+}
+
+// pushDigest finds the digest in the output of docker push and nerdctl push
+var pushDigest = regexp.MustCompile(`digest: (sha256:[a-f0-9]{64})`)
+
+// pushImage pushes an image and returns its digest, which is empty when the
+// container engine does not print it. The output is written to log instead
+// of the console when it is not nil.
+var pushImage = func(image string, log io.Writer) (string, error) {
+	command, args, err := builder.EngineCommand("push", image)
+	if err != nil {
+		return "", err
+	}
+
+	task := v1execute.ExecTask{
+		Command:     command,
+		Args:        args,
+		StreamStdio: log == nil,
+	}
+
+	res, err := task.Execute()
+	if log != nil {
+		io.WriteString(log, res.Stdout)
+		io.WriteString(log, res.Stderr)
+	}
+	if err != nil {
+		return "", err
+	}
+	if res.ExitCode != 0 {
+		return "", fmt.Errorf("received non-zero exit code from push: %d", res.ExitCode)
+	}
+
+	return parsePushDigest(res.Stdout), nil
+}
+
+// pushWithLog pushes an image, writing the output of the container engine
+// to the log file of the function when --log-dir is set, the output is
+// discarded in quiet mode when there is no log file
+func pushWithLog(functionName, image string, quiet bool) (string, error) {
+	pushLog, logPath, err := builder.OpenLog(functionName, "push")
+	if err != nil {
+		return "", err
+	}
+	if pushLog == nil {
+		if quiet {
+			return pushImage(image, ioutil.Discard)
+		}
+		return pushImage(image, nil)
+	}
+	defer pushLog.Close()
+
+	digest, err := pushImage(image, pushLog)
+	if err != nil {
+		return digest, fmt.Errorf("%s, see the log: %s", err, logPath)
+	}
+	return digest, nil
+}
+
+func parsePushDigest(out string) string {
+	matches := pushDigest.FindAllStringSubmatch(out, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[len(matches)-1][1]
+}
+
+// registryLimiter limits how many images are pushed to each registry at
+// once, so that a large stack does not hit the rate limits of a registry
+type registryLimiter struct {
+	limit int
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+func newRegistryLimiter(limit int) *registryLimiter {
+	return &registryLimiter{limit: limit, slots: map[string]chan struct{}{}}
 }
 
-func pushStack(services *stack.Services, queueDepth int, tagMode schema.BuildFormat) {
+// acquire waits until the registry of the image has a free slot, call the
+// returned func once the image has been pushed
+func (l *registryLimiter) acquire(image string) func() {
+	if l.limit < 1 {
+		return func() {}
+	}
+
+	host := ""
+	if ref, err := registry.ParseReference(image); err == nil {
+		host = ref.Registry
+	}
+
+	l.mu.Lock()
+	slots, ok := l.slots[host]
+	if !ok {
+		slots = make(chan struct{}, l.limit)
+		l.slots[host] = slots
+	}
+	l.mu.Unlock()
+
+	slots <- struct{}{}
+	return func() { <-slots }
+}
+
+// pushStack pushes the images of the functions with queueDepth workers and
+// at most registryDepth pushes to any one registry, the results are in the
+// order of the functions in the stack, the progress is discarded when quiet
+func pushStack(services *stack.Services, queueDepth int, registryDepth int, tagMode schema.BuildFormat, quiet bool) []pushResult {
+	out := output.Progress(quiet)
 	wg := sync.WaitGroup{}
 
-	workChannel := make(chan stack.Function)
+	type pushWork struct {
+		index    int
+		function stack.Function
+	}
+
+	names := services.FunctionNames()
+	results := make([]*pushResult, len(names))
+	limiter := newRegistryLimiter(registryDepth)
+
+	workChannel := make(chan pushWork)
 
 	wg.Add(queueDepth)
 	for i := 0; i < queueDepth; i++ {
 		go func(index int) {
-			for function := range workChannel {
+			for work := range workChannel {
+				function := work.function
 				branch, sha, err := builder.GetImageTagValues(tagMode)
 				if err != nil {
 					tagMode = schema.DefaultFormat
 				}
 				imageName := schema.BuildImageName(tagMode, function.Image, sha, branch)
 
-				fmt.Printf(aec.YellowF.Apply("[%d] > Pushing %s [%s].\n"), index, function.Name, imageName)
+				fmt.Fprint(out, output.Info("[%d] > Pushing %s [%s].\n", index, function.Name, imageName))
 				if len(function.Image) == 0 {
-					fmt.Println("Please provide a valid Image value in the YAML file.")
-				} else if function.SkipBuild {
-					fmt.Printf("Skipping %s\n", function.Name)
+					fmt.Fprintln(out, "Please provide a valid Image value in the YAML file.")
+				} else if function.SkipBuild || function.SkipPush || skipped(skipFunctions, function.Name) {
+					fmt.Fprintf(out, "Skipping %s\n", function.Name)
+				} else if function.Prebuilt() {
+					fmt.Fprintf(out, "Skipping %s, it uses the prebuilt image %s\n", function.Name, function.Image)
 				} else {
+					release := limiter.acquire(imageName)
+					done := timings.trackResult(phasePush, function.Name)
+					digest, err := pushWithLog(function.Name, imageName, quiet)
+					done(err)
+					release()
 
-					pushImage(imageName)
-					fmt.Printf(aec.YellowF.Apply("[%d] < Pushing %s [%s] done.\n"), index, function.Name, imageName)
+					results[work.index] = &pushResult{Function: function.Name, Image: imageName, Digest: digest, Err: err}
+					if err != nil {
+						fmt.Fprintln(out, output.Failure("[%d] < Pushing %s [%s] failed: %s", index, function.Name, imageName, err))
+					} else {
+						fmt.Fprint(out, output.Info("[%d] < Pushing %s [%s] done.\n", index, function.Name, imageName))
+					}
 				}
 			}
 
-			fmt.Printf(aec.YellowF.Apply("[%d] Worker done.\n"), index)
+			fmt.Fprint(out, output.Info("[%d] Worker done.\n", index))
 			wg.Done()
 		}(i)
 	}
 
-	for k, function := range services.Functions {
+	for i, k := range names {
+		function := services.Functions[k]
 		function.Name = k
-		workChannel <- function
+		workChannel <- pushWork{index: i, function: function}
 	}
 
 	close(workChannel)
 
 	wg.Wait()
 
+	pushed := []pushResult{}
+	for _, result := range results {
+		if result != nil {
+			pushed = append(pushed, *result)
+		}
+	}
+	return pushed
+}
+
+// printPushSummary prints the digest of each image which was pushed, so that
+// they can be found in one place after the output of parallel pushes
+func printPushSummary(w io.Writer, results []pushResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nFUNCTION\tIMAGE\tDIGEST")
+	for _, result := range results {
+		digest := result.Digest
+		if result.Err != nil {
+			digest = "failed"
+		} else if len(digest) == 0 {
+			digest = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", result.Function, result.Image, digest)
+	}
+	tw.Flush()
 }
 
 func validateImages(functions map[string]stack.Function) []string {
@@ -130,7 +340,10 @@ func validateImages(functions map[string]stack.Function) []string {
 
 	for name, function := range functions {
 
-		if !function.SkipBuild && !strings.Contains(function.Image, `/`) {
+		if function.SkipBuild || function.SkipPush || skipped(skipFunctions, name) || function.Prebuilt() {
+			continue
+		}
+		if !strings.Contains(function.Image, `/`) {
 			invalidImages = append(invalidImages, name)
 		}
 	}
@@ -4,8 +4,18 @@
 package commands
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/openfaas/faas-cli/builder"
+	"github.com/openfaas/faas-cli/schema"
 	"github.com/openfaas/faas-cli/stack"
 )
 
@@ -14,19 +24,29 @@ func Test_PushValidation(t *testing.T) {
 		name     string
 		scenario string
 		image    string
+		skipPush bool
+		prebuilt bool
 		isValid  bool
 	}{
 		{scenario: "Valid image with username", name: "cli", image: "alexellis/faas-cli", isValid: true},
 		{scenario: "Valid image with remote repo", name: "cli", image: "10.1.95.201:5000/faas-cli", isValid: true},
 		{scenario: "Invalid image - missing prefix", name: "cli", image: "faas-cli", isValid: false},
+		{scenario: "Image without prefix which is not pushed", name: "cli", image: "faas-cli", skipPush: true, isValid: true},
+		{scenario: "Prebuilt image without prefix", name: "cli", image: "nginx:latest", prebuilt: true, isValid: true},
 	}
 
 	for _, testCase := range testCases {
+		function := stack.Function{
+			Name:     testCase.name,
+			Image:    testCase.image,
+			SkipPush: testCase.skipPush,
+		}
+		if !testCase.prebuilt {
+			function.Language = "go"
+			function.Handler = "./cli"
+		}
 		functions := map[string]stack.Function{
-			"cli": stack.Function{
-				Name:  testCase.name,
-				Image: testCase.image,
-			},
+			"cli": function,
 		}
 		invalidImages := validateImages(functions)
 		if len(invalidImages) > 0 && testCase.isValid == true {
@@ -40,3 +60,131 @@ func Test_PushValidation(t *testing.T) {
 
 	}
 }
+
+func Test_parsePushDigest(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("ab", 32)
+
+	cases := []struct {
+		name string
+		out  string
+		want string
+	}{
+		{
+			name: "docker push",
+			out:  "The push refers to repository [docker.io/alexellis/cli]\nlatest: digest: " + digest + " size: 1570\n",
+			want: digest,
+		},
+		{
+			name: "no digest",
+			out:  "Writing manifest to image destination\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parsePushDigest(tc.out); got != tc.want {
+				t.Fatalf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_registryLimiter_limitsEachRegistry(t *testing.T) {
+	limiter := newRegistryLimiter(1)
+
+	releaseHub := limiter.acquire("alexellis/cli:latest")
+	acquired := make(chan struct{})
+	go func() {
+		release := limiter.acquire("docker.io/alexellis/other:latest")
+		close(acquired)
+		release()
+	}()
+
+	// Another registry is not held up by Docker Hub
+	limiter.acquire("ghcr.io/alexellis/cli:latest")()
+
+	select {
+	case <-acquired:
+		t.Fatalf("want the second push to Docker Hub to wait")
+	default:
+	}
+
+	releaseHub()
+	<-acquired
+}
+
+func Test_pushStack_summary(t *testing.T) {
+	defer func(original func(string, io.Writer) (string, error)) { pushImage = original }(pushImage)
+
+	var mu sync.Mutex
+	pushed := []string{}
+	pushImage = func(image string, log io.Writer) (string, error) {
+		mu.Lock()
+		pushed = append(pushed, image)
+		mu.Unlock()
+
+		if strings.Contains(image, "broken") {
+			return "", fmt.Errorf("denied")
+		}
+		return "sha256:" + strings.Repeat("0", 64), nil
+	}
+
+	services := &stack.Services{
+		Functions: map[string]stack.Function{
+			"api":    {Image: "alexellis/api:latest", Language: "go", Handler: "./api"},
+			"broken": {Image: "alexellis/broken:latest", Language: "go", Handler: "./broken"},
+			"skip":   {Image: "alexellis/skip:latest", Language: "go", Handler: "./skip", SkipPush: true},
+		},
+	}
+
+	results := pushStack(services, 2, 1, schema.DefaultFormat, false)
+	if len(results) != 2 || len(pushed) != 2 {
+		t.Fatalf("want 2 images pushed, got %d results for %v", len(results), pushed)
+	}
+	if results[0].Function != "api" || results[0].Err != nil {
+		t.Fatalf("want api to be pushed first, got %+v", results[0])
+	}
+	if results[1].Function != "broken" || results[1].Err == nil {
+		t.Fatalf("want broken to fail, got %+v", results[1])
+	}
+
+	var out bytes.Buffer
+	printPushSummary(&out, results)
+	if !strings.Contains(out.String(), "sha256:"+strings.Repeat("0", 64)) || !strings.Contains(out.String(), "failed") {
+		t.Fatalf("want the digest and the failure in the summary, got:\n%s", out.String())
+	}
+}
+
+func Test_pushWithLog_WritesTheLogOfTheFunction(t *testing.T) {
+	defer func(original func(string, io.Writer) (string, error)) { pushImage = original }(pushImage)
+	defer func(original string) { builder.LogDir = original }(builder.LogDir)
+
+	dir, err := ioutil.TempDir("", "faas-cli-push-logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	builder.LogDir = filepath.Join(dir, "logs")
+
+	pushImage = func(image string, log io.Writer) (string, error) {
+		if log == nil {
+			t.Fatalf("want the output written to the log")
+		}
+		fmt.Fprintf(log, "pushing %s\n", image)
+		return "", fmt.Errorf("denied")
+	}
+
+	_, err = pushWithLog("api", "alexellis/api:latest", false)
+	logPath := filepath.Join(builder.LogDir, "api.push.log")
+	if err == nil || !strings.Contains(err.Error(), logPath) {
+		t.Fatalf("want the error to name %s, got: %v", logPath, err)
+	}
+
+	data, err := ioutil.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "pushing alexellis/api:latest\n" {
+		t.Fatalf("unexpected log: %q", string(data))
+	}
+}
@@ -0,0 +1,17 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"strings"
+)
+
+// functionURL is the URL a function is invoked at through the gateway
+func functionURL(gateway, name, namespace string) string {
+	functionURL := strings.TrimRight(gateway, "/") + "/function/" + name
+	if len(namespace) > 0 {
+		functionURL += "." + namespace
+	}
+	return functionURL
+}
@@ -0,0 +1,74 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// defaultSensitivePattern matches the names of environment variables whose
+// values are redacted when they are printed, i.e. DB_PASSWORD or API_KEY
+const defaultSensitivePattern = `(?i)(PASSWORD|TOKEN|KEY)`
+
+var (
+	showSensitive    bool
+	sensitivePattern string
+)
+
+// addSensitiveFlags registers --show-sensitive and --sensitive-pattern for a
+// command which prints environment variables
+func addSensitiveFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&showSensitive, "show-sensitive", false, "Print the values of environment variables which match --sensitive-pattern instead of redacting them")
+	flags.StringVar(&sensitivePattern, "sensitive-pattern", defaultSensitivePattern, "Regular expression for the names of environment variables whose values are redacted")
+}
+
+// sensitiveKeys redacts the values of environment variables whose names
+// match pattern, unless show is set
+type sensitiveKeys struct {
+	pattern *regexp.Regexp
+	show    bool
+}
+
+// sensitiveKeysFromFlags reads --sensitive-pattern and --show-sensitive
+func sensitiveKeysFromFlags() (sensitiveKeys, error) {
+	pattern, err := regexp.Compile(sensitivePattern)
+	if err != nil {
+		return sensitiveKeys{}, fmt.Errorf("invalid --sensitive-pattern %q: %s", sensitivePattern, err)
+	}
+	return sensitiveKeys{pattern: pattern, show: showSensitive}, nil
+}
+
+// redact returns value, or redactedEnvValue when the name of the
+// environment variable is sensitive
+func (s sensitiveKeys) redact(name, value string) string {
+	if s.show || s.pattern == nil || !s.pattern.MatchString(name) {
+		return value
+	}
+	return redactedEnvValue
+}
+
+// redactEnv returns a copy of envVars with the sensitive values redacted
+func (s sensitiveKeys) redactEnv(envVars map[string]string) map[string]string {
+	if envVars == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(envVars))
+	for name, value := range envVars {
+		redacted[name] = s.redact(name, value)
+	}
+	return redacted
+}
+
+// redactFlattened redacts a value from flattenApplied, where environment
+// variables are keyed as environment.NAME
+func (s sensitiveKeys) redactFlattened(key, value string) string {
+	if name := strings.TrimPrefix(key, "environment."); name != key {
+		return s.redact(name, value)
+	}
+	return value
+}
@@ -0,0 +1,109 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"bytes"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func Test_sensitiveKeys_redactEnv(t *testing.T) {
+	envVars := map[string]string{
+		"db_password": "s3cret",
+		"API_TOKEN":   "abc",
+		"SIGNING_KEY": "xyz",
+		"MODE":        "prod",
+	}
+
+	cases := []struct {
+		name string
+		show bool
+		want map[string]string
+	}{
+		{
+			name: "sensitive values are redacted",
+			want: map[string]string{
+				"db_password": redactedEnvValue,
+				"API_TOKEN":   redactedEnvValue,
+				"SIGNING_KEY": redactedEnvValue,
+				"MODE":        "prod",
+			},
+		},
+		{
+			name: "sensitive values are shown with --show-sensitive",
+			show: true,
+			want: envVars,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sensitive := sensitiveKeys{pattern: regexp.MustCompile(defaultSensitivePattern), show: tc.show}
+			got := sensitive.redactEnv(envVars)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_sensitiveKeysFromFlags(t *testing.T) {
+	defer func() { sensitivePattern = defaultSensitivePattern }()
+
+	sensitivePattern = "^CERT_"
+	sensitive, err := sensitiveKeysFromFlags()
+	if err != nil {
+		t.Fatalf("want no error, got %s", err)
+	}
+	if got := sensitive.redact("CERT_PEM", "pem"); got != redactedEnvValue {
+		t.Fatalf("want CERT_PEM redacted, got %q", got)
+	}
+	if got := sensitive.redact("API_KEY", "abc"); got != "abc" {
+		t.Fatalf("want API_KEY shown with a custom pattern, got %q", got)
+	}
+
+	sensitivePattern = "("
+	if _, err := sensitiveKeysFromFlags(); err == nil {
+		t.Fatalf("want an error for an invalid --sensitive-pattern")
+	}
+}
+
+func Test_diffApplied_redactsSensitiveValues(t *testing.T) {
+	sensitive := sensitiveKeys{pattern: regexp.MustCompile(defaultSensitivePattern)}
+	last := flattenApplied(appliedFunction{EnvVars: map[string]string{"API_KEY": "old", "MODE": "test"}})
+	planned := flattenApplied(appliedFunction{EnvVars: map[string]string{"API_KEY": "new", "MODE": "prod"}})
+
+	want := []string{
+		"~ environment.API_KEY: <redacted> -> <redacted>",
+		"~ environment.MODE: test -> prod",
+	}
+	if got := diffApplied(last, planned, sensitive); !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+}
+
+func Test_printDeployPlan(t *testing.T) {
+	sensitive := sensitiveKeys{pattern: regexp.MustCompile(defaultSensitivePattern)}
+	planned := []appliedFunction{{
+		Name:    "api",
+		Image:   "alexellis/api:0.1",
+		EnvVars: map[string]string{"DB_PASSWORD": "s3cret", "MODE": "prod"},
+	}}
+
+	var out bytes.Buffer
+	printDeployPlan(&out, "http://127.0.0.1:8080", planned, sensitive)
+	got := out.String()
+
+	for _, w := range []string{"nothing was deployed to http://127.0.0.1:8080", "environment.DB_PASSWORD: <redacted>", "environment.MODE: prod", "image: alexellis/api:0.1"} {
+		if !strings.Contains(got, w) {
+			t.Errorf("want output to contain %q, got:\n%s", w, got)
+		}
+	}
+	if strings.Contains(got, "s3cret") {
+		t.Errorf("want the password redacted, got:\n%s", got)
+	}
+}
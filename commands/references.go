@@ -0,0 +1,147 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/openfaas/faas-cli/output"
+	"github.com/openfaas/faas-cli/vault"
+	types "github.com/openfaas/faas-provider/types"
+)
+
+// referenceLister lists the namespaces and secrets of a gateway
+type referenceLister interface {
+	ListNamespaces(ctx context.Context) ([]string, error)
+	GetSecretList(ctx context.Context, namespace string) ([]types.Secret, error)
+}
+
+// referenceCache holds the namespaces and secrets of a gateway for the length
+// of one command, so that references in stack.yml can be checked before deploy
+type referenceCache struct {
+	// namespaces is nil when the gateway could not list them, i.e. faasd
+	namespaces map[string]bool
+
+	// secrets by namespace, a namespace is missing when its secrets could not be listed
+	secrets map[string]map[string]bool
+}
+
+// prefetchReferences lists the namespaces and the secrets of each of the given
+// namespaces concurrently, anything which cannot be listed is not checked later.
+// Nothing is fetched when no namespace is given.
+func prefetchReferences(ctx context.Context, client referenceLister, namespaces []string) *referenceCache {
+	cache := &referenceCache{
+		secrets: map[string]map[string]bool{},
+	}
+	if len(namespaces) == 0 {
+		return cache
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		list, err := client.ListNamespaces(ctx)
+		if err != nil || len(list) == 0 {
+			return
+		}
+
+		names := map[string]bool{}
+		for _, namespace := range list {
+			names[namespace] = true
+		}
+
+		mu.Lock()
+		cache.namespaces = names
+		mu.Unlock()
+	}()
+
+	seen := map[string]bool{}
+	for _, namespace := range namespaces {
+		if seen[namespace] {
+			continue
+		}
+		seen[namespace] = true
+
+		wg.Add(1)
+		go func(namespace string) {
+			defer wg.Done()
+
+			list, err := client.GetSecretList(ctx, namespace)
+			if err != nil {
+				return
+			}
+
+			names := map[string]bool{}
+			for _, secret := range list {
+				names[secret.Name] = true
+			}
+
+			mu.Lock()
+			cache.secrets[namespace] = names
+			mu.Unlock()
+		}(namespace)
+	}
+
+	wg.Wait()
+	return cache
+}
+
+// missingReferences describes the namespace and secrets of a function which
+// do not exist on the gateway, secrets from vault are created during deploy
+func (c *referenceCache) missingReferences(functionName, namespace string, secrets []string) []string {
+	missing := []string{}
+
+	if len(namespace) > 0 && c.namespaces != nil && !c.namespaces[namespace] {
+		missing = append(missing, fmt.Sprintf("function %s uses the namespace %s, which does not exist", functionName, namespace))
+		return missing
+	}
+
+	existing, ok := c.secrets[namespace]
+	if !ok {
+		return missing
+	}
+
+	for _, secret := range secrets {
+		if vault.IsReference(secret) || existing[secret] {
+			continue
+		}
+
+		where, create := "", "faas-cli secret create "+secret
+		if len(namespace) > 0 {
+			where = " in namespace " + namespace
+			create += " --namespace " + namespace
+		}
+		missing = append(missing, fmt.Sprintf("function %s uses the secret %s, which does not exist%s, create it with: %s", functionName, secret, where, create))
+	}
+
+	return missing
+}
+
+// checkReferences fails with every missing reference, so that nothing is
+// deployed, or prints them as warnings when the check is skipped
+func checkReferences(w io.Writer, missing []string, skip bool) error {
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+
+	if skip {
+		for _, message := range missing {
+			fmt.Fprintln(w, output.Warning("%s", message))
+		}
+		return nil
+	}
+
+	return fmt.Errorf("found references which do not exist on the gateway, use --skip-secret-check to deploy anyway:\n  - %s",
+		strings.Join(missing, "\n  - "))
+}
@@ -0,0 +1,140 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sync"
+	"testing"
+
+	types "github.com/openfaas/faas-provider/types"
+)
+
+type fakeReferenceLister struct {
+	mu         sync.Mutex
+	calls      []string
+	namespaces []string
+	secrets    map[string][]string
+}
+
+func (f *fakeReferenceLister) ListNamespaces(ctx context.Context) ([]string, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, "namespaces")
+	f.mu.Unlock()
+
+	if f.namespaces == nil {
+		return nil, fmt.Errorf("namespaces are not supported")
+	}
+	return f.namespaces, nil
+}
+
+func (f *fakeReferenceLister) GetSecretList(ctx context.Context, namespace string) ([]types.Secret, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, "secrets:"+namespace)
+	f.mu.Unlock()
+
+	names, ok := f.secrets[namespace]
+	if !ok {
+		return nil, fmt.Errorf("unable to list secrets in %s", namespace)
+	}
+
+	secrets := []types.Secret{}
+	for _, name := range names {
+		secrets = append(secrets, types.Secret{Name: name, Namespace: namespace})
+	}
+	return secrets, nil
+}
+
+func Test_referenceCache_missingReferences(t *testing.T) {
+	lister := &fakeReferenceLister{
+		namespaces: []string{"openfaas-fn", "dev"},
+		secrets: map[string][]string{
+			"":    {"api-key"},
+			"dev": {"api-key", "db-password"},
+		},
+	}
+
+	cache := prefetchReferences(context.Background(), lister, []string{"", "dev", "dev", "staging", "locked"})
+
+	if len(lister.calls) != 5 {
+		t.Errorf("want each namespace to be listed once, but got calls: %v", lister.calls)
+	}
+
+	cases := []struct {
+		name      string
+		namespace string
+		secrets   []string
+		want      int
+	}{
+		{name: "all secrets exist", namespace: "", secrets: []string{"api-key"}, want: 0},
+		{name: "missing secret", namespace: "dev", secrets: []string{"api-key", "token"}, want: 1},
+		{name: "vault secrets are created on deploy", namespace: "dev", secrets: []string{"vault:kv/data/app#token"}, want: 0},
+		{name: "missing namespace", namespace: "staging", secrets: []string{"api-key"}, want: 1},
+		{name: "secrets could not be listed", namespace: "openfaas-fn", secrets: []string{"token"}, want: 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := cache.missingReferences("fn", c.namespace, c.secrets)
+			if len(got) != c.want {
+				t.Fatalf("want %d missing references, but got: %v", c.want, got)
+			}
+		})
+	}
+
+	got := cache.missingReferences("fn", "dev", []string{"token"})
+	want := []string{"function fn uses the secret token, which does not exist in namespace dev, create it with: faas-cli secret create token --namespace dev"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want the hint to create the secret in its namespace %v, but got: %v", want, got)
+	}
+
+	got = cache.missingReferences("fn", "", []string{"token"})
+	want = []string{"function fn uses the secret token, which does not exist, create it with: faas-cli secret create token"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, but got: %v", want, got)
+	}
+}
+
+func Test_prefetchReferences_NothingToCheck(t *testing.T) {
+	lister := &fakeReferenceLister{}
+
+	cache := prefetchReferences(context.Background(), lister, nil)
+
+	if len(lister.calls) != 0 {
+		t.Errorf("want no calls to the gateway, but got: %v", lister.calls)
+	}
+	if got := cache.missingReferences("fn", "dev", []string{"token"}); !reflect.DeepEqual(got, []string{}) {
+		t.Errorf("want no missing references, but got: %v", got)
+	}
+}
+
+func Test_checkReferences(t *testing.T) {
+	missing := []string{
+		"function b uses the secret token, which does not exist",
+		"function a uses the secret api-key, which does not exist",
+	}
+
+	err := checkReferences(ioutil.Discard, missing, false)
+	if err == nil {
+		t.Fatalf("want error for missing references, but got nil")
+	}
+
+	want := "found references which do not exist on the gateway, use --skip-secret-check to deploy anyway:\n" +
+		"  - function a uses the secret api-key, which does not exist\n" +
+		"  - function b uses the secret token, which does not exist"
+	if err.Error() != want {
+		t.Errorf("want error:\n%s\nbut got:\n%s", want, err.Error())
+	}
+
+	if err := checkReferences(ioutil.Discard, missing, true); err != nil {
+		t.Errorf("want only warnings with --skip-secret-check, but got: %s", err)
+	}
+
+	if err := checkReferences(ioutil.Discard, nil, false); err != nil {
+		t.Errorf("want no error without missing references, but got: %s", err)
+	}
+}
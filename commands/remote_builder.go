@@ -0,0 +1,53 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"github.com/openfaas/faas-cli/builder"
+)
+
+// Flags to build with a builder service instead of a local container engine
+var (
+	// remoteBuilder is the URL of the builder, i.e. the OpenFaaS Pro builder
+	remoteBuilder string
+
+	// payloadSecretPath is a file with the secret which signs the requests
+	// to the builder
+	payloadSecretPath string
+)
+
+// checkBuilder points the builder at the service given with --remote, or
+// checks the local container engine when there is none
+func checkBuilder(quiet bool) error {
+	if len(remoteBuilder) == 0 {
+		return checkContainerEngine(quiet)
+	}
+
+	u, err := url.Parse(remoteBuilder)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return fmt.Errorf("--remote must be the http or https URL of a builder, but was: %s", remoteBuilder)
+	}
+
+	secret := ""
+	if len(payloadSecretPath) > 0 {
+		data, err := ioutil.ReadFile(payloadSecretPath)
+		if err != nil {
+			return fmt.Errorf("unable to read the payload secret: %s", err)
+		}
+		secret = strings.TrimSpace(string(data))
+	}
+
+	builder.RemoteBuilder = remoteBuilder
+	builder.PayloadSecret = secret
+
+	if !quiet {
+		fmt.Printf("Using the builder at %s to build and push images\n", remoteBuilder)
+	}
+	return nil
+}
@@ -47,7 +47,7 @@ func runDelete(cmd *cobra.Command, args []string) error {
 	var gatewayAddress string
 	var yamlGateway string
 	if len(yamlFile) > 0 && len(args) == 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter, envsubst)
+		parsedServices, err := parseStackFile(yamlFile, envsubst)
 		if err != nil {
 			return err
 		}
@@ -69,13 +69,15 @@ func runDelete(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	proxyclient.CallID = requestID
 	ctx := context.Background()
 
 	if len(services.Functions) > 0 {
 
-		for k, function := range services.Functions {
+		for _, k := range services.FunctionNames() {
+			function := services.Functions[k]
 			function.Namespace = getNamespace(functionNamespace, function.Namespace)
-			function.Name = k
+			function.Name = deployedFunctionName(k)
 			fmt.Printf("Deleting: %s.%s\n", function.Name, function.Namespace)
 
 			proxyclient.DeleteFunction(ctx, function.Name, function.Namespace)
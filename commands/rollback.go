@@ -0,0 +1,99 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rollbackWait        bool
+	rollbackWaitTimeout time.Duration
+)
+
+func init() {
+	rollbackCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL starting with http(s)://")
+	rollbackCmd.Flags().StringVarP(&functionNamespace, "namespace", "n", "", "Namespace of the function")
+	rollbackCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
+	rollbackCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
+	rollbackCmd.Flags().BoolVar(&rollbackWait, "wait", false, "Wait for all of the replicas of the function to run the previous image after it is rolled back")
+	rollbackCmd.Flags().DurationVar(&rollbackWaitTimeout, "wait-timeout", defaultWaitTimeout, "The longest time to --wait for the function to be ready")
+
+	faasCmd.AddCommand(rollbackCmd)
+}
+
+var rollbackCmd = &cobra.Command{
+	Use:   `rollback FUNCTION_NAME [--gateway GATEWAY_URL] [--namespace NAMESPACE] [--wait]`,
+	Short: "Deploy the previous recorded deployment of a function",
+	Long: `Deploys a function as it was before its latest deployment, from the state
+file which deploy --record keeps on this machine for each gateway. Running it
+again goes back another deployment.`,
+	Example: `  faas-cli deploy -f stack.yml --record
+  faas-cli rollback api
+  faas-cli rollback api --namespace staging --gateway https://gw.example.com
+  faas-cli rollback api --wait --wait-timeout 5m`,
+	RunE: runRollback,
+}
+
+func runRollback(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("give the name of the function to roll back")
+	}
+	name := args[0]
+
+	var yamlGateway string
+	if len(yamlFile) > 0 {
+		if services, err := parseStackFile(yamlFile, envsubst); err == nil && services != nil {
+			yamlGateway = services.Provider.GatewayURL
+		}
+	}
+	gatewayAddress := getGatewayURL(gateway, defaultGateway, yamlGateway, os.Getenv(openFaaSURLEnvironment))
+
+	state, err := loadDeployState(gatewayAddress)
+	if err != nil {
+		return err
+	}
+
+	previous, err := state.previous(name, functionNamespace)
+	if err != nil {
+		return err
+	}
+
+	cliAuth, err := proxy.NewCLIAuth(token, gatewayAddress)
+	if err != nil {
+		return err
+	}
+	transport := GetDefaultCLITransport(tlsInsecure, &commandTimeout)
+	proxyClient, err := proxy.NewClient(cliAuth, gatewayAddress, transport, &commandTimeout)
+	if err != nil {
+		return err
+	}
+	proxyClient.CallID = requestID
+
+	fmt.Printf("Rolling back %s to %s, deployed at %s\n", appliedKey(name, functionNamespace), previous.Image, previous.AppliedAt.Format(time.RFC3339))
+
+	spec := previous.spec()
+	spec.TLSInsecure = tlsInsecure
+	spec.Token = token
+	if statusCode, err := proxyClient.DeployFunctionWithError(context.Background(), spec); err != nil {
+		return deployFailed(map[string]int{name: statusCode}, map[string]error{name: err})
+	}
+
+	state.rolledBack(name, functionNamespace)
+	if err := state.save(); err != nil {
+		return err
+	}
+
+	if rollbackWait {
+		targets := []waitTarget{{Name: name, Namespace: functionNamespace, Image: spec.Image}}
+		return waitForFunctions(context.Background(), proxyClient, targets, rollbackWaitTimeout, os.Stderr, stderrIsTerminal())
+	}
+	return nil
+}
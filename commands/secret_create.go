@@ -128,6 +128,7 @@ func runSecretCreate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	client.CallID = requestID
 
 	fmt.Println("Creating secret: " + secret.Name)
 	_, output := client.CreateSecret(context.Background(), secret)
@@ -0,0 +1,37 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+// secretHintNotes explains how hinted secrets reach a function on OpenFaaS
+// providers, which mount each secret as a file named after the secret
+func secretHintNotes(function stack.Function) []string {
+	names := make([]string, 0, len(function.SecretHints))
+	for name := range function.SecretHints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	notes := []string{}
+	for _, name := range names {
+		hint := function.SecretHints[name]
+		target := hint.TargetFor(name)
+
+		switch {
+		case hint.IsEnv():
+			notes = append(notes, fmt.Sprintf("%s: OpenFaaS providers do not set %s from secret %s, read it from %s%s instead",
+				function.Name, target, name, stack.SecretsMountPath, name))
+		case target != name:
+			notes = append(notes, fmt.Sprintf("%s: OpenFaaS providers mount secret %s at %s%s, not %s%s",
+				function.Name, name, stack.SecretsMountPath, name, stack.SecretsMountPath, target))
+		}
+	}
+	return notes
+}
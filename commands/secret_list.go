@@ -48,17 +48,22 @@ func runSecretList(cmd *cobra.Command, args []string) error {
 		fmt.Println(msg)
 	}
 
-	cliAuth, err := proxy.NewCLIAuth(token, gatewayAddress)
-	if err != nil {
-		return err
-	}
-	transport := GetDefaultCLITransport(tlsInsecure, &commandTimeout)
-	client, err := proxy.NewClient(cliAuth, gatewayAddress, transport, &commandTimeout)
-	if err != nil {
+	var secrets []types.Secret
+	err := withGatewayFailover(gatewayAddress, func(gatewayAddress string) error {
+		cliAuth, err := proxy.NewCLIAuth(token, gatewayAddress)
+		if err != nil {
+			return err
+		}
+		transport := GetDefaultCLITransport(tlsInsecure, &commandTimeout)
+		client, err := proxy.NewClient(cliAuth, gatewayAddress, transport, &commandTimeout)
+		if err != nil {
+			return err
+		}
+		client.CallID = requestID
+
+		secrets, err = client.GetSecretList(context.Background(), functionNamespace)
 		return err
-	}
-
-	secrets, err := client.GetSecretList(context.Background(), functionNamespace)
+	})
 	if err != nil {
 		return err
 	}
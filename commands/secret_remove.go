@@ -65,6 +65,7 @@ func runSecretRemove(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	client.CallID = requestID
 
 	err = client.RemoveSecret(context.Background(), secret)
 	if err != nil {
@@ -110,6 +110,7 @@ func runSecretUpdate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	client.CallID = requestID
 
 	fmt.Println("Updating secret: " + secret.Name)
 	_, output := client.UpdateSecret(context.Background(), secret)
@@ -0,0 +1,49 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"github.com/openfaas/faas-cli/stack"
+)
+
+// parseStackFile parses a stack file with the functions picked by --regex,
+// --filter and --select, which can be given together and must all match, and
+// applies the profile given with --env-profile, then expands the templates
+// in the images. The files given with further -f flags are merged over it.
+func parseStackFile(yamlFile string, envsubst bool) (*stack.Services, error) {
+	selector, err := stack.NewSelector(stackSelectorFlags())
+	if err != nil {
+		return nil, err
+	}
+	return parseStackProfile(append([]string{yamlFile}, overrideYAMLFiles...), selector, envsubst, envProfile)
+}
+
+func parseStackProfile(yamlFiles []string, selector *stack.Selector, envsubst bool, profile string) (*stack.Services, error) {
+	services, err := stack.ParseYAMLFilesSelect(yamlFiles, selector, envsubst)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(profile) > 0 {
+		if err := services.ApplyProfile(profile); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := expandImageTemplates(services); err != nil {
+		return nil, err
+	}
+	return services, nil
+}
+
+// stackSelectorFlags collects the flags which select functions
+func stackSelectorFlags() stack.SelectorFlags {
+	return stack.SelectorFlags{
+		Regex:            regex,
+		RegexExact:       regexExact,
+		Filter:           filter,
+		FilterIgnoreCase: filterIgnoreCase,
+		Expression:       selectExpr,
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/openfaas/faas-cli/output"
+	"github.com/openfaas/faas-cli/stack"
+)
+
+// skipFunctions are the functions named with --skip, which build, push and
+// deploy leave out
+var skipFunctions []string
+
+// skipped is true when a function is named in skip
+func skipped(skip []string, name string) bool {
+	for _, s := range skip {
+		if strings.TrimSpace(s) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// warnUnknownSkips warns about names given to --skip which are not in the
+// stack, i.e. a typo which would leave the function in. Functions left out by
+// --regex, --filter or --select cannot be told apart, so there is no warning then.
+func warnUnknownSkips(w io.Writer, services *stack.Services, skip []string) {
+	if len(regex) > 0 || len(filter) > 0 || len(selectExpr) > 0 {
+		return
+	}
+	for _, name := range skip {
+		name = strings.TrimSpace(name)
+		if _, ok := services.Functions[name]; !ok && len(name) > 0 {
+			fmt.Fprintln(w, output.Warning("--skip %s: there is no function named %s in the stack file", name, name))
+		}
+	}
+}
+
+// removeSkipped removes the functions which are named in skip, or for which
+// skipField is true, from a stack and prints each one to w
+func removeSkipped(w io.Writer, services *stack.Services, skip []string, action string, skipField func(stack.Function) bool) {
+	for _, name := range services.FunctionNames() {
+		if skipped(skip, name) || skipField(services.Functions[name]) {
+			fmt.Fprintf(w, "Skipping %s of: %s.\n", action, name)
+			delete(services.Functions, name)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+const skipStack = `provider:
+  name: openfaas
+functions:
+  api:
+    lang: go
+    handler: ./api
+    image: alexellis/api:latest
+  redis:
+    image: redis:6
+    skip_build: true
+    skip_push: true
+  worker:
+    lang: go
+    handler: ./worker
+    image: alexellis/worker:latest
+    skip_deploy: true
+  cron:
+    lang: go
+    handler: ./cron
+    image: alexellis/cron:latest
+`
+
+func Test_removeSkipped(t *testing.T) {
+	cases := []struct {
+		name string
+		skip []string
+		want []string
+	}{
+		{name: "skip_deploy in stack.yml", want: []string{"api", "redis", "cron"}},
+		{name: "with --skip", skip: []string{"api", " cron"}, want: []string{"redis"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			services, err := stack.ParseYAMLData([]byte(skipStack), "", "", false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			removeSkipped(ioutil.Discard, services, tc.skip, "deploy", func(function stack.Function) bool {
+				return function.SkipDeploy
+			})
+
+			if got := services.FunctionNames(); strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_validateImages_Skipped(t *testing.T) {
+	services, err := stack.ParseYAMLData([]byte(skipStack), "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// redis has no prefix, but it is neither built nor pushed
+	if invalid := validateImages(services.Functions); len(invalid) > 0 {
+		t.Errorf("want no invalid images, got %v", invalid)
+	}
+
+	services.Functions["local"] = stack.Function{Image: "local:latest", Language: "go", Handler: "./local"}
+	if invalid := validateImages(services.Functions); len(invalid) != 1 {
+		t.Errorf("want local to be invalid, got %v", invalid)
+	}
+
+	skipFunctions = []string{"local"}
+	defer func() { skipFunctions = nil }()
+	if invalid := validateImages(services.Functions); len(invalid) > 0 {
+		t.Errorf("want no invalid images when local is given to --skip, got %v", invalid)
+	}
+}
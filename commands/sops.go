@@ -0,0 +1,57 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	v1execute "github.com/alexellis/go-execute/pkg/v1"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const sopsAgeKeyFileEnvironment = "SOPS_AGE_KEY_FILE"
+
+var sopsAgeKeyFile string
+
+// isSOPSEncrypted reports whether the YAML data was encrypted by SOPS, which
+// adds a top-level "sops" key with its metadata
+func isSOPSEncrypted(data []byte) bool {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return false
+	}
+	_, ok := doc["sops"]
+	return ok
+}
+
+// decryptSOPSFile decrypts a YAML file with the sops binary, the age key file
+// given by --sops-age-key-file takes precedence over SOPS_AGE_KEY_FILE
+var decryptSOPSFile = func(file string) ([]byte, error) {
+	if _, err := exec.LookPath("sops"); err != nil {
+		return nil, fmt.Errorf("%s is encrypted with SOPS, but the sops binary was not found in your PATH", file)
+	}
+
+	task := v1execute.ExecTask{
+		Command: "sops",
+		Args:    []string{"--decrypt", "--input-type", "yaml", "--output-type", "yaml", file},
+	}
+
+	if len(sopsAgeKeyFile) > 0 {
+		task.Env = []string{sopsAgeKeyFileEnvironment + "=" + sopsAgeKeyFile}
+	}
+
+	res, err := task.Execute()
+	if err != nil {
+		return nil, err
+	}
+
+	if res.ExitCode != 0 {
+		return nil, fmt.Errorf(`unable to decrypt %s with sops, check the key given with --sops-age-key-file or %s
+%s`, file, sopsAgeKeyFileEnvironment, strings.TrimSpace(res.Stderr))
+	}
+
+	return []byte(res.Stdout), nil
+}
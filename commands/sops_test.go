@@ -0,0 +1,82 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const sopsEncryptedEnvironmentFile = `environment:
+    API_KEY: ENC[AES256_GCM,data:8zI=,iv:X1Y=,tag:Zw==,type:str]
+sops:
+    age:
+        - recipient: age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+    mac: ENC[AES256_GCM,data:c2U=,iv:X1Y=,tag:Zw==,type:str]
+    version: 3.7.1
+`
+
+func Test_isSOPSEncrypted(t *testing.T) {
+	testcases := []struct {
+		Name  string
+		Input string
+		Want  bool
+	}{
+		{Name: "plain environment file", Input: "environment:\n  API_KEY: secret\n", Want: false},
+		{Name: "SOPS encrypted environment file", Input: sopsEncryptedEnvironmentFile, Want: true},
+		{Name: "invalid YAML", Input: "environment: [", Want: false},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.Name, func(t *testing.T) {
+			got := isSOPSEncrypted([]byte(testcase.Input))
+			if got != testcase.Want {
+				t.Fatalf("want %t, but got %t", testcase.Want, got)
+			}
+		})
+	}
+}
+
+func Test_readFiles_SOPSEncrypted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "faas-cli-sops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "secrets.yml")
+	if err := ioutil.WriteFile(file, []byte(sopsEncryptedEnvironmentFile), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	defaultDecryptSOPSFile := decryptSOPSFile
+	defer func() { decryptSOPSFile = defaultDecryptSOPSFile }()
+
+	t.Run("decrypted values are used", func(t *testing.T) {
+		decryptSOPSFile = func(string) ([]byte, error) {
+			return []byte("environment:\n  API_KEY: secret\n"), nil
+		}
+
+		envs, err := readFiles([]string{file})
+		if err != nil {
+			t.Fatalf("want no error, but got: %s", err)
+		}
+		if envs["API_KEY"] != "secret" {
+			t.Fatalf("want API_KEY to be decrypted, but got: %q", envs["API_KEY"])
+		}
+	})
+
+	t.Run("decryption errors are returned", func(t *testing.T) {
+		decryptSOPSFile = func(string) ([]byte, error) {
+			return nil, fmt.Errorf("no key")
+		}
+
+		if _, err := readFiles([]string{file}); err == nil {
+			t.Fatalf("want error when decryption fails, but got nil")
+		}
+	})
+}
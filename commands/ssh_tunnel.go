@@ -0,0 +1,217 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	osexec "os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/spf13/cobra"
+)
+
+// sshTunnelTimeout is how long ssh has to log in and open the tunnel
+const sshTunnelTimeout = 15 * time.Second
+
+// sshGateway is the host which the gateway is reached through, set by
+// --ssh-gateway
+var sshGateway string
+
+func init() {
+	faasCmd.PersistentFlags().StringVar(&sshGateway, "ssh-gateway", "", "Reach the gateway through an SSH tunnel to this host for the command, i.e. user@bastion or user@bastion:2222")
+
+	cobra.OnInitialize(applySSHGateway)
+}
+
+// applySSHGateway sends the connections to the gateway through SSH tunnels
+// once the flags have been parsed
+func applySSHGateway() {
+	if len(sshGateway) == 0 {
+		proxy.DialGateway = nil
+		return
+	}
+	sshTunnels.bastion = sshGateway
+	proxy.DialGateway = sshTunnels.dial
+}
+
+// tunnels are opened with ssh -L on the first connection to each gateway,
+// and are closed when faas-cli exits. The ssh binary is used rather than
+// golang.org/x/crypto/ssh, which is not vendored, so that ~/.ssh/config,
+// ssh-agent and known_hosts work as they do for ssh itself.
+type tunnels struct {
+	sync.Mutex
+
+	bastion  string
+	gateways map[string]bool
+	local    map[string]string
+	stops    []func()
+}
+
+var sshTunnels = &tunnels{}
+
+// startTunnel forwards local to remote with ssh and returns once the tunnel
+// accepts connections, it is a variable so that tests do not need ssh
+var startTunnel = func(bastion, local, remote string) (func(), error) {
+	if _, err := osexec.LookPath("ssh"); err != nil {
+		return nil, fmt.Errorf("ssh is needed for --ssh-gateway")
+	}
+
+	cmd := osexec.Command("ssh", sshTunnelArgs(bastion, local, remote)...)
+	cmd.Stderr = os.Stderr
+	configureTunnelProcess(cmd)
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	stop := func() {
+		killTunnelProcess(cmd)
+		<-exited
+	}
+
+	deadline := time.Now().Add(sshTunnelTimeout)
+	for {
+		select {
+		case err := <-exited:
+			exited <- err
+			return nil, fmt.Errorf("ssh to %s exited before the tunnel was open: %v", bastion, err)
+		default:
+		}
+
+		conn, err := net.DialTimeout("tcp", local, time.Second)
+		if err == nil {
+			conn.Close()
+			return stop, nil
+		}
+		if time.Now().After(deadline) {
+			stop()
+			return nil, fmt.Errorf("timed out waiting for the SSH tunnel through %s", bastion)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// sshTunnelArgs are the arguments to ssh to forward local to remote through
+// the bastion, which is user@host with an optional port
+func sshTunnelArgs(bastion, local, remote string) []string {
+	host, port, err := net.SplitHostPort(remote)
+	if err == nil && strings.Contains(host, ":") {
+		remote = "[" + host + "]:" + port
+	}
+
+	args := []string{
+		"-N",
+		"-o", "BatchMode=yes",
+		"-o", "ExitOnForwardFailure=yes",
+		"-L", local + ":" + remote,
+	}
+	if i := strings.LastIndex(bastion, ":"); i > -1 {
+		if _, err := strconv.Atoi(bastion[i+1:]); err == nil {
+			args = append(args, "-p", bastion[i+1:])
+			bastion = bastion[:i]
+		}
+	}
+	return append(args, bastion)
+}
+
+// addGateway sends the connections to the host and port of gatewayURL
+// through a tunnel, other addresses such as the template store are dialled
+// directly
+func (t *tunnels) addGateway(gatewayURL string) {
+	u, err := url.Parse(gatewayURL)
+	if err != nil || len(u.Host) == 0 {
+		return
+	}
+	port := u.Port()
+	if len(port) == 0 {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	t.Lock()
+	defer t.Unlock()
+	if t.gateways == nil {
+		t.gateways = map[string]bool{}
+	}
+	t.gateways[net.JoinHostPort(strings.ToLower(u.Hostname()), port)] = true
+}
+
+// dial connects to addr through its tunnel when it is a gateway, the tunnel
+// is opened when it is the first connection to addr
+func (t *tunnels) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	var dialer net.Dialer
+	if !t.isGateway(addr) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	local, err := t.open(addr)
+	if err != nil {
+		return nil, err
+	}
+	return dialer.DialContext(ctx, network, local)
+}
+
+func (t *tunnels) isGateway(addr string) bool {
+	t.Lock()
+	defer t.Unlock()
+	return t.gateways[strings.ToLower(addr)]
+}
+
+func (t *tunnels) open(addr string) (string, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	if local, ok := t.local[addr]; ok {
+		return local, nil
+	}
+
+	local, err := freeLocalAddress()
+	if err != nil {
+		return "", err
+	}
+	stop, err := startTunnel(t.bastion, local, addr)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(os.Stderr, "Connecting to %s through an SSH tunnel via %s\n", addr, t.bastion)
+
+	if t.local == nil {
+		t.local = map[string]string{}
+	}
+	t.local[addr] = local
+	t.stops = append(t.stops, stop)
+	return local, nil
+}
+
+// close closes every tunnel
+func (t *tunnels) close() {
+	t.Lock()
+	defer t.Unlock()
+
+	for _, stop := range t.stops {
+		stop()
+	}
+	t.stops = nil
+	t.local = nil
+}
+
+// freeLocalAddress finds a port on the loopback interface for a tunnel
+func freeLocalAddress() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer listener.Close()
+	return listener.Addr().String(), nil
+}
@@ -0,0 +1,29 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+//go:build linux
+// +build linux
+
+package commands
+
+import (
+	osexec "os/exec"
+	"syscall"
+)
+
+// configureTunnelProcess runs ssh in its own process group, so that a
+// ProxyCommand from ~/.ssh/config is killed with it, and has the kernel kill
+// it if faas-cli exits without closing the tunnel
+func configureTunnelProcess(cmd *osexec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid:   true,
+		Pdeathsig: syscall.SIGKILL,
+	}
+}
+
+// killTunnelProcess kills the process group of ssh
+func killTunnelProcess(cmd *osexec.Cmd) {
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,19 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+//go:build !linux
+// +build !linux
+
+package commands
+
+import osexec "os/exec"
+
+// configureTunnelProcess leaves ssh in the process group of faas-cli, so that
+// Ctrl+C in the terminal stops it along with faas-cli
+func configureTunnelProcess(cmd *osexec.Cmd) {
+}
+
+// killTunnelProcess kills ssh
+func killTunnelProcess(cmd *osexec.Cmd) {
+	cmd.Process.Kill()
+}
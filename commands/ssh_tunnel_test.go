@@ -0,0 +1,166 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/proxy"
+)
+
+func Test_sshTunnelArgs(t *testing.T) {
+	cases := []struct {
+		name    string
+		bastion string
+		remote  string
+		want    []string
+	}{
+		{
+			name:    "default port",
+			bastion: "alex@bastion.example.com",
+			remote:  "gateway.internal:8080",
+			want:    []string{"-N", "-o", "BatchMode=yes", "-o", "ExitOnForwardFailure=yes", "-L", "127.0.0.1:4000:gateway.internal:8080", "alex@bastion.example.com"},
+		},
+		{
+			name:    "custom port",
+			bastion: "alex@bastion.example.com:2222",
+			remote:  "10.0.0.5:31112",
+			want:    []string{"-N", "-o", "BatchMode=yes", "-o", "ExitOnForwardFailure=yes", "-L", "127.0.0.1:4000:10.0.0.5:31112", "-p", "2222", "alex@bastion.example.com"},
+		},
+		{
+			name:    "IPv6 gateway",
+			bastion: "bastion",
+			remote:  "[fd00::5]:8080",
+			want:    []string{"-N", "-o", "BatchMode=yes", "-o", "ExitOnForwardFailure=yes", "-L", "127.0.0.1:4000:[fd00::5]:8080", "bastion"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := sshTunnelArgs(c.bastion, "127.0.0.1:4000", c.remote)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("want %v, got %v", c.want, got)
+			}
+		})
+	}
+}
+
+// forward is a stand-in for ssh -L which forwards local to target
+func forward(t *testing.T, local, target string) func() {
+	listener, err := net.Listen("tcp", local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			upstream, err := net.Dial("tcp", target)
+			if err != nil {
+				conn.Close()
+				continue
+			}
+			go func() {
+				io.Copy(upstream, conn)
+				upstream.Close()
+			}()
+			go func() {
+				io.Copy(conn, upstream)
+				conn.Close()
+			}()
+		}
+	}()
+	return func() { listener.Close() }
+}
+
+func Test_tunnels_dial(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer server.Close()
+	target := strings.TrimPrefix(server.URL, "http://")
+
+	opened := []string{}
+	defer func(original func(string, string, string) (func(), error)) { startTunnel = original }(startTunnel)
+	startTunnel = func(bastion, local, remote string) (func(), error) {
+		opened = append(opened, bastion+" "+remote)
+		return forward(t, local, target), nil
+	}
+
+	defer func() {
+		sshTunnels.close()
+		sshTunnels.gateways = nil
+		sshGateway = ""
+		applySSHGateway()
+	}()
+	sshGateway = "alex@bastion"
+	applySSHGateway()
+	getGatewayURL("gateway.internal:8080", defaultGateway, "", "")
+
+	client := proxy.MakeHTTPClient(nil, false)
+
+	// Only the gateway is tunnelled, i.e. not the template store
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	res.Body.Close()
+	if len(opened) != 0 {
+		t.Fatalf("want no tunnel for %s, got %v", server.URL, opened)
+	}
+
+	for i := 0; i < 2; i++ {
+		res, err := client.Get("http://gateway.internal:8080/healthz")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if string(body) != "gateway.internal:8080" {
+			t.Fatalf("want the Host of the gateway kept, got: %s", body)
+		}
+	}
+
+	if want := []string{"alex@bastion gateway.internal:8080"}; !reflect.DeepEqual(opened, want) {
+		t.Fatalf("want one tunnel %v, got %v", want, opened)
+	}
+}
+
+func Test_tunnels_addGateway(t *testing.T) {
+	cases := []struct {
+		gatewayURL string
+		want       string
+	}{
+		{gatewayURL: "http://gateway.internal:8080", want: "gateway.internal:8080"},
+		{gatewayURL: "http://gateway.internal", want: "gateway.internal:80"},
+		{gatewayURL: "https://gateway.internal", want: "gateway.internal:443"},
+		{gatewayURL: "https://[fd00::1]", want: "[fd00::1]:443"},
+		{gatewayURL: "https://GW.example.com", want: "gw.example.com:443"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.gatewayURL, func(t *testing.T) {
+			tunnels := &tunnels{}
+			tunnels.addGateway(c.gatewayURL)
+			if !tunnels.isGateway(c.want) {
+				t.Fatalf("want %s tunnelled, got: %v", c.want, tunnels.gateways)
+			}
+			if upper := strings.ToUpper(c.want); !tunnels.isGateway(upper) {
+				t.Fatalf("want %s tunnelled, got: %v", upper, tunnels.gateways)
+			}
+			if tunnels.isGateway("github.com:443") {
+				t.Fatalf("want github.com:443 dialled directly, got: %v", tunnels.gateways)
+			}
+		})
+	}
+}
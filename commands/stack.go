@@ -0,0 +1,23 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	faasCmd.AddCommand(stackCmd)
+}
+
+// stackCmd groups the commands which maintain a stack.yml file
+var stackCmd = &cobra.Command{
+	Use:   `stack [COMMAND]`,
+	Short: "Maintain stack.yml files",
+	Long:  "Commands to generate and maintain the functions in a stack.yml file",
+	Example: `  faas-cli stack discover ./functions/
+  faas-cli stack discover ./functions/ -f functions.yml
+  faas-cli stack copy-fn url-ping url-ping-staging
+  faas-cli stack migrate -f functions.yml`,
+}
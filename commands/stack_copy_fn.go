@@ -0,0 +1,231 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/spf13/cobra"
+)
+
+var (
+	copyFnTagSuffix string
+	copyFnImage     string
+)
+
+var imageLinePattern = regexp.MustCompile(`^(\s+image:\s*)(["']?)([^"'#\s]+)(["']?)(.*)$`)
+
+func init() {
+	stackCopyFnCmd.Flags().StringVar(&copyFnTagSuffix, "tag-suffix", "", "Suffix for the tag of the copy's image, defaults to the part of DST after SRC, i.e. -staging")
+	stackCopyFnCmd.Flags().StringVar(&copyFnImage, "image", "", "Image for the copy, instead of adding a suffix to the tag")
+
+	stackCmd.AddCommand(stackCopyFnCmd)
+}
+
+// stackCopyFnCmd duplicates a function in a stack file under a new name
+var stackCopyFnCmd = &cobra.Command{
+	Use:   `copy-fn SRC DST [-f YAML_FILE]`,
+	Short: "Copy a function in a stack file under a new name",
+	Long: `Copies the definition of the function SRC in the stack file to a new function
+DST, which is written after SRC, i.e. to create a -staging variant. Comments and
+the order of the fields are kept.
+
+A suffix is added to the tag of the copy's image so that the variants can be
+built and pushed side by side, when DST starts with SRC the suffix is the rest
+of DST. Pass --tag-suffix to choose the suffix, or --image to set the image.`,
+	Example: `  faas-cli stack copy-fn url-ping url-ping-staging
+  faas-cli stack copy-fn url-ping canary --tag-suffix -canary -f functions.yml
+  faas-cli stack copy-fn url-ping url-ping-v2 --image alexellis/url-ping:2.0.0`,
+	RunE: runStackCopyFn,
+}
+
+func runStackCopyFn(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("give the function to copy and the name of the copy, i.e. faas-cli stack copy-fn url-ping url-ping-staging")
+	}
+	src, dst := args[0], args[1]
+
+	if err := validateFunctionName(dst); err != nil {
+		return err
+	}
+
+	stackFile := yamlFile
+	if len(stackFile) == 0 {
+		stackFile = defaultYAML
+	}
+
+	data, err := ioutil.ReadFile(stackFile)
+	if err != nil {
+		return err
+	}
+
+	services, err := stack.ParseYAMLData(data, "", "", false)
+	if err != nil {
+		return err
+	}
+	function, ok := services.Functions[src]
+	if !ok {
+		return fmt.Errorf("function %s was not found in %s", src, stackFile)
+	}
+	if _, ok := services.Functions[dst]; ok {
+		return fmt.Errorf("function %s is already in %s", dst, stackFile)
+	}
+
+	image := copyFnImage
+	if len(image) == 0 && len(function.Image) > 0 {
+		suffix := copyFnTagSuffix
+		if len(suffix) == 0 && strings.HasPrefix(dst, src) {
+			suffix = dst[len(src):]
+		}
+
+		if len(suffix) > 0 {
+			image, err = suffixImageTag(function.Image, suffix)
+			if err != nil {
+				return err
+			}
+		} else {
+			fmt.Printf("%s uses the same image as %s, pass --tag-suffix or --image to change it\n", dst, src)
+		}
+	}
+
+	updated, err := copyFunctionYAML(data, src, dst, image)
+	if err != nil {
+		return err
+	}
+
+	if _, err := stack.ParseYAMLData(updated, "", "", false); err != nil {
+		return fmt.Errorf("the copy of %s is not valid YAML: %s", src, err)
+	}
+
+	info, err := os.Stat(stackFile)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(stackFile, updated, info.Mode()); err != nil {
+		return err
+	}
+
+	if len(image) > 0 {
+		fmt.Printf("Copied %s to %s with image %s in %s\n", src, dst, image, stackFile)
+	} else {
+		fmt.Printf("Copied %s to %s in %s\n", src, dst, stackFile)
+	}
+	return nil
+}
+
+// copyFunctionYAML copies the lines of a function in a stack file, renames the
+// copy and sets its image when one is given, the copy is added after the source
+func copyFunctionYAML(data []byte, src, dst, image string) ([]byte, error) {
+	lines := strings.Split(string(data), "\n")
+
+	functions := -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, "functions:") {
+			functions = i
+			break
+		}
+	}
+	if functions < 0 {
+		return nil, fmt.Errorf("no functions found in the stack file")
+	}
+
+	start, indent := -1, ""
+	for i := functions + 1; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if len(trimmed) == 0 || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		lineIndent := lines[i][:len(lines[i])-len(strings.TrimLeft(lines[i], " \t"))]
+		if len(lineIndent) == 0 {
+			break
+		}
+
+		if isFunctionKey(trimmed, src) {
+			start, indent = i, lineIndent
+			break
+		}
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("function %s was not found in the stack file", src)
+	}
+
+	end := start + 1
+	for ; end < len(lines); end++ {
+		trimmed := strings.TrimSpace(lines[end])
+		if len(trimmed) == 0 || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		lineIndent := len(lines[end]) - len(strings.TrimLeft(lines[end], " \t"))
+		if lineIndent <= len(indent) {
+			break
+		}
+	}
+	// Blank lines and comments before the next key belong to what follows
+	for end > start+1 && (len(strings.TrimSpace(lines[end-1])) == 0 || strings.HasPrefix(strings.TrimSpace(lines[end-1]), "#")) {
+		end--
+	}
+
+	block := make([]string, 0, end-start+1)
+	block = append(block, indent+dst+":")
+	imageSet := false
+	childIndent := -1
+	for _, line := range lines[start+1 : end] {
+		trimmed := strings.TrimSpace(line)
+		lineIndent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if childIndent < 0 && len(trimmed) > 0 && !strings.HasPrefix(trimmed, "#") {
+			childIndent = lineIndent
+		}
+
+		if len(image) > 0 && !imageSet && lineIndent == childIndent {
+			if match := imageLinePattern.FindStringSubmatch(line); match != nil {
+				line = match[1] + match[2] + image + match[4] + match[5]
+				imageSet = true
+			}
+		}
+		block = append(block, line)
+	}
+
+	if len(image) > 0 && !imageSet {
+		return nil, fmt.Errorf("unable to find the image of %s to change it", src)
+	}
+
+	copied := make([]string, 0, len(lines)+len(block)+1)
+	copied = append(copied, lines[:end]...)
+	copied = append(copied, "")
+	copied = append(copied, block...)
+	copied = append(copied, lines[end:]...)
+	return []byte(strings.Join(copied, "\n")), nil
+}
+
+// isFunctionKey is true for the line which starts the definition of a function
+func isFunctionKey(line, name string) bool {
+	for _, key := range []string{name, `"` + name + `"`, `'` + name + `'`} {
+		if !strings.HasPrefix(line, key+":") {
+			continue
+		}
+		rest := strings.TrimSpace(line[len(key)+1:])
+		return len(rest) == 0 || strings.HasPrefix(rest, "#")
+	}
+	return false
+}
+
+// suffixImageTag adds a suffix to the tag of an image, the tag is latest when
+// the image has none
+func suffixImageTag(image, suffix string) (string, error) {
+	if strings.Contains(image, "@") {
+		return "", fmt.Errorf("image %s is pinned to a digest, pass --image to set the image of the copy", image)
+	}
+
+	name, tag := image, "latest"
+	if colon := strings.LastIndex(image, ":"); colon > strings.LastIndex(image, "/") {
+		name, tag = image[:colon], image[colon+1:]
+	}
+	return name + ":" + tag + suffix, nil
+}
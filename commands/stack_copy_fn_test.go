@@ -0,0 +1,126 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+const copyFnStack = `version: 1.0
+provider:
+  name: openfaas
+  gateway: http://127.0.0.1:8080
+functions:
+  # Pings a URL
+  url-ping:
+    lang: python3
+    handler: ./url-ping
+    image: "alexellis/url-ping:0.2" # pinned
+    environment:
+      timeout: 5s
+
+  figlet:
+    lang: dockerfile
+    handler: ./figlet
+    image: alexellis/figlet
+configuration:
+  templates:
+    - name: python3
+`
+
+func Test_copyFunctionYAML(t *testing.T) {
+	got, err := copyFunctionYAML([]byte(copyFnStack), "url-ping", "url-ping-staging", "alexellis/url-ping:0.2-staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `version: 1.0
+provider:
+  name: openfaas
+  gateway: http://127.0.0.1:8080
+functions:
+  # Pings a URL
+  url-ping:
+    lang: python3
+    handler: ./url-ping
+    image: "alexellis/url-ping:0.2" # pinned
+    environment:
+      timeout: 5s
+
+  url-ping-staging:
+    lang: python3
+    handler: ./url-ping
+    image: "alexellis/url-ping:0.2-staging" # pinned
+    environment:
+      timeout: 5s
+
+  figlet:
+    lang: dockerfile
+    handler: ./figlet
+    image: alexellis/figlet
+configuration:
+  templates:
+    - name: python3
+`
+	if string(got) != want {
+		t.Fatalf("want:\n%s\nbut got:\n%s", want, string(got))
+	}
+}
+
+func Test_copyFunctionYAML_lastFunction(t *testing.T) {
+	got, err := copyFunctionYAML([]byte(copyFnStack), "figlet", "figlet-canary", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `    image: alexellis/figlet
+
+  figlet-canary:
+    lang: dockerfile
+    handler: ./figlet
+    image: alexellis/figlet
+configuration:
+`
+	if !strings.Contains(string(got), want) {
+		t.Fatalf("want:\n%s\nin:\n%s", want, string(got))
+	}
+}
+
+func Test_copyFunctionYAML_notFound(t *testing.T) {
+	if _, err := copyFunctionYAML([]byte(copyFnStack), "env", "env-staging", ""); err == nil {
+		t.Fatal("want an error for a function which is not in the stack file")
+	}
+}
+
+func Test_suffixImageTag(t *testing.T) {
+	cases := []struct {
+		image   string
+		want    string
+		wantErr bool
+	}{
+		{image: "alexellis/url-ping:0.2", want: "alexellis/url-ping:0.2-staging"},
+		{image: "alexellis/url-ping", want: "alexellis/url-ping:latest-staging"},
+		{image: "registry:5000/url-ping", want: "registry:5000/url-ping:latest-staging"},
+		{image: "alexellis/url-ping@sha256:abc", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.image, func(t *testing.T) {
+			got, err := suffixImageTag(c.image, "-staging")
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("want error, but got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Fatalf("want %s, but got %s", c.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/spf13/cobra"
+)
+
+var preferLanguages []string
+
+func init() {
+	stackDiscoverCmd.Flags().StringVarP(&gateway, "gateway", "g", defaultGateway, "Gateway URL to store in a new YAML stack file")
+	stackDiscoverCmd.Flags().StringVarP(&imagePrefix, "prefix", "p", "", "Set prefix for the function images")
+	stackDiscoverCmd.Flags().StringSliceVar(&preferLanguages, "prefer-lang", []string{}, "Languages to pick when a handler matches more than one template, i.e. node14,python3")
+
+	stackCmd.AddCommand(stackDiscoverCmd)
+}
+
+// stackDiscoverCmd adds the handler folders found under a directory to a stack file
+var stackDiscoverCmd = &cobra.Command{
+	Use:   `discover DIRECTORY [-f YAML_FILE]`,
+	Short: "Add the functions found in a directory tree to a stack file",
+	Long: `Scans a directory tree for handler folders and adds a function for each one
+which is not already in the stack file. The language of a handler is inferred from
+the templates in ./template: a folder is a handler for a template when it has every
+file of the template's handler folder, i.e. handler.py and requirements.txt for
+python3. The function is named after its folder.
+
+When a handler matches more than one template, such as node12 and node14, it is
+skipped unless one of them is given with --prefer-lang.
+
+The stack file is created when it does not exist, functions which are already in
+it are left as they are.`,
+	Example: `  faas-cli stack discover ./functions/
+  faas-cli stack discover ./functions/ -f functions.yml --prefix alexellis
+  faas-cli stack discover . --prefer-lang node14,python3-http`,
+	RunE: runStackDiscover,
+}
+
+// discoveredFunction is a handler folder and the template it was written for
+type discoveredFunction struct {
+	Name     string
+	Handler  string
+	Language string
+}
+
+func runStackDiscover(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("give the directory to scan for handlers, i.e. faas-cli stack discover ./functions/")
+	}
+
+	stackFile := yamlFile
+	if len(stackFile) == 0 {
+		stackFile = defaultYAML
+	}
+
+	markers, err := templateMarkers(templateDirectory)
+	if err != nil {
+		return err
+	}
+
+	functions, warnings, err := discoverFunctions(args[0], filepath.Dir(stackFile), markers, preferLanguages)
+	if err != nil {
+		return err
+	}
+
+	for _, warning := range warnings {
+		fmt.Printf("Skipped %s\n", warning)
+	}
+
+	existing := map[string]bool{}
+	appendMode := false
+	if _, err := os.Stat(stackFile); err == nil {
+		services, err := stack.ParseYAMLFile(stackFile, "", "", false)
+		if err != nil {
+			return err
+		}
+		for name := range services.Functions {
+			existing[name] = true
+		}
+		appendMode = true
+	}
+
+	gatewayAddress := getGatewayURL(gateway, defaultGateway, "", os.Getenv(openFaaSURLEnvironment))
+	prefix := strings.TrimSpace(getPrefixValue())
+
+	content := ""
+	added := 0
+	for _, function := range functions {
+		if existing[function.Name] {
+			fmt.Printf("Function %s is already in %s\n", function.Name, stackFile)
+			continue
+		}
+
+		image := function.Name + ":latest"
+		if len(prefix) > 0 {
+			image = prefix + "/" + image
+		}
+
+		content += prepareYAMLContent(appendMode || added > 0, gatewayAddress, &stack.Function{
+			Name:     function.Name,
+			Handler:  function.Handler,
+			Language: function.Language,
+			Image:    image,
+		})
+		added++
+
+		fmt.Printf("Added function %s (%s) from %s\n", function.Name, function.Language, function.Handler)
+	}
+
+	if added == 0 {
+		fmt.Printf("No new functions found in %s\n", args[0])
+		return nil
+	}
+
+	f, err := os.OpenFile(stackFile, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("could not open file '%s' %s", stackFile, err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(content); err != nil {
+		return fmt.Errorf("error writing stack file %s", err)
+	}
+
+	fmt.Printf("Stack file updated: %s\n", stackFile)
+	return nil
+}
+
+// templateMarkers lists the files in the handler folder of each template,
+// a folder with all of them is taken to be a handler for that template
+func templateMarkers(templateDir string) (map[string][]string, error) {
+	templates, err := ioutil.ReadDir(templateDir)
+	if err != nil || len(templates) == 0 {
+		return nil, fmt.Errorf("no templates found in %s, run \"faas-cli template pull\" first", templateDir)
+	}
+
+	markers := map[string][]string{}
+	for _, template := range templates {
+		if !template.IsDir() {
+			continue
+		}
+
+		langTemplate, err := stack.ParseYAMLForLanguageTemplate(filepath.Join(templateDir, template.Name(), "template.yml"))
+		if err != nil {
+			continue
+		}
+
+		handlerFolder := "function"
+		if len(langTemplate.HandlerFolder) > 0 {
+			handlerFolder = langTemplate.HandlerFolder
+		}
+
+		files, err := ioutil.ReadDir(filepath.Join(templateDir, template.Name(), handlerFolder))
+		if err != nil {
+			continue
+		}
+
+		names := []string{}
+		for _, file := range files {
+			if !file.IsDir() && !strings.HasPrefix(file.Name(), ".") {
+				names = append(names, file.Name())
+			}
+		}
+
+		if len(names) > 0 {
+			markers[template.Name()] = names
+		}
+	}
+
+	return markers, nil
+}
+
+// discoverFunctions walks root for handler folders, handlers are written
+// relative to stackDir, the folder of the stack file
+func discoverFunctions(root, stackDir string, markers map[string][]string, prefer []string) ([]discoveredFunction, []string, error) {
+	functions := []discoveredFunction{}
+	warnings := []string{}
+	names := map[string]string{}
+
+	templatePath, _ := filepath.Abs(templateDirectory)
+
+	// Rel needs both paths to be absolute or both relative, and DIRECTORY
+	// and the stack file may be given one of each way
+	absStackDir, err := filepath.Abs(stackDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+
+		if path != root && (strings.HasPrefix(info.Name(), ".") || info.Name() == "node_modules" || info.Name() == "build") {
+			return filepath.SkipDir
+		}
+		if abs, _ := filepath.Abs(path); abs == templatePath {
+			return filepath.SkipDir
+		}
+
+		language, candidates := inferLanguage(path, markers, prefer)
+		if len(candidates) == 0 {
+			return nil
+		}
+
+		name := filepath.Base(path)
+		if abs, err := filepath.Abs(path); err == nil {
+			name = filepath.Base(abs)
+		}
+
+		switch {
+		case len(language) == 0:
+			warnings = append(warnings, fmt.Sprintf("%s: matches the templates %s, choose one with --prefer-lang", path, strings.Join(candidates, ", ")))
+		case validateFunctionName(name) != nil:
+			warnings = append(warnings, fmt.Sprintf("%s: %s is not a valid function name", path, name))
+		case len(names[name]) > 0:
+			warnings = append(warnings, fmt.Sprintf("%s: a function named %s was found in %s", path, name, names[name]))
+		default:
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				return err
+			}
+			handler, err := filepath.Rel(absStackDir, absPath)
+			if err != nil {
+				return err
+			}
+			if handler != "." {
+				handler = "./" + filepath.ToSlash(handler)
+			}
+			names[name] = path
+			functions = append(functions, discoveredFunction{
+				Name:     name,
+				Handler:  handler,
+				Language: language,
+			})
+		}
+
+		// A handler's own sub-folders are part of the function
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return functions, warnings, nil
+}
+
+// inferLanguage returns the template whose markers are all in dir, when
+// several match the one with the most markers wins, then the preferred one
+func inferLanguage(dir string, markers map[string][]string, prefer []string) (string, []string) {
+	best := 0
+	candidates := []string{}
+
+	for language, files := range markers {
+		found := true
+		for _, file := range files {
+			if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+				found = false
+				break
+			}
+		}
+		if !found {
+			continue
+		}
+
+		switch {
+		case len(files) > best:
+			best = len(files)
+			candidates = []string{language}
+		case len(files) == best:
+			candidates = append(candidates, language)
+		}
+	}
+	sort.Strings(candidates)
+
+	if len(candidates) == 1 {
+		return candidates[0], candidates
+	}
+
+	for _, language := range prefer {
+		for _, candidate := range candidates {
+			if candidate == language {
+				return language, candidates
+			}
+		}
+	}
+
+	return "", candidates
+}
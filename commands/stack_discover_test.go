@@ -0,0 +1,125 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_discoverFunctions(t *testing.T) {
+	root, err := ioutil.TempDir("", "stack-discover")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	writeFiles := func(dir string, names ...string) {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0700); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range names {
+			if err := ioutil.WriteFile(filepath.Join(root, dir, name), []byte{}, 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	writeFiles("functions/billing/resize", "handler.py", "requirements.txt")
+	writeFiles("functions/billing/resize/lib", "handler.py", "requirements.txt")
+	writeFiles("functions/invoices", "handler.js", "package.json")
+	writeFiles("functions/docs", "README.md")
+	writeFiles("functions/.cache/stale", "handler.py", "requirements.txt")
+	writeFiles("functions/Upper_Case", "handler.py", "requirements.txt")
+
+	markers := map[string][]string{
+		"python3":    {"handler.py", "requirements.txt"},
+		"python3-go": {"handler.py"},
+		"node12":     {"handler.js", "package.json"},
+		"node14":     {"handler.js", "package.json"},
+	}
+
+	t.Run("ambiguous handlers are skipped", func(t *testing.T) {
+		functions, warnings, err := discoverFunctions(filepath.Join(root, "functions"), root, markers, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []discoveredFunction{
+			{Name: "resize", Handler: "./functions/billing/resize", Language: "python3"},
+		}
+		if !reflect.DeepEqual(functions, want) {
+			t.Errorf("want functions %v, but got %v", want, functions)
+		}
+		if len(warnings) != 2 {
+			t.Errorf("want warnings for the ambiguous and invalid handlers, but got: %v", warnings)
+		}
+	})
+
+	t.Run("preferred language", func(t *testing.T) {
+		functions, _, err := discoverFunctions(filepath.Join(root, "functions"), root, markers, []string{"node14"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []discoveredFunction{
+			{Name: "resize", Handler: "./functions/billing/resize", Language: "python3"},
+			{Name: "invoices", Handler: "./functions/invoices", Language: "node14"},
+		}
+		if !reflect.DeepEqual(functions, want) {
+			t.Errorf("want functions %v, but got %v", want, functions)
+		}
+	})
+
+	t.Run("absolute directory and relative stack file", func(t *testing.T) {
+		wd, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Chdir(wd)
+		if err := os.Chdir(root); err != nil {
+			t.Fatal(err)
+		}
+
+		functions, _, err := discoverFunctions(filepath.Join(root, "functions", "billing"), ".", markers, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []discoveredFunction{
+			{Name: "resize", Handler: "./functions/billing/resize", Language: "python3"},
+		}
+		if !reflect.DeepEqual(functions, want) {
+			t.Errorf("want functions %v, but got %v", want, functions)
+		}
+	})
+}
+
+func Test_inferLanguage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "infer-language")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"handler.go", "go.mod"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte{}, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	markers := map[string][]string{
+		"golang-http":       {"handler.go"},
+		"golang-middleware": {"handler.go", "go.mod"},
+		"dockerfile":        {"Dockerfile"},
+	}
+
+	language, candidates := inferLanguage(dir, markers, nil)
+	if language != "golang-middleware" {
+		t.Errorf("want the template with the most markers, but got %q from %v", language, candidates)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// overrideYAMLFiles are the stack files given with -f after the first one,
+// they are merged over it in order, i.e. for an environment
+var overrideYAMLFiles []string
+
+// yamlFileFlag is the value of -f. The first file is kept in yamlFile, so
+// that commands which only read one stack file work as before.
+var yamlFileFlag yamlFileValue
+
+type yamlFileValue struct {
+	// parsing is set by the first -f until the flags have been parsed, so
+	// that a later -f is merged over it
+	parsing bool
+
+	// files are those given with -f on the command line
+	files []string
+}
+
+func init() {
+	cobra.OnInitialize(endYAMLFileFlags)
+}
+
+func (v *yamlFileValue) Set(value string) error {
+	if !v.parsing {
+		v.parsing = true
+		v.files = nil
+		yamlFile = value
+		overrideYAMLFiles = nil
+	} else {
+		overrideYAMLFiles = append(overrideYAMLFiles, value)
+	}
+	v.files = append(v.files, value)
+	return nil
+}
+
+// String is empty unless -f was given, as the help only leaves out the
+// default of a flag whose value is empty
+func (v *yamlFileValue) String() string {
+	return strings.Join(v.files, ",")
+}
+
+func (v *yamlFileValue) Type() string {
+	return "string"
+}
+
+// endYAMLFileFlags runs once the flags have been parsed, so that the files
+// from an earlier command line are not merged into the next one
+func endYAMLFileFlags() {
+	if !yamlFileFlag.parsing {
+		yamlFileFlag.files = nil
+		overrideYAMLFiles = nil
+	}
+	yamlFileFlag.parsing = false
+}
+
+// stackFiles are the stack files given with -f, in the order they are merged
+func stackFiles() []string {
+	return append([]string{yamlFile}, overrideYAMLFiles...)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func parseYAMLFileFlags(t *testing.T, args ...string) {
+	t.Helper()
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.VarP(&yamlFileFlag, "yaml", "f", "")
+	if err := flags.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	endYAMLFileFlags()
+}
+
+func Test_yamlFileFlag(t *testing.T) {
+	defer resetForTest()
+	resetForTest()
+	yamlFile = defaultYAML
+
+	parseYAMLFileFlags(t, "-f", "base.yml", "-f", "prod.yml", "--yaml", "eu.yml")
+	if want := []string{"base.yml", "prod.yml", "eu.yml"}; !reflect.DeepEqual(stackFiles(), want) {
+		t.Fatalf("want %v, got %v", want, stackFiles())
+	}
+
+	parseYAMLFileFlags(t, "-f", "other.yml")
+	if want := []string{"other.yml"}; !reflect.DeepEqual(stackFiles(), want) {
+		t.Fatalf("want only the files of the last command line %v, got %v", want, stackFiles())
+	}
+
+	parseYAMLFileFlags(t)
+	if want := []string{"other.yml"}; !reflect.DeepEqual(stackFiles(), want) {
+		t.Fatalf("want %v, got %v", want, stackFiles())
+	}
+}
+
+func Test_parseStackFile_mergesOverrides(t *testing.T) {
+	defer resetForTest()
+	resetForTest()
+
+	dir, err := ioutil.TempDir("", "faas-cli-stack-files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	base := filepath.Join(dir, "stack.yml")
+	prod := filepath.Join(dir, "prod.yml")
+	ioutil.WriteFile(base, []byte(`provider:
+  name: openfaas
+functions:
+  api:
+    lang: go
+    handler: ./api
+    image: alexellis/api:latest
+`), 0600)
+	ioutil.WriteFile(prod, []byte(`provider:
+  gateway: https://gw.example.com
+functions:
+  api:
+    image: alexellis/api:1.2.0
+`), 0600)
+
+	parseYAMLFileFlags(t, "-f", base, "-f", prod)
+	services, err := parseStackFile(yamlFile, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := services.Functions["api"].Image; got != "alexellis/api:1.2.0" {
+		t.Fatalf("want the image from prod.yml, got: %s", got)
+	}
+	if got := services.Functions["api"].Handler; got != "./api" {
+		t.Fatalf("want the handler from stack.yml, got: %s", got)
+	}
+	if got := services.Provider.GatewayURL; got != "https://gw.example.com" {
+		t.Fatalf("want the gateway from prod.yml, got: %s", got)
+	}
+}
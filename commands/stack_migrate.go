@@ -0,0 +1,79 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/spf13/cobra"
+)
+
+var migrateDryRun bool
+
+func init() {
+	stackMigrateCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "Print the upgraded stack file instead of writing it")
+
+	stackCmd.AddCommand(stackMigrateCmd)
+}
+
+// stackMigrateCmd upgrades a stack file to the latest version of the schema
+var stackMigrateCmd = &cobra.Command{
+	Use:   `migrate [-f YAML_FILE] [--dry-run]`,
+	Short: "Upgrade a stack file to the latest schema version",
+	Long: `Upgrades a stack file to the latest version of the schema, which is ` + defaultSchemaVersion + `.
+The version is set when it is missing or older, the legacy provider name "faas"
+becomes "openfaas" and provider.network, which is no longer used, is removed.
+
+Only the lines which change are rewritten, so comments and the order of the
+fields are kept. The stack files named under includes are not changed, run
+migrate for each of them.`,
+	Example: `  faas-cli stack migrate
+  faas-cli stack migrate -f functions.yml
+  faas-cli stack migrate --dry-run`,
+	RunE: runStackMigrate,
+}
+
+func runStackMigrate(cmd *cobra.Command, args []string) error {
+	stackFile := yamlFile
+	if len(stackFile) == 0 {
+		stackFile = defaultYAML
+	}
+
+	data, err := ioutil.ReadFile(stackFile)
+	if err != nil {
+		return err
+	}
+
+	migrated, changes, err := stack.Migrate(data)
+	if err != nil {
+		return fmt.Errorf("unable to migrate %s: %s", stackFile, err)
+	}
+
+	if migrateDryRun {
+		fmt.Print(string(migrated))
+		return nil
+	}
+
+	if len(changes) == 0 {
+		fmt.Printf("%s is already at version %s\n", stackFile, defaultSchemaVersion)
+		return nil
+	}
+
+	info, err := os.Stat(stackFile)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(stackFile, migrated, info.Mode()); err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		fmt.Printf("  - %s\n", change)
+	}
+	fmt.Printf("Migrated %s to version %s\n", stackFile, defaultSchemaVersion)
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_runStackMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stack-migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer resetForTest()
+
+	stackFile := filepath.Join(dir, "stack.yml")
+	legacy := `provider:
+  name: faas
+  gateway: http://127.0.0.1:8080
+  network: func_functions
+functions:
+  figlet:
+    lang: dockerfile
+    image: alexellis/figlet
+`
+	if err := ioutil.WriteFile(stackFile, []byte(legacy), 0640); err != nil {
+		t.Fatal(err)
+	}
+	yamlFile = stackFile
+
+	if err := runStackMigrate(stackMigrateCmd, nil); err != nil {
+		t.Fatalf("want no error, got %s", err)
+	}
+
+	got, err := ioutil.ReadFile(stackFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `version: 1.0
+provider:
+  name: openfaas
+  gateway: http://127.0.0.1:8080
+functions:
+  figlet:
+    lang: dockerfile
+    image: alexellis/figlet
+`
+	if string(got) != want {
+		t.Fatalf("want:\n%s\ngot:\n%s", want, got)
+	}
+
+	info, err := os.Stat(stackFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Fatalf("want mode 0640, got %o", info.Mode().Perm())
+	}
+}
@@ -124,15 +124,10 @@ func runStoreDeploy(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	proxyClient.CallID = requestID
 
-	statusCode, err := deployImage(context.Background(), proxyClient, imageName, item.Fprocess, itemName, "", storeDeployFlags,
+	_, err = deployImage(context.Background(), proxyClient, imageName, item.Fprocess, itemName, "", storeDeployFlags,
 		tlsInsecure, item.ReadOnlyRootFilesystem, token, functionNamespace)
 
-	if badStatusCode(statusCode) {
-		failedStatusCode := map[string]int{itemName: statusCode}
-		err := deployFailed(failedStatusCode)
-		return err
-	}
-
 	return err
 }
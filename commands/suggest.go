@@ -0,0 +1,97 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// shortcuts are commands which can be given without the command they belong
+// to, for those used to the same command in docker or kubectl
+var shortcuts = map[string][]string{
+	"pull": {"template", "pull"},
+}
+
+// expandShortcuts replaces a shortcut given as the command with the full
+// command, the flags before it are kept where they are
+func expandShortcuts(args []string, flags *pflag.FlagSet) []string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return args
+		}
+		if strings.HasPrefix(arg, "-") && len(arg) > 1 {
+			if flagTakesValue(arg, flags) {
+				i++
+			}
+			continue
+		}
+
+		command, ok := shortcuts[arg]
+		if !ok {
+			return args
+		}
+		expanded := append([]string{}, args[:i]...)
+		expanded = append(expanded, command...)
+		return append(expanded, args[i+1:]...)
+	}
+	return args
+}
+
+// flagTakesValue is true when the value of the flag is the next argument
+func flagTakesValue(arg string, flags *pflag.FlagSet) bool {
+	if strings.Contains(arg, "=") {
+		return false
+	}
+
+	var flag *pflag.Flag
+	if strings.HasPrefix(arg, "--") {
+		flag = flags.Lookup(arg[2:])
+	} else {
+		flag = flags.ShorthandLookup(arg[len(arg)-1:])
+	}
+	return flag != nil && len(flag.NoOptDefVal) == 0
+}
+
+// suggestSubcommands makes the commands which only group others, i.e.
+// template and secret, print "did you mean" for a mistyped command like the
+// root command does, rather than their help
+func suggestSubcommands(cmd *cobra.Command) {
+	for _, sub := range cmd.Commands() {
+		if sub.HasSubCommands() && !sub.Runnable() {
+			sub.RunE = runCommandGroup
+		}
+		suggestSubcommands(sub)
+	}
+}
+
+func runCommandGroup(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return cmd.Help()
+	}
+	return fmt.Errorf("unknown command %q for %q%s", args[0], cmd.CommandPath(), commandSuggestions(cmd, args[0]))
+}
+
+// commandSuggestions lists the commands of cmd which are close to typed, in
+// the same format as cobra
+func commandSuggestions(cmd *cobra.Command, typed string) string {
+	if cmd.SuggestionsMinimumDistance <= 0 {
+		cmd.SuggestionsMinimumDistance = 2
+	}
+	suggestions := cmd.SuggestionsFor(typed)
+	if len(suggestions) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\nDid you mean this?\n")
+	for _, suggestion := range suggestions {
+		fmt.Fprintf(&b, "\t%s\n", suggestion)
+	}
+	return b.String()
+}
@@ -0,0 +1,95 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func Test_expandShortcuts(t *testing.T) {
+	cases := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "shortcut", args: []string{"pull", "https://github.com/openfaas/templates"}, want: []string{"template", "pull", "https://github.com/openfaas/templates"}},
+		{name: "subcommand of the shortcut", args: []string{"pull", "stack"}, want: []string{"template", "pull", "stack"}},
+		{name: "after a flag with a value", args: []string{"-f", "stack.yml", "pull", "stack"}, want: []string{"-f", "stack.yml", "template", "pull", "stack"}},
+		{name: "after a long flag with a value", args: []string{"--yaml", "pull"}, want: []string{"--yaml", "pull"}},
+		{name: "after a flag with = and a value", args: []string{"--yaml=stack.yml", "pull"}, want: []string{"--yaml=stack.yml", "template", "pull"}},
+		{name: "after a flag without a value", args: []string{"--yes", "pull"}, want: []string{"--yes", "template", "pull"}},
+		{name: "not the command", args: []string{"template", "pull"}, want: []string{"template", "pull"}},
+		{name: "argument of another command", args: []string{"invoke", "pull"}, want: []string{"invoke", "pull"}},
+		{name: "after --", args: []string{"--", "pull"}, want: []string{"--", "pull"}},
+		{name: "no arguments", args: []string{}, want: []string{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := expandShortcuts(tc.args, faasCmd.PersistentFlags())
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Fatalf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_suggestSubcommands(t *testing.T) {
+	root := &cobra.Command{Use: "faas-cli"}
+	group := &cobra.Command{Use: "secret"}
+	group.AddCommand(&cobra.Command{Use: "list", RunE: func(cmd *cobra.Command, args []string) error { return nil }})
+	group.AddCommand(&cobra.Command{Use: "create", RunE: func(cmd *cobra.Command, args []string) error { return nil }})
+	root.AddCommand(group)
+
+	suggestSubcommands(root)
+
+	cases := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "typo", args: []string{"secret", "lst"}, wantErr: "unknown command \"lst\" for \"faas-cli secret\"\n\nDid you mean this?\n\tlist\n"},
+		{name: "prefix", args: []string{"secret", "cr"}, wantErr: "unknown command \"cr\" for \"faas-cli secret\"\n\nDid you mean this?\n\tcreate\n"},
+		{name: "nothing close", args: []string{"secret", "rotate"}, wantErr: "unknown command \"rotate\" for \"faas-cli secret\""},
+		{name: "no command prints the help", args: []string{"secret"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out strings.Builder
+			root.SetOut(&out)
+			root.SetArgs(tc.args)
+			root.SilenceErrors = true
+			root.SilenceUsage = true
+
+			err := root.Execute()
+			if len(tc.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("want no error, got %s", err)
+				}
+				if !strings.Contains(out.String(), "Available Commands") {
+					t.Fatalf("want the help, got %q", out.String())
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("want error %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_describe_inspectAlias(t *testing.T) {
+	cmd, _, err := faasCmd.Find([]string{"inspect", "figlet"})
+	if err != nil {
+		t.Fatalf("want no error, got %s", err)
+	}
+	if cmd != describeCmd {
+		t.Fatalf("want %s, got %s", describeCmd.Name(), cmd.Name())
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+// selectTags are the tags given with --tags, only the functions with at least
+// one of them are built, pushed and deployed
+var selectTags []string
+
+// selectTagged removes the functions which have none of the tags from a stack,
+// it fails when no function is left so that a typo does not fall back to the
+// flags for a single function
+func selectTagged(services *stack.Services, tags []string) error {
+	wanted := []string{}
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); len(tag) > 0 {
+			wanted = append(wanted, tag)
+		}
+	}
+	if len(wanted) == 0 || len(services.Functions) == 0 {
+		return nil
+	}
+
+	for name, function := range services.Functions {
+		if !hasAnyTag(function, wanted) {
+			delete(services.Functions, name)
+		}
+	}
+
+	if len(services.Functions) == 0 {
+		return fmt.Errorf("no functions in the stack file are tagged with: %s", strings.Join(wanted, ", "))
+	}
+	return nil
+}
+
+func hasAnyTag(function stack.Function, tags []string) bool {
+	for _, tag := range tags {
+		if function.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
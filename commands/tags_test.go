@@ -0,0 +1,69 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+const tagsStack = `provider:
+  name: openfaas
+functions:
+  web:
+    lang: node18
+    handler: ./web
+    image: alexellis/web:latest
+    tags: [frontend, critical]
+  api:
+    lang: go
+    handler: ./api
+    image: alexellis/api:latest
+    tags: [backend, critical]
+  report:
+    lang: python3
+    handler: ./report
+    image: alexellis/report:latest
+`
+
+func Test_selectTagged(t *testing.T) {
+	cases := []struct {
+		name    string
+		tags    []string
+		want    []string
+		wantErr string
+	}{
+		{name: "no tags selects all functions", want: []string{"web", "api", "report"}},
+		{name: "one tag", tags: []string{"frontend"}, want: []string{"web"}},
+		{name: "tag shared by functions", tags: []string{"critical"}, want: []string{"web", "api"}},
+		{name: "any of the tags", tags: []string{"frontend", " backend"}, want: []string{"web", "api"}},
+		{name: "unknown tag", tags: []string{"legacy"}, wantErr: "no functions in the stack file are tagged with: legacy"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			services, err := stack.ParseYAMLData([]byte(tagsStack), "", "", false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = selectTagged(services, tc.tags)
+			if len(tc.wantErr) > 0 {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("want error %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := services.FunctionNames(); strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
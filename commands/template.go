@@ -13,12 +13,14 @@ func init() {
 
 // templateCmd allows access to store and pull commands
 var templateCmd = &cobra.Command{
-	Use:   `template [COMMAND]`,
-	Short: "OpenFaaS template store and pull commands",
-	Long:  "Allows browsing templates from store or pulling custom templates",
+	Use:     `template [COMMAND]`,
+	Aliases: []string{"templates"},
+	Short:   "OpenFaaS template store and pull commands",
+	Long:    "Allows browsing templates from store or pulling custom templates",
 	Example: `  faas-cli template pull https://github.com/custom/template
   faas-cli template store list
   faas-cli template store ls
   faas-cli template store pull ruby-http
-  faas-cli template store pull openfaas-incubator/ruby-http`,
+  faas-cli template store pull openfaas-incubator/ruby-http
+  faas-cli template new crystal`,
 }
@@ -0,0 +1,251 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1execute "github.com/alexellis/go-execute/pkg/v1"
+	"github.com/spf13/cobra"
+)
+
+const diffContextLines = 3
+
+var (
+	bumpBaseDiff        bool
+	bumpBaseTemplateDir string
+)
+
+func init() {
+	templateBumpBaseCmd.Flags().BoolVar(&bumpBaseDiff, "diff", false, "Print a patch with the changes instead of writing the Dockerfiles")
+	templateBumpBaseCmd.Flags().StringVar(&bumpBaseTemplateDir, "template-dir", "./template", "Directory with the pulled templates")
+
+	templateCmd.AddCommand(templateBumpBaseCmd)
+}
+
+// templateBumpBaseCmd pins the base images of pulled templates to their latest digests
+var templateBumpBaseCmd = &cobra.Command{
+	Use:   `bump-base [TEMPLATE...]`,
+	Short: "Pin the base images of pulled templates to their latest digests",
+	Long: `Scans the Dockerfile of each pulled template in ./template, looks up the
+current digest of every base image in its registry with "docker buildx imagetools"
+and rewrites the FROM lines as image:tag@sha256:digest. Images which are already
+pinned are updated when a newer digest has been published for their tag.
+
+Use --diff to print a patch instead of changing the files, i.e. for a pull request.`,
+	Example: `  faas-cli template bump-base
+  faas-cli template bump-base python3-http golang-middleware
+  faas-cli templates bump-base --diff > bump-base.patch`,
+	RunE: runTemplateBumpBase,
+}
+
+// resolveImageDigest looks up the digest of an image reference in its registry
+var resolveImageDigest = func(image string) (string, error) {
+	task := v1execute.ExecTask{
+		Command: "docker",
+		Args:    []string{"buildx", "imagetools", "inspect", image},
+	}
+
+	res, err := task.Execute()
+	if err != nil {
+		return "", err
+	}
+
+	if res.ExitCode != 0 {
+		return "", fmt.Errorf("unable to inspect %s: %s", image, strings.TrimSpace(res.Stderr))
+	}
+
+	for _, line := range strings.Split(res.Stdout, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "Digest:" {
+			return fields[1], nil
+		}
+	}
+
+	return "", fmt.Errorf("no digest found for %s", image)
+}
+
+func runTemplateBumpBase(cmd *cobra.Command, args []string) error {
+	dockerfiles, err := templateDockerfiles(bumpBaseTemplateDir, args)
+	if err != nil {
+		return err
+	}
+
+	digests := map[string]string{}
+	for _, dockerfile := range dockerfiles {
+		data, err := ioutil.ReadFile(dockerfile)
+		if err != nil {
+			return err
+		}
+
+		lines := strings.Split(string(data), "\n")
+		updated, changes, err := pinBaseImages(lines, digests)
+		if err != nil {
+			return fmt.Errorf("%s: %s", dockerfile, err)
+		}
+
+		if len(changes) == 0 {
+			continue
+		}
+
+		if bumpBaseDiff {
+			fmt.Print(unifiedDiff(filepath.ToSlash(dockerfile), lines, updated))
+			continue
+		}
+
+		info, err := os.Stat(dockerfile)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(dockerfile, []byte(strings.Join(updated, "\n")), info.Mode()); err != nil {
+			return err
+		}
+
+		for _, change := range changes {
+			fmt.Printf("%s: %s\n", dockerfile, change)
+		}
+	}
+
+	return nil
+}
+
+// templateDockerfiles finds the Dockerfile of each template, or of the named templates
+func templateDockerfiles(templateDir string, templates []string) ([]string, error) {
+	if len(templates) == 0 {
+		matches, err := filepath.Glob(filepath.Join(templateDir, "*", "Dockerfile"))
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no templates found in %s, run \"faas-cli template pull\" first", templateDir)
+		}
+		return matches, nil
+	}
+
+	dockerfiles := []string{}
+	for _, template := range templates {
+		dockerfile := filepath.Join(templateDir, template, "Dockerfile")
+		if _, err := os.Stat(dockerfile); err != nil {
+			return nil, fmt.Errorf("template %s has no Dockerfile in %s", template, templateDir)
+		}
+		dockerfiles = append(dockerfiles, dockerfile)
+	}
+	return dockerfiles, nil
+}
+
+// pinBaseImages rewrites FROM lines to pin each base image to its current digest,
+// digests are cached between Dockerfiles as templates often share a base image
+func pinBaseImages(lines []string, digests map[string]string) ([]string, []string, error) {
+	updated := make([]string, len(lines))
+	copy(updated, lines)
+
+	changes := []string{}
+	stages := map[string]bool{}
+
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.EqualFold(fields[0], "FROM") {
+			continue
+		}
+
+		imageIndex := 1
+		for imageIndex < len(fields) && strings.HasPrefix(fields[imageIndex], "--") {
+			imageIndex++
+		}
+		if imageIndex >= len(fields) {
+			continue
+		}
+
+		if imageIndex+2 < len(fields) && strings.EqualFold(fields[imageIndex+1], "AS") {
+			stages[strings.ToLower(fields[imageIndex+2])] = true
+		}
+
+		image := fields[imageIndex]
+		if image == "scratch" || strings.Contains(image, "$") || stages[strings.ToLower(image)] {
+			continue
+		}
+
+		tagged := image
+		currentDigest := ""
+		if at := strings.Index(image, "@"); at >= 0 {
+			tagged, currentDigest = image[:at], image[at+1:]
+		}
+
+		digest, ok := digests[tagged]
+		if !ok {
+			var err error
+			digest, err = resolveImageDigest(tagged)
+			if err != nil {
+				return nil, nil, err
+			}
+			digests[tagged] = digest
+		}
+
+		if digest == currentDigest {
+			continue
+		}
+
+		pinned := tagged + "@" + digest
+		updated[i] = strings.Replace(line, image, pinned, 1)
+		changes = append(changes, fmt.Sprintf("%s => %s", image, pinned))
+	}
+
+	return updated, changes, nil
+}
+
+// unifiedDiff writes a patch for two versions of a file with the same number of lines
+func unifiedDiff(file string, before, after []string) string {
+	// A trailing newline is not a line of its own
+	if n := len(before); n > 0 && before[n-1] == "" && after[n-1] == "" {
+		before, after = before[:n-1], after[:n-1]
+	}
+
+	changed := []int{}
+	for i := range before {
+		if before[i] != after[i] {
+			changed = append(changed, i)
+		}
+	}
+
+	if len(changed) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- a/%s\n+++ b/%s\n", file, file)
+
+	for start := 0; start < len(changed); {
+		end := start
+		for end+1 < len(changed) && changed[end+1]-changed[end] <= 2*diffContextLines {
+			end++
+		}
+
+		first := changed[start] - diffContextLines
+		if first < 0 {
+			first = 0
+		}
+		last := changed[end] + diffContextLines
+		if last >= len(before) {
+			last = len(before) - 1
+		}
+
+		count := last - first + 1
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", first+1, count, first+1, count)
+		for i := first; i <= last; i++ {
+			if before[i] == after[i] {
+				fmt.Fprintf(&sb, " %s\n", before[i])
+				continue
+			}
+			fmt.Fprintf(&sb, "-%s\n+%s\n", before[i], after[i])
+		}
+
+		start = end + 1
+	}
+
+	return sb.String()
+}
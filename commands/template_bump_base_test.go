@@ -0,0 +1,91 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_pinBaseImages(t *testing.T) {
+	defaultResolveImageDigest := resolveImageDigest
+	defer func() { resolveImageDigest = defaultResolveImageDigest }()
+
+	resolved := []string{}
+	resolveImageDigest = func(image string) (string, error) {
+		resolved = append(resolved, image)
+		switch image {
+		case "openfaas/of-watchdog:0.8.0":
+			return "sha256:aaa", nil
+		case "alpine:3.12":
+			return "sha256:bbb", nil
+		}
+		return "", fmt.Errorf("unexpected image %s", image)
+	}
+
+	dockerfile := `FROM --platform=${TARGETPLATFORM:-linux/amd64} openfaas/of-watchdog:0.8.0 as watchdog
+FROM --platform=${BUILDPLATFORM:-linux/amd64} alpine:3.12@sha256:old AS build
+COPY --from=watchdog /fwatchdog /usr/bin/fwatchdog
+FROM build as ship
+FROM scratch
+FROM ${BASE_IMAGE}
+FROM alpine:3.12@sha256:bbb
+`
+	want := `FROM --platform=${TARGETPLATFORM:-linux/amd64} openfaas/of-watchdog:0.8.0@sha256:aaa as watchdog
+FROM --platform=${BUILDPLATFORM:-linux/amd64} alpine:3.12@sha256:bbb AS build
+COPY --from=watchdog /fwatchdog /usr/bin/fwatchdog
+FROM build as ship
+FROM scratch
+FROM ${BASE_IMAGE}
+FROM alpine:3.12@sha256:bbb
+`
+
+	got, changes, err := pinBaseImages(strings.Split(dockerfile, "\n"), map[string]string{})
+	if err != nil {
+		t.Fatalf("want no error, but got: %s", err)
+	}
+
+	if strings.Join(got, "\n") != want {
+		t.Fatalf("want:\n%s\nbut got:\n%s", want, strings.Join(got, "\n"))
+	}
+
+	if len(changes) != 2 {
+		t.Fatalf("want 2 changes, but got: %v", changes)
+	}
+
+	wantResolved := []string{"openfaas/of-watchdog:0.8.0", "alpine:3.12"}
+	if !reflect.DeepEqual(wantResolved, resolved) {
+		t.Fatalf("want each image resolved once: %v, but got: %v", wantResolved, resolved)
+	}
+}
+
+func Test_unifiedDiff(t *testing.T) {
+	before := strings.Split("FROM a:1\nFROM b:1\nRUN 1\nRUN 2\nRUN 3\nRUN 4\nRUN 5\nRUN 6\nRUN 7\nRUN 8\nFROM c:1\n", "\n")
+	after := strings.Split("FROM a:1@sha256:a\nFROM b:1@sha256:b\nRUN 1\nRUN 2\nRUN 3\nRUN 4\nRUN 5\nRUN 6\nRUN 7\nRUN 8\nFROM c:1@sha256:c\n", "\n")
+
+	want := `--- a/template/go/Dockerfile
++++ b/template/go/Dockerfile
+@@ -1,5 +1,5 @@
+-FROM a:1
++FROM a:1@sha256:a
+-FROM b:1
++FROM b:1@sha256:b
+ RUN 1
+ RUN 2
+ RUN 3
+@@ -8,4 +8,4 @@
+ RUN 6
+ RUN 7
+ RUN 8
+-FROM c:1
++FROM c:1@sha256:c
+`
+
+	got := unifiedDiff("template/go/Dockerfile", before, after)
+	if got != want {
+		t.Fatalf("want:\n%s\nbut got:\n%s", want, got)
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var templateNewDir string
+
+var validTemplateName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9._]*[a-z0-9])?$`)
+
+func init() {
+	templateNewCmd.Flags().StringVar(&templateNewDir, "template-dir", "./template", "Directory to create the template in")
+
+	templateCmd.AddCommand(templateNewCmd)
+}
+
+// templateNewCmd scaffolds a new language template
+var templateNewCmd = &cobra.Command{
+	Use:   `new LANG`,
+	Short: "Create the skeleton of a new language template",
+	Long: `Creates the files every template needs in ./template/LANG: a template.yml,
+a Dockerfile with the of-watchdog, a handler stub and a test script which is run
+during the build. Replace the handler and the runtime in the Dockerfile with
+those of your language, then try the template with:
+
+  faas-cli new my-fn --lang LANG
+  faas-cli build -f my-fn.yml
+
+You are asked before an existing template is overwritten, pass --yes to
+overwrite it without asking.`,
+	Example: `  faas-cli template new crystal
+  faas-cli template new zig --template-dir ./templates/template`,
+	RunE: runTemplateNew,
+}
+
+func runTemplateNew(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("give the name of the language for the template, i.e. faas-cli template new crystal")
+	}
+
+	language := args[0]
+
+	dir := filepath.Join(templateNewDir, language)
+	if _, err := os.Stat(dir); err == nil && validTemplateName.MatchString(language) {
+		overwrite, err := confirm(fmt.Sprintf("Template %s already exists in %s, overwrite it?", language, templateNewDir))
+		if err != nil && !errors.Is(err, errNonInteractive) {
+			return err
+		}
+		if overwrite {
+			if err := os.RemoveAll(dir); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := scaffoldTemplate(templateNewDir, language); err != nil {
+		return err
+	}
+
+	fmt.Printf("Template %s created in %s\n", language, filepath.Join(templateNewDir, language))
+	return nil
+}
+
+// scaffoldTemplate writes the files of a new template, it will not
+// overwrite a template which already exists
+func scaffoldTemplate(templateDir, language string) error {
+	if !validTemplateName.MatchString(language) {
+		return fmt.Errorf("template name can only contain a-z, 0-9, dashes, dots and underscores")
+	}
+
+	dir := filepath.Join(templateDir, language)
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("template %s already exists in %s", language, templateDir)
+	}
+
+	files := templateSkeleton(language)
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), defaultTemplateDirPermissions); err != nil {
+			return err
+		}
+
+		mode := os.FileMode(0644)
+		if strings.HasSuffix(name, ".sh") || name == "function/handler" {
+			mode = 0755
+		}
+		if err := ioutil.WriteFile(path, []byte(files[name]), mode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// templateSkeleton returns the content of each file in a new template
+func templateSkeleton(language string) map[string]string {
+	replacer := strings.NewReplacer("LANGUAGE", language)
+
+	return map[string]string{
+		"template.yml":     replacer.Replace(skeletonTemplateYAML),
+		"Dockerfile":       replacer.Replace(skeletonDockerfile),
+		"function/handler": replacer.Replace(skeletonHandler),
+		"function/test.sh": skeletonTest,
+	}
+}
+
+const skeletonTemplateYAML = `language: LANGUAGE
+fprocess: ./function/handler
+watchdog:
+  modes:
+  - streaming
+  - serializing
+welcome_message: |
+  You have created a new function which uses the LANGUAGE template.
+  Edit the handler, then run the tests in test.sh with faas-cli build.
+`
+
+const skeletonDockerfile = `FROM --platform=${TARGETPLATFORM:-linux/amd64} openfaas/of-watchdog:0.8.2 as watchdog
+FROM --platform=${TARGETPLATFORM:-linux/amd64} alpine:3.13 as ship
+
+COPY --from=watchdog /fwatchdog /usr/bin/fwatchdog
+RUN chmod +x /usr/bin/fwatchdog
+
+# Install the runtime and tools for LANGUAGE here, i.e.
+# RUN apk add --no-cache python3
+
+RUN addgroup -S app && adduser -S -g app app
+WORKDIR /home/app
+
+COPY function/ ./function/
+RUN chown -R app:app ./
+
+USER app
+
+# The image is only built when the handler's tests pass
+RUN ./function/test.sh
+
+ENV fprocess="./function/handler"
+ENV mode="streaming"
+EXPOSE 8080
+
+HEALTHCHECK --interval=3s CMD [ -e /tmp/.lock ] || exit 1
+
+CMD ["fwatchdog"]
+`
+
+const skeletonHandler = `#!/bin/sh
+# Replace this stub with a handler written in LANGUAGE, the request body is
+# given on stdin and whatever is written to stdout is the response.
+cat
+`
+
+const skeletonTest = `#!/bin/sh
+# Tests for the handler, the build fails when this script exits non-zero.
+set -e
+
+want="hello"
+got=$(printf "%s" "$want" | ./function/handler)
+
+if [ "$got" != "$want" ]; then
+  echo "want response: $want, but got: $got"
+  exit 1
+fi
+
+echo "Tests passed"
+`
@@ -0,0 +1,59 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+func Test_scaffoldTemplate(t *testing.T) {
+	templateDir, err := ioutil.TempDir("", "template-new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(templateDir)
+
+	if err := scaffoldTemplate(templateDir, "crystal"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"template.yml", "Dockerfile", "function/handler", "function/test.sh"} {
+		if _, err := os.Stat(filepath.Join(templateDir, "crystal", name)); err != nil {
+			t.Errorf("want %s in the new template, but got: %s", name, err)
+		}
+	}
+
+	info, err := os.Stat(filepath.Join(templateDir, "crystal", "function", "test.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&0111 == 0 {
+		t.Errorf("want test.sh to be executable, but got mode %s", info.Mode())
+	}
+
+	langTemplate, err := stack.ParseYAMLForLanguageTemplate(filepath.Join(templateDir, "crystal", "template.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if langTemplate.Language != "crystal" {
+		t.Errorf("want language crystal in template.yml, but got %q", langTemplate.Language)
+	}
+
+	if err := scaffoldTemplate(templateDir, "crystal"); err == nil {
+		t.Errorf("want error when the template already exists, but got nil")
+	}
+}
+
+func Test_scaffoldTemplate_InvalidName(t *testing.T) {
+	for _, name := range []string{"", "Crystal", "../crystal", "crystal/"} {
+		if err := scaffoldTemplate(os.TempDir(), name); err == nil {
+			t.Errorf("want error for template name %q, but got nil", name)
+		}
+	}
+}
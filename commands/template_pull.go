@@ -5,18 +5,29 @@ package commands
 import (
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/openfaas/faas-cli/flags"
 	"github.com/spf13/cobra"
 )
 
 var (
 	repository string
 	overwrite  bool
+	prune      bool
 	pullDebug  bool
+
+	overwriteLanguages []string
+
+	verifyTemplateFlag   bool
+	templateChecksumFlag string
 )
 
 func init() {
-	templatePullCmd.Flags().BoolVar(&overwrite, "overwrite", false, "Overwrite existing templates?")
+	addOverwriteFlag(templatePullCmd)
+	templatePullCmd.Flags().BoolVar(&prune, "prune", false, "Delete local templates which were pulled from the repository, but are no longer in it")
+	templatePullCmd.Flags().BoolVar(&verifyTemplateFlag, "verify", false, "Fail unless the tag, or the commit when no tag is given, has a valid git signature")
+	templatePullCmd.Flags().StringVar(&templateChecksumFlag, "checksum", "", "Fail unless the sha256 of the template folder matches, i.e. sha256:HEX")
 	templatePullCmd.Flags().BoolVar(&pullDebug, "debug", false, "Enable debug output")
 
 	templateCmd.AddCommand(templatePullCmd)
@@ -30,10 +41,23 @@ var templatePullCmd = &cobra.Command{
 directory from the root of the repo, if it exists.
 
 [REPOSITORY_URL] may specify a specific branch or tag to copy by adding a URL fragment with the branch or tag name.
+
+Use --verify to require a valid signature on the tag, or on the commit when no tag is given.
+Signatures are checked with "git verify-tag" and "git verify-commit", so the keys must be
+trusted by your local gpg or ssh configuration. Use --checksum to pin the content of the
+template folder to the checksum printed by a previous pull.
+
+"faas-cli pull" is a shortcut for "faas-cli template pull".
 	`,
 	Example: `
   faas-cli template pull https://github.com/openfaas/templates
   faas-cli template pull https://github.com/openfaas/templates#1.0
+  faas-cli template pull https://github.com/openfaas/templates --overwrite
+  faas-cli template pull https://github.com/openfaas/templates --overwrite-lang node12,python3
+  faas-cli template pull https://github.com/openfaas/templates --prune
+  faas-cli template pull https://github.com/openfaas/templates#1.0 --verify
+  faas-cli template pull https://github.com/openfaas/templates#1.0 --checksum sha256:9f86d0...
+  faas-cli pull https://github.com/openfaas/templates
 `,
 	RunE: runTemplatePull,
 }
@@ -43,8 +67,25 @@ func runTemplatePull(cmd *cobra.Command, args []string) error {
 	if len(args) > 0 {
 		repository = args[0]
 	}
+	// A bool flag never takes the next argument, so --overwrite node12,python3
+	// would otherwise overwrite every template from a repository "node12,python3"
+	if overwrite && len(repository) > 0 && !strings.ContainsAny(repository, "/:") {
+		return fmt.Errorf("%s is not a repository URL, use --overwrite-lang %s to overwrite only those templates", repository, repository)
+	}
 	repository = getTemplateURL(repository, os.Getenv(templateURLEnvironment), DefaultTemplateRepository)
-	return pullTemplate(repository)
+	return pullTemplate(os.Stdout, repository)
+}
+
+// addOverwriteFlag adds --overwrite to overwrite all templates, and
+// --overwrite-lang to overwrite only the templates of some languages
+func addOverwriteFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "Overwrite existing templates?")
+	cmd.Flags().StringSliceVar(&overwriteLanguages, "overwrite-lang", nil, "Overwrite only the existing templates of these languages, i.e. node12,python3")
+}
+
+// overwriteFlags is the templates which may be overwritten by a pull
+func overwriteFlags() flags.Overwrite {
+	return flags.Overwrite{All: overwrite, Languages: overwriteLanguages}
 }
 
 func pullDebugPrint(message string) {
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 
@@ -17,7 +18,7 @@ var (
 )
 
 func init() {
-	templatePullStackCmd.Flags().BoolVar(&overwrite, "overwrite", false, "Overwrite existing templates?")
+	addOverwriteFlag(templatePullStackCmd)
 	templatePullStackCmd.Flags().BoolVar(&pullDebug, "debug", false, "Enable debug output")
 
 	templatePullCmd.AddCommand(templatePullStackCmd)
@@ -41,7 +42,7 @@ func runTemplatePullStack(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	return pullStackTemplates(templatesConfig, cmd)
+	return pullStackTemplates(os.Stdout, templatesConfig)
 }
 
 func loadTemplateConfig() ([]stack.TemplateSource, error) {
@@ -69,20 +70,24 @@ func readStackConfig() (stack.Configuration, error) {
 	return configField, nil
 }
 
-func pullStackTemplates(templateInfo []stack.TemplateSource, cmd *cobra.Command) error {
+// pullStackTemplates pulls each template from its source, or from the store
+// when it has none, the progress is written to out
+func pullStackTemplates(out io.Writer, templateInfo []stack.TemplateSource) error {
 	for _, val := range templateInfo {
-		fmt.Printf("Pulling template: %s from configuration file: %s\n", val.Name, yamlFile)
+		fmt.Fprintf(out, "Pulling template: %s from configuration file: %s\n", val.Name, yamlFile)
+		done := timings.track(phaseTemplates, val.Name)
 		if len(val.Source) == 0 {
-			pullErr := runTemplateStorePull(cmd, []string{val.Name})
+			pullErr := pullStoreTemplate(out, []string{val.Name})
 			if pullErr != nil {
 				return pullErr
 			}
 		} else {
-			pullErr := pullTemplate(val.Source)
+			pullErr := pullTemplate(out, val.Source)
 			if pullErr != nil {
 				return pullErr
 			}
 		}
+		done()
 	}
 	return nil
 }
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -101,7 +102,7 @@ func Test_pullAllTemplates(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.title, func(t *testing.T) {
-			actualError := pullStackTemplates(test.existingTemplates, templatePullStackCmd)
+			actualError := pullStackTemplates(ioutil.Discard, test.existingTemplates)
 			if actualError != nil && test.expectedError == false {
 				t.Errorf("Unexpected error: %s", actualError.Error())
 			}
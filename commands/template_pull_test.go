@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"reflect"
 	"regexp"
 	"strings"
 	"testing"
+
+	"github.com/openfaas/faas-cli/flags"
 )
 
 func Test_templatePull(t *testing.T) {
@@ -74,6 +77,31 @@ func Test_templatePull(t *testing.T) {
 		}
 	})
 
+	t.Run("OverwriteLanguageList", func(t *testing.T) {
+		defer tearDownFetchTemplates(t)
+		defer func() {
+			overwrite = false
+			overwriteLanguages = nil
+		}()
+
+		faasCmd.SetArgs([]string{"template", "pull", "--overwrite", "node,python"})
+		err := faasCmd.Execute()
+		if err == nil || !strings.Contains(err.Error(), "use --overwrite-lang node,python") {
+			t.Fatalf("want an error suggesting --overwrite-lang, got: %v", err)
+		}
+		overwrite = false
+
+		faasCmd.SetArgs([]string{"template", "pull", "--overwrite-lang", "node,python", localTemplateRepository})
+		if err := faasCmd.Execute(); err != nil {
+			t.Fatalf("unexpected error while executing template pull with --overwrite-lang: %s", err)
+		}
+
+		want := flags.Overwrite{Languages: []string{"node", "python"}}
+		if got := overwriteFlags(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("want %+v, got %+v", want, got)
+		}
+	})
+
 	t.Run("InvalidUrlError", func(t *testing.T) {
 		faasCmd.SetArgs([]string{"template", "pull", "user@host.xz:openfaas/faas-cli.git"})
 		err := faasCmd.Execute()
@@ -8,6 +8,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -22,6 +23,9 @@ const (
 	// DefaultTemplatesStore is the URL where the official store can be found
 	DefaultTemplatesStore = "https://raw.githubusercontent.com/openfaas/store/master/templates.json"
 	mainPlatform          = "x86_64"
+
+	// maxTemplateStoreSize is the largest store file which will be read
+	maxTemplateStoreSize = 5 * 1024 * 1024
 )
 
 var (
@@ -92,10 +96,13 @@ func getTemplateInfo(repository string) ([]TemplateInfo, error) {
 		return nil, fmt.Errorf("unexpected status code wanted: %d got: %d", http.StatusOK, res.StatusCode)
 	}
 
-	body, bodyErr := ioutil.ReadAll(res.Body)
+	body, bodyErr := ioutil.ReadAll(io.LimitReader(res.Body, maxTemplateStoreSize+1))
 	if bodyErr != nil {
 		return nil, fmt.Errorf("error while reading data from templates body: %s", bodyErr.Error())
 	}
+	if len(body) > maxTemplateStoreSize {
+		return nil, fmt.Errorf("the template store at %s is larger than the limit of %d bytes", repository, maxTemplateStoreSize)
+	}
 
 	templatesInfo := []TemplateInfo{}
 	unmarshallErr := json.Unmarshal(body, &templatesInfo)
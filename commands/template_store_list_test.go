@@ -1,6 +1,9 @@
 package commands
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -28,3 +31,15 @@ func Test_FilterTemplate(t *testing.T) {
 		}
 	}
 }
+
+func Test_getTemplateInfo_SizeLimit(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[" + strings.Repeat(" ", maxTemplateStoreSize) + "]"))
+	}))
+	defer s.Close()
+
+	_, err := getTemplateInfo(s.URL)
+	if err == nil || !strings.Contains(err.Error(), "larger than the limit") {
+		t.Fatalf("want error for a store over the size limit, but got: %v", err)
+	}
+}
@@ -5,6 +5,7 @@ package commands
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -31,6 +32,12 @@ var templateStorePullCmd = &cobra.Command{
 }
 
 func runTemplateStorePull(cmd *cobra.Command, args []string) error {
+	return pullStoreTemplate(os.Stdout, args)
+}
+
+// pullStoreTemplate pulls the template named in args from the repository the
+// store lists for it, the progress is written to out
+func pullStoreTemplate(out io.Writer, args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("\nNeed to specify one of the store templates, check available ones by running the command:\n\nfaas-cli template store list\n")
 	}
@@ -51,7 +58,7 @@ func runTemplateStorePull(cmd *cobra.Command, args []string) error {
 	for _, storeTemplate := range storeTemplates {
 		sourceName := fmt.Sprintf("%s/%s", storeTemplate.Source, storeTemplate.TemplateName)
 		if templateName == storeTemplate.TemplateName || templateName == sourceName {
-			err := runTemplatePull(cmd, []string{storeTemplate.Repository})
+			err := pullTemplate(out, storeTemplate.Repository)
 			if err != nil {
 				return fmt.Errorf("error while pulling template: %s : %s", storeTemplate.TemplateName, err.Error())
 			}
@@ -0,0 +1,178 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	v1execute "github.com/alexellis/go-execute/pkg/v1"
+)
+
+const templateChecksumPrefix = "sha256:"
+
+var templateChecksumPattern = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
+// templateVerification is how a pulled template repository is verified
+// before any of its templates are copied into ./template
+type templateVerification struct {
+	// Signature requires a valid signature on the tag, or on the commit when
+	// no tag was given, as checked by git verify-tag and git verify-commit
+	Signature bool
+
+	// Checksum is the expected sha256 of the repository's template folder
+	Checksum string
+}
+
+// parseTemplateChecksum validates a checksum given as sha256:HEX
+func parseTemplateChecksum(checksum string) (string, error) {
+	if len(checksum) == 0 {
+		return "", nil
+	}
+
+	checksum = strings.ToLower(checksum)
+	if !strings.HasPrefix(checksum, templateChecksumPrefix) {
+		checksum = templateChecksumPrefix + checksum
+	}
+
+	if !templateChecksumPattern.MatchString(checksum) {
+		return "", fmt.Errorf("invalid checksum %q, give it as sha256:HEX", checksum)
+	}
+	return checksum, nil
+}
+
+// verifyTemplates checks the signature and checksum of a cloned repository
+func verifyTemplates(repoPath, refName string, verification templateVerification) error {
+	if verification.Signature {
+		if err := verifyTemplateSignature(repoPath, refName); err != nil {
+			return fmt.Errorf("signature verification failed: %s", err)
+		}
+		fmt.Printf("Verified the signature of %s\n", describeTemplateRef(refName))
+	}
+
+	templatePath := filepath.Join(repoPath, templateDirectory)
+	if _, err := os.Stat(templatePath); err != nil && len(verification.Checksum) == 0 {
+		return nil
+	}
+
+	checksum, err := templateChecksum(templatePath)
+	if err != nil {
+		return err
+	}
+	log.Printf("Template checksum: %s\n", checksum)
+
+	if len(verification.Checksum) > 0 && checksum != verification.Checksum {
+		return fmt.Errorf("checksum verification failed: want %s, but got %s", verification.Checksum, checksum)
+	}
+
+	return nil
+}
+
+func describeTemplateRef(refName string) string {
+	if len(refName) == 0 {
+		return "HEAD"
+	}
+	return refName
+}
+
+// verifyTemplateSignature runs git verify-tag when the ref is a tag, otherwise
+// git verify-commit, git uses gpg, or ssh when gpg.format is set to ssh
+var verifyTemplateSignature = func(repoPath, refName string) error {
+	args := []string{"-C", repoPath, "verify-commit", "HEAD"}
+	if len(refName) > 0 && runGit(repoPath, "show-ref", "--verify", "--quiet", "refs/tags/"+refName) == nil {
+		args = []string{"-C", repoPath, "verify-tag", refName}
+	}
+
+	task := v1execute.ExecTask{
+		Command: "git",
+		Args:    args,
+	}
+
+	res, err := task.Execute()
+	if err != nil {
+		return err
+	}
+
+	if res.ExitCode != 0 {
+		message := strings.TrimSpace(res.Stderr)
+		if len(message) == 0 {
+			message = fmt.Sprintf("no valid signature on %s", describeTemplateRef(refName))
+		}
+		return fmt.Errorf("%s", message)
+	}
+	return nil
+}
+
+func runGit(repoPath string, args ...string) error {
+	task := v1execute.ExecTask{
+		Command: "git",
+		Args:    append([]string{"-C", repoPath}, args...),
+	}
+
+	res, err := task.Execute()
+	if err != nil {
+		return err
+	}
+	if res.ExitCode != 0 {
+		return fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(res.Stderr))
+	}
+	return nil
+}
+
+// templateChecksum hashes the path and content of every file in a template
+// folder in lexical order, file modes and timestamps are left out so that
+// the checksum is the same on every OS
+func templateChecksum(dir string) (string, error) {
+	hash := sha256.New()
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		switch {
+		case info.IsDir():
+			return nil
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(hash, "link %s %s\n", rel, filepath.ToSlash(target))
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		content := sha256.New()
+		if _, err := io.Copy(content, file); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(hash, "file %s %s\n", rel, hex.EncodeToString(content.Sum(nil)))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return templateChecksumPrefix + hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,101 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_parseTemplateChecksum(t *testing.T) {
+	hex := strings.Repeat("ab", 32)
+
+	cases := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", input: "", want: ""},
+		{name: "prefixed", input: "sha256:" + hex, want: "sha256:" + hex},
+		{name: "bare hex", input: hex, want: "sha256:" + hex},
+		{name: "upper case", input: "SHA256:" + strings.ToUpper(hex), want: "sha256:" + hex},
+		{name: "too short", input: "sha256:abc", wantErr: true},
+		{name: "other algorithm", input: "md5:" + hex, wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := parseTemplateChecksum(c.input)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("want error for %q, but got nil", c.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Fatalf("want %q, but got %q", c.want, got)
+			}
+		})
+	}
+}
+
+func Test_verifyTemplates(t *testing.T) {
+	repoPath, err := ioutil.TempDir("", "verify-templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoPath)
+
+	writeTestTemplate(t, filepath.Join(repoPath, "template", "node12"), "language: node12")
+
+	checksum, err := templateChecksum(filepath.Join(repoPath, "template"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("matching checksum", func(t *testing.T) {
+		if err := verifyTemplates(repoPath, "", templateVerification{Checksum: checksum}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("checksum changes with content", func(t *testing.T) {
+		writeTestTemplate(t, filepath.Join(repoPath, "template", "python3"), "language: python3")
+		defer os.RemoveAll(filepath.Join(repoPath, "template", "python3"))
+
+		err := verifyTemplates(repoPath, "", templateVerification{Checksum: checksum})
+		if err == nil || !strings.Contains(err.Error(), "checksum verification failed") {
+			t.Fatalf("want checksum verification error, but got: %v", err)
+		}
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		defer func(verify func(string, string) error) { verifyTemplateSignature = verify }(verifyTemplateSignature)
+		verifyTemplateSignature = func(repoPath, refName string) error {
+			return fmt.Errorf("no signature found")
+		}
+
+		err := verifyTemplates(repoPath, "1.0", templateVerification{Signature: true})
+		if err == nil || !strings.Contains(err.Error(), "signature verification failed") {
+			t.Fatalf("want signature verification error, but got: %v", err)
+		}
+	})
+
+	t.Run("unsigned commit", func(t *testing.T) {
+		localTemplateRepository := setupLocalTemplateRepo(t)
+		defer os.RemoveAll(localTemplateRepository)
+
+		if err := verifyTemplateSignature(localTemplateRepository, ""); err == nil {
+			t.Fatalf("want error for an unsigned commit, but got nil")
+		}
+	})
+}
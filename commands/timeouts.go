@@ -0,0 +1,65 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	readTimeoutEnv  = "read_timeout"
+	writeTimeoutEnv = "write_timeout"
+	execTimeoutEnv  = "exec_timeout"
+)
+
+// applyTimeout sets the read, write and exec timeouts of the watchdog to the
+// same value, which replaces any set in stack.yml or with --env
+func applyTimeout(envs map[string]string, timeout time.Duration) map[string]string {
+	if timeout <= 0 {
+		return envs
+	}
+
+	value := timeout.String()
+	return mergeMap(envs, map[string]string{
+		readTimeoutEnv:  value,
+		writeTimeoutEnv: value,
+		execTimeoutEnv:  value,
+	})
+}
+
+// checkTimeouts returns a warning when the exec_timeout of a function is longer
+// than its read_timeout or write_timeout, as the request would be cut off by the
+// shorter timeout first
+func checkTimeouts(functionName string, envs map[string]string) string {
+	exec, ok := parseTimeoutEnv(envs[execTimeoutEnv])
+	if !ok {
+		return ""
+	}
+
+	for _, name := range []string{readTimeoutEnv, writeTimeoutEnv} {
+		timeout, ok := parseTimeoutEnv(envs[name])
+		if ok && timeout < exec {
+			return fmt.Sprintf("%s: %s (%s) is shorter than %s (%s), requests will be cut off after %s, set all three with --timeout",
+				functionName, name, timeout, execTimeoutEnv, exec, timeout)
+		}
+	}
+	return ""
+}
+
+// parseTimeoutEnv parses a timeout as the watchdog does, a number without a
+// unit is in seconds
+func parseTimeoutEnv(value string) (time.Duration, bool) {
+	if len(value) == 0 {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, seconds > 0
+	}
+
+	timeout, err := time.ParseDuration(value)
+	return timeout, err == nil && timeout > 0
+}
@@ -0,0 +1,64 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_applyTimeout(t *testing.T) {
+	envs := map[string]string{"read_timeout": "5s", "debug": "true"}
+
+	got := applyTimeout(envs, 90*time.Second)
+	want := map[string]string{
+		"debug":         "true",
+		"read_timeout":  "1m30s",
+		"write_timeout": "1m30s",
+		"exec_timeout":  "1m30s",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, but got %v", want, got)
+	}
+
+	if got := applyTimeout(envs, 0); !reflect.DeepEqual(got, envs) {
+		t.Errorf("want the environment unchanged without a timeout, but got %v", got)
+	}
+}
+
+func Test_checkTimeouts(t *testing.T) {
+	cases := []struct {
+		name string
+		envs map[string]string
+		want string
+	}{
+		{
+			name: "no timeouts",
+			envs: map[string]string{},
+		},
+		{
+			name: "consistent",
+			envs: map[string]string{"read_timeout": "60s", "write_timeout": "1m", "exec_timeout": "60"},
+		},
+		{
+			name: "write timeout shorter than exec timeout",
+			envs: map[string]string{"read_timeout": "2m", "write_timeout": "10s", "exec_timeout": "2m"},
+			want: "fn: write_timeout (10s) is shorter than exec_timeout (2m0s), requests will be cut off after 10s, set all three with --timeout",
+		},
+		{
+			name: "read timeout in seconds",
+			envs: map[string]string{"read_timeout": "5", "exec_timeout": "30s"},
+			want: "fn: read_timeout (5s) is shorter than exec_timeout (30s), requests will be cut off after 5s, set all three with --timeout",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := checkTimeouts("fn", c.envs); got != c.want {
+				t.Errorf("want %q, but got %q", c.want, got)
+			}
+		})
+	}
+}
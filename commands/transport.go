@@ -0,0 +1,51 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"time"
+
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/spf13/cobra"
+)
+
+// Flags which tune the connections to the gateway and functions
+var (
+	maxIdleConns    int
+	useHTTP2        bool
+	tlsSessionCache int
+	idleConnTimeout time.Duration
+)
+
+func init() {
+	faasCmd.PersistentFlags().IntVar(&maxIdleConns, "max-idle-conns", 0, "Idle connections to keep open to the gateway for reuse, 0 keeps the default of 2")
+	faasCmd.PersistentFlags().BoolVar(&useHTTP2, "http2", true, "Use HTTP/2 with gateways which support it over TLS")
+	faasCmd.PersistentFlags().IntVar(&tlsSessionCache, "tls-session-cache", 0, "TLS sessions to keep to resume connections without a full handshake, 0 disables the cache")
+
+	faasCmd.PersistentFlags().DurationVar(&idleConnTimeout, "idle-conn-timeout", proxy.DefaultIdleConnTimeout, "How long to keep idle connections open to the gateway for reuse")
+
+	cobra.OnInitialize(applyTransportFlags)
+}
+
+// applyTransportFlags configures the transports made by the proxy package
+// once the flags have been parsed
+func applyTransportFlags() {
+	proxy.DefaultTransportConfig = transportConfig(maxIdleConns, useHTTP2, tlsSessionCache, idleConnTimeout)
+}
+
+func transportConfig(maxIdleConns int, useHTTP2 bool, tlsSessionCache int, idleConnTimeout time.Duration) proxy.TransportConfig {
+	config := proxy.TransportConfig{
+		DisableHTTP2: !useHTTP2,
+	}
+	if maxIdleConns > 0 {
+		config.MaxIdleConns = maxIdleConns
+	}
+	if tlsSessionCache > 0 {
+		config.TLSSessionCacheSize = tlsSessionCache
+	}
+	if idleConnTimeout > 0 && idleConnTimeout != proxy.DefaultIdleConnTimeout {
+		config.IdleConnTimeout = idleConnTimeout
+	}
+	return config
+}
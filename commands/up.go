@@ -5,6 +5,7 @@ package commands
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -64,18 +65,42 @@ func preRunUp(cmd *cobra.Command, args []string) error {
 }
 
 func upHandler(cmd *cobra.Command, args []string) error {
-	if err := runBuild(cmd, args); err != nil {
-		return err
-	}
-	fmt.Println()
-	if !skipPush {
-		if err := runPush(cmd, args); err != nil {
+	defer reportProfile(os.Stderr)
+	defer reportResults()
+
+	// --quiet is registered by build, so it is shared with deploy which then
+	// prints only the URL of each function
+	if quietBuild {
+		images, err := buildFunctions(cmd, args)
+		if err == nil && !skipPush && len(remoteBuilder) == 0 {
+			err = runPush(cmd, args)
+		}
+		if err != nil {
+			return err
+		}
+
+		if skipDeploy {
+			for _, image := range images {
+				fmt.Println(image)
+			}
+		}
+	} else {
+		if err := runBuild(cmd, args); err != nil {
 			return err
 		}
 		fmt.Println()
+		// A remote builder pushes the images itself
+		if !skipPush && len(remoteBuilder) == 0 {
+			if err := runPush(cmd, args); err != nil {
+				return err
+			}
+			fmt.Println()
+		}
 	}
 	if !skipDeploy {
-		if err := runDeploy(cmd, args); err != nil {
+		options := deployOptions(deployFlags)
+		options.Quiet = options.Quiet || quietBuild
+		if err := deployWithOptions(options); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,153 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/openfaas/faas-cli/vault"
+	types "github.com/openfaas/faas-provider/types"
+)
+
+// secretWriter creates and updates secrets on the gateway
+type secretWriter interface {
+	CreateSecret(ctx context.Context, secret types.Secret) (int, string)
+	UpdateSecret(ctx context.Context, secret types.Secret) (int, string)
+}
+
+// secretReader reads the value of a secret referenced in stack.yml
+type secretReader interface {
+	Read(ref vault.Reference) (string, error)
+}
+
+// newVaultReader is overridden in tests
+var newVaultReader = func() (secretReader, error) {
+	return vault.NewClientFromEnv()
+}
+
+// vaultSecrets logs into vault on the first reference to it, and uses the
+// same login for the rest of the functions of the command
+type vaultSecrets struct {
+	reader secretReader
+
+	// stored is the reference which each gateway secret was stored from, by
+	// namespace and name, as the name is only the key of the reference
+	stored map[string]vault.Reference
+}
+
+// claim reserves the gateway secret for ref, it fails when a reference with
+// another path has the same key, which would overwrite the secret. It reports
+// whether the secret was already stored from ref.
+func (v *vaultSecrets) claim(ref vault.Reference, namespace string) (bool, error) {
+	if v.stored == nil {
+		v.stored = map[string]vault.Reference{}
+	}
+
+	name := namespace + "/" + ref.Key
+	if existing, ok := v.stored[name]; ok {
+		if existing.Path != ref.Path {
+			return false, fmt.Errorf("vault secrets %s%s#%s and %s%s#%s would both be stored as the secret %s, use a different key for one of them",
+				vault.ReferencePrefix, existing.Path, existing.Key, vault.ReferencePrefix, ref.Path, ref.Key, ref.Key)
+		}
+		return true, nil
+	}
+	v.stored[name] = ref
+	return false, nil
+}
+
+// resolve creates or refreshes a gateway secret for each secret that refers
+// to vault, i.e. vault:kv/data/app#token and returns the list of secrets with
+// each reference replaced by the name of the gateway secret. Each secret which
+// is refreshed is printed to out.
+func (v *vaultSecrets) resolve(ctx context.Context, client secretWriter, out io.Writer, secrets []string, namespace string) ([]string, error) {
+	resolved := make([]string, 0, len(secrets))
+
+	for _, secret := range secrets {
+		if !vault.IsReference(secret) {
+			resolved = append(resolved, secret)
+			continue
+		}
+
+		ref, err := vault.ParseReference(secret)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := validateSecretName(ref.Key); err != nil {
+			return nil, err
+		}
+
+		stored, err := v.claim(ref, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if stored {
+			resolved = append(resolved, ref.Key)
+			continue
+		}
+
+		if v.reader == nil {
+			if v.reader, err = newVaultReader(); err != nil {
+				return nil, err
+			}
+		}
+
+		value, err := v.reader.Read(ref)
+		if err != nil {
+			return nil, err
+		}
+
+		gatewaySecret := types.Secret{
+			Name:      ref.Key,
+			Namespace: namespace,
+			Value:     value,
+		}
+
+		fmt.Fprintf(out, "Refreshing secret %s from vault: %s\n", ref.Key, ref.Path)
+		status, output := client.CreateSecret(ctx, gatewaySecret)
+		if status == http.StatusConflict {
+			status, output = client.UpdateSecret(ctx, gatewaySecret)
+		}
+
+		if badSecretStatusCode(status) {
+			return nil, fmt.Errorf("unable to store secret %s from vault: %s", ref.Key, output)
+		}
+
+		resolved = append(resolved, ref.Key)
+	}
+
+	return resolved, nil
+}
+
+// names replaces each reference to vault with the name of the gateway secret
+// it would be stored in, without reading vault
+func (v *vaultSecrets) names(secrets []string, namespace string) ([]string, error) {
+	names := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		if !vault.IsReference(secret) {
+			names = append(names, secret)
+			continue
+		}
+
+		ref, err := vault.ParseReference(secret)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := validateSecretName(ref.Key); err != nil {
+			return nil, err
+		}
+		if _, err := v.claim(ref, namespace); err != nil {
+			return nil, err
+		}
+		names = append(names, ref.Key)
+	}
+	return names, nil
+}
+
+func badSecretStatusCode(statusCode int) bool {
+	return statusCode != http.StatusOK && statusCode != http.StatusCreated && statusCode != http.StatusAccepted
+}
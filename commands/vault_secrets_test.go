@@ -0,0 +1,194 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/openfaas/faas-cli/test"
+	"github.com/openfaas/faas-cli/vault"
+	types "github.com/openfaas/faas-provider/types"
+)
+
+type fakeSecretWriter struct {
+	existing map[string]bool
+	written  map[string]string
+}
+
+func (f *fakeSecretWriter) CreateSecret(ctx context.Context, secret types.Secret) (int, string) {
+	if f.existing[secret.Name] {
+		return http.StatusConflict, "exists"
+	}
+	f.written[secret.Name] = secret.Value
+	return http.StatusCreated, "Created"
+}
+
+func (f *fakeSecretWriter) UpdateSecret(ctx context.Context, secret types.Secret) (int, string) {
+	f.written[secret.Name] = secret.Value
+	return http.StatusOK, "Updated"
+}
+
+type fakeVaultReader map[string]string
+
+func (f fakeVaultReader) Read(ref vault.Reference) (string, error) {
+	return f[ref.Path+"#"+ref.Key], nil
+}
+
+func Test_resolveVaultSecrets(t *testing.T) {
+	newVaultReader = func() (secretReader, error) {
+		return fakeVaultReader{
+			"kv/data/app#token":   "s3cr3t",
+			"kv/data/app#api-key": "k3y",
+		}, nil
+	}
+	defer func() {
+		newVaultReader = func() (secretReader, error) { return vault.NewClientFromEnv() }
+	}()
+
+	writer := &fakeSecretWriter{
+		existing: map[string]bool{"api-key": true},
+		written:  map[string]string{},
+	}
+
+	secrets := &vaultSecrets{}
+	got, err := secrets.resolve(context.Background(), writer, ioutil.Discard,
+		[]string{"db-password", "vault:kv/data/app#token", "vault:kv/data/app#api-key"}, "openfaas-fn")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []string{"db-password", "token", "api-key"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want secrets %v, got %v", want, got)
+	}
+
+	wantWritten := map[string]string{"token": "s3cr3t", "api-key": "k3y"}
+	if !reflect.DeepEqual(writer.written, wantWritten) {
+		t.Fatalf("want gateway secrets %v, got %v", wantWritten, writer.written)
+	}
+}
+
+func Test_vaultSecrets_logsInOnce(t *testing.T) {
+	logins := 0
+	newVaultReader = func() (secretReader, error) {
+		logins++
+		return fakeVaultReader{"kv/data/app#token": "s3cr3t"}, nil
+	}
+	defer func() {
+		newVaultReader = func() (secretReader, error) { return vault.NewClientFromEnv() }
+	}()
+
+	writer := &fakeSecretWriter{written: map[string]string{}}
+	secrets := &vaultSecrets{}
+	for _, namespace := range []string{"openfaas-fn", "staging-fn"} {
+		if _, err := secrets.resolve(context.Background(), writer, ioutil.Discard, []string{"vault:kv/data/app#token"}, namespace); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if logins != 1 {
+		t.Fatalf("want 1 login to vault, got %d", logins)
+	}
+}
+
+func Test_vaultSecrets_sameKeyFromTwoPaths(t *testing.T) {
+	newVaultReader = func() (secretReader, error) {
+		return fakeVaultReader{
+			"kv/data/app1#token": "app1-s3cr3t",
+			"kv/data/app2#token": "app2-s3cr3t",
+		}, nil
+	}
+	defer func() {
+		newVaultReader = func() (secretReader, error) { return vault.NewClientFromEnv() }
+	}()
+
+	writer := &fakeSecretWriter{written: map[string]string{}}
+	secrets := &vaultSecrets{}
+	if _, err := secrets.resolve(context.Background(), writer, ioutil.Discard, []string{"vault:kv/data/app1#token"}, "openfaas-fn"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_, err := secrets.resolve(context.Background(), writer, ioutil.Discard, []string{"vault:kv/data/app2#token"}, "openfaas-fn")
+	want := "vault secrets vault:kv/data/app1#token and vault:kv/data/app2#token would both be stored as the secret token, use a different key for one of them"
+	if err == nil || err.Error() != want {
+		t.Fatalf("want error %q, got %v", want, err)
+	}
+	if got := writer.written["token"]; got != "app1-s3cr3t" {
+		t.Fatalf("want the first secret to be kept, got %q", got)
+	}
+
+	if _, err := secrets.resolve(context.Background(), writer, ioutil.Discard, []string{"vault:kv/data/app2#token"}, "staging-fn"); err != nil {
+		t.Fatalf("want the same key to be allowed in another namespace, got %s", err)
+	}
+
+	plan := &vaultSecrets{}
+	_, err = plan.names([]string{"vault:kv/data/app1#token", "vault:kv/data/app2#token"}, "openfaas-fn")
+	if err == nil || err.Error() != want {
+		t.Fatalf("want error %q from a plan, got %v", want, err)
+	}
+}
+
+func Test_Deploy_planSkipsVaultSecrets(t *testing.T) {
+	newVaultReader = func() (secretReader, error) {
+		t.Fatal("want vault not to be read for a plan")
+		return nil, nil
+	}
+	defer func() {
+		newVaultReader = func() (secretReader, error) { return vault.NewClientFromEnv() }
+	}()
+
+	dir, err := ioutil.TempDir("", "faas-cli-vault")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	stackFile := filepath.Join(dir, "stack.yml")
+	ioutil.WriteFile(stackFile, []byte(`version: 1.0
+provider:
+  name: openfaas
+functions:
+  fn1:
+    image: functions/fn1:0.1
+    secrets:
+      - db-password
+      - vault:kv/data/app#token
+`), 0600)
+
+	s := test.MockHttpServer(t, []test.Request{})
+	defer s.Close()
+
+	var planned []*proxy.DeployFunctionSpec
+	test.CaptureStdout(func() {
+		_, err = Deploy(context.Background(), DeployOptions{
+			YAMLFile:     stackFile,
+			Gateway:      s.URL,
+			NoProvenance: true,
+			plan: func(gateway string, spec *proxy.DeployFunctionSpec) {
+				planned = append(planned, spec)
+			},
+		})
+	})
+	if err != nil {
+		t.Fatalf("want no error, got: %s", err)
+	}
+
+	if len(s.Requests()) != 0 {
+		t.Fatalf("want no requests to the gateway, got %d", len(s.Requests()))
+	}
+	if len(planned) != 1 {
+		t.Fatalf("want 1 function planned, got %d", len(planned))
+	}
+	want := []string{"db-password", "token"}
+	if !reflect.DeepEqual(planned[0].Secrets, want) {
+		t.Fatalf("want secrets %v, got %v", want, planned[0].Secrets)
+	}
+}
@@ -5,6 +5,7 @@ package commands
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"runtime"
 	"time"
@@ -12,6 +13,7 @@ import (
 	"os"
 
 	"github.com/morikuni/aec"
+	"github.com/openfaas/faas-cli/output"
 	"github.com/openfaas/faas-cli/proxy"
 	"github.com/openfaas/faas-cli/stack"
 	"github.com/openfaas/faas-cli/version"
@@ -20,8 +22,9 @@ import (
 
 // GitCommit injected at build-time
 var (
-	shortVersion bool
-	warnUpdate   bool
+	shortVersion  bool
+	warnUpdate    bool
+	versionOutput string
 )
 
 func init() {
@@ -30,7 +33,8 @@ func init() {
 	versionCmd.Flags().BoolVar(&tlsInsecure, "tls-no-verify", false, "Disable TLS validation")
 	versionCmd.Flags().BoolVar(&envsubst, "envsubst", true, "Substitute environment variables in stack.yml file")
 
-	versionCmd.Flags().BoolVar(&warnUpdate, "warn-update", true, "Check for new version and warn about updating")
+	versionCmd.Flags().BoolVar(&warnUpdate, "warn-update", false, "Check GitHub for a newer release and warn about updating")
+	versionCmd.Flags().StringVarP(&versionOutput, "output", "o", "", "Output format, \"json\" for tooling")
 
 	versionCmd.Flags().StringVarP(&token, "token", "k", "", "Pass a JWT token to use instead of basic auth")
 	faasCmd.AddCommand(versionCmd)
@@ -42,31 +46,89 @@ var versionCmd = &cobra.Command{
 	Short: "Display the clients version information",
 	Long: fmt.Sprintf(`The version command returns the current clients version information.
 
-This currently consists of the GitSHA from which the client was built.
+This consists of the GitSHA from which the client was built, the build date,
+the Go version and platform, and the version of the gateway and its provider.
 - https://github.com/openfaas/faas-cli/tree/%s`, version.GitCommit),
 	Example: `  faas-cli version
-  faas-cli version --short-version`,
+  faas-cli version --short-version
+  faas-cli version --warn-update
+  faas-cli version -o json`,
 	RunE: runVersionE,
 }
 
+const latestReleaseURL = "https://github.com/openfaas/faas-cli/releases/latest"
+
+// versionInfo is printed by version -o json
+type versionInfo struct {
+	CLI           cliVersion      `json:"cli"`
+	Gateway       *gatewayVersion `json:"gateway,omitempty"`
+	GatewayError  string          `json:"gateway_error,omitempty"`
+	LatestVersion string          `json:"latest_version,omitempty"`
+}
+
+type cliVersion struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date,omitempty"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+type gatewayVersion struct {
+	URL      string          `json:"url"`
+	Version  string          `json:"version"`
+	SHA      string          `json:"sha"`
+	Provider providerVersion `json:"provider"`
+}
+
+type providerVersion struct {
+	Name          string `json:"name"`
+	Orchestration string `json:"orchestration"`
+	Version       string `json:"version"`
+	SHA           string `json:"sha"`
+}
+
+func currentCLIVersion() cliVersion {
+	return cliVersion{
+		Version:   version.BuildVersion(),
+		Commit:    version.GitCommit,
+		BuildDate: version.BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+}
+
 func runVersionE(cmd *cobra.Command, args []string) error {
-	releases := "https://github.com/openfaas/faas-cli/releases/latest"
+	switch versionOutput {
+	case "":
+	case "json":
+		return printVersionJSON()
+	default:
+		return fmt.Errorf("unknown --output %q, use \"json\"", versionOutput)
+	}
 
 	if shortVersion {
 		fmt.Println(version.BuildVersion())
 
 	} else {
+		cli := currentCLIVersion()
 		printLogo()
 		fmt.Printf(`CLI:
  commit:  %s
  version: %s
-`, version.GitCommit, version.BuildVersion())
+`, cli.Commit, cli.Version)
+		if len(cli.BuildDate) > 0 {
+			fmt.Printf(" built:   %s\n", cli.BuildDate)
+		}
+		fmt.Printf(` go:      %s
+ os/arch: %s
+`, cli.GoVersion, cli.Platform)
 		printServerVersions()
 	}
 
 	if warnUpdate {
 		version := version.Version
-		latest, err := findRelease(releases)
+		latest, err := findRelease(latestReleaseURL)
 		if err != nil {
 			return fmt.Errorf("unable to find latest version online error: %s", err.Error())
 		}
@@ -79,13 +141,59 @@ func runVersionE(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// printVersionJSON prints the versions of the CLI and gateway for tooling, a
+// gateway which cannot be reached is reported in gateway_error
+func printVersionJSON() error {
+	info := versionInfo{CLI: currentCLIVersion()}
+
+	gatewayInfo, err := getServerVersions()
+	if err != nil {
+		info.GatewayError = err.Error()
+	} else {
+		info.Gateway = gatewayInfo
+	}
+
+	if warnUpdate {
+		latest, err := findRelease(latestReleaseURL)
+		if err != nil {
+			return fmt.Errorf("unable to find latest version online error: %s", err.Error())
+		}
+		info.LatestVersion = latest
+	}
+
+	out, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
 func printServerVersions() error {
+	gatewayInfo, err := getServerVersions()
+	if err != nil {
+		return err
+	}
+
+	printGatewayDetails(gatewayInfo.URL, gatewayInfo.Version, gatewayInfo.SHA)
+
+	fmt.Printf(`
+Provider
+ name:          %s
+ orchestration: %s
+ version:       %s 
+ sha:           %s
+`, gatewayInfo.Provider.Name, gatewayInfo.Provider.Orchestration, gatewayInfo.Provider.Version, gatewayInfo.Provider.SHA)
+	return nil
+}
 
+// getServerVersions queries the gateway for its version and that of its provider
+func getServerVersions() (*gatewayVersion, error) {
 	var services stack.Services
 	var gatewayAddress string
 	var yamlGateway string
 	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter, envsubst)
+		parsedServices, err := parseStackFile(yamlFile, envsubst)
 		if err == nil && parsedServices != nil {
 			services = *parsedServices
 			yamlGateway = services.Provider.GatewayURL
@@ -97,28 +205,33 @@ func printServerVersions() error {
 	versionTimeout := 5 * time.Second
 	cliAuth, err := proxy.NewCLIAuth(token, gatewayAddress)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	transport := GetDefaultCLITransport(tlsInsecure, &versionTimeout)
 	cliClient, err := proxy.NewClient(cliAuth, gatewayAddress, transport, &versionTimeout)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	cliClient.CallID = requestID
 	gatewayInfo, err := cliClient.GetSystemInfo(context.Background())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	printGatewayDetails(gatewayAddress, gatewayInfo.Version.Release, gatewayInfo.Version.SHA)
-
-	fmt.Printf(`
-Provider
- name:          %s
- orchestration: %s
- version:       %s 
- sha:           %s
-`, gatewayInfo.Provider.Name, gatewayInfo.Provider.Orchestration, gatewayInfo.Provider.Version.Release, gatewayInfo.Provider.Version.SHA)
-	return nil
+	info := &gatewayVersion{URL: gatewayAddress}
+	if gatewayInfo.Version != nil {
+		info.Version = gatewayInfo.Version.Release
+		info.SHA = gatewayInfo.Version.SHA
+	}
+	if provider := gatewayInfo.Provider; provider != nil {
+		info.Provider.Name = provider.Name
+		info.Provider.Orchestration = provider.Orchestration
+		if provider.Version != nil {
+			info.Provider.Version = provider.Version.Release
+			info.Provider.SHA = provider.Version.SHA
+		}
+	}
+	return info, nil
 }
 
 func printGatewayDetails(gatewayAddress, version, sha string) {
@@ -138,9 +251,9 @@ Gateway
 
 // printLogo prints an ASCII logo, which was generated with figlet
 func printLogo() {
-	figletColoured := aec.BlueF.Apply(figletStr)
+	figletColoured := output.Color(figletStr, aec.BlueF)
 	if runtime.GOOS == "windows" {
-		figletColoured = aec.GreenF.Apply(figletStr)
+		figletColoured = output.Color(figletStr, aec.GreenF)
 	}
 	fmt.Printf(figletColoured)
 }
@@ -4,7 +4,9 @@
 package commands
 
 import (
+	"encoding/json"
 	"regexp"
+	"runtime"
 	"testing"
 
 	"fmt"
@@ -151,6 +153,76 @@ func Test_gateway_details_prior_to_0_8_4_should_not_be_displayed(t *testing.T) {
 	}
 }
 
+func Test_version_json_output(t *testing.T) {
+	resetForTest()
+	version.GitCommit = "sha-test"
+	version.Version = "version.tag"
+	version.BuildDate = "2020-10-01T10:00:00Z"
+	defer func() {
+		versionOutput = ""
+		version.BuildDate = ""
+	}()
+
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/info",
+			ResponseStatusCode: http.StatusOK,
+			ResponseBody:       gateway_response_0_8_4_onwards,
+		},
+	})
+	defer s.Close()
+
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{
+			"version",
+			"--gateway=" + s.URL,
+			"--warn-update=false",
+			"-o", "json",
+		})
+		faasCmd.Execute()
+	})
+
+	var info versionInfo
+	if err := json.Unmarshal([]byte(stdOut), &info); err != nil {
+		t.Fatalf("output is not valid JSON: %s\n%s", err, stdOut)
+	}
+
+	wantCLI := cliVersion{
+		Version:   "version.tag",
+		Commit:    "sha-test",
+		BuildDate: "2020-10-01T10:00:00Z",
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if info.CLI != wantCLI {
+		t.Errorf("want cli: %+v, got: %+v", wantCLI, info.CLI)
+	}
+
+	if info.Gateway == nil {
+		t.Fatalf("want gateway information, got error: %q", info.GatewayError)
+	}
+
+	wantGateway := gatewayVersion{
+		URL:     s.URL,
+		Version: "gateway-0.4.3",
+		SHA:     "999a6669148c30adeb64400609953cf59db2fb64",
+		Provider: providerVersion{
+			Name:          "faas-swarm",
+			Orchestration: "swarm",
+			Version:       "provider-0.3.3",
+			SHA:           "c890cba302d059de8edbef3f3de7fe15444b1ecf",
+		},
+	}
+	if *info.Gateway != wantGateway {
+		t.Errorf("want gateway: %+v, got: %+v", wantGateway, *info.Gateway)
+	}
+
+	if len(info.LatestVersion) > 0 {
+		t.Errorf("want no update check without --warn-update, got: %s", info.LatestVersion)
+	}
+}
+
 func executeVersionCmd(t *testing.T, responseBody string) (versionInfo string, gatewayUri string) {
 	resetForTest()
 	s := test.MockHttpServer(t, []test.Request{
@@ -0,0 +1,104 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/docker/docker/pkg/term"
+	"github.com/openfaas/faas-cli/proxy"
+	types "github.com/openfaas/faas-provider/types"
+)
+
+// defaultWaitTimeout is how long --wait waits for a function to be ready
+const defaultWaitTimeout = 2 * time.Minute
+
+// spinnerFrames are drawn in turn while waiting on a terminal
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// stderrIsTerminal is a variable so that tests can act as a terminal
+var stderrIsTerminal = func() bool {
+	return term.IsTerminal(os.Stderr.Fd())
+}
+
+// waitTarget is a function which was deployed and is waited for, Image is
+// the image it was deployed with
+type waitTarget struct {
+	Name      string
+	Namespace string
+	Image     string
+}
+
+// waitForFunctions waits for each function to run its image on all of its replicas,
+// all of them share the timeout so that it bounds the whole wait
+func waitForFunctions(ctx context.Context, client *proxy.Client, targets []waitTarget, timeout time.Duration, out io.Writer, tty bool) error {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	for _, target := range targets {
+		progress := newWaitProgress(out, target.Name, tty)
+		_, err := client.WaitForFunction(ctx, target.Name, target.Namespace, proxy.WaitOptions{
+			Image:    target.Image,
+			Progress: progress.update,
+		})
+		progress.done(err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitProgress prints the progress of a wait, a spinner which is redrawn on
+// a terminal and a line for each change of the replicas otherwise
+type waitProgress struct {
+	out   io.Writer
+	name  string
+	tty   bool
+	frame int
+	last  string
+	start time.Time
+}
+
+func newWaitProgress(out io.Writer, name string, tty bool) *waitProgress {
+	return &waitProgress{out: out, name: name, tty: tty, start: time.Now()}
+}
+
+func (p *waitProgress) update(status types.FunctionStatus, elapsed time.Duration, err error) {
+	message := fmt.Sprintf("%d/%d replicas available", status.AvailableReplicas, status.Replicas)
+	if err != nil {
+		message = err.Error()
+	}
+
+	if p.tty {
+		fmt.Fprintf(p.out, "\r\033[K%s Waiting for %s: %s (%s)", spinnerFrames[p.frame%len(spinnerFrames)], p.name, message, elapsed.Round(time.Second))
+		p.frame++
+		return
+	}
+
+	if message != p.last {
+		fmt.Fprintf(p.out, "Waiting for %s: %s\n", p.name, message)
+		p.last = message
+	}
+}
+
+func (p *waitProgress) done(err error) {
+	if p.tty {
+		fmt.Fprint(p.out, "\r\033[K")
+	}
+	if err == nil {
+		fmt.Fprintf(p.out, "%s is ready after %s\n", p.name, time.Since(p.start).Round(100*time.Millisecond))
+	}
+}
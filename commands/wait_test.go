@@ -0,0 +1,76 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas-cli/proxy"
+	types "github.com/openfaas/faas-provider/types"
+)
+
+func Test_waitProgress(t *testing.T) {
+	status := func(available uint64) types.FunctionStatus {
+		return types.FunctionStatus{Name: "figlet", Replicas: 1, AvailableReplicas: available}
+	}
+
+	t.Run("a line for each change", func(t *testing.T) {
+		var out bytes.Buffer
+		progress := newWaitProgress(&out, "figlet", false)
+		progress.update(status(0), time.Second, nil)
+		progress.update(status(0), 2*time.Second, nil)
+		progress.update(status(1), 3*time.Second, nil)
+		progress.done(nil)
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		want := []string{"Waiting for figlet: 0/1 replicas available", "Waiting for figlet: 1/1 replicas available"}
+		if len(lines) != 3 || lines[0] != want[0] || lines[1] != want[1] || !strings.HasPrefix(lines[2], "figlet is ready after ") {
+			t.Errorf("want lines %q and a ready line, got %q", want, lines)
+		}
+	})
+
+	t.Run("a spinner on a terminal", func(t *testing.T) {
+		var out bytes.Buffer
+		progress := newWaitProgress(&out, "figlet", true)
+		progress.update(status(0), time.Second, nil)
+		progress.update(status(0), 2*time.Second, nil)
+		progress.done(nil)
+
+		got := out.String()
+		for _, want := range []string{"\r\033[K| Waiting for figlet: 0/1 replicas available (1s)", "\r\033[K/ Waiting for figlet: 0/1 replicas available (2s)", "\r\033[Kfiglet is ready after "} {
+			if !strings.Contains(got, want) {
+				t.Errorf("want output to contain %q, got %q", want, got)
+			}
+		}
+	})
+}
+
+func Test_waitForFunctions_Timeout(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(types.FunctionStatus{Name: "figlet", Replicas: 1})
+	}))
+	defer s.Close()
+
+	client, err := proxy.NewClient(noAuth{}, s.URL, nil, &commandTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err = waitForFunctions(context.Background(), client, []waitTarget{{Name: "figlet"}}, 50*time.Millisecond, &out, false)
+	want := "timed out waiting for the function to be ready: figlet has 0/1 replicas available"
+	if err == nil || err.Error() != want {
+		t.Fatalf("want error %q, got %v", want, err)
+	}
+	if strings.Contains(out.String(), "is ready") {
+		t.Errorf("want no ready line after a timeout, got %q", out.String())
+	}
+}
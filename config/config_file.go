@@ -12,13 +12,14 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/mitchellh/go-homedir"
 	"gopkg.in/yaml.v2"
 )
 
-//AuthType auth type
+// AuthType auth type
 type AuthType string
 
 const (
@@ -46,14 +47,69 @@ const (
 
 // ConfigFile for OpenFaaS CLI exclusively.
 type ConfigFile struct {
-	AuthConfigs []AuthConfig `yaml:"auths"`
-	FilePath    string       `yaml:"-"`
+	AuthConfigs []AuthConfig       `yaml:"auths"`
+	Fallbacks   []GatewayFallbacks `yaml:"fallbacks,omitempty"`
+	Headers     []GatewayHeaders   `yaml:"headers,omitempty"`
+
+	// Settings which "faas-cli config set" changes, see Settings
+	CurrentContext string          `yaml:"current_context,omitempty"`
+	Contexts       []Context       `yaml:"contexts,omitempty"`
+	Defaults       Defaults        `yaml:"defaults,omitempty"`
+	Templates      TemplateSources `yaml:"templates,omitempty"`
+
+	FilePath string `yaml:"-"`
+}
+
+// GatewayFallbacks are gateways which read-only commands use when Gateway
+// cannot be reached, in the order given
+type GatewayFallbacks struct {
+	Gateway string   `yaml:"gateway"`
+	URLs    []string `yaml:"urls"`
+}
+
+// GatewayHeaders are sent with every request to Gateway, i.e. the service
+// token of Cloudflare Access or of an API gateway in front of it
+type GatewayHeaders struct {
+	Gateway string            `yaml:"gateway"`
+	Headers map[string]string `yaml:"headers"`
 }
 
 type AuthConfig struct {
 	Gateway string   `yaml:"gateway,omitempty"`
 	Auth    AuthType `yaml:"auth,omitempty"`
 	Token   string   `yaml:"token,omitempty"`
+
+	// CredentialStore is the name of the store which has the token, when it
+	// is not kept in the config file
+	CredentialStore string `yaml:"credential_store,omitempty"`
+
+	// ExpiresAt is when the token expires, in seconds since the Unix epoch
+	ExpiresAt int64 `yaml:"expires_at,omitempty"`
+
+	// ClientCredentials are kept when the token was obtained with the
+	// client_credentials or refresh_token grant, so that it can be refreshed
+	// when it expires
+	ClientCredentials *ClientCredentials `yaml:"client_credentials,omitempty"`
+}
+
+// RefreshTokenGrant is the Grant of ClientCredentials which refresh a user's
+// token with a refresh token rather than with the client secret
+const RefreshTokenGrant = "refresh_token"
+
+// ClientCredentials request a token for a machine user from an OAuth2 or
+// OIDC token endpoint, or refresh a user's token when Grant is
+// RefreshTokenGrant. The secret and refresh token are kept in the credential
+// store with the token when one is used.
+type ClientCredentials struct {
+	TokenURL     string `yaml:"token_url"`
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret,omitempty"`
+	Audience     string `yaml:"audience,omitempty"`
+	Scope        string `yaml:"scope,omitempty"`
+
+	// Grant is empty for the client_credentials grant
+	Grant        string `yaml:"grant,omitempty"`
+	RefreshToken string `yaml:"refresh_token,omitempty"`
 }
 
 // New initializes a config file for the given file path
@@ -182,6 +238,12 @@ func (configFile *ConfigFile) load() error {
 	if len(conf.AuthConfigs) > 0 {
 		configFile.AuthConfigs = conf.AuthConfigs
 	}
+	configFile.Fallbacks = conf.Fallbacks
+	configFile.Headers = conf.Headers
+	configFile.CurrentContext = conf.CurrentContext
+	configFile.Contexts = conf.Contexts
+	configFile.Defaults = conf.Defaults
+	configFile.Templates = conf.Templates
 	return nil
 }
 
@@ -207,8 +269,38 @@ func DecodeAuth(input string) (string, string, error) {
 	return arr[0], arr[1], nil
 }
 
-// UpdateAuthConfig creates or updates the username and password for a given gateway
+// UpdateAuthConfig creates or updates the username and password for a given
+// gateway, the token is saved in plaintext in the config file
 func UpdateAuthConfig(gateway, token string, authType AuthType) error {
+	return updateAuthConfig(gateway, token, authType, nil)
+}
+
+// UpdateAuthConfigInStore creates or updates the credentials for a given
+// gateway, the token is saved in the credential store and not in the config file
+func UpdateAuthConfigInStore(gateway, token string, authType AuthType, store CredentialStore) error {
+	if store == nil {
+		return fmt.Errorf("no credential store given for %s", gateway)
+	}
+	return updateAuthConfig(gateway, token, authType, store)
+}
+
+// UpdateClientCredentialsAuthConfig creates or updates a token obtained with
+// the client_credentials or refresh_token grant along with what is needed to
+// refresh it. The token, client secret and refresh token are saved in the
+// store, or in the config file when the store is nil.
+func UpdateClientCredentialsAuthConfig(auth AuthConfig, store CredentialStore) error {
+	if auth.ClientCredentials == nil {
+		return fmt.Errorf("no client credentials given for %s", auth.Gateway)
+	}
+	return saveAuthConfig(auth, store)
+}
+
+func updateAuthConfig(gateway, token string, authType AuthType, store CredentialStore) error {
+	return saveAuthConfig(AuthConfig{Gateway: gateway, Auth: authType, Token: token}, store)
+}
+
+func saveAuthConfig(auth AuthConfig, store CredentialStore) error {
+	gateway := auth.Gateway
 	_, err := url.ParseRequestURI(gateway)
 	if err != nil || len(gateway) < 1 {
 		return fmt.Errorf("invalid gateway URL")
@@ -228,10 +320,27 @@ func UpdateAuthConfig(gateway, token string, authType AuthType) error {
 		return err
 	}
 
-	auth := AuthConfig{
-		Gateway: gateway,
-		Auth:    authType,
-		Token:   token,
+	if store != nil {
+		if err := store.Store(gateway, auth.Token); err != nil {
+			return err
+		}
+		auth.Token = ""
+		auth.CredentialStore = store.Name()
+
+		if auth.ClientCredentials != nil {
+			credentials := *auth.ClientCredentials
+			if err := store.Store(clientSecretKey(gateway), credentials.ClientSecret); err != nil {
+				return err
+			}
+			credentials.ClientSecret = ""
+			if credentials.Grant == RefreshTokenGrant {
+				if err := store.Store(refreshTokenKey(gateway), credentials.RefreshToken); err != nil {
+					return err
+				}
+				credentials.RefreshToken = ""
+			}
+			auth.ClientCredentials = &credentials
+		}
 	}
 
 	index := -1
@@ -242,6 +351,22 @@ func UpdateAuthConfig(gateway, token string, authType AuthType) error {
 		}
 	}
 
+	// Don't leave a token behind in a store which is no longer used
+	if index > -1 {
+		previous := cfg.AuthConfigs[index]
+		if len(previous.CredentialStore) > 0 && previous.CredentialStore != auth.CredentialStore {
+			eraseAuthConfig(previous)
+		} else if previous.ClientCredentials != nil && len(auth.CredentialStore) > 0 {
+			store := NewCredentialStore(auth.CredentialStore)
+			if auth.ClientCredentials == nil {
+				store.Erase(clientSecretKey(gateway))
+			}
+			if previous.ClientCredentials.Grant == RefreshTokenGrant && (auth.ClientCredentials == nil || auth.ClientCredentials.Grant != RefreshTokenGrant) {
+				store.Erase(refreshTokenKey(gateway))
+			}
+		}
+	}
+
 	if index == -1 {
 		cfg.AuthConfigs = append(cfg.AuthConfigs, auth)
 	} else {
@@ -280,6 +405,29 @@ func LookupAuthConfig(gateway string) (AuthConfig, error) {
 	for _, v := range cfg.AuthConfigs {
 		if gateway == v.Gateway {
 			authConfig = v
+			if len(authConfig.CredentialStore) > 0 {
+				token, err := NewCredentialStore(authConfig.CredentialStore).Get(gateway)
+				if err != nil {
+					return authConfig, fmt.Errorf("unable to read the credentials for %s: %s", gateway, err)
+				}
+				authConfig.Token = token
+
+				if authConfig.ClientCredentials != nil {
+					secret, err := NewCredentialStore(authConfig.CredentialStore).Get(clientSecretKey(gateway))
+					if err != nil {
+						return authConfig, fmt.Errorf("unable to read the client secret for %s: %s", gateway, err)
+					}
+					authConfig.ClientCredentials.ClientSecret = secret
+
+					if authConfig.ClientCredentials.Grant == RefreshTokenGrant {
+						refreshToken, err := NewCredentialStore(authConfig.CredentialStore).Get(refreshTokenKey(gateway))
+						if err != nil {
+							return authConfig, fmt.Errorf("unable to read the refresh token for %s: %s", gateway, err)
+						}
+						authConfig.ClientCredentials.RefreshToken = refreshToken
+					}
+				}
+			}
 			return authConfig, nil
 		}
 	}
@@ -316,6 +464,9 @@ func RemoveAuthConfig(gateway string) error {
 	}
 
 	if index > -1 {
+		if err := eraseAuthConfig(cfg.AuthConfigs[index]); err != nil {
+			return err
+		}
 		cfg.AuthConfigs = removeAuthByIndex(cfg.AuthConfigs, index)
 		if err := cfg.save(); err != nil {
 			return err
@@ -327,6 +478,198 @@ func RemoveAuthConfig(gateway string) error {
 	return nil
 }
 
+// eraseAuthConfig erases the token, client secret and refresh token of a
+// gateway from its credential store, if it has one
+func eraseAuthConfig(auth AuthConfig) error {
+	if len(auth.CredentialStore) == 0 {
+		return nil
+	}
+
+	store := NewCredentialStore(auth.CredentialStore)
+	if err := store.Erase(auth.Gateway); err != nil {
+		return err
+	}
+	if auth.ClientCredentials != nil {
+		if auth.ClientCredentials.Grant == RefreshTokenGrant {
+			if err := store.Erase(refreshTokenKey(auth.Gateway)); err != nil {
+				return err
+			}
+		}
+		return store.Erase(clientSecretKey(auth.Gateway))
+	}
+	return nil
+}
+
+// clientSecretKey is where the client secret of a gateway is kept in a
+// credential store, next to its token
+func clientSecretKey(gateway string) string {
+	return gateway + "#client_secret"
+}
+
+// refreshTokenKey is where the refresh token of a gateway is kept in a
+// credential store
+func refreshTokenKey(gateway string) string {
+	return gateway + "#refresh_token"
+}
+
 func removeAuthByIndex(s []AuthConfig, index int) []AuthConfig {
 	return append(s[:index], s[index+1:]...)
 }
+
+// LookupFallbacks returns the fallback gateways for a gateway, there are none
+// when the config file does not exist
+func LookupFallbacks(gateway string) ([]string, error) {
+	if !fileExists() {
+		return nil, nil
+	}
+
+	configPath, err := EnsureFile()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := New(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.load(); err != nil {
+		return nil, err
+	}
+
+	gateway = strings.TrimRight(gateway, "/")
+	for _, v := range cfg.Fallbacks {
+		if v.Gateway == gateway {
+			return v.URLs, nil
+		}
+	}
+	return nil, nil
+}
+
+// UpdateFallbacks sets the fallback gateways for a gateway, they are removed
+// when none are given
+func UpdateFallbacks(gateway string, urls []string) error {
+	gateway = strings.TrimRight(gateway, "/")
+	for _, u := range append([]string{gateway}, urls...) {
+		if _, err := url.ParseRequestURI(u); err != nil || len(u) < 1 {
+			return fmt.Errorf("invalid gateway URL: %q", u)
+		}
+	}
+
+	configPath, err := EnsureFile()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := New(configPath)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.load(); err != nil {
+		return err
+	}
+
+	trimmed := make([]string, 0, len(urls))
+	for _, u := range urls {
+		trimmed = append(trimmed, strings.TrimRight(u, "/"))
+	}
+
+	fallbacks := []GatewayFallbacks{}
+	for _, v := range cfg.Fallbacks {
+		if v.Gateway != gateway {
+			fallbacks = append(fallbacks, v)
+		}
+	}
+	if len(trimmed) > 0 {
+		fallbacks = append(fallbacks, GatewayFallbacks{Gateway: gateway, URLs: trimmed})
+	}
+	cfg.Fallbacks = fallbacks
+
+	return cfg.save()
+}
+
+// headerName is a token, the format of the name of a HTTP header
+var headerName = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+// ValidateHeader checks that a header can be sent in a HTTP request
+func ValidateHeader(name, value string) error {
+	if !headerName.MatchString(name) {
+		return fmt.Errorf("invalid header name: %q", name)
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("invalid value for header %s, it cannot span lines", name)
+	}
+	return nil
+}
+
+// LookupHeaders returns the headers to send to a gateway, there are none
+// when the config file does not exist
+func LookupHeaders(gateway string) (map[string]string, error) {
+	if !fileExists() {
+		return nil, nil
+	}
+
+	configPath, err := EnsureFile()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := New(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.load(); err != nil {
+		return nil, err
+	}
+
+	gateway = strings.TrimRight(gateway, "/")
+	for _, v := range cfg.Headers {
+		if v.Gateway == gateway {
+			return v.Headers, nil
+		}
+	}
+	return nil, nil
+}
+
+// UpdateHeaders sets the headers to send to a gateway, they are removed when
+// none are given
+func UpdateHeaders(gateway string, headers map[string]string) error {
+	gateway = strings.TrimRight(gateway, "/")
+	if _, err := url.ParseRequestURI(gateway); err != nil || len(gateway) < 1 {
+		return fmt.Errorf("invalid gateway URL: %q", gateway)
+	}
+	for name, value := range headers {
+		if err := ValidateHeader(name, value); err != nil {
+			return err
+		}
+	}
+
+	configPath, err := EnsureFile()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := New(configPath)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.load(); err != nil {
+		return err
+	}
+
+	gatewayHeaders := []GatewayHeaders{}
+	for _, v := range cfg.Headers {
+		if v.Gateway != gateway {
+			gatewayHeaders = append(gatewayHeaders, v)
+		}
+	}
+	if len(headers) > 0 {
+		gatewayHeaders = append(gatewayHeaders, GatewayHeaders{Gateway: gateway, Headers: headers})
+	}
+	cfg.Headers = gatewayHeaders
+
+	return cfg.save()
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"reflect"
 	"regexp"
 	"strings"
 	"testing"
@@ -406,3 +407,89 @@ func Test_ConfigDir(t *testing.T) {
 	}
 
 }
+
+func Test_UpdateFallbacks(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "faas-cli-file-test")
+	if err != nil {
+		t.Fatalf("can not create test config directory: %s", err)
+	}
+	defer os.RemoveAll(configDir)
+
+	os.Setenv(ConfigLocationEnv, configDir)
+	defer os.Unsetenv(ConfigLocationEnv)
+
+	gatewayURL := "http://openfaas.test"
+	if err := UpdateAuthConfig(gatewayURL, EncodeAuth("admin", "pass"), BasicAuthType); err != nil {
+		t.Fatalf("unexpected error when updating auth config: %s", err)
+	}
+
+	if err := UpdateFallbacks(gatewayURL+"/", []string{"http://openfaas-dr.test/", "http://127.0.0.1:8080"}); err != nil {
+		t.Fatalf("unexpected error when updating fallbacks: %s", err)
+	}
+
+	fallbacks, err := LookupFallbacks(gatewayURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"http://openfaas-dr.test", "http://127.0.0.1:8080"}
+	if strings.Join(fallbacks, ",") != strings.Join(want, ",") {
+		t.Errorf("want fallbacks %v, got %v", want, fallbacks)
+	}
+
+	if _, err := LookupAuthConfig(gatewayURL); err != nil {
+		t.Errorf("want the auth config to be kept, got: %s", err)
+	}
+
+	if err := UpdateFallbacks(gatewayURL, nil); err != nil {
+		t.Fatalf("unexpected error when removing fallbacks: %s", err)
+	}
+	if fallbacks, _ := LookupFallbacks(gatewayURL); len(fallbacks) != 0 {
+		t.Errorf("want no fallbacks after removing them, got %v", fallbacks)
+	}
+
+	if err := UpdateFallbacks(gatewayURL, []string{"not a url"}); err == nil {
+		t.Errorf("want an error for an invalid fallback URL")
+	}
+}
+
+func Test_UpdateHeaders(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "faas-cli-file-test")
+	if err != nil {
+		t.Fatalf("can not create test config directory: %s", err)
+	}
+	defer os.RemoveAll(configDir)
+
+	os.Setenv(ConfigLocationEnv, configDir)
+	defer os.Unsetenv(ConfigLocationEnv)
+
+	gatewayURL := "http://openfaas.test"
+	headers := map[string]string{"CF-Access-Client-Id": "abc.access", "X-Org-Token": "s3cr3t"}
+	if err := UpdateHeaders(gatewayURL+"/", headers); err != nil {
+		t.Fatalf("unexpected error when updating headers: %s", err)
+	}
+
+	got, err := LookupHeaders(gatewayURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, headers) {
+		t.Errorf("want headers %v, got %v", headers, got)
+	}
+	if other, _ := LookupHeaders("http://127.0.0.1:8080"); len(other) != 0 {
+		t.Errorf("want no headers for another gateway, got %v", other)
+	}
+
+	if err := UpdateHeaders(gatewayURL, nil); err != nil {
+		t.Fatalf("unexpected error when removing headers: %s", err)
+	}
+	if got, _ := LookupHeaders(gatewayURL); len(got) != 0 {
+		t.Errorf("want no headers after removing them, got %v", got)
+	}
+
+	if err := UpdateHeaders(gatewayURL, map[string]string{"X Org": "1"}); err == nil {
+		t.Errorf("want an error for an invalid header name")
+	}
+	if err := UpdateHeaders(gatewayURL, map[string]string{"X-Org": "1\r\nHost: evil"}); err == nil {
+		t.Errorf("want an error for a value which spans lines")
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// credentialHelperPrefix is the prefix of the credential helpers which Docker
+// uses, they keep secrets in the keyring of the OS
+const credentialHelperPrefix = "docker-credential-"
+
+// credentialsNotFound is printed by a credential helper for an unknown server
+const credentialsNotFound = "credentials not found"
+
+// CredentialStore keeps the token of a gateway outside of the config file,
+// the config file only records which store has it
+type CredentialStore interface {
+	// Name is recorded in the config file to find the store again
+	Name() string
+	Store(gateway, token string) error
+	Get(gateway string) (string, error)
+	Erase(gateway string) error
+}
+
+// NewCredentialStore returns the store with a name, which is the suffix of a
+// docker-credential- helper, i.e. osxkeychain, wincred, secretservice or pass
+var NewCredentialStore = func(name string) CredentialStore {
+	return &helperStore{name: name}
+}
+
+// DefaultCredentialStore returns the keyring store of the OS, it fails when
+// its credential helper is not installed
+func DefaultCredentialStore() (CredentialStore, error) {
+	name := defaultCredentialHelper(runtime.GOOS)
+	program := credentialHelperPrefix + name
+	if _, err := exec.LookPath(program); err != nil {
+		return nil, fmt.Errorf("the credential helper %s was not found in PATH", program)
+	}
+	return NewCredentialStore(name), nil
+}
+
+func defaultCredentialHelper(goos string) string {
+	switch goos {
+	case "darwin":
+		return "osxkeychain"
+	case "windows":
+		return "wincred"
+	default:
+		return "secretservice"
+	}
+}
+
+// helperStore runs a credential helper with the protocol used by Docker
+type helperStore struct {
+	name string
+}
+
+// helperCredentials is the message read and written by a credential helper
+type helperCredentials struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+func (s *helperStore) Name() string {
+	return s.name
+}
+
+func (s *helperStore) Store(gateway, token string) error {
+	input, err := json.Marshal(helperCredentials{
+		ServerURL: gateway,
+		Username:  "faas-cli",
+		Secret:    token,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.run("store", input)
+	return err
+}
+
+func (s *helperStore) Get(gateway string) (string, error) {
+	out, err := s.run("get", []byte(gateway))
+	if err != nil {
+		return "", err
+	}
+
+	var credentials helperCredentials
+	if err := json.Unmarshal(out, &credentials); err != nil {
+		return "", fmt.Errorf("unable to read the credentials from %s%s: %s", credentialHelperPrefix, s.name, err)
+	}
+	return credentials.Secret, nil
+}
+
+func (s *helperStore) Erase(gateway string) error {
+	_, err := s.run("erase", []byte(gateway))
+	if err != nil && strings.Contains(err.Error(), credentialsNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (s *helperStore) run(action string, input []byte) ([]byte, error) {
+	program := credentialHelperPrefix + s.name
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(program, action)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		// Helpers print the reason for an error to stdout
+		message := strings.TrimSpace(stdout.String() + " " + stderr.String())
+		if len(message) == 0 {
+			message = err.Error()
+		}
+		return nil, fmt.Errorf("%s %s: %s", program, action, message)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,266 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeCredentialHelper keeps one set of credentials in a file next to itself
+const fakeCredentialHelper = `#!/bin/sh
+file="$(dirname "$0")/credentials.json"
+case "$1" in
+store) cat > "$file" ;;
+get)
+  if [ ! -f "$file" ]; then
+    echo "credentials not found in native keychain"
+    exit 1
+  fi
+  cat "$file" ;;
+erase) rm -f "$file" ;;
+esac
+`
+
+func Test_UpdateAuthConfigInStore(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake credential helper is a shell script")
+	}
+
+	configDir, err := ioutil.TempDir("", "faas-cli-file-test")
+	if err != nil {
+		t.Fatalf("can not create test config directory: %s", err)
+	}
+	defer os.RemoveAll(configDir)
+
+	os.Setenv(ConfigLocationEnv, configDir)
+	defer os.Unsetenv(ConfigLocationEnv)
+
+	helper := filepath.Join(configDir, credentialHelperPrefix+"faastest")
+	if err := ioutil.WriteFile(helper, []byte(fakeCredentialHelper), 0700); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", configDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	gatewayURL := "http://openfaas.test"
+	token := EncodeAuth("admin", "some pass")
+	if err := UpdateAuthConfigInStore(gatewayURL, token, BasicAuthType, NewCredentialStore("faastest")); err != nil {
+		t.Fatalf("unexpected error when updating auth config: %s", err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(configDir, DefaultFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), token) {
+		t.Errorf("want the token to be kept out of the config file, got:\n%s", string(data))
+	}
+	if !strings.Contains(string(data), "credential_store: faastest") {
+		t.Errorf("want the credential store in the config file, got:\n%s", string(data))
+	}
+
+	authConfig, err := LookupAuthConfig(gatewayURL)
+	if err != nil {
+		t.Fatalf("unexpected error when looking up auth config: %s", err)
+	}
+	if authConfig.Token != token || authConfig.Auth != BasicAuthType {
+		t.Errorf("want the token from the credential store, got %v", authConfig)
+	}
+
+	if err := RemoveAuthConfig(gatewayURL); err != nil {
+		t.Fatalf("unexpected error when removing auth config: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "credentials.json")); !os.IsNotExist(err) {
+		t.Errorf("want the token to be erased from the credential store")
+	}
+}
+
+func Test_UpdateAuthConfig_ErasesPreviousStore(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "faas-cli-file-test")
+	if err != nil {
+		t.Fatalf("can not create test config directory: %s", err)
+	}
+	defer os.RemoveAll(configDir)
+
+	os.Setenv(ConfigLocationEnv, configDir)
+	defer os.Unsetenv(ConfigLocationEnv)
+
+	store := &memoryStore{tokens: map[string]string{}}
+	defer func(newStore func(string) CredentialStore) { NewCredentialStore = newStore }(NewCredentialStore)
+	NewCredentialStore = func(name string) CredentialStore { return store }
+
+	gatewayURL := "http://openfaas.test"
+	if err := UpdateAuthConfigInStore(gatewayURL, "token-1", Oauth2AuthType, store); err != nil {
+		t.Fatal(err)
+	}
+	if store.tokens[gatewayURL] != "token-1" {
+		t.Fatalf("want the token in the store, got %v", store.tokens)
+	}
+
+	if err := UpdateAuthConfig(gatewayURL, "token-2", Oauth2AuthType); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.tokens[gatewayURL]; ok {
+		t.Errorf("want the token to be erased from the store which is no longer used")
+	}
+
+	authConfig, err := LookupAuthConfig(gatewayURL)
+	if err != nil || authConfig.Token != "token-2" || len(authConfig.CredentialStore) > 0 {
+		t.Errorf("want the plaintext token, got %v, %v", authConfig, err)
+	}
+}
+
+func Test_UpdateClientCredentialsAuthConfig_InStore(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "faas-cli-file-test")
+	if err != nil {
+		t.Fatalf("can not create test config directory: %s", err)
+	}
+	defer os.RemoveAll(configDir)
+
+	os.Setenv(ConfigLocationEnv, configDir)
+	defer os.Unsetenv(ConfigLocationEnv)
+
+	store := &memoryStore{tokens: map[string]string{}}
+	defer func(newStore func(string) CredentialStore) { NewCredentialStore = newStore }(NewCredentialStore)
+	NewCredentialStore = func(name string) CredentialStore { return store }
+
+	gatewayURL := "http://openfaas.test"
+	auth := AuthConfig{
+		Gateway:   gatewayURL,
+		Auth:      Oauth2AuthType,
+		Token:     "token-1",
+		ExpiresAt: 1700000000,
+		ClientCredentials: &ClientCredentials{
+			TokenURL:     "https://idp.example.com/oauth/token",
+			ClientID:     "ci",
+			ClientSecret: "s3cr3t",
+			Audience:     gatewayURL,
+		},
+	}
+	if err := UpdateClientCredentialsAuthConfig(auth, store); err != nil {
+		t.Fatal(err)
+	}
+	if auth.ClientCredentials.ClientSecret != "s3cr3t" {
+		t.Errorf("want the client credentials of the caller to be unchanged")
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(configDir, DefaultFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "s3cr3t") || strings.Contains(string(data), "token-1") {
+		t.Errorf("want the token and client secret to be kept out of the config file, got:\n%s", string(data))
+	}
+
+	authConfig, err := LookupAuthConfig(gatewayURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authConfig.Token != "token-1" || authConfig.ExpiresAt != 1700000000 {
+		t.Errorf("want the token and its expiry, got %v", authConfig)
+	}
+	if authConfig.ClientCredentials == nil || authConfig.ClientCredentials.ClientSecret != "s3cr3t" || authConfig.ClientCredentials.ClientID != "ci" {
+		t.Errorf("want the client credentials with the secret from the store, got %v", authConfig.ClientCredentials)
+	}
+
+	if err := RemoveAuthConfig(gatewayURL); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.tokens) > 0 {
+		t.Errorf("want the token and client secret to be erased, got %v", store.tokens)
+	}
+}
+
+func Test_UpdateClientCredentialsAuthConfig_RefreshTokenInStore(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "faas-cli-file-test")
+	if err != nil {
+		t.Fatalf("can not create test config directory: %s", err)
+	}
+	defer os.RemoveAll(configDir)
+
+	os.Setenv(ConfigLocationEnv, configDir)
+	defer os.Unsetenv(ConfigLocationEnv)
+
+	store := &memoryStore{tokens: map[string]string{}}
+	defer func(newStore func(string) CredentialStore) { NewCredentialStore = newStore }(NewCredentialStore)
+	NewCredentialStore = func(name string) CredentialStore { return store }
+
+	gatewayURL := "http://openfaas.test"
+	auth := AuthConfig{
+		Gateway: gatewayURL,
+		Auth:    Oauth2AuthType,
+		Token:   "token-1",
+		ClientCredentials: &ClientCredentials{
+			TokenURL:     "https://idp.example.com/oauth/token",
+			ClientID:     "cli",
+			Grant:        RefreshTokenGrant,
+			RefreshToken: "r3fr3sh",
+		},
+	}
+	if err := UpdateClientCredentialsAuthConfig(auth, store); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(configDir, DefaultFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "r3fr3sh") {
+		t.Errorf("want the refresh token to be kept out of the config file, got:\n%s", string(data))
+	}
+
+	authConfig, err := LookupAuthConfig(gatewayURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authConfig.ClientCredentials == nil || authConfig.ClientCredentials.RefreshToken != "r3fr3sh" || authConfig.ClientCredentials.Grant != RefreshTokenGrant {
+		t.Errorf("want the refresh token from the store, got %v", authConfig.ClientCredentials)
+	}
+
+	// A token without a refresh token does not leave the old one behind
+	if err := UpdateAuthConfigInStore(gatewayURL, "token-2", Oauth2AuthType, store); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.tokens[refreshTokenKey(gatewayURL)]; ok {
+		t.Errorf("want the refresh token to be erased, got %v", store.tokens)
+	}
+}
+
+func Test_defaultCredentialHelper(t *testing.T) {
+	cases := map[string]string{
+		"darwin":  "osxkeychain",
+		"windows": "wincred",
+		"linux":   "secretservice",
+	}
+	for goos, want := range cases {
+		if got := defaultCredentialHelper(goos); got != want {
+			t.Errorf("%s: want %s, got %s", goos, want, got)
+		}
+	}
+}
+
+type memoryStore struct {
+	tokens map[string]string
+}
+
+func (s *memoryStore) Name() string { return "memory" }
+
+func (s *memoryStore) Store(gateway, token string) error {
+	s.tokens[gateway] = token
+	return nil
+}
+
+func (s *memoryStore) Get(gateway string) (string, error) {
+	return s.tokens[gateway], nil
+}
+
+func (s *memoryStore) Erase(gateway string) error {
+	delete(s.tokens, gateway)
+	return nil
+}
@@ -0,0 +1,521 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/openfaas/faas-cli/versioncontrol"
+)
+
+// Context is a named gateway and namespace, the current context is used when
+// neither is given by a flag, stack.yml or an environment variable
+type Context struct {
+	Name      string `yaml:"name"`
+	Gateway   string `yaml:"gateway,omitempty"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// Defaults are used when a setting is not given any other way
+type Defaults struct {
+	Gateway   string `yaml:"gateway,omitempty"`
+	Namespace string `yaml:"namespace,omitempty"`
+	Prefix    string `yaml:"prefix,omitempty"`
+}
+
+// TemplateSources are where templates are pulled from
+type TemplateSources struct {
+	Repository string `yaml:"repository,omitempty"`
+	Store      string `yaml:"store,omitempty"`
+}
+
+// Resolved are the settings from the config file which apply, those of the
+// current context take priority over the defaults
+type Resolved struct {
+	Gateway            string
+	Namespace          string
+	Prefix             string
+	TemplateRepository string
+	TemplateStore      string
+}
+
+// contextName is the placeholder for the name of a context in a key
+const contextName = "NAME"
+
+var (
+	validNamespace   = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	validImagePrefix = regexp.MustCompile(`^[a-z0-9]+([._\-/:][a-z0-9]+)*$`)
+)
+
+// Setting is a key which can be changed with "faas-cli config set"
+type Setting struct {
+	Key         string
+	Description string
+
+	// Format and Pattern describe the value in the JSON schema
+	Format  string
+	Pattern string
+
+	validate func(value string) error
+
+	// field gives the value of the setting in a config file, a context is
+	// added for the name when create is true
+	field func(cfg *ConfigFile, name string, create bool) *string
+}
+
+// Settings are the keys which can be set, in the order they are documented
+var Settings = []Setting{
+	{
+		Key:         "current_context",
+		Description: "Context to use when no gateway or namespace is given",
+		Pattern:     validNamespace.String(),
+		field: func(cfg *ConfigFile, name string, create bool) *string {
+			return &cfg.CurrentContext
+		},
+	},
+	{
+		Key:         "contexts." + contextName + ".gateway",
+		Description: "Gateway URL of the context",
+		Format:      "uri",
+		validate:    validateURL,
+		field: func(cfg *ConfigFile, name string, create bool) *string {
+			if context := cfg.context(name, create); context != nil {
+				return &context.Gateway
+			}
+			return nil
+		},
+	},
+	{
+		Key:         "contexts." + contextName + ".namespace",
+		Description: "Namespace to deploy and remove functions in with the context",
+		Pattern:     validNamespace.String(),
+		validate:    validateNamespace,
+		field: func(cfg *ConfigFile, name string, create bool) *string {
+			if context := cfg.context(name, create); context != nil {
+				return &context.Namespace
+			}
+			return nil
+		},
+	},
+	{
+		Key:         "defaults.gateway",
+		Description: "Gateway URL when there is no current context",
+		Format:      "uri",
+		validate:    validateURL,
+		field: func(cfg *ConfigFile, name string, create bool) *string {
+			return &cfg.Defaults.Gateway
+		},
+	},
+	{
+		Key:         "defaults.namespace",
+		Description: "Namespace to deploy and remove functions in when there is no current context",
+		Pattern:     validNamespace.String(),
+		validate:    validateNamespace,
+		field: func(cfg *ConfigFile, name string, create bool) *string {
+			return &cfg.Defaults.Namespace
+		},
+	},
+	{
+		Key:         "defaults.prefix",
+		Description: "Image prefix for new functions, i.e. docker.io/alexellis",
+		Pattern:     validImagePrefix.String(),
+		validate:    validatePrefix,
+		field: func(cfg *ConfigFile, name string, create bool) *string {
+			return &cfg.Defaults.Prefix
+		},
+	},
+	{
+		Key:         "templates.repository",
+		Description: "Git repository to pull templates from",
+		validate:    validateTemplateRepository,
+		field: func(cfg *ConfigFile, name string, create bool) *string {
+			return &cfg.Templates.Repository
+		},
+	},
+	{
+		Key:         "templates.store",
+		Description: "URL of the template store",
+		Format:      "uri",
+		validate:    validateURL,
+		field: func(cfg *ConfigFile, name string, create bool) *string {
+			return &cfg.Templates.Store
+		},
+	},
+}
+
+// context returns the context with a name, it is added when create is true
+func (configFile *ConfigFile) context(name string, create bool) *Context {
+	for i := range configFile.Contexts {
+		if configFile.Contexts[i].Name == name {
+			return &configFile.Contexts[i]
+		}
+	}
+	if !create {
+		return nil
+	}
+	configFile.Contexts = append(configFile.Contexts, Context{Name: name})
+	return &configFile.Contexts[len(configFile.Contexts)-1]
+}
+
+// lookupSetting finds the setting for a key and the name of the context in it
+func lookupSetting(key string) (*Setting, string, error) {
+	parts := strings.Split(key, ".")
+	name := ""
+	pattern := key
+	if len(parts) == 3 && parts[0] == "contexts" {
+		name = parts[1]
+		pattern = strings.Join([]string{parts[0], contextName, parts[2]}, ".")
+	}
+
+	for i := range Settings {
+		if Settings[i].Key != pattern {
+			continue
+		}
+		if len(name) > 0 && !validNamespace.MatchString(name) {
+			return nil, "", fmt.Errorf("invalid context name %q, use lowercase letters, digits and dashes", name)
+		}
+		return &Settings[i], name, nil
+	}
+
+	return nil, "", fmt.Errorf("unknown key %q, valid keys are: %s", key, strings.Join(SettingKeys(), ", "))
+}
+
+// SettingKeys returns the keys which can be set
+func SettingKeys() []string {
+	keys := make([]string, 0, len(Settings))
+	for _, setting := range Settings {
+		keys = append(keys, setting.Key)
+	}
+	return keys
+}
+
+// GetSetting returns the value of a key in the config file, it is empty when
+// the key is not set
+func GetSetting(key string) (string, error) {
+	setting, name, err := lookupSetting(key)
+	if err != nil {
+		return "", err
+	}
+
+	cfg, err := loadSettings()
+	if err != nil || cfg == nil {
+		return "", err
+	}
+
+	if value := setting.field(cfg, name, false); value != nil {
+		return *value, nil
+	}
+	return "", nil
+}
+
+// SetSetting validates a value and writes it to the config file
+func SetSetting(key, value string) error {
+	setting, name, err := lookupSetting(key)
+	if err != nil {
+		return err
+	}
+
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return fmt.Errorf("give a value for %s, or use \"faas-cli config unset %s\"", key, key)
+	}
+	if setting.validate != nil {
+		if err := setting.validate(value); err != nil {
+			return fmt.Errorf("invalid value for %s: %s", key, err)
+		}
+	}
+
+	configPath, err := EnsureFile()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := New(configPath)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.load(); err != nil {
+		return err
+	}
+
+	if setting.Key == "current_context" && cfg.context(value, false) == nil {
+		return fmt.Errorf("context %q was not found, add it first with \"faas-cli config set contexts.%s.gateway URL\"", value, value)
+	}
+
+	*setting.field(cfg, name, true) = value
+	return cfg.save()
+}
+
+// UnsetSetting removes a key from the config file, a context is removed with
+// its last setting
+func UnsetSetting(key string) error {
+	setting, name, err := lookupSetting(key)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadSettings()
+	if err != nil {
+		return err
+	}
+	if cfg == nil {
+		return nil
+	}
+
+	value := setting.field(cfg, name, false)
+	if value == nil {
+		return nil
+	}
+	*value = ""
+
+	if len(name) > 0 {
+		contexts := cfg.Contexts[:0]
+		for _, context := range cfg.Contexts {
+			if len(context.Gateway) > 0 || len(context.Namespace) > 0 {
+				contexts = append(contexts, context)
+			} else if context.Name == cfg.CurrentContext {
+				cfg.CurrentContext = ""
+			}
+		}
+		cfg.Contexts = contexts
+	}
+
+	return cfg.save()
+}
+
+// SettingValue is a key which is set in the config file and its value
+type SettingValue struct {
+	Key   string
+	Value string
+}
+
+// ListSettings returns the keys which are set in the config file, in the
+// order of Settings and then of the contexts
+func ListSettings() ([]SettingValue, error) {
+	cfg, err := loadSettings()
+	if err != nil || cfg == nil {
+		return nil, err
+	}
+
+	values := []SettingValue{}
+	for _, setting := range Settings {
+		names := []string{""}
+		if strings.Contains(setting.Key, contextName) {
+			names = names[:0]
+			for _, context := range cfg.Contexts {
+				names = append(names, context.Name)
+			}
+		}
+
+		for _, name := range names {
+			if value := setting.field(cfg, name, false); value != nil && len(*value) > 0 {
+				values = append(values, SettingValue{
+					Key:   strings.Replace(setting.Key, contextName, name, 1),
+					Value: *value,
+				})
+			}
+		}
+	}
+	return values, nil
+}
+
+// LookupSettings returns the settings from the config file which apply, they
+// are empty when the config file does not exist
+func LookupSettings() (Resolved, error) {
+	var resolved Resolved
+
+	cfg, err := loadSettings()
+	if err != nil || cfg == nil {
+		return resolved, err
+	}
+
+	if err := cfg.ValidateSettings(); err != nil {
+		return resolved, err
+	}
+
+	resolved = Resolved{
+		Gateway:            cfg.Defaults.Gateway,
+		Namespace:          cfg.Defaults.Namespace,
+		Prefix:             cfg.Defaults.Prefix,
+		TemplateRepository: cfg.Templates.Repository,
+		TemplateStore:      cfg.Templates.Store,
+	}
+
+	if context := cfg.context(cfg.CurrentContext, false); context != nil {
+		if len(context.Gateway) > 0 {
+			resolved.Gateway = context.Gateway
+		}
+		if len(context.Namespace) > 0 {
+			resolved.Namespace = context.Namespace
+		}
+	}
+
+	return resolved, nil
+}
+
+// ValidateSettings checks each setting in the config file, so that a mistake
+// made by editing the file by hand is reported along with the key
+func (configFile *ConfigFile) ValidateSettings() error {
+	for _, setting := range Settings {
+		names := []string{""}
+		if strings.Contains(setting.Key, contextName) {
+			names = names[:0]
+			for _, context := range configFile.Contexts {
+				names = append(names, context.Name)
+			}
+		}
+
+		for _, name := range names {
+			key := strings.Replace(setting.Key, contextName, name, 1)
+			if len(name) > 0 && !validNamespace.MatchString(name) {
+				return fmt.Errorf("invalid context name %q in %s", name, configFile.FilePath)
+			}
+
+			value := setting.field(configFile, name, false)
+			if value == nil || len(*value) == 0 || setting.validate == nil {
+				continue
+			}
+			if err := setting.validate(*value); err != nil {
+				return fmt.Errorf("invalid value for %s in %s: %s", key, configFile.FilePath, err)
+			}
+		}
+	}
+
+	if len(configFile.CurrentContext) > 0 && configFile.context(configFile.CurrentContext, false) == nil {
+		return fmt.Errorf("current_context %q in %s is not one of the contexts", configFile.CurrentContext, configFile.FilePath)
+	}
+	return nil
+}
+
+// Schema returns a JSON schema for the settings in the config file, which
+// editors can use to check the file as it is written
+func Schema() ([]byte, error) {
+	contextProperties := map[string]interface{}{
+		"name": map[string]interface{}{
+			"type":        "string",
+			"description": "Name of the context",
+			"pattern":     validNamespace.String(),
+		},
+	}
+	sections := map[string]map[string]interface{}{}
+	properties := map[string]interface{}{
+		"auths":     map[string]interface{}{"type": "array"},
+		"fallbacks": map[string]interface{}{"type": "array"},
+		"headers":   map[string]interface{}{"type": "array"},
+	}
+
+	for _, setting := range Settings {
+		property := map[string]interface{}{
+			"type":        "string",
+			"description": setting.Description,
+		}
+		if len(setting.Format) > 0 {
+			property["format"] = setting.Format
+		}
+		if len(setting.Pattern) > 0 {
+			property["pattern"] = setting.Pattern
+		}
+
+		parts := strings.Split(setting.Key, ".")
+		switch {
+		case len(parts) == 1:
+			properties[parts[0]] = property
+		case parts[0] == "contexts":
+			contextProperties[parts[2]] = property
+		default:
+			if sections[parts[0]] == nil {
+				sections[parts[0]] = map[string]interface{}{}
+			}
+			sections[parts[0]][parts[1]] = property
+		}
+	}
+
+	properties["contexts"] = map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":                 "object",
+			"required":             []string{"name"},
+			"additionalProperties": false,
+			"properties":           contextProperties,
+		},
+	}
+
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		properties[name] = map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": false,
+			"properties":           sections[name],
+		}
+	}
+
+	return json.MarshalIndent(map[string]interface{}{
+		"$schema":              "http://json-schema.org/draft-07/schema#",
+		"title":                "faas-cli config.yml",
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties":           properties,
+	}, "", "  ")
+}
+
+// loadSettings reads the config file, it is nil when the file does not exist
+func loadSettings() (*ConfigFile, error) {
+	if !fileExists() {
+		return nil, nil
+	}
+
+	configPath, err := EnsureFile()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := New(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.load(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func validateURL(value string) error {
+	u, err := url.ParseRequestURI(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return fmt.Errorf("%q is not a URL, use one starting with http:// or https://, i.e. https://gw.example.com", value)
+	}
+	return nil
+}
+
+func validateNamespace(value string) error {
+	if len(value) > 63 || !validNamespace.MatchString(value) {
+		return fmt.Errorf("%q is not a namespace, use lowercase letters, digits and dashes, i.e. openfaas-fn", value)
+	}
+	return nil
+}
+
+func validatePrefix(value string) error {
+	if !validImagePrefix.MatchString(value) {
+		return fmt.Errorf("%q is not an image prefix, use lowercase letters, digits, dots, dashes and slashes, i.e. docker.io/alexellis", value)
+	}
+	return nil
+}
+
+func validateTemplateRepository(value string) error {
+	if !versioncontrol.IsGitRemote(value) && !versioncontrol.IsPinnedGitRemote(value) {
+		return fmt.Errorf("%q is not a git repository, use i.e. https://github.com/openfaas/templates.git", value)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_SetSetting(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "faas-cli-file-test")
+	if err != nil {
+		t.Fatalf("can not create test config directory: %s", err)
+	}
+	defer os.RemoveAll(configDir)
+
+	os.Setenv(ConfigLocationEnv, configDir)
+	defer os.Unsetenv(ConfigLocationEnv)
+
+	if resolved, err := LookupSettings(); err != nil || resolved != (Resolved{}) {
+		t.Fatalf("want no settings without a config file, got %v, %v", resolved, err)
+	}
+
+	gatewayURL := "http://openfaas.test"
+	if err := UpdateAuthConfig(gatewayURL, EncodeAuth("admin", "pass"), BasicAuthType); err != nil {
+		t.Fatalf("unexpected error when updating auth config: %s", err)
+	}
+
+	settings := [][2]string{
+		{"defaults.gateway", "https://gw.example.com"},
+		{"defaults.namespace", "openfaas-fn"},
+		{"defaults.prefix", "docker.io/alexellis"},
+		{"templates.repository", "https://github.com/openfaas/templates.git"},
+		{"contexts.staging.gateway", "https://gw-staging.example.com"},
+		{"contexts.staging.namespace", "staging-fn"},
+	}
+	for _, setting := range settings {
+		if err := SetSetting(setting[0], setting[1]); err != nil {
+			t.Fatalf("unexpected error setting %s: %s", setting[0], err)
+		}
+	}
+
+	resolved, err := LookupSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Resolved{
+		Gateway:            "https://gw.example.com",
+		Namespace:          "openfaas-fn",
+		Prefix:             "docker.io/alexellis",
+		TemplateRepository: "https://github.com/openfaas/templates.git",
+	}
+	if resolved != want {
+		t.Errorf("want %v, got %v", want, resolved)
+	}
+
+	if err := SetSetting("current_context", "staging"); err != nil {
+		t.Fatal(err)
+	}
+	resolved, _ = LookupSettings()
+	if resolved.Gateway != "https://gw-staging.example.com" || resolved.Namespace != "staging-fn" {
+		t.Errorf("want the current context to take priority, got %v", resolved)
+	}
+
+	if value, _ := GetSetting("contexts.staging.namespace"); value != "staging-fn" {
+		t.Errorf("want staging-fn, got %q", value)
+	}
+	if _, err := LookupAuthConfig(gatewayURL); err != nil {
+		t.Errorf("want the auth config to be kept, got: %s", err)
+	}
+
+	if err := UnsetSetting("contexts.staging.gateway"); err != nil {
+		t.Fatal(err)
+	}
+	if err := UnsetSetting("contexts.staging.namespace"); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := GetSetting("current_context"); len(value) > 0 {
+		t.Errorf("want current_context to be unset with its context, got %q", value)
+	}
+
+	values, err := ListSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 4 || values[0].Key != "defaults.gateway" {
+		t.Errorf("want the 4 defaults and templates keys, got %v", values)
+	}
+}
+
+func Test_SetSetting_Invalid(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "faas-cli-file-test")
+	if err != nil {
+		t.Fatalf("can not create test config directory: %s", err)
+	}
+	defer os.RemoveAll(configDir)
+
+	os.Setenv(ConfigLocationEnv, configDir)
+	defer os.Unsetenv(ConfigLocationEnv)
+
+	cases := []struct {
+		key   string
+		value string
+		want  string
+	}{
+		{key: "gateway", value: "https://gw.example.com", want: `unknown key "gateway", valid keys are: current_context, `},
+		{key: "defaults.gateway", value: "gw.example.com", want: "invalid value for defaults.gateway: \"gw.example.com\" is not a URL"},
+		{key: "defaults.namespace", value: "OpenFaaS", want: "is not a namespace"},
+		{key: "defaults.prefix", value: "docker.io/Alex Ellis", want: "is not an image prefix"},
+		{key: "templates.repository", value: "templates", want: "is not a git repository"},
+		{key: "contexts.Staging.gateway", value: "https://gw.example.com", want: "invalid context name"},
+		{key: "current_context", value: "prod", want: `context "prod" was not found`},
+		{key: "defaults.prefix", value: " ", want: "give a value for defaults.prefix"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.key+"="+tc.value, func(t *testing.T) {
+			err := SetSetting(tc.key, tc.value)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("want error containing %q, got: %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func Test_ValidateSettings(t *testing.T) {
+	cfg := &ConfigFile{
+		FilePath:       "config.yml",
+		CurrentContext: "prod",
+		Contexts:       []Context{{Name: "prod", Gateway: "https://gw.example.com"}},
+		Defaults:       Defaults{Namespace: "openfaas-fn"},
+	}
+	if err := cfg.ValidateSettings(); err != nil {
+		t.Fatalf("want valid settings, got: %s", err)
+	}
+
+	cfg.Contexts[0].Gateway = "gw.example.com"
+	err := cfg.ValidateSettings()
+	if err == nil || !strings.Contains(err.Error(), "invalid value for contexts.prod.gateway in config.yml") {
+		t.Errorf("want an error naming the key, got: %v", err)
+	}
+
+	cfg.Contexts[0].Gateway = "https://gw.example.com"
+	cfg.CurrentContext = "staging"
+	if err := cfg.ValidateSettings(); err == nil {
+		t.Errorf("want an error for a current_context which is not one of the contexts")
+	}
+}
+
+func Test_Schema(t *testing.T) {
+	data, err := Schema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var schema struct {
+		Properties map[string]struct {
+			Properties map[string]interface{} `json:"properties"`
+			Items      struct {
+				Properties map[string]interface{} `json:"properties"`
+			} `json:"items"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %s", err)
+	}
+
+	for _, key := range []string{"auths", "current_context", "contexts", "defaults", "templates"} {
+		if _, ok := schema.Properties[key]; !ok {
+			t.Errorf("want %s in the schema", key)
+		}
+	}
+	if _, ok := schema.Properties["defaults"].Properties["prefix"]; !ok {
+		t.Errorf("want defaults.prefix in the schema")
+	}
+	if _, ok := schema.Properties["contexts"].Items.Properties["namespace"]; !ok {
+		t.Errorf("want the namespace of a context in the schema")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	osexec "os/exec"
 
 	"github.com/morikuni/aec"
+	"github.com/openfaas/faas-cli/output"
 )
 
 // Command run a system command
@@ -23,16 +24,16 @@ func Command(tempPath string, builder []string) {
 	err := targetCmd.Wait()
 	if err != nil {
 		errString := fmt.Sprintf("ERROR - Could not execute command: %s", builder)
-		log.Fatalf(aec.RedF.Apply(errString))
+		log.Fatalf(output.Color(errString, aec.RedF))
 	}
 }
 
 // CommandWithOutput run a system command an return stdout
 func CommandWithOutput(builder []string, skipFailure bool) string {
-	output, err := osexec.Command(builder[0], builder[1:]...).CombinedOutput()
+	out, err := osexec.Command(builder[0], builder[1:]...).CombinedOutput()
 	if err != nil && !skipFailure {
 		errString := fmt.Sprintf("ERROR - Could not execute command: %s", builder)
-		log.Fatalf(aec.RedF.Apply(errString))
+		log.Fatalf(output.Color(errString, aec.RedF))
 	}
-	return string(output)
+	return string(out)
 }
@@ -0,0 +1,21 @@
+package flags
+
+// Overwrite selects the templates which may be overwritten, either all of them
+// with --overwrite or only some languages with --overwrite-lang node,python
+type Overwrite struct {
+	All       bool
+	Languages []string
+}
+
+// Allows reports whether the template for language may be overwritten
+func (o Overwrite) Allows(language string) bool {
+	if o.All {
+		return true
+	}
+	for _, allowed := range o.Languages {
+		if allowed == language {
+			return true
+		}
+	}
+	return false
+}
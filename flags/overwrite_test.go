@@ -0,0 +1,33 @@
+package flags
+
+import (
+	"testing"
+)
+
+func TestOverwrite(t *testing.T) {
+	cases := []struct {
+		name      string
+		overwrite Overwrite
+		allowed   []string
+		denied    []string
+	}{
+		{"all allows all languages", Overwrite{All: true}, []string{"node", "go"}, nil},
+		{"empty allows no languages", Overwrite{}, nil, []string{"node"}},
+		{"list allows only those languages", Overwrite{Languages: []string{"node", "python"}}, []string{"node", "python"}, []string{"go"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, language := range tc.allowed {
+				if !tc.overwrite.Allows(language) {
+					t.Errorf("expected %s to be allowed", language)
+				}
+			}
+			for _, language := range tc.denied {
+				if tc.overwrite.Allows(language) {
+					t.Errorf("expected %s to be denied", language)
+				}
+			}
+		})
+	}
+}
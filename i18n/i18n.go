@@ -0,0 +1,92 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+// Package i18n holds the catalog of user-facing messages printed by the CLI
+// so that they can be translated. The language is picked with FAAS_LANG.
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// LanguageEnvironment selects the language of messages, i.e. FAAS_LANG=en
+const LanguageEnvironment = "FAAS_LANG"
+
+// DefaultLanguage is used when FAAS_LANG is unset, or has no catalog
+const DefaultLanguage = "en"
+
+// Catalog maps a message ID to a format string for fmt.Sprintf
+type Catalog map[string]string
+
+var (
+	catalogs = map[string]Catalog{
+		DefaultLanguage: english,
+	}
+	catalogsLock sync.RWMutex
+)
+
+// Register adds or replaces the messages for a language. Messages that are
+// missing from the catalog fall back to the DefaultLanguage.
+func Register(language string, catalog Catalog) {
+	catalogsLock.Lock()
+	defer catalogsLock.Unlock()
+
+	catalogs[normalize(language)] = catalog
+}
+
+// Language returns the language selected through FAAS_LANG
+func Language() string {
+	language := normalize(os.Getenv(LanguageEnvironment))
+	if len(language) == 0 {
+		return DefaultLanguage
+	}
+	return language
+}
+
+// T translates a message ID into the selected language and formats it with args
+func T(id string, args ...interface{}) string {
+	format := lookup(Language(), id)
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Errorf returns an error with the translated message for id
+func Errorf(id string, args ...interface{}) error {
+	return errors.New(T(id, args...))
+}
+
+func lookup(language, id string) string {
+	catalogsLock.RLock()
+	defer catalogsLock.RUnlock()
+
+	if catalog, ok := catalogs[language]; ok {
+		if format, ok := catalog[id]; ok {
+			return format
+		}
+	}
+
+	// Only the base language is needed, i.e. "pt" from "pt_BR.UTF-8"
+	if base := strings.SplitN(language, "_", 2)[0]; base != language {
+		if catalog, ok := catalogs[base]; ok {
+			if format, ok := catalog[id]; ok {
+				return format
+			}
+		}
+	}
+
+	if format, ok := catalogs[DefaultLanguage][id]; ok {
+		return format
+	}
+	return id
+}
+
+func normalize(language string) string {
+	language = strings.SplitN(language, ".", 2)[0]
+	return strings.ToLower(strings.Replace(strings.TrimSpace(language), "-", "_", -1))
+}
@@ -0,0 +1,57 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package i18n
+
+import (
+	"os"
+	"testing"
+)
+
+func Test_T_DefaultsToEnglish(t *testing.T) {
+	os.Unsetenv(LanguageEnvironment)
+
+	got := T(NewStackFileWritten, "fn.yml")
+	want := "Stack file written: fn.yml\n"
+	if got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
+
+func Test_T_RegisteredLanguage(t *testing.T) {
+	Register("xx", Catalog{
+		NewStackFileWritten: "Stack file xx: %s\n",
+	})
+	defer Register("xx", Catalog{})
+
+	cases := []struct {
+		name     string
+		language string
+		id       string
+		want     string
+	}{
+		{"exact match", "xx", NewStackFileWritten, "Stack file xx: fn.yml\n"},
+		{"region and encoding are ignored", "xx_YY.UTF-8", NewStackFileWritten, "Stack file xx: fn.yml\n"},
+		{"missing message falls back to English", "xx", NewFolderCreated, "Folder: fn.yml created.\n"},
+		{"unknown language falls back to English", "zz", NewStackFileWritten, "Stack file written: fn.yml\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			os.Setenv(LanguageEnvironment, c.language)
+			defer os.Unsetenv(LanguageEnvironment)
+
+			got := T(c.id, "fn.yml")
+			if got != c.want {
+				t.Fatalf("want %q, got %q", c.want, got)
+			}
+		})
+	}
+}
+
+func Test_T_UnknownIDReturnsID(t *testing.T) {
+	got := T("not.a.message")
+	if got != "not.a.message" {
+		t.Fatalf("want message ID to be returned, got %q", got)
+	}
+}
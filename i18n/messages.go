@@ -0,0 +1,98 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package i18n
+
+// Message IDs for the deploy flow
+const (
+	DeployUpdateReplaceConflict    = "deploy.update_replace_conflict"
+	DeployUpdateReplaceConflictErr = "deploy.update_replace_conflict_error"
+	DeployMissingImageOrName       = "deploy.missing_image_or_name"
+	DeployingFunction              = "deploy.deploying_function"
+	DeployTemplateMissing          = "deploy.template_missing"
+	DeployFailedStatus             = "deploy.failed_status"
+)
+
+// Message IDs for the build flow
+const (
+	BuildMissingImage       = "build.missing_image"
+	BuildMissingHandler     = "build.missing_handler"
+	BuildMissingName        = "build.missing_name"
+	BuildMissingLanguage    = "build.missing_language"
+	BuildSkipping           = "build.skipping"
+	BuildSkippingPrebuilt   = "build.skipping_prebuilt"
+	BuildStarted            = "build.started"
+	BuildFinished           = "build.finished"
+	BuildWorkerDone         = "build.worker_done"
+	BuildTotalTime          = "build.total_time"
+	BuildErrorSummary       = "build.error_summary"
+	BuildPullTemplatesError = "build.pull_templates_error"
+	BuildInterrupted        = "build.interrupted"
+	BuildInterruptedSummary = "build.interrupted_summary"
+)
+
+// Message IDs for the new flow
+const (
+	NewMissingLanguage       = "new.missing_language"
+	NewMissingName           = "new.missing_name"
+	NewNoTemplates           = "new.no_templates"
+	NewLanguagesAvailable    = "new.languages_available"
+	NewUnsupportedLanguage   = "new.unsupported_language"
+	NewStackFileUpdated      = "new.stack_file_updated"
+	NewStackFileWritten      = "new.stack_file_written"
+	NewFolderExists          = "new.folder_exists"
+	NewFileExists            = "new.file_exists"
+	NewFolderCreated         = "new.folder_created"
+	NewFunctionCreated       = "new.function_created"
+	NewTemplateNotes         = "new.template_notes"
+	NewDuplicateFunctionName = "new.duplicate_function_name"
+	NewFunctionInOtherStacks = "new.function_in_other_stacks"
+)
+
+var english = Catalog{
+	DeployUpdateReplaceConflict: `Cannot specify --update and --replace at the same time. One of --update or --replace must be false.
+  --replace    updates the function in place when possible, otherwise removes and re-creates it
+  --update     performs a rolling update to a new function image or configuration (default true)`,
+	DeployUpdateReplaceConflictErr: "cannot specify --update and --replace at the same time",
+	DeployMissingImageOrName:       "To deploy a function give --yaml/-f or a --image and --name flag",
+	DeployingFunction:              "Deploying: %s.\n",
+	DeployTemplateMissing: `template directory may be missing or invalid, please run "faas-cli template pull"
+Error: %s`,
+	DeployFailedStatus: "Function '%s' failed to deploy with status code: %d",
+
+	BuildMissingImage:       "please provide a valid --image name for your Docker image",
+	BuildMissingHandler:     "please provide the full path to your function's handler",
+	BuildMissingName:        "please provide the deployed --name of your function",
+	BuildMissingLanguage:    "Please provide a valid language for your function.",
+	BuildSkipping:           "Skipping build of: %s.\n",
+	BuildSkippingPrebuilt:   "Skipping build of: %s, it uses the prebuilt image %s.\n",
+	BuildStarted:            "[%d] > Building %s.\n",
+	BuildFinished:           "[%d] < Building %s done in %1.2fs.\n",
+	BuildWorkerDone:         "[%d] Worker done.\n",
+	BuildTotalTime:          "Total build time: %1.2fs",
+	BuildErrorSummary:       "Errors received during build:\n",
+	BuildPullTemplatesError: "could not pull templates for OpenFaaS: %v",
+	BuildInterrupted:        "\nInterrupted, stopping the builds which are running.\n",
+	BuildInterruptedSummary: "Built: %s\nNot built: %s\n",
+
+	NewMissingLanguage: "you must supply a function language with the --lang flag",
+	NewMissingName:     "please provide a name for the function",
+	NewNoTemplates: `no language templates were found.
+
+Download templates:
+  faas-cli template pull           download the default templates
+  faas-cli template store list     view the community template store`,
+	NewLanguagesAvailable:  "Languages available as templates:\n%s\n",
+	NewUnsupportedLanguage: "%s is unavailable or not supported",
+	NewStackFileUpdated:    "Stack file updated: %s\n",
+	NewStackFileWritten:    "Stack file written: %s\n",
+	NewFolderExists:        "folder: %s already exists",
+	NewFileExists:          "file: %s already exists",
+	NewFolderCreated:       "Folder: %s created.\n",
+	NewFunctionCreated:     "\nFunction created in folder: %s\n",
+	NewTemplateNotes:       "\nNotes:\n",
+	NewDuplicateFunctionName: `
+Function %s already exists in %s file. 
+Cannot have duplicate function names in same yaml file`,
+	NewFunctionInOtherStacks: "function %s is also in %s, the stacks would deploy over the same function",
+}
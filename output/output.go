@@ -0,0 +1,82 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+// Package output formats the CLI's leveled messages, colors are only used when
+// stdout is a terminal and NO_COLOR is not set, see https://no-color.org
+package output
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/docker/docker/pkg/term"
+	"github.com/morikuni/aec"
+)
+
+// NoColorEnvironment disables colors when set to any value
+const NoColorEnvironment = "NO_COLOR"
+
+// Glyphs printed before success, warning and failure messages
+const (
+	SuccessGlyph = "✔"
+	WarningGlyph = "⚠"
+	FailureGlyph = "✘"
+)
+
+// Colors is true when messages are colored, it is detected once at start-up
+var Colors = colorsEnabled(os.Stdout)
+
+func colorsEnabled(f *os.File) bool {
+	if _, ok := os.LookupEnv(NoColorEnvironment); ok {
+		return false
+	}
+	return term.IsTerminal(f.Fd())
+}
+
+// Color applies the ANSI color to s when colors are enabled
+func Color(s string, color aec.ANSI) string {
+	if !Colors {
+		return s
+	}
+	return color.Apply(s)
+}
+
+// Info formats a progress message, such as the start of a build
+func Info(format string, a ...interface{}) string {
+	return Color(fmt.Sprintf(format, a...), aec.YellowF)
+}
+
+// Success formats a message for a step which completed
+func Success(format string, a ...interface{}) string {
+	return Color(SuccessGlyph+" "+fmt.Sprintf(format, a...), aec.GreenF)
+}
+
+// Warning formats a message for a problem which does not stop the command
+func Warning(format string, a ...interface{}) string {
+	return Color(WarningGlyph+" "+fmt.Sprintf(format, a...), aec.YellowF)
+}
+
+// Failure formats a message for a step which failed
+func Failure(format string, a ...interface{}) string {
+	return Color(FailureGlyph+" "+fmt.Sprintf(format, a...), aec.RedF)
+}
+
+// Progress is where a command writes its progress, it is discarded in quiet
+// mode so that only the results of the command are written to stdout
+func Progress(quiet bool) io.Writer {
+	if quiet {
+		return ioutil.Discard
+	}
+	return os.Stdout
+}
+
+// Warnings is where a command writes its warnings, in quiet mode they are
+// written to stderr so that they are still seen, but not mixed with the results
+func Warnings(quiet bool) io.Writer {
+	if quiet {
+		return os.Stderr
+	}
+	return os.Stdout
+}
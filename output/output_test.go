@@ -0,0 +1,48 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package output
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/morikuni/aec"
+)
+
+func Test_Color(t *testing.T) {
+	defer func(colors bool) { Colors = colors }(Colors)
+
+	Colors = false
+	if got := Failure("build of %s failed", "figlet"); got != "✘ build of figlet failed" {
+		t.Fatalf("want plain text without colors, but got: %q", got)
+	}
+
+	Colors = true
+	want := aec.GreenF.Apply("✔ Image: figlet built")
+	if got := Success("Image: %s built", "figlet"); got != want {
+		t.Fatalf("want: %q, but got: %q", want, got)
+	}
+}
+
+func Test_colorsEnabled_NoColor(t *testing.T) {
+	os.Setenv(NoColorEnvironment, "")
+	defer os.Unsetenv(NoColorEnvironment)
+
+	if colorsEnabled(os.Stdout) {
+		t.Fatalf("want colors to be disabled when %s is set", NoColorEnvironment)
+	}
+}
+
+func Test_Progress_Warnings(t *testing.T) {
+	if Progress(false) != os.Stdout || Warnings(false) != os.Stdout {
+		t.Fatalf("want progress and warnings on stdout")
+	}
+	if Progress(true) != ioutil.Discard {
+		t.Fatalf("want progress discarded in quiet mode")
+	}
+	if Warnings(true) != os.Stderr {
+		t.Fatalf("want warnings on stderr in quiet mode")
+	}
+}
@@ -58,6 +58,12 @@ func NewCLIAuth(token string, gateway string) (ClientAuth, error) {
 
 	}
 
+	// A token obtained with the client_credentials or refresh_token grant is
+	// refreshed when it expires, unless a token is given
+	if len(token) == 0 && authConfig.ClientCredentials != nil {
+		return NewClientCredentialsAuth(authConfig), nil
+	}
+
 	// User specified token gets priority
 	if len(token) > 0 {
 		bearerToken = token
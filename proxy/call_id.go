@@ -0,0 +1,69 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// CallIDHeader is read by the gateway and written into its logs, so that a
+// request made by the CLI can be found again
+const CallIDHeader = "X-Call-Id"
+
+// NewCallID returns a random UUID (v4) to use as a call ID
+func NewCallID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// setCallID sets the call ID header, unless one was given already
+func setCallID(req *http.Request, callID string) string {
+	if existing := req.Header.Get(CallIDHeader); len(existing) > 0 {
+		return existing
+	}
+
+	if len(callID) == 0 {
+		callID = NewCallID()
+	}
+	req.Header.Set(CallIDHeader, callID)
+	return callID
+}
+
+// CallError is an error from a call to the gateway, with the call ID which
+// the gateway logged the call under
+type CallError struct {
+	CallID string
+	Err    error
+}
+
+func (e *CallError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CallError) Unwrap() error {
+	return e.Err
+}
+
+// withCallID wraps err in a CallError with the call ID of req
+func withCallID(req *http.Request, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &CallError{CallID: req.Header.Get(CallIDHeader), Err: err}
+}
+
+// callIDSuffix is added to a failure which is returned as text rather than as
+// an error, so that it can be matched to the logs of the gateway
+func callIDSuffix(req *http.Request) string {
+	return fmt.Sprintf(" (%s: %s)", CallIDHeader, req.Header.Get(CallIDHeader))
+}
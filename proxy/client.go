@@ -22,6 +22,14 @@ type Client struct {
 	GatewayURL *url.URL
 	//UserAgent user agent for the client
 	UserAgent string
+	//CallID sent in the X-Call-Id header of each request, when empty a new ID is generated per request
+	CallID string
+	//Quiet leaves out the progress of DeployFunction and DeleteFunction, their
+	//failures are written to stderr instead of stdout
+	Quiet bool
+
+	// headers are sent with each request, see gatewayHeaders
+	headers map[string]string
 }
 
 //ClientAuth an interface for client authentication.
@@ -30,6 +38,13 @@ type ClientAuth interface {
 	Set(req *http.Request) error
 }
 
+// RefreshableAuth is a ClientAuth whose token can be replaced, a request which
+// is rejected with 401 Unauthorized is sent once more after a refresh
+type RefreshableAuth interface {
+	ClientAuth
+	Refresh() error
+}
+
 //NewClient initializes a new API client
 func NewClient(auth ClientAuth, gatewayURL string, transport http.RoundTripper, timeout *time.Duration) (*Client, error) {
 	gatewayURL = strings.TrimRight(gatewayURL, "/")
@@ -47,10 +62,16 @@ func NewClient(auth ClientAuth, gatewayURL string, transport http.RoundTripper,
 		client.Transport = transport
 	}
 
+	headers, err := gatewayHeaders(gatewayURL)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Client{
 		ClientAuth: auth,
 		httpClient: client,
 		GatewayURL: baseURL,
+		headers:    headers,
 	}, nil
 }
 
@@ -82,8 +103,12 @@ func (c *Client) newRequest(method, path string, body io.Reader) (*http.Request,
 		req.Header.Set("User-Agent", c.UserAgent)
 	}
 
+	setCallID(req, c.CallID)
+
 	c.ClientAuth.Set(req)
 
+	setHeaders(req, c.headers)
+
 	return req, err
 }
 
@@ -100,6 +125,13 @@ func (c *Client) doRequest(ctx context.Context, req *http.Request) (*http.Respon
 	}
 	resp, err := c.httpClient.Do(req)
 
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		if retried, ok := c.retryWithRefresh(req); ok {
+			resp.Body.Close()
+			resp, err = c.httpClient.Do(retried)
+		}
+	}
+
 	if err != nil {
 		select {
 		case <-ctx.Done():
@@ -111,6 +143,40 @@ func (c *Client) doRequest(ctx context.Context, req *http.Request) (*http.Respon
 	return resp, err
 }
 
+// retryWithRefresh refreshes the token when the auth supports it and returns
+// a copy of the request with the new token. A token can expire while a long
+// command runs, so the request is sent once more before the 401 is returned.
+func (c *Client) retryWithRefresh(req *http.Request) (*http.Request, bool) {
+	auth, ok := c.ClientAuth.(RefreshableAuth)
+	if !ok {
+		return nil, false
+	}
+
+	// A body which has been read can only be sent again when it can be copied
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return nil, false
+	}
+
+	if err := auth.Refresh(); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to refresh the token after 401 Unauthorized: %s\n", err)
+		return nil, false
+	}
+
+	retried := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, false
+		}
+		retried.Body = body
+	}
+
+	if err := auth.Set(retried); err != nil {
+		return nil, false
+	}
+	return retried, true
+}
+
 func addQueryParams(u string, params map[string]string) (string, error) {
 	parsedURL, err := url.Parse(u)
 	if err != nil {
@@ -0,0 +1,186 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openfaas/faas-cli/config"
+)
+
+// tokenExpiryMargin is how long before it expires that a token is refreshed,
+// so that it does not expire while a request is in flight
+const tokenExpiryMargin = 30 * time.Second
+
+// ClientCredentialsToken is the response of a token endpoint
+type ClientCredentialsToken struct {
+	AccessToken string `json:"access_token"`
+	Scope       string `json:"scope"`
+	ExpiresIn   int    `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+
+	// RefreshToken is set when the token endpoint rotates the refresh token
+	RefreshToken string `json:"refresh_token"`
+}
+
+// ExpiresAt is when the token expires in seconds since the Unix epoch, or 0
+// when the token endpoint did not say
+func (t ClientCredentialsToken) ExpiresAt(now time.Time) int64 {
+	if t.ExpiresIn <= 0 {
+		return 0
+	}
+	return now.Add(time.Duration(t.ExpiresIn) * time.Second).Unix()
+}
+
+// RequestClientCredentialsToken requests a token for a machine user from an
+// OAuth2 or OIDC token endpoint with the client_credentials grant
+func RequestClientCredentialsToken(client *http.Client, credentials config.ClientCredentials) (ClientCredentialsToken, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", credentials.ClientID)
+	form.Set("client_secret", credentials.ClientSecret)
+	if len(credentials.Audience) > 0 {
+		form.Set("audience", credentials.Audience)
+	}
+	if len(credentials.Scope) > 0 {
+		form.Set("scope", credentials.Scope)
+	}
+	return requestToken(client, credentials.TokenURL, form)
+}
+
+// RequestRefreshedToken requests a new token for a user from an OAuth2 or
+// OIDC token endpoint with the refresh_token grant, the client secret is only
+// sent for a confidential client
+func RequestRefreshedToken(client *http.Client, credentials config.ClientCredentials) (ClientCredentialsToken, error) {
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", credentials.RefreshToken)
+	form.Set("client_id", credentials.ClientID)
+	if len(credentials.ClientSecret) > 0 {
+		form.Set("client_secret", credentials.ClientSecret)
+	}
+	if len(credentials.Scope) > 0 {
+		form.Set("scope", credentials.Scope)
+	}
+	return requestToken(client, credentials.TokenURL, form)
+}
+
+func requestToken(client *http.Client, tokenURL string, form url.Values) (ClientCredentialsToken, error) {
+	var token ClientCredentialsToken
+
+	req, err := http.NewRequest(http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return token, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return token, fmt.Errorf("cannot POST to %s: %s", tokenURL, err)
+	}
+	defer res.Body.Close()
+
+	body, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK {
+		return token, fmt.Errorf("cannot authenticate, code: %d.\nResponse: %s", res.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, &token); err != nil {
+		return token, fmt.Errorf("unable to unmarshal token: %s", string(body))
+	}
+	if len(token.AccessToken) == 0 {
+		return token, fmt.Errorf("no access_token was returned by %s", tokenURL)
+	}
+	return token, nil
+}
+
+// ClientCredentialsAuth sets a bearer token which was obtained with the
+// client_credentials or refresh_token grant, the token is requested again with
+// the same grant when it expires and saved for the next command, so that long
+// deployments keep working
+type ClientCredentialsAuth struct {
+	mu   sync.Mutex
+	auth config.AuthConfig
+
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewClientCredentialsAuth returns the auth for a gateway whose config has
+// client credentials
+func NewClientCredentialsAuth(auth config.AuthConfig) *ClientCredentialsAuth {
+	return &ClientCredentialsAuth{auth: auth, now: time.Now}
+}
+
+func (c *ClientCredentialsAuth) Set(req *http.Request) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.expired() {
+		if err := c.refresh(); err != nil {
+			return err
+		}
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.auth.Token)
+	return nil
+}
+
+// Refresh requests a new token even when the current one has not expired, it
+// is used when the gateway rejects the token
+func (c *ClientCredentialsAuth) Refresh() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.refresh()
+}
+
+func (c *ClientCredentialsAuth) expired() bool {
+	if len(c.auth.Token) == 0 {
+		return true
+	}
+	if c.auth.ExpiresAt == 0 {
+		return false
+	}
+	return !c.now().Add(tokenExpiryMargin).Before(time.Unix(c.auth.ExpiresAt, 0))
+}
+
+func (c *ClientCredentialsAuth) refresh() error {
+	request := RequestClientCredentialsToken
+	if c.auth.ClientCredentials.Grant == config.RefreshTokenGrant {
+		request = RequestRefreshedToken
+	}
+	token, err := request(c.client, *c.auth.ClientCredentials)
+	if err != nil {
+		return fmt.Errorf("unable to refresh the token for %s: %s", c.auth.Gateway, err)
+	}
+
+	c.auth.Token = token.AccessToken
+	c.auth.ExpiresAt = token.ExpiresAt(c.now())
+	if c.auth.ClientCredentials.Grant == config.RefreshTokenGrant && len(token.RefreshToken) > 0 {
+		credentials := *c.auth.ClientCredentials
+		credentials.RefreshToken = token.RefreshToken
+		c.auth.ClientCredentials = &credentials
+	}
+
+	var store config.CredentialStore
+	if len(c.auth.CredentialStore) > 0 {
+		store = config.NewCredentialStore(c.auth.CredentialStore)
+	}
+
+	// The new token is used for this command even when it cannot be saved
+	config.UpdateClientCredentialsAuthConfig(c.auth, store)
+	return nil
+}
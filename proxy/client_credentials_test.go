@@ -0,0 +1,312 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas-cli/config"
+)
+
+// newTokenServer issues numbered tokens which expire after expiresIn seconds
+func newTokenServer(t *testing.T, expiresIn int, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("client_id") != "ci" || r.Form.Get("client_secret") != "s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, `{"error":"invalid_client"}`)
+			return
+		}
+		if r.Form.Get("audience") != "http://openfaas.test" || r.Form.Get("scope") != "deploy" {
+			t.Errorf("want the audience and scope, got %v", r.Form)
+		}
+
+		*requests++
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":%d}`, *requests, expiresIn)
+	}))
+}
+
+func testClientCredentials(tokenURL string) *config.ClientCredentials {
+	return &config.ClientCredentials{
+		TokenURL:     tokenURL,
+		ClientID:     "ci",
+		ClientSecret: "s3cr3t",
+		Audience:     "http://openfaas.test",
+		Scope:        "deploy",
+	}
+}
+
+func Test_RequestClientCredentialsToken(t *testing.T) {
+	requests := 0
+	server := newTokenServer(t, 3600, &requests)
+	defer server.Close()
+
+	token, err := RequestClientCredentialsToken(nil, *testClientCredentials(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "token-1" {
+		t.Errorf("want token-1, got %s", token.AccessToken)
+	}
+
+	now := time.Unix(1700000000, 0)
+	if got := token.ExpiresAt(now); got != 1700003600 {
+		t.Errorf("want the token to expire in an hour, got %d", got)
+	}
+
+	credentials := testClientCredentials(server.URL)
+	credentials.ClientSecret = "wrong"
+	_, err = RequestClientCredentialsToken(nil, *credentials)
+	want := "cannot authenticate, code: 401.\nResponse: {\"error\":\"invalid_client\"}"
+	if err == nil || err.Error() != want {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+}
+
+func Test_ClientCredentialsAuth_Refresh(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "faas-cli-client-credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(configDir)
+
+	os.Setenv(config.ConfigLocationEnv, configDir)
+	defer os.Unsetenv(config.ConfigLocationEnv)
+
+	requests := 0
+	server := newTokenServer(t, 60, &requests)
+	defer server.Close()
+
+	gatewayURL := "http://openfaas.test"
+	now := time.Unix(1700000000, 0)
+	auth := config.AuthConfig{
+		Gateway:           gatewayURL,
+		Auth:              config.Oauth2AuthType,
+		Token:             "token-0",
+		ExpiresAt:         now.Add(time.Minute).Unix(),
+		ClientCredentials: testClientCredentials(server.URL),
+	}
+	if err := config.UpdateClientCredentialsAuthConfig(auth, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	cliAuth, err := NewCLIAuth("", gatewayURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientAuth, ok := cliAuth.(*ClientCredentialsAuth)
+	if !ok {
+		t.Fatalf("want a ClientCredentialsAuth, got %T", cliAuth)
+	}
+	clientAuth.now = func() time.Time { return now }
+
+	cases := []struct {
+		name      string
+		elapsed   time.Duration
+		wantToken string
+	}{
+		{name: "the saved token is valid", elapsed: 0, wantToken: "token-0"},
+		{name: "the saved token is about to expire", elapsed: 40 * time.Second, wantToken: "token-1"},
+		{name: "the new token is valid", elapsed: 50 * time.Second, wantToken: "token-1"},
+		{name: "the new token has expired", elapsed: 2 * time.Minute, wantToken: "token-2"},
+	}
+
+	for _, tc := range cases {
+		clientAuth.now = func() time.Time { return now.Add(tc.elapsed) }
+
+		req := httptest.NewRequest(http.MethodGet, gatewayURL+"/system/functions", nil)
+		if err := clientAuth.Set(req); err != nil {
+			t.Fatalf("%s: %s", tc.name, err)
+		}
+		if got := req.Header.Get("Authorization"); got != "Bearer "+tc.wantToken {
+			t.Errorf("%s: want Bearer %s, got %s", tc.name, tc.wantToken, got)
+		}
+	}
+
+	saved, err := config.LookupAuthConfig(gatewayURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Token != "token-2" || saved.ExpiresAt != now.Add(3*time.Minute).Unix() {
+		t.Errorf("want the refreshed token to be saved, got %s which expires at %d", saved.Token, saved.ExpiresAt)
+	}
+
+	// A token which is given is used as it is
+	cliAuth, err = NewCLIAuth("given", gatewayURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cliAuth.(*BearerToken); !ok {
+		t.Errorf("want a BearerToken for a token which is given, got %T", cliAuth)
+	}
+}
+
+func Test_doRequest_RetriesWithRefreshedToken(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "faas-cli-client-credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(configDir)
+
+	os.Setenv(config.ConfigLocationEnv, configDir)
+	defer os.Unsetenv(config.ConfigLocationEnv)
+
+	requests := 0
+	tokenServer := newTokenServer(t, 3600, &requests)
+	defer tokenServer.Close()
+
+	bodies := []string{}
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if r.Header.Get("Authorization") != "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer gateway.Close()
+
+	// The saved token has not expired, but the gateway no longer accepts it
+	auth := NewClientCredentialsAuth(config.AuthConfig{
+		Gateway:           gateway.URL,
+		Auth:              config.Oauth2AuthType,
+		Token:             "revoked",
+		ClientCredentials: testClientCredentials(tokenServer.URL),
+	})
+
+	cases := []struct {
+		name       string
+		auth       ClientAuth
+		wantStatus int
+		wantBodies []string
+	}{
+		{name: "refreshable auth", auth: auth, wantStatus: http.StatusAccepted, wantBodies: []string{`{"service":"fn"}`, `{"service":"fn"}`}},
+		{name: "token which cannot be refreshed", auth: &BearerToken{token: "revoked"}, wantStatus: http.StatusUnauthorized, wantBodies: []string{`{"service":"fn"}`}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			bodies = []string{}
+
+			client, err := NewClient(tc.auth, gateway.URL, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := client.newRequest(http.MethodPost, "/system/functions", strings.NewReader(`{"service":"fn"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := client.doRequest(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if res.StatusCode != tc.wantStatus {
+				t.Errorf("want status %d, got %d", tc.wantStatus, res.StatusCode)
+			}
+			if !reflect.DeepEqual(bodies, tc.wantBodies) {
+				t.Errorf("want the gateway to receive %q, got %q", tc.wantBodies, bodies)
+			}
+		})
+	}
+
+	if requests != 1 {
+		t.Errorf("want one token to be requested, got %d", requests)
+	}
+}
+
+func Test_ClientCredentialsAuth_RefreshTokenGrant(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "faas-cli-refresh-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(configDir)
+
+	os.Setenv(config.ConfigLocationEnv, configDir)
+	defer os.Unsetenv(config.ConfigLocationEnv)
+
+	// Each refresh token can be used once, a new one is issued with the token
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		want := fmt.Sprintf("refresh-%d", requests)
+		if r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("refresh_token") != want || r.Form.Get("client_id") != "cli" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"error":"invalid_grant"}`)
+			return
+		}
+		if _, ok := r.Form["client_secret"]; ok {
+			t.Errorf("want no client_secret for a public client, got %v", r.Form)
+		}
+
+		requests++
+		fmt.Fprintf(w, `{"access_token":"token-%d","refresh_token":"refresh-%d","token_type":"Bearer","expires_in":3600}`, requests, requests)
+	}))
+	defer server.Close()
+
+	gatewayURL := "http://openfaas.test"
+	auth := config.AuthConfig{
+		Gateway: gatewayURL,
+		Auth:    config.Oauth2AuthType,
+		Token:   "token-0",
+		ClientCredentials: &config.ClientCredentials{
+			TokenURL:     server.URL,
+			ClientID:     "cli",
+			Grant:        config.RefreshTokenGrant,
+			RefreshToken: "refresh-0",
+		},
+	}
+	if err := config.UpdateClientCredentialsAuthConfig(auth, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	cliAuth, err := NewCLIAuth("", gatewayURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientAuth, ok := cliAuth.(*ClientCredentialsAuth)
+	if !ok {
+		t.Fatalf("want a ClientCredentialsAuth, got %T", cliAuth)
+	}
+
+	for _, wantToken := range []string{"token-1", "token-2"} {
+		if err := clientAuth.Refresh(); err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodGet, gatewayURL+"/system/functions", nil)
+		if err := clientAuth.Set(req); err != nil {
+			t.Fatal(err)
+		}
+		if got := req.Header.Get("Authorization"); got != "Bearer "+wantToken {
+			t.Errorf("want Bearer %s, got %s", wantToken, got)
+		}
+	}
+
+	saved, err := config.LookupAuthConfig(gatewayURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Token != "token-2" || saved.ClientCredentials.RefreshToken != "refresh-2" {
+		t.Errorf("want the token and the new refresh token to be saved, got %s and %s", saved.Token, saved.ClientCredentials.RefreshToken)
+	}
+	if auth.ClientCredentials.RefreshToken != "refresh-0" {
+		t.Errorf("want the client credentials of the caller to be unchanged")
+	}
+}
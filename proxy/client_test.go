@@ -1,7 +1,13 @@
 package proxy
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
 	"testing"
+
+	"github.com/openfaas/faas-cli/test"
 )
 
 func Test_NewClient(t *testing.T) {
@@ -117,3 +123,50 @@ func Test_addQueryParams(t *testing.T) {
 		}
 	}
 }
+
+func Test_newRequest_CallID(t *testing.T) {
+	client, err := NewClient(NewTestAuth(nil), "http://127.0.0.1:8080", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	first, _ := client.newRequest(http.MethodGet, "/system/functions", nil)
+	second, _ := client.newRequest(http.MethodGet, "/system/functions", nil)
+
+	firstID := first.Header.Get(CallIDHeader)
+	if len(firstID) != 36 {
+		t.Fatalf("want a generated UUID in %s, got: %q", CallIDHeader, firstID)
+	}
+	if firstID == second.Header.Get(CallIDHeader) {
+		t.Fatalf("want a new call ID per request, got %q twice", firstID)
+	}
+
+	client.CallID = "deploy-1234"
+	req, _ := client.newRequest(http.MethodGet, "/system/functions", nil)
+	if got := req.Header.Get(CallIDHeader); got != "deploy-1234" {
+		t.Fatalf("want call ID %q, got: %q", "deploy-1234", got)
+	}
+}
+
+func Test_ListFunctions_CallIDInError(t *testing.T) {
+	s := test.MockHttpServerStatus(t, http.StatusInternalServerError)
+	defer s.Close()
+
+	client, err := NewClient(NewTestAuth(nil), s.URL, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	client.CallID = "list-1234"
+
+	_, err = client.ListFunctions(context.Background(), "")
+	var callErr *CallError
+	if !errors.As(err, &callErr) {
+		t.Fatalf("want a CallError, got: %v", err)
+	}
+	if callErr.CallID != "list-1234" {
+		t.Fatalf("want call ID %q, got: %q", "list-1234", callErr.CallID)
+	}
+	if !strings.Contains(err.Error(), "server returned unexpected status code: 500") {
+		t.Fatalf("want the message of the failure, got: %s", err)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"io/ioutil"
 	"net/http"
 
+	"github.com/openfaas/faas-cli/output"
 	"github.com/openfaas/faas/gateway/requests"
 )
 
@@ -30,14 +31,14 @@ func (c *Client) DeleteFunction(ctx context.Context, functionName string, namesp
 
 	req, err := c.newRequest(http.MethodDelete, deleteEndpoint, reader)
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(output.Warnings(c.Quiet), err)
 		return err
 	}
 	delRes, delErr := c.doRequest(ctx, req)
 
 	if delErr != nil {
-		fmt.Printf("Error removing existing function: %s, gateway=%s, functionName=%s\n", delErr.Error(), c.GatewayURL.String(), functionName)
-		return delErr
+		fmt.Fprintf(output.Warnings(c.Quiet), "Error removing existing function: %s, gateway=%s, functionName=%s\n", delErr.Error(), c.GatewayURL.String(), functionName)
+		return withCallID(req, fmt.Errorf("%w on URL: %s, error: %s", ErrGatewayUnreachable, c.GatewayURL.String(), delErr))
 	}
 
 	if delRes.Body != nil {
@@ -46,11 +47,11 @@ func (c *Client) DeleteFunction(ctx context.Context, functionName string, namesp
 
 	switch delRes.StatusCode {
 	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
-		fmt.Println("Removing old function.")
+		fmt.Fprintln(output.Progress(c.Quiet), "Removing old function.")
 	case http.StatusNotFound:
-		err = fmt.Errorf("No existing function to remove")
+		err = fmt.Errorf("No existing function to remove: function %s %w", functionName, ErrNotFound)
 	case http.StatusUnauthorized:
-		err = fmt.Errorf("unauthorized access, run \"faas-cli login\" to setup authentication for this server")
+		err = ErrUnauthorized
 	default:
 		var bodyReadErr error
 		bytesOut, bodyReadErr := ioutil.ReadAll(delRes.Body)
@@ -61,5 +62,5 @@ func (c *Client) DeleteFunction(ctx context.Context, functionName string, namesp
 		}
 	}
 
-	return err
+	return withCallID(req, err)
 }
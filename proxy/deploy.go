@@ -7,11 +7,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/openfaas/faas-cli/output"
 	"github.com/openfaas/faas-cli/stack"
 
 	types "github.com/openfaas/faas-provider/types"
@@ -59,25 +62,75 @@ func generateFuncStr(spec *DeployFunctionSpec) string {
 
 // DeployFunction first tries to deploy a function and if it exists will then attempt
 // a rolling update. Warnings are suppressed for the second API call (if required.)
+// With Replace the function is only removed and re-created when the gateway
+// refuses to update it.
 func (c *Client) DeployFunction(context context.Context, spec *DeployFunctionSpec) int {
+	statusCode, _ := c.DeployFunctionWithError(context, spec)
+	return statusCode
+}
+
+// DeployFunctionWithError deploys a function like DeployFunction and also
+// returns why the deployment failed. The error wraps ErrGatewayUnreachable
+// when no response was received, ErrUnauthorized when the credentials were
+// refused and ErrDeployRejected for any other status from the gateway.
+func (c *Client) DeployFunctionWithError(context context.Context, spec *DeployFunctionSpec) (int, error) {
+	var statusCode int
+	var deployOutput string
+	var err error
 
-	rollingUpdateInfo := fmt.Sprintf("Function %s already exists, attempting rolling-update.", spec.FunctionName)
-	statusCode, deployOutput := c.deploy(context, spec, spec.Update)
+	if spec.Replace {
+		statusCode, deployOutput, err = c.recreate(context, spec)
+	} else {
+		rollingUpdateInfo := fmt.Sprintf("Function %s already exists, attempting rolling-update.", spec.FunctionName)
+		statusCode, deployOutput, err = c.deploy(context, spec, spec.Update)
 
-	if spec.Update == true && statusCode == http.StatusNotFound {
-		// Re-run the function with update=false
+		if spec.Update == true && statusCode == http.StatusNotFound {
+			// Re-run the function with update=false
 
-		statusCode, deployOutput = c.deploy(context, spec, false)
-	} else if statusCode == http.StatusOK {
-		fmt.Println(rollingUpdateInfo)
+			statusCode, deployOutput, err = c.deploy(context, spec, false)
+		} else if statusCode == http.StatusOK {
+			fmt.Fprintln(output.Progress(c.Quiet), rollingUpdateInfo)
+		}
 	}
-	fmt.Println()
-	fmt.Println(deployOutput)
-	return statusCode
+	if err == nil {
+		fmt.Fprintln(output.Progress(c.Quiet))
+		fmt.Fprintln(output.Progress(c.Quiet), output.Success("%s", deployOutput))
+	} else {
+		fmt.Fprintln(output.Warnings(c.Quiet))
+		fmt.Fprintln(output.Warnings(c.Quiet), output.Failure("%s", deployOutput))
+	}
+	return statusCode, err
+}
+
+// recreate updates a function in place and falls back to removing and creating
+// it again only when the gateway refuses the update, i.e. for a change which
+// cannot be rolled out. The function is left as it was when it cannot be removed.
+func (c *Client) recreate(ctx context.Context, spec *DeployFunctionSpec) (int, string, error) {
+	statusCode, deployOutput, err := c.deploy(ctx, spec, true)
+
+	switch statusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
+		fmt.Fprintf(output.Progress(c.Quiet), "Function %s was updated in place, it did not need to be re-created.\n", spec.FunctionName)
+		return statusCode, deployOutput, err
+	case http.StatusNotFound:
+		return c.deploy(ctx, spec, false)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return statusCode, deployOutput, err
+	}
+	if errors.Is(err, ErrGatewayUnreachable) {
+		return statusCode, deployOutput, err
+	}
+
+	fmt.Fprintf(output.Progress(c.Quiet), "The gateway refused to update function %s, re-creating it.\n", spec.FunctionName)
+	if deleteErr := c.DeleteFunction(ctx, spec.FunctionName, spec.Namespace); deleteErr != nil && !errors.Is(deleteErr, ErrNotFound) {
+		return statusCode, deployOutput + fmt.Sprintf("Unable to remove function %s, it was left as it was: %s\n", spec.FunctionName, deleteErr), err
+	}
+
+	return c.deploy(ctx, spec, false)
 }
 
 // deploy a function to an OpenFaaS gateway over REST
-func (c *Client) deploy(context context.Context, spec *DeployFunctionSpec, update bool) (int, string) {
+func (c *Client) deploy(context context.Context, spec *DeployFunctionSpec, update bool) (int, string, error) {
 
 	var deployOutput string
 	// Need to alter Gateway to allow nil/empty string as fprocess, to avoid this repetition.
@@ -86,10 +139,6 @@ func (c *Client) deploy(context context.Context, spec *DeployFunctionSpec, updat
 		fprocessTemplate = spec.FProcess
 	}
 
-	if spec.Replace {
-		c.DeleteFunction(context, spec.FunctionName, spec.Namespace)
-	}
-
 	req := types.FunctionDeployment{
 		EnvProcess:             fprocessTemplate,
 		Image:                  spec.Image,
@@ -146,16 +195,18 @@ func (c *Client) deploy(context context.Context, spec *DeployFunctionSpec, updat
 	request, err = c.newRequest(method, "/system/functions", reader)
 
 	if err != nil {
+		err = fmt.Errorf("unable to create a request for %s: %w", c.GatewayURL.String(), err)
 		deployOutput += fmt.Sprintln(err)
-		return http.StatusInternalServerError, deployOutput
+		return http.StatusInternalServerError, deployOutput, err
 	}
 
 	res, err := c.doRequest(context, request)
 
 	if err != nil {
+		err = fmt.Errorf("%w on URL: %s, error: %s", ErrGatewayUnreachable, c.GatewayURL.String(), err)
 		deployOutput += fmt.Sprintln("Is OpenFaaS deployed? Do you need to specify the --gateway flag?")
 		deployOutput += fmt.Sprintln(err)
-		return http.StatusInternalServerError, deployOutput
+		return http.StatusInternalServerError, deployOutput, withCallID(request, err)
 	}
 
 	if res.Body != nil {
@@ -169,14 +220,22 @@ func (c *Client) deploy(context context.Context, spec *DeployFunctionSpec, updat
 		deployedURL := fmt.Sprintf("URL: %s/function/%s", c.GatewayURL.String(), generateFuncStr(spec))
 		deployOutput += fmt.Sprintln(deployedURL)
 	case http.StatusUnauthorized:
-		deployOutput += fmt.Sprintln("unauthorized access, run \"faas-cli login\" to setup authentication for this server")
+		err = ErrUnauthorized
+		deployOutput += fmt.Sprintln(err)
 
 	default:
-		bytesOut, err := ioutil.ReadAll(res.Body)
-		if err == nil {
-			deployOutput += fmt.Sprintf("Unexpected status: %d, message: %s\n", res.StatusCode, string(bytesOut))
+		message := ""
+		if bytesOut, readErr := ioutil.ReadAll(res.Body); readErr == nil {
+			message = strings.TrimSpace(string(bytesOut))
+			deployOutput += fmt.Sprintf("Unexpected status: %d, message: %s\n", res.StatusCode, message)
+			deployOutput += hintForDeployError(message, spec)
+		}
+		if res.StatusCode == http.StatusForbidden {
+			err = fmt.Errorf("%w: %s", ErrUnauthorized, message)
+		} else {
+			err = fmt.Errorf("%w with status %d: %s", ErrDeployRejected, res.StatusCode, message)
 		}
 	}
 
-	return res.StatusCode, deployOutput
+	return res.StatusCode, deployOutput, withCallID(request, err)
 }
@@ -0,0 +1,75 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// deployHint maps an error returned by the gateway for a deployment to what
+// the user can do about it
+type deployHint struct {
+	pattern *regexp.Regexp
+	hint    func(spec *DeployFunctionSpec) string
+}
+
+// deployHints are matched against the body of a failed deployment, the
+// messages come from faas-netes, faasd and the OpenFaaS Pro operator
+var deployHints = []deployHint{
+	{
+		pattern: regexp.MustCompile(`(?i)(invalid image|invalidimagename|invalid reference format|couldn't parse image reference|could not parse reference)`),
+		hint: func(spec *DeployFunctionSpec) string {
+			return fmt.Sprintf("the image %q is not a valid reference, use a name such as docker.io/user/fn:0.1.0", spec.Image)
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)secret.*(not found|does not exist|unable to find|not exist)|(unable to find|unable to get) secret`),
+		hint: func(spec *DeployFunctionSpec) string {
+			return fmt.Sprintf("create the secrets of the function in its namespace first, i.e. faas-cli secret create NAME%s, then list them with faas-cli secret list", namespaceFlag(spec.Namespace))
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)constraint`),
+		hint: func(spec *DeployFunctionSpec) string {
+			return "check the constraints of the function, they take the form key=value or key==value, and some providers such as faasd do not support them"
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)namespace.*(not allowed|not found|is not valid|invalid|must be annotated)|unable to find namespace`),
+		hint: func(spec *DeployFunctionSpec) string {
+			return "the namespace must exist and be annotated with openfaas=\"1\" for the gateway to use it, see faas-cli namespaces"
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)(quantities must match|unable to parse quantity|invalid (memory|cpu|resource))`),
+		hint: func(spec *DeployFunctionSpec) string {
+			return "check the limits and requests of the function, i.e. memory: 128Mi and cpu: 100m"
+		},
+	},
+}
+
+// hintForDeployError returns the hints for a message from the gateway, one
+// per line, or an empty string when the message is not recognised
+func hintForDeployError(message string, spec *DeployFunctionSpec) string {
+	hints := []string{}
+	for _, h := range deployHints {
+		if h.pattern.MatchString(message) {
+			hints = append(hints, "Hint: "+h.hint(spec))
+		}
+	}
+
+	if len(hints) == 0 {
+		return ""
+	}
+	return strings.Join(hints, "\n") + "\n"
+}
+
+func namespaceFlag(namespace string) string {
+	if len(namespace) == 0 {
+		return ""
+	}
+	return " --namespace " + namespace
+}
@@ -0,0 +1,64 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_hintForDeployError(t *testing.T) {
+	spec := &DeployFunctionSpec{FunctionName: "fn", Image: "Alex/Fn:latest", Namespace: "staging"}
+
+	cases := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{
+			name:    "invalid image",
+			message: `couldn't parse image reference "Alex/Fn:latest": invalid reference format: repository name must be lowercase`,
+			want:    `the image "Alex/Fn:latest" is not a valid reference`,
+		},
+		{
+			name:    "missing secret",
+			message: `unable to find secret: api-key`,
+			want:    "faas-cli secret create NAME --namespace staging",
+		},
+		{
+			name:    "missing secret in faas-netes",
+			message: `secrets "api-key" not found`,
+			want:    "faas-cli secret create NAME --namespace staging",
+		},
+		{
+			name:    "unsupported constraint",
+			message: `constraints are not supported by this provider`,
+			want:    "check the constraints of the function",
+		},
+		{
+			name:    "namespace",
+			message: `namespace not allowed`,
+			want:    `annotated with openfaas="1"`,
+		},
+		{
+			name:    "unknown",
+			message: `something went wrong`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := hintForDeployError(tc.message, spec)
+			if len(tc.want) == 0 {
+				if len(got) > 0 {
+					t.Fatalf("want no hint, got: %s", got)
+				}
+				return
+			}
+			if !strings.Contains(got, tc.want) {
+				t.Fatalf("want a hint with %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
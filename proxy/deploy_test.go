@@ -5,6 +5,7 @@ package proxy
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"testing"
@@ -22,6 +23,7 @@ type deployProxyTest struct {
 	replace             bool
 	update              bool
 	expectedOutput      string
+	expectedErr         error
 }
 
 func runDeployProxyTest(t *testing.T, deployTest deployProxyTest) {
@@ -34,8 +36,9 @@ func runDeployProxyTest(t *testing.T, deployTest deployProxyTest) {
 	cliAuth := NewTestAuth(nil)
 	proxyClient, _ := NewClient(cliAuth, s.URL, nil, &defaultCommandTimeout)
 
+	var err error
 	stdout := test.CaptureStdout(func() {
-		proxyClient.DeployFunction(context.TODO(), &DeployFunctionSpec{
+		_, err = proxyClient.DeployFunctionWithError(context.TODO(), &DeployFunctionSpec{
 			"fprocess",
 			"function",
 			"image",
@@ -61,23 +64,52 @@ func runDeployProxyTest(t *testing.T, deployTest deployProxyTest) {
 	if !r.MatchString(stdout) {
 		t.Fatalf("Output not matched: %s", stdout)
 	}
+	if deployTest.expectedErr == nil && err != nil {
+		t.Fatalf("want no error, got %s", err)
+	}
+	if !errors.Is(err, deployTest.expectedErr) {
+		t.Fatalf("want error %s, got %v", deployTest.expectedErr, err)
+	}
 }
 
 func Test_RunDeployProxyTests(t *testing.T) {
 	var deployProxyTests = []deployProxyTest{
 		{
-			title:               "200_Deploy",
-			mockServerResponses: []int{http.StatusOK, http.StatusOK},
+			title:               "ReplaceUpdatedInPlace",
+			mockServerResponses: []int{http.StatusOK},
+			replace:             true,
+			update:              false,
+			expectedOutput:      `(?m:updated in place)`,
+		},
+		{
+			title:               "ReplaceNotFoundCreated",
+			mockServerResponses: []int{http.StatusNotFound, http.StatusOK},
 			replace:             true,
 			update:              false,
 			expectedOutput:      `(?m:Deployed)`,
 		},
 		{
-			title:               "404_Deploy",
-			mockServerResponses: []int{http.StatusOK, http.StatusNotFound},
+			title:               "ReplaceRefusedRecreated",
+			mockServerResponses: []int{http.StatusBadRequest, http.StatusOK, http.StatusOK},
+			replace:             true,
+			update:              false,
+			expectedOutput:      `(?ms:re-creating it.*Removing old function.*Deployed)`,
+		},
+		{
+			title:               "ReplaceRemoveFailedNotCreated",
+			mockServerResponses: []int{http.StatusBadRequest, http.StatusInternalServerError},
+			replace:             true,
+			update:              false,
+			expectedOutput:      `(?m:Unable to remove function function, it was left as it was)`,
+			expectedErr:         ErrDeployRejected,
+		},
+		{
+			title:               "ReplaceRecreateFailed",
+			mockServerResponses: []int{http.StatusBadRequest, http.StatusOK, http.StatusNotFound},
 			replace:             true,
 			update:              false,
 			expectedOutput:      `(?m:Unexpected status: 404)`,
+			expectedErr:         ErrDeployRejected,
 		},
 		{
 			title:               "UpdateFailedDeployed",
@@ -86,6 +118,18 @@ func Test_RunDeployProxyTests(t *testing.T) {
 			update:              true,
 			expectedOutput:      `(?m:Deployed)`,
 		},
+		{
+			title:               "Rejected",
+			mockServerResponses: []int{http.StatusBadRequest},
+			expectedOutput:      `(?m:Unexpected status: 400)`,
+			expectedErr:         ErrDeployRejected,
+		},
+		{
+			title:               "Unauthorized",
+			mockServerResponses: []int{http.StatusUnauthorized},
+			expectedOutput:      `(?m:unauthorized access)`,
+			expectedErr:         ErrUnauthorized,
+		},
 	}
 	for _, tst := range deployProxyTests {
 		t.Run(tst.title, func(t *testing.T) {
@@ -173,3 +217,19 @@ func NewTestAuth(err error) ClientAuth {
 		err: err,
 	}
 }
+
+func Test_DeployFunctionWithError_gatewayUnreachable(t *testing.T) {
+	s := test.MockHttpServerStatus(t)
+	s.Close()
+
+	proxyClient, _ := NewClient(NewTestAuth(nil), s.URL, nil, &defaultCommandTimeout)
+
+	var err error
+	test.CaptureStdout(func() {
+		_, err = proxyClient.DeployFunctionWithError(context.TODO(), &DeployFunctionSpec{FunctionName: "function", Image: "image"})
+	})
+
+	if !errors.Is(err, ErrGatewayUnreachable) {
+		t.Fatalf("want error %s, got %v", ErrGatewayUnreachable, err)
+	}
+}
@@ -30,12 +30,12 @@ func (c *Client) GetFunctionInfo(ctx context.Context, functionName string, names
 
 	getRequest, err := c.newRequest(http.MethodGet, functionPath, nil)
 	if err != nil {
-		return result, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", c.GatewayURL.String())
+		return result, fmt.Errorf("unable to create a request for %s: %w", c.GatewayURL.String(), err)
 	}
 
 	res, err := c.doRequest(ctx, getRequest)
 	if err != nil {
-		return result, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", c.GatewayURL.String())
+		return result, withCallID(getRequest, fmt.Errorf("%w on URL: %s", ErrGatewayUnreachable, c.GatewayURL.String()))
 
 	}
 
@@ -47,21 +47,21 @@ func (c *Client) GetFunctionInfo(ctx context.Context, functionName string, names
 	case http.StatusOK:
 		bytesOut, err := ioutil.ReadAll(res.Body)
 		if err != nil {
-			return result, fmt.Errorf("cannot read result from OpenFaaS on URL: %s", c.GatewayURL.String())
+			return result, withCallID(getRequest, fmt.Errorf("cannot read result from OpenFaaS on URL: %s", c.GatewayURL.String()))
 		}
 
 		jsonErr := json.Unmarshal(bytesOut, &result)
 		if jsonErr != nil {
-			return result, fmt.Errorf("cannot parse result from OpenFaaS on URL: %s\n%s", c.GatewayURL.String(), jsonErr.Error())
+			return result, withCallID(getRequest, fmt.Errorf("cannot parse result from OpenFaaS on URL: %s\n%s", c.GatewayURL.String(), jsonErr.Error()))
 		}
 	case http.StatusUnauthorized:
-		return result, fmt.Errorf("unauthorized access, run \"faas-cli login\" to setup authentication for this server")
+		return result, withCallID(getRequest, ErrUnauthorized)
 	case http.StatusNotFound:
-		return result, fmt.Errorf("No such function: %s", functionName)
+		return result, withCallID(getRequest, fmt.Errorf("function %s %w", functionName, ErrNotFound))
 	default:
 		bytesOut, err := ioutil.ReadAll(res.Body)
 		if err == nil {
-			return result, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut))
+			return result, withCallID(getRequest, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut)))
 		}
 	}
 	return result, nil
@@ -5,6 +5,7 @@ package proxy
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
@@ -77,8 +78,8 @@ func Test_GetFunctionInfo_NotFound(t *testing.T) {
 		t.Fatalf("Error was not returned")
 	}
 
-	expectedErrMsg := fmt.Sprintf("No such function: %s", functionName)
-	if err.Error() != expectedErrMsg {
+	expectedErrMsg := fmt.Sprintf("function %s not found", functionName)
+	if !errors.Is(err, ErrNotFound) || err.Error() != expectedErrMsg {
 		t.Fatalf("Want: %s, Got: %s", expectedErrMsg, err.Error())
 	}
 
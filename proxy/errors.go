@@ -0,0 +1,20 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import "errors"
+
+var (
+	// ErrUnauthorized is returned when the gateway rejects the credentials, if any, of the client
+	ErrUnauthorized = errors.New("unauthorized access, run \"faas-cli login\" to setup authentication for this server")
+
+	// ErrNotFound is returned when the gateway has no such function or secret
+	ErrNotFound = errors.New("not found")
+
+	// ErrGatewayUnreachable is returned when a request could not be made to the gateway
+	ErrGatewayUnreachable = errors.New("cannot connect to OpenFaaS")
+
+	// ErrDeployRejected is returned when the gateway refuses to deploy a function
+	ErrDeployRejected = errors.New("the gateway rejected the deployment")
+)
@@ -0,0 +1,60 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"net/http"
+
+	"github.com/openfaas/faas-cli/config"
+)
+
+// ExtraHeaders are sent with every request to a gateway, i.e. the service
+// token of Cloudflare Access or of an API gateway in front of it. They are
+// set from --gateway-header and take priority over those in the config file.
+var ExtraHeaders map[string]string
+
+// lookupHeaders is a variable so that tests do not need a config file
+var lookupHeaders = config.LookupHeaders
+
+// gatewayHeaders returns the headers saved for the gateway in the config
+// file with ExtraHeaders on top
+func gatewayHeaders(gatewayURL string) (map[string]string, error) {
+	saved, err := lookupHeaders(gatewayURL)
+	if err != nil {
+		return nil, err
+	}
+	if len(saved) == 0 && len(ExtraHeaders) == 0 {
+		return nil, nil
+	}
+
+	headers := map[string]string{}
+	for name, value := range saved {
+		headers[name] = value
+	}
+	for name, value := range ExtraHeaders {
+		headers[name] = value
+	}
+	return headers, nil
+}
+
+// setHeaders adds headers to req, a header which the request already has is
+// kept, so that one given for a single request or by its auth wins
+func setHeaders(req *http.Request, headers map[string]string) {
+	for name, value := range headers {
+		if len(req.Header.Get(name)) == 0 {
+			req.Header.Set(name, value)
+		}
+	}
+}
+
+// SetGatewayHeaders adds the headers for the gateway to a request which is
+// not made with a Client
+func SetGatewayHeaders(req *http.Request, gatewayURL string) error {
+	headers, err := gatewayHeaders(gatewayURL)
+	if err != nil {
+		return err
+	}
+	setHeaders(req, headers)
+	return nil
+}
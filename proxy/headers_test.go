@@ -0,0 +1,65 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"net/http"
+	"testing"
+)
+
+func Test_newRequest_GatewayHeaders(t *testing.T) {
+	defer func(original func(string) (map[string]string, error)) { lookupHeaders = original }(lookupHeaders)
+	lookupHeaders = func(gateway string) (map[string]string, error) {
+		if gateway != "http://127.0.0.1:8080" {
+			return nil, nil
+		}
+		return map[string]string{
+			"CF-Access-Client-Id": "abc.access",
+			"X-Org-Token":         "from-config",
+		}, nil
+	}
+
+	defer func(original map[string]string) { ExtraHeaders = original }(ExtraHeaders)
+	ExtraHeaders = map[string]string{
+		"X-Org-Token":   "from-flag",
+		"Authorization": "Bearer ignored",
+	}
+
+	client, err := NewClient(&BearerToken{token: "gateway-token"}, "http://127.0.0.1:8080", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	req, _ := client.newRequest(http.MethodGet, "/system/functions", nil)
+
+	want := map[string]string{
+		"CF-Access-Client-Id": "abc.access",
+		"X-Org-Token":         "from-flag",
+		"Authorization":       "Bearer gateway-token",
+	}
+	for name, value := range want {
+		if got := req.Header.Get(name); got != value {
+			t.Fatalf("want %s: %q, got: %q", name, value, got)
+		}
+	}
+}
+
+func Test_SetGatewayHeaders_KeepsRequestHeaders(t *testing.T) {
+	defer func(original func(string) (map[string]string, error)) { lookupHeaders = original }(lookupHeaders)
+	lookupHeaders = func(gateway string) (map[string]string, error) {
+		return map[string]string{"X-Org-Token": "from-config", "X-Tenant": "acme"}, nil
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, "http://127.0.0.1:8080/function/figlet", nil)
+	req.Header.Set("X-Org-Token", "from-invoke")
+
+	if err := SetGatewayHeaders(req, "http://127.0.0.1:8080"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := req.Header.Get("X-Org-Token"); got != "from-invoke" {
+		t.Fatalf("want the header of the request kept, got: %q", got)
+	}
+	if got := req.Header.Get("X-Tenant"); got != "acme" {
+		t.Fatalf("want X-Tenant: acme, got: %q", got)
+	}
+}
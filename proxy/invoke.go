@@ -55,7 +55,7 @@ func InvokeFunction(gateway string, name string, bytesIn *[]byte, contentType st
 	if err != nil {
 		fmt.Println()
 		fmt.Println(err)
-		return nil, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", gateway)
+		return nil, fmt.Errorf("%w on URL: %s", ErrGatewayUnreachable, gateway)
 	}
 
 	req.Header.Add("Content-Type", contentType)
@@ -64,6 +64,13 @@ func InvokeFunction(gateway string, name string, bytesIn *[]byte, contentType st
 		req.Header.Add(name, value)
 	}
 
+	if err := SetGatewayHeaders(req, gateway); err != nil {
+		return nil, err
+	}
+
+	// A call ID passed with --header is kept as-is
+	setCallID(req, "")
+
 	// Removed by AE - the system-level basic auth secrets should not be transmitted
 	// to functions. Functions should implement their own auth.
 	// SetAuth(req, gateway)
@@ -73,7 +80,7 @@ func InvokeFunction(gateway string, name string, bytesIn *[]byte, contentType st
 	if err != nil {
 		fmt.Println()
 		fmt.Println(err)
-		return nil, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", gateway)
+		return nil, withCallID(req, fmt.Errorf("%w on URL: %s", ErrGatewayUnreachable, gateway))
 	}
 
 	if res.Body != nil {
@@ -87,14 +94,14 @@ func InvokeFunction(gateway string, name string, bytesIn *[]byte, contentType st
 		var readErr error
 		resBytes, readErr = ioutil.ReadAll(res.Body)
 		if readErr != nil {
-			return nil, fmt.Errorf("cannot read result from OpenFaaS on URL: %s %s", gateway, readErr)
+			return nil, withCallID(req, fmt.Errorf("cannot read result from OpenFaaS on URL: %s %s", gateway, readErr))
 		}
 	case http.StatusUnauthorized:
-		return nil, fmt.Errorf("unauthorized access, run \"faas-cli login\" to setup authentication for this server")
+		return nil, withCallID(req, ErrUnauthorized)
 	default:
 		bytesOut, err := ioutil.ReadAll(res.Body)
 		if err == nil {
-			return nil, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut))
+			return nil, withCallID(req, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut)))
 		}
 	}
 
@@ -36,12 +36,12 @@ func (c *Client) ListFunctions(ctx context.Context, namespace string) ([]types.F
 
 	getRequest, err := c.newRequest(http.MethodGet, listEndpoint, nil)
 	if err != nil {
-		return nil, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", c.GatewayURL.String())
+		return nil, fmt.Errorf("unable to create a request for %s: %w", c.GatewayURL.String(), err)
 	}
 
 	res, err := c.doRequest(ctx, getRequest)
 	if err != nil {
-		return nil, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", c.GatewayURL.String())
+		return nil, withCallID(getRequest, fmt.Errorf("%w on URL: %s", ErrGatewayUnreachable, c.GatewayURL.String()))
 	}
 
 	if res.Body != nil {
@@ -51,20 +51,17 @@ func (c *Client) ListFunctions(ctx context.Context, namespace string) ([]types.F
 	switch res.StatusCode {
 	case http.StatusOK:
 
-		bytesOut, err := ioutil.ReadAll(res.Body)
-		if err != nil {
-			return nil, fmt.Errorf("cannot read result from OpenFaaS on URL: %s", c.GatewayURL.String())
-		}
-		jsonErr := json.Unmarshal(bytesOut, &results)
+		// Decode the body as it is read, the list can be large on big clusters
+		jsonErr := json.NewDecoder(res.Body).Decode(&results)
 		if jsonErr != nil {
-			return nil, fmt.Errorf("cannot parse result from OpenFaaS on URL: %s\n%s", c.GatewayURL.String(), jsonErr.Error())
+			return nil, withCallID(getRequest, fmt.Errorf("cannot parse result from OpenFaaS on URL: %s\n%s", c.GatewayURL.String(), jsonErr.Error()))
 		}
 	case http.StatusUnauthorized:
-		return nil, fmt.Errorf("unauthorized access, run \"faas-cli login\" to setup authentication for this server")
+		return nil, withCallID(getRequest, ErrUnauthorized)
 	default:
 		bytesOut, err := ioutil.ReadAll(res.Body)
 		if err == nil {
-			return nil, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut))
+			return nil, withCallID(getRequest, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut)))
 		}
 	}
 	return results, nil
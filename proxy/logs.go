@@ -20,14 +20,14 @@ func (c *Client) GetLogs(ctx context.Context, params logs.Request) (<-chan logs.
 
 	logRequest, err := c.newRequest(http.MethodGet, "/system/logs", nil)
 	if err != nil {
-		return nil, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", c.GatewayURL.String())
+		return nil, fmt.Errorf("unable to create a request for %s: %w", c.GatewayURL.String(), err)
 	}
 
 	logRequest.URL.RawQuery = reqAsQueryValues(params).Encode()
 
 	res, err := c.doRequest(ctx, logRequest)
 	if err != nil {
-		return nil, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", c.GatewayURL.String())
+		return nil, withCallID(logRequest, fmt.Errorf("%w on URL: %s", ErrGatewayUnreachable, c.GatewayURL.String()))
 	}
 
 	logStream := make(chan logs.Message, 1000)
@@ -49,11 +49,11 @@ func (c *Client) GetLogs(ctx context.Context, params logs.Request) (<-chan logs.
 			}
 		}()
 	case http.StatusUnauthorized:
-		return nil, fmt.Errorf("unauthorized access, run \"faas-cli login\" to setup authentication for this server")
+		return nil, withCallID(logRequest, ErrUnauthorized)
 	default:
 		bytesOut, err := ioutil.ReadAll(res.Body)
 		if err == nil {
-			return nil, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut))
+			return nil, withCallID(logRequest, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut)))
 		}
 	}
 	return logStream, nil
@@ -19,12 +19,12 @@ func (c *Client) ListNamespaces(ctx context.Context) ([]string, error) {
 	getRequest, err := c.newRequest(http.MethodGet, namespacesPath, nil)
 
 	if err != nil {
-		return nil, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", c.GatewayURL.String())
+		return nil, fmt.Errorf("unable to create a request for %s: %w", c.GatewayURL.String(), err)
 	}
 
 	res, err := c.doRequest(ctx, getRequest)
 	if err != nil {
-		return nil, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", c.GatewayURL.String())
+		return nil, withCallID(getRequest, fmt.Errorf("%w on URL: %s", ErrGatewayUnreachable, c.GatewayURL.String()))
 	}
 
 	if res.Body != nil {
@@ -36,18 +36,18 @@ func (c *Client) ListNamespaces(ctx context.Context) ([]string, error) {
 
 		bytesOut, err := ioutil.ReadAll(res.Body)
 		if err != nil {
-			return nil, fmt.Errorf("cannot read namespaces from OpenFaaS on URL: %s", c.GatewayURL.String())
+			return nil, withCallID(getRequest, fmt.Errorf("cannot read namespaces from OpenFaaS on URL: %s", c.GatewayURL.String()))
 		}
 		jsonErr := json.Unmarshal(bytesOut, &namespaces)
 		if jsonErr != nil {
-			return nil, fmt.Errorf("cannot parse namespaces from OpenFaaS on URL: %s\n%s", c.GatewayURL.String(), jsonErr.Error())
+			return nil, withCallID(getRequest, fmt.Errorf("cannot parse namespaces from OpenFaaS on URL: %s\n%s", c.GatewayURL.String(), jsonErr.Error()))
 		}
 	case http.StatusUnauthorized:
-		return nil, fmt.Errorf("unauthorized access, run \"faas-cli login\" to setup authentication for this server")
+		return nil, withCallID(getRequest, ErrUnauthorized)
 	default:
 		bytesOut, err := ioutil.ReadAll(res.Body)
 		if err == nil {
-			return nil, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut))
+			return nil, withCallID(getRequest, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut)))
 		}
 	}
 	return namespaces, nil
@@ -4,8 +4,6 @@
 package proxy
 
 import (
-	"crypto/tls"
-	"net"
 	"net/http"
 	"time"
 )
@@ -19,28 +17,11 @@ func MakeHTTPClient(timeout *time.Duration, tlsInsecure bool) http.Client {
 func makeHTTPClientWithDisableKeepAlives(timeout *time.Duration, tlsInsecure bool, disableKeepAlives bool) http.Client {
 	client := http.Client{}
 
-	if timeout != nil || tlsInsecure {
-		tr := &http.Transport{
-			Proxy:             http.ProxyFromEnvironment,
-			DisableKeepAlives: disableKeepAlives,
-		}
-
-		if timeout != nil {
-			client.Timeout = *timeout
-			tr.DialContext = (&net.Dialer{
-				Timeout: *timeout,
-			}).DialContext
-
-			tr.IdleConnTimeout = 120 * time.Millisecond
-			tr.ExpectContinueTimeout = 1500 * time.Millisecond
-		}
-
-		if tlsInsecure {
-			tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: tlsInsecure}
-		}
-
-		tr.DisableKeepAlives = disableKeepAlives
+	if timeout != nil {
+		client.Timeout = *timeout
+	}
 
+	if tr := newTransport(timeout, tlsInsecure, disableKeepAlives, DefaultTransportConfig); tr != nil {
 		client.Transport = tr
 	}
 
@@ -35,12 +35,12 @@ func (c *Client) ScaleFunction(ctx context.Context, functionName, namespace stri
 
 	req, err := c.newRequest(http.MethodPost, functionPath, bodyReader)
 	if err != nil {
-		return fmt.Errorf("cannot connect to OpenFaaS on URL: %s", c.GatewayURL.String())
+		return fmt.Errorf("unable to create a request for %s: %w", c.GatewayURL.String(), err)
 	}
 
 	res, err := c.doRequest(ctx, req)
 	if err != nil {
-		return fmt.Errorf("cannot connect to OpenFaaS on URL: %s", c.GatewayURL.String())
+		return withCallID(req, fmt.Errorf("%w on URL: %s", ErrGatewayUnreachable, c.GatewayURL.String()))
 
 	}
 
@@ -53,19 +53,19 @@ func (c *Client) ScaleFunction(ctx context.Context, functionName, namespace stri
 		break
 
 	case http.StatusNotFound:
-		return fmt.Errorf("function %s not found", functionName)
+		return withCallID(req, fmt.Errorf("function %s %w", functionName, ErrNotFound))
 
 	case http.StatusUnauthorized:
-		return fmt.Errorf("unauthorized action, please setup authentication for this server")
+		return withCallID(req, ErrUnauthorized)
 
 	default:
 		var bodyReadErr error
 		bytesOut, bodyReadErr := ioutil.ReadAll(res.Body)
 		if bodyReadErr != nil {
-			return bodyReadErr
+			return withCallID(req, bodyReadErr)
 		}
 
-		return fmt.Errorf("server returned unexpected status code %d %s", res.StatusCode, string(bytesOut))
+		return withCallID(req, fmt.Errorf("server returned unexpected status code %d %s", res.StatusCode, string(bytesOut)))
 	}
 	return nil
 }
@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -50,9 +51,8 @@ func Test_ScaleFunction_Unauthorized(t *testing.T) {
 
 	err := proxyClient.ScaleFunction(context.Background(), "function-to-scale", "", 0)
 
-	expectedErr := fmt.Errorf("unauthorized action, please setup authentication for this server")
-	if err.Error() != expectedErr.Error() {
-		t.Fatalf("Want: %s, got: %s", expectedErr.Error(), err.Error())
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("Want: %s, got: %s", ErrUnauthorized, err)
 	}
 }
 
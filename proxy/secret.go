@@ -30,12 +30,12 @@ func (c *Client) GetSecretList(ctx context.Context, namespace string) ([]types.S
 	getRequest, err := c.newRequest(http.MethodGet, secretPath, nil)
 
 	if err != nil {
-		return nil, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", c.GatewayURL.String())
+		return nil, fmt.Errorf("unable to create a request for %s: %w", c.GatewayURL.String(), err)
 	}
 
 	res, err := c.doRequest(ctx, getRequest)
 	if err != nil {
-		return nil, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", c.GatewayURL.String())
+		return nil, withCallID(getRequest, fmt.Errorf("%w on URL: %s", ErrGatewayUnreachable, c.GatewayURL.String()))
 	}
 
 	if res.Body != nil {
@@ -47,21 +47,21 @@ func (c *Client) GetSecretList(ctx context.Context, namespace string) ([]types.S
 
 		bytesOut, err := ioutil.ReadAll(res.Body)
 		if err != nil {
-			return nil, fmt.Errorf("cannot read result from OpenFaaS on URL: %s", c.GatewayURL.String())
+			return nil, withCallID(getRequest, fmt.Errorf("cannot read result from OpenFaaS on URL: %s", c.GatewayURL.String()))
 		}
 
 		jsonErr := json.Unmarshal(bytesOut, &results)
 		if jsonErr != nil {
-			return nil, fmt.Errorf("cannot parse result from OpenFaaS on URL: %s\n%s", c.GatewayURL.String(), jsonErr.Error())
+			return nil, withCallID(getRequest, fmt.Errorf("cannot parse result from OpenFaaS on URL: %s\n%s", c.GatewayURL.String(), jsonErr.Error()))
 		}
 
 	case http.StatusUnauthorized:
-		return nil, fmt.Errorf("unauthorized access, run \"faas-cli login\" to setup authentication for this server")
+		return nil, withCallID(getRequest, ErrUnauthorized)
 
 	default:
 		bytesOut, err := ioutil.ReadAll(res.Body)
 		if err == nil {
-			return nil, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut))
+			return nil, withCallID(getRequest, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut)))
 		}
 	}
 
@@ -76,13 +76,13 @@ func (c *Client) UpdateSecret(ctx context.Context, secret types.Secret) (int, st
 	putRequest, err := c.newRequest(http.MethodPut, secretEndpoint, bytes.NewBuffer(reqBytes))
 
 	if err != nil {
-		output += fmt.Sprintf("cannot connect to OpenFaaS on URL: %s", c.GatewayURL.String())
+		output += fmt.Sprintf("unable to create a request for %s: %s", c.GatewayURL.String(), err)
 		return http.StatusInternalServerError, output
 	}
 
 	res, err := c.doRequest(ctx, putRequest)
 	if err != nil {
-		output += fmt.Sprintf("cannot connect to OpenFaaS on URL: %s", c.GatewayURL.String())
+		output += fmt.Sprintf("%s on URL: %s%s", ErrGatewayUnreachable, c.GatewayURL.String(), callIDSuffix(putRequest))
 		return http.StatusInternalServerError, output
 	}
 
@@ -96,15 +96,15 @@ func (c *Client) UpdateSecret(ctx context.Context, secret types.Secret) (int, st
 		break
 
 	case http.StatusNotFound:
-		output += fmt.Sprintf("unable to find secret: %s", secret.Name)
+		output += fmt.Sprintf("unable to find secret: %s%s", secret.Name, callIDSuffix(putRequest))
 
 	case http.StatusUnauthorized:
-		output += fmt.Sprintf("unauthorized access, run \"faas-cli login\" to setup authentication for this server")
+		output += ErrUnauthorized.Error() + callIDSuffix(putRequest)
 
 	default:
 		bytesOut, err := ioutil.ReadAll(res.Body)
 		if err == nil {
-			output += fmt.Sprintf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut))
+			output += fmt.Sprintf("server returned unexpected status code: %d - %s%s", res.StatusCode, string(bytesOut), callIDSuffix(putRequest))
 		}
 	}
 
@@ -116,12 +116,12 @@ func (c *Client) RemoveSecret(ctx context.Context, secret types.Secret) error {
 	body, _ := json.Marshal(secret)
 	req, err := c.newRequest(http.MethodDelete, secretEndpoint, bytes.NewBuffer(body))
 	if err != nil {
-		return fmt.Errorf("cannot connect to OpenFaaS on URL: %s", c.GatewayURL.String())
+		return fmt.Errorf("unable to create a request for %s: %w", c.GatewayURL.String(), err)
 	}
 
 	res, err := c.doRequest(ctx, req)
 	if err != nil {
-		return fmt.Errorf("cannot connect to OpenFaaS on URL: %s", c.GatewayURL.String())
+		return withCallID(req, fmt.Errorf("%w on URL: %s", ErrGatewayUnreachable, c.GatewayURL.String()))
 	}
 
 	if res.Body != nil {
@@ -132,14 +132,14 @@ func (c *Client) RemoveSecret(ctx context.Context, secret types.Secret) error {
 	case http.StatusOK, http.StatusAccepted:
 		break
 	case http.StatusNotFound:
-		return fmt.Errorf("unable to find secret: %s", secret.Name)
+		return withCallID(req, fmt.Errorf("secret %s %w", secret.Name, ErrNotFound))
 	case http.StatusUnauthorized:
-		return fmt.Errorf("unauthorized access, run \"faas-cli login\" to setup authentication for this server")
+		return withCallID(req, ErrUnauthorized)
 
 	default:
 		bytesOut, err := ioutil.ReadAll(res.Body)
 		if err == nil {
-			return fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut))
+			return withCallID(req, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut)))
 		}
 	}
 
@@ -155,13 +155,13 @@ func (c *Client) CreateSecret(ctx context.Context, secret types.Secret) (int, st
 	request, err := c.newRequest(http.MethodPost, secretEndpoint, reader)
 
 	if err != nil {
-		output += fmt.Sprintf("cannot connect to OpenFaaS on URL: %s\n", c.GatewayURL.String())
+		output += fmt.Sprintf("unable to create a request for %s: %s\n", c.GatewayURL.String(), err)
 		return http.StatusInternalServerError, output
 	}
 
 	res, err := c.doRequest(ctx, request)
 	if err != nil {
-		output += fmt.Sprintf("cannot connect to OpenFaaS on URL: %s\n", c.GatewayURL.String())
+		output += fmt.Sprintf("%s on URL: %s%s\n", ErrGatewayUnreachable, c.GatewayURL.String(), callIDSuffix(request))
 		return http.StatusInternalServerError, output
 	}
 
@@ -174,7 +174,7 @@ func (c *Client) CreateSecret(ctx context.Context, secret types.Secret) (int, st
 		output += fmt.Sprintf("Created: %s\n", res.Status)
 
 	case http.StatusUnauthorized:
-		output += fmt.Sprintln("unauthorized access, run \"faas-cli login\" to setup authentication for this server")
+		output += fmt.Sprintf("%s%s\n", ErrUnauthorized, callIDSuffix(request))
 
 	case http.StatusConflict:
 		output += fmt.Sprintf("secret with the name %q already exists\n", secret.Name)
@@ -182,7 +182,7 @@ func (c *Client) CreateSecret(ctx context.Context, secret types.Secret) (int, st
 	default:
 		bytesOut, err := ioutil.ReadAll(res.Body)
 		if err == nil {
-			output += fmt.Sprintf("server returned unexpected status code: %d - %s\n", res.StatusCode, string(bytesOut))
+			output += fmt.Sprintf("server returned unexpected status code: %d - %s%s\n", res.StatusCode, string(bytesOut), callIDSuffix(request))
 		}
 	}
 
@@ -0,0 +1,106 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportConfig tunes the connections made to the gateway and functions,
+// i.e. when invoking or deploying many functions. The zero value keeps the
+// defaults of net/http.
+type TransportConfig struct {
+	// MaxIdleConns is the number of idle connections kept open to each host
+	// to be reused, net/http keeps 2 per host when it is 0
+	MaxIdleConns int
+
+	// DisableHTTP2 only uses HTTP/1.1, otherwise HTTP/2 is used with hosts
+	// which support it over TLS
+	DisableHTTP2 bool
+
+	// TLSSessionCacheSize is the number of TLS sessions kept to be resumed
+	// without a full handshake, there is no cache when it is 0
+	TLSSessionCacheSize int
+
+	// IdleConnTimeout is how long an idle connection is kept open to be
+	// reused, it is DefaultIdleConnTimeout when 0
+	IdleConnTimeout time.Duration
+}
+
+// DefaultIdleConnTimeout is how long idle connections are kept open, the
+// same as http.DefaultTransport
+const DefaultIdleConnTimeout = 90 * time.Second
+
+// DefaultTransportConfig applies to the clients made by MakeHTTPClient and
+// to the transports made by NewTransport
+var DefaultTransportConfig TransportConfig
+
+// DialGateway makes the connections of the clients made by MakeHTTPClient and
+// of the transports made by NewTransport when it is set, i.e. through an SSH
+// tunnel. It is given every address, so it dials those which are not the
+// gateway directly. The URL of the gateway is kept, so TLS and the Host header
+// work.
+var DialGateway func(ctx context.Context, network, addr string) (net.Conn, error)
+
+func (c TransportConfig) isZero() bool {
+	return c == TransportConfig{}
+}
+
+// NewTransport makes a HTTP transport with good defaults for timeouts and
+// DefaultTransportConfig, it is nil when http.DefaultTransport can be used
+func NewTransport(timeout *time.Duration, tlsInsecure bool) *http.Transport {
+	return newTransport(timeout, tlsInsecure, false, DefaultTransportConfig)
+}
+
+func newTransport(timeout *time.Duration, tlsInsecure bool, disableKeepAlives bool, config TransportConfig) *http.Transport {
+	if timeout == nil && !tlsInsecure && config.isZero() && DialGateway == nil {
+		return nil
+	}
+
+	tr := &http.Transport{
+		Proxy:             http.ProxyFromEnvironment,
+		DisableKeepAlives: disableKeepAlives,
+		ForceAttemptHTTP2: !config.DisableHTTP2,
+		IdleConnTimeout:   DefaultIdleConnTimeout,
+	}
+
+	if config.IdleConnTimeout > 0 {
+		tr.IdleConnTimeout = config.IdleConnTimeout
+	}
+
+	if timeout != nil {
+		tr.DialContext = (&net.Dialer{
+			Timeout: *timeout,
+		}).DialContext
+
+		tr.ExpectContinueTimeout = 1500 * time.Millisecond
+	}
+
+	if DialGateway != nil {
+		tr.DialContext = DialGateway
+	}
+
+	if config.MaxIdleConns > 0 {
+		tr.MaxIdleConns = config.MaxIdleConns
+		tr.MaxIdleConnsPerHost = config.MaxIdleConns
+	}
+
+	if config.DisableHTTP2 {
+		// A non-nil empty map turns off the upgrade to HTTP/2
+		tr.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	if tlsInsecure || config.TLSSessionCacheSize > 0 {
+		tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: tlsInsecure}
+		if config.TLSSessionCacheSize > 0 {
+			tr.TLSClientConfig.ClientSessionCache = tls.NewLRUClientSessionCache(config.TLSSessionCacheSize)
+		}
+	}
+
+	return tr
+}
@@ -0,0 +1,71 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func Test_newTransport_Config(t *testing.T) {
+	cases := []struct {
+		name    string
+		timeout *time.Duration
+		config  TransportConfig
+		match   func(*http.Transport) bool
+	}{
+		{name: "defaults use http.DefaultTransport", match: func(transport *http.Transport) bool {
+			return transport == nil
+		}},
+		{name: "HTTP/2 is attempted by default", timeout: durationPtr(time.Second), match: func(transport *http.Transport) bool {
+			return transport.ForceAttemptHTTP2 && transport.TLSNextProto == nil
+		}},
+		{name: "HTTP/2 disabled", config: TransportConfig{DisableHTTP2: true}, match: func(transport *http.Transport) bool {
+			return !transport.ForceAttemptHTTP2 &&
+				transport.TLSNextProto != nil &&
+				len(transport.TLSNextProto) == 0 &&
+				transport.TLSClientConfig == nil
+		}},
+		{name: "max idle connections", config: TransportConfig{MaxIdleConns: 64}, match: func(transport *http.Transport) bool {
+			return transport.MaxIdleConns == 64 &&
+				transport.MaxIdleConnsPerHost == 64 &&
+				transport.Proxy != nil
+		}},
+		{name: "idle connections are kept for the default", timeout: durationPtr(time.Second), match: func(transport *http.Transport) bool {
+			return transport.IdleConnTimeout == DefaultIdleConnTimeout
+		}},
+		{name: "idle connection timeout", config: TransportConfig{IdleConnTimeout: 5 * time.Minute}, match: func(transport *http.Transport) bool {
+			return transport.IdleConnTimeout == 5*time.Minute
+		}},
+		{name: "TLS session cache", config: TransportConfig{TLSSessionCacheSize: 32}, match: func(transport *http.Transport) bool {
+			return transport.TLSClientConfig != nil &&
+				transport.TLSClientConfig.ClientSessionCache != nil &&
+				!transport.TLSClientConfig.InsecureSkipVerify
+		}},
+	}
+
+	for _, v := range cases {
+		t.Run(v.name, func(t *testing.T) {
+			transport := newTransport(v.timeout, false, false, v.config)
+			if !v.match(transport) {
+				t.Errorf("%s did not match", v.name)
+			}
+		})
+	}
+}
+
+func Test_MakeHTTPClient_DefaultTransportConfig(t *testing.T) {
+	defer func() { DefaultTransportConfig = TransportConfig{} }()
+	DefaultTransportConfig = TransportConfig{MaxIdleConns: 16}
+
+	client := MakeHTTPClient(nil, false)
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("want a transport for the default config, got %v", client.Transport)
+	}
+	if transport.MaxIdleConnsPerHost != 16 {
+		t.Errorf("want 16 idle connections per host, got %d", transport.MaxIdleConnsPerHost)
+	}
+}
@@ -20,12 +20,12 @@ func (c *Client) GetSystemInfo(ctx context.Context) (types.GatewayInfo, error) {
 
 	req, err := c.newRequest(http.MethodGet, infoEndPoint, nil)
 	if err != nil {
-		return info, fmt.Errorf("invalid HTTP method or invalid URL")
+		return info, fmt.Errorf("unable to create a request for %s: %w", c.GatewayURL.String(), err)
 	}
 
 	response, err := c.doRequest(ctx, req)
 	if err != nil {
-		return info, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", c.GatewayURL.String())
+		return info, withCallID(req, fmt.Errorf("%w on URL: %s", ErrGatewayUnreachable, c.GatewayURL.String()))
 	}
 
 	if response.Body != nil {
@@ -36,19 +36,19 @@ func (c *Client) GetSystemInfo(ctx context.Context) (types.GatewayInfo, error) {
 	case http.StatusOK:
 		bytesOut, err := ioutil.ReadAll(response.Body)
 		if err != nil {
-			return info, fmt.Errorf("cannot read result from OpenFaaS on URL: %s", c.GatewayURL.String())
+			return info, withCallID(req, fmt.Errorf("cannot read result from OpenFaaS on URL: %s", c.GatewayURL.String()))
 		}
 		err = json.Unmarshal(bytesOut, &info)
 		if err != nil {
-			return info, fmt.Errorf("cannot parse result from OpenFaaS on URL: %s\n%s", c.GatewayURL.String(), err.Error())
+			return info, withCallID(req, fmt.Errorf("cannot parse result from OpenFaaS on URL: %s\n%s", c.GatewayURL.String(), err.Error()))
 		}
 
 	case http.StatusUnauthorized:
-		return info, fmt.Errorf("unauthorized access, run \"faas-cli login\" to setup authentication for this server")
+		return info, withCallID(req, ErrUnauthorized)
 	default:
 		bytesOut, err := ioutil.ReadAll(response.Body)
 		if err == nil {
-			return info, fmt.Errorf("server returned unexpected status code: %d - %s", response.StatusCode, string(bytesOut))
+			return info, withCallID(req, fmt.Errorf("server returned unexpected status code: %d - %s", response.StatusCode, string(bytesOut)))
 		}
 	}
 
@@ -0,0 +1,123 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	types "github.com/openfaas/faas-provider/types"
+)
+
+// Defaults for the intervals between polls of WaitForFunction
+const (
+	defaultWaitInterval    = 500 * time.Millisecond
+	defaultWaitMaxInterval = 10 * time.Second
+)
+
+// ErrWaitTimeout is returned when a function is not ready within the timeout
+var ErrWaitTimeout = errors.New("timed out waiting for the function to be ready")
+
+// WaitOptions control how WaitForFunction polls a function
+type WaitOptions struct {
+	// Timeout is the longest time to wait, when it is 0 only the context
+	// stops the wait
+	Timeout time.Duration
+
+	// Interval is the time before the second poll, it doubles after each
+	// poll up to MaxInterval
+	Interval    time.Duration
+	MaxInterval time.Duration
+
+	// Image is the image which was deployed, when it is set the function is
+	// only ready once it runs that image, so that an update is not reported
+	// as ready while the replicas of the previous image are still available
+	Image string
+
+	// Progress is called after each poll with the status of the function,
+	// or the error when it could not be read
+	Progress func(status types.FunctionStatus, elapsed time.Duration, err error)
+}
+
+// WaitForFunction polls a function until all of its replicas are available
+// and it runs options.Image, with an exponential backoff between polls. Errors from the gateway other than
+// ErrUnauthorized are retried, as a function may not be found for a moment
+// after it is deployed.
+func (c *Client) WaitForFunction(ctx context.Context, functionName, namespace string, options WaitOptions) (types.FunctionStatus, error) {
+	interval := options.Interval
+	if interval <= 0 {
+		interval = defaultWaitInterval
+	}
+	maxInterval := options.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = defaultWaitMaxInterval
+	}
+
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	var lastStatus types.FunctionStatus
+	var lastErr error
+	for {
+		status, err := c.GetFunctionInfo(ctx, functionName, namespace)
+		ready := err == nil && functionReady(status, options.Image)
+		if !ready && ctx.Err() != nil {
+			// The poll was cut short by the context, so its error says
+			// nothing about the function
+			return lastStatus, waitError(ctx, functionName, options.Image, lastStatus, lastErr)
+		}
+		if options.Progress != nil {
+			options.Progress(status, time.Since(start), err)
+		}
+		if ready {
+			return status, nil
+		}
+		if errors.Is(err, ErrUnauthorized) {
+			return status, err
+		}
+		lastStatus, lastErr = status, err
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return lastStatus, waitError(ctx, functionName, options.Image, lastStatus, lastErr)
+		case <-timer.C:
+		}
+
+		interval *= 2
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
+
+// functionReady reports whether every replica of the function is available
+// and, when image is set, whether the function has been updated to it
+func functionReady(status types.FunctionStatus, image string) bool {
+	if status.AvailableReplicas == 0 || status.AvailableReplicas < status.Replicas {
+		return false
+	}
+	return len(image) == 0 || status.Image == image
+}
+
+// waitError explains why a wait stopped, with the last status or error
+func waitError(ctx context.Context, functionName, image string, status types.FunctionStatus, lastErr error) error {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ctx.Err()
+	}
+	if lastErr != nil {
+		return fmt.Errorf("%w: %s, last error: %s", ErrWaitTimeout, functionName, lastErr)
+	}
+	if len(image) > 0 && len(status.Image) > 0 && status.Image != image {
+		return fmt.Errorf("%w: %s runs %s rather than %s, %d/%d replicas available", ErrWaitTimeout, functionName, status.Image, image, status.AvailableReplicas, status.Replicas)
+	}
+	return fmt.Errorf("%w: %s has %d/%d replicas available", ErrWaitTimeout, functionName, status.AvailableReplicas, status.Replicas)
+}
@@ -0,0 +1,222 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	types "github.com/openfaas/faas-provider/types"
+)
+
+// functionStatusServer answers each request for a function with the next of
+// the given status codes and available replicas, repeating the last.
+// afterRequest is called with the number of requests so far, when it is set.
+func functionStatusServer(codes []int, available []uint64, afterRequest func(int)) (*httptest.Server, *int) {
+	var mu sync.Mutex
+	requests := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		i := requests
+		if i >= len(codes) {
+			i = len(codes) - 1
+		}
+		requests++
+		if afterRequest != nil {
+			afterRequest(requests)
+		}
+		mu.Unlock()
+
+		w.WriteHeader(codes[i])
+		if codes[i] == http.StatusOK {
+			json.NewEncoder(w).Encode(types.FunctionStatus{Name: "figlet", Replicas: 1, AvailableReplicas: available[i]})
+		}
+	})), &requests
+}
+
+// expiringContext reaches its deadline when expire is called, so that a test
+// does not depend on how many polls fit in a timeout
+type expiringContext struct {
+	context.Context
+	done chan struct{}
+	once sync.Once
+}
+
+func newExpiringContext() *expiringContext {
+	return &expiringContext{Context: context.Background(), done: make(chan struct{})}
+}
+
+func (c *expiringContext) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *expiringContext) Err() error {
+	select {
+	case <-c.done:
+		return context.DeadlineExceeded
+	default:
+		return nil
+	}
+}
+
+func (c *expiringContext) expire() {
+	c.once.Do(func() { close(c.done) })
+}
+
+func Test_WaitForFunction(t *testing.T) {
+	cases := []struct {
+		name         string
+		codes        []int
+		available    []uint64
+		expireAfter  int
+		wantErr      string
+		wantRequests int
+	}{
+		{name: "ready at once", codes: []int{200}, available: []uint64{1}, wantRequests: 1},
+		{name: "ready after polls", codes: []int{200, 200, 200}, available: []uint64{0, 0, 1}, wantRequests: 3},
+		{name: "not found at first", codes: []int{404, 200}, available: []uint64{0, 1}, wantRequests: 2},
+		{name: "never ready", codes: []int{200}, available: []uint64{0}, expireAfter: 3, wantErr: "timed out waiting for the function to be ready: figlet has 0/1 replicas available"},
+		{name: "never found", codes: []int{404}, available: []uint64{0}, expireAfter: 3, wantErr: "timed out waiting for the function to be ready: figlet, last error: "},
+		{name: "unauthorized is not retried", codes: []int{401}, available: []uint64{0}, wantErr: ErrUnauthorized.Error(), wantRequests: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := newExpiringContext()
+			s, requests := functionStatusServer(tc.codes, tc.available, func(n int) {
+				if tc.expireAfter > 0 && n >= tc.expireAfter {
+					ctx.expire()
+				}
+			})
+			defer s.Close()
+
+			client, _ := NewClient(NewTestAuth(nil), s.URL, nil, &defaultCommandTimeout)
+
+			progress := 0
+			_, err := client.WaitForFunction(ctx, "figlet", "", WaitOptions{
+				Interval:    time.Millisecond,
+				MaxInterval: 4 * time.Millisecond,
+				Progress: func(status types.FunctionStatus, elapsed time.Duration, err error) {
+					progress++
+				},
+			})
+
+			if len(tc.wantErr) > 0 {
+				if err == nil || !strings.HasPrefix(err.Error(), tc.wantErr) {
+					t.Fatalf("want error %q, got %v", tc.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.wantRequests > 0 {
+				if *requests != tc.wantRequests {
+					t.Errorf("want %d requests, got %d", tc.wantRequests, *requests)
+				}
+				if progress != tc.wantRequests {
+					t.Errorf("want progress for each of the %d requests, got %d", tc.wantRequests, progress)
+				}
+			}
+		})
+	}
+}
+
+func Test_WaitForFunction_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s, _ := functionStatusServer([]int{200}, []uint64{0}, func(n int) {
+		if n == 2 {
+			cancel()
+		}
+	})
+	defer s.Close()
+
+	client, _ := NewClient(NewTestAuth(nil), s.URL, nil, &defaultCommandTimeout)
+
+	_, err := client.WaitForFunction(ctx, "figlet", "", WaitOptions{Interval: time.Millisecond})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("want context.Canceled, got %v", err)
+	}
+}
+
+func Test_WaitForFunction_Timeout(t *testing.T) {
+	s, _ := functionStatusServer([]int{200}, []uint64{0}, nil)
+	defer s.Close()
+
+	client, _ := NewClient(NewTestAuth(nil), s.URL, nil, &defaultCommandTimeout)
+
+	// The deadline passes before the first poll can answer, which is a
+	// timeout rather than an unreachable gateway
+	_, err := client.WaitForFunction(context.Background(), "figlet", "", WaitOptions{Timeout: time.Nanosecond})
+	if !errors.Is(err, ErrWaitTimeout) {
+		t.Fatalf("want %s, got %v", ErrWaitTimeout, err)
+	}
+	if errors.Is(err, ErrGatewayUnreachable) {
+		t.Fatalf("want the timeout not to be reported as an unreachable gateway, got %v", err)
+	}
+}
+
+func Test_WaitForFunction_Update(t *testing.T) {
+	// The replica of the previous image stays available while the new one
+	// is rolled out
+	statuses := []types.FunctionStatus{
+		{Name: "figlet", Image: "functions/figlet:0.1.0", Replicas: 1, AvailableReplicas: 1},
+		{Name: "figlet", Image: "functions/figlet:0.2.0", Replicas: 2, AvailableReplicas: 1},
+		{Name: "figlet", Image: "functions/figlet:0.2.0", Replicas: 1, AvailableReplicas: 1},
+	}
+	var mu sync.Mutex
+	requests := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		i := requests
+		if i >= len(statuses) {
+			i = len(statuses) - 1
+		}
+		requests++
+		json.NewEncoder(w).Encode(statuses[i])
+	}))
+	defer s.Close()
+
+	client, _ := NewClient(NewTestAuth(nil), s.URL, nil, &defaultCommandTimeout)
+
+	status, err := client.WaitForFunction(context.Background(), "figlet", "", WaitOptions{
+		Image:    "functions/figlet:0.2.0",
+		Interval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != len(statuses) {
+		t.Errorf("want %d requests, got %d", len(statuses), requests)
+	}
+	if status.Image != "functions/figlet:0.2.0" {
+		t.Errorf("want the status of the new image, got %s", status.Image)
+	}
+}
+
+func Test_WaitForFunction_UpdateNeverRolledOut(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(types.FunctionStatus{Name: "figlet", Image: "functions/figlet:0.1.0", Replicas: 1, AvailableReplicas: 1})
+	}))
+	defer s.Close()
+
+	client, _ := NewClient(NewTestAuth(nil), s.URL, nil, &defaultCommandTimeout)
+
+	_, err := client.WaitForFunction(context.Background(), "figlet", "", WaitOptions{
+		Image:    "functions/figlet:0.2.0",
+		Timeout:  50 * time.Millisecond,
+		Interval: time.Millisecond,
+	})
+	want := "timed out waiting for the function to be ready: figlet runs functions/figlet:0.1.0 rather than functions/figlet:0.2.0, 1/1 replicas available"
+	if err == nil || err.Error() != want {
+		t.Fatalf("want error %q, got %v", want, err)
+	}
+}
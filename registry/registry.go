@@ -0,0 +1,311 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+// Package registry checks whether an image has been pushed by asking its
+// registry for the manifest with the Docker Registry HTTP API V2.
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// DockerHub is the registry used for images without a registry host
+	DockerHub = "docker.io"
+
+	dockerHubAPI = "registry-1.docker.io"
+	defaultTag   = "latest"
+)
+
+// manifestTypes are accepted so that both single and multi-arch images are found
+var manifestTypes = []string{
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+}
+
+// Reference is an image split into the parts used by the registry API
+type Reference struct {
+	// Registry host, i.e. ghcr.io or docker.io
+	Registry string
+	// Repository within the registry, i.e. library/alpine
+	Repository string
+	// Tag or digest of the image
+	Tag string
+}
+
+// ParseReference splits an image such as alexellis/figlet:0.1 into its parts
+func ParseReference(image string) (Reference, error) {
+	if len(image) == 0 || strings.ContainsAny(image, " \t") {
+		return Reference{}, fmt.Errorf("invalid image name %q", image)
+	}
+
+	ref := Reference{Registry: DockerHub, Tag: defaultTag}
+	name := image
+
+	if at := strings.Index(name, "@"); at >= 0 {
+		name, ref.Tag = name[:at], name[at+1:]
+	} else if colon := strings.LastIndex(name, ":"); colon > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:colon], name[colon+1:]
+	}
+
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Registry, name = parts[0], parts[1]
+	}
+
+	if ref.Registry == DockerHub && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+
+	if len(name) == 0 || len(ref.Tag) == 0 {
+		return Reference{}, fmt.Errorf("invalid image name %q", image)
+	}
+
+	ref.Repository = name
+	return ref, nil
+}
+
+// Credentials returns the username and password for a registry, or empty
+// strings to use the registry anonymously
+type Credentials func(registry string) (username, password string)
+
+// ManifestExists reports whether the registry has a manifest for the image
+func ManifestExists(ctx context.Context, client *http.Client, image string, credentials Credentials) (bool, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return false, err
+	}
+
+	username, password := "", ""
+	if credentials != nil {
+		username, password = credentials(ref.Registry)
+	}
+
+	res, err := headManifestWithAuth(ctx, client, ref, username, password)
+	if err != nil {
+		return false, err
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		// Registries such as the Docker Hub answer with a 401 for private
+		// images which do not exist, so that their names are not leaked
+		if len(username) == 0 {
+			return false, fmt.Errorf("no credentials for %s, run docker login to check private images", ref.Registry)
+		}
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status code from %s: %d", ref.Registry, res.StatusCode)
+	}
+}
+
+// ManifestDigest returns the digest of the manifest of an image, which
+// pins the image as it is in the registry
+func ManifestDigest(ctx context.Context, client *http.Client, image string, credentials Credentials) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+
+	username, password := "", ""
+	if credentials != nil {
+		username, password = credentials(ref.Registry)
+	}
+
+	res, err := headManifestWithAuth(ctx, client, ref, username, password)
+	if err != nil {
+		return "", err
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		digest := res.Header.Get("Docker-Content-Digest")
+		if len(digest) == 0 {
+			return "", fmt.Errorf("%s did not return the digest of %s", ref.Registry, image)
+		}
+		return digest, nil
+	case http.StatusNotFound:
+		return "", fmt.Errorf("%s was not found in %s", image, ref.Registry)
+	default:
+		return "", fmt.Errorf("unexpected status code from %s: %d", ref.Registry, res.StatusCode)
+	}
+}
+
+// headManifestWithAuth asks for the manifest of an image, and again with an
+// Authorization header when the registry sends a challenge
+func headManifestWithAuth(ctx context.Context, client *http.Client, ref Reference, username, password string) (*http.Response, error) {
+	manifestURL := fmt.Sprintf("%s/v2/%s/manifests/%s", registryBaseURL(ref.Registry), ref.Repository, ref.Tag)
+
+	res, err := headManifest(ctx, client, manifestURL, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusUnauthorized {
+		authorization, err := authorize(ctx, client, res.Header.Get("Www-Authenticate"), username, password)
+		if err != nil {
+			return nil, err
+		}
+
+		return headManifest(ctx, client, manifestURL, authorization)
+	}
+	return res, nil
+}
+
+func registryBaseURL(registry string) string {
+	if registry == DockerHub {
+		return "https://" + dockerHubAPI
+	}
+
+	host := registry
+	if colon := strings.LastIndex(host, ":"); colon >= 0 {
+		host = host[:colon]
+	}
+	if host == "localhost" || host == "127.0.0.1" {
+		return "http://" + registry
+	}
+	return "https://" + registry
+}
+
+func headManifest(ctx context.Context, client *http.Client, manifestURL, authorization string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	req.Header.Set("Accept", strings.Join(manifestTypes, ", "))
+	if len(authorization) > 0 {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+	return res, nil
+}
+
+// authorize answers a Basic or Bearer challenge with the value of the
+// Authorization header to send, a Bearer token is fetched from its realm
+func authorize(ctx context.Context, client *http.Client, challenge, username, password string) (string, error) {
+	scheme, params := parseChallenge(challenge)
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if len(username) == 0 {
+			return "", nil
+		}
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth(username, password)
+		return req.Header.Get("Authorization"), nil
+	case "bearer":
+		return fetchToken(ctx, client, params, username, password)
+	default:
+		return "", fmt.Errorf("unsupported authentication challenge from the registry: %q", challenge)
+	}
+}
+
+func fetchToken(ctx context.Context, client *http.Client, params map[string]string, username, password string) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || len(realm.Host) == 0 {
+		return "", fmt.Errorf("invalid token realm from the registry: %q", params["realm"])
+	}
+
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if value, ok := params[key]; ok {
+			query.Set(key, value)
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+
+	if len(username) > 0 {
+		req.SetBasicAuth(username, password)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to get a token from %s, status code: %d", realm.Host, res.StatusCode)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("unable to parse the token from %s: %s", realm.Host, err)
+	}
+
+	if len(token.Token) == 0 {
+		token.Token = token.AccessToken
+	}
+	return "Bearer " + token.Token, nil
+}
+
+// parseChallenge parses a WWW-Authenticate header such as
+// Bearer realm="https://auth.docker.io/token",service="registry.docker.io"
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := map[string]string{}
+
+	challenge = strings.TrimSpace(challenge)
+	space := strings.Index(challenge, " ")
+	if space < 0 {
+		return challenge, params
+	}
+
+	scheme, rest := challenge[:space], challenge[space+1:]
+	for len(rest) > 0 {
+		rest = strings.TrimLeft(rest, " ,")
+		equals := strings.Index(rest, "=")
+		if equals < 0 {
+			break
+		}
+
+		key := strings.ToLower(strings.TrimSpace(rest[:equals]))
+		rest = rest[equals+1:]
+
+		value := ""
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.Index(rest, ",")
+			if end < 0 {
+				value, rest = rest, ""
+			} else {
+				value, rest = rest[:end], rest[end:]
+			}
+		}
+		params[key] = value
+	}
+
+	return scheme, params
+}
@@ -0,0 +1,149 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_ParseReference(t *testing.T) {
+	cases := []struct {
+		image   string
+		want    Reference
+		wantErr bool
+	}{
+		{image: "alpine", want: Reference{Registry: "docker.io", Repository: "library/alpine", Tag: "latest"}},
+		{image: "alexellis/figlet:0.1", want: Reference{Registry: "docker.io", Repository: "alexellis/figlet", Tag: "0.1"}},
+		{image: "ghcr.io/openfaas/figlet:latest", want: Reference{Registry: "ghcr.io", Repository: "openfaas/figlet", Tag: "latest"}},
+		{image: "localhost:5000/figlet", want: Reference{Registry: "localhost:5000", Repository: "figlet", Tag: "latest"}},
+		{image: "registry:5000/team/figlet:1.0", want: Reference{Registry: "registry:5000", Repository: "team/figlet", Tag: "1.0"}},
+		{image: "alpine@sha256:abc", want: Reference{Registry: "docker.io", Repository: "library/alpine", Tag: "sha256:abc"}},
+		{image: "", wantErr: true},
+		{image: "alpine:", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.image, func(t *testing.T) {
+			got, err := ParseReference(c.image)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("want error, but got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Fatalf("want %+v, but got %+v", c.want, got)
+			}
+		})
+	}
+}
+
+func Test_ManifestExists(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			user, password, ok := r.BasicAuth()
+			if !ok || user != "alex" || password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("scope") != "repository:team/figlet:pull" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{"token": "abc"}`)
+		case r.Header.Get("Authorization") != "Bearer abc":
+			w.Header().Set("Www-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:team/figlet:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v2/team/figlet/manifests/1.0":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	registry := strings.TrimPrefix(server.URL, "http://")
+	credentials := func(host string) (string, string) {
+		if host != registry {
+			t.Errorf("want credentials for %s, but got a request for %s", registry, host)
+		}
+		return "alex", "secret"
+	}
+
+	found, err := ManifestExists(context.Background(), server.Client(), registry+"/team/figlet:1.0", credentials)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found {
+		t.Errorf("want the pushed image to be found")
+	}
+
+	found, err = ManifestExists(context.Background(), server.Client(), registry+"/team/figlet:2.0", credentials)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Errorf("want an image which was not pushed not to be found")
+	}
+
+	_, err = ManifestExists(context.Background(), server.Client(), registry+"/team/figlet:1.0", nil)
+	if err == nil {
+		t.Errorf("want error without credentials, but got nil")
+	}
+}
+
+func Test_ManifestDigest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/team/figlet/manifests/1.0" {
+			w.Header().Set("Docker-Content-Digest", "sha256:abc")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	registry := strings.TrimPrefix(server.URL, "http://")
+
+	digest, err := ManifestDigest(context.Background(), server.Client(), registry+"/team/figlet:1.0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != "sha256:abc" {
+		t.Errorf("want digest sha256:abc, but got %q", digest)
+	}
+
+	if _, err := ManifestDigest(context.Background(), server.Client(), registry+"/team/figlet:2.0", nil); err == nil {
+		t.Errorf("want error for an image which was not pushed, but got nil")
+	}
+}
+
+func Test_parseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/alpine:pull"`)
+
+	if scheme != "Bearer" {
+		t.Errorf("want scheme Bearer, but got %q", scheme)
+	}
+
+	want := map[string]string{
+		"realm":   "https://auth.docker.io/token",
+		"service": "registry.docker.io",
+		"scope":   "repository:library/alpine:pull",
+	}
+	for key, value := range want {
+		if params[key] != value {
+			t.Errorf("want %s=%q, but got %q", key, value, params[key])
+		}
+	}
+}
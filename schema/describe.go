@@ -16,4 +16,6 @@ type FunctionDescription struct {
 	AsyncURL          string
 	Labels            *map[string]string
 	Annotations       *map[string]string
+	EnvVars           map[string]string
+	Secrets           []string
 }
@@ -0,0 +1,50 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package v1alpha2
+
+import (
+	"github.com/openfaas/faas-cli/schema"
+)
+
+// APIVersionLatest latest API version of the FunctionIngress CRD
+const APIVersionLatest = "openfaas.com/v1alpha2"
+
+// Kind of the FunctionIngress CRD
+const Kind = "FunctionIngress"
+
+// Spec describes how a domain and path are mapped to a function
+type Spec struct {
+	//Domain such as "api.example.com"
+	Domain string `yaml:"domain"`
+	//Function name of the function to route to
+	Function string `yaml:"function"`
+	//IngressType such as "nginx"
+	IngressType string `yaml:"ingressType,omitempty"`
+	//Path to match, i.e. "/v1/profiles/(.*)"
+	Path string `yaml:"path,omitempty"`
+
+	TLS *TLS `yaml:"tls,omitempty"`
+}
+
+// TLS settings for the domain
+type TLS struct {
+	Enabled   bool      `yaml:"enabled"`
+	IssuerRef IssuerRef `yaml:"issuerRef,omitempty"`
+}
+
+// IssuerRef reference to a cert-manager Issuer or ClusterIssuer
+type IssuerRef struct {
+	Name string `yaml:"name,omitempty"`
+	Kind string `yaml:"kind,omitempty"`
+}
+
+// CRD root level YAML definition for the object
+type CRD struct {
+	//APIVersion CRD API version
+	APIVersion string `yaml:"apiVersion"`
+	//Kind kind of the object
+	Kind     string          `yaml:"kind"`
+	Metadata schema.Metadata `yaml:"metadata"`
+	Spec     Spec            `yaml:"spec"`
+}
@@ -0,0 +1,54 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package v3
+
+//Version of the OpenAPI specification used for documents
+const Version = "3.0.3"
+
+//Document root level of an OpenAPI 3 document
+type Document struct {
+	OpenAPI string              `yaml:"openapi"`
+	Info    Info                `yaml:"info"`
+	Servers []Server            `yaml:"servers,omitempty"`
+	Paths   map[string]PathItem `yaml:"paths"`
+}
+
+//Info metadata about the API
+type Info struct {
+	Title   string `yaml:"title"`
+	Version string `yaml:"version"`
+}
+
+//Server a URL where the API is served
+type Server struct {
+	URL string `yaml:"url"`
+}
+
+//PathItem maps a lower-case HTTP method to an Operation
+type PathItem map[string]Operation
+
+//Operation a single method on a path
+type Operation struct {
+	OperationID string              `yaml:"operationId,omitempty"`
+	Summary     string              `yaml:"summary,omitempty"`
+	Description string              `yaml:"description,omitempty"`
+	RequestBody *RequestBody        `yaml:"requestBody,omitempty"`
+	Responses   map[string]Response `yaml:"responses"`
+}
+
+//RequestBody body accepted by an Operation
+type RequestBody struct {
+	Content map[string]MediaType `yaml:"content"`
+}
+
+//Response returned by an Operation
+type Response struct {
+	Description string               `yaml:"description"`
+	Content     map[string]MediaType `yaml:"content,omitempty"`
+}
+
+//MediaType schema for a content-type
+type MediaType struct {
+	Schema map[string]interface{} `yaml:"schema,omitempty"`
+}
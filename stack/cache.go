@@ -0,0 +1,161 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"sync"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// maxParsedStacks is the number of stack files which are kept parsed
+const maxParsedStacks = 8
+
+// parsedStacks are the stack files which have been parsed, by the hash of
+// their contents after environment substitution. up parses the same file to
+// build, push and deploy, so a large stack is only unmarshalled once.
+var parsedStacks = struct {
+	sync.Mutex
+	entries map[[sha256.Size]byte]*Services
+}{entries: map[[sha256.Size]byte]*Services{}}
+
+// parseServices unmarshals and validates a stack file, the result is shared
+// and must be copied before it is returned to a caller
+func parseServices(source []byte) (*Services, error) {
+	key := sha256.Sum256(source)
+
+	parsedStacks.Lock()
+	cached, ok := parsedStacks.entries[key]
+	parsedStacks.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	var services Services
+	if err := yaml.Unmarshal(source, &services); err != nil {
+		fmt.Printf("Error with YAML file\n")
+		return nil, err
+	}
+
+	if err := validateServices(&services); err != nil {
+		return nil, err
+	}
+
+	parsedStacks.Lock()
+	if len(parsedStacks.entries) >= maxParsedStacks {
+		parsedStacks.entries = map[[sha256.Size]byte]*Services{}
+	}
+	parsedStacks.entries[key] = &services
+	parsedStacks.Unlock()
+
+	return &services, nil
+}
+
+// validateServices checks the provider and the version of a stack
+func validateServices(services *Services) error {
+	if err := validateProviderName(services.Provider.Name); err != nil {
+		return err
+	}
+	return validateVersion(services.Version)
+}
+
+func validateProviderName(name string) error {
+	if name == legacyProviderName {
+		return fmt.Errorf(`['%s'] is the only valid "provider.name" for the OpenFaaS CLI, but you gave: %s, run faas-cli stack migrate to upgrade the stack file`, providerName, name)
+	}
+	if name != providerName {
+		return fmt.Errorf(`['%s'] is the only valid "provider.name" for the OpenFaaS CLI, but you gave: %s`, providerName, name)
+	}
+	return nil
+}
+
+func validateVersion(version string) error {
+	if len(version) > 0 && !IsValidSchemaVersion(version) {
+		hint := "upgrade faas-cli to read it"
+		if olderSchemaVersion(version) {
+			hint = "run faas-cli stack migrate to upgrade it"
+		}
+		return fmt.Errorf("%s are the only valid versions for the stack file - found: %s, %s", ValidSchemaVersions, version, hint)
+	}
+	return nil
+}
+
+// copyServices copies a parsed stack with only the functions which match, so
+// that the caller can change it without changing the cached stack
+func copyServices(parsed *Services, match func(name string, function Function) bool) *Services {
+	services := Services{
+		Version:            parsed.Version,
+		Provider:           parsed.Provider,
+		StackConfiguration: deepCopy(reflect.ValueOf(parsed.StackConfiguration)).Interface().(StackConfiguration),
+		Environments:       deepCopy(reflect.ValueOf(parsed.Environments)).Interface().(map[string]EnvironmentProfile),
+		Includes:           parsed.Includes,
+		order:              parsed.order,
+	}
+
+	if parsed.Functions == nil {
+		return &services
+	}
+
+	services.Functions = make(map[string]Function, len(parsed.Functions))
+	for name, function := range parsed.Functions {
+		if match(name, function) {
+			services.Functions[name] = deepCopy(reflect.ValueOf(function)).Interface().(Function)
+		}
+	}
+	return &services
+}
+
+// deepCopy copies a value along with the maps, slices and pointers in it
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Elem().Type())
+		copied.Elem().Set(deepCopy(v.Elem()))
+		return copied
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(deepCopy(v.Elem()))
+		return copied
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for _, key := range v.MapKeys() {
+			copied.SetMapIndex(key, deepCopy(v.MapIndex(key)))
+		}
+		return copied
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return copied
+
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			copied.Field(i).Set(deepCopy(v.Field(i)))
+		}
+		return copied
+
+	default:
+		return v
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"crypto/sha256"
+	"strings"
+	"testing"
+)
+
+const cachedStack = `version: 1.0
+provider:
+  name: openfaas
+  gateway: http://127.0.0.1:8080
+functions:
+  url-ping:
+    lang: python
+    handler: ./sample/url-ping
+    image: alexellis/faas-url-ping
+    environment:
+      debug: "true"
+    labels:
+      team: dev
+    openapi:
+      request_schema:
+        type: object
+  nodejs-echo:
+    lang: node
+    handler: ./sample/nodejs-echo
+    image: alexellis/faas-nodejs-echo
+`
+
+func Test_ParseYAMLData_CachedStackIsCopied(t *testing.T) {
+	first, err := ParseYAMLData([]byte(cachedStack), "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedStacks.Lock()
+	_, cached := parsedStacks.entries[sha256.Sum256([]byte(cachedStack))]
+	parsedStacks.Unlock()
+	if !cached {
+		t.Fatalf("want the parsed stack to be cached")
+	}
+
+	// Change the first result as commands do before they deploy
+	function := first.Functions["url-ping"]
+	function.Environment["debug"] = "false"
+	(*function.Labels)["team"] = "ops"
+	function.OpenAPI.RequestSchema["type"] = "string"
+	first.Functions["url-ping"] = function
+	delete(first.Functions, "nodejs-echo")
+
+	second, err := ParseYAMLData([]byte(cachedStack), "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(second.Functions) != 2 {
+		t.Fatalf("want 2 functions, got %d", len(second.Functions))
+	}
+	function = second.Functions["url-ping"]
+	if function.Environment["debug"] != "true" || (*function.Labels)["team"] != "dev" || function.OpenAPI.RequestSchema["type"] != "object" {
+		t.Errorf("want the cached stack to be unchanged, got %v", function)
+	}
+}
+
+func Test_ParseYAMLData_CachedStackIsFiltered(t *testing.T) {
+	if _, err := ParseYAMLData([]byte(cachedStack), "", "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	filtered, err := ParseYAMLData([]byte(cachedStack), "", "url-*", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := filtered.Functions["url-ping"]; !ok || len(filtered.Functions) != 1 {
+		t.Errorf("want only url-ping, got %v", filtered.Functions)
+	}
+
+	_, err = ParseYAMLData([]byte(cachedStack), "[", "", false)
+	if err == nil || !strings.Contains(err.Error(), "error parsing regexp") {
+		t.Errorf("want an error for an invalid regex, got: %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var dotEnvKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// ParseDotEnvFile reads environment variables from a file in the dotenv
+// format used by docker compose's env_file
+func ParseDotEnvFile(file string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	envs, err := ParseDotEnv(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+	return envs, nil
+}
+
+// ParseDotEnv parses KEY=VALUE lines with the quoting rules of docker compose:
+//   - blank lines and lines starting with # are ignored, "export " is allowed
+//   - unquoted values are trimmed and end at an inline " #" comment
+//   - 'single quoted' values are taken literally
+//   - "double quoted" values may span lines and accept \n, \r, \t, \", \\ and \$
+//   - $VAR and ${VAR} are expanded in unquoted and double quoted values from
+//     earlier lines, then the environment; ${VAR:-default} and $$ are supported
+//   - a KEY on its own takes its value from the environment, when set
+func ParseDotEnv(data []byte) (map[string]string, error) {
+	envs := map[string]string{}
+	lookup := func(key string) (string, bool) {
+		if value, ok := envs[key]; ok {
+			return value, true
+		}
+		return os.LookupEnv(key)
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNumber := i + 1
+		line := strings.TrimSpace(lines[i])
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "export ") || strings.HasPrefix(line, "export\t") {
+			line = strings.TrimSpace(line[len("export"):])
+		}
+
+		separator := strings.Index(line, "=")
+		if separator < 0 {
+			if !dotEnvKey.MatchString(line) {
+				return nil, fmt.Errorf("line %d: invalid variable name %q", lineNumber, line)
+			}
+			if value, ok := os.LookupEnv(line); ok {
+				envs[line] = value
+			}
+			continue
+		}
+
+		key := strings.TrimSpace(line[:separator])
+		if !dotEnvKey.MatchString(key) {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", lineNumber, key)
+		}
+
+		value := strings.TrimLeft(line[separator+1:], " \t")
+		if len(value) == 0 || (value[0] != '\'' && value[0] != '"') {
+			if comment := strings.Index(value, " #"); comment >= 0 {
+				value = value[:comment]
+			}
+			if comment := strings.Index(value, "\t#"); comment >= 0 {
+				value = value[:comment]
+			}
+
+			expanded, err := expandDotEnv(strings.TrimSpace(value), lookup)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", lineNumber, err)
+			}
+			envs[key] = expanded
+			continue
+		}
+
+		quote := value[0]
+		value = value[1:]
+		end := closingQuote(value, quote)
+		for end < 0 {
+			i++
+			if i >= len(lines) {
+				return nil, fmt.Errorf("line %d: missing closing quote %c for %s", lineNumber, quote, key)
+			}
+			value += "\n" + lines[i]
+			end = closingQuote(value, quote)
+		}
+
+		if trailing := strings.TrimSpace(value[end+1:]); len(trailing) > 0 && !strings.HasPrefix(trailing, "#") {
+			return nil, fmt.Errorf("line %d: unexpected characters after closing quote for %s: %q", lineNumber, key, trailing)
+		}
+		value = value[:end]
+
+		if quote == '"' {
+			expanded, err := expandDotEnv(unescapeDotEnv(value), lookup)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", lineNumber, err)
+			}
+			value = expanded
+		}
+		envs[key] = value
+	}
+
+	return envs, nil
+}
+
+func closingQuote(value string, quote byte) int {
+	for i := 0; i < len(value); i++ {
+		if quote == '"' && value[i] == '\\' {
+			i++
+			continue
+		}
+		if value[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+// unescapeDotEnv replaces escape sequences in a double quoted value, an
+// escaped $ is written as $$ so that it is not expanded afterwards
+func unescapeDotEnv(value string) string {
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i == len(value)-1 {
+			sb.WriteByte(value[i])
+			continue
+		}
+
+		i++
+		switch value[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case '"', '\\':
+			sb.WriteByte(value[i])
+		case '$':
+			sb.WriteString("$$")
+		default:
+			sb.WriteByte('\\')
+			sb.WriteByte(value[i])
+		}
+	}
+	return sb.String()
+}
+
+func expandDotEnv(value string, lookup func(string) (string, bool)) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' || i == len(value)-1 {
+			sb.WriteByte(value[i])
+			continue
+		}
+
+		switch next := value[i+1]; {
+		case next == '$':
+			sb.WriteByte('$')
+			i++
+		case next == '{':
+			end := strings.Index(value[i:], "}")
+			if end < 0 {
+				return "", fmt.Errorf("missing closing brace in %q", value)
+			}
+			name := value[i+2 : i+end]
+			i += end
+
+			fallback, hasFallback := "", false
+			emptyIsUnset := false
+			if separator := strings.Index(name, ":-"); separator >= 0 {
+				name, fallback, hasFallback, emptyIsUnset = name[:separator], name[separator+2:], true, true
+			} else if separator := strings.Index(name, "-"); separator >= 0 {
+				name, fallback, hasFallback = name[:separator], name[separator+1:], true
+			}
+
+			resolved, ok := lookup(name)
+			if hasFallback && (!ok || (emptyIsUnset && len(resolved) == 0)) {
+				resolved = fallback
+			}
+			sb.WriteString(resolved)
+		case next == '_' || isASCIILetter(next):
+			end := i + 1
+			for end < len(value) && (value[end] == '_' || isASCIILetter(value[end]) || (value[end] >= '0' && value[end] <= '9')) {
+				end++
+			}
+			resolved, _ := lookup(value[i+1 : end])
+			sb.WriteString(resolved)
+			i = end - 1
+		default:
+			sb.WriteByte('$')
+		}
+	}
+	return sb.String(), nil
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
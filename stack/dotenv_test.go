@@ -0,0 +1,99 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func Test_ParseDotEnv(t *testing.T) {
+	os.Setenv("FAAS_DOTENV_TEST", "from-environment")
+	defer os.Unsetenv("FAAS_DOTENV_TEST")
+
+	testcases := []struct {
+		Name  string
+		Input string
+		Want  map[string]string
+	}{
+		{
+			Name:  "comments, blank lines and export",
+			Input: "# comment\n\nexport A=1\nB = 2 \n",
+			Want:  map[string]string{"A": "1", "B": "2"},
+		},
+		{
+			Name:  "inline comment on unquoted value",
+			Input: "URL=http://gateway:8080#path # the gateway\n",
+			Want:  map[string]string{"URL": "http://gateway:8080#path"},
+		},
+		{
+			Name:  "single quotes are literal",
+			Input: `A='$HOME\n # not a comment'`,
+			Want:  map[string]string{"A": `$HOME\n # not a comment`},
+		},
+		{
+			Name:  "double quotes accept escapes",
+			Input: `A="line1\nline2 \"quoted\" \$HOME"`,
+			Want:  map[string]string{"A": "line1\nline2 \"quoted\" $HOME"},
+		},
+		{
+			Name:  "double quotes span lines",
+			Input: "A=\"first\nsecond\"\nB=3",
+			Want:  map[string]string{"A": "first\nsecond", "B": "3"},
+		},
+		{
+			Name:  "interpolation from earlier lines and the environment",
+			Input: "HOST=gateway\nURL=http://${HOST}:8080\nFROM_ENV=$FAAS_DOTENV_TEST\nDEFAULT=${FAAS_DOTENV_UNSET:-fallback}\nDOLLAR=$$HOST",
+			Want: map[string]string{
+				"HOST":     "gateway",
+				"URL":      "http://gateway:8080",
+				"FROM_ENV": "from-environment",
+				"DEFAULT":  "fallback",
+				"DOLLAR":   "$HOST",
+			},
+		},
+		{
+			Name:  "key without a value is read from the environment",
+			Input: "FAAS_DOTENV_TEST\nFAAS_DOTENV_UNSET",
+			Want:  map[string]string{"FAAS_DOTENV_TEST": "from-environment"},
+		},
+		{
+			Name:  "empty value",
+			Input: "A=\nB=''",
+			Want:  map[string]string{"A": "", "B": ""},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.Name, func(t *testing.T) {
+			got, err := ParseDotEnv([]byte(testcase.Input))
+			if err != nil {
+				t.Fatalf("want no error, but got: %s", err)
+			}
+			if !reflect.DeepEqual(testcase.Want, got) {
+				t.Fatalf("want: %q, but got: %q", testcase.Want, got)
+			}
+		})
+	}
+}
+
+func Test_ParseDotEnv_Errors(t *testing.T) {
+	testcases := []struct {
+		Name  string
+		Input string
+	}{
+		{Name: "missing closing quote", Input: `A="value`},
+		{Name: "characters after closing quote", Input: `A="value" extra`},
+		{Name: "invalid key", Input: `1A=value`},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.Name, func(t *testing.T) {
+			if _, err := ParseDotEnv([]byte(testcase.Input)); err == nil {
+				t.Fatalf("want error, but got nil")
+			}
+		})
+	}
+}
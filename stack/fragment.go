@@ -0,0 +1,79 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// FragmentFile is the name of a function's own stack file, kept next to its
+// handler in a monorepo
+const FragmentFile = "function.yml"
+
+// functionFragment is a single function, its name defaults to the folder
+type functionFragment struct {
+	Name     string `yaml:"name"`
+	Function `yaml:",inline"`
+}
+
+// ParseFragmentFile reads a function.yml file, the name of the function
+// defaults to the folder of the file and its handler to the folder itself.
+// Relative paths are resolved from the folder of the file. Environment
+// variables are not substituted, so that they are kept for deploy.
+func ParseFragmentFile(file string) (Function, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return Function{}, err
+	}
+
+	var fragment functionFragment
+	if err := yaml.UnmarshalStrict(data, &fragment); err != nil {
+		return Function{}, fmt.Errorf("%s: %s", file, err)
+	}
+
+	dir := filepath.Dir(file)
+
+	function := fragment.Function
+	function.Name = fragment.Name
+	if len(function.Name) == 0 {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return Function{}, err
+		}
+		function.Name = filepath.Base(abs)
+	}
+
+	if len(function.Handler) == 0 {
+		function.Handler = dir
+	} else {
+		function.Handler = fragmentPath(dir, function.Handler)
+	}
+
+	function.EnvFile = fragmentPath(dir, function.EnvFile)
+	if len(function.EnvironmentFile) > 0 {
+		files := make([]string, len(function.EnvironmentFile))
+		for i, file := range function.EnvironmentFile {
+			files[i] = fragmentPath(dir, file)
+		}
+		function.EnvironmentFile = files
+	}
+
+	if function.Language == "Dockerfile" {
+		function.Language = "dockerfile"
+	}
+
+	return function, nil
+}
+
+func fragmentPath(dir, path string) string {
+	if len(path) == 0 || filepath.IsAbs(path) || strings.Contains(path, "$") {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
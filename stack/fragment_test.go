@@ -0,0 +1,94 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_ParseFragmentFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fragment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	handlerDir := filepath.Join(dir, "resize")
+	if err := os.Mkdir(handlerDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		content string
+		want    Function
+		wantErr bool
+	}{
+		{
+			name: "defaults to the folder",
+			content: `lang: python3
+image: resize:latest
+environment_file:
+  - env.yml
+environment:
+  region: ${REGION}
+`,
+			want: Function{
+				Name:            "resize",
+				Language:        "python3",
+				Handler:         handlerDir,
+				Image:           "resize:latest",
+				EnvironmentFile: []string{filepath.Join(handlerDir, "env.yml")},
+				Environment:     map[string]string{"region": "${REGION}"},
+			},
+		},
+		{
+			name: "name and handler",
+			content: `name: thumbnail
+lang: Dockerfile
+handler: ./src
+image: thumbnail:latest
+`,
+			want: Function{
+				Name:     "thumbnail",
+				Language: "dockerfile",
+				Handler:  filepath.Join(handlerDir, "src"),
+				Image:    "thumbnail:latest",
+			},
+		},
+		{
+			name:    "unknown keys",
+			content: "lang: python3\nimages: resize:latest\n",
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			file := filepath.Join(handlerDir, FragmentFile)
+			if err := ioutil.WriteFile(file, []byte(c.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ParseFragmentFile(file)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("want error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("want %+v, but got %+v", c.want, got)
+			}
+		})
+	}
+}
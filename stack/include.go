@@ -0,0 +1,161 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// hasIncludes is true when a stack file includes others, so that a stack
+// file without any is parsed and cached as before
+func hasIncludes(fileData []byte) bool {
+	var declared struct {
+		Includes []string `yaml:"includes"`
+	}
+	return yaml.Unmarshal(fileData, &declared) == nil && len(declared.Includes) > 0
+}
+
+// parseIncludes parses a stack file along with the files it includes, with
+// only the functions which the selector picks
+func parseIncludes(yamlFile string, fileData []byte, selector *Selector, envsubst bool) (*Services, error) {
+	services, _, err := loadStackFile(yamlFile, fileData, envsubst, []string{includeKey(yamlFile)})
+	if err != nil {
+		return nil, err
+	}
+	if err := validateServices(services); err != nil {
+		return nil, err
+	}
+	return selectServices(services, selector)
+}
+
+// loadStackFile parses a stack file and merges it over the files which it
+// includes, in order, along with the keys which were written in all of them.
+// chain holds the files which include this one, to find a cycle.
+func loadStackFile(yamlFile string, fileData []byte, envsubst bool, chain []string) (*Services, yaml.MapSlice, error) {
+	if envsubst {
+		var err error
+		if fileData, err = substituteEnvironment(fileData); err != nil {
+			return nil, nil, fmt.Errorf("%s: %s", yamlFile, err)
+		}
+	}
+
+	var parsed Services
+	if err := yaml.Unmarshal(fileData, &parsed); err != nil {
+		return nil, nil, fmt.Errorf("%s: %s", yamlFile, err)
+	}
+	var written yaml.MapSlice
+	if err := yaml.Unmarshal(fileData, &written); err != nil {
+		return nil, nil, fmt.Errorf("%s: %s", yamlFile, err)
+	}
+	if len(parsed.Includes) == 0 {
+		return &parsed, written, nil
+	}
+
+	merged := &Services{}
+	var mergedWritten yaml.MapSlice
+	for _, include := range parsed.Includes {
+		location, err := includeLocation(yamlFile, include)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key := includeKey(location)
+		for i, seen := range chain {
+			if seen == key {
+				cycle := append(append([]string{}, chain[i:]...), key)
+				return nil, nil, fmt.Errorf("stack files include each other: %s", strings.Join(cycle, " -> "))
+			}
+		}
+
+		includedData, err := readYAML(location)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read %s, included by %s: %s", include, yamlFile, err)
+		}
+		included, includedWritten, err := loadStackFile(location, includedData, envsubst, append(chain[:len(chain):len(chain)], key))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rebaseFunctionPaths(included, yamlFile, location)
+		MergeServices(merged, included, includedWritten)
+		mergedWritten = mergeWritten(mergedWritten, includedWritten)
+	}
+
+	MergeServices(merged, &parsed, written)
+	return merged, mergeWritten(mergedWritten, written), nil
+}
+
+// isRemote is true for a stack file which is read from a URL
+func isRemote(location string) bool {
+	u, err := url.Parse(location)
+	return err == nil && len(u.Scheme) > 1
+}
+
+// includeLocation resolves an include from the folder or URL of the file
+// which includes it
+func includeLocation(parent, include string) (string, error) {
+	if isRemote(include) {
+		return include, nil
+	}
+
+	if isRemote(parent) {
+		base, err := url.Parse(parent)
+		if err != nil {
+			return "", err
+		}
+		ref, err := url.Parse(filepath.ToSlash(include))
+		if err != nil {
+			return "", fmt.Errorf("invalid include %q in %s: %s", include, parent, err)
+		}
+		return base.ResolveReference(ref).String(), nil
+	}
+
+	if filepath.IsAbs(include) {
+		return include, nil
+	}
+	return filepath.Join(filepath.Dir(parent), include), nil
+}
+
+// includeKey identifies a stack file to find a cycle, whichever path it was
+// included by
+func includeKey(location string) string {
+	if isRemote(location) {
+		return location
+	}
+	if abs, err := filepath.Abs(location); err == nil {
+		return abs
+	}
+	return filepath.Clean(location)
+}
+
+// rebaseFunctionPaths makes the paths of the functions in a local included
+// file relative to the folder of the file which includes it, as if they had
+// been written there. The paths in a remote file are kept as they are.
+func rebaseFunctionPaths(included *Services, parent, location string) {
+	if isRemote(parent) || isRemote(location) {
+		return
+	}
+	dir, err := filepath.Rel(filepath.Dir(parent), filepath.Dir(location))
+	if err != nil || dir == "." {
+		return
+	}
+
+	for name, function := range included.Functions {
+		function.Handler = fragmentPath(dir, function.Handler)
+		function.EnvFile = fragmentPath(dir, function.EnvFile)
+		if len(function.EnvironmentFile) > 0 {
+			files := make([]string, len(function.EnvironmentFile))
+			for i, file := range function.EnvironmentFile {
+				files[i] = fragmentPath(dir, file)
+			}
+			function.EnvironmentFile = files
+		}
+		included.Functions[name] = function
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeStackFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "faas-cli-include")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func Test_ParseYAMLFileSelect_Includes(t *testing.T) {
+	dir := writeStackFiles(t, map[string]string{
+		"stack.yml": `version: 1.0
+provider:
+  name: openfaas
+includes:
+  - ./functions/api.yml
+functions:
+  api:
+    image: alexellis/api:1.2.0
+  worker:
+    lang: go
+    handler: ./worker
+    image: alexellis/worker:latest
+`,
+		"functions/api.yml": `functions:
+  api:
+    lang: go
+    handler: ./api
+    image: alexellis/api:latest
+    env_file: api.env
+    environment:
+      LOG_LEVEL: debug
+`,
+	})
+
+	services, err := ParseYAMLFileSelect(filepath.Join(dir, "stack.yml"), nil, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	api := services.Functions["api"]
+	if api.Image != "alexellis/api:1.2.0" {
+		t.Fatalf("want the image from the including file, got: %s", api.Image)
+	}
+	if want := filepath.Join("functions", "api"); api.Handler != want {
+		t.Fatalf("want the handler %s, got: %s", want, api.Handler)
+	}
+	if want := filepath.Join("functions", "api.env"); api.EnvFile != want {
+		t.Fatalf("want the env_file %s, got: %s", want, api.EnvFile)
+	}
+	if api.Environment["LOG_LEVEL"] != "debug" {
+		t.Fatalf("want the environment of the included file, got: %v", api.Environment)
+	}
+	if got := services.Functions["worker"].Handler; got != "./worker" {
+		t.Fatalf("want the handler of the including file kept, got: %s", got)
+	}
+	if want := []string{"api", "worker"}; !reflect.DeepEqual(services.FunctionNames(), want) {
+		t.Fatalf("want functions in order %v, got %v", want, services.FunctionNames())
+	}
+}
+
+func Test_ParseYAMLFileSelect_IncludeCycle(t *testing.T) {
+	dir := writeStackFiles(t, map[string]string{
+		"stack.yml": "provider:\n  name: openfaas\nincludes:\n  - a.yml\n",
+		"a.yml":     "includes:\n  - b/b.yml\n",
+		"b/b.yml":   "includes:\n  - ../a.yml\n",
+	})
+
+	_, err := ParseYAMLFileSelect(filepath.Join(dir, "stack.yml"), nil, false)
+	if err == nil || !strings.Contains(err.Error(), "include each other") {
+		t.Fatalf("want an error for the cycle, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), filepath.Join(dir, "a.yml")+" -> "+filepath.Join(dir, "b", "b.yml")+" -> "+filepath.Join(dir, "a.yml")) {
+		t.Fatalf("want the cycle in the error, got: %s", err)
+	}
+}
+
+func Test_ParseYAMLFileSelect_IncludeMissing(t *testing.T) {
+	dir := writeStackFiles(t, map[string]string{
+		"stack.yml": "provider:\n  name: openfaas\nincludes:\n  - missing.yml\n",
+	})
+
+	_, err := ParseYAMLFileSelect(filepath.Join(dir, "stack.yml"), nil, false)
+	if err == nil || !strings.Contains(err.Error(), "missing.yml, included by") {
+		t.Fatalf("want an error for the missing include, got: %v", err)
+	}
+}
+
+func Test_ParseYAMLFileSelect_RemoteIncludes(t *testing.T) {
+	files := map[string]string{
+		"/stacks/stack.yml":   "provider:\n  name: openfaas\nincludes:\n  - fns/api.yml\n",
+		"/stacks/fns/api.yml": "functions:\n  api:\n    lang: go\n    handler: ./api\n    image: alexellis/api:latest\n",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	services, err := ParseYAMLFileSelect(server.URL+"/stacks/stack.yml", nil, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := services.Functions["api"].Handler; got != "./api" {
+		t.Fatalf("want the handler of a remote file kept, got: %s", got)
+	}
+}
+
+func Test_ParseYAMLFile_IncludesFilter(t *testing.T) {
+	dir := writeStackFiles(t, map[string]string{
+		"stack.yml": "provider:\n  name: openfaas\nincludes:\n  - fns.yml\n",
+		"fns.yml":   "functions:\n  api:\n    image: alexellis/api\n  worker:\n    image: alexellis/worker\n",
+	})
+
+	services, err := ParseYAMLFile(filepath.Join(dir, "stack.yml"), "", "work*", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(services.Functions) != 1 || len(services.Functions["worker"].Image) == 0 {
+		t.Fatalf("want only worker, got: %v", services.Functions)
+	}
+}
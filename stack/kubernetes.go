@@ -0,0 +1,82 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ServiceAccountAnnotation is read by faas-netes to run a function's Pod
+// with a service account, i.e. one bound to a cloud IAM role
+const ServiceAccountAnnotation = "com.openfaas.serviceaccount"
+
+// serviceAccountName is an RFC-1123 subdomain, the format of Kubernetes
+// object names
+var serviceAccountName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+// KubernetesOptions are the settings of a function which only the Kubernetes
+// provider reads
+type KubernetesOptions struct {
+	// ServiceAccount the function's Pod runs as
+	ServiceAccount string `yaml:"service_account,omitempty"`
+
+	// NodeSelector picks the nodes the function is scheduled on, i.e. a
+	// label for nodes with a GPU
+	NodeSelector map[string]string `yaml:"node_selector,omitempty"`
+}
+
+// Annotations returns the annotations which faas-netes reads for the
+// settings
+func (k *KubernetesOptions) Annotations() (map[string]string, error) {
+	annotations := map[string]string{}
+	if k == nil || len(k.ServiceAccount) == 0 {
+		return annotations, nil
+	}
+
+	if !serviceAccountName.MatchString(k.ServiceAccount) {
+		return nil, fmt.Errorf("invalid k8s service_account %q, use lower case letters, numbers, '-' and '.'", k.ServiceAccount)
+	}
+	annotations[ServiceAccountAnnotation] = k.ServiceAccount
+	return annotations, nil
+}
+
+// Constraints returns the node selector as constraints, which faas-netes
+// turns into the node selector of the function's Pod, sorted by key
+func (k *KubernetesOptions) Constraints() ([]string, error) {
+	if k == nil || len(k.NodeSelector) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(k.NodeSelector))
+	for key := range k.NodeSelector {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	constraints := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value := k.NodeSelector[key]
+		if len(strings.TrimSpace(key)) == 0 || strings.Contains(key, "=") {
+			return nil, fmt.Errorf("invalid k8s node_selector key %q", key)
+		}
+		if strings.Contains(value, "=") {
+			return nil, fmt.Errorf("invalid k8s node_selector value %q for %s", value, key)
+		}
+		constraints = append(constraints, key+"="+value)
+	}
+	return constraints, nil
+}
+
+// ConstraintKey returns the node label which a constraint matches on, i.e.
+// disktype for disktype=ssd or node.role for node.role == manager
+func ConstraintKey(constraint string) string {
+	key := constraint
+	if i := strings.IndexAny(constraint, "=!"); i > -1 {
+		key = constraint[:i]
+	}
+	return strings.TrimSpace(key)
+}
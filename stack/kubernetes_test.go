@@ -0,0 +1,80 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_KubernetesOptions(t *testing.T) {
+	cases := []struct {
+		name            string
+		options         *KubernetesOptions
+		wantAnnotations map[string]string
+		wantConstraints []string
+		wantErr         bool
+	}{
+		{
+			name:            "no k8s block",
+			wantAnnotations: map[string]string{},
+		},
+		{
+			name: "service account and node selector",
+			options: &KubernetesOptions{
+				ServiceAccount: "s3-reader",
+				NodeSelector:   map[string]string{"disktype": "ssd", "cloud.google.com/gke-accelerator": "nvidia-tesla-t4"},
+			},
+			wantAnnotations: map[string]string{ServiceAccountAnnotation: "s3-reader"},
+			wantConstraints: []string{"cloud.google.com/gke-accelerator=nvidia-tesla-t4", "disktype=ssd"},
+		},
+		{
+			name:    "invalid service account",
+			options: &KubernetesOptions{ServiceAccount: "S3_Reader"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid node selector",
+			options: &KubernetesOptions{NodeSelector: map[string]string{"disktype": "a=b"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			annotations, annotationsErr := tc.options.Annotations()
+			constraints, constraintsErr := tc.options.Constraints()
+			if tc.wantErr {
+				if annotationsErr == nil && constraintsErr == nil {
+					t.Fatalf("want an error")
+				}
+				return
+			}
+			if annotationsErr != nil || constraintsErr != nil {
+				t.Fatalf("want no error, got %v, %v", annotationsErr, constraintsErr)
+			}
+			if !reflect.DeepEqual(annotations, tc.wantAnnotations) {
+				t.Fatalf("want annotations %v, got %v", tc.wantAnnotations, annotations)
+			}
+			if !reflect.DeepEqual(constraints, tc.wantConstraints) {
+				t.Fatalf("want constraints %v, got %v", tc.wantConstraints, constraints)
+			}
+		})
+	}
+}
+
+func Test_ConstraintKey(t *testing.T) {
+	cases := map[string]string{
+		"disktype=ssd":                         "disktype",
+		"node.role == manager":                 "node.role",
+		"node.platform.os != windows":          "node.platform.os",
+		"kubernetes.io/hostname":               "kubernetes.io/hostname",
+		"cloud.google.com/gke-nodepool=gpu-np": "cloud.google.com/gke-nodepool",
+	}
+	for constraint, want := range cases {
+		if got := ConstraintKey(constraint); got != want {
+			t.Errorf("want %s for %q, got %s", want, constraint, got)
+		}
+	}
+}
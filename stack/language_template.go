@@ -52,6 +52,7 @@ func IsValidTemplate(lang string) bool {
 	var found bool
 
 	lang = strings.ToLower(lang)
+	lang, _ = SplitLanguage(lang)
 
 	if _, err := os.Stat("./template/" + lang); err == nil {
 		templateYAMLPath := "./template/" + lang + "/template.yml"
@@ -67,6 +68,7 @@ func IsValidTemplate(lang string) bool {
 //LoadLanguageTemplate loads language template details from template.yml file.
 func LoadLanguageTemplate(lang string) (*LanguageTemplate, error) {
 	lang = strings.ToLower(lang)
+	lang, _ = SplitLanguage(lang)
 	_, err := os.Stat("./template/" + lang)
 
 	if err == nil {
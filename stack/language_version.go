@@ -0,0 +1,60 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// languageVersionSeparator separates the template from its version in lang,
+// i.e. python3@3.11
+const languageVersionSeparator = "@"
+
+// TemplateVersion is a version of the runtime of a template, which a function
+// selects with lang: template@version
+type TemplateVersion struct {
+	// BuildArgs are passed to the build, i.e. to pick the base image
+	BuildArgs map[string]string `yaml:"build_args,omitempty"`
+	// Dockerfile in the template folder which replaces the Dockerfile
+	Dockerfile string `yaml:"dockerfile,omitempty"`
+}
+
+// SplitLanguage splits lang into the name of the template and the version,
+// the version is empty when lang has no qualifier
+func SplitLanguage(lang string) (template string, version string) {
+	if i := strings.Index(lang, languageVersionSeparator); i >= 0 {
+		return lang[:i], lang[i+len(languageVersionSeparator):]
+	}
+	return lang, ""
+}
+
+// ResolveVersion returns the version of the template which a function asked
+// for, it is nil when no version was given and an error when the template
+// does not declare the version
+func (t *LanguageTemplate) ResolveVersion(template, version string) (*TemplateVersion, error) {
+	if len(version) == 0 {
+		return nil, nil
+	}
+
+	if len(t.Versions) == 0 {
+		return nil, fmt.Errorf("the %s template does not declare any versions, remove @%s from lang", template, version)
+	}
+
+	templateVersion, ok := t.Versions[version]
+	if !ok {
+		available := make([]string, 0, len(t.Versions))
+		for name := range t.Versions {
+			available = append(available, name)
+		}
+		sort.Strings(available)
+		return nil, fmt.Errorf("version %q is not declared by the %s template, use one of: %s", version, template, strings.Join(available, ", "))
+	}
+
+	if strings.ContainsAny(templateVersion.Dockerfile, `/\`) {
+		return nil, fmt.Errorf("version %q of the %s template: dockerfile must be a file in the template folder, but was: %s", version, template, templateVersion.Dockerfile)
+	}
+	return &templateVersion, nil
+}
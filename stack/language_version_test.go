@@ -0,0 +1,103 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_SplitLanguage(t *testing.T) {
+	cases := []struct {
+		lang         string
+		wantTemplate string
+		wantVersion  string
+	}{
+		{lang: "python3", wantTemplate: "python3"},
+		{lang: "python3@3.11", wantTemplate: "python3", wantVersion: "3.11"},
+		{lang: "node@", wantTemplate: "node"},
+		{lang: "dockerfile", wantTemplate: "dockerfile"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.lang, func(t *testing.T) {
+			template, version := SplitLanguage(tc.lang)
+			if template != tc.wantTemplate || version != tc.wantVersion {
+				t.Fatalf("want %q %q, got %q %q", tc.wantTemplate, tc.wantVersion, template, version)
+			}
+		})
+	}
+}
+
+func Test_LanguageTemplate_ResolveVersion(t *testing.T) {
+	template := &LanguageTemplate{
+		Versions: map[string]TemplateVersion{
+			"3.11": {BuildArgs: map[string]string{"PYTHON_VERSION": "3.11"}},
+			"3.8":  {Dockerfile: "Dockerfile.3.8"},
+			"bad":  {Dockerfile: "../Dockerfile"},
+		},
+	}
+
+	cases := []struct {
+		name     string
+		template *LanguageTemplate
+		version  string
+		want     *TemplateVersion
+		wantErr  string
+	}{
+		{
+			name:     "no version",
+			template: template,
+		},
+		{
+			name:     "version with build args",
+			template: template,
+			version:  "3.11",
+			want:     &TemplateVersion{BuildArgs: map[string]string{"PYTHON_VERSION": "3.11"}},
+		},
+		{
+			name:     "version with a Dockerfile",
+			template: template,
+			version:  "3.8",
+			want:     &TemplateVersion{Dockerfile: "Dockerfile.3.8"},
+		},
+		{
+			name:     "undeclared version",
+			template: template,
+			version:  "2.7",
+			wantErr:  `version "2.7" is not declared by the python3 template, use one of: 3.11, 3.8, bad`,
+		},
+		{
+			name:     "template without versions",
+			template: &LanguageTemplate{},
+			version:  "3.11",
+			wantErr:  "the python3 template does not declare any versions",
+		},
+		{
+			name:     "Dockerfile outside of the template",
+			template: template,
+			version:  "bad",
+			wantErr:  "dockerfile must be a file in the template folder",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.template.ResolveVersion("python3", tc.version)
+			if len(tc.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("want error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("want %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
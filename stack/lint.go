@@ -0,0 +1,29 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Lint returns warnings for settings of a function which are ignored, or
+// which suggest that it is not set up as intended. readTemplate is true when
+// the fprocess of a function is read from its template at deploy time.
+func (f Function) Lint(readTemplate bool) []string {
+	warnings := []string{}
+
+	language, _ := SplitLanguage(f.Language)
+	templated := len(language) > 0 && strings.ToLower(language) != "dockerfile"
+
+	if readTemplate && templated && len(f.FProcess) > 0 {
+		warnings = append(warnings, fmt.Sprintf("fprocess is ignored, as it is read from the %s template", language))
+	}
+
+	if len(f.Handler) > 0 && len(f.Language) == 0 && len(f.Image) > 0 {
+		warnings = append(warnings, "handler is set without lang, so the function is not built and its image is deployed as it is")
+	}
+
+	return warnings
+}
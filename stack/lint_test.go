@@ -0,0 +1,61 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import "testing"
+
+func Test_Function_Lint(t *testing.T) {
+	cases := []struct {
+		name         string
+		function     Function
+		readTemplate bool
+		want         int
+	}{
+		{
+			name:         "fprocess with a template",
+			function:     Function{Language: "python3", Handler: "./fn", Image: "fn:latest", FProcess: "python3 index.py"},
+			readTemplate: true,
+			want:         1,
+		},
+		{
+			name:         "fprocess with a versioned template",
+			function:     Function{Language: "python3@3.11", Handler: "./fn", Image: "fn:latest", FProcess: "python3 index.py"},
+			readTemplate: true,
+			want:         1,
+		},
+		{
+			name:         "fprocess when the template is not read",
+			function:     Function{Language: "python3", Handler: "./fn", Image: "fn:latest", FProcess: "python3 index.py"},
+			readTemplate: false,
+		},
+		{
+			name:         "fprocess with a Dockerfile",
+			function:     Function{Language: "dockerfile", Handler: "./fn", Image: "fn:latest", FProcess: "./fn"},
+			readTemplate: true,
+		},
+		{
+			name:         "fprocess with an image",
+			function:     Function{Image: "functions/nodeinfo:latest", FProcess: "node index.js"},
+			readTemplate: true,
+		},
+		{
+			name:     "handler without lang",
+			function: Function{Handler: "./fn", Image: "fn:latest"},
+			want:     1,
+		},
+		{
+			name:     "prebuilt image",
+			function: Function{Image: "functions/nodeinfo:latest"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.function.Lint(tc.readTemplate)
+			if len(got) != tc.want {
+				t.Fatalf("want %d warnings, got %v", tc.want, got)
+			}
+		})
+	}
+}
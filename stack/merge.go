@@ -0,0 +1,165 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ParseYAMLFilesSelect parses stack files which are merged in order, i.e. a
+// base stack.yml and one for an environment, with only the functions which
+// the selector picks. A later file only needs the settings which it changes.
+func ParseYAMLFilesSelect(yamlFiles []string, selector *Selector, envsubst bool) (*Services, error) {
+	switch len(yamlFiles) {
+	case 0:
+		return nil, fmt.Errorf("no stack files were given")
+	case 1:
+		return ParseYAMLFileSelect(yamlFiles[0], selector, envsubst)
+	}
+
+	merged := &Services{}
+	for _, yamlFile := range yamlFiles {
+		fileData, err := readYAML(yamlFile)
+		if err != nil {
+			return nil, err
+		}
+		parsed, written, err := loadStackFile(yamlFile, fileData, envsubst, []string{includeKey(yamlFile)})
+		if err != nil {
+			return nil, err
+		}
+		MergeServices(merged, parsed, written)
+	}
+
+	if err := validateServices(merged); err != nil {
+		return nil, err
+	}
+	return selectServices(merged, selector)
+}
+
+// MergeServices merges a stack file into base. Only the settings which were
+// written in the file, as given by written, are changed. A map such as a
+// function, its environment or its labels is merged a key at a time, any
+// other value replaces the one in base, including lists such as secrets.
+// Functions which are new to base are declared after its own.
+func MergeServices(base, override *Services, written yaml.MapSlice) {
+	mergeValue(reflect.ValueOf(base).Elem(), reflect.ValueOf(override).Elem(), written)
+
+	declared := make(map[string]bool, len(base.order))
+	for _, name := range base.order {
+		declared[name] = true
+	}
+	for _, name := range override.order {
+		if !declared[name] {
+			base.order = append(base.order, name)
+		}
+	}
+}
+
+// mergeValue sets dst from src where written, the YAML which src was parsed
+// from, has a value
+func mergeValue(dst, src reflect.Value, written interface{}) {
+	keys, ok := written.(yaml.MapSlice)
+	if !ok {
+		dst.Set(src)
+		return
+	}
+
+	switch dst.Kind() {
+	case reflect.Ptr:
+		if dst.IsNil() || src.IsNil() {
+			dst.Set(src)
+			return
+		}
+		merged := reflect.New(dst.Type().Elem())
+		merged.Elem().Set(dst.Elem())
+		mergeValue(merged.Elem(), src.Elem(), written)
+		dst.Set(merged)
+
+	case reflect.Struct:
+		for _, item := range keys {
+			if i, ok := yamlField(dst.Type(), fmt.Sprint(item.Key)); ok {
+				mergeValue(dst.Field(i), src.Field(i), item.Value)
+			}
+		}
+
+	case reflect.Map:
+		if src.IsNil() {
+			dst.Set(src)
+			return
+		}
+		merged := reflect.MakeMap(dst.Type())
+		for _, k := range dst.MapKeys() {
+			merged.SetMapIndex(k, dst.MapIndex(k))
+		}
+		for _, item := range keys {
+			k := reflect.ValueOf(fmt.Sprint(item.Key)).Convert(dst.Type().Key())
+			value := src.MapIndex(k)
+			if !value.IsValid() {
+				continue
+			}
+			if existing := merged.MapIndex(k); existing.IsValid() {
+				copied := reflect.New(dst.Type().Elem()).Elem()
+				copied.Set(existing)
+				mergeValue(copied, value, item.Value)
+				value = copied
+			}
+			merged.SetMapIndex(k, value)
+		}
+		dst.Set(merged)
+
+	default:
+		dst.Set(src)
+	}
+}
+
+// mergeWritten merges the keys which were written in two stack files, in the
+// same way as MergeServices merges their values
+func mergeWritten(base, override yaml.MapSlice) yaml.MapSlice {
+	merged := make(yaml.MapSlice, len(base), len(base)+len(override))
+	copy(merged, base)
+
+	for _, item := range override {
+		found := false
+		for i := range merged {
+			if merged[i].Key != item.Key {
+				continue
+			}
+			found = true
+			baseKeys, baseOK := merged[i].Value.(yaml.MapSlice)
+			overrideKeys, overrideOK := item.Value.(yaml.MapSlice)
+			if baseOK && overrideOK {
+				merged[i].Value = mergeWritten(baseKeys, overrideKeys)
+			} else {
+				merged[i].Value = item.Value
+			}
+			break
+		}
+		if !found {
+			merged = append(merged, item)
+		}
+	}
+	return merged
+}
+
+// yamlField finds the field of a struct which a YAML key is read into
+func yamlField(t reflect.Type, key string) (int, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if len(name) == 0 {
+			name = strings.ToLower(field.Name)
+		}
+		if name == key {
+			return i, true
+		}
+	}
+	return 0, false
+}
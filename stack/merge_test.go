@@ -0,0 +1,155 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const mergeBaseStack = `version: 1.0
+provider:
+  name: openfaas
+  gateway: http://127.0.0.1:8080
+functions:
+  api:
+    lang: go
+    handler: ./api
+    image: alexellis/api:latest
+    environment:
+      LOG_LEVEL: debug
+      DB_HOST: db.dev
+    secrets:
+      - db-password
+  worker:
+    lang: go
+    handler: ./worker
+    image: alexellis/worker:latest
+`
+
+const mergeProdStack = `provider:
+  gateway: https://gw.example.com
+functions:
+  api:
+    image: alexellis/api:1.2.0
+    environment:
+      LOG_LEVEL: info
+    secrets:
+      - db-password-prod
+  cron:
+    lang: go
+    handler: ./cron
+    image: alexellis/cron:1.2.0
+`
+
+func writeMergeStacks(t *testing.T, stacks ...string) []string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "faas-cli-merge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	files := []string{}
+	for i, s := range stacks {
+		file := filepath.Join(dir, string(rune('a'+i))+".yml")
+		if err := ioutil.WriteFile(file, []byte(s), 0600); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+func Test_ParseYAMLFilesSelect_Merges(t *testing.T) {
+	files := writeMergeStacks(t, mergeBaseStack, mergeProdStack)
+
+	services, err := ParseYAMLFilesSelect(files, nil, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got := services.Provider.GatewayURL; got != "https://gw.example.com" {
+		t.Fatalf("want the gateway of the later file, got: %s", got)
+	}
+
+	api := services.Functions["api"]
+	if api.Image != "alexellis/api:1.2.0" || api.Language != "go" || api.Handler != "./api" {
+		t.Fatalf("want the image overridden and the rest kept, got: %+v", api)
+	}
+	wantEnv := map[string]string{"LOG_LEVEL": "info", "DB_HOST": "db.dev"}
+	if !reflect.DeepEqual(api.Environment, wantEnv) {
+		t.Fatalf("want environment %v, got %v", wantEnv, api.Environment)
+	}
+	if want := []string{"db-password-prod"}; !reflect.DeepEqual(api.Secrets, want) {
+		t.Fatalf("want the secrets replaced with %v, got %v", want, api.Secrets)
+	}
+
+	if want := []string{"api", "worker", "cron"}; !reflect.DeepEqual(services.FunctionNames(), want) {
+		t.Fatalf("want functions in order %v, got %v", want, services.FunctionNames())
+	}
+}
+
+func Test_ParseYAMLFilesSelect_SelectsAfterMerge(t *testing.T) {
+	files := writeMergeStacks(t, mergeBaseStack, mergeProdStack)
+
+	selector, err := NewSelector(SelectorFlags{Filter: "cron"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	services, err := ParseYAMLFilesSelect(files, selector, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(services.Functions) != 1 || services.Functions["cron"].Image != "alexellis/cron:1.2.0" {
+		t.Fatalf("want only cron, got: %v", services.Functions)
+	}
+}
+
+func Test_ParseYAMLFilesSelect_Envsubst(t *testing.T) {
+	os.Setenv("MERGE_TAG", "1.3.0")
+	defer os.Unsetenv("MERGE_TAG")
+
+	files := writeMergeStacks(t, mergeBaseStack, "functions:\n  api:\n    image: alexellis/api:${MERGE_TAG}\n")
+
+	services, err := ParseYAMLFilesSelect(files, nil, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := services.Functions["api"].Image; got != "alexellis/api:1.3.0" {
+		t.Fatalf("want alexellis/api:1.3.0, got: %s", got)
+	}
+}
+
+func Test_ParseYAMLFilesSelect_KeepsScalars(t *testing.T) {
+	files := writeMergeStacks(t, mergeBaseStack, "functions:\n  api:\n    environment:\n      MODE: 0755\n      RATIO: 1.50\n    skip_build: true\n")
+
+	services, err := ParseYAMLFilesSelect(files, nil, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	api := services.Functions["api"]
+	if api.Environment["MODE"] != "0755" || api.Environment["RATIO"] != "1.50" {
+		t.Fatalf("want the values as written, got: %v", api.Environment)
+	}
+	if !api.SkipBuild {
+		t.Fatalf("want skip_build from the later file")
+	}
+	if services.Version != "1.0" {
+		t.Fatalf("want version 1.0, got: %s", services.Version)
+	}
+}
+
+func Test_ParseYAMLFilesSelect_ValidatesMerged(t *testing.T) {
+	files := writeMergeStacks(t, mergeBaseStack, "provider:\n  name: faas\n")
+
+	_, err := ParseYAMLFilesSelect(files, nil, false)
+	if err == nil || !strings.Contains(err.Error(), "provider.name") {
+		t.Fatalf("want an error for the provider, got: %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// keyLinePattern splits a "key: value # comment" line of YAML into the key,
+// the quotes and value, and the rest of the line
+var keyLinePattern = regexp.MustCompile(`^(\s*)(["']?[\w-]+["']?)(:\s*)(["']?)([^"'#\s]*)(["']?)(.*)$`)
+
+// Migrate upgrades a stack file to the latest version of the schema. Only the
+// lines which change are rewritten, so comments and the order of the fields
+// are kept. The changes are returned to be shown to the user, there are none
+// when the file is already up to date.
+func Migrate(data []byte) ([]byte, []string, error) {
+	lines := strings.Split(string(data), "\n")
+	changes := []string{}
+
+	provider, end := topLevelBlock(lines, "provider")
+	if provider > -1 {
+		indent := childIndent(lines[provider+1 : end])
+		for i := provider + 1; i < end; i++ {
+			match := keyLinePattern.FindStringSubmatch(lines[i])
+			if match == nil || len(match[1]) != indent {
+				continue
+			}
+
+			switch unquote(match[2]) {
+			case "name":
+				if match[5] == legacyProviderName {
+					lines[i] = match[1] + match[2] + match[3] + match[4] + providerName + match[6] + match[7]
+					changes = append(changes, fmt.Sprintf("provider.name %s is now %s", legacyProviderName, providerName))
+				}
+			case "network":
+				// The network of the functions on Swarm is set by the provider
+				removed := 1
+				for i+removed < end && lineIndent(lines[i+removed]) > indent && !isBlankOrComment(lines[i+removed]) {
+					removed++
+				}
+				lines = append(lines[:i], lines[i+removed:]...)
+				end -= removed
+				i--
+				changes = append(changes, "removed provider.network, which is no longer used")
+			}
+		}
+	}
+
+	version, _ := topLevelBlock(lines, "version")
+	if version > -1 {
+		match := keyLinePattern.FindStringSubmatch(lines[version])
+		if match == nil {
+			return nil, nil, fmt.Errorf("unable to read the version of the stack file")
+		}
+		// A newer version is left for the validation to report
+		if !IsValidSchemaVersion(match[5]) && (len(match[5]) == 0 || olderSchemaVersion(match[5])) {
+			lines[version] = match[1] + match[2] + match[3] + defaultSchemaVersion + match[7]
+			if len(match[5]) > 0 {
+				changes = append(changes, fmt.Sprintf("version %s is now %s", match[5], defaultSchemaVersion))
+			} else {
+				changes = append(changes, fmt.Sprintf("set version to %s", defaultSchemaVersion))
+			}
+		}
+	} else {
+		first := 0
+		for first < len(lines) && (isBlankOrComment(lines[first]) || strings.HasPrefix(lines[first], "---")) {
+			first++
+		}
+		lines = append(lines[:first], append([]string{"version: " + defaultSchemaVersion}, lines[first:]...)...)
+		changes = append(changes, fmt.Sprintf("set version to %s", defaultSchemaVersion))
+	}
+
+	// Only what was migrated is validated, a file which is merged over
+	// another or included by one may not have a provider
+	migrated := []byte(strings.Join(lines, "\n"))
+	var services Services
+	if err := yaml.Unmarshal(migrated, &services); err != nil {
+		return nil, nil, err
+	}
+	if err := validateVersion(services.Version); err != nil {
+		return nil, nil, err
+	}
+	if len(services.Provider.Name) > 0 {
+		if err := validateProviderName(services.Provider.Name); err != nil {
+			return nil, nil, err
+		}
+	}
+	return migrated, changes, nil
+}
+
+// olderSchemaVersion is true for a version before the first one which is
+// valid, i.e. 0.9 or 1 which is read as 1.0
+func olderSchemaVersion(version string) bool {
+	v, err := strconv.ParseFloat(version, 64)
+	if err != nil {
+		return false
+	}
+	first, _ := strconv.ParseFloat(ValidSchemaVersions[0], 64)
+	return v <= first
+}
+
+// topLevelBlock finds the line of a key at the top of the stack file, and the
+// line after its block ends, the line is -1 when the key is not there
+func topLevelBlock(lines []string, key string) (int, int) {
+	start := -1
+	for i, line := range lines {
+		if lineIndent(line) > 0 || isBlankOrComment(line) {
+			continue
+		}
+		if start > -1 {
+			return start, i
+		}
+		if match := keyLinePattern.FindStringSubmatch(line); match != nil && unquote(match[2]) == key {
+			start = i
+		}
+	}
+	return start, len(lines)
+}
+
+// childIndent is the indent of the first key of a block
+func childIndent(lines []string) int {
+	for _, line := range lines {
+		if !isBlankOrComment(line) {
+			return lineIndent(line)
+		}
+	}
+	return 0
+}
+
+func lineIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+func isBlankOrComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return len(trimmed) == 0 || strings.HasPrefix(trimmed, "#")
+}
+
+func unquote(key string) string {
+	return strings.Trim(key, `"'`)
+}
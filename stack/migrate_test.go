@@ -0,0 +1,197 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_Migrate(t *testing.T) {
+	cases := []struct {
+		name        string
+		file        string
+		want        string
+		wantChanges []string
+	}{
+		{
+			name: "legacy stack",
+			file: `# Functions for the demo
+provider:
+  name: faas # renamed
+  gateway: http://127.0.0.1:8080
+  network: "func_functions"
+functions:
+  figlet:
+    lang: dockerfile
+    image: alexellis/figlet
+`,
+			want: `# Functions for the demo
+version: 1.0
+provider:
+  name: openfaas # renamed
+  gateway: http://127.0.0.1:8080
+functions:
+  figlet:
+    lang: dockerfile
+    image: alexellis/figlet
+`,
+			wantChanges: []string{
+				"provider.name faas is now openfaas",
+				"removed provider.network, which is no longer used",
+				"set version to 1.0",
+			},
+		},
+		{
+			name: "override file without a provider",
+			file: `version: 0.9
+functions:
+  figlet:
+    environment:
+      debug: true
+`,
+			want: `version: 1.0
+functions:
+  figlet:
+    environment:
+      debug: true
+`,
+			wantChanges: []string{"version 0.9 is now 1.0"},
+		},
+		{
+			name: "quoted provider name",
+			file: `version: 1.0
+provider:
+  name: "faas"
+  gateway: http://127.0.0.1:8080
+`,
+			want: `version: 1.0
+provider:
+  name: "openfaas"
+  gateway: http://127.0.0.1:8080
+`,
+			wantChanges: []string{"provider.name faas is now openfaas"},
+		},
+		{
+			name: "older version",
+			file: `version: "1"
+provider:
+  name: openfaas
+`,
+			want: `version: 1.0
+provider:
+  name: openfaas
+`,
+			wantChanges: []string{"version 1 is now 1.0"},
+		},
+		{
+			name: "network as a block",
+			file: `version: 1.0
+provider:
+  network:
+    name: func_functions
+  name: openfaas
+functions:
+  network:
+    image: functions/network
+`,
+			want: `version: 1.0
+provider:
+  name: openfaas
+functions:
+  network:
+    image: functions/network
+`,
+			wantChanges: []string{"removed provider.network, which is no longer used"},
+		},
+		{
+			name: "after the document marker",
+			file: `---
+provider:
+  name: openfaas
+`,
+			want: `---
+version: 1.0
+provider:
+  name: openfaas
+`,
+			wantChanges: []string{"set version to 1.0"},
+		},
+		{
+			name: "up to date",
+			file: `version: 1.0
+provider:
+  name: openfaas
+  gateway: http://127.0.0.1:8080
+`,
+			want: `version: 1.0
+provider:
+  name: openfaas
+  gateway: http://127.0.0.1:8080
+`,
+			wantChanges: []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, changes, err := Migrate([]byte(tc.file))
+			if err != nil {
+				t.Fatalf("want no error, got %s", err)
+			}
+			if string(got) != tc.want {
+				t.Fatalf("want:\n%s\ngot:\n%s", tc.want, got)
+			}
+			if !reflect.DeepEqual(tc.wantChanges, changes) {
+				t.Fatalf("want changes %q, got %q", tc.wantChanges, changes)
+			}
+		})
+	}
+}
+
+func Test_Migrate_invalid(t *testing.T) {
+	cases := []struct {
+		name    string
+		file    string
+		wantErr string
+	}{
+		{
+			name: "newer version",
+			file: `version: 1.35
+provider:
+  name: openfaas
+`,
+			wantErr: "upgrade faas-cli to read it",
+		},
+		{
+			name: "other provider",
+			file: `provider:
+  name: serverless
+`,
+			wantErr: `is the only valid "provider.name"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := Migrate([]byte(tc.file))
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("want error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_ParseYAMLData_olderSchemaVersion(t *testing.T) {
+	file := `version: 0.9
+provider:
+  name: openfaas
+`
+	_, err := ParseYAMLData([]byte(file), "", "", false)
+	want := "run faas-cli stack migrate to upgrade it"
+	if err == nil || !strings.HasSuffix(err.Error(), want) {
+		t.Fatalf("want error ending with %q, got %v", want, err)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"fmt"
+	"sort"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// UnmarshalYAML records the order in which the functions are declared, which
+// the map of functions does not keep
+func (s *Services) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Services
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	var declared struct {
+		Functions yaml.MapSlice `yaml:"functions"`
+	}
+	if err := unmarshal(&declared); err != nil {
+		return err
+	}
+
+	s.order = make([]string, 0, len(declared.Functions))
+	for _, item := range declared.Functions {
+		s.order = append(s.order, fmt.Sprint(item.Key))
+	}
+	return nil
+}
+
+// FunctionNames returns the names of the functions in the order they are
+// declared in the stack file, so that commands run and print in the same order
+// each time. Functions which were not read from a stack file are sorted by
+// name after the others.
+func (s *Services) FunctionNames() []string {
+	names := make([]string, 0, len(s.Functions))
+	seen := make(map[string]bool, len(s.Functions))
+
+	for _, name := range s.order {
+		if _, ok := s.Functions[name]; ok && !seen[name] {
+			names = append(names, name)
+			seen[name] = true
+		}
+	}
+
+	added := []string{}
+	for name := range s.Functions {
+		if !seen[name] {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+
+	return append(names, added...)
+}
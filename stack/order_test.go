@@ -0,0 +1,75 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"strings"
+	"testing"
+)
+
+const orderedStack = `provider:
+  name: openfaas
+functions:
+  zebra:
+    lang: go
+    handler: ./zebra
+    image: zebra:latest
+  apple:
+    lang: go
+    handler: ./apple
+    image: apple:latest
+  mango-api:
+    lang: go
+    handler: ./mango
+    image: mango:latest
+  123:
+    lang: go
+    handler: ./numbers
+    image: numbers:latest
+`
+
+func Test_FunctionNames_DeclarationOrder(t *testing.T) {
+	cases := []struct {
+		name   string
+		filter string
+		want   []string
+	}{
+		{name: "all functions", want: []string{"zebra", "apple", "mango-api", "123"}},
+		{name: "filtered functions", filter: "*a*", want: []string{"zebra", "apple", "mango-api"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			services, err := ParseYAMLData([]byte(orderedStack), "", tc.filter, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := services.FunctionNames()
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_FunctionNames_AddedFunctionsAreSorted(t *testing.T) {
+	services, err := ParseYAMLData([]byte(orderedStack), "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	services.Functions["kiwi"] = Function{}
+	services.Functions["banana"] = Function{}
+	delete(services.Functions, "apple")
+
+	want := []string{"zebra", "mango-api", "123", "banana", "kiwi"}
+	if got := services.FunctionNames(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	unparsed := Services{Functions: map[string]Function{"b": {}, "a": {}}}
+	if got := unparsed.FunctionNames(); strings.Join(got, ",") != "a,b" {
+		t.Errorf("want the functions sorted by name, got %v", got)
+	}
+}
@@ -0,0 +1,10 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+// Prebuilt is true for a function which only gives an image, i.e. one from the
+// function store, it is deployed as it is and never built or pushed
+func (f Function) Prebuilt() bool {
+	return len(f.Image) > 0 && len(f.Language) == 0 && len(f.Handler) == 0
+}
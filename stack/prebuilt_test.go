@@ -0,0 +1,28 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import "testing"
+
+func Test_FunctionPrebuilt(t *testing.T) {
+	cases := []struct {
+		name     string
+		function Function
+		want     bool
+	}{
+		{name: "image only", function: Function{Image: "ghcr.io/openfaas/figlet:latest"}, want: true},
+		{name: "language and handler", function: Function{Image: "alexellis/echo:latest", Language: "go", Handler: "./echo"}, want: false},
+		{name: "handler without a language", function: Function{Image: "alexellis/echo:latest", Handler: "./echo"}, want: false},
+		{name: "language without a handler", function: Function{Image: "alexellis/echo:latest", Language: "go"}, want: false},
+		{name: "no image", function: Function{}, want: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.function.Prebuilt(); got != tc.want {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
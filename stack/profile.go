@@ -0,0 +1,168 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Labels read by the providers for the replicas of a function
+const (
+	scaleMinLabel = "com.openfaas.scale.min"
+	scaleMaxLabel = "com.openfaas.scale.max"
+)
+
+// EnvironmentProfile overrides parts of a stack for one environment, i.e. dev,
+// stage or prod, so that one stack file can be deployed to each of them
+type EnvironmentProfile struct {
+	// Gateway replaces provider.gateway
+	Gateway string `yaml:"gateway,omitempty"`
+
+	// ImageTag replaces the tag of the image of every function, i.e. 1.2.0
+	ImageTag string `yaml:"image_tag,omitempty"`
+
+	// Environment is merged over the environment of every function
+	Environment map[string]string `yaml:"environment,omitempty"`
+
+	// Replicas sets the minimum and maximum replicas of every function
+	Replicas *ProfileReplicas `yaml:"replicas,omitempty"`
+
+	// Functions override single functions, after the settings above
+	Functions map[string]FunctionProfile `yaml:"functions,omitempty"`
+}
+
+// FunctionProfile overrides one function in an EnvironmentProfile
+type FunctionProfile struct {
+	// Image replaces the image of the function
+	Image string `yaml:"image,omitempty"`
+
+	// ImageTag replaces the tag of the image of the function
+	ImageTag string `yaml:"image_tag,omitempty"`
+
+	// Environment is merged over the environment of the function
+	Environment map[string]string `yaml:"environment,omitempty"`
+
+	// Replicas sets the minimum and maximum replicas of the function
+	Replicas *ProfileReplicas `yaml:"replicas,omitempty"`
+}
+
+// ProfileReplicas are set as the com.openfaas.scale.min and max labels
+type ProfileReplicas struct {
+	Min *int `yaml:"min,omitempty"`
+	Max *int `yaml:"max,omitempty"`
+}
+
+// ApplyProfile overrides the stack with one of its environment profiles
+func (s *Services) ApplyProfile(name string) error {
+	profile, ok := s.Environments[name]
+	if !ok {
+		names := make([]string, 0, len(s.Environments))
+		for n := range s.Environments {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+
+		if len(names) == 0 {
+			return fmt.Errorf("environment profile %q was not found, the stack file has no environments", name)
+		}
+		return fmt.Errorf("environment profile %q was not found, use one of: %s", name, strings.Join(names, ", "))
+	}
+
+	for fn := range profile.Functions {
+		if _, ok := s.Functions[fn]; !ok && !s.declared(fn) {
+			return fmt.Errorf("environment profile %q overrides function %q, which is not in the stack file", name, fn)
+		}
+	}
+
+	if len(profile.Gateway) > 0 {
+		s.Provider.GatewayURL = profile.Gateway
+	}
+
+	for fn, function := range s.Functions {
+		if len(profile.ImageTag) > 0 {
+			function.Image = withImageTag(function.Image, profile.ImageTag)
+		}
+		function.Environment = mergeProfileEnvironment(function.Environment, profile.Environment)
+		function.Labels = withReplicas(function.Labels, profile.Replicas)
+
+		if override, ok := profile.Functions[fn]; ok {
+			if len(override.Image) > 0 {
+				function.Image = override.Image
+			}
+			if len(override.ImageTag) > 0 {
+				function.Image = withImageTag(function.Image, override.ImageTag)
+			}
+			function.Environment = mergeProfileEnvironment(function.Environment, override.Environment)
+			function.Labels = withReplicas(function.Labels, override.Replicas)
+		}
+
+		s.Functions[fn] = function
+	}
+
+	return nil
+}
+
+// declared is true when a function is in the stack file, although it may
+// have been left out by a selector
+func (s *Services) declared(name string) bool {
+	for _, n := range s.order {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func mergeProfileEnvironment(environment, overrides map[string]string) map[string]string {
+	if len(overrides) == 0 {
+		return environment
+	}
+
+	merged := make(map[string]string, len(environment)+len(overrides))
+	for k, v := range environment {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
+
+func withReplicas(labels *map[string]string, replicas *ProfileReplicas) *map[string]string {
+	if replicas == nil || (replicas.Min == nil && replicas.Max == nil) {
+		return labels
+	}
+
+	merged := map[string]string{}
+	if labels != nil {
+		for k, v := range *labels {
+			merged[k] = v
+		}
+	}
+	if replicas.Min != nil {
+		merged[scaleMinLabel] = strconv.Itoa(*replicas.Min)
+	}
+	if replicas.Max != nil {
+		merged[scaleMaxLabel] = strconv.Itoa(*replicas.Max)
+	}
+	return &merged
+}
+
+// withImageTag replaces the tag of an image, an image pinned to a digest is
+// left as it is
+func withImageTag(image, tag string) string {
+	if strings.Contains(image, "@") {
+		return image
+	}
+
+	// A colon before the last slash is the port of a registry
+	name := image
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		name = image[:i]
+	}
+	return name + ":" + tag
+}
@@ -0,0 +1,154 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"reflect"
+	"testing"
+)
+
+const profileStack = `provider:
+  name: openfaas
+  gateway: http://127.0.0.1:8080
+functions:
+  api:
+    lang: go
+    handler: ./api
+    image: ghcr.io/alexellis/api:latest
+    environment:
+      log_level: debug
+      db_host: localhost
+    labels:
+      team: payments
+  worker:
+    lang: go
+    handler: ./worker
+    image: localhost:5000/worker
+environments:
+  prod:
+    gateway: https://gw.example.com
+    image_tag: 1.2.0
+    environment:
+      log_level: info
+    replicas:
+      min: 2
+      max: 10
+    functions:
+      api:
+        environment:
+          db_host: db.prod.internal
+        replicas:
+          max: 20
+      worker:
+        image: ghcr.io/alexellis/worker-prod
+        image_tag: 1.1.0
+  dev: {}
+`
+
+func Test_ApplyProfile(t *testing.T) {
+	services, err := ParseYAMLData([]byte(profileStack), "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := services.ApplyProfile("prod"); err != nil {
+		t.Fatal(err)
+	}
+
+	if services.Provider.GatewayURL != "https://gw.example.com" {
+		t.Errorf("want the gateway of the profile, got %s", services.Provider.GatewayURL)
+	}
+
+	api := services.Functions["api"]
+	if api.Image != "ghcr.io/alexellis/api:1.2.0" {
+		t.Errorf("want the image tag of the profile, got %s", api.Image)
+	}
+	wantEnvironment := map[string]string{"log_level": "info", "db_host": "db.prod.internal"}
+	if !reflect.DeepEqual(api.Environment, wantEnvironment) {
+		t.Errorf("want environment %v, got %v", wantEnvironment, api.Environment)
+	}
+	wantLabels := map[string]string{"team": "payments", "com.openfaas.scale.min": "2", "com.openfaas.scale.max": "20"}
+	if api.Labels == nil || !reflect.DeepEqual(*api.Labels, wantLabels) {
+		t.Errorf("want labels %v, got %v", wantLabels, api.Labels)
+	}
+
+	worker := services.Functions["worker"]
+	if worker.Image != "ghcr.io/alexellis/worker-prod:1.1.0" {
+		t.Errorf("want the image of the function's profile, got %s", worker.Image)
+	}
+	if worker.Environment["log_level"] != "info" {
+		t.Errorf("want the environment of the profile, got %v", worker.Environment)
+	}
+
+	// The cached stack is not changed by a profile
+	again, err := ParseYAMLData([]byte(profileStack), "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Functions["api"].Image != "ghcr.io/alexellis/api:latest" || again.Functions["api"].Environment["log_level"] != "debug" {
+		t.Errorf("want the parsed stack to be unchanged, got %v", again.Functions["api"])
+	}
+}
+
+func Test_ApplyProfile_Errors(t *testing.T) {
+	cases := []struct {
+		name    string
+		stack   string
+		profile string
+		want    string
+	}{
+		{name: "unknown profile", stack: profileStack, profile: "stage", want: `environment profile "stage" was not found, use one of: dev, prod`},
+		{name: "no environments", stack: "provider:\n  name: openfaas\n", profile: "prod", want: `environment profile "prod" was not found, the stack file has no environments`},
+		{name: "unknown function", stack: "provider:\n  name: openfaas\nenvironments:\n  prod:\n    functions:\n      missing:\n        image_tag: 1.0.0\n", profile: "prod", want: `environment profile "prod" overrides function "missing", which is not in the stack file`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			services, err := ParseYAMLData([]byte(tc.stack), "", "", false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = services.ApplyProfile(tc.profile)
+			if err == nil || err.Error() != tc.want {
+				t.Errorf("want error %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func Test_ApplyProfile_SelectedFunctions(t *testing.T) {
+	services, err := ParseYAMLData([]byte(profileStack), "", "api", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// worker is overridden by the profile but left out by --filter
+	if err := services.ApplyProfile("prod"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := services.Functions["worker"]; ok {
+		t.Errorf("want worker to stay left out")
+	}
+}
+
+func Test_withImageTag(t *testing.T) {
+	cases := []struct {
+		image string
+		want  string
+	}{
+		{image: "alexellis/api:latest", want: "alexellis/api:1.2.0"},
+		{image: "alexellis/api", want: "alexellis/api:1.2.0"},
+		{image: "localhost:5000/api", want: "localhost:5000/api:1.2.0"},
+		{image: "localhost:5000/api:0.1", want: "localhost:5000/api:1.2.0"},
+		{image: "alexellis/api@sha256:4f8e", want: "alexellis/api@sha256:4f8e"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.image, func(t *testing.T) {
+			if got := withImageTag(tc.image, "1.2.0"); got != tc.want {
+				t.Errorf("want %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ProviderExtension holds the settings for one provider under
+// provider.extensions, they are kept as they were written so that a new
+// setting does not need a change to the schema
+type ProviderExtension map[string]interface{}
+
+// extensionNames are the other names which the extensions for an
+// orchestration can be given under, faasd reports containerd
+var extensionNames = map[string][]string{
+	"containerd": {"faasd"},
+}
+
+// Extension returns the settings for the orchestration which the gateway
+// reports, or nil when there are none
+func (p Provider) Extension(orchestration string) ProviderExtension {
+	if extension, ok := p.Extensions[orchestration]; ok {
+		return extension
+	}
+	for _, name := range extensionNames[orchestration] {
+		if extension, ok := p.Extensions[name]; ok {
+			return extension
+		}
+	}
+	return nil
+}
+
+// String returns a setting which is a string, or an error when it has
+// another type
+func (e ProviderExtension) String(key string) (string, error) {
+	value, ok := e[key]
+	if !ok || value == nil {
+		return "", nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("provider extension %s must be a string, got: %v", key, value)
+	}
+	return s, nil
+}
+
+// StringMap returns a setting which is a map of strings, i.e. node
+// selectors, or an error when it has another type
+func (e ProviderExtension) StringMap(key string) (map[string]string, error) {
+	value, ok := e[key]
+	if !ok || value == nil {
+		return nil, nil
+	}
+
+	values := map[string]string{}
+	switch m := value.(type) {
+	case map[interface{}]interface{}:
+		for k, v := range m {
+			ks, kok := k.(string)
+			vs, vok := v.(string)
+			if !kok || !vok {
+				return nil, fmt.Errorf("provider extension %s must be a map of strings, got: %v: %v", key, k, v)
+			}
+			values[ks] = vs
+		}
+	case map[string]interface{}:
+		for k, v := range m {
+			vs, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("provider extension %s must be a map of strings, got: %v: %v", key, k, v)
+			}
+			values[k] = vs
+		}
+	case map[string]string:
+		for k, v := range m {
+			values[k] = v
+		}
+	default:
+		return nil, fmt.Errorf("provider extension %s must be a map of strings, got: %v", key, value)
+	}
+	return values, nil
+}
+
+// Keys returns the names of the settings, sorted
+func (e ProviderExtension) Keys() []string {
+	keys := make([]string, 0, len(e))
+	for k := range e {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
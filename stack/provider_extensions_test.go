@@ -0,0 +1,88 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"reflect"
+	"testing"
+)
+
+const extensionsStack = `version: 1.0
+provider:
+  name: openfaas
+  gateway: http://127.0.0.1:8080
+  extensions:
+    kubernetes:
+      serviceAccount: fn-runner
+      nodeSelector:
+        disktype: ssd
+    faasd:
+      socket: /run/faasd/faasd.sock
+functions:
+  fn1:
+    lang: python3
+    handler: ./fn1
+    image: fn1:latest
+`
+
+func Test_ParseYAMLData_ProviderExtensions(t *testing.T) {
+	services, err := ParseYAMLData([]byte(extensionsStack), "", "", false)
+	if err != nil {
+		t.Fatalf("want no error, got %s", err)
+	}
+
+	kubernetes := services.Provider.Extension("kubernetes")
+	serviceAccount, err := kubernetes.String("serviceAccount")
+	if err != nil {
+		t.Fatalf("want no error, got %s", err)
+	}
+	if serviceAccount != "fn-runner" {
+		t.Fatalf("want fn-runner, got %q", serviceAccount)
+	}
+
+	nodeSelector, err := kubernetes.StringMap("nodeSelector")
+	if err != nil {
+		t.Fatalf("want no error, got %s", err)
+	}
+	if want := map[string]string{"disktype": "ssd"}; !reflect.DeepEqual(nodeSelector, want) {
+		t.Fatalf("want %v, got %v", want, nodeSelector)
+	}
+
+	socket, err := services.Provider.Extension("containerd").String("socket")
+	if err != nil {
+		t.Fatalf("want no error, got %s", err)
+	}
+	if socket != "/run/faasd/faasd.sock" {
+		t.Fatalf("want the faasd extensions for containerd, got %q", socket)
+	}
+
+	if extension := services.Provider.Extension("swarm"); extension != nil {
+		t.Fatalf("want no extensions for swarm, got %v", extension)
+	}
+}
+
+func Test_ProviderExtension_types(t *testing.T) {
+	extension := ProviderExtension{
+		"replicas":     3,
+		"nodeSelector": "ssd",
+		"labels":       map[interface{}]interface{}{"tier": 1},
+	}
+
+	if _, err := extension.String("replicas"); err == nil {
+		t.Errorf("want an error for a number read as a string")
+	}
+	if _, err := extension.StringMap("nodeSelector"); err == nil {
+		t.Errorf("want an error for a string read as a map")
+	}
+	if _, err := extension.StringMap("labels"); err == nil {
+		t.Errorf("want an error for a map with a number value")
+	}
+
+	if value, err := extension.String("missing"); err != nil || value != "" {
+		t.Errorf("want an empty value for a missing setting, got %q, %v", value, err)
+	}
+	if want := []string{"labels", "nodeSelector", "replicas"}; !reflect.DeepEqual(extension.Keys(), want) {
+		t.Errorf("want %v, got %v", want, extension.Keys())
+	}
+}
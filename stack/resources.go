@@ -0,0 +1,159 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// quantityPattern splits a Kubernetes quantity into its number, a decimal
+// exponent such as e3 and its suffix
+var quantityPattern = regexp.MustCompile(`^\+?([0-9]+(?:\.[0-9]*)?|\.[0-9]+)(?:[eE]([+-]?[0-9]+)|([a-zA-Z]*))$`)
+
+// memoryUnits are the suffixes Kubernetes accepts for memory quantities, and
+// m which Swarm stack files use for megabytes
+var memoryUnits = map[string]int64{
+	"":   1,
+	"k":  1000,
+	"M":  1000 * 1000,
+	"G":  1000 * 1000 * 1000,
+	"T":  1000 * 1000 * 1000 * 1000,
+	"P":  1000 * 1000 * 1000 * 1000 * 1000,
+	"E":  1000 * 1000 * 1000 * 1000 * 1000 * 1000,
+	"Ki": 1 << 10,
+	"Mi": 1 << 20,
+	"Gi": 1 << 30,
+	"Ti": 1 << 40,
+	"Pi": 1 << 50,
+	"Ei": 1 << 60,
+	"m":  1 << 20,
+}
+
+// parseQuantity returns the value of a quantity with its exponent applied,
+// and its suffix
+func parseQuantity(quantity string) (*big.Float, string, bool) {
+	match := quantityPattern.FindStringSubmatch(strings.TrimSpace(quantity))
+	if match == nil {
+		return nil, "", false
+	}
+
+	value, ok := new(big.Float).SetString(match[1])
+	if !ok {
+		return nil, "", false
+	}
+
+	if len(match[2]) > 0 {
+		exponent, err := strconv.Atoi(match[2])
+		if err != nil || exponent > 18 || exponent < -18 {
+			return nil, "", false
+		}
+		scale := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(exponent))), nil))
+		if exponent < 0 {
+			value.Quo(value, scale)
+		} else {
+			value.Mul(value, scale)
+		}
+	}
+	return value, match[3], true
+}
+
+// ParseMemory parses a memory quantity such as 128Mi, 1Gi, 500M or 1e9 into
+// bytes. The m of Swarm stack files, i.e. 128m, is read as megabytes.
+func ParseMemory(quantity string) (int64, error) {
+	value, suffix, ok := parseQuantity(quantity)
+	if !ok {
+		return 0, fmt.Errorf("invalid memory quantity %q, use a value such as 128Mi or 1Gi", quantity)
+	}
+
+	unit, ok := memoryUnits[suffix]
+	if !ok {
+		return 0, fmt.Errorf("invalid memory unit %q in %q, use one of Ki, Mi, Gi, Ti, Pi, Ei, k, M, G, T, P or E", suffix, quantity)
+	}
+
+	value.Mul(value, new(big.Float).SetInt64(unit))
+	if value.Cmp(new(big.Float).SetInt64(math.MaxInt64)) > 0 {
+		return 0, fmt.Errorf("invalid memory quantity %q, it is too large", quantity)
+	}
+	bytes, _ := value.Int64()
+	return bytes, nil
+}
+
+// ParseCPU parses a CPU quantity such as 100m, 0.5 or 2 into millicores
+func ParseCPU(quantity string) (int64, error) {
+	value, suffix, ok := parseQuantity(quantity)
+	if !ok || (suffix != "" && suffix != "m") {
+		return 0, fmt.Errorf("invalid CPU quantity %q, use cores such as 0.5 or millicores such as 500m", quantity)
+	}
+
+	if suffix == "" {
+		value.Mul(value, big.NewFloat(1000))
+	}
+
+	millicores, accuracy := value.Int64()
+	if accuracy != big.Exact {
+		return 0, fmt.Errorf("invalid CPU quantity %q, the smallest unit is 1m", quantity)
+	}
+	return millicores, nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// ValidateResources checks the units of a function's limits and requests and
+// returns a warning for each request which is above its limit
+func ValidateResources(limits, requests *FunctionResources) ([]string, error) {
+	warnings := []string{}
+
+	memory := map[string]int64{}
+	cpu := map[string]int64{}
+
+	for _, kind := range []string{"limits", "requests"} {
+		resources := limits
+		if kind == "requests" {
+			resources = requests
+		}
+		if resources == nil {
+			continue
+		}
+
+		if len(resources.Memory) > 0 {
+			bytes, err := ParseMemory(resources.Memory)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", kind, err)
+			}
+			memory[kind] = bytes
+		}
+
+		if len(resources.CPU) > 0 {
+			millicores, err := ParseCPU(resources.CPU)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", kind, err)
+			}
+			cpu[kind] = millicores
+		}
+	}
+
+	if limit, ok := memory["limits"]; ok {
+		if request, ok := memory["requests"]; ok && request > limit {
+			warnings = append(warnings, fmt.Sprintf("the memory request %s is more than the limit %s", requests.Memory, limits.Memory))
+		}
+	}
+
+	if limit, ok := cpu["limits"]; ok {
+		if request, ok := cpu["requests"]; ok && request > limit {
+			warnings = append(warnings, fmt.Sprintf("the CPU request %s is more than the limit %s", requests.CPU, limits.CPU))
+		}
+	}
+
+	return warnings, nil
+}
@@ -0,0 +1,106 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"testing"
+)
+
+func Test_ParseMemory(t *testing.T) {
+	cases := []struct {
+		quantity string
+		want     int64
+		wantErr  bool
+	}{
+		{quantity: "128Mi", want: 128 * 1024 * 1024},
+		{quantity: "1Gi", want: 1024 * 1024 * 1024},
+		{quantity: "1.5Gi", want: 1536 * 1024 * 1024},
+		{quantity: "500M", want: 500 * 1000 * 1000},
+		{quantity: "1048576", want: 1048576},
+		{quantity: "128m", want: 128 * 1024 * 1024},
+		{quantity: "1Pi", want: 1 << 50},
+		{quantity: "2Ei", want: 2 << 60},
+		{quantity: "1P", want: 1000 * 1000 * 1000 * 1000 * 1000},
+		{quantity: "1E", want: 1000 * 1000 * 1000 * 1000 * 1000 * 1000},
+		{quantity: "1e9", want: 1000 * 1000 * 1000},
+		{quantity: "+1Ki", want: 1024},
+		{quantity: "1e-1", want: 0},
+		{quantity: "8Ei", wantErr: true},
+		{quantity: "128MB", wantErr: true},
+		{quantity: "lots", wantErr: true},
+		{quantity: "-1Gi", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.quantity, func(t *testing.T) {
+			got, err := ParseMemory(c.quantity)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("want error, but got %d", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Fatalf("want %d bytes, but got %d", c.want, got)
+			}
+		})
+	}
+}
+
+func Test_ParseCPU(t *testing.T) {
+	cases := []struct {
+		quantity string
+		want     int64
+		wantErr  bool
+	}{
+		{quantity: "100m", want: 100},
+		{quantity: "0.5", want: 500},
+		{quantity: "2", want: 2000},
+		{quantity: "0.0001", wantErr: true},
+		{quantity: "100Mi", wantErr: true},
+		{quantity: "half", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.quantity, func(t *testing.T) {
+			got, err := ParseCPU(c.quantity)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("want error, but got %d", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Fatalf("want %d millicores, but got %d", c.want, got)
+			}
+		})
+	}
+}
+
+func Test_ValidateResources(t *testing.T) {
+	warnings, err := ValidateResources(
+		&FunctionResources{Memory: "128Mi", CPU: "100m"},
+		&FunctionResources{Memory: "256Mi", CPU: "0.1"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("want a warning for the memory request over its limit, but got: %v", warnings)
+	}
+
+	if _, err := ValidateResources(nil, &FunctionResources{Memory: "64MB"}); err == nil {
+		t.Fatalf("want error for an invalid memory request, but got nil")
+	}
+
+	if warnings, err := ValidateResources(nil, nil); err != nil || len(warnings) != 0 {
+		t.Fatalf("want no warnings or error without resources, but got: %v %v", warnings, err)
+	}
+}
@@ -7,6 +7,10 @@ package stack
 type Provider struct {
 	Name       string `yaml:"name"`
 	GatewayURL string `yaml:"gateway"`
+
+	// Extensions are settings which only one provider reads, keyed by the
+	// orchestration which the gateway reports, i.e. kubernetes or faasd
+	Extensions map[string]ProviderExtension `yaml:"extensions,omitempty"`
 }
 
 // Function as deployed or built on FaaS
@@ -22,21 +26,44 @@ type Function struct {
 	// Image Docker image name
 	Image string `yaml:"image"`
 
-	FProcess string `yaml:"fprocess"`
+	FProcess string `yaml:"fprocess,omitempty"`
 
-	Environment map[string]string `yaml:"environment"`
+	Environment map[string]string `yaml:"environment,omitempty"`
 
 	// Secrets list of secrets to be made available to function
 	Secrets []string `yaml:"secrets,omitempty"`
 
+	// SecretHints say how the function reads each secret, as a file or an
+	// environment variable, for providers which can translate them
+	SecretHints map[string]FunctionSecretHint `yaml:"secret_hints,omitempty"`
+
 	SkipBuild bool `yaml:"skip_build,omitempty"`
 
+	// SkipPush leaves the function out of push, i.e. to build it for local
+	// testing only
+	SkipPush bool `yaml:"skip_push,omitempty"`
+
+	// SkipDeploy leaves the function out of deploy
+	SkipDeploy bool `yaml:"skip_deploy,omitempty"`
+
+	// Tags group functions, i.e. frontend or critical, so that a group can be
+	// selected with --tags
+	Tags []string `yaml:"tags,omitempty"`
+
+	// Test is the command which runs the unit tests of the handler from its
+	// folder, i.e. go test ./..., it is run by faas-cli test
+	Test string `yaml:"test,omitempty"`
+
 	Constraints *[]string `yaml:"constraints,omitempty"`
 
 	// EnvironmentFile is a list of files to import and override environmental variables.
 	// These are overriden in order.
 	EnvironmentFile []string `yaml:"environment_file,omitempty"`
 
+	// EnvFile is a file in the dotenv format used by docker compose, its values
+	// override those from EnvironmentFile
+	EnvFile string `yaml:"env_file,omitempty"`
+
 	Labels *map[string]string `yaml:"labels,omitempty"`
 
 	// Limits for function
@@ -62,6 +89,70 @@ type Function struct {
 
 	// Platforms for use with buildx and faas-cli publish
 	Platforms string `yaml:"platforms,omitempty"`
+
+	// CacheFrom external cache sources for the build, i.e. type=registry,ref=user/app:cache
+	CacheFrom []string `yaml:"cache_from,omitempty"`
+
+	// CacheTo cache export destinations for the build, these require Docker buildx
+	CacheTo []string `yaml:"cache_to,omitempty"`
+
+	// Aliases custom domains or paths used to expose the function
+	Aliases []FunctionAlias `yaml:"aliases,omitempty"`
+
+	// OpenAPI hints used by faas-cli generate openapi
+	OpenAPI *FunctionOpenAPI `yaml:"openapi,omitempty"`
+
+	// Watchdog sets the mode and timeouts of the watchdog without magic
+	// environment variables
+	Watchdog *FunctionWatchdog `yaml:"watchdog,omitempty"`
+
+	// K8s sets the service account and node selector of the function when
+	// it is deployed to Kubernetes
+	K8s *KubernetesOptions `yaml:"k8s,omitempty"`
+}
+
+// FunctionOpenAPI describes the HTTP API of a function for the OpenAPI document
+type FunctionOpenAPI struct {
+	Summary     string `yaml:"summary,omitempty"`
+	Description string `yaml:"description,omitempty"`
+
+	// Methods accepted by the function, defaults to POST
+	Methods []string `yaml:"methods,omitempty"`
+
+	// ContentType of the request and response, defaults to "application/json"
+	ContentType string `yaml:"content_type,omitempty"`
+
+	// RequestSchema JSON Schema of the request body
+	RequestSchema map[string]interface{} `yaml:"request_schema,omitempty"`
+
+	// ResponseSchema JSON Schema of the response body
+	ResponseSchema map[string]interface{} `yaml:"response_schema,omitempty"`
+}
+
+// FunctionAlias a custom domain and optional path for a function, which is
+// mapped to a FunctionIngress by the ingress-operator
+type FunctionAlias struct {
+	Domain string `yaml:"domain"`
+
+	// Path to match on the domain, defaults to "/"
+	Path string `yaml:"path,omitempty"`
+
+	// IngressType such as "nginx" or "traefik"
+	IngressType string `yaml:"ingress_type,omitempty"`
+
+	// TLS settings for the domain
+	TLS *FunctionAliasTLS `yaml:"tls,omitempty"`
+}
+
+// FunctionAliasTLS TLS settings for a FunctionAlias
+type FunctionAliasTLS struct {
+	Enabled bool `yaml:"enabled"`
+
+	// IssuerName of the cert-manager Issuer
+	IssuerName string `yaml:"issuer_name,omitempty"`
+
+	// IssuerKind either "Issuer" or "ClusterIssuer"
+	IssuerKind string `yaml:"issuer_kind,omitempty"`
 }
 
 // Configuration for the stack.yml file
@@ -71,7 +162,7 @@ type Configuration struct {
 
 // StackConfiguration for the overall stack.yml
 type StackConfiguration struct {
-	TemplateConfigs []TemplateSource `yaml:"templates"`
+	TemplateConfigs []TemplateSource `yaml:"templates,omitempty"`
 
 	// CopyExtraPaths specifies additional paths (relative to the stack file) that will be copied
 	// into the functions build context, e.g. specifying `"common"` will look for and copy the
@@ -79,7 +170,7 @@ type StackConfiguration struct {
 	// within the project root defined by the location of the stack file.
 	//
 	// The yaml uses the shorter name `copy` to make it easier for developers to read and use
-	CopyExtraPaths []string `yaml:"copy"`
+	CopyExtraPaths []string `yaml:"copy,omitempty"`
 }
 
 // TemplateSource for build templates
@@ -105,6 +196,17 @@ type Services struct {
 	Functions          map[string]Function `yaml:"functions,omitempty"`
 	Provider           Provider            `yaml:"provider,omitempty"`
 	StackConfiguration StackConfiguration  `yaml:"configuration,omitempty"`
+
+	// Environments are profiles which override the stack, selected with
+	// --env-profile
+	Environments map[string]EnvironmentProfile `yaml:"environments,omitempty"`
+
+	// Includes are stack files, local or remote, whose functions and other
+	// settings are merged under those of this file
+	Includes []string `yaml:"includes,omitempty"`
+
+	// order of the functions as they were declared in the stack file
+	order []string
 }
 
 // LanguageTemplate read from template.yml within root of a language template folder
@@ -117,6 +219,24 @@ type LanguageTemplate struct {
 	WelcomeMessage string `yaml:"welcome_message,omitempty"`
 	// HandlerFolder to copy the function code into
 	HandlerFolder string `yaml:"handler_folder,omitempty"`
+	// Entrypoint is the file in the handler folder which the template's
+	// Dockerfile expects to find, i.e. handler.py
+	Entrypoint string `yaml:"entrypoint,omitempty"`
+	// Watchdog modes supported by the template
+	Watchdog *TemplateWatchdog `yaml:"watchdog,omitempty"`
+	// Test is how the unit tests of a handler created from the template run
+	Test *TemplateTest `yaml:"test,omitempty"`
+	// Versions of the runtime which a function can pin with lang: template@version
+	Versions map[string]TemplateVersion `yaml:"versions,omitempty"`
+}
+
+// TemplateTest is written to stack.yml by faas-cli new, so that a new
+// function can be tested with faas-cli test
+type TemplateTest struct {
+	// Command runs the tests from the handler folder
+	Command string `yaml:"command"`
+	// Folder in the template with sample tests to copy into the handler
+	Folder string `yaml:"folder,omitempty"`
 }
 
 // BuildOption a named build option for one or more packages
@@ -0,0 +1,94 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// SecretHintFile mounts a secret as a file, the default
+	SecretHintFile = "file"
+
+	// SecretHintEnv exposes a secret as an environment variable
+	SecretHintEnv = "env"
+
+	// SecretsMountPath is where OpenFaaS providers mount secrets as files
+	SecretsMountPath = "/var/openfaas/secrets/"
+)
+
+var (
+	secretEnvName  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	secretFileName = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+)
+
+// FunctionSecretHint describes how a function expects to read a secret, so
+// that it can be given to the function in the same way on each provider
+type FunctionSecretHint struct {
+	// Type is "file" or "env"
+	Type string `yaml:"type,omitempty"`
+
+	// Target is the file name under /var/openfaas/secrets/ or the name of
+	// the environment variable, it defaults to the name of the secret
+	Target string `yaml:"target,omitempty"`
+}
+
+// IsEnv is true when the secret is to be read from an environment variable
+func (h FunctionSecretHint) IsEnv() bool {
+	return h.Type == SecretHintEnv
+}
+
+// TargetFor returns the file name or environment variable for a secret
+func (h FunctionSecretHint) TargetFor(secret string) string {
+	if len(h.Target) > 0 {
+		return h.Target
+	}
+	return secret
+}
+
+// ValidateSecretHints checks that each hint is for one of the function's
+// secrets and has a valid type and target
+func ValidateSecretHints(secrets []string, hints map[string]FunctionSecretHint) error {
+	names := make([]string, 0, len(hints))
+	for name := range hints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !hasSecret(secrets, name) {
+			return fmt.Errorf("secret_hints has a hint for %s, but it is not in secrets", name)
+		}
+
+		hint := hints[name]
+		target := hint.TargetFor(name)
+
+		switch hint.Type {
+		case "", SecretHintFile:
+			if !secretFileName.MatchString(target) || target == "." || target == ".." {
+				return fmt.Errorf("secret_hints for %s: invalid file name %q, it must not contain a path", name, target)
+			}
+		case SecretHintEnv:
+			if !secretEnvName.MatchString(target) {
+				return fmt.Errorf("secret_hints for %s: invalid environment variable name %q", name, target)
+			}
+		default:
+			return fmt.Errorf("secret_hints for %s: invalid type %q, use %s", name, hint.Type, strings.Join([]string{SecretHintFile, SecretHintEnv}, " or "))
+		}
+	}
+	return nil
+}
+
+// hasSecret matches a secret by name, or by the key of a vault:PATH#KEY reference
+func hasSecret(secrets []string, name string) bool {
+	for _, secret := range secrets {
+		if secret == name || strings.HasSuffix(secret, "#"+name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_ValidateSecretHints(t *testing.T) {
+	secrets := []string{"api-key", "vault:kv/data/app#token"}
+
+	cases := []struct {
+		name    string
+		hints   map[string]FunctionSecretHint
+		wantErr string
+	}{
+		{
+			name: "valid hints",
+			hints: map[string]FunctionSecretHint{
+				"api-key": {Type: SecretHintEnv, Target: "API_KEY"},
+				"token":   {Target: "token.txt"},
+			},
+		},
+		{
+			name:    "secret not listed",
+			hints:   map[string]FunctionSecretHint{"db-password": {Type: SecretHintEnv}},
+			wantErr: "secret_hints has a hint for db-password, but it is not in secrets",
+		},
+		{
+			name:    "unknown type",
+			hints:   map[string]FunctionSecretHint{"api-key": {Type: "volume"}},
+			wantErr: `invalid type "volume", use file or env`,
+		},
+		{
+			name:    "invalid environment variable",
+			hints:   map[string]FunctionSecretHint{"api-key": {Type: SecretHintEnv}},
+			wantErr: `invalid environment variable name "api-key"`,
+		},
+		{
+			name:    "file target with a path",
+			hints:   map[string]FunctionSecretHint{"api-key": {Target: "../api-key"}},
+			wantErr: `invalid file name "../api-key"`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateSecretHints(secrets, c.hints)
+			if len(c.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("want no error, but got %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Fatalf("want error %q, but got %v", c.wantErr, err)
+			}
+		})
+	}
+}
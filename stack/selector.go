@@ -0,0 +1,179 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	glob "github.com/ryanuber/go-glob"
+)
+
+// Keys which a selector term can match on
+const (
+	selectorName = "name"
+	selectorTag  = "tag"
+)
+
+// Selector picks functions from a stack file by their name and tags. Every
+// term must match for a function to be selected.
+//
+// A selector expression is a comma separated list of terms:
+//
+//	name=PATTERN   the name matches the wildcard, as with --filter
+//	name~REGEX     the name matches the regular expression, as with --regex
+//	tag=TAG        the function has the tag
+//	tag~REGEX      the function has a tag which matches the regular expression
+//
+// A term starting with ! excludes the functions which it matches, i.e.
+// "name~api, !name~legacy, tag=critical".
+type Selector struct {
+	terms []selectorTerm
+}
+
+type selectorTerm struct {
+	key        string
+	negate     bool
+	pattern    string
+	regex      *regexp.Regexp
+	ignoreCase bool
+}
+
+// SelectorFlags are the flags which select functions from a stack file, any
+// of which may be empty
+type SelectorFlags struct {
+	// Regex matches function names, anywhere in the name unless RegexExact
+	// is set
+	Regex string
+
+	// RegexExact only matches a Regex against the whole name, so that fn1
+	// does not match fn10
+	RegexExact bool
+
+	// Filter matches function names with a wildcard
+	Filter string
+
+	// FilterIgnoreCase matches a Filter without regard to case
+	FilterIgnoreCase bool
+
+	// Expression is a selector expression, i.e. "name~api, tag=critical"
+	Expression string
+}
+
+// NewSelector returns a selector for the --regex, --filter and --select flags
+func NewSelector(flags SelectorFlags) (*Selector, error) {
+	selector := &Selector{}
+
+	if len(flags.Regex) > 0 {
+		regex := flags.Regex
+		if flags.RegexExact {
+			regex = "^(?:" + regex + ")$"
+		}
+		pattern, err := regexp.Compile(regex)
+		if err != nil {
+			return nil, err
+		}
+		selector.terms = append(selector.terms, selectorTerm{key: selectorName, pattern: regex, regex: pattern})
+	}
+
+	if len(flags.Filter) > 0 {
+		selector.terms = append(selector.terms, selectorTerm{key: selectorName, pattern: flags.Filter, ignoreCase: flags.FilterIgnoreCase})
+	}
+
+	for _, text := range strings.Split(flags.Expression, ",") {
+		text = strings.TrimSpace(text)
+		if len(text) == 0 {
+			continue
+		}
+
+		term, err := parseSelectorTerm(text)
+		if err != nil {
+			return nil, err
+		}
+		selector.terms = append(selector.terms, term)
+	}
+
+	return selector, nil
+}
+
+func parseSelectorTerm(text string) (selectorTerm, error) {
+	term := selectorTerm{}
+
+	expression := text
+	if strings.HasPrefix(expression, "!") {
+		term.negate = true
+		expression = strings.TrimSpace(expression[1:])
+	}
+
+	index := strings.IndexAny(expression, "=~")
+	if index < 0 {
+		return term, fmt.Errorf("invalid selector %q, use name=PATTERN, name~REGEX, tag=TAG or tag~REGEX", text)
+	}
+
+	term.key = strings.TrimSpace(expression[:index])
+	term.pattern = strings.TrimSpace(expression[index+1:])
+
+	if term.key != selectorName && term.key != selectorTag {
+		return term, fmt.Errorf("invalid selector %q, only name and tag can be selected on", text)
+	}
+	if len(term.pattern) == 0 {
+		return term, fmt.Errorf("invalid selector %q, a value is required after %q", text, expression[index:index+1])
+	}
+
+	if expression[index] == '~' {
+		pattern, err := regexp.Compile(term.pattern)
+		if err != nil {
+			return term, fmt.Errorf("invalid selector %q: %s", text, err)
+		}
+		term.regex = pattern
+	}
+
+	return term, nil
+}
+
+// Empty is true when the selector selects every function
+func (s *Selector) Empty() bool {
+	return s == nil || len(s.terms) == 0
+}
+
+// Matches is true when a function is selected
+func (s *Selector) Matches(name string, function Function) bool {
+	if s == nil {
+		return true
+	}
+
+	for _, term := range s.terms {
+		if term.matches(name, function) == term.negate {
+			return false
+		}
+	}
+	return true
+}
+
+func (t selectorTerm) matches(name string, function Function) bool {
+	if t.key == selectorName {
+		return t.matchValue(name)
+	}
+
+	for _, tag := range function.Tags {
+		if t.matchValue(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t selectorTerm) matchValue(value string) bool {
+	if t.regex != nil {
+		return t.regex.MatchString(value)
+	}
+	if t.key == selectorName {
+		if t.ignoreCase {
+			return glob.Glob(strings.ToLower(t.pattern), strings.ToLower(value))
+		}
+		return glob.Glob(t.pattern, value)
+	}
+	return t.pattern == value
+}
@@ -0,0 +1,92 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+const selectorStack = `provider:
+  name: openfaas
+functions:
+  api-orders:
+    lang: go
+    handler: ./api-orders
+    image: alexellis/api-orders:latest
+    tags: [backend, critical]
+  api-legacy:
+    lang: go
+    handler: ./api-legacy
+    image: alexellis/api-legacy:latest
+    tags: [backend, critical]
+  web:
+    lang: node18
+    handler: ./web
+    image: alexellis/web:latest
+    tags: [frontend]
+  report:
+    lang: python3
+    handler: ./report
+    image: alexellis/report:latest
+`
+
+func Test_ParseYAMLDataSelect(t *testing.T) {
+	cases := []struct {
+		name       string
+		regex      string
+		filter     string
+		expression string
+		want       []string
+		wantErr    string
+	}{
+		{name: "empty selects all", want: []string{"api-legacy", "api-orders", "report", "web"}},
+		{name: "name regex", expression: "name~^api", want: []string{"api-legacy", "api-orders"}},
+		{name: "name wildcard", expression: "name=*-orders", want: []string{"api-orders"}},
+		{name: "exclude by name", expression: "name~api, !name~legacy", want: []string{"api-orders"}},
+		{name: "only exclusions", expression: "!tag=backend", want: []string{"report", "web"}},
+		{name: "tag", expression: "tag=critical", want: []string{"api-legacy", "api-orders"}},
+		{name: "tag regex", expression: "tag~end$", want: []string{"api-legacy", "api-orders", "web"}},
+		{name: "terms must all match", expression: "name~api, !name~legacy, tag=critical", want: []string{"api-orders"}},
+		{name: "regex and filter together", regex: "^api", filter: "*legacy", want: []string{"api-legacy"}},
+		{name: "filter with an exclusion", filter: "api-*", expression: "!name=api-legacy", want: []string{"api-orders"}},
+		{name: "nothing selected", expression: "tag=missing", wantErr: "no functions matching --filter/--regex/--select were found in the YAML file"},
+		{name: "unknown key", expression: "lang=go", wantErr: `invalid selector "lang=go", only name and tag can be selected on`},
+		{name: "no operator", expression: "api", wantErr: `invalid selector "api", use name=PATTERN, name~REGEX, tag=TAG or tag~REGEX`},
+		{name: "no value", expression: "tag=", wantErr: `invalid selector "tag=", a value is required after "="`},
+		{name: "invalid regex", expression: "name~(", wantErr: `invalid selector "name~(": error parsing regexp`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			selector, err := NewSelector(SelectorFlags{Regex: tc.regex, Filter: tc.filter, Expression: tc.expression})
+
+			var services *Services
+			if err == nil {
+				services, err = ParseYAMLDataSelect([]byte(selectorStack), selector, false)
+			}
+
+			if len(tc.wantErr) > 0 {
+				if err == nil || !strings.HasPrefix(err.Error(), tc.wantErr) {
+					t.Fatalf("want error %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := []string{}
+			for name := range services.Functions {
+				got = append(got, name)
+			}
+			sort.Strings(got)
+
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -10,12 +10,9 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"regexp"
 	"time"
 
 	envsubst "github.com/drone/envsubst"
-	glob "github.com/ryanuber/go-glob"
-	yaml "gopkg.in/yaml.v2"
 )
 
 const legacyProviderName = "faas"
@@ -29,24 +26,43 @@ var ValidSchemaVersions = []string{
 
 // ParseYAMLFile parse YAML file into a stack of "services".
 func ParseYAMLFile(yamlFile, regex, filter string, envsubst bool) (*Services, error) {
-	var err error
-	var fileData []byte
-	urlParsed, err := url.Parse(yamlFile)
-	if err == nil && len(urlParsed.Scheme) > 0 {
-		fmt.Println("Parsed: " + urlParsed.String())
-		fileData, err = fetchYAML(urlParsed)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		fileData, err = ioutil.ReadFile(yamlFile)
+	fileData, err := readYAML(yamlFile)
+	if err != nil {
+		return nil, err
+	}
+	if hasIncludes(fileData) {
+		selector, err := regexFilterSelector(regex, filter)
 		if err != nil {
 			return nil, err
 		}
+		return parseIncludes(yamlFile, fileData, selector, envsubst)
 	}
 	return ParseYAMLData(fileData, regex, filter, envsubst)
 }
 
+// ParseYAMLFileSelect parses a YAML file into a stack of "services" with only
+// the functions which the selector picks
+func ParseYAMLFileSelect(yamlFile string, selector *Selector, envsubst bool) (*Services, error) {
+	fileData, err := readYAML(yamlFile)
+	if err != nil {
+		return nil, err
+	}
+	if hasIncludes(fileData) {
+		return parseIncludes(yamlFile, fileData, selector, envsubst)
+	}
+	return ParseYAMLDataSelect(fileData, selector, envsubst)
+}
+
+// readYAML reads a stack file from disk or from a URL
+func readYAML(yamlFile string) ([]byte, error) {
+	urlParsed, err := url.Parse(yamlFile)
+	if err == nil && len(urlParsed.Scheme) > 0 {
+		fmt.Println("Parsed: " + urlParsed.String())
+		return fetchYAML(urlParsed)
+	}
+	return ioutil.ReadFile(yamlFile)
+}
+
 func substituteEnvironment(data []byte) ([]byte, error) {
 
 	ret, err := envsubst.Parse(string(data))
@@ -66,73 +82,63 @@ func substituteEnvironment(data []byte) ([]byte, error) {
 
 // ParseYAMLData parse YAML data into a stack of "services".
 func ParseYAMLData(fileData []byte, regex string, filter string, envsubst bool) (*Services, error) {
-	var services Services
-	regexExists := len(regex) > 0
-	filterExists := len(filter) > 0
+	return parseYAMLData(fileData, envsubst, func() (*Selector, error) {
+		return regexFilterSelector(regex, filter)
+	})
+}
+
+func regexFilterSelector(regex, filter string) (*Selector, error) {
+	if len(regex) > 0 && len(filter) > 0 {
+		return nil, fmt.Errorf("pass in a regex or a filter, not both")
+	}
+	return NewSelector(SelectorFlags{Regex: regex, Filter: filter})
+}
 
+// ParseYAMLDataSelect parses YAML data into a stack of "services" with only
+// the functions which the selector picks
+func ParseYAMLDataSelect(fileData []byte, selector *Selector, envsubst bool) (*Services, error) {
+	return parseYAMLData(fileData, envsubst, func() (*Selector, error) {
+		return selector, nil
+	})
+}
+
+// parseYAMLData parses and validates the stack before the selector is made,
+// so that an invalid stack is reported first
+func parseYAMLData(fileData []byte, envsubst bool, newSelector func() (*Selector, error)) (*Services, error) {
 	var source []byte
 	if envsubst {
 		substData, substErr := substituteEnvironment(fileData)
 
 		if substErr != nil {
-			return &services, substErr
+			return &Services{}, substErr
 		}
 		source = substData
 	} else {
 		source = fileData
 	}
 
-	err := yaml.Unmarshal(source, &services)
+	parsed, err := parseServices(source)
 	if err != nil {
-		fmt.Printf("Error with YAML file\n")
 		return nil, err
 	}
 
-	for _, f := range services.Functions {
-		if f.Language == "Dockerfile" {
-			f.Language = "dockerfile"
-		}
-	}
-
-	if services.Provider.Name != providerName {
-		return nil, fmt.Errorf(`['%s'] is the only valid "provider.name" for the OpenFaaS CLI, but you gave: %s`, providerName, services.Provider.Name)
-	}
-
-	if len(services.Version) > 0 && !IsValidSchemaVersion(services.Version) {
-		return nil, fmt.Errorf("%s are the only valid versions for the stack file - found: %s", ValidSchemaVersions, services.Version)
-	}
-
-	if regexExists && filterExists {
-		return nil, fmt.Errorf("pass in a regex or a filter, not both")
+	selector, err := newSelector()
+	if err != nil {
+		return nil, err
 	}
 
-	if regexExists || filterExists {
-		for k, function := range services.Functions {
-			var match bool
-			var err error
-			function.Name = k
-
-			if regexExists {
-				match, err = regexp.MatchString(regex, function.Name)
-				if err != nil {
-					return nil, err
-				}
-			} else {
-				match = glob.Glob(filter, function.Name)
-			}
-
-			if !match {
-				delete(services.Functions, function.Name)
-			}
-		}
+	return selectServices(parsed, selector)
+}
 
-		if len(services.Functions) == 0 {
-			return nil, fmt.Errorf("no functions matching --filter/--regex were found in the YAML file")
-		}
+// selectServices copies only the functions which match from a parsed stack
+func selectServices(parsed *Services, selector *Selector) (*Services, error) {
+	services := copyServices(parsed, selector.Matches)
 
+	if !selector.Empty() && len(services.Functions) == 0 {
+		return nil, fmt.Errorf("no functions matching --filter/--regex/--select were found in the YAML file")
 	}
 
-	return &services, nil
+	return services, nil
 }
 
 func makeHTTPClient(timeout *time.Duration) http.Client {
@@ -54,7 +54,7 @@ provider:
 
 `
 
-const noMatchesErrorMsg string = "no functions matching --filter/--regex were found in the YAML file"
+const noMatchesErrorMsg string = "no functions matching --filter/--regex/--select were found in the YAML file"
 const invalidRegexErrorMsg string = "error parsing regexp"
 
 var ParseYAMLTests_Regex = []struct {
@@ -327,6 +327,161 @@ func Test_ParseYAMLDataFilterAndRegex(t *testing.T) {
 	}
 }
 
+const TestData_Numbered string = `version: 1.0
+provider:
+  name: openfaas
+
+functions:
+  fn1:
+    lang: go
+    handler: ./fn1
+    image: alexellis/fn1
+
+  fn10:
+    lang: go
+    handler: ./fn10
+    image: alexellis/fn10
+
+  fn11:
+    lang: go
+    handler: ./fn11
+    image: alexellis/fn11
+
+  Resize-Image:
+    lang: dockerfile
+    handler: ./resize-image
+    image: alexellis/resize-image
+
+  resize-video:
+    lang: dockerfile
+    handler: ./resize-video
+    image: alexellis/resize-video
+`
+
+var ParseYAMLTests_MatchOptions = []struct {
+	title         string
+	flags         SelectorFlags
+	functions     []string
+	expectedError string
+}{
+	{
+		title:     "Regex matches anywhere in the name by default: 'fn1'",
+		flags:     SelectorFlags{Regex: "fn1"},
+		functions: []string{"fn1", "fn10", "fn11"},
+	},
+	{
+		title:     "Exact regex matches the whole name: 'fn1'",
+		flags:     SelectorFlags{Regex: "fn1", RegexExact: true},
+		functions: []string{"fn1"},
+	},
+	{
+		title:     "Exact regex with an alternation is anchored as a whole: 'fn1|fn10'",
+		flags:     SelectorFlags{Regex: "fn1|fn10", RegexExact: true},
+		functions: []string{"fn1", "fn10"},
+	},
+	{
+		title:     "Exact regex with a character class: 'fn1[0-9]'",
+		flags:     SelectorFlags{Regex: "fn1[0-9]", RegexExact: true},
+		functions: []string{"fn10", "fn11"},
+	},
+	{
+		title:     "Exact regex which is already anchored: '^fn1$'",
+		flags:     SelectorFlags{Regex: "^fn1$", RegexExact: true},
+		functions: []string{"fn1"},
+	},
+	{
+		title:         "Exact regex which only matches part of a name: 'fn'",
+		flags:         SelectorFlags{Regex: "fn", RegexExact: true},
+		functions:     []string{},
+		expectedError: noMatchesErrorMsg,
+	},
+	{
+		title:         "Exact regex which is invalid: '['",
+		flags:         SelectorFlags{Regex: "[", RegexExact: true},
+		functions:     []string{},
+		expectedError: invalidRegexErrorMsg,
+	},
+	{
+		title:     "Exact without a regex selects all functions",
+		flags:     SelectorFlags{RegexExact: true},
+		functions: []string{"Resize-Image", "fn1", "fn10", "fn11", "resize-video"},
+	},
+	{
+		title:     "Filter is case sensitive by default: 'resize-*'",
+		flags:     SelectorFlags{Filter: "resize-*"},
+		functions: []string{"resize-video"},
+	},
+	{
+		title:     "Filter ignoring case: 'resize-*'",
+		flags:     SelectorFlags{Filter: "resize-*", FilterIgnoreCase: true},
+		functions: []string{"Resize-Image", "resize-video"},
+	},
+	{
+		title:     "Filter ignoring case with an upper case pattern: 'RESIZE-IMAGE'",
+		flags:     SelectorFlags{Filter: "RESIZE-IMAGE", FilterIgnoreCase: true},
+		functions: []string{"Resize-Image"},
+	},
+	{
+		title:         "Filter ignoring case which finds no matches: 'FN2*'",
+		flags:         SelectorFlags{Filter: "FN2*", FilterIgnoreCase: true},
+		functions:     []string{},
+		expectedError: noMatchesErrorMsg,
+	},
+	{
+		title:     "Ignore case without a filter selects all functions",
+		flags:     SelectorFlags{FilterIgnoreCase: true},
+		functions: []string{"Resize-Image", "fn1", "fn10", "fn11", "resize-video"},
+	},
+	{
+		title:     "Exact regex and a filter ignoring case together: 'fn1.' and 'FN*'",
+		flags:     SelectorFlags{Regex: "fn1.", RegexExact: true, Filter: "FN*", FilterIgnoreCase: true},
+		functions: []string{"fn10", "fn11"},
+	},
+}
+
+func Test_ParseYAMLDataMatchOptions(t *testing.T) {
+
+	for _, test := range ParseYAMLTests_MatchOptions {
+		t.Run(test.title, func(t *testing.T) {
+
+			selector, err := NewSelector(test.flags)
+			var parsedYAML *Services
+			if err == nil {
+				parsedYAML, err = ParseYAMLDataSelect([]byte(TestData_Numbered), selector, true)
+			}
+
+			if len(test.expectedError) > 0 {
+				if err == nil {
+					t.Fatalf("Test_ParseYAMLDataMatchOptions test [%s] test failed, expected error not thrown", test.title)
+				}
+
+				if !strings.Contains(err.Error(), test.expectedError) {
+					t.Errorf("Test_ParseYAMLDataMatchOptions test [%s] test failed, expected error message of '%s', got '%v'", test.title, test.expectedError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Test_ParseYAMLDataMatchOptions test [%s] test failed, unexpected error thrown: %v", test.title, err)
+			}
+
+			strkeys := []string{}
+			for name := range parsedYAML.Functions {
+				strkeys = append(strkeys, name)
+			}
+			sort.Strings(strkeys)
+
+			if !reflect.DeepEqual(strkeys, test.functions) {
+				t.Errorf("Test_ParseYAMLDataMatchOptions test [%s] failed, does not match expected result;\n  parsedYAML:   [%v]\n  expected: [%v]",
+					test.title,
+					strkeys,
+					test.functions,
+				)
+			}
+		})
+	}
+}
+
 func Test_ParseYAMLData_ProviderValues(t *testing.T) {
 	testCases := []struct {
 		title         string
@@ -359,7 +514,7 @@ provider:
 		{
 			title:         "Provider is faas and gives error",
 			provider:      "faas",
-			expectedError: `['openfaas'] is the only valid "provider.name" for the OpenFaaS CLI, but you gave: faas`,
+			expectedError: `['openfaas'] is the only valid "provider.name" for the OpenFaaS CLI, but you gave: faas, run faas-cli stack migrate to upgrade the stack file`,
 			file: `version: 1.0
 provider:
   name: faas
@@ -0,0 +1,92 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WatchdogModes are the modes of the of-watchdog
+var WatchdogModes = []string{"http", "serializing", "streaming"}
+
+// FunctionWatchdog configures the watchdog in the function's image through
+// the environment variables which it reads at start-up
+type FunctionWatchdog struct {
+	// Mode of the of-watchdog: http, serializing or streaming
+	Mode string `yaml:"mode,omitempty"`
+
+	// ExecTimeout is the longest a request may run for, i.e. 30s
+	ExecTimeout string `yaml:"exec_timeout,omitempty"`
+
+	// Port the function's process listens on in http mode
+	Port int `yaml:"port,omitempty"`
+}
+
+// TemplateWatchdog declares the watchdog modes a template supports
+type TemplateWatchdog struct {
+	Modes []string `yaml:"modes,omitempty"`
+}
+
+// Environment returns the environment variables for the watchdog settings,
+// these are the same as would be set by hand under environment
+func (w *FunctionWatchdog) Environment() (map[string]string, error) {
+	envs := map[string]string{}
+	if w == nil {
+		return envs, nil
+	}
+
+	if len(w.Mode) > 0 {
+		if !containsString(WatchdogModes, w.Mode) {
+			return nil, fmt.Errorf("invalid watchdog mode %q, use one of: %s", w.Mode, strings.Join(WatchdogModes, ", "))
+		}
+		envs["mode"] = w.Mode
+	}
+
+	if len(w.ExecTimeout) > 0 {
+		timeout, err := time.ParseDuration(w.ExecTimeout)
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("invalid watchdog exec_timeout %q, use a duration such as 30s or 2m", w.ExecTimeout)
+		}
+		envs["exec_timeout"] = w.ExecTimeout
+	}
+
+	if w.Port != 0 {
+		if w.Mode != "http" {
+			return nil, fmt.Errorf("watchdog port is only used in http mode, but the mode is %q", w.Mode)
+		}
+		if w.Port < 1 || w.Port > 65535 {
+			return nil, fmt.Errorf("invalid watchdog port %d", w.Port)
+		}
+		envs["upstream_url"] = fmt.Sprintf("http://127.0.0.1:%d", w.Port)
+	}
+
+	return envs, nil
+}
+
+// ValidateWatchdog checks that a template supports the watchdog mode of a
+// function, any mode is accepted when the template does not declare its modes
+func ValidateWatchdog(watchdog *FunctionWatchdog, template *LanguageTemplate) error {
+	if watchdog == nil || len(watchdog.Mode) == 0 || template == nil || template.Watchdog == nil || len(template.Watchdog.Modes) == 0 {
+		return nil
+	}
+
+	if !containsString(template.Watchdog.Modes, watchdog.Mode) {
+		modes := append([]string{}, template.Watchdog.Modes...)
+		sort.Strings(modes)
+		return fmt.Errorf("the %s template does not support watchdog mode %q, it supports: %s", template.Language, watchdog.Mode, strings.Join(modes, ", "))
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
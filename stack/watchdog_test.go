@@ -0,0 +1,97 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_FunctionWatchdog_Environment(t *testing.T) {
+	cases := []struct {
+		name     string
+		watchdog *FunctionWatchdog
+		want     map[string]string
+		wantErr  string
+	}{
+		{
+			name: "no watchdog block",
+			want: map[string]string{},
+		},
+		{
+			name:     "http mode with port",
+			watchdog: &FunctionWatchdog{Mode: "http", ExecTimeout: "30s", Port: 3000},
+			want: map[string]string{
+				"mode":         "http",
+				"exec_timeout": "30s",
+				"upstream_url": "http://127.0.0.1:3000",
+			},
+		},
+		{
+			name:     "streaming mode",
+			watchdog: &FunctionWatchdog{Mode: "streaming"},
+			want:     map[string]string{"mode": "streaming"},
+		},
+		{
+			name:     "unknown mode",
+			watchdog: &FunctionWatchdog{Mode: "afterburn"},
+			wantErr:  `invalid watchdog mode "afterburn"`,
+		},
+		{
+			name:     "invalid exec_timeout",
+			watchdog: &FunctionWatchdog{ExecTimeout: "30"},
+			wantErr:  `invalid watchdog exec_timeout "30"`,
+		},
+		{
+			name:     "port without http mode",
+			watchdog: &FunctionWatchdog{Mode: "streaming", Port: 3000},
+			wantErr:  "watchdog port is only used in http mode",
+		},
+		{
+			name:     "port out of range",
+			watchdog: &FunctionWatchdog{Mode: "http", Port: 70000},
+			wantErr:  "invalid watchdog port 70000",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := c.watchdog.Environment()
+			if len(c.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+					t.Fatalf("want error %q, but got %v", c.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("want %v, but got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func Test_ValidateWatchdog(t *testing.T) {
+	template := &LanguageTemplate{
+		Language: "python3-http",
+		Watchdog: &TemplateWatchdog{Modes: []string{"http"}},
+	}
+
+	if err := ValidateWatchdog(&FunctionWatchdog{Mode: "http"}, template); err != nil {
+		t.Errorf("want a supported mode to be valid, but got %s", err)
+	}
+
+	err := ValidateWatchdog(&FunctionWatchdog{Mode: "streaming"}, template)
+	want := `the python3-http template does not support watchdog mode "streaming", it supports: http`
+	if err == nil || err.Error() != want {
+		t.Errorf("want error %q, but got %v", want, err)
+	}
+
+	if err := ValidateWatchdog(&FunctionWatchdog{Mode: "streaming"}, &LanguageTemplate{Language: "classic"}); err != nil {
+		t.Errorf("want any mode when the template does not declare its modes, but got %s", err)
+	}
+}
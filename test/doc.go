@@ -0,0 +1,20 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+// Package test provides a mock OpenFaaS gateway for testing code which uses
+// faas-cli's proxy client, or the CLI itself, without a running gateway.
+//
+// MockHttpServer answers requests in the order they are given, checking the
+// method, URI, headers and body of each one when they are set:
+//
+//	s := test.MockHttpServer(t, []test.Request{
+//		test.SystemInfoRequest(),
+//		test.ListFunctionsRequest("", "figlet", "nodeinfo"),
+//	})
+//	defer s.Close()
+//
+//	client, _ := proxy.NewClient(auth, s.URL, nil, nil)
+//
+// Close fails the test when fewer requests were received than expected, and
+// Requests returns what was received for further assertions.
+package test
@@ -0,0 +1,104 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package test
+
+import (
+	"net/http"
+	"net/url"
+
+	providerTypes "github.com/openfaas/faas-provider/types"
+	"github.com/openfaas/faas/gateway/types"
+)
+
+// GatewayInfo is the response of /system/info for a gateway on faas-netes
+func GatewayInfo() types.GatewayInfo {
+	return types.GatewayInfo{
+		Arch: "x86_64",
+		Provider: &providerTypes.ProviderInfo{
+			Name:          "faas-netes",
+			Orchestration: "kubernetes",
+			Version: &providerTypes.VersionInfo{
+				Release: "0.12.8",
+				SHA:     "c2d2c0ed7d3d5dc4b4a7c1e4f3d3e3b1e2f1a0b9",
+			},
+		},
+		Version: &providerTypes.VersionInfo{
+			Release: "0.20.1",
+			SHA:     "8c4a0ed5ce4b0a4d2bd1bb3c1a1fb5e0b2b3c4d5",
+		},
+	}
+}
+
+// FunctionStatus is a deployed function with one ready replica
+func FunctionStatus(name string) providerTypes.FunctionStatus {
+	return providerTypes.FunctionStatus{
+		Name:              name,
+		Image:             "functions/" + name + ":latest",
+		Namespace:         "openfaas-fn",
+		Replicas:          1,
+		AvailableReplicas: 1,
+	}
+}
+
+// SystemInfoRequest expects a GET of /system/info and responds with GatewayInfo
+func SystemInfoRequest() Request {
+	return Request{
+		Method:             http.MethodGet,
+		Uri:                "/system/info",
+		ResponseStatusCode: http.StatusOK,
+		ResponseBody:       GatewayInfo(),
+	}
+}
+
+// ListFunctionsRequest expects a GET of /system/functions and responds with
+// the named functions, namespace is left out of the query when empty
+func ListFunctionsRequest(namespace string, names ...string) Request {
+	functions := []providerTypes.FunctionStatus{}
+	for _, name := range names {
+		functions = append(functions, FunctionStatus(name))
+	}
+
+	return Request{
+		Method:             http.MethodGet,
+		Uri:                withNamespace("/system/functions", namespace),
+		ResponseStatusCode: http.StatusOK,
+		ResponseBody:       functions,
+	}
+}
+
+// DescribeFunctionRequest expects a GET of /system/function/NAME and responds
+// with FunctionStatus
+func DescribeFunctionRequest(namespace, name string) Request {
+	return Request{
+		Method:             http.MethodGet,
+		Uri:                withNamespace("/system/function/"+name, namespace),
+		ResponseStatusCode: http.StatusOK,
+		ResponseBody:       FunctionStatus(name),
+	}
+}
+
+// DeployFunctionRequest expects a POST of /system/functions and responds with
+// 202 Accepted
+func DeployFunctionRequest() Request {
+	return Request{
+		Method:             http.MethodPost,
+		Uri:                "/system/functions",
+		ResponseStatusCode: http.StatusAccepted,
+	}
+}
+
+// UnauthorizedRequest responds with 401 to any request
+func UnauthorizedRequest() Request {
+	return Request{
+		ResponseStatusCode: http.StatusUnauthorized,
+		ResponseBody:       "unauthorized",
+	}
+}
+
+func withNamespace(path, namespace string) string {
+	if len(namespace) == 0 {
+		return path
+	}
+	return path + "?" + url.Values{"namespace": []string{namespace}}.Encode()
+}
@@ -4,38 +4,82 @@ import (
 	"bytes"
 	"encoding/json"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"reflect"
+	"sync"
 	"testing"
 )
 
+// Request is a request which the server expects and the response it sends back
 type Request struct {
-	Method             string
-	Uri                string
+	// Method and Uri are checked when set, Uri includes the query string
+	Method string
+	Uri    string
+
+	// RequestHeaders are checked when set, other headers are ignored
+	RequestHeaders map[string]string
+
+	// RequestBody is checked when set, a string is compared as it is,
+	// any other value is compared as JSON
+	RequestBody interface{}
+
+	// ResponseStatusCode defaults to 200
 	ResponseStatusCode int
-	ResponseBody       interface{}
+
+	// ResponseBody is sent as it is when it is a string, otherwise as JSON
+	ResponseBody interface{}
 }
 
-type server struct {
+// RecordedRequest is a request which was received by the server
+type RecordedRequest struct {
+	Method string
+	Uri    string
+	Header http.Header
+	Body   []byte
+}
+
+// Server is a gateway which sends canned responses, see MockHttpServer
+type Server struct {
 	URL                string // Shortcut to httptest.Server.URL
 	server             *httptest.Server
 	requestCounter     int
 	nbExpectedRequests int
+	received           []RecordedRequest
+	mutex              sync.Mutex
 	t                  *testing.T
 }
 
 // MockHttpServer creates a test server which will send responses in the given order
-// It is possible to check on Method and Uri if set
+// It is possible to check on Method, Uri, headers and body if set
 // Responses can contain JSON-encoded body if ResponseBody is set
-func MockHttpServer(t *testing.T, requests []Request) *server {
-	s := server{
+func MockHttpServer(t *testing.T, requests []Request) *Server {
+	s := &Server{
 		requestCounter:     0,
 		nbExpectedRequests: len(requests),
 		t:                  t,
 	}
 
 	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		body, _ := ioutil.ReadAll(r.Body)
+		s.received = append(s.received, RecordedRequest{
+			Method: r.Method,
+			Uri:    r.RequestURI,
+			Header: r.Header.Clone(),
+			Body:   body,
+		})
+
+		if len(requests) == 0 {
+			t.Errorf("Request n° %d: %s %s was not expected", len(s.received), r.Method, r.RequestURI)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
 		var request Request
 		request, requests = requests[0], requests[1:]
 
@@ -57,6 +101,27 @@ func MockHttpServer(t *testing.T, requests []Request) *server {
 			)
 		}
 
+		for name, value := range request.RequestHeaders {
+			if got := r.Header.Get(name); got != value {
+				t.Fatalf(
+					"Request n° %d: Expected header %s '%s' but got '%s'",
+					s.requestCounter+1,
+					name,
+					value,
+					got,
+				)
+			}
+		}
+
+		if request.RequestBody != nil && !bodyMatches(request.RequestBody, body) {
+			t.Fatalf(
+				"Request n° %d: Expected body '%v' but got '%s'",
+				s.requestCounter+1,
+				request.RequestBody,
+				string(body),
+			)
+		}
+
 		w.Header().Add("Content-Type", "application/json")
 
 		// Status code defaults to 200
@@ -85,12 +150,29 @@ func MockHttpServer(t *testing.T, requests []Request) *server {
 
 	s.URL = s.server.URL
 
-	return &s
+	return s
+}
+
+func bodyMatches(want interface{}, body []byte) bool {
+	if s, ok := want.(string); ok {
+		return s == string(body)
+	}
+
+	wantJSON, err := json.Marshal(want)
+	if err != nil {
+		return false
+	}
+
+	var wantValue, gotValue interface{}
+	if json.Unmarshal(wantJSON, &wantValue) != nil || json.Unmarshal(body, &gotValue) != nil {
+		return false
+	}
+	return reflect.DeepEqual(wantValue, gotValue)
 }
 
 // MockHttpServerStatus creates a test server which will send empty responses with the given status code
 // the responses which will be sent are in the given order
-func MockHttpServerStatus(t *testing.T, statusCode ...int) *server {
+func MockHttpServerStatus(t *testing.T, statusCode ...int) *Server {
 	var requests []Request
 	for _, s := range statusCode {
 		requests = append(requests, Request{
@@ -101,15 +183,26 @@ func MockHttpServerStatus(t *testing.T, statusCode ...int) *server {
 	return MockHttpServer(t, requests)
 }
 
-// Close closes the test server
-func (s *server) Close() {
+// Close closes the test server and fails the test when fewer requests were
+// received than expected
+func (s *Server) Close() {
 	s.server.Close()
 
 	s.assertNbRequests()
 }
 
+// Requests returns the requests received so far, in order
+func (s *Server) Requests() []RecordedRequest {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	received := make([]RecordedRequest, len(s.received))
+	copy(received, s.received)
+	return received
+}
+
 // assertNbRequests verify if the number of received requests matches the expected number
-func (s *server) assertNbRequests() {
+func (s *Server) assertNbRequests() {
 	if s.nbExpectedRequests != s.requestCounter {
 		s.t.Fatalf(
 			"Expected %d requests but received %d",
@@ -119,6 +212,7 @@ func (s *server) assertNbRequests() {
 	}
 }
 
+// CaptureStdout returns what f writes to os.Stdout
 func CaptureStdout(f func()) string {
 	stdOut := os.Stdout
 	r, w, _ := os.Pipe()
@@ -0,0 +1,75 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/proxy"
+)
+
+func Test_MockHttpServer_fixtures(t *testing.T) {
+	s := MockHttpServer(t, []Request{
+		SystemInfoRequest(),
+		ListFunctionsRequest("staging", "figlet", "nodeinfo"),
+		DescribeFunctionRequest("", "figlet"),
+	})
+	defer s.Close()
+
+	client, err := proxy.NewClient(&proxy.BearerToken{}, s.URL, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := client.GetSystemInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Provider.Name != "faas-netes" {
+		t.Errorf("want provider faas-netes, got: %s", info.Provider.Name)
+	}
+
+	functions, err := client.ListFunctions(context.Background(), "staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(functions) != 2 || functions[0].Name != "figlet" {
+		t.Errorf("want figlet and nodeinfo, got: %v", functions)
+	}
+
+	function, err := client.GetFunctionInfo(context.Background(), "figlet", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if function.Image != "functions/figlet:latest" {
+		t.Errorf("want image functions/figlet:latest, got: %s", function.Image)
+	}
+}
+
+func Test_MockHttpServer_RequestBody(t *testing.T) {
+	s := MockHttpServer(t, []Request{
+		{
+			Method:         http.MethodPost,
+			Uri:            "/system/functions",
+			RequestHeaders: map[string]string{"Content-Type": "application/json"},
+			RequestBody:    map[string]string{"service": "figlet", "image": "functions/figlet:latest"},
+		},
+	})
+	defer s.Close()
+
+	body := `{"image": "functions/figlet:latest", "service": "figlet"}`
+	res, err := http.Post(s.URL+"/system/functions", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	received := s.Requests()
+	if len(received) != 1 || string(received[0].Body) != body {
+		t.Errorf("want the request to be recorded with its body, got: %v", received)
+	}
+}
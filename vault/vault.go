@@ -0,0 +1,184 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+// Package vault reads secret values from HashiCorp Vault for secrets which
+// are referenced in stack.yml as "vault:PATH#KEY".
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ReferencePrefix marks a secret in stack.yml as a reference to Vault
+const ReferencePrefix = "vault:"
+
+// Environment variables used to connect and authenticate to Vault
+const (
+	AddressEnvironment  = "VAULT_ADDR"
+	TokenEnvironment    = "VAULT_TOKEN"
+	RoleIDEnvironment   = "VAULT_ROLE_ID"
+	SecretIDEnvironment = "VAULT_SECRET_ID"
+)
+
+// Reference to a key of a secret in Vault, i.e. vault:kv/data/app#token
+type Reference struct {
+	// Path of the secret, i.e. kv/data/app
+	Path string
+	// Key within the secret's data, i.e. token. The key is also used
+	// as the name of the secret on the gateway
+	Key string
+}
+
+// IsReference reports whether a secret from stack.yml refers to Vault
+func IsReference(secret string) bool {
+	return strings.HasPrefix(secret, ReferencePrefix)
+}
+
+// ParseReference parses a secret from stack.yml in the form vault:PATH#KEY
+func ParseReference(secret string) (Reference, error) {
+	if !IsReference(secret) {
+		return Reference{}, fmt.Errorf("secret %q is not a vault reference", secret)
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(secret, ReferencePrefix), "#", 2)
+	if len(parts) != 2 || len(strings.Trim(parts[0], "/")) == 0 || len(parts[1]) == 0 {
+		return Reference{}, fmt.Errorf("vault secret %q must take the form vault:PATH#KEY", secret)
+	}
+
+	return Reference{Path: strings.Trim(parts[0], "/"), Key: parts[1]}, nil
+}
+
+// Client reads secrets from the Vault HTTP API
+type Client struct {
+	Address    string
+	Token      string
+	httpClient *http.Client
+}
+
+// NewClientFromEnv creates a Client from VAULT_ADDR and either VAULT_TOKEN or
+// VAULT_ROLE_ID and VAULT_SECRET_ID for the AppRole auth method
+func NewClientFromEnv() (*Client, error) {
+	address := strings.TrimRight(os.Getenv(AddressEnvironment), "/")
+	if len(address) == 0 {
+		return nil, fmt.Errorf("%s must be set to read secrets from vault", AddressEnvironment)
+	}
+
+	client := &Client{
+		Address:    address,
+		Token:      os.Getenv(TokenEnvironment),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+
+	if len(client.Token) > 0 {
+		return client, nil
+	}
+
+	roleID, secretID := os.Getenv(RoleIDEnvironment), os.Getenv(SecretIDEnvironment)
+	if len(roleID) == 0 || len(secretID) == 0 {
+		return nil, fmt.Errorf("set %s, or %s and %s to authenticate to vault", TokenEnvironment, RoleIDEnvironment, SecretIDEnvironment)
+	}
+
+	token, err := client.loginAppRole(roleID, secretID)
+	if err != nil {
+		return nil, err
+	}
+	client.Token = token
+
+	return client, nil
+}
+
+type vaultResponse struct {
+	Auth struct {
+		ClientToken string `json:"client_token"`
+	} `json:"auth"`
+	Data   map[string]interface{} `json:"data"`
+	Errors []string               `json:"errors"`
+}
+
+func (c *Client) loginAppRole(roleID, secretID string) (string, error) {
+	body, _ := json.Marshal(map[string]string{"role_id": roleID, "secret_id": secretID})
+
+	req, err := http.NewRequest(http.MethodPost, c.Address+"/v1/auth/approle/login", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to log in to vault with approle: %s", err)
+	}
+
+	if len(res.Auth.ClientToken) == 0 {
+		return "", fmt.Errorf("unable to log in to vault with approle: no token returned")
+	}
+	return res.Auth.ClientToken, nil
+}
+
+// Read returns the value of the key referenced by ref. Both the KV version 1
+// and version 2 secrets engines are supported.
+func (c *Client) Read(ref Reference) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, c.Address+"/v1/"+ref.Path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", c.Token)
+
+	res, err := c.do(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to read %s from vault: %s", ref.Path, err)
+	}
+
+	data := res.Data
+	// KV version 2 nests the secret's data in a second "data" field
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, hasMetadata := data["metadata"]; hasMetadata {
+			data = nested
+		}
+	}
+
+	value, ok := data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in vault secret %s", ref.Key, ref.Path)
+	}
+
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprintf("%v", value), nil
+}
+
+func (c *Client) do(req *http.Request) (*vaultResponse, error) {
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	bytesOut, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var out vaultResponse
+	if len(bytesOut) > 0 {
+		if err := json.Unmarshal(bytesOut, &out); err != nil {
+			return nil, fmt.Errorf("cannot parse response from vault: %s", err)
+		}
+	}
+
+	if res.StatusCode != http.StatusOK {
+		if len(out.Errors) > 0 {
+			return nil, fmt.Errorf("vault returned status code: %d - %s", res.StatusCode, strings.Join(out.Errors, ", "))
+		}
+		return nil, fmt.Errorf("vault returned status code: %d", res.StatusCode)
+	}
+
+	return &out, nil
+}
@@ -0,0 +1,126 @@
+// Copyright (c) OpenFaaS Author(s) 2020. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package vault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func Test_ParseReference(t *testing.T) {
+	cases := []struct {
+		name    string
+		input   string
+		want    Reference
+		wantErr bool
+	}{
+		{name: "kv v2 path", input: "vault:kv/data/app#token", want: Reference{Path: "kv/data/app", Key: "token"}},
+		{name: "leading slash is trimmed", input: "vault:/secret/app#api-key", want: Reference{Path: "secret/app", Key: "api-key"}},
+		{name: "missing key", input: "vault:kv/data/app", wantErr: true},
+		{name: "missing path", input: "vault:#token", wantErr: true},
+		{name: "not a reference", input: "api-key", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := ParseReference(c.input)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("want error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != c.want {
+				t.Fatalf("want %+v, got %+v", c.want, got)
+			}
+		})
+	}
+}
+
+func Test_Read(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/kv/data/app":
+			w.Write([]byte(`{"data":{"data":{"token":"v2-value"},"metadata":{"version":1}}}`))
+		case "/v1/secret/app":
+			w.Write([]byte(`{"data":{"token":"v1-value"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer s.Close()
+
+	client := &Client{Address: s.URL, Token: "s.token", httpClient: http.DefaultClient}
+
+	cases := []struct {
+		name    string
+		ref     Reference
+		want    string
+		wantErr bool
+	}{
+		{name: "kv v2", ref: Reference{Path: "kv/data/app", Key: "token"}, want: "v2-value"},
+		{name: "kv v1", ref: Reference{Path: "secret/app", Key: "token"}, want: "v1-value"},
+		{name: "missing key", ref: Reference{Path: "secret/app", Key: "password"}, wantErr: true},
+		{name: "missing path", ref: Reference{Path: "secret/other", Key: "token"}, wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := client.Read(c.ref)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("want error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != c.want {
+				t.Fatalf("want %q, got %q", c.want, got)
+			}
+		})
+	}
+}
+
+func Test_NewClientFromEnv_AppRole(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/auth/approle/login" || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"auth":{"client_token":"s.approle"}}`))
+	}))
+	defer s.Close()
+
+	os.Setenv(AddressEnvironment, s.URL)
+	os.Setenv(RoleIDEnvironment, "role")
+	os.Setenv(SecretIDEnvironment, "secret")
+	os.Unsetenv(TokenEnvironment)
+	defer func() {
+		os.Unsetenv(AddressEnvironment)
+		os.Unsetenv(RoleIDEnvironment)
+		os.Unsetenv(SecretIDEnvironment)
+	}()
+
+	client, err := NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if client.Token != "s.approle" {
+		t.Fatalf("want token from approle login, got %q", client.Token)
+	}
+}
@@ -2,8 +2,9 @@ package version
 
 const UserAgent = "OpenFaaS CLI"
 
+// Version, GitCommit and BuildDate are set at build time with -ldflags
 var (
-	Version, GitCommit string
+	Version, GitCommit, BuildDate string
 )
 
 func BuildVersion() string {
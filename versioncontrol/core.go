@@ -4,6 +4,7 @@ package versioncontrol
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -26,8 +27,17 @@ type vcsCmd struct {
 // Invoke executes the vcsCmd replacing varibables in the cmds with the keyval
 // variables passed.
 func (v *vcsCmd) Invoke(dir string, args map[string]string) error {
+	return v.InvokeContext(context.Background(), dir, args)
+}
+
+// InvokeContext executes the vcsCmd like Invoke, the running command is
+// killed when the context is cancelled.
+func (v *vcsCmd) InvokeContext(ctx context.Context, dir string, args map[string]string) error {
 	for _, cmd := range v.cmds {
-		if _, err := v.run(dir, cmd, args, true); err != nil {
+		if _, err := v.run(ctx, dir, cmd, args, true); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
 			return err
 		}
 	}
@@ -35,7 +45,7 @@ func (v *vcsCmd) Invoke(dir string, args map[string]string) error {
 }
 
 // run is the generalized implementation of executing our commands.
-func (v *vcsCmd) run(dir string, cmdline string, keyval map[string]string, verbose bool) ([]byte, error) {
+func (v *vcsCmd) run(ctx context.Context, dir string, cmdline string, keyval map[string]string, verbose bool) ([]byte, error) {
 	args := strings.Fields(cmdline)
 	for i, arg := range args {
 		args[i] = replaceVars(keyval, arg)
@@ -48,7 +58,7 @@ func (v *vcsCmd) run(dir string, cmdline string, keyval map[string]string, verbo
 		return nil, err
 	}
 
-	cmd := exec.Command(v.cmd, args...)
+	cmd := exec.CommandContext(ctx, v.cmd, args...)
 	cmd.Dir = dir
 	cmd.Env = envWithPWD(cmd.Dir)
 
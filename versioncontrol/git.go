@@ -1,9 +1,8 @@
 package versioncontrol
 
 import (
+	"os/exec"
 	"strings"
-
-	"github.com/openfaas/faas-cli/exec"
 )
 
 // GitClone defines the command to clone a repo into a directory
@@ -53,7 +52,8 @@ var GitInitRepo = &vcsCmd{
 	scheme: []string{"git", "https", "http", "git+ssh", "ssh"},
 }
 
-// GetGitDescribe returns the human readable name for the current commit using `git-describe`
+// GetGitDescribe returns the human readable name for the current commit using `git-describe`,
+// it is empty outside of a Git repository
 func GetGitDescribe() string {
 	// git-describe - Give an object a human readable name based on an available ref
 	// --tags                use any tag, even unannotated
@@ -63,34 +63,59 @@ func GetGitDescribe() string {
 	// <most-recent-parent-tag>-<number-of-commits-to-that-tag>-g<short-sha>
 	// using --always, means that if the repo does not use tags, then we will still get the <short-sha>
 	// as output, similar to GetGitSHA
-	getDescribeCommand := []string{"git", "describe", "--tags", "--always"}
-	sha := exec.CommandWithOutput(getDescribeCommand, true)
-	if strings.Contains(sha, "Not a git repository") {
-		return ""
-	}
-	sha = strings.TrimSuffix(sha, "\n")
-
+	sha, _ := gitOutput("describe", "--tags", "--always")
 	return sha
 }
 
-// GetGitSHA returns the short Git commit SHA from local repo
+// GetGitSHA returns the short Git commit SHA from local repo, it is empty
+// outside of a Git repository
 func GetGitSHA() string {
-	getShaCommand := []string{"git", "rev-parse", "--short", "HEAD"}
-	sha := exec.CommandWithOutput(getShaCommand, true)
-	if strings.Contains(sha, "Not a git repository") {
-		return ""
-	}
-	sha = strings.TrimSuffix(sha, "\n")
+	sha, _ := gitOutput("rev-parse", "--short", "HEAD")
+	return sha
+}
 
+// GetGitFullSHA returns the full Git commit SHA from local repo, it is empty
+// outside of a Git repository
+func GetGitFullSHA() string {
+	sha, _ := gitOutput("rev-parse", "HEAD")
 	return sha
 }
 
+// GetGitRemoteURL returns the URL of the origin remote, it is empty when
+// there is none or outside of a Git repository
+func GetGitRemoteURL() string {
+	remote, _ := gitOutput("config", "--get", "remote.origin.url")
+	return remote
+}
+
+// GetGitCommitTime returns the time of the latest commit in seconds since the
+// Unix epoch, it is empty outside of a Git repository
+func GetGitCommitTime() string {
+	commitTime, _ := gitOutput("log", "-1", "--format=%ct")
+	return commitTime
+}
+
+// GetGitDirty reports whether the working tree has changes which are not
+// committed, including new files, it is false outside of a Git repository
+func GetGitDirty() bool {
+	status, ok := gitOutput("status", "--porcelain")
+	return ok && len(status) > 0
+}
+
+// GetGitBranch returns the name of the current branch, it is empty outside
+// of a Git repository
 func GetGitBranch() string {
-	getBranchCommand := []string{"git", "rev-parse", "--symbolic-full-name", "--abbrev-ref", "HEAD"}
-	branch := exec.CommandWithOutput(getBranchCommand, true)
-	if strings.Contains(branch, "Not a git repository") {
-		return ""
-	}
-	branch = strings.TrimSuffix(branch, "\n")
+	branch, _ := gitOutput("rev-parse", "--symbolic-full-name", "--abbrev-ref", "HEAD")
 	return branch
 }
+
+// gitOutput runs git with args in the working directory and returns what it
+// printed to stdout, ok is false and the output is empty when git fails, i.e.
+// outside of a Git repository or when git is not installed
+func gitOutput(args ...string) (string, bool) {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(out)), true
+}
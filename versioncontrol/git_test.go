@@ -0,0 +1,52 @@
+package versioncontrol
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_GitHelpers_OutsideRepository(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "faas-cli-no-git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	// Stop git from finding a repository in a parent of the temp dir
+	ceiling, ceilingSet := os.LookupEnv("GIT_CEILING_DIRECTORIES")
+	os.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(dir))
+	defer func() {
+		if ceilingSet {
+			os.Setenv("GIT_CEILING_DIRECTORIES", ceiling)
+		} else {
+			os.Unsetenv("GIT_CEILING_DIRECTORIES")
+		}
+	}()
+
+	values := map[string]string{
+		"GetGitDescribe":   GetGitDescribe(),
+		"GetGitSHA":        GetGitSHA(),
+		"GetGitFullSHA":    GetGitFullSHA(),
+		"GetGitRemoteURL":  GetGitRemoteURL(),
+		"GetGitCommitTime": GetGitCommitTime(),
+		"GetGitBranch":     GetGitBranch(),
+	}
+	for name, value := range values {
+		if len(value) > 0 {
+			t.Errorf("%s: want empty outside of a Git repository, got %q", name, value)
+		}
+	}
+	if GetGitDirty() {
+		t.Errorf("GetGitDirty: want false outside of a Git repository")
+	}
+}